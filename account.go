@@ -352,7 +352,7 @@ func (a *accounts) createNewDatabase(tenantID, email string, active, memoryMode
 		TenantID:      tenantID,
 		Name:          dbName,
 		IsActive:      active,
-		AllowedDomain: []string{"localhost"},
+		AllowedDomain: []string{middleware.DefaultAllowedDomain},
 	}
 
 	bc, err := backend.DB.CreateDatabase(base)
@@ -449,7 +449,7 @@ func (a *accounts) addUser(w http.ResponseWriter, r *http.Request) {
 		mship := backend.Membership(conf)
 		_, newUser, err := mship.CreateUser(auth.AccountID, data.Email, data.Password, 0)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), authErrorStatus(err, http.StatusInternalServerError))
 			return
 		}
 
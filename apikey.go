@@ -0,0 +1,70 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// sudoAPIKeys lists (GET) or creates (POST) the API keys of the database and
+// revokes one (DELETE /sudo/apikeys/{id}). The key is only returned on
+// creation.
+func sudoAPIKeys(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := mship.ListAPIKeys()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, keys)
+	case http.MethodPost:
+		var data backend.NewAPIKey
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		key, k, err := mship.CreateAPIKey(auth, data)
+		if errors.Is(err, backend.ErrInvalidAPIKey) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result := struct {
+			Key    string       `json:"key"`
+			APIKey model.APIKey `json:"apiKey"`
+		}{key, k}
+		respond(w, http.StatusCreated, result)
+	case http.MethodDelete:
+		id := getURLPart(r.URL.Path, 3)
+		if len(id) == 0 {
+			http.Error(w, "missing API key id", http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.RevokeAPIKey(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,200 @@
+package staticbackend
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func keyReq(t *testing.T, hf func(http.ResponseWriter, *http.Request), method, path, key, ip string) *http.Response {
+	return keyReqVia(t, hf, method, path, key, ip, "", `{"title":"from an api key"}`)
+}
+
+// keyReqVia sends the request from the remote IP with an X-Forwarded-For
+// header when forwarded is set
+func keyReqVia(t *testing.T, hf func(http.ResponseWriter, *http.Request), method, path, key, remote, forwarded, body string) *http.Response {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("SB-PUBLIC-KEY", pubKey)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
+	if len(remote) > 0 {
+		req.RemoteAddr = remote + ":1234"
+	}
+	if len(forwarded) > 0 {
+		req.Header.Set("X-Forwarded-For", forwarded)
+	}
+
+	chain := []middleware.Middleware{
+		middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
+		middleware.AllowAPIKey(middleware.CollectionScope(2)),
+		middleware.RequireAuth(backend.DB, backend.Cache),
+	}
+
+	w := httptest.NewRecorder()
+	middleware.Chain(http.HandlerFunc(hf), chain...).ServeHTTP(w, req)
+	return w.Result()
+}
+
+func createAPIKey(t *testing.T, data backend.NewAPIKey) (string, model.APIKey) {
+	resp := dbReq(t, sudoAPIKeys, "POST", "/sudo/apikeys", data, true)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var result struct {
+		Key    string       `json:"key"`
+		APIKey model.APIKey `json:"apiKey"`
+	}
+	if err := parseBody(resp.Body, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result.Key, result.APIKey
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	key, k := createAPIKey(t, backend.NewAPIKey{
+		Name:   "scopes",
+		Scopes: []string{"db:read:*", "db:write:apikeys_tasks"},
+	})
+
+	if !strings.HasPrefix(key, model.APIKeyPrefix) || !strings.HasPrefix(key, k.Prefix) {
+		t.Fatalf("expected key %s to start with %s", key, k.Prefix)
+	}
+
+	resp := keyReq(t, db.dbreq, "POST", "/db/apikeys_tasks", key, "")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201 got %s", GetResponseBody(t, resp))
+	}
+
+	var doc map[string]any
+	if err := parseBody(resp.Body, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := keyReq(t, db.dbreq, "GET", "/db/apikeys_tasks", key, ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 got %s", GetResponseBody(t, resp))
+	}
+
+	if resp := keyReq(t, db.dbreq, "POST", "/db/tasks", key, ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403 without the write scope got %s", GetResponseBody(t, resp))
+	}
+
+	// getting documents by IDs is a read
+	if resp := keyReqVia(t, db.dbreq, "POST", "/db/apikeys_tasks?ids=1", key, "", "", fmt.Sprintf(`["%v"]`, doc["id"])); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 getting by IDs with the read scope got %s", GetResponseBody(t, resp))
+	}
+
+	if resp := keyReqVia(t, db.dbreq, "POST", "/db/tasks?ids=1&bulk=1", key, "", "", `[{"title":"bulk"}]`); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403 for a bulk add without the write scope got %s", GetResponseBody(t, resp))
+	}
+
+	// routes that do not allow API keys reject them
+	if resp := sessionReq(t, mship.me, "GET", "/me", key, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403 on /me got %s", GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, sudoAPIKeys, "GET", "/sudo/apikeys", nil, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var keys []model.APIKey
+	if err := parseBody(resp.Body, &keys); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, x := range keys {
+		if x.ID == k.ID && (x.LastUsed.IsZero() || len(x.LastIP) == 0) {
+			t.Errorf("expected last used to be tracked got %v", x)
+		}
+	}
+
+	bad := backend.NewAPIKey{Name: "bad", Scopes: []string{"db:delete:tasks"}}
+	if resp := dbReq(t, sudoAPIKeys, "POST", "/sudo/apikeys", bad, true); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid scope got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestAPIKeyAllowedIPs(t *testing.T) {
+	key, _ := createAPIKey(t, backend.NewAPIKey{
+		Name:       "ips",
+		Scopes:     []string{"db:read:*"},
+		AllowedIPs: []string{"10.0.0.0/8"},
+	})
+
+	if resp := keyReq(t, db.dbreq, "GET", "/db/tasks", key, ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403 from another IP got %s", GetResponseBody(t, resp))
+	}
+
+	if resp := keyReq(t, db.dbreq, "GET", "/db/tasks", key, "10.1.2.3"); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 from an allowed IP got %s", GetResponseBody(t, resp))
+	}
+
+	// a client cannot spoof an allowed IP
+	if resp := keyReqVia(t, db.dbreq, "GET", "/db/tasks", key, "198.51.100.9", "10.1.2.3", ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403 with a spoofed X-Forwarded-For got %s", GetResponseBody(t, resp))
+	}
+
+	prev := config.Current.TrustedProxies
+	config.Current.TrustedProxies = "192.0.2.10"
+	defer func() {
+		config.Current.TrustedProxies = prev
+	}()
+
+	if resp := keyReqVia(t, db.dbreq, "GET", "/db/tasks", key, "192.0.2.10", "10.1.2.3", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 via a trusted proxy got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestAPIKeyExpiryAndRevocation(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := backend.DB.GetRootForBase(conf.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := "sbk_expired-unit-test-key"
+	k := model.APIKey{
+		AccountID:  root.AccountID,
+		UserID:     root.ID,
+		Name:       "expired",
+		Prefix:     expired[:12],
+		KeyHash:    model.HashAPIKey(expired),
+		Scopes:     []string{"db:read:*"},
+		AllowedIPs: []string{},
+		Expires:    time.Now().Add(-time.Minute),
+		Created:    time.Now(),
+	}
+	if _, err := backend.DB.CreateAPIKey(conf.Name, k); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := keyReq(t, db.dbreq, "GET", "/db/tasks", expired, ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403 for an expired key got %s", GetResponseBody(t, resp))
+	}
+
+	key, revoked := createAPIKey(t, backend.NewAPIKey{Name: "revoked", Scopes: []string{"db:read:*"}})
+	if resp := keyReq(t, db.dbreq, "GET", "/db/tasks", key, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 got %s", GetResponseBody(t, resp))
+	}
+
+	if resp := dbReq(t, sudoAPIKeys, "DELETE", "/sudo/apikeys/"+revoked.ID, nil, true); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if resp := keyReq(t, db.dbreq, "GET", "/db/tasks", key, ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403 for a revoked key got %s", GetResponseBody(t, resp))
+	}
+}
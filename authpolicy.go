@@ -0,0 +1,113 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// authErrorStatus returns the status for sign-in, registration and password
+// errors caused by the database auth policy, fallback is used otherwise
+func authErrorStatus(err error, fallback int) int {
+	var pwErr *backend.PasswordPolicyError
+	switch {
+	case errors.As(err, &pwErr):
+		return http.StatusBadRequest
+	case errors.Is(err, backend.ErrEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, backend.ErrInvalidVerificationCode):
+		return http.StatusUnauthorized
+	}
+	return fallback
+}
+
+// verifyEmail flags a user's email as verified on POST /verify-email with the
+// email and code that were sent. POST /verify-email/resend sends a new code.
+func (m *membership) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	var data = new(struct {
+		Email string `json:"email"`
+		Code  string `json:"code"`
+	})
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	if getURLPart(r.URL.Path, 2) == "resend" {
+		if err := mship.SendVerificationEmail(data.Email); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+		return
+	}
+
+	if err := mship.VerifyEmail(data.Email, data.Code); err != nil {
+		http.Error(w, err.Error(), authErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	respond(w, http.StatusOK, true)
+}
+
+// sudoAuthPolicy returns (GET) or sets (POST) the email verification and
+// password policy of the database
+func sudoAuthPolicy(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		respond(w, http.StatusOK, conf.AuthPolicy)
+		return
+	} else if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var policy model.AuthPolicy
+	if err := parseBody(r.Body, &policy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch policy.EmailVerification {
+	case "", model.EmailVerificationBlock, model.EmailVerificationLimit:
+	default:
+		http.Error(w, "emailVerification must be empty, block or limit", http.StatusBadRequest)
+		return
+	}
+
+	if policy.Password.MinLength < 0 {
+		http.Error(w, "minLength cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := backend.DB.SetAuthPolicy(conf.ID, policy); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// refresh the cached database config used by the WithDB middleware
+	conf.AuthPolicy = policy
+	if err := backend.Cache.SetTyped(conf.ID, conf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, policy)
+}
@@ -0,0 +1,132 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func setAuthPolicy(t *testing.T, policy model.AuthPolicy) {
+	resp := dbReq(t, sudoAuthPolicy, "POST", "/sudo/authpolicy", policy, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+}
+
+func verificationCode(t *testing.T, email string) string {
+	var v struct {
+		Code string `json:"code"`
+	}
+	if err := backend.Cache.GetTyped("verify-email:"+email, &v); err != nil {
+		t.Fatal(err)
+	}
+	return v.Code
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	setAuthPolicy(t, model.AuthPolicy{
+		Password: model.PasswordPolicy{
+			MinLength:     10,
+			RequireDigit:  true,
+			CheckBreached: true,
+		},
+	})
+	defer setAuthPolicy(t, model.AuthPolicy{})
+
+	weak := []string{"password", "short1", "no-digits-in-here"}
+	for _, pw := range weak {
+		login := model.Login{Email: "pwpolicy@test.com", Password: pw}
+		resp := sessionReq(t, mship.register, "POST", "/register", "", login)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400 got %s", pw, GetResponseBody(t, resp))
+		}
+	}
+
+	login := model.Login{Email: "pwpolicy@test.com", Password: "long-enough-pw-1"}
+	resp := sessionReq(t, mship.register, "POST", "/register", "", login)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestEmailVerificationBlock(t *testing.T) {
+	email := "verifyblock@test.com"
+
+	setAuthPolicy(t, model.AuthPolicy{EmailVerification: model.EmailVerificationBlock})
+	defer setAuthPolicy(t, model.AuthPolicy{})
+
+	login := model.Login{Email: email, Password: "verify_block_pw"}
+	resp := sessionReq(t, mship.register, "POST", "/register", "", login)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected status 202 got %s", GetResponseBody(t, resp))
+	}
+
+	resp = mfaLogin(t, login.Email, login.Password)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403 got %s", GetResponseBody(t, resp))
+	}
+
+	wrong := map[string]string{"email": email, "code": "not-the-code"}
+	resp = sessionReq(t, mship.verifyEmail, "POST", "/verify-email", "", wrong)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status 401 got %s", GetResponseBody(t, resp))
+	}
+
+	verify := map[string]string{"email": email, "code": verificationCode(t, email)}
+	resp = sessionReq(t, mship.verifyEmail, "POST", "/verify-email", "", verify)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = mfaLogin(t, login.Email, login.Password)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestEmailVerificationLimit(t *testing.T) {
+	email := "verifylimit@test.com"
+
+	setAuthPolicy(t, model.AuthPolicy{EmailVerification: model.EmailVerificationLimit})
+	defer setAuthPolicy(t, model.AuthPolicy{})
+
+	login := model.Login{Email: email, Password: "verify_limit_pw"}
+	resp := sessionReq(t, mship.register, "POST", "/register", "", login)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var token string
+	if err := parseBody(resp.Body, &token); err != nil {
+		t.Fatal(err)
+	}
+
+	me := func() model.Auth {
+		resp := sessionReq(t, mship.me, "GET", "/me", token, nil)
+		if resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+
+		var auth model.Auth
+		if err := parseBody(resp.Body, &auth); err != nil {
+			t.Fatal(err)
+		}
+		return auth
+	}
+
+	if auth := me(); auth.Verified || auth.Role != 0 {
+		t.Errorf("expected unverified user with role 0 got %v", auth)
+	}
+
+	verify := map[string]string{"email": email, "code": verificationCode(t, email)}
+	resp = sessionReq(t, mship.verifyEmail, "POST", "/verify-email", "", verify)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if auth := me(); !auth.Verified || auth.Role != 50 {
+		t.Errorf("expected verified user with role 50 got %v", auth)
+	}
+}
@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+// apiKeyPrefixLen is how many characters of a key are kept to identify it
+const apiKeyPrefixLen = 12
+
+// ErrInvalidAPIKey is returned when the settings of a new API key are invalid
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// NewAPIKey holds the settings of an API key to create
+type NewAPIKey struct {
+	Name       string    `json:"name"`
+	Scopes     []string  `json:"scopes"`
+	AllowedIPs []string  `json:"allowedIps"`
+	Expires    time.Time `json:"expires"`
+}
+
+// CreateAPIKey creates an API key acting as the root user in auth. The key is
+// only returned here, it cannot be retrieved later.
+func (u User) CreateAPIKey(auth model.Auth, data NewAPIKey) (key string, k model.APIKey, err error) {
+	if err = validateAPIKey(data); err != nil {
+		return
+	}
+
+	secret, err := newRefreshSecret()
+	if err != nil {
+		return
+	}
+
+	key = model.APIKeyPrefix + secret
+
+	k = model.APIKey{
+		AccountID:  auth.AccountID,
+		UserID:     auth.UserID,
+		Name:       strings.TrimSpace(data.Name),
+		Prefix:     key[:apiKeyPrefixLen],
+		KeyHash:    model.HashAPIKey(key),
+		Scopes:     data.Scopes,
+		AllowedIPs: data.AllowedIPs,
+		Expires:    data.Expires,
+		Created:    time.Now(),
+	}
+	if k.AllowedIPs == nil {
+		k.AllowedIPs = []string{}
+	}
+
+	id, err := DB.CreateAPIKey(u.conf.Name, k)
+	if err != nil {
+		return
+	}
+
+	k.ID = id
+	return
+}
+
+// ListAPIKeys returns the API keys of the database
+func (u User) ListAPIKeys() ([]model.APIKey, error) {
+	return DB.ListAPIKeys(u.conf.Name)
+}
+
+// RevokeAPIKey deletes an API key, requests using it are rejected right away
+func (u User) RevokeAPIKey(id string) error {
+	return DB.DeleteAPIKey(u.conf.Name, id)
+}
+
+func validateAPIKey(data NewAPIKey) error {
+	if len(strings.TrimSpace(data.Name)) == 0 {
+		return fmt.Errorf("%w: the API key name is required", ErrInvalidAPIKey)
+	} else if len(data.Scopes) == 0 {
+		return fmt.Errorf("%w: the API key needs at least one scope", ErrInvalidAPIKey)
+	}
+
+	for _, s := range data.Scopes {
+		if !model.ValidScope(s) {
+			return fmt.Errorf("%w: invalid scope: %s", ErrInvalidAPIKey, s)
+		}
+	}
+
+	for _, ip := range data.AllowedIPs {
+		if net.ParseIP(ip) != nil {
+			continue
+		} else if _, _, err := net.ParseCIDR(ip); err != nil {
+			return fmt.Errorf("%w: invalid IP or CIDR: %s", ErrInvalidAPIKey, ip)
+		}
+	}
+
+	if !data.Expires.IsZero() && data.Expires.Before(time.Now()) {
+		return fmt.Errorf("%w: the API key expiry is in the past", ErrInvalidAPIKey)
+	}
+	return nil
+}
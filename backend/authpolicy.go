@@ -0,0 +1,198 @@
+package backend
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/internal"
+)
+
+const (
+	// VerificationCodeTTL is how long an email verification code can be used
+	VerificationCodeTTL = 24 * time.Hour
+
+	verificationMaxAttempts = 5
+)
+
+var (
+	// ErrEmailNotVerified is returned when signing in before verifying the
+	// email and the database policy blocks unverified users
+	ErrEmailNotVerified = errors.New("email address not verified")
+	// ErrInvalidVerificationCode is returned when an email verification code
+	// is invalid, expired or had too many failed attempts
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+)
+
+// PasswordPolicyError is returned when a password does not follow the
+// database password policy
+type PasswordPolicyError struct {
+	Reasons []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the policy: " + strings.Join(e.Reasons, ", ")
+}
+
+type emailVerification struct {
+	Code     string    `json:"code"`
+	Attempts int       `json:"attempts"`
+	Expires  time.Time `json:"expires"`
+}
+
+// ValidatePassword makes sure a new password follows the database password
+// policy, a *PasswordPolicyError lists the rules that are not met
+func (u User) ValidatePassword(password string) error {
+	p := u.conf.AuthPolicy.Password
+
+	var reasons []string
+	if len([]rune(password)) < p.MinLength {
+		reasons = append(reasons, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		reasons = append(reasons, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		reasons = append(reasons, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		reasons = append(reasons, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		reasons = append(reasons, "must contain a symbol")
+	}
+	if p.CheckBreached && internal.IsBreachedPassword(password) {
+		reasons = append(reasons, "is a known breached password")
+	}
+
+	if len(reasons) > 0 {
+		return &PasswordPolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
+// SendVerificationEmail sends a new verification code to a user that did not
+// verify their email yet. Nothing is sent for unknown or verified users.
+func (u User) SendVerificationEmail(address string) error {
+	address = strings.ToLower(address)
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, address)
+	if err != nil || tok.Verified {
+		return nil
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+
+	v := emailVerification{
+		Code:    fmt.Sprintf("%06d", n.Int64()),
+		Expires: time.Now().Add(VerificationCodeTTL),
+	}
+	if err := Cache.SetTyped("verify-email:"+address, v); err != nil {
+		return err
+	}
+
+	policy := u.conf.AuthPolicy
+
+	link := ""
+	if len(policy.VerifyURL) > 0 {
+		sep := "?"
+		if strings.Contains(policy.VerifyURL, "?") {
+			sep = "&"
+		}
+		link = fmt.Sprintf("%s%semail=%s&code=%s", policy.VerifyURL, sep, url.QueryEscape(address), v.Code)
+	}
+
+	mail := email.SendMailData{
+		From:     Config.FromEmail,
+		FromName: Config.FromName,
+		To:       address,
+		Subject:  "Verify your email address",
+		Template: policy.Template,
+		Data: map[string]any{
+			"Email": address,
+			"Code":  v.Code,
+			"Link":  link,
+		},
+	}
+
+	if len(policy.Template) == 0 {
+		body := fmt.Sprintf("<p>Your verification code is <strong>%s</strong>.</p>", v.Code)
+		if len(link) > 0 {
+			body += fmt.Sprintf(`<p>You may also <a href="%s">verify your email</a>.</p>`, link)
+		}
+		mail.HTMLBody = body
+	}
+
+	outbox := email.Outbox{
+		Mailer:  Emailer,
+		DB:      DB,
+		Storage: Filestore,
+	}
+
+	_, err = outbox.Send(u.conf.Name, mail)
+	return err
+}
+
+// VerifyEmail flags the email of a user as verified with the code that was
+// sent to them
+func (u User) VerifyEmail(address, code string) error {
+	address = strings.ToLower(address)
+	key := "verify-email:" + address
+
+	var v emailVerification
+	if err := Cache.GetTyped(key, &v); err != nil {
+		return ErrInvalidVerificationCode
+	} else if time.Now().After(v.Expires) || v.Attempts >= verificationMaxAttempts {
+		return ErrInvalidVerificationCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(v.Code), []byte(strings.TrimSpace(code))) != 1 {
+		v.Attempts++
+		if err := Cache.SetTyped(key, v); err != nil {
+			return err
+		}
+		return ErrInvalidVerificationCode
+	}
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, address)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.SetUserVerified(u.conf.Name, tok.ID, true); err != nil {
+		return err
+	}
+
+	if err := Cache.Del(key); err != nil {
+		return err
+	}
+
+	// active sessions get their full role back
+	tok.Verified = true
+	return u.cacheAuth(tok)
+}
@@ -131,9 +131,11 @@ import (
 	"github.com/staticbackendhq/core/email"
 	"github.com/staticbackendhq/core/function"
 	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/metrics"
 	"github.com/staticbackendhq/core/model"
 	"github.com/staticbackendhq/core/search"
 	"github.com/staticbackendhq/core/storage"
+	"github.com/staticbackendhq/core/tracing"
 	mongodrv "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -167,8 +169,10 @@ var (
 	// storage as well as the database storage.
 	Storage func(model.Auth, model.DatabaseConfig) FileStore
 
-	// Scheduler to execute schedule jobs (only on PrimaryInstance)
+	// Scheduler to execute schedule jobs (only running on the leader)
 	Scheduler *function.TaskScheduler
+	// Elector elects the instance running the Scheduler
+	Elector *function.Elector
 )
 
 // Setup initializes the core services based on the configuration received.
@@ -177,44 +181,42 @@ func Setup(cfg config.AppConfig) {
 
 	Log = logger.Get(cfg)
 
+	cp := cfg.CacheProvider
 	if strings.EqualFold(cfg.DatabaseURL, "mem") || strings.EqualFold(cfg.RedisHost, "mem") {
-		Cache = cache.NewDevCache(Log)
-	} else {
-		Cache = cache.NewCache(Log)
-	}
-
-	persister := config.Current.DataStore
-	if strings.EqualFold(cfg.DatabaseURL, "mem") {
-		DB = memory.New(Cache.PublishDocument)
-	} else if strings.EqualFold(persister, "mongo") {
-		cl, err := openMongoDatabase(cfg.DatabaseURL)
+		Cache = tracing.NewVolatilizer(metrics.NewVolatilizer(cache.NewDevCache(Log), "dev"), "dev")
+	} else if strings.EqualFold(cp, cache.CacheProviderNATS) {
+		nc, err := cache.NewNATSCache(cfg.NATSURL, Log)
 		if err != nil {
-			Log.Fatal().Err(err).Msg("failed to create connection with mongodb")
+			Log.Fatal().Err(err).Msg("failed to create connection with NATS")
 		}
-		DB = mongo.New(cl, Cache.PublishDocument, Log)
-	} else if strings.EqualFold(persister, "sqlite") {
-		cl, err := openSQLite(cfg.DatabaseURL)
-		if err != nil {
-			Log.Fatal().Err(err).Msg("failed to create connection with SQLite")
+		Cache = tracing.NewVolatilizer(metrics.NewVolatilizer(nc, "nats"), "nats")
+	} else if strings.EqualFold(cp, cache.CacheProviderEmbedded) {
+		cacheFile := cfg.CacheFile
+		if len(cacheFile) == 0 {
+			cacheFile = "sb.cache"
 		}
-
-		DB = sqlite.New(cl, Cache.PublishDocument, Log)
-	} else {
-		cl, err := openPGDatabase(cfg.DatabaseURL)
+		bc, err := cache.NewBoltCache(cacheFile, Log)
 		if err != nil {
-			Log.Fatal().Err(err).Msg("failed to create connection with postgres")
+			Log.Fatal().Err(err).Msg("unable to open the embedded cache")
 		}
-
-		DB = postgresql.New(cl, Cache.PublishDocument, Log)
+		Cache = tracing.NewVolatilizer(metrics.NewVolatilizer(bc, "embedded"), "embedded")
+	} else {
+		Cache = tracing.NewVolatilizer(metrics.NewVolatilizer(cache.NewCache(Log), "redis"), "redis")
 	}
 
-	mp := cfg.MailProvider
-	if strings.EqualFold(mp, email.MailProviderSES) {
-		Emailer = email.AWSSES{}
-	} else {
-		Emailer = email.Dev{}
+	db, err := OpenDatabase(config.Current.DataStore, cfg.DatabaseURL)
+	if err != nil {
+		Log.Fatal().Err(err).Msg("failed to create connection with the database")
 	}
 
+	// the tracing wrapper must be the outer one to be bound to a context
+	engine := databaseEngine(config.Current.DataStore, cfg.DatabaseURL)
+	DB = tracing.NewPersister(metrics.NewPersister(db, engine), engine)
+
+	mailer = email.NewReloadable(newMailer(cfg))
+	Emailer = mailer
+	applied = cfg
+
 	sp := cfg.StorageProvider
 	if strings.EqualFold(sp, storage.StorageProviderS3) {
 		Filestore = storage.S3{}
@@ -246,46 +248,104 @@ func Setup(cfg config.AppConfig) {
 			Volatile:  Cache,
 			Search:    Search,
 			Email:     Emailer,
+			Storage:   Filestore,
 			Log:       Log,
 		}
 
 		return exe, nil
 	}
 
-	isPrimary := false
-	if len(cfg.PrimaryInstanceHostname) == 0 {
-		// if no value is provided, like on GH action for tests, we assume primary
-		isPrimary = true
-	} else if hostname, err := os.Hostname(); err != nil {
-		Log.Warn().Err(err).Msg("cannot determine if it's primary instance")
-	} else if strings.EqualFold(hostname, cfg.PrimaryInstanceHostname) {
-		isPrimary = true
+	Elector = function.NewElector(Cache, Log)
+
+	Scheduler = &function.TaskScheduler{
+		Volatile:   Cache,
+		DataStore:  DB,
+		Search:     Search,
+		Email:      Emailer,
+		Storage:    Filestore,
+		Log:        Log,
+		Backup:     BackupTask,
+		InstanceID: Elector.ID,
+	}
+
+	// the leader runs the job scheduler, another instance takes over if it
+	// stops renewing its lease
+	Elector.OnElected = func() {
+		go Scheduler.Start()
+		Log.Info().Msg("job scheduler / runner started on leader instance")
 	}
+	Elector.OnDemoted = Scheduler.Stop
+
+	// task changes are applied by the instance running the scheduler
+	go Scheduler.Listen()
 
-	sub.IsPrimaryInstance = isPrimary
+	sub.IsLeader = Elector.IsLeader
 
 	// start system events subscriber
 	go sub.Start()
 
-	// for primary instance, we start the job scheduler
-	if isPrimary {
-		runner := &function.TaskScheduler{
-			Volatile:  Cache,
-			DataStore: DB,
-			Search:    Search,
-			Email:     Emailer,
-			Log:       Log,
-		}
-
-		Scheduler = runner
-		go runner.Start()
-		Log.Info().Msg("job scheduler / runner started on primary instance")
+	// when set, only the primary instance can be elected
+	if eligibleForLeadership(cfg.PrimaryInstanceHostname) {
+		go Elector.Run()
 	}
 
 	Membership = newUser
 	Storage = newFile
 }
 
+// OpenDatabase opens a connection to a database engine, a "mem" URL uses
+// the memory engine. The Cache and Log must be initialized.
+func OpenDatabase(dataStore, url string) (database.Persister, error) {
+	if strings.EqualFold(url, "mem") {
+		return memory.New(Cache.PublishDocument), nil
+	} else if strings.EqualFold(dataStore, "mongo") {
+		cl, err := openMongoDatabase(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create connection with mongodb: %w", err)
+		}
+		return mongo.New(cl, Cache.PublishDocument, Log), nil
+	} else if strings.EqualFold(dataStore, "sqlite") {
+		cl, err := openSQLite(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create connection with SQLite: %w", err)
+		}
+		return sqlite.New(cl, Cache.PublishDocument, Log), nil
+	}
+
+	cl, err := openPGDatabase(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection with postgres: %w", err)
+	}
+	return postgresql.New(cl, Cache.PublishDocument, Log), nil
+}
+
+// eligibleForLeadership returns true when no primary instance is configured
+// or this instance's hostname matches it
+func eligibleForLeadership(primary string) bool {
+	if len(primary) == 0 {
+		return true
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		Log.Warn().Err(err).Msg("cannot determine if it's primary instance")
+		return false
+	}
+	return strings.EqualFold(hostname, primary)
+}
+
+// databaseEngine returns the engine opened by OpenDatabase
+func databaseEngine(dataStore, url string) string {
+	if strings.EqualFold(url, "mem") {
+		return database.DataStoreMemory
+	} else if strings.EqualFold(dataStore, "mongo") {
+		return database.DataStoreMongoDB
+	} else if strings.EqualFold(dataStore, "sqlite") {
+		return "sqlite"
+	}
+	return database.DataStorePostgreSQL
+}
+
 func openMongoDatabase(dbHost string) (*mongodrv.Client, error) {
 	uri := dbHost
 
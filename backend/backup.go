@@ -0,0 +1,370 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/storage"
+)
+
+// default retention of a backup task without policy
+const (
+	defaultBackupDaily  = 7
+	defaultBackupWeekly = 4
+)
+
+var (
+	// ErrBackupNotFound is returned when the backup ID is not in the index
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrInvalidDatabaseName is returned when restoring to a name that cannot
+	// be used as a schema or table prefix
+	ErrInvalidDatabaseName = errors.New("the database name must start with a letter and contain only lowercase letters, digits and underscores")
+)
+
+// backupMx serializes the updates of the backup indexes
+var backupMx sync.Mutex
+
+var validDatabaseName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// BackupDatabase takes a snapshot of a database in a local directory, or via
+// the storage provider when dir is empty, and records it in the database's
+// backup index. The retention policy removes the older scheduled backups.
+//
+// The index is kept via the storage provider so the backups can be listed
+// and restored even if the database is lost.
+func BackupDatabase(dbName, dir string, policy model.BackupPolicy, manual bool) (b model.Backup, err error) {
+	f, err := os.CreateTemp("", "sb-backup-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	m, err := ExportDatabase(DB, Filestore, dbName, ExportOptions{Blobs: policy.Blobs}, f)
+	if err != nil {
+		return
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	b = model.Backup{
+		ID:       DB.NewID(),
+		Database: dbName,
+		Dir:      dir,
+		Size:     size,
+		Tables:   len(m.Tables),
+		Blobs:    m.Blobs,
+		Manual:   manual,
+		Created:  m.Created,
+	}
+
+	for _, n := range m.Tables {
+		b.Rows += n
+	}
+
+	b.Key = fmt.Sprintf("%s/backups/%s_%s.tar.gz", dbName, b.Created.UTC().Format("20060102T150405Z"), internal.RandStringRunes(8))
+
+	upData := model.UploadFileData{
+		FileKey:  b.Key,
+		File:     f,
+		Size:     size,
+		Mimetype: "application/gzip",
+		// the snapshots contain the users' password hashes and secrets
+		Private: true,
+	}
+	if _, err = backupStore(dir).Save(upData); err != nil {
+		return
+	}
+
+	backupMx.Lock()
+	defer backupMx.Unlock()
+
+	list, err := loadBackupIndex(dbName)
+	if err != nil {
+		// the snapshot would not be listed nor expired
+		if err := backupStore(dir).Delete(b.Key); err != nil {
+			Log.Warn().Err(err).Msgf("cannot delete backup %s", b.Key)
+		}
+		return
+	}
+
+	list = append([]model.Backup{b}, list...)
+
+	if !manual {
+		var expired []model.Backup
+		list, expired = applyRetention(list, policy)
+
+		for _, old := range expired {
+			if err := backupStore(old.Dir).Delete(old.Key); err != nil {
+				Log.Warn().Err(err).Msgf("cannot delete expired backup %s", old.Key)
+			}
+		}
+	}
+
+	err = saveBackupIndex(dbName, list)
+	return
+}
+
+// ListBackups returns the backups of a database, newest first
+func ListBackups(dbName string) ([]model.Backup, error) {
+	backupMx.Lock()
+	defer backupMx.Unlock()
+
+	return loadBackupIndex(dbName)
+}
+
+// DeleteBackup removes a backup snapshot and its index entry
+func DeleteBackup(dbName, id string) error {
+	backupMx.Lock()
+	defer backupMx.Unlock()
+
+	list, err := loadBackupIndex(dbName)
+	if err != nil {
+		return err
+	}
+
+	for i, b := range list {
+		if b.ID != id {
+			continue
+		}
+
+		if err := backupStore(b.Dir).Delete(b.Key); err != nil {
+			Log.Warn().Err(err).Msgf("cannot delete backup %s", b.Key)
+		}
+
+		list = append(list[:i], list[i+1:]...)
+		return saveBackupIndex(dbName, list)
+	}
+	return ErrBackupNotFound
+}
+
+// RestoreBackup loads a backup of dbName in the database named name. When
+// name is empty or dbName, the database is replaced by the snapshot after
+// taking a manual backup of its current state, this backup is restored if
+// the import fails. Otherwise a new database is created with a new public
+// key.
+func RestoreBackup(dbName, id, name string) (conf model.DatabaseConfig, err error) {
+	if len(name) == 0 {
+		name = dbName
+	} else if !validDatabaseName.MatchString(name) {
+		return conf, ErrInvalidDatabaseName
+	}
+
+	list, err := ListBackups(dbName)
+	if err != nil {
+		return
+	}
+
+	var b model.Backup
+	for _, x := range list {
+		if x.ID == id {
+			b = x
+			break
+		}
+	}
+
+	if len(b.ID) == 0 {
+		return conf, ErrBackupNotFound
+	}
+
+	rc, err := backupStore(b.Dir).Get(b.Key)
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+
+	var safety model.Backup
+	opts := ImportOptions{}
+	if name == dbName {
+		exists, err := DB.DatabaseExists(dbName)
+		if err != nil {
+			return conf, err
+		}
+
+		if exists {
+			// the current state can be restored if the snapshot is not
+			// the one expected
+			safety, err = BackupDatabase(dbName, b.Dir, model.BackupPolicy{Blobs: b.Blobs > 0}, true)
+			if err != nil {
+				return conf, fmt.Errorf("error backing up the database before restoring: %w", err)
+			}
+
+			if err := DB.DeleteDatabase(dbName); err != nil {
+				return conf, err
+			}
+		}
+	} else {
+		opts.Name = name
+	}
+
+	if _, err = ImportDatabase(DB, Filestore, rc, opts); err != nil {
+		if len(safety.ID) == 0 {
+			return
+		}
+
+		if rerr := rollbackRestore(dbName, safety); rerr != nil {
+			return conf, fmt.Errorf("error restoring the backup: %v, rolling back to backup %s failed: %w", err, safety.ID, rerr)
+		}
+		return conf, fmt.Errorf("error restoring the backup, the database was rolled back: %w", err)
+	}
+
+	conf, err = DB.FindDatabaseByName(name)
+	if err != nil {
+		return
+	}
+
+	// the database config is cached by its public key
+	if err := Cache.Del(conf.ID); err != nil {
+		Log.Warn().Err(err).Msg("cannot clear the cached database config")
+	}
+	return
+}
+
+// rollbackRestore replaces a partially restored database by the backup taken
+// before the restore
+func rollbackRestore(dbName string, safety model.Backup) error {
+	exists, err := DB.DatabaseExists(dbName)
+	if err != nil {
+		return err
+	} else if exists {
+		if err := DB.DeleteDatabase(dbName); err != nil {
+			return err
+		}
+	}
+
+	rc, err := backupStore(safety.Dir).Get(safety.Key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = ImportDatabase(DB, Filestore, rc, ImportOptions{})
+	return err
+}
+
+// BackupTask runs a backup task, its value is the local directory of the
+// snapshots or "storage" and its meta data the model.BackupPolicy
+func BackupTask(task model.Task) error {
+	var policy model.BackupPolicy
+	if len(task.Meta) > 0 {
+		if err := json.Unmarshal([]byte(task.Meta), &policy); err != nil {
+			return fmt.Errorf("invalid backup policy: %w", err)
+		}
+	}
+
+	dir := task.Value
+	if dir == "storage" {
+		dir = ""
+	}
+
+	_, err := BackupDatabase(task.BaseName, dir, policy, false)
+	return err
+}
+
+// applyRetention keeps the manual backups, the last backup of the policy's
+// number of days and the last backup of its number of weeks. The list must
+// be sorted newest first.
+func applyRetention(list []model.Backup, policy model.BackupPolicy) (kept, expired []model.Backup) {
+	if policy.Daily <= 0 && policy.Weekly <= 0 {
+		policy.Daily = defaultBackupDaily
+		policy.Weekly = defaultBackupWeekly
+	}
+
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+
+	for _, b := range list {
+		if b.Manual {
+			kept = append(kept, b)
+			continue
+		}
+
+		keep := false
+
+		day := b.Created.UTC().Format("2006-01-02")
+		if !days[day] && len(days) < policy.Daily {
+			days[day] = true
+			keep = true
+		}
+
+		year, w := b.Created.UTC().ISOWeek()
+		week := fmt.Sprintf("%d-%d", year, w)
+		if !weeks[week] && len(weeks) < policy.Weekly {
+			weeks[week] = true
+			keep = true
+		}
+
+		if keep {
+			kept = append(kept, b)
+		} else {
+			expired = append(expired, b)
+		}
+	}
+	return
+}
+
+func backupStore(dir string) storage.Storer {
+	if len(dir) == 0 {
+		return Filestore
+	}
+	return storage.Dir{Root: dir}
+}
+
+func backupIndexKey(dbName string) string {
+	return fmt.Sprintf("%s/backups/index.json", dbName)
+}
+
+// loadBackupIndex returns the backups of a database, the index does not exist
+// before the first backup
+func loadBackupIndex(dbName string) (list []model.Backup, err error) {
+	rc, err := Filestore.Get(backupIndexKey(dbName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []model.Backup{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading the backup index: %w", err)
+	}
+	defer rc.Close()
+
+	if err = json.NewDecoder(rc).Decode(&list); err != nil {
+		return
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Created.After(list[j].Created)
+	})
+	return
+}
+
+func saveBackupIndex(dbName string, list []model.Backup) error {
+	b, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+
+	upData := model.UploadFileData{
+		FileKey:  backupIndexKey(dbName),
+		File:     bytes.NewReader(b),
+		Size:     int64(len(b)),
+		Mimetype: "application/json",
+		Private:  true,
+	}
+	_, err = Filestore.Save(upData)
+	return err
+}
@@ -0,0 +1,178 @@
+package backend_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/storage"
+)
+
+func TestBackupRetention(t *testing.T) {
+	dir := t.TempDir()
+	policy := model.BackupPolicy{Daily: 1}
+
+	manual, err := backend.BackupDatabase(base.Name, dir, policy, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last model.Backup
+	for i := 0; i < 3; i++ {
+		last, err = backend.BackupDatabase(base.Name, dir, policy, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := backend.ListBackups(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, b := range list {
+		if b.Dir == dir {
+			ids = append(ids, b.ID)
+		}
+	}
+
+	// only the last scheduled backup of the day and the manual one are kept
+	if len(ids) != 2 || ids[0] != last.ID || ids[1] != manual.ID {
+		t.Fatalf("expected the last scheduled and the manual backups got %v", list)
+	}
+
+	for _, id := range ids {
+		if err := backend.DeleteBackup(base.Name, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := backend.DeleteBackup(base.Name, last.ID); err != backend.ErrBackupNotFound {
+		t.Errorf("expected ErrBackupNotFound got %v", err)
+	}
+}
+
+// recordingStore records the uploads made via the storage provider
+type recordingStore struct {
+	storage.Storer
+	saved []model.UploadFileData
+}
+
+func (rs *recordingStore) Save(data model.UploadFileData) (string, error) {
+	rs.saved = append(rs.saved, data)
+	return rs.Storer.Save(data)
+}
+
+func TestBackupIsPrivate(t *testing.T) {
+	rs := &recordingStore{Storer: backend.Filestore}
+
+	prev := backend.Filestore
+	backend.Filestore = rs
+	defer func() {
+		backend.Filestore = prev
+	}()
+
+	b, err := backend.BackupDatabase(base.Name, "", model.BackupPolicy{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.DeleteBackup(base.Name, b.ID)
+
+	// the snapshot and the index
+	if len(rs.saved) != 2 {
+		t.Fatalf("expected 2 uploads got %d", len(rs.saved))
+	}
+
+	for _, data := range rs.saved {
+		if !data.Private {
+			t.Errorf("expected %s to be private", data.FileKey)
+		}
+	}
+}
+
+// failingStore cannot read files
+type failingStore struct {
+	storage.Storer
+}
+
+func (failingStore) Get(string) (io.ReadCloser, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func TestBackupIndexReadError(t *testing.T) {
+	b, err := backend.BackupDatabase(base.Name, "", model.BackupPolicy{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.DeleteBackup(base.Name, b.ID)
+
+	before, err := backend.ListBackups(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := backend.Filestore
+	backend.Filestore = failingStore{Storer: prev}
+
+	_, err = backend.BackupDatabase(base.Name, "", model.BackupPolicy{}, false)
+	backend.Filestore = prev
+	if err == nil {
+		t.Fatal("expected an error when the index cannot be read")
+	}
+
+	// the index was not overwritten
+	after, err := backend.ListBackups(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(after) != len(before) || after[0].ID != b.ID {
+		t.Errorf("expected the index to be kept got %v", after)
+	}
+}
+
+func TestRestoreBackupRollsBack(t *testing.T) {
+	conf, err := backend.DB.CreateDatabase(model.DatabaseConfig{
+		ID:       backend.DB.NewID(),
+		TenantID: base.TenantID,
+		Name:     "rollbacktest",
+		IsActive: true,
+		Created:  time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.DB.DeleteDatabase(conf.Name)
+
+	doc, err := backend.DB.CreateDocument(adminAuth, conf.Name, "rollbacks", map[string]any{"title": "kept"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	b, err := backend.BackupDatabase(conf.Name, dir, model.BackupPolicy{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a truncated snapshot fails during the import
+	if err := os.Truncate(filepath.Join(dir, b.Key), b.Size/2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := backend.RestoreBackup(conf.Name, b.ID, ""); err == nil {
+		t.Fatal("expected the truncated snapshot to fail")
+	}
+
+	id := doc["id"].(string)
+	check, err := backend.DB.GetDocumentByID(adminAuth, conf.Name, "rollbacks", id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check["title"] != "kept" {
+		t.Errorf("expected the document to be restored got %v", check)
+	}
+}
@@ -0,0 +1,569 @@
+package backend
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/storage"
+)
+
+// DumpFormat is the version of the export archive format
+const DumpFormat = 1
+
+// number of rows inserted at once on import
+const restoreBatchSize = 500
+
+var (
+	// ErrDumpLayout is returned when importing an archive exported from an
+	// engine storing its rows differently than the target engine. Archives
+	// move between SQLite and PostgreSQL, the memory and MongoDB archives are
+	// only imported in the same engine unless exported as portable.
+	ErrDumpLayout = errors.New("this archive cannot be imported in this database engine")
+	// ErrDatabaseExists is returned when importing a database already in the
+	// target engine
+	ErrDatabaseExists = errors.New("a database with this name already exists")
+)
+
+// dumpTableOrder lists the system tables referenced by others, they are
+// imported first
+var dumpTableOrder = []string{"sb_accounts", "sb_tokens", "sb_functions", "sb_groups", "sb_tasks"}
+
+// ExportOptions controls what is included in an export archive
+type ExportOptions struct {
+	// Blobs includes the content of the uploaded files
+	Blobs bool
+	// Portable exports the records and documents in an engine-neutral form
+	// through the Persister, the archive is imported in any engine. The
+	// sessions, invitations, form submissions and logs are not included.
+	Portable bool
+}
+
+// DumpManifest describes an export archive
+type DumpManifest struct {
+	Format   int              `json:"format"`
+	Layout   string           `json:"layout"`
+	Database string           `json:"database"`
+	Created  time.Time        `json:"created"`
+	Tables   map[string]int64 `json:"tables"`
+	Blobs    int              `json:"blobs"`
+}
+
+// dumpTenant holds all the tenant and database fields, including the ones
+// hidden from the API
+type dumpTenant struct {
+	Tenant struct {
+		ID               string    `json:"id"`
+		Email            string    `json:"email"`
+		StripeID         string    `json:"stripeId"`
+		SubscriptionID   string    `json:"subId"`
+		Plan             int       `json:"plan"`
+		IsActive         bool      `json:"isActive"`
+		MonthlyEmailSent int       `json:"monthlyEmailSent"`
+		Created          time.Time `json:"created"`
+		ExternalLogins   []byte    `json:"externalLogins"`
+	} `json:"tenant"`
+	Database struct {
+		ID               string           `json:"id"`
+		Name             string           `json:"name"`
+		AllowedDomain    []string         `json:"allowedDomain"`
+		IsActive         bool             `json:"isActive"`
+		MonthlySentEmail int              `json:"monthlySentEmail"`
+		Created          time.Time        `json:"created"`
+		SMSConfig        []byte           `json:"smsConfig"`
+		MFARole          int              `json:"mfaRole"`
+		AuthPolicy       model.AuthPolicy `json:"authPolicy"`
+	} `json:"database"`
+}
+
+// ImportOptions controls how an export archive is imported
+type ImportOptions struct {
+	// Name imports the database under another name with a new ID (public
+	// key), the exported name and ID are kept when empty
+	Name string
+}
+
+// ExportDatabase writes a gzipped tar archive of a database to w. It holds a
+// manifest.json, the tenant and database in tenant.json, one NDJSON file per
+// system table and collection in tables/ and optionally the uploaded files
+// in blobs/ by their storage key.
+//
+// Encrypted settings like external logins and SMS credentials are exported
+// as is, the target must use the same APP_SECRET.
+func ExportDatabase(src database.Persister, files storage.Storer, dbName string, opts ExportOptions, w io.Writer) (m DumpManifest, err error) {
+	conf, err := src.FindDatabaseByName(dbName)
+	if err != nil {
+		return
+	}
+
+	cus, err := src.FindTenant(conf.TenantID)
+	if err != nil {
+		return
+	}
+
+	if opts.Portable {
+		return exportPortable(src, files, cus, conf, opts, w)
+	}
+
+	tables, err := src.DumpTables(dbName)
+	if err != nil {
+		return
+	}
+	sortDumpTables(tables)
+
+	m = DumpManifest{
+		Format:   DumpFormat,
+		Layout:   src.DumpLayout(),
+		Database: conf.Name,
+		Created:  time.Now(),
+		Tables:   make(map[string]int64),
+	}
+
+	// tar entries need their size upfront, the tables are spooled to disk
+	dir, err := os.MkdirTemp("", "sb-export-*")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	if err = spoolTables(src, dbName, tables, dir, m.Tables); err != nil {
+		return
+	}
+
+	var keys []string
+	if opts.Blobs {
+		keys, err = blobKeys(src, dbName)
+		if err != nil {
+			return
+		}
+		m.Blobs = len(keys)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err = writeTarJSON(tw, "manifest.json", m); err != nil {
+		return
+	}
+
+	if err = writeTarJSON(tw, "tenant.json", toDumpTenant(cus, conf)); err != nil {
+		return
+	}
+
+	for _, table := range tables {
+		if err = writeTarFile(tw, "tables/"+table+".ndjson", filepath.Join(dir, table+".ndjson")); err != nil {
+			return
+		}
+	}
+
+	for _, key := range keys {
+		if err = writeTarBlob(tw, files, dir, key); err != nil {
+			return m, fmt.Errorf("error exporting file %s: %w", key, err)
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return
+	}
+	err = gz.Close()
+	return
+}
+
+// ImportDatabase loads an archive created by ExportDatabase in dst keeping
+// the IDs, owners and timestamps. The archive layout must match dst, see
+// ErrDumpLayout, portable archives are imported in any engine with new IDs
+// and a new public key. The tenant is created if it does not exist and the
+// database must not exist. The uploaded files are saved with their
+// original storage key, the file URLs are kept as exported.
+func ImportDatabase(dst database.Persister, files storage.Storer, r io.Reader, opts ImportOptions) (m DumpManifest, err error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	var dt dumpTenant
+	var pi *portableImport
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return m, err
+		}
+
+		switch name := hdr.Name; {
+		case name == "manifest.json":
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return m, err
+			}
+
+			if len(opts.Name) > 0 {
+				m.Database = opts.Name
+			}
+
+			if m.Format != DumpFormat {
+				return m, fmt.Errorf("unsupported archive format %d", m.Format)
+			} else if m.Layout == database.DumpLayoutPortable {
+				pi = &portableImport{dst: dst, dbName: m.Database, ids: make(map[string]string)}
+			} else if err := CheckDumpLayout(m.Layout, dst.DumpLayout()); err != nil {
+				return m, err
+			}
+
+			exists, err := dst.DatabaseExists(m.Database)
+			if err != nil {
+				return m, err
+			} else if exists {
+				return m, ErrDatabaseExists
+			}
+		case name == "tenant.json":
+			if len(m.Database) == 0 {
+				return m, errors.New("the manifest must be the first entry of the archive")
+			}
+
+			if err := json.NewDecoder(tr).Decode(&dt); err != nil {
+				return m, err
+			}
+
+			cus, conf := dt.toModel()
+			if pi != nil {
+				if cus, conf, err = pi.tenant(cus, conf); err != nil {
+					return m, err
+				}
+			} else if len(opts.Name) > 0 {
+				conf.ID = dst.NewID()
+				conf.Name = opts.Name
+			}
+
+			if err := dst.RestoreTenant(cus, conf); err != nil {
+				return m, err
+			}
+		case strings.HasPrefix(name, "tables/"):
+			if len(dt.Database.ID) == 0 {
+				return m, errors.New("the tenant must be imported before the tables")
+			}
+
+			table := strings.TrimSuffix(strings.TrimPrefix(name, "tables/"), ".ndjson")
+			if err := restoreTable(dst, m.Database, table, tr); err != nil {
+				return m, fmt.Errorf("error importing %s: %w", table, err)
+			}
+		case pi != nil && (name == "ids.ndjson" || strings.HasPrefix(name, "records/") || strings.HasPrefix(name, "collections/")):
+			if len(dt.Database.ID) == 0 {
+				return m, errors.New("the tenant must be imported before the records")
+			}
+
+			if err := pi.restore(name, tr); err != nil {
+				return m, fmt.Errorf("error importing %s: %w", name, err)
+			}
+		case strings.HasPrefix(name, "blobs/"):
+			key := strings.TrimPrefix(name, "blobs/")
+			if err := restoreBlob(files, key, tr); err != nil {
+				return m, fmt.Errorf("error importing file %s: %w", key, err)
+			}
+		}
+	}
+
+	if len(dt.Database.ID) == 0 {
+		err = errors.New("the archive does not contain a database")
+	}
+	return
+}
+
+// CheckDumpLayout returns ErrDumpLayout when rows dumped in the from layout
+// cannot be restored in an engine using the to layout
+func CheckDumpLayout(from, to string) error {
+	if from != to {
+		return fmt.Errorf("%w: the archive uses the %s layout and the database the %s layout", ErrDumpLayout, from, to)
+	}
+	return nil
+}
+
+// sortDumpTables orders the referenced system tables first, then the other
+// system tables and the collections
+func sortDumpTables(tables []string) {
+	rank := func(name string) int {
+		for i, t := range dumpTableOrder {
+			if t == name {
+				return i
+			}
+		}
+
+		if strings.HasPrefix(name, "sb_") {
+			return len(dumpTableOrder)
+		}
+		return len(dumpTableOrder) + 1
+	}
+
+	sort.SliceStable(tables, func(i, j int) bool {
+		ri, rj := rank(tables[i]), rank(tables[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return tables[i] < tables[j]
+	})
+}
+
+// spoolTables writes the rows of each table to {dir}/{table}.ndjson from a
+// single snapshot and counts them
+func spoolTables(src database.Persister, dbName string, tables []string, dir string, counts map[string]int64) error {
+	files := make(map[string]*os.File)
+	writers := make(map[string]*bufio.Writer)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	// empty tables get an empty file
+	for _, table := range tables {
+		f, err := os.Create(filepath.Join(dir, table+".ndjson"))
+		if err != nil {
+			return err
+		}
+
+		files[table] = f
+		writers[table] = bufio.NewWriter(f)
+		counts[table] = 0
+	}
+
+	err := src.DumpRows(dbName, tables, func(table string, row map[string]any) error {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("error exporting %s: %w", table, err)
+		}
+
+		w := writers[table]
+		if _, err := w.Write(append(b, '\n')); err != nil {
+			return err
+		}
+
+		counts[table]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, w := range writers {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blobKeys returns the distinct storage keys of the uploaded files, files
+// with the same content share a key
+func blobKeys(src database.Persister, dbName string) (keys []string, err error) {
+	list, err := src.ListAllFiles(dbName, "")
+	if err != nil {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range list {
+		if seen[f.Key] {
+			continue
+		}
+
+		seen[f.Key] = true
+		keys = append(keys, f.Key)
+	}
+
+	sort.Strings(keys)
+	return
+}
+
+func writeTarJSON(tw *tar.Writer, name string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(b)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = tw.Write(b)
+	return err
+}
+
+func writeTarFile(tw *tar.Writer, name, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// writeTarBlob copies a stored file to a temporary file to get its size
+// and adds it to the archive
+func writeTarBlob(tw *tar.Writer, files storage.Storer, dir, key string) error {
+	rc, err := files.Get(key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	filename := filepath.Join(dir, "blob")
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, rc)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(filename)
+	return writeTarFile(tw, "blobs/"+key, filename)
+}
+
+func restoreTable(dst database.Persister, dbName, table string, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var rows []map[string]any
+	for {
+		var row map[string]any
+		if err := dec.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		rows = append(rows, row)
+		if len(rows) == restoreBatchSize {
+			if err := dst.RestoreRows(dbName, table, rows); err != nil {
+				return err
+			}
+			rows = nil
+		}
+	}
+
+	// collections are created even when empty
+	if len(rows) == 0 && strings.HasPrefix(table, "sb_") {
+		return nil
+	}
+	return dst.RestoreRows(dbName, table, rows)
+}
+
+// restoreBlob copies the content to a temporary file since the storage
+// providers need to seek
+func restoreBlob(files storage.Storer, key string, r io.Reader) error {
+	f, err := os.CreateTemp("", "sb-import-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	upData := model.UploadFileData{
+		FileKey:  key,
+		File:     f,
+		Size:     size,
+		Mimetype: mime.TypeByExtension(filepath.Ext(key)),
+	}
+	_, err = files.Save(upData)
+	return err
+}
+
+func toDumpTenant(cus model.Tenant, conf model.DatabaseConfig) (dt dumpTenant) {
+	dt.Tenant.ID = cus.ID
+	dt.Tenant.Email = cus.Email
+	dt.Tenant.StripeID = cus.StripeID
+	dt.Tenant.SubscriptionID = cus.SubscriptionID
+	dt.Tenant.Plan = cus.Plan
+	dt.Tenant.IsActive = cus.IsActive
+	dt.Tenant.MonthlyEmailSent = cus.MonthlyEmailSent
+	dt.Tenant.Created = cus.Created
+	dt.Tenant.ExternalLogins = cus.ExternalLogins
+
+	dt.Database.ID = conf.ID
+	dt.Database.Name = conf.Name
+	dt.Database.AllowedDomain = conf.AllowedDomain
+	dt.Database.IsActive = conf.IsActive
+	dt.Database.MonthlySentEmail = conf.MonthlySentEmail
+	dt.Database.Created = conf.Created
+	dt.Database.SMSConfig = conf.SMSConfig
+	dt.Database.MFARole = conf.MFARole
+	dt.Database.AuthPolicy = conf.AuthPolicy
+	return
+}
+
+func (dt dumpTenant) toModel() (model.Tenant, model.DatabaseConfig) {
+	cus := model.Tenant{
+		ID:               dt.Tenant.ID,
+		Email:            dt.Tenant.Email,
+		StripeID:         dt.Tenant.StripeID,
+		SubscriptionID:   dt.Tenant.SubscriptionID,
+		Plan:             dt.Tenant.Plan,
+		IsActive:         dt.Tenant.IsActive,
+		MonthlyEmailSent: dt.Tenant.MonthlyEmailSent,
+		Created:          dt.Tenant.Created,
+		ExternalLogins:   dt.Tenant.ExternalLogins,
+	}
+
+	conf := model.DatabaseConfig{
+		ID:               dt.Database.ID,
+		TenantID:         dt.Tenant.ID,
+		Name:             dt.Database.Name,
+		AllowedDomain:    dt.Database.AllowedDomain,
+		IsActive:         dt.Database.IsActive,
+		MonthlySentEmail: dt.Database.MonthlySentEmail,
+		Created:          dt.Database.Created,
+		SMSConfig:        dt.Database.SMSConfig,
+		MFARole:          dt.Database.MFARole,
+		AuthPolicy:       dt.Database.AuthPolicy,
+	}
+	return cus, conf
+}
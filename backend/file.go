@@ -1,12 +1,15 @@
 package backend
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"path/filepath"
 	"time"
 
+	"github.com/staticbackendhq/core/extra"
 	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/model"
 )
@@ -24,49 +27,71 @@ func newFile(auth model.Auth, conf model.DatabaseConfig) FileStore {
 	}
 }
 
-// SavedFile when a file is saved it has an ID and an URL
+// SavedFile when a file is saved it has an ID, an URL and the SHA-256
+// checksum of its content (hex encoded) for client verification
 type SavedFile struct {
-	ID  string `json:"id"`
-	URL string `json:"url"`
+	ID       string `json:"id"`
+	URL      string `json:"url"`
+	Checksum string `json:"checksum"`
 }
 
 // Save saves a file content to the file storage (Storer interface) and to the
-// database
+// database, the checksum is computed while the content is stored
+//
+// When the DedupFiles config is enabled, a file with the same content as an
+// existing file in this database reuses the stored blob, the content just
+// stored is removed.
 func (f FileStore) Save(filename, name string, file io.ReadSeeker, size int64) (sf SavedFile, err error) {
 	ext := filepath.Ext(filename)
 
-	if len(name) == 0 {
-		// if no forced name is used, let's use the original file name
-		name = internal.CleanUpFileName(filename)
-	}
+	hr := internal.NewHashingReader(file)
 
-	// add random char to prevent duplicate key
-	name += "_" + internal.RandStringRunes(16)
-
-	fileKey := fmt.Sprintf("%s/%s/%s%s",
-		f.conf.Name,
-		f.auth.AccountID,
-		name,
-		ext,
-	)
+	sbFile := model.File{
+		AccountID: f.auth.AccountID,
+		UserID:    f.auth.UserID,
+		Key:       f.fileKey(filename, name, ext),
+		Size:      size,
+		Uploaded:  time.Now(),
+	}
 
 	upData := model.UploadFileData{
-		FileKey:  fileKey,
-		File:     file,
+		FileKey:  sbFile.Key,
+		File:     hr,
 		Size:     size,
-		Mimetype: mime.TypeByExtension("." + ext),
+		Mimetype: mime.TypeByExtension(ext),
 	}
-	url, err := Filestore.Save(upData)
+	sbFile.URL, err = Filestore.Save(upData)
 	if err != nil {
 		return
 	}
 
-	sbFile := model.File{
-		AccountID: f.auth.AccountID,
-		Key:       fileKey,
-		URL:       url,
-		Size:      size,
-		Uploaded:  time.Now(),
+	sbFile.Checksum, err = hr.Checksum()
+	if err != nil {
+		return
+	}
+
+	if Config.DedupFiles {
+		var unlock func()
+		unlock, err = f.lockContent(sbFile.Checksum)
+		if err != nil {
+			return
+		}
+		defer unlock()
+
+		var existing model.File
+		existing, err = DB.GetFileByChecksum(f.conf.Name, sbFile.Checksum)
+		if err != nil {
+			return
+		}
+
+		if len(existing.ID) > 0 {
+			if err = Filestore.Delete(sbFile.Key); err != nil {
+				return
+			}
+
+			sbFile.Key = existing.Key
+			sbFile.URL = existing.URL
+		}
 	}
 
 	newID, err := DB.AddFile(f.conf.Name, sbFile)
@@ -75,26 +100,112 @@ func (f FileStore) Save(filename, name string, file io.ReadSeeker, size int64) (
 	}
 
 	sf.ID = newID
-	sf.URL = url
+	sf.URL = sbFile.URL
+	sf.Checksum = sbFile.Checksum
 
 	return
 }
 
-// Delete removes a file from storage and database
+// GeneratePDF renders the HTML template with data to a PDF document and
+// saves it as {name}.pdf. See extra.RenderPDF for the supported HTML.
+func (f FileStore) GeneratePDF(name, tmpl string, data any) (sf SavedFile, err error) {
+	var buf bytes.Buffer
+	if err = extra.RenderPDF(tmpl, data, &buf); err != nil {
+		return
+	}
+
+	if len(name) == 0 {
+		name = "document"
+	}
+
+	return f.Save(name+".pdf", name, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
+
+const (
+	// contentLockTTL bounds how long a crashed instance holds a content lock
+	contentLockTTL = 30 * time.Second
+	// contentLockWait is how long a save or delete waits for a content lock
+	contentLockWait = 10 * time.Second
+)
+
+// ErrContentLocked is returned when the lock of a file content could not be
+// acquired in time
+var ErrContentLocked = errors.New("the file content is locked by another operation")
+
+// lockContent serializes the saves and deletes of the files sharing the same
+// content, so a blob is never removed while a new file references it
+func (f FileStore) lockContent(content string) (func(), error) {
+	key := fmt.Sprintf("sb:file:%s:%s:lock", f.conf.Name, content)
+	owner := internal.RandStringRunes(16)
+
+	for deadline := time.Now().Add(contentLockWait); ; time.Sleep(25 * time.Millisecond) {
+		ok, err := Cache.AcquireLock(key, owner, contentLockTTL)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			break
+		} else if time.Now().After(deadline) {
+			return nil, ErrContentLocked
+		}
+	}
+
+	unlock := func() {
+		if err := Cache.ReleaseLock(key, owner); err != nil {
+			Log.Warn().Err(err).Msgf("error releasing the file content lock %s", key)
+		}
+	}
+	return unlock, nil
+}
+
+func (f FileStore) fileKey(filename, name, ext string) string {
+	if len(name) == 0 {
+		// if no forced name is used, let's use the original file name
+		name = internal.CleanUpFileName(filename)
+	}
+
+	// add random char to prevent duplicate key
+	name += "_" + internal.RandStringRunes(16)
+
+	return fmt.Sprintf("%s/%s/%s%s",
+		f.conf.Name,
+		f.auth.AccountID,
+		name,
+		ext,
+	)
+}
+
+// Delete removes a file from the database and from storage once no other
+// file references the same stored content. The reference removal and the
+// blob removal hold the content's lock so a concurrent Save cannot reference
+// a blob being removed.
 func (f FileStore) Delete(fileID string) error {
 	file, err := DB.GetFileByID(f.conf.Name, fileID)
 	if err != nil {
 		return err
 	}
 
-	fileKey := file.Key
+	// files uploaded before the checksums have their own blob
+	content := file.Checksum
+	if len(content) == 0 {
+		content = file.Key
+	}
 
-	if err := Filestore.Delete(fileKey); err != nil {
+	unlock, err := f.lockContent(content)
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
 	if err := DB.DeleteFile(f.conf.Name, file.ID); err != nil {
 		return err
 	}
-	return nil
+
+	refs, err := DB.CountFileReferences(f.conf.Name, file.Key)
+	if err != nil {
+		return err
+	} else if refs > 0 {
+		return nil
+	}
+
+	return Filestore.Delete(file.Key)
 }
@@ -0,0 +1,226 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+// ErrInvalidGroup is returned when a group or role is invalid
+var ErrInvalidGroup = errors.New("invalid group or role")
+
+// GroupData holds the name and roles of a group to create or update
+type GroupData struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+// ListRoles returns the roles of the database
+func (u User) ListRoles() ([]model.Role, error) {
+	return DB.ListRoles(u.conf.Name)
+}
+
+// SaveRole creates or updates a role, members of groups granted the role get
+// its new permissions right away
+func (u User) SaveRole(role model.Role) error {
+	role.Name = strings.TrimSpace(role.Name)
+	if len(role.Name) == 0 {
+		return fmt.Errorf("%w: a role needs a name", ErrInvalidGroup)
+	}
+
+	role.Updated = time.Now()
+	if err := DB.SaveRole(u.conf.Name, role); err != nil {
+		return err
+	}
+	return u.refreshRoleMembers(role.Name)
+}
+
+// DeleteRole removes a role, groups granted the role lose its permissions
+func (u User) DeleteRole(name string) error {
+	if err := DB.DeleteRole(u.conf.Name, name); err != nil {
+		return err
+	}
+	return u.refreshRoleMembers(name)
+}
+
+// ListGroups returns the groups of an account with their members
+func (u User) ListGroups(accountID string) ([]model.Group, error) {
+	return DB.ListGroups(u.conf.Name, accountID)
+}
+
+// CreateGroup creates a group in an account
+func (u User) CreateGroup(accountID string, data GroupData) (g model.Group, err error) {
+	if err = u.validateGroup(data); err != nil {
+		return
+	}
+
+	g = model.Group{
+		AccountID: accountID,
+		Name:      strings.TrimSpace(data.Name),
+		Roles:     data.Roles,
+		Created:   time.Now(),
+	}
+	if g.Roles == nil {
+		g.Roles = []string{}
+	}
+
+	g.ID, err = DB.CreateGroup(u.conf.Name, g)
+	return
+}
+
+// UpdateGroup renames a group and changes its roles
+func (u User) UpdateGroup(accountID, id string, data GroupData) (g model.Group, err error) {
+	if err = u.validateGroup(data); err != nil {
+		return
+	}
+
+	g, err = DB.GetGroup(u.conf.Name, accountID, id)
+	if err != nil {
+		return
+	}
+
+	g.Name = strings.TrimSpace(data.Name)
+	g.Roles = data.Roles
+	if g.Roles == nil {
+		g.Roles = []string{}
+	}
+
+	if err = DB.UpdateGroup(u.conf.Name, g); err != nil {
+		return
+	}
+
+	err = u.refreshGroupAccess(accountID, g.Members...)
+	return
+}
+
+// DeleteGroup removes a group, its members lose its permissions
+func (u User) DeleteGroup(accountID, id string) error {
+	g, err := DB.GetGroup(u.conf.Name, accountID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.DeleteGroup(u.conf.Name, accountID, id); err != nil {
+		return err
+	}
+	return u.refreshGroupAccess(accountID, g.Members...)
+}
+
+// AddGroupMember adds a user of the account to a group
+func (u User) AddGroupMember(accountID, groupID, userID string) error {
+	if _, err := DB.GetGroup(u.conf.Name, accountID, groupID); err != nil {
+		return err
+	}
+
+	// the user must be part of the group's account
+	if _, err := DB.GetUserByID(u.conf.Name, accountID, userID); err != nil {
+		return err
+	}
+
+	if err := DB.AddGroupMember(u.conf.Name, groupID, userID); err != nil {
+		return err
+	}
+	return u.refreshGroupAccess(accountID, userID)
+}
+
+// RemoveGroupMember removes a user from a group
+func (u User) RemoveGroupMember(accountID, groupID, userID string) error {
+	if _, err := DB.GetGroup(u.conf.Name, accountID, groupID); err != nil {
+		return err
+	}
+
+	if err := DB.RemoveGroupMember(u.conf.Name, groupID, userID); err != nil {
+		return err
+	}
+	return u.refreshGroupAccess(accountID, userID)
+}
+
+func (u User) validateGroup(data GroupData) error {
+	if len(strings.TrimSpace(data.Name)) == 0 {
+		return fmt.Errorf("%w: a group needs a name", ErrInvalidGroup)
+	}
+
+	roles, err := DB.ListRoles(u.conf.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range data.Roles {
+		found := false
+		for _, r := range roles {
+			if r.Name == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("%w: unknown role %s", ErrInvalidGroup, name)
+		}
+	}
+	return nil
+}
+
+// groupAccess returns the groups of a user and the permissions their roles
+// grant
+func (u User) groupAccess(userID string) (groups []string, permissions []string, err error) {
+	list, err := DB.ListUserGroups(u.conf.Name, userID)
+	if err != nil || len(list) == 0 {
+		return
+	}
+
+	roles, err := DB.ListRoles(u.conf.Name)
+	if err != nil {
+		return
+	}
+
+	groups, permissions = model.GroupAccess(list, roles)
+	return
+}
+
+// refreshGroupAccess updates the cached sessions of users whose groups
+// changed
+func (u User) refreshGroupAccess(accountID string, userIDs ...string) error {
+	for _, userID := range userIDs {
+		tok, err := DB.GetUserByID(u.conf.Name, accountID, userID)
+		if err != nil {
+			// the user might have been removed
+			continue
+		}
+
+		if err := u.cacheAuth(tok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u User) refreshRoleMembers(name string) error {
+	accounts, err := DB.ListAccounts(u.conf.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, acct := range accounts {
+		groups, err := DB.ListGroups(u.conf.Name, acct.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, g := range groups {
+			for _, role := range g.Roles {
+				if role != name {
+					continue
+				}
+
+				if err := u.refreshGroupAccess(acct.ID, g.Members...); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
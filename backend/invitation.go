@@ -0,0 +1,257 @@
+package backend
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/model"
+)
+
+// InvitationTTL is how long an invitation link can be accepted
+var InvitationTTL = 7 * 24 * time.Hour
+
+// invitationAudience tells invitation tokens apart from session tokens
+const invitationAudience = "sb-invitation"
+
+// ErrInvalidInvitation is returned when an invitation link is invalid, expired
+// or was revoked
+var ErrInvalidInvitation = errors.New("invalid or expired invitation")
+
+// InviteData holds who to invite, with which role and the application page
+// accepting invitations. The invitation token is added to the link as the
+// token query string parameter.
+type InviteData struct {
+	Email string `json:"email"`
+	Role  int    `json:"role"`
+	Link  string `json:"link"`
+}
+
+// Invite creates an invitation to join the account of auth and emails its
+// link, the invitee cannot get a higher role than the user inviting them
+func (u User) Invite(auth model.Auth, data InviteData) (inv model.Invitation, err error) {
+	data.Email = strings.ToLower(strings.TrimSpace(data.Email))
+	if !strings.Contains(data.Email, "@") || !strings.Contains(data.Email, ".") {
+		err = errors.New("invalid email")
+		return
+	} else if len(data.Link) == 0 {
+		err = errors.New("the invitation link is required")
+		return
+	} else if data.Role < 0 || data.Role > auth.Role {
+		err = errors.New("cannot invite a user with a higher role than yours")
+		return
+	}
+
+	exists, err := DB.UserEmailExists(u.conf.Name, data.Email)
+	if err != nil {
+		return
+	} else if exists {
+		err = errors.New("email already in use")
+		return
+	}
+
+	pending, err := DB.ListInvitations(u.conf.Name, auth.AccountID)
+	if err != nil {
+		return
+	}
+
+	for _, p := range pending {
+		if p.Email == data.Email {
+			err = errors.New("this email has already been invited, resend the invitation instead")
+			return
+		}
+	}
+
+	nonce, err := newRefreshSecret()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	inv = model.Invitation{
+		AccountID: auth.AccountID,
+		InvitedBy: auth.UserID,
+		Email:     data.Email,
+		Role:      data.Role,
+		Link:      data.Link,
+		Nonce:     nonce,
+		Expires:   now.Add(InvitationTTL),
+		Created:   now,
+	}
+
+	inv.ID, err = DB.CreateInvitation(u.conf.Name, inv)
+	if err != nil {
+		return
+	}
+
+	err = u.sendInvitation(inv)
+	return
+}
+
+// ListInvitations returns the pending invitations of an account
+func (u User) ListInvitations(accountID string) ([]model.Invitation, error) {
+	return DB.ListInvitations(u.conf.Name, accountID)
+}
+
+// ResendInvitation emails a new link for an invitation and extends its
+// expiry, the previous links stop working
+func (u User) ResendInvitation(accountID, id string) error {
+	inv, err := u.accountInvitation(accountID, id)
+	if err != nil {
+		return err
+	}
+
+	inv.Nonce, err = newRefreshSecret()
+	if err != nil {
+		return err
+	}
+
+	inv.Expires = time.Now().Add(InvitationTTL)
+	if err := DB.RenewInvitation(u.conf.Name, inv.ID, inv.Nonce, inv.Expires); err != nil {
+		return err
+	}
+	return u.sendInvitation(inv)
+}
+
+// RevokeInvitation removes a pending invitation
+func (u User) RevokeInvitation(accountID, id string) error {
+	if _, err := u.accountInvitation(accountID, id); err != nil {
+		return err
+	}
+	return DB.DeleteInvitation(u.conf.Name, id)
+}
+
+// GetInvitation returns the pending invitation of an invitation token
+func (u User) GetInvitation(token string) (model.Invitation, error) {
+	var pl jwt.Payload
+
+	validator := jwt.ValidatePayload(
+		&pl,
+		jwt.ExpirationTimeValidator(time.Now()),
+		jwt.AudienceValidator(jwt.Audience{invitationAudience + ":" + u.conf.Name}),
+	)
+	if _, err := jwt.Verify([]byte(token), model.HashSecret, &pl, validator); err != nil {
+		return model.Invitation{}, ErrInvalidInvitation
+	}
+
+	inv, err := DB.GetInvitation(u.conf.Name, pl.Subject)
+	if err != nil {
+		return model.Invitation{}, ErrInvalidInvitation
+	}
+
+	// a resent invitation changes its nonce
+	if subtle.ConstantTimeCompare([]byte(inv.Nonce), []byte(pl.JWTID)) != 1 || inv.Expired(time.Now()) {
+		return model.Invitation{}, ErrInvalidInvitation
+	}
+	return inv, nil
+}
+
+// AcceptInvitation creates the invited user in the inviting account with the
+// password they chose. The email is considered verified since the invitee
+// received the link.
+func (u User) AcceptInvitation(token, password string) (model.User, error) {
+	if err := u.ValidatePassword(password); err != nil {
+		return model.User{}, err
+	}
+
+	inv, err := u.GetInvitation(token)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	exists, err := DB.UserEmailExists(u.conf.Name, inv.Email)
+	if err != nil {
+		return model.User{}, err
+	} else if exists {
+		return model.User{}, errors.New("email already in use")
+	}
+
+	_, tok, err := u.createUser(inv.AccountID, inv.Email, password, inv.Role, true)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	// an invitation can only be used once
+	if err := DB.DeleteInvitation(u.conf.Name, inv.ID); err != nil {
+		return model.User{}, err
+	}
+	return tok, nil
+}
+
+// AcceptInvitationAndSignIn accepts an invitation and starts a session for
+// the new user
+func (u User) AcceptInvitationAndSignIn(token, password, userAgent, ip string) (model.AuthTokens, error) {
+	tok, err := u.AcceptInvitation(token, password)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	return u.signIn(tok, userAgent, ip)
+}
+
+func (u User) accountInvitation(accountID, id string) (model.Invitation, error) {
+	inv, err := DB.GetInvitation(u.conf.Name, id)
+	if err != nil {
+		return inv, err
+	} else if inv.AccountID != accountID {
+		return inv, errors.New("invitation not found")
+	}
+	return inv, nil
+}
+
+func (u User) invitationToken(inv model.Invitation) ([]byte, error) {
+	now := time.Now()
+	pl := jwt.Payload{
+		Issuer:         "StaticBackend",
+		Subject:        inv.ID,
+		Audience:       jwt.Audience{invitationAudience + ":" + u.conf.Name},
+		ExpirationTime: jwt.NumericDate(inv.Expires),
+		IssuedAt:       jwt.NumericDate(now),
+		JWTID:          inv.Nonce,
+	}
+
+	return jwt.Sign(pl, model.HashSecret)
+}
+
+func (u User) sendInvitation(inv model.Invitation) error {
+	token, err := u.invitationToken(inv)
+	if err != nil {
+		return err
+	}
+
+	sep := "?"
+	if strings.Contains(inv.Link, "?") {
+		sep = "&"
+	}
+	link := fmt.Sprintf("%s%stoken=%s", inv.Link, sep, token)
+
+	body := fmt.Sprintf(
+		"<p>You have been invited to join an account.</p>"+
+			`<p><a href="%s">Accept the invitation</a></p>`+
+			"<p>This invitation expires on %s.</p>",
+		html.EscapeString(link),
+		inv.Expires.UTC().Format(time.RFC1123),
+	)
+
+	mail := email.SendMailData{
+		From:     Config.FromEmail,
+		FromName: Config.FromName,
+		To:       inv.Email,
+		Subject:  "You have been invited",
+		HTMLBody: body,
+		TextBody: email.StripHTML(body),
+	}
+
+	outbox := email.Outbox{
+		Mailer:  Emailer,
+		DB:      DB,
+		Storage: Filestore,
+	}
+
+	_, err = outbox.Send(u.conf.Name, mail)
+	return err
+}
@@ -0,0 +1,171 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/email"
+)
+
+var (
+	// LoginAttemptPolicy applies to failed sign-in, password reset and magic
+	// link attempts for an email
+	LoginAttemptPolicy = cache.DefaultAttemptPolicy
+	// IPAttemptPolicy applies to the same attempts for a client IP, it's more
+	// permissive since users might share an IP
+	IPAttemptPolicy = cache.AttemptPolicy{
+		FreeAttempts:    15,
+		MaxAttempts:     50,
+		Window:          15 * time.Minute,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		LockoutDuration: 15 * time.Minute,
+		MaxLockout:      12 * time.Hour,
+	}
+)
+
+// TooManyAttemptsError is returned when an email or IP must wait before
+// trying to sign in again
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed attempts, temporarily locked, retry in %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Lockout is the failed attempts state of a user's email
+type Lockout struct {
+	Email string `json:"email"`
+	cache.Attempts
+	Locked bool `json:"locked"`
+}
+
+func (u User) emailAttemptKey(address string) string {
+	return fmt.Sprintf("login-attempts:%s:email:%s", u.conf.Name, strings.ToLower(address))
+}
+
+func (u User) ipAttemptKey(ip string) string {
+	return fmt.Sprintf("login-attempts:%s:ip:%s", u.conf.Name, ip)
+}
+
+// checkAttempts returns a *TooManyAttemptsError when the email or IP must
+// wait before another attempt
+func (u User) checkAttempts(address, ip string) error {
+	tracker := cache.NewAttemptTracker(Cache, LoginAttemptPolicy)
+	if err := waitFor(tracker, u.emailAttemptKey(address)); err != nil {
+		return err
+	}
+
+	if len(ip) == 0 {
+		return nil
+	}
+
+	ipTracker := cache.NewAttemptTracker(Cache, IPAttemptPolicy)
+	return waitFor(ipTracker, u.ipAttemptKey(ip))
+}
+
+func waitFor(tracker cache.AttemptTracker, key string) error {
+	wait, err := tracker.Wait(key)
+	if err != nil || wait == 0 {
+		return err
+	}
+
+	a, err := tracker.Get(key)
+	if err != nil {
+		return err
+	}
+	return &TooManyAttemptsError{RetryAfter: wait, Locked: a.Locked(time.Now())}
+}
+
+// failAttempt records a failed attempt for the email and IP and notifies the
+// user when their email gets locked
+func (u User) failAttempt(address, ip string) error {
+	tracker := cache.NewAttemptTracker(Cache, LoginAttemptPolicy)
+
+	a, locked, err := tracker.Fail(u.emailAttemptKey(address))
+	if err != nil {
+		return err
+	}
+
+	if len(ip) > 0 {
+		ipTracker := cache.NewAttemptTracker(Cache, IPAttemptPolicy)
+		if _, _, err := ipTracker.Fail(u.ipAttemptKey(ip)); err != nil {
+			return err
+		}
+	}
+
+	if locked {
+		if err := u.sendLockoutEmail(address, a); err != nil {
+			Log.Error().Err(err).Msgf("error sending lockout notification to %s", address)
+		}
+	}
+	return nil
+}
+
+// clearAttempts forgets the failed attempts of an email after a successful
+// attempt, the IP keeps its failures
+func (u User) clearAttempts(address string) error {
+	tracker := cache.NewAttemptTracker(Cache, LoginAttemptPolicy)
+	return tracker.Reset(u.emailAttemptKey(address))
+}
+
+// LockoutStatus returns the failed attempts state of an email
+func (u User) LockoutStatus(address string) (Lockout, error) {
+	tracker := cache.NewAttemptTracker(Cache, LoginAttemptPolicy)
+
+	a, err := tracker.Get(u.emailAttemptKey(address))
+	if err != nil {
+		return Lockout{}, err
+	}
+
+	lo := Lockout{
+		Email:    strings.ToLower(address),
+		Attempts: a,
+		Locked:   a.Locked(time.Now()),
+	}
+	return lo, nil
+}
+
+// Unlock removes the lockout and failed attempts of an email
+func (u User) Unlock(address string) error {
+	return u.clearAttempts(address)
+}
+
+func (u User) sendLockoutEmail(address string, a cache.Attempts) error {
+	tok, err := DB.FindUserByEmail(u.conf.Name, strings.ToLower(address))
+	if err != nil {
+		// nothing to notify for an unknown email
+		return nil
+	}
+
+	body := fmt.Sprintf(
+		"<p>Someone failed to sign in to your account %d times. It is locked until %s.</p>"+
+			"<p>If it was not you, consider changing your password once it's unlocked.</p>",
+		a.Failures,
+		a.LockedUntil.UTC().Format(time.RFC1123),
+	)
+
+	mail := email.SendMailData{
+		From:     Config.FromEmail,
+		FromName: Config.FromName,
+		To:       tok.Email,
+		Subject:  "Your account has been temporarily locked",
+		HTMLBody: body,
+	}
+
+	outbox := email.Outbox{
+		Mailer:  Emailer,
+		DB:      DB,
+		Storage: Filestore,
+	}
+
+	_, err = outbox.Send(u.conf.Name, mail)
+	return err
+}
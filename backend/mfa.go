@@ -0,0 +1,397 @@
+package backend
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// MFAChallengeTTL is how long a sign-in MFA challenge can be used
+	MFAChallengeTTL = 5 * time.Minute
+
+	mfaMaxAttempts    = 5
+	recoveryCodeCount = 10
+)
+
+var (
+	// ErrInvalidMFACode is returned when a TOTP or recovery code is invalid
+	ErrInvalidMFACode = errors.New("invalid two-factor code")
+	// ErrInvalidMFAChallenge is returned when an MFA challenge is unknown,
+	// expired or had too many failed attempts
+	ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor challenge")
+	// ErrMFAAlreadyEnabled is returned when enrolling a user that already has
+	// a second factor
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrMFANotEnabled is returned when the user has no second factor
+	ErrMFANotEnabled = errors.New("two-factor authentication is not enabled")
+)
+
+// MFARequiredError is returned when signing in requires a second factor,
+// the challenge is exchanged for a session via VerifyMFA
+type MFARequiredError struct {
+	Challenge model.MFAChallenge
+}
+
+func (e *MFARequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+type mfaChallenge struct {
+	UserID    string    `json:"userId"`
+	AccountID string    `json:"accountId"`
+	Enroll    bool      `json:"enroll"`
+	UserAgent string    `json:"ua"`
+	IP        string    `json:"ip"`
+	Attempts  int       `json:"attempts"`
+	Expires   time.Time `json:"expires"`
+}
+
+// signIn starts a session for a user that passed their first factor or
+// returns an MFARequiredError when a second factor is needed. Unverified
+// users are rejected when the database policy blocks them.
+func (u User) signIn(tok model.User, userAgent, ip string) (model.AuthTokens, error) {
+	if !tok.Verified && u.conf.AuthPolicy.EmailVerification == model.EmailVerificationBlock {
+		return model.AuthTokens{}, ErrEmailNotVerified
+	}
+
+	m, err := DB.GetMFA(u.conf.Name, tok.ID)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	enroll := false
+	if !m.Enabled {
+		if !u.mfaRequired(tok.Role) {
+			return u.StartSession(tok, userAgent, ip)
+		}
+
+		enroll = true
+	}
+
+	ch := mfaChallenge{
+		UserID:    tok.ID,
+		AccountID: tok.AccountID,
+		Enroll:    enroll,
+		UserAgent: userAgent,
+		IP:        ip,
+		Expires:   time.Now().Add(MFAChallengeTTL),
+	}
+
+	challenge := internal.RandStringRunes(40)
+	if err := Cache.SetTyped("mfa-challenge:"+challenge, ch); err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	return model.AuthTokens{}, &MFARequiredError{
+		Challenge: model.MFAChallenge{
+			Challenge: challenge,
+			Enroll:    enroll,
+			Expires:   ch.Expires,
+		},
+	}
+}
+
+// SignInExternal starts a session for a user authenticated by an external
+// login provider, the second factor is required as for a password sign in.
+func (u User) SignInExternal(tok model.User, userAgent, ip string) (model.AuthTokens, error) {
+	return u.signIn(tok, userAgent, ip)
+}
+
+func (u User) mfaRequired(role int) bool {
+	return u.conf.MFARole > 0 && role >= u.conf.MFARole
+}
+
+func (u User) getChallenge(challenge string) (ch mfaChallenge, err error) {
+	if err = Cache.GetTyped("mfa-challenge:"+challenge, &ch); err != nil {
+		return ch, ErrInvalidMFAChallenge
+	} else if time.Now().After(ch.Expires) || ch.Attempts >= mfaMaxAttempts {
+		return ch, ErrInvalidMFAChallenge
+	}
+	return
+}
+
+// EnrollMFAWithChallenge starts the second factor enrollment of a user
+// signing in when the database policy requires one
+func (u User) EnrollMFAWithChallenge(challenge, issuer string) (model.MFAEnrollment, error) {
+	ch, err := u.getChallenge(challenge)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	} else if !ch.Enroll {
+		return model.MFAEnrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	tok, err := DB.GetUserByID(u.conf.Name, ch.AccountID, ch.UserID)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	return u.enrollMFA(tok.ID, tok.Email, issuer)
+}
+
+// VerifyMFA exchanges a sign-in challenge and a TOTP or recovery code for a
+// session. For enrollment challenges, the code confirms the enrollment and the
+// recovery codes are returned with the tokens.
+func (u User) VerifyMFA(challenge, code string) (model.AuthTokens, error) {
+	ch, err := u.getChallenge(challenge)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	tok, err := DB.GetUserByID(u.conf.Name, ch.AccountID, ch.UserID)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	var codes []string
+	if ch.Enroll {
+		codes, err = u.confirmMFA(tok.ID, code)
+	} else {
+		err = u.checkMFACode(tok.ID, code)
+	}
+
+	if errors.Is(err, ErrInvalidMFACode) {
+		ch.Attempts++
+		if err := Cache.SetTyped("mfa-challenge:"+challenge, ch); err != nil {
+			return model.AuthTokens{}, err
+		}
+		return model.AuthTokens{}, err
+	} else if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	if err := Cache.Del("mfa-challenge:" + challenge); err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	tokens, err := u.StartSession(tok, ch.UserAgent, ch.IP)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	tokens.RecoveryCodes = codes
+	return tokens, nil
+}
+
+// MFAStatus returns the second factor state of a user
+func (u User) MFAStatus(auth model.Auth) (model.MFAStatus, error) {
+	m, err := DB.GetMFA(u.conf.Name, auth.UserID)
+	if err != nil {
+		return model.MFAStatus{}, err
+	}
+
+	status := model.MFAStatus{
+		Enabled:  m.Enabled,
+		Required: u.mfaRequired(auth.Role),
+	}
+	if m.Enabled {
+		status.RecoveryCodesLeft = len(m.RecoveryCodes)
+	}
+	return status, nil
+}
+
+// EnrollMFA starts the second factor enrollment of a signed-in user, it
+// becomes active once confirmed with ConfirmMFA
+func (u User) EnrollMFA(auth model.Auth, issuer string) (model.MFAEnrollment, error) {
+	return u.enrollMFA(auth.UserID, auth.Email, issuer)
+}
+
+func (u User) enrollMFA(userID, email, issuer string) (model.MFAEnrollment, error) {
+	m, err := DB.GetMFA(u.conf.Name, userID)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	} else if m.Enabled {
+		return model.MFAEnrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := internal.NewTOTPSecret()
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	b, err := model.EncryptMFASecret(secret)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	pending := model.MFA{
+		UserID: userID,
+		Secret: b,
+	}
+	if err := DB.SaveMFA(u.conf.Name, pending); err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	if len(issuer) == 0 {
+		issuer = u.conf.Name
+	}
+
+	uri := internal.TOTPURI(issuer, email, secret)
+
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	enrollment := model.MFAEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}
+	return enrollment, nil
+}
+
+// ConfirmMFA activates the second factor of a user with a valid TOTP code
+// and returns the one-time recovery codes
+func (u User) ConfirmMFA(auth model.Auth, code string) ([]string, error) {
+	return u.confirmMFA(auth.UserID, code)
+}
+
+func (u User) confirmMFA(userID, code string) ([]string, error) {
+	m, err := DB.GetMFA(u.conf.Name, userID)
+	if err != nil {
+		return nil, err
+	} else if len(m.UserID) == 0 {
+		return nil, ErrMFANotEnabled
+	} else if m.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, err := u.validateTOTP(m, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	m.Enabled = true
+	m.LastStep = step
+	m.RecoveryCodes = hashes
+	if err := DB.SaveMFA(u.conf.Name, m); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA removes the second factor of a user after validating a TOTP or
+// recovery code
+func (u User) DisableMFA(auth model.Auth, code string) error {
+	if err := u.checkMFACode(auth.UserID, code); err != nil {
+		return err
+	}
+	return DB.DeleteMFA(u.conf.Name, auth.UserID)
+}
+
+// ResetMFA removes the second factor of a user without a code, for instance
+// when an administrator helps a user that lost their device
+func (u User) ResetMFA(userID string) error {
+	return DB.DeleteMFA(u.conf.Name, userID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user after
+// validating a TOTP code
+func (u User) RegenerateRecoveryCodes(auth model.Auth, code string) ([]string, error) {
+	m, err := DB.GetMFA(u.conf.Name, auth.UserID)
+	if err != nil {
+		return nil, err
+	} else if !m.Enabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	step, err := u.validateTOTP(m, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	m.LastStep = step
+	m.RecoveryCodes = hashes
+	if err := DB.SaveMFA(u.conf.Name, m); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkMFACode validates a TOTP code or consumes a recovery code
+func (u User) checkMFACode(userID, code string) error {
+	m, err := DB.GetMFA(u.conf.Name, userID)
+	if err != nil {
+		return err
+	} else if !m.Enabled {
+		return ErrMFANotEnabled
+	}
+
+	// recovery codes are longer than TOTP codes
+	if len(strings.TrimSpace(code)) > internal.TOTPDigits {
+		hash := hashRecoveryCode(code)
+		for i, h := range m.RecoveryCodes {
+			if h == hash {
+				m.RecoveryCodes = append(m.RecoveryCodes[:i], m.RecoveryCodes[i+1:]...)
+				return DB.SaveMFA(u.conf.Name, m)
+			}
+		}
+		return ErrInvalidMFACode
+	}
+
+	step, err := u.validateTOTP(m, code)
+	if err != nil {
+		return err
+	}
+
+	m.LastStep = step
+	return DB.SaveMFA(u.conf.Name, m)
+}
+
+func (u User) validateTOTP(m model.MFA, code string) (int64, error) {
+	secret, err := m.GetSecret()
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := internal.ValidateTOTP(secret, code, time.Now(), m.LastStep)
+	if !ok {
+		return 0, ErrInvalidMFACode
+	}
+	return step, nil
+}
+
+// newRecoveryCodes returns the recovery codes and their hashes
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err = rand.Read(b); err != nil {
+			return
+		}
+
+		s := strings.ToLower(enc.EncodeToString(b))
+		code := s[:8] + "-" + s[8:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
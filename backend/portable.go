@@ -0,0 +1,664 @@
+package backend
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/storage"
+)
+
+// portableRecords lists the records of a portable archive in the order they
+// are imported, the ones referenced by others first
+var portableRecords = []string{
+	"accounts",
+	"users",
+	"mfa",
+	"identities",
+	"roles",
+	"groups",
+	"files",
+	"apikeys",
+	"functions",
+	"tasks",
+	"forms",
+	"templates",
+}
+
+// portableUser holds the user fields hidden from the API
+type portableUser struct {
+	model.User
+	Password  string `json:"password"`
+	ResetCode string `json:"resetCode"`
+}
+
+// portableMFA holds the second factor fields hidden from the API
+type portableMFA struct {
+	model.MFA
+	Secret        []byte   `json:"secret"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+	LastStep      int64    `json:"lastStep"`
+}
+
+// portableAPIKey holds the API key hash hidden from the API
+type portableAPIKey struct {
+	model.APIKey
+	KeyHash string `json:"keyHash"`
+}
+
+// portableSpool writes the records and documents of a portable archive to
+// {dir}/{name}.ndjson and the exported IDs to {dir}/ids.ndjson
+type portableSpool struct {
+	dir     string
+	counts  map[string]int64
+	files   map[string]*os.File
+	writers map[string]*bufio.Writer
+}
+
+func (ps *portableSpool) create(name string) error {
+	if _, ok := ps.files[name]; ok {
+		return nil
+	}
+
+	filename := filepath.Join(ps.dir, name+".ndjson")
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	ps.files[name] = f
+	ps.writers[name] = bufio.NewWriter(f)
+	if name != "ids" {
+		ps.counts[name] = 0
+	}
+	return nil
+}
+
+func (ps *portableSpool) write(name string, v any) error {
+	if err := ps.create(name); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error exporting %s: %w", name, err)
+	}
+
+	if _, err := ps.writers[name].Write(append(b, '\n')); err != nil {
+		return err
+	}
+
+	if name != "ids" {
+		ps.counts[name]++
+	}
+	return nil
+}
+
+// id records an exported ID, the references to it are rewritten when the
+// archive is imported
+func (ps *portableSpool) id(id string) error {
+	return ps.write("ids", id)
+}
+
+func (ps *portableSpool) close() error {
+	for _, w := range ps.writers {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range ps.files {
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportPortable writes a portable archive, see ExportOptions.Portable. It
+// holds the manifest.json, the tenant.json, the exported IDs in ids.ndjson,
+// one NDJSON file per kind of record in records/, one per collection in
+// collections/ and optionally the uploaded files in blobs/.
+func exportPortable(src database.Persister, files storage.Storer, cus model.Tenant, conf model.DatabaseConfig, opts ExportOptions, w io.Writer) (m DumpManifest, err error) {
+	m = DumpManifest{
+		Format:   DumpFormat,
+		Layout:   database.DumpLayoutPortable,
+		Database: conf.Name,
+		Created:  time.Now(),
+		Tables:   make(map[string]int64),
+	}
+
+	dir, err := os.MkdirTemp("", "sb-export-*")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	ps := &portableSpool{
+		dir:     dir,
+		counts:  m.Tables,
+		files:   make(map[string]*os.File),
+		writers: make(map[string]*bufio.Writer),
+	}
+
+	cols, err := spoolPortable(src, conf.Name, ps)
+	if cerr := ps.close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return
+	}
+
+	var keys []string
+	if opts.Blobs {
+		keys, err = blobKeys(src, conf.Name)
+		if err != nil {
+			return
+		}
+		m.Blobs = len(keys)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err = writeTarJSON(tw, "manifest.json", m); err != nil {
+		return
+	}
+
+	if err = writeTarJSON(tw, "tenant.json", toDumpTenant(cus, conf)); err != nil {
+		return
+	}
+
+	names := []string{"ids"}
+	for _, name := range portableRecords {
+		names = append(names, "records/"+name)
+	}
+	for _, col := range cols {
+		names = append(names, "collections/"+col)
+	}
+
+	for _, name := range names {
+		if _, ok := ps.files[name]; !ok {
+			continue
+		}
+
+		if err = writeTarFile(tw, name+".ndjson", filepath.Join(dir, name+".ndjson")); err != nil {
+			return
+		}
+	}
+
+	for _, key := range keys {
+		if err = writeTarBlob(tw, files, dir, key); err != nil {
+			return m, fmt.Errorf("error exporting file %s: %w", key, err)
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return
+	}
+	err = gz.Close()
+	return
+}
+
+// spoolPortable writes the records and documents of a database through the
+// Persister and returns the collections
+func spoolPortable(src database.Persister, dbName string, ps *portableSpool) (cols []string, err error) {
+	accounts, err := src.ListAccounts(dbName)
+	if err != nil {
+		return
+	}
+
+	for _, a := range accounts {
+		if err = ps.write("records/accounts", a); err != nil {
+			return
+		} else if err = ps.id(a.ID); err != nil {
+			return
+		}
+
+		if err = spoolPortableUsers(src, dbName, a.ID, ps); err != nil {
+			return
+		}
+	}
+
+	roles, err := src.ListRoles(dbName)
+	if err != nil {
+		return
+	}
+	for _, r := range roles {
+		if err = ps.write("records/roles", r); err != nil {
+			return
+		}
+	}
+
+	list, err := src.ListAllFiles(dbName, "")
+	if err != nil {
+		return
+	}
+	for _, f := range list {
+		if err = ps.write("records/files", f); err != nil {
+			return
+		} else if err = ps.id(f.ID); err != nil {
+			return
+		}
+	}
+
+	keys, err := src.ListAPIKeys(dbName)
+	if err != nil {
+		return
+	}
+	for _, k := range keys {
+		if err = ps.write("records/apikeys", portableAPIKey{APIKey: k, KeyHash: k.KeyHash}); err != nil {
+			return
+		}
+	}
+
+	fns, err := src.ListFunctions(dbName)
+	if err != nil {
+		return
+	}
+	for _, fn := range fns {
+		// the run history stays behind
+		fn.History = nil
+		if err = ps.write("records/functions", fn); err != nil {
+			return
+		}
+	}
+
+	tasks, err := src.ListTasksByBase(dbName)
+	if err != nil {
+		return
+	}
+	for _, t := range tasks {
+		if err = ps.write("records/tasks", t); err != nil {
+			return
+		}
+	}
+
+	forms, err := src.ListFormDefinitions(dbName)
+	if err != nil {
+		return
+	}
+	for _, def := range forms {
+		if err = ps.write("records/forms", def); err != nil {
+			return
+		}
+	}
+
+	templates, err := src.ListEmailTemplates(dbName)
+	if err != nil {
+		return
+	}
+	for _, tmpl := range templates {
+		if err = ps.write("records/templates", tmpl); err != nil {
+			return
+		}
+	}
+
+	tables, err := src.DumpTables(dbName)
+	if err != nil {
+		return
+	}
+
+	for _, table := range tables {
+		if strings.HasPrefix(table, "sb_") {
+			continue
+		}
+
+		// collections are created even when empty
+		if err = ps.create("collections/" + table); err != nil {
+			return
+		}
+		cols = append(cols, table)
+	}
+	sort.Strings(cols)
+
+	err = src.DumpDocuments(dbName, cols, func(col string, doc model.PortableDocument) error {
+		if err := ps.write("collections/"+col, doc); err != nil {
+			return err
+		}
+		return ps.id(doc.ID)
+	})
+	return
+}
+
+func spoolPortableUsers(src database.Persister, dbName, accountID string, ps *portableSpool) error {
+	users, err := src.ListUsers(dbName, accountID)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		pu := portableUser{User: u, Password: u.Password, ResetCode: u.ResetCode}
+		if err := ps.write("records/users", pu); err != nil {
+			return err
+		} else if err := ps.id(u.ID); err != nil {
+			return err
+		}
+
+		mfa, err := src.GetMFA(dbName, u.ID)
+		if err != nil {
+			return err
+		} else if len(mfa.UserID) > 0 {
+			pm := portableMFA{
+				MFA:           mfa,
+				Secret:        mfa.Secret,
+				RecoveryCodes: mfa.RecoveryCodes,
+				LastStep:      mfa.LastStep,
+			}
+			if err := ps.write("records/mfa", pm); err != nil {
+				return err
+			}
+		}
+
+		identities, err := src.ListIdentities(dbName, u.ID)
+		if err != nil {
+			return err
+		}
+		for _, idt := range identities {
+			if err := ps.write("records/identities", idt); err != nil {
+				return err
+			}
+		}
+	}
+
+	groups, err := src.ListGroups(dbName, accountID)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if err := ps.write("records/groups", g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// portableImport loads the entries of a portable archive in dst. All records
+// and documents get new IDs from dst and the references to the exported IDs,
+// including the ones in the documents, are rewritten.
+type portableImport struct {
+	dst    database.Persister
+	dbName string
+	ids    map[string]string
+}
+
+// tenant returns the tenant and database with new IDs, an existing tenant
+// with the same email is reused
+func (pi *portableImport) tenant(cus model.Tenant, conf model.DatabaseConfig) (model.Tenant, model.DatabaseConfig, error) {
+	exists, err := pi.dst.EmailExists(cus.Email)
+	if err != nil {
+		return cus, conf, err
+	}
+
+	if exists {
+		existing, err := pi.dst.GetTenantByEmail(cus.Email)
+		if err != nil {
+			return cus, conf, err
+		}
+		cus.ID = existing.ID
+	} else {
+		cus.ID = pi.dst.NewID()
+	}
+
+	conf.ID = pi.dst.NewID()
+	conf.TenantID = cus.ID
+	conf.Name = pi.dbName
+	return cus, conf, nil
+}
+
+// restore imports an ids.ndjson, records/ or collections/ entry
+func (pi *portableImport) restore(name string, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	if name == "ids.ndjson" {
+		return pi.restoreIDs(dec)
+	}
+
+	kind := strings.TrimSuffix(name, ".ndjson")
+	if col, ok := strings.CutPrefix(kind, "collections/"); ok {
+		return pi.restoreDocuments(col, dec)
+	}
+
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := pi.restoreRecord(strings.TrimPrefix(kind, "records/"), raw); err != nil {
+			return err
+		}
+	}
+}
+
+func (pi *portableImport) restoreIDs(dec *json.Decoder) error {
+	for {
+		var id string
+		if err := dec.Decode(&id); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		newID := pi.dst.NewID()
+		if len(newID) == 0 {
+			return fmt.Errorf("unable to generate a new ID for %s", id)
+		}
+		pi.ids[id] = newID
+	}
+}
+
+func (pi *portableImport) restoreRecord(kind string, raw json.RawMessage) error {
+	dst, dbName := pi.dst, pi.dbName
+
+	switch kind {
+	case "accounts":
+		var a model.Account
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return err
+		}
+
+		a.ID = pi.id(a.ID)
+		return dst.RestoreAccount(dbName, a)
+	case "users":
+		var pu portableUser
+		if err := json.Unmarshal(raw, &pu); err != nil {
+			return err
+		}
+
+		tok := pu.User
+		tok.ID = pi.id(tok.ID)
+		tok.AccountID = pi.id(tok.AccountID)
+		tok.AvatarID = pi.id(tok.AvatarID)
+		tok.Password = pu.Password
+		tok.ResetCode = pu.ResetCode
+		if tok.Profile != nil {
+			tok.Profile = pi.rewrite(tok.Profile).(map[string]any)
+		}
+		return dst.RestoreUser(dbName, tok)
+	case "mfa":
+		var pm portableMFA
+		if err := json.Unmarshal(raw, &pm); err != nil {
+			return err
+		}
+
+		mfa := pm.MFA
+		mfa.UserID = pi.id(mfa.UserID)
+		mfa.Secret = pm.Secret
+		mfa.RecoveryCodes = pm.RecoveryCodes
+		mfa.LastStep = pm.LastStep
+		return dst.SaveMFA(dbName, mfa)
+	case "identities":
+		var idt model.Identity
+		if err := json.Unmarshal(raw, &idt); err != nil {
+			return err
+		}
+
+		idt.AccountID = pi.id(idt.AccountID)
+		idt.UserID = pi.id(idt.UserID)
+		_, err := dst.CreateIdentity(dbName, idt)
+		return err
+	case "roles":
+		var role model.Role
+		if err := json.Unmarshal(raw, &role); err != nil {
+			return err
+		}
+		return dst.SaveRole(dbName, role)
+	case "groups":
+		var g model.Group
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return err
+		}
+
+		g.AccountID = pi.id(g.AccountID)
+		members := g.Members
+		g.Members = nil
+
+		id, err := dst.CreateGroup(dbName, g)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range members {
+			if err := dst.AddGroupMember(dbName, id, pi.id(userID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "files":
+		var f model.File
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return err
+		}
+
+		f.ID = pi.id(f.ID)
+		f.AccountID = pi.id(f.AccountID)
+		f.UserID = pi.id(f.UserID)
+		return dst.RestoreFile(dbName, f)
+	case "apikeys":
+		var pk portableAPIKey
+		if err := json.Unmarshal(raw, &pk); err != nil {
+			return err
+		}
+
+		k := pk.APIKey
+		k.AccountID = pi.id(k.AccountID)
+		k.UserID = pi.id(k.UserID)
+		k.KeyHash = pk.KeyHash
+		_, err := dst.CreateAPIKey(dbName, k)
+		return err
+	case "functions":
+		var fn model.ExecData
+		if err := json.Unmarshal(raw, &fn); err != nil {
+			return err
+		}
+
+		fn.AccountID = pi.id(fn.AccountID)
+		_, err := dst.AddFunction(dbName, fn)
+		return err
+	case "tasks":
+		var t model.Task
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return err
+		}
+
+		t.BaseName = dbName
+		_, err := dst.AddTask(dbName, t)
+		return err
+	case "forms":
+		var def model.FormDefinition
+		if err := json.Unmarshal(raw, &def); err != nil {
+			return err
+		}
+		return dst.SaveFormDefinition(dbName, def)
+	case "templates":
+		var tmpl model.EmailTemplate
+		if err := json.Unmarshal(raw, &tmpl); err != nil {
+			return err
+		}
+		return dst.SaveEmailTemplate(dbName, tmpl)
+	}
+	return fmt.Errorf("unknown record %s", kind)
+}
+
+func (pi *portableImport) restoreDocuments(col string, dec *json.Decoder) error {
+	var docs []model.PortableDocument
+	for {
+		var doc model.PortableDocument
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		doc.ID = pi.id(doc.ID)
+		doc.AccountID = pi.id(doc.AccountID)
+		doc.OwnerID = pi.id(doc.OwnerID)
+		doc.Data, _ = pi.rewrite(doc.Data).(map[string]any)
+		if doc.Data == nil {
+			doc.Data = make(map[string]any)
+		}
+
+		docs = append(docs, doc)
+		if len(docs) == restoreBatchSize {
+			if err := pi.dst.RestoreDocuments(pi.dbName, col, docs); err != nil {
+				return err
+			}
+			docs = nil
+		}
+	}
+
+	// collections are created even when empty
+	return pi.dst.RestoreDocuments(pi.dbName, col, docs)
+}
+
+// id returns the new ID of an exported ID, other values are returned as is
+func (pi *portableImport) id(id string) string {
+	if newID, ok := pi.ids[id]; ok {
+		return newID
+	}
+	return id
+}
+
+// rewrite replaces the exported IDs by their new ID in a decoded value and
+// converts the numbers to int64 or float64
+func (pi *portableImport) rewrite(v any) any {
+	switch x := v.(type) {
+	case string:
+		return pi.id(x)
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]any:
+		for k, val := range x {
+			x[k] = pi.rewrite(val)
+		}
+		return x
+	case []any:
+		for i, val := range x {
+			x[i] = pi.rewrite(val)
+		}
+		return x
+	}
+	return v
+}
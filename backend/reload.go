@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/logger"
+)
+
+var (
+	// mailer is the Emailer created by Setup, Reload replaces its provider
+	mailer *email.Reloadable
+
+	// applied is the configuration in effect, Config and config.Current are
+	// not modified after Setup since they're read without synchronization
+	applied   config.AppConfig
+	reloadMux sync.Mutex
+)
+
+// newMailer returns the Mailer for the configured mail provider
+func newMailer(cfg config.AppConfig) email.Mailer {
+	mp := cfg.MailProvider
+	if strings.EqualFold(mp, email.MailProviderSES) {
+		return email.AWSSES{}
+	} else if strings.EqualFold(mp, email.MailProviderSMTP) {
+		return email.SMTP{
+			Host:       cfg.SMTPHost,
+			Port:       cfg.SMTPPort,
+			Username:   cfg.SMTPUsername,
+			Password:   cfg.SMTPPassword,
+			Encryption: cfg.SMTPEncryption,
+		}
+	}
+	return email.Dev{}
+}
+
+// Reload applies the settings that can change while the server runs: the
+// console log level and the mail provider with its SMTP settings. It
+// returns the other settings that changed and need a restart to apply.
+func Reload(cfg config.AppConfig) ([]string, error) {
+	if mailer == nil {
+		return nil, errors.New("backend is not initialized, call Setup first")
+	}
+
+	reloadMux.Lock()
+	defer reloadMux.Unlock()
+
+	next := applied
+	next.LogConsoleLevel = cfg.LogConsoleLevel
+	if err := logger.SetLevel(next); err != nil {
+		return nil, err
+	}
+
+	next.MailProvider = cfg.MailProvider
+	next.SMTPHost = cfg.SMTPHost
+	next.SMTPPort = cfg.SMTPPort
+	next.SMTPUsername = cfg.SMTPUsername
+	next.SMTPPassword = cfg.SMTPPassword
+	next.SMTPEncryption = cfg.SMTPEncryption
+	mailer.Set(newMailer(next))
+
+	applied = next
+
+	return config.Changed(applied, cfg), nil
+}
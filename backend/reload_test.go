@@ -0,0 +1,49 @@
+package backend_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/email"
+)
+
+func TestReload(t *testing.T) {
+	prevLevel := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(prevLevel)
+
+	next := config.Current
+	next.AppEnv = "prod"
+	next.LogConsoleLevel = "warn"
+	next.MailProvider = email.MailProviderSMTP
+	next.SMTPHost = "127.0.0.1"
+	next.SMTPPort = "1"
+	next.DataStore = "sqlite"
+
+	restart, err := backend.Reload(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Reload(config.Current)
+
+	// APP_ENV needs a restart, dev still forces the trace level
+	if zerolog.GlobalLevel() != zerolog.TraceLevel {
+		t.Errorf("expected log level trace got %v", zerolog.GlobalLevel())
+	}
+
+	// the sends go to the new provider which fails to connect
+	err = backend.Emailer.Send(email.SendMailData{From: "a@test.com", To: "b@test.com"})
+	if err == nil {
+		t.Errorf("expected the SMTP provider to be used")
+	}
+
+	if expected := []string{"APP_ENV", "DATA_STORE"}; !reflect.DeepEqual(restart, expected) {
+		t.Errorf("expected restart %v got %v", expected, restart)
+	}
+
+	if backend.Config.MailProvider == email.MailProviderSMTP {
+		t.Errorf("Config should not change on reload")
+	}
+}
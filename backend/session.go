@@ -0,0 +1,266 @@
+package backend
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// AccessTokenTTL is how long an access (session) token is valid
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a session can stay unused before it expires
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidRefreshToken is returned when a refresh token does not match
+	// an active session
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token
+	// is used, the session is revoked since the token might have been stolen
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+)
+
+// StartSession creates a session for a user and returns its access and
+// refresh tokens. The userAgent and ip are informative and displayed when
+// listing the sessions.
+func (u User) StartSession(tok model.User, userAgent, ip string) (tokens model.AuthTokens, err error) {
+	secret, err := newRefreshSecret()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	s := model.Session{
+		AccountID:   tok.AccountID,
+		UserID:      tok.ID,
+		RefreshHash: hashRefreshSecret(secret),
+		JWTID:       internal.RandStringRunes(32),
+		UserAgent:   userAgent,
+		IP:          ip,
+		Created:     now,
+		LastUsed:    now,
+		Expires:     now.Add(RefreshTokenTTL),
+	}
+
+	id, err := DB.CreateSession(u.conf.Name, s)
+	if err != nil {
+		return
+	}
+
+	s.ID = id
+	return u.issueTokens(tok, s, secret)
+}
+
+// RefreshSession exchanges a refresh token for a new access token. The refresh
+// token is rotated, using a refresh token already rotated for a session, or
+// the same token twice concurrently, revokes it.
+func (u User) RefreshSession(refreshToken, userAgent, ip string) (tokens model.AuthTokens, err error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || len(id) == 0 || len(secret) == 0 {
+		return tokens, ErrInvalidRefreshToken
+	}
+
+	s, err := DB.GetSessionByID(u.conf.Name, id)
+	if err != nil {
+		return tokens, ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(s.Expires) {
+		if err := u.revokeSession(s); err != nil {
+			return tokens, err
+		}
+		return tokens, ErrInvalidRefreshToken
+	}
+
+	hash := hashRefreshSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(s.RefreshHash)) != 1 {
+		// only a previous refresh token of this session is a reuse, other
+		// tokens are rejected without revoking the session
+		if rotated, err := Cache.Get(model.RotatedRefreshKey(hash)); err != nil || rotated != s.ID {
+			return tokens, ErrInvalidRefreshToken
+		}
+
+		if err := u.revokeSession(s); err != nil {
+			return tokens, err
+		}
+		return tokens, ErrRefreshTokenReused
+	}
+
+	tok, err := DB.GetUserByID(u.conf.Name, s.AccountID, s.UserID)
+	if err != nil {
+		return
+	}
+
+	secret, err = newRefreshSecret()
+	if err != nil {
+		return
+	}
+
+	// the previous access token is still valid until it expires, requests
+	// in-flight while refreshing are not rejected
+	now := time.Now()
+	prevHash := s.RefreshHash
+	s.RefreshHash = hashRefreshSecret(secret)
+	s.JWTID = internal.RandStringRunes(32)
+	s.LastUsed = now
+	s.Expires = now.Add(RefreshTokenTTL)
+	if len(userAgent) > 0 {
+		s.UserAgent = userAgent
+	}
+	if len(ip) > 0 {
+		s.IP = ip
+	}
+
+	// recorded before rotating, a concurrent request losing the rotation
+	// below sees this token as reused
+	if err = Cache.SetEx(model.RotatedRefreshKey(prevHash), s.ID, RefreshTokenTTL); err != nil {
+		return
+	}
+
+	rotated, err := DB.RotateSession(u.conf.Name, s, prevHash)
+	if err != nil {
+		return
+	} else if !rotated {
+		// the token was used by another request in the meantime, the
+		// session as rotated by that request is revoked
+		if cur, err := DB.GetSessionByID(u.conf.Name, s.ID); err == nil {
+			if err := u.revokeSession(cur); err != nil {
+				return tokens, err
+			}
+		}
+		return tokens, ErrRefreshTokenReused
+	}
+
+	return u.issueTokens(tok, s, secret)
+}
+
+// Sessions lists the active sessions of a user
+func (u User) Sessions(userID string) ([]model.Session, error) {
+	list, err := DB.ListSessions(u.conf.Name, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	var results []model.Session
+	for _, s := range list {
+		if now.After(s.Expires) {
+			continue
+		}
+		results = append(results, s)
+	}
+	return results, nil
+}
+
+// RevokeSession signs out a session of a user, its refresh token cannot be
+// used anymore and its last access token is rejected
+func (u User) RevokeSession(userID, sessionID string) error {
+	s, err := DB.GetSessionByID(u.conf.Name, sessionID)
+	if err != nil {
+		return err
+	} else if s.UserID != userID {
+		return errors.New("session not found")
+	}
+
+	return u.revokeSession(s)
+}
+
+// Logout revokes the session of the access token in auth
+func (u User) Logout(auth model.Auth) error {
+	if err := Cache.Set(model.RevokedTokenKey(auth.JWTID), "1"); err != nil {
+		return err
+	}
+
+	list, err := DB.ListSessions(u.conf.Name, auth.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range list {
+		if s.JWTID == auth.JWTID {
+			return DB.DeleteSession(u.conf.Name, s.ID)
+		}
+	}
+	return nil
+}
+
+// SignOutEverywhere revokes all sessions of a user and rejects all access
+// tokens issued before now.
+func (u User) SignOutEverywhere(auth model.Auth) error {
+	list, err := DB.ListSessions(u.conf.Name, auth.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range list {
+		if err := Cache.Set(model.RevokedTokenKey(s.JWTID), "1"); err != nil {
+			return err
+		}
+	}
+
+	if err := DB.DeleteUserSessions(u.conf.Name, auth.UserID); err != nil {
+		return err
+	}
+
+	cutoff := fmt.Sprintf("%d", time.Now().Unix())
+	if err := Cache.Set(model.SignedOutKey(auth.UserID), cutoff); err != nil {
+		return err
+	}
+
+	token := auth.ReconstructToken()
+	if err := Cache.Del(token); err != nil {
+		return err
+	}
+	return Cache.Del("base:" + token)
+}
+
+func (u User) revokeSession(s model.Session) error {
+	if err := Cache.Set(model.RevokedTokenKey(s.JWTID), "1"); err != nil {
+		return err
+	}
+	return DB.DeleteSession(u.conf.Name, s.ID)
+}
+
+func (u User) issueTokens(tok model.User, s model.Session, secret string) (tokens model.AuthTokens, err error) {
+	token := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
+
+	jwtBytes, err := signJWT(token, s.JWTID)
+	if err != nil {
+		return
+	}
+
+	if err = u.cacheAuth(tok); err != nil {
+		return
+	}
+
+	tokens = model.AuthTokens{
+		Token:        string(jwtBytes),
+		RefreshToken: fmt.Sprintf("%s.%s", s.ID, secret),
+		Expires:      time.Now().Add(AccessTokenTTL),
+	}
+	return
+}
+
+func newRefreshSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashRefreshSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
@@ -1,9 +1,11 @@
 package backend
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
 	"errors"
 	"fmt"
-	"math/rand"
+	"math/big"
 	"strings"
 	"time"
 
@@ -25,83 +27,102 @@ func newUser(base model.DatabaseConfig) User {
 
 // Authenticate tries to authenticate an email/password and return a session token
 func (u User) Authenticate(email, password string) (string, error) {
-	email = strings.ToLower(email)
-
-	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	tokens, err := u.Login(email, password, "", "")
 	if err != nil {
 		return "", err
 	}
+	return tokens.Token, nil
+}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(tok.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid email/password")
+// Login authenticates an email/password and starts a session returning its
+// access and refresh tokens. An *MFARequiredError is returned when the user
+// must complete a second factor.
+func (u User) Login(email, password, userAgent, ip string) (model.AuthTokens, error) {
+	if err := u.checkAttempts(email, ip); err != nil {
+		return model.AuthTokens{}, err
 	}
 
-	token := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
-
-	jwt, err := GetJWT(token)
+	tok, err := u.checkPassword(email, password)
 	if err != nil {
-		return "", err
+		if err := u.failAttempt(email, ip); err != nil {
+			return model.AuthTokens{}, err
+		}
+		return model.AuthTokens{}, err
 	}
 
-	auth := model.Auth{
-		AccountID: tok.AccountID,
-		UserID:    tok.ID,
-		Email:     tok.Email,
-		Role:      tok.Role,
-		Token:     tok.Token,
+	if err := u.clearAttempts(email); err != nil {
+		return model.AuthTokens{}, err
 	}
 
-	//TODO: find a good way to find all occurences of those two
-	// and make them easily callable via a shared function
-	if err = Cache.SetTyped(token, auth); err != nil {
-		return "", err
-	}
-	if err = Cache.SetTyped("base:"+token, u.conf); err != nil {
-		return "", err
+	return u.signIn(tok, userAgent, ip)
+}
+
+func (u User) checkPassword(email, password string) (model.User, error) {
+	email = strings.ToLower(email)
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		return model.User{}, err
 	}
 
-	return string(jwt), nil
+	if err = bcrypt.CompareHashAndPassword([]byte(tok.Password), []byte(password)); err != nil {
+		return model.User{}, errors.New("invalid email/password")
+	}
+	return tok, nil
 }
 
 // Register creates a new account and user
 func (u User) Register(email, password string) (string, error) {
+	tokens, err := u.SignUp(email, password, "", "")
+	if err != nil {
+		return "", err
+	}
+	return tokens.Token, nil
+}
+
+// SignUp creates a new account and user and starts a session returning its
+// access and refresh tokens
+func (u User) SignUp(email, password, userAgent, ip string) (model.AuthTokens, error) {
 	email = strings.ToLower(email)
 
 	exists, err := DB.UserEmailExists(u.conf.Name, email)
 	if err != nil {
-		return "", err
+		return model.AuthTokens{}, err
 	} else if exists {
-		return "", errors.New("invalid email")
+		return model.AuthTokens{}, errors.New("invalid email")
 	}
 
-	// account creator have the role=50 (Account Admin)
-	jwtBytes, tok, err := u.CreateAccountAndUser(email, password, 50)
-	if err != nil {
-		return "", err
+	if err := u.ValidatePassword(password); err != nil {
+		return model.AuthTokens{}, err
 	}
 
-	token := string(jwtBytes)
-
-	auth := model.Auth{
-		AccountID: tok.AccountID,
-		UserID:    tok.ID,
-		Email:     tok.Email,
-		Role:      tok.Role,
-		Token:     tok.Token,
+	acctID, err := DB.CreateAccount(u.conf.Name, email)
+	if err != nil {
+		return model.AuthTokens{}, err
 	}
 
-	if err := Cache.SetTyped(token, auth); err != nil {
-		return "", err
+	// account creator have the role=50 (Account Admin)
+	verified := !u.conf.AuthPolicy.VerificationRequired()
+	_, tok, err := u.createUser(acctID, email, password, 50, verified)
+	if err != nil {
+		return model.AuthTokens{}, err
 	}
-	if err := Cache.SetTyped("base:"+token, u.conf); err != nil {
-		return "", err
+
+	if !verified {
+		if err := u.SendVerificationEmail(email); err != nil {
+			return model.AuthTokens{}, err
+		}
 	}
 
-	return token, nil
+	return u.signIn(tok, userAgent, ip)
 }
 
 // CreateAccountAndUser creates an account with a user
 func (u User) CreateAccountAndUser(email, password string, role int) ([]byte, model.User, error) {
+	if err := u.ValidatePassword(password); err != nil {
+		return nil, model.User{}, err
+	}
+
 	acctID, err := DB.CreateAccount(u.conf.Name, email)
 	if err != nil {
 		return nil, model.User{}, err
@@ -114,8 +135,17 @@ func (u User) CreateAccountAndUser(email, password string, role int) ([]byte, mo
 	return jwtBytes, tok, nil
 }
 
-// CreateUser creates a user for an Account
+// CreateUser creates a user for an Account, the password must follow the
+// database password policy
 func (u User) CreateUser(accountID, email, password string, role int) ([]byte, model.User, error) {
+	if err := u.ValidatePassword(password); err != nil {
+		return nil, model.User{}, err
+	}
+
+	return u.createUser(accountID, email, password, role, true)
+}
+
+func (u User) createUser(accountID, email, password string, role int, verified bool) ([]byte, model.User, error) {
 	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, model.User{}, err
@@ -127,6 +157,7 @@ func (u User) CreateUser(accountID, email, password string, role int) ([]byte, m
 		Token:     DB.NewID(),
 		Password:  string(b),
 		Role:      role,
+		Verified:  verified,
 	}
 
 	tokID, err := DB.CreateUser(u.conf.Name, tok)
@@ -144,14 +175,7 @@ func (u User) CreateUser(accountID, email, password string, role int) ([]byte, m
 		return nil, tok, err
 	}
 
-	auth := model.Auth{
-		AccountID: tok.AccountID,
-		UserID:    tok.ID,
-		Email:     tok.Email,
-		Role:      role,
-		Token:     tok.Token,
-	}
-	if err := Cache.SetTyped(token, auth); err != nil {
+	if err := u.cacheAuth(tok); err != nil {
 		return nil, tok, err
 	}
 
@@ -175,14 +199,56 @@ func (u User) SetPasswordResetCode(email, code string) error {
 
 // ResetPassword resets the password of a matching email/code for a user
 func (u User) ResetPassword(email, code, password string) error {
+	return u.RecoverPassword(email, code, password, "")
+}
+
+// RecoverPassword resets the password of a matching email/code for a user,
+// failed attempts are tracked for the email and the client ip
+func (u User) RecoverPassword(email, code, password, ip string) error {
 	email = strings.ToLower(email)
 
+	if err := u.checkAttempts(email, ip); err != nil {
+		return err
+	} else if err := u.ValidatePassword(password); err != nil {
+		return err
+	}
+
 	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
-	return DB.ResetPassword(u.conf.Name, email, code, string(b))
+	if len(code) == 0 {
+		err = errors.New("invalid code")
+	} else {
+		err = DB.ResetPassword(u.conf.Name, email, code, string(b))
+	}
+
+	if err != nil {
+		if err := u.failAttempt(email, ip); err != nil {
+			return err
+		}
+		return err
+	}
+
+	if err := u.clearAttempts(email); err != nil {
+		return err
+	}
+
+	// the password might have been compromised, all sessions are signed out
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		return err
+	}
+
+	auth := model.Auth{
+		AccountID: tok.AccountID,
+		UserID:    tok.ID,
+		Email:     tok.Email,
+		Role:      tok.Role,
+		Token:     tok.Token,
+	}
+	return u.SignOutEverywhere(auth)
 }
 
 // SetUserRole changes the role of a user
@@ -195,12 +261,12 @@ func (u User) SetUserRole(email string, role int) error {
 func (u User) UserSetPassword(email, oldpw, newpw string) error {
 	email = strings.ToLower(email)
 
-	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	tok, err := u.checkPassword(email, oldpw)
 	if err != nil {
 		return err
 	}
 
-	if _, err := u.Authenticate(email, oldpw); err != nil {
+	if err := u.ValidatePassword(newpw); err != nil {
 		return err
 	}
 
@@ -212,52 +278,61 @@ func (u User) UserSetPassword(email, oldpw, newpw string) error {
 	return DB.UserSetPassword(u.conf.Name, tok.ID, string(b))
 }
 
-// GetAuthToken returns a session token for a user
+// GetAuthToken starts a session for a user and returns its access token
 func (u User) GetAuthToken(tok model.User) (jwtBytes []byte, err error) {
+	tokens, err := u.StartSession(tok, "", "")
+	if err != nil {
+		return
+	}
+
+	jwtBytes = []byte(tokens.Token)
+	return
+}
+
+func (u User) cacheAuth(tok model.User) error {
 	token := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
 
-	// get their JWT
-	jwtBytes, err = GetJWT(token)
+	groups, permissions, err := u.groupAccess(tok.ID)
 	if err != nil {
-		return
+		return err
 	}
 
 	auth := model.Auth{
-		AccountID: tok.AccountID,
-		UserID:    tok.ID,
-		Email:     tok.Email,
-		Role:      tok.Role,
-		Token:     tok.Token,
+		AccountID:   tok.AccountID,
+		UserID:      tok.ID,
+		Email:       tok.Email,
+		Role:        u.conf.UserRole(tok),
+		Token:       tok.Token,
+		Verified:    tok.Verified,
+		Groups:      groups,
+		Permissions: permissions,
 	}
 
-	//TODO: find a good way to find all occurences of those two
-	// and make them easily callable via a shared function
-	if err = Cache.SetTyped(token, auth); err != nil {
-		return
-	}
-	if err = Cache.SetTyped("base:"+token, u.conf); err != nil {
-		return
+	if err := Cache.SetTyped(token, auth); err != nil {
+		return err
 	}
-
-	return
+	return Cache.SetTyped("base:"+token, u.conf)
 }
 
-// GetJWT returns a session token from a token
+// GetJWT returns a short-lived session token from a token
 func GetJWT(token string) ([]byte, error) {
+	return signJWT(token, internal.RandStringRunes(32))
+}
+
+func signJWT(token, jwtID string) ([]byte, error) {
 	now := time.Now()
 	pl := model.JWTPayload{
 		Payload: jwt.Payload{
 			Issuer:         "StaticBackend",
-			ExpirationTime: jwt.NumericDate(now.Add(12 * time.Hour)),
-			NotBefore:      jwt.NumericDate(now.Add(30 * time.Minute)),
+			ExpirationTime: jwt.NumericDate(now.Add(AccessTokenTTL)),
+			NotBefore:      jwt.NumericDate(now),
 			IssuedAt:       jwt.NumericDate(now),
-			JWTID:          internal.RandStringRunes(32),
+			JWTID:          jwtID,
 		},
 		Token: token,
 	}
 
 	return jwt.Sign(pl, model.HashSecret)
-
 }
 
 // MagicLinkData magic links for no-password sign-in
@@ -274,7 +349,12 @@ type MagicLinkData struct {
 func (u User) SetupMagicLink(data MagicLinkData) error {
 	data.Email = strings.ToLower(data.Email)
 
-	code := rand.Intn(987654) + 123456
+	n, err := rand.Int(rand.Reader, big.NewInt(987654))
+	if err != nil {
+		return err
+	}
+
+	code := n.Int64() + 123456
 	//TODO: the constant AppEnv should be moved to the config package?
 	// to accomodate unit test, we hard code a magic link code in dev mode
 	if Config.AppEnv == "dev" {
@@ -282,7 +362,7 @@ func (u User) SetupMagicLink(data MagicLinkData) error {
 	}
 	data.MagicLink += fmt.Sprintf("?code=%d&email=%s", code, data.Email)
 
-	if err := Cache.Set("ml-"+data.Email, fmt.Sprintf("%d a", code)); err != nil {
+	if err := Cache.Set("ml-"+data.Email, fmt.Sprintf("%d", code)); err != nil {
 		return err
 	}
 
@@ -302,41 +382,43 @@ func (u User) SetupMagicLink(data MagicLinkData) error {
 // ValidateMagicLink validates a magic link code and returns a session token on
 // success
 func (u User) ValidateMagicLink(email, code string) (string, error) {
-	email = strings.ToLower(email)
-
-	val, err := Cache.Get("ml-" + email)
+	tokens, err := u.LoginWithMagicLink(email, code, "", "")
 	if err != nil {
 		return "", err
 	}
+	return tokens.Token, nil
+}
 
-	parts := strings.Split(val, " ")
-	if len(parts) != 2 {
-		return "", errors.New("invalid code")
-	}
+// LoginWithMagicLink validates a magic link code and starts a session
+// returning its access and refresh tokens
+func (u User) LoginWithMagicLink(email, code, userAgent, ip string) (model.AuthTokens, error) {
+	email = strings.ToLower(email)
 
-	// if the code isn't what was set we make sure they're not trying to
-	// "brute force" random code.
-	if parts[0] != code {
-		if len(parts[1]) >= 10 {
-			return "", errors.New("maximum retry reached")
-		}
+	if err := u.checkAttempts(email, ip); err != nil {
+		return model.AuthTokens{}, err
+	}
 
-		if err := Cache.Set("ml-"+email, val+"a"); err != nil {
-			return "", err
+	val, err := Cache.Get("ml-" + email)
+	if err != nil || subtle.ConstantTimeCompare([]byte(val), []byte(code)) != 1 {
+		if err := u.failAttempt(email, ip); err != nil {
+			return model.AuthTokens{}, err
 		}
+		return model.AuthTokens{}, errors.New("invalid code")
 	}
 
-	// they got the right code, return a session token
+	// a magic link can only be used once
+	if err := Cache.Del("ml-" + email); err != nil {
+		return model.AuthTokens{}, err
+	}
 
-	tok, err := DB.FindUserByEmail(u.conf.Name, email)
-	if err != nil {
-		return "", err
+	if err := u.clearAttempts(email); err != nil {
+		return model.AuthTokens{}, err
 	}
 
-	jwtBytes, err := u.GetAuthToken(tok)
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
 	if err != nil {
-		return "", err
+		return model.AuthTokens{}, err
 	}
 
-	return string(jwtBytes), nil
+	return u.signIn(tok, userAgent, ip)
 }
@@ -0,0 +1,468 @@
+package backend
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// UserDataExport bundles a user's data into a downloadable archive
+	UserDataExport = "export"
+	// UserDataErase removes a user's data across collections and storage
+	UserDataErase = "erase"
+
+	// statuses of a user data job
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+var (
+	// ErrNoUserDataJob is returned when no export or erase job ran for a
+	// user recently
+	ErrNoUserDataJob = errors.New("no job found for this user")
+	// ErrUserNotFound is returned when the user ID does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserDataJobRunning is returned when starting a job while the same
+	// kind of job is still running for the user
+	ErrUserDataJobRunning = errors.New("a job is already running for this user")
+)
+
+// UserDataJob is the status of a background export or erase of a user's data
+type UserDataJob struct {
+	UserID  string    `json:"userId"`
+	Kind    string    `json:"kind"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Key     string    `json:"key,omitempty"`
+	Started time.Time `json:"started"`
+	Ended   time.Time `json:"ended"`
+
+	Documents   int64 `json:"documents"`
+	Files       int   `json:"files"`
+	Submissions int   `json:"submissions"`
+}
+
+// UpdateProfile sets the display name, avatar file and custom profile fields
+// of a user. The avatar must be a file uploaded in the user's account.
+func (u User) UpdateProfile(userID string, p model.UserProfile) (user model.User, err error) {
+	p.DisplayName = strings.TrimSpace(p.DisplayName)
+	if len(p.DisplayName) > 100 {
+		err = errors.New("the display name cannot exceed 100 characters")
+		return
+	}
+
+	if p.Profile == nil {
+		p.Profile = make(map[string]interface{})
+	}
+
+	user, err = u.findUser(userID)
+	if err != nil {
+		return
+	}
+
+	if len(p.AvatarID) > 0 {
+		f, err := DB.GetFileByID(u.conf.Name, p.AvatarID)
+		if err != nil || f.AccountID != user.AccountID {
+			return user, errors.New("invalid avatar file")
+		}
+	}
+
+	if err = DB.UpdateUserProfile(u.conf.Name, userID, p); err != nil {
+		return
+	}
+
+	user.UserProfile = p
+	return
+}
+
+// ExportUserData starts a background job bundling the documents a user
+// created, the files they uploaded and the form submissions holding their
+// email into a zip archive. The files uploaded before the uploader was
+// recorded are included when the user's documents reference them. The
+// archive is private, it's read with OpenUserDataExport once the job is done.
+func (u User) ExportUserData(userID string) (UserDataJob, error) {
+	return u.startUserDataJob(userID, UserDataExport, u.exportUserData)
+}
+
+// EraseUserData starts a background job removing the documents a user created
+// in all collections, the files they uploaded from storage (as found for the
+// export), the form submissions holding their email, their last export
+// archive and their profile fields. The user itself is kept.
+func (u User) EraseUserData(userID string) (UserDataJob, error) {
+	return u.startUserDataJob(userID, UserDataErase, u.eraseUserData)
+}
+
+// GetUserDataJob returns the status of the last export or erase job of a user
+func (u User) GetUserDataJob(userID, kind string) (job UserDataJob, err error) {
+	if err = Cache.GetTyped(u.userDataJobKey(userID, kind), &job); err != nil || len(job.UserID) == 0 {
+		return job, ErrNoUserDataJob
+	}
+	return
+}
+
+// OpenUserDataExport returns the archive of the user's last export
+func (u User) OpenUserDataExport(userID string) (io.ReadCloser, error) {
+	job, err := u.GetUserDataJob(userID, UserDataExport)
+	if err != nil {
+		return nil, err
+	} else if job.Status != JobDone || len(job.Key) == 0 {
+		return nil, ErrNoUserDataJob
+	}
+
+	return Filestore.Get(job.Key)
+}
+
+func (u User) startUserDataJob(userID, kind string, run func(model.User, *UserDataJob) error) (job UserDataJob, err error) {
+	user, err := u.findUser(userID)
+	if err != nil {
+		return
+	}
+
+	last, lastErr := u.GetUserDataJob(userID, kind)
+	if lastErr == nil && last.Status == JobRunning {
+		return last, ErrUserDataJobRunning
+	}
+
+	job = UserDataJob{
+		UserID: user.ID,
+		Kind:   kind,
+		Status: JobRunning,
+		// keeps track of the previous archive until it's replaced
+		Key:     last.Key,
+		Started: time.Now(),
+	}
+
+	key := u.userDataJobKey(userID, kind)
+	if err = Cache.SetTyped(key, job); err != nil {
+		return
+	}
+
+	go func(job UserDataJob) {
+		if err := run(user, &job); err != nil {
+			Log.Error().Err(err).Msgf("error running user data %s for %s", kind, user.ID)
+
+			job.Status = JobFailed
+			job.Error = err.Error()
+		} else {
+			job.Status = JobDone
+		}
+
+		job.Ended = time.Now()
+		if err := Cache.SetTyped(key, job); err != nil {
+			Log.Error().Err(err).Msg("error saving user data job status")
+		}
+	}(job)
+
+	return
+}
+
+// exportUserData streams the archive to a temporary file so it's never held
+// in memory, then stores it privately
+func (u User) exportUserData(user model.User, job *UserDataJob) error {
+	f, err := os.CreateTemp("", "sb-userdata-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	if err := u.writeUserData(zw, user, job); err != nil {
+		return err
+	} else if err := zw.Close(); err != nil {
+		return err
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// an export replaces the previous archive of this user
+	if len(job.Key) > 0 {
+		if err := Filestore.Delete(job.Key); err != nil {
+			Log.Warn().Err(err).Msg("cannot delete previous user data export")
+		}
+	}
+
+	job.Key = fmt.Sprintf("%s/exports/%s_%s.zip", u.conf.Name, user.ID, internal.RandStringRunes(16))
+
+	upData := model.UploadFileData{
+		FileKey:  job.Key,
+		File:     f,
+		Size:     size,
+		Mimetype: "application/zip",
+		Private:  true,
+	}
+	_, err = Filestore.Save(upData)
+	return err
+}
+
+func (u User) writeUserData(zw *zip.Writer, user model.User, job *UserDataJob) error {
+	// the session token is not part of the user's data
+	user.Token = ""
+	if err := writeJSON(zw, "user.json", user); err != nil {
+		return err
+	}
+
+	cols, err := u.userCollections()
+	if err != nil {
+		return err
+	}
+
+	refs := make(map[string]bool)
+	for _, col := range cols {
+		docs, err := DB.ListDocumentsByOwner(u.conf.Name, col, user.ID)
+		if err != nil {
+			return err
+		} else if len(docs) == 0 {
+			continue
+		}
+
+		if err := writeNDJSON(zw, "documents/"+col+".ndjson", docs); err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			collectStrings(doc, refs)
+		}
+		job.Documents += int64(len(docs))
+	}
+
+	files, err := u.userFiles(user, refs)
+	if err != nil {
+		return err
+	}
+
+	if len(files) > 0 {
+		if err := writeJSON(zw, "files.json", files); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		if err := copyFile(zw, "files/"+f.ID+filepath.Ext(f.Key), f.Key); err != nil {
+			return err
+		}
+	}
+	job.Files = len(files)
+
+	entries, err := DB.ListFormSubmissionsByEmail(u.conf.Name, user.Email)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) > 0 {
+		if err := writeNDJSON(zw, "forms.ndjson", entries); err != nil {
+			return err
+		}
+	}
+	job.Submissions = len(entries)
+	return nil
+}
+
+func (u User) eraseUserData(user model.User, job *UserDataJob) error {
+	cols, err := u.userCollections()
+	if err != nil {
+		return err
+	}
+
+	// the files referenced by the documents are found before they're erased
+	refs, err := u.documentReferences(user, cols)
+	if err != nil {
+		return err
+	}
+
+	files, err := u.userFiles(user, refs)
+	if err != nil {
+		return err
+	}
+
+	for _, col := range cols {
+		n, err := DB.DeleteDocumentsByOwner(u.conf.Name, col, user.ID)
+		if err != nil {
+			return err
+		}
+
+		job.Documents += n
+	}
+
+	fs := newFile(model.Auth{}, u.conf)
+	for _, f := range files {
+		if err := fs.Delete(f.ID); err != nil {
+			return err
+		}
+	}
+	job.Files = len(files)
+
+	n, err := DB.DeleteFormSubmissionsByEmail(u.conf.Name, user.Email)
+	if err != nil {
+		return err
+	}
+	job.Submissions = int(n)
+
+	if last, err := u.GetUserDataJob(user.ID, UserDataExport); err == nil && len(last.Key) > 0 {
+		if err := Filestore.Delete(last.Key); err != nil {
+			return err
+		}
+
+		if err := Cache.Del(u.userDataJobKey(user.ID, UserDataExport)); err != nil {
+			return err
+		}
+	}
+
+	return DB.UpdateUserProfile(u.conf.Name, user.ID, model.UserProfile{
+		Profile: make(map[string]interface{}),
+	})
+}
+
+func (u User) findUser(userID string) (model.User, error) {
+	user, err := DB.FindUserByID(u.conf.Name, userID)
+	if err != nil || len(user.ID) == 0 {
+		return user, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// userCollections lists the collections holding documents, the system
+// collections are skipped
+func (u User) userCollections() (cols []string, err error) {
+	names, err := DB.ListCollections(u.conf.Name)
+	if err != nil {
+		return
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(name, "sb_") {
+			continue
+		}
+		cols = append(cols, name)
+	}
+	return
+}
+
+// userFiles returns the files uploaded by the user and their avatar. The
+// files uploaded before the uploader was recorded are the user's when one of
+// the user's documents references them by ID or URL.
+func (u User) userFiles(user model.User, refs map[string]bool) (files []model.File, err error) {
+	all, err := DB.ListAllFiles(u.conf.Name, user.AccountID)
+	if err != nil {
+		return
+	}
+
+	for _, f := range all {
+		switch {
+		case f.UserID == user.ID,
+			len(user.AvatarID) > 0 && f.ID == user.AvatarID,
+			len(f.UserID) == 0 && (refs[f.ID] || refs[f.URL]):
+			files = append(files, f)
+		}
+	}
+	return
+}
+
+// documentReferences returns the string values of the user's documents
+func (u User) documentReferences(user model.User, cols []string) (map[string]bool, error) {
+	refs := make(map[string]bool)
+	for _, col := range cols {
+		docs, err := DB.ListDocumentsByOwner(u.conf.Name, col, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			collectStrings(doc, refs)
+		}
+	}
+	return refs, nil
+}
+
+// collectStrings adds the string values found in v to refs, the engines may
+// return their own map and slice types
+func collectStrings(v any, refs map[string]bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		if rv.Len() > 0 {
+			refs[rv.String()] = true
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			collectStrings(iter.Value().Interface(), refs)
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// binary data
+			return
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			collectStrings(rv.Index(i).Interface(), refs)
+		}
+	}
+}
+
+func (u User) userDataJobKey(userID, kind string) string {
+	return fmt.Sprintf("userdata_%s_%s_%s", kind, u.conf.Name, userID)
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeNDJSON writes one JSON document per line
+func writeNDJSON(zw *zip.Writer, name string, docs []map[string]interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(zw *zip.Writer, name, key string) error {
+	rc, err := Filestore.Get(key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, rc)
+	return err
+}
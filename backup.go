@@ -0,0 +1,93 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// sudoBackups lists the backups with GET /sudo/backups, takes a backup now
+// with POST /sudo/backups, restores one with POST /sudo/backups/{id}/restore
+// and deletes one with DELETE /sudo/backups/{id}
+func sudoBackups(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := getURLPart(r.URL.Path, 3)
+	action := getURLPart(r.URL.Path, 4)
+
+	switch {
+	case r.Method == http.MethodGet && len(id) == 0:
+		list, err := backend.ListBackups(conf.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, list)
+	case r.Method == http.MethodPost && len(id) == 0:
+		var data struct {
+			Blobs bool `json:"blobs"`
+		}
+		if r.ContentLength > 0 {
+			if err := parseBody(r.Body, &data); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		b, err := backend.BackupDatabase(conf.Name, "", model.BackupPolicy{Blobs: data.Blobs}, true)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusCreated, b)
+	case r.Method == http.MethodPost && action == "restore":
+		var data struct {
+			Name string `json:"name"`
+		}
+		if r.ContentLength > 0 {
+			if err := parseBody(r.Body, &data); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		restored, err := backend.RestoreBackup(conf.Name, id, data.Name)
+		if err != nil {
+			http.Error(w, err.Error(), backupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, restored)
+	case r.Method == http.MethodDelete && len(id) > 0:
+		if err := backend.DeleteBackup(conf.Name, id); err != nil {
+			http.Error(w, err.Error(), backupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func backupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrBackupNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, backend.ErrInvalidDatabaseName):
+		return http.StatusBadRequest
+	case errors.Is(err, backend.ErrDatabaseExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
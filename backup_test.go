@@ -0,0 +1,139 @@
+package staticbackend
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/storage"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	add := func(title string) string {
+		resp := dbReq(t, db.add, "POST", "/db/backuptests", map[string]any{"title": title})
+		if resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+
+		var doc map[string]any
+		if err := parseBody(resp.Body, &doc); err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprintf("%v", doc["id"])
+	}
+
+	kept := add("before backup")
+
+	resp := dbReq(t, sudoBackups, "POST", "/sudo/backups", nil, true)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var b model.Backup
+	if err := parseBody(resp.Body, &b); err != nil {
+		t.Fatal(err)
+	} else if !b.Manual || b.Rows == 0 {
+		t.Fatalf("unexpected backup %v", b)
+	}
+
+	lost := add("after backup")
+
+	resp = dbReq(t, sudoBackups, "GET", "/sudo/backups", nil, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var list []model.Backup
+	if err := parseBody(resp.Body, &list); err != nil {
+		t.Fatal(err)
+	} else if len(list) == 0 || list[0].ID != b.ID {
+		t.Fatalf("expected the backup in the list got %v", list)
+	}
+
+	invalid := map[string]string{"name": "Not Valid"}
+	if resp := dbReq(t, sudoBackups, "POST", "/sudo/backups/"+b.ID+"/restore", invalid, true); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid name got %s", GetResponseBody(t, resp))
+	}
+
+	if resp := dbReq(t, sudoBackups, "POST", "/sudo/backups/unknown/restore", nil, true); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown backup got %s", GetResponseBody(t, resp))
+	}
+
+	// restoring in a new database
+	resp = dbReq(t, sudoBackups, "POST", "/sudo/backups/"+b.ID+"/restore", map[string]string{"name": "restoredcopy"}, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var copied model.DatabaseConfig
+	if err := parseBody(resp.Body, &copied); err != nil {
+		t.Fatal(err)
+	} else if copied.Name != "restoredcopy" || copied.ID == conf.ID {
+		t.Fatalf("expected a new database with a new public key got %v", copied)
+	}
+
+	auth := model.Auth{AccountID: testAccountID, Role: 100}
+	if _, err := backend.DB.GetDocumentByID(auth, copied.Name, "backuptests", kept); err != nil {
+		t.Errorf("expected the backed up document in the copy got %v", err)
+	}
+	if _, err := backend.DB.GetDocumentByID(auth, copied.Name, "backuptests", lost); err == nil {
+		t.Error("expected the document created after the backup to be missing in the copy")
+	}
+
+	// restoring in place
+	resp = dbReq(t, sudoBackups, "POST", "/sudo/backups/"+b.ID+"/restore", nil, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if _, err := backend.DB.GetDocumentByID(auth, conf.Name, "backuptests", lost); err == nil {
+		t.Error("expected the document created after the backup to be removed")
+	}
+
+	list, err = backend.ListBackups(conf.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 2 || !list[0].Manual {
+		t.Errorf("expected a backup taken before restoring got %v", list)
+	}
+
+	for _, x := range list {
+		if resp := dbReq(t, sudoBackups, "DELETE", "/sudo/backups/"+x.ID, nil, true); resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+	}
+
+	if err := backend.DB.DeleteDatabase(copied.Name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalFilesHidePrivate(t *testing.T) {
+	key := "backuptests/backups/index.json"
+
+	up := model.UploadFileData{FileKey: key, File: strings.NewReader("[]"), Private: true}
+	if _, err := (storage.Local{}).Save(up); err != nil {
+		t.Fatal(err)
+	}
+	defer (storage.Local{}).Delete(key)
+
+	h := http.StripPrefix("/localfs/", localFiles(http.FileServer(http.Dir(os.TempDir()))))
+
+	for _, p := range []string{"/localfs/sb-private/" + key, "/localfs/x/../sb-private/" + key} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404 got %d", p, w.Code)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package staticbackend
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+// sudoCache reads and writes the database's cache keys, type selects the
+// kind of value: "" for a string, "queue", "hash" or "zset". GET also
+// accepts the "exists" and "ttl" types and POST the "expire", "zincr" and
+// "ack" types. DELETE removes a key or the field/member of a hash/zset.
+//
+// The queues are reliable, GET leases the next item which must be
+// acknowledged with its receipt before its visibility timeout or it becomes
+// available again.
+// The op parameter returns the queue's "stats", "items" or "dead" letters
+// instead.
+func sudoCache(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		qs := r.URL.Query()
+		key := fmt.Sprintf("%s_%s", conf.Name, qs.Get("key"))
+
+		var val any
+		switch qs.Get("type") {
+		case "queue":
+			val, err = queueGet(key, qs)
+			if errors.Is(err, errInvalidQueueParam) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case "exists":
+			val, err = backend.Cache.Exists(key)
+		case "ttl":
+			var ttl time.Duration
+			ttl, err = backend.Cache.TTL(key)
+			// in seconds, 0 means the key never expires
+			val = int64(math.Ceil(ttl.Seconds()))
+		case "hash":
+			if field := qs.Get("field"); len(field) > 0 {
+				val, err = backend.Cache.HGet(key, field)
+			} else {
+				val, err = backend.Cache.HGetAll(key)
+			}
+		case "zset":
+			if member := qs.Get("member"); len(member) > 0 {
+				val, err = backend.Cache.ZScore(key, member)
+				break
+			}
+
+			start, stop := int64(0), int64(-1)
+			if v := qs.Get("start"); len(v) > 0 {
+				start, err = strconv.ParseInt(v, 10, 64)
+			}
+			if v := qs.Get("stop"); len(v) > 0 && err == nil {
+				stop, err = strconv.ParseInt(v, 10, 64)
+			}
+			if err != nil {
+				http.Error(w, "start and stop must be integers", http.StatusBadRequest)
+				return
+			}
+
+			val, err = backend.Cache.ZRange(key, start, stop, qs.Get("rev") == "true")
+		default:
+			val, err = backend.Cache.Get(key)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, val)
+	} else if r.Method == http.MethodPost {
+		data := new(struct {
+			Key    string  `json:"key"`
+			Value  string  `json:"value"`
+			Type   string  `json:"type"`
+			TTL    int64   `json:"ttl"`
+			NX     bool    `json:"nx"`
+			Field  string  `json:"field"`
+			Member string  `json:"member"`
+			Score  float64 `json:"score"`
+			Delay  int64   `json:"delay"`
+			// Receipt is the lease receipt of the item to acknowledge
+			Receipt string `json:"receipt"`
+		})
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data.Key = fmt.Sprintf("%s_%s", conf.Name, data.Key)
+		ttl := time.Duration(data.TTL) * time.Second
+
+		var val any = true
+		switch data.Type {
+		case "queue":
+			val, err = backend.Cache.Enqueue(data.Key, data.Value, time.Duration(data.Delay)*time.Second)
+		case "ack":
+			val, err = backend.Cache.Ack(data.Key, data.Receipt)
+		case "expire":
+			val, err = backend.Cache.Expire(data.Key, ttl)
+		case "hash":
+			err = backend.Cache.HSet(data.Key, data.Field, data.Value)
+		case "zset":
+			err = backend.Cache.ZAdd(data.Key, data.Member, data.Score)
+		case "zincr":
+			val, err = backend.Cache.ZIncrBy(data.Key, data.Member, data.Score)
+		default:
+			if data.NX {
+				val, err = backend.Cache.SetNX(data.Key, data.Value, ttl)
+			} else if ttl > 0 {
+				err = backend.Cache.SetEx(data.Key, data.Value, ttl)
+			} else {
+				err = backend.Cache.Set(data.Key, data.Value)
+			}
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, val)
+	} else if r.Method == http.MethodDelete {
+		qs := r.URL.Query()
+		key := fmt.Sprintf("%s_%s", conf.Name, qs.Get("key"))
+
+		switch qs.Get("type") {
+		case "hash":
+			err = backend.Cache.HDel(key, qs.Get("field"))
+		case "zset":
+			err = backend.Cache.ZRem(key, qs.Get("member"))
+		default:
+			err = backend.Cache.Del(key)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	}
+}
+
+var errInvalidQueueParam = errors.New("visibility and maxAttempts must be integers")
+
+// queueGet leases the next item of a queue or returns its stats, items or
+// dead letters based on the op parameter
+func queueGet(key string, qs url.Values) (any, error) {
+	switch qs.Get("op") {
+	case "stats":
+		return backend.Cache.QueueStats(key)
+	case "items":
+		return backend.Cache.QueueItems(key)
+	case "dead":
+		return backend.Cache.DeadLetters(key)
+	}
+
+	// visibility in seconds, 0 for the default
+	var visibility, maxAttempts int
+	var err error
+	if v := qs.Get("visibility"); len(v) > 0 {
+		if visibility, err = strconv.Atoi(v); err != nil {
+			return nil, errInvalidQueueParam
+		}
+	}
+	if v := qs.Get("maxAttempts"); len(v) > 0 {
+		if maxAttempts, err = strconv.Atoi(v); err != nil {
+			return nil, errInvalidQueueParam
+		}
+	}
+
+	return backend.Cache.Lease(key, time.Duration(visibility)*time.Second, maxAttempts)
+}
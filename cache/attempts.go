@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"strconv"
+	"time"
+)
+
+// AttemptPolicy configures the progressive delays and lockouts applied after
+// failed attempts
+type AttemptPolicy struct {
+	// FreeAttempts is the number of failures allowed before delays apply
+	FreeAttempts int
+	// MaxAttempts is the number of failures within Window that locks a key
+	MaxAttempts int
+	// Window is how long failures are remembered after the last one
+	Window time.Duration
+	// BaseDelay is the delay after the first failure past FreeAttempts, it
+	// doubles for each following failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutDuration is how long a key is locked, it doubles for each
+	// consecutive lockout up to MaxLockout
+	LockoutDuration time.Duration
+	MaxLockout      time.Duration
+}
+
+// DefaultAttemptPolicy is used to track sign-in attempts
+var DefaultAttemptPolicy = AttemptPolicy{
+	FreeAttempts:    3,
+	MaxAttempts:     10,
+	Window:          15 * time.Minute,
+	BaseDelay:       time.Second,
+	MaxDelay:        30 * time.Second,
+	LockoutDuration: 15 * time.Minute,
+	MaxLockout:      12 * time.Hour,
+}
+
+// Attempts is the failed attempts state of a key
+type Attempts struct {
+	Failures    int       `json:"failures"`
+	Lockouts    int       `json:"lockouts"`
+	LastFailure time.Time `json:"lastFailure"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// Locked returns true when the key is locked at t
+func (a Attempts) Locked(t time.Time) bool {
+	return t.Before(a.LockedUntil)
+}
+
+// AttemptTracker records failed attempts for keys (i.e. an email or an IP)
+// in a Volatilizer and tells how long callers must wait before trying again
+type AttemptTracker struct {
+	Cache  Volatilizer
+	Policy AttemptPolicy
+}
+
+// NewAttemptTracker returns an AttemptTracker using the policy
+func NewAttemptTracker(c Volatilizer, policy AttemptPolicy) AttemptTracker {
+	return AttemptTracker{Cache: c, Policy: policy}
+}
+
+// lockState is the part of Attempts only written by the failure locking the
+// key, the failures are counted atomically in their own keys
+type lockState struct {
+	Lockouts    int       `json:"lockouts"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+func (s lockState) Locked(t time.Time) bool {
+	return t.Before(s.LockedUntil)
+}
+
+func failuresKey(key string) string {
+	return key + ":failures"
+}
+
+func lastFailureKey(key string) string {
+	return key + ":last"
+}
+
+// Get returns the attempts of a key, a key without failures returns an empty
+// Attempts
+func (t AttemptTracker) Get(key string) (Attempts, error) {
+	var a Attempts
+
+	var state lockState
+	if err := t.Cache.GetTyped(key, &state); err == nil {
+		a.Lockouts = state.Lockouts
+		a.LockedUntil = state.LockedUntil
+	}
+
+	// the failures expire once the window passed since the last one
+	if v, err := t.Cache.Get(failuresKey(key)); err == nil {
+		a.Failures, _ = strconv.Atoi(v)
+	}
+
+	if v, err := t.Cache.Get(lastFailureKey(key)); err == nil {
+		a.LastFailure, _ = time.Parse(time.RFC3339Nano, v)
+	}
+
+	if a.LastFailure.IsZero() {
+		a.Failures = 0
+	}
+	return a, nil
+}
+
+// Wait returns how long to wait before the next attempt for a key, 0 when an
+// attempt is allowed now
+func (t AttemptTracker) Wait(key string) (time.Duration, error) {
+	a, err := t.Get(key)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	if a.Locked(now) {
+		return a.LockedUntil.Sub(now), nil
+	}
+
+	delay := t.delay(a.Failures)
+	if wait := a.LastFailure.Add(delay).Sub(now); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// Fail records a failed attempt for a key, locked is true when this failure
+// locked the key. The failures are counted with Inc so concurrent failures
+// are all counted and only one of them locks the key.
+func (t AttemptTracker) Fail(key string) (a Attempts, locked bool, err error) {
+	now := time.Now()
+
+	var state lockState
+	if err := t.Cache.GetTyped(key, &state); err != nil {
+		state = lockState{}
+	}
+
+	n, err := t.count(key)
+	if err != nil {
+		return
+	}
+
+	// a failure after an expired lockout starts a new series
+	if !state.LockedUntil.IsZero() && !state.Locked(now) && n > int64(t.Policy.MaxAttempts) {
+		if err = t.Cache.Del(failuresKey(key)); err != nil {
+			return
+		}
+
+		if n, err = t.count(key); err != nil {
+			return
+		}
+	}
+
+	if err = t.Cache.SetEx(lastFailureKey(key), now.Format(time.RFC3339Nano), t.Policy.Window); err != nil {
+		return
+	}
+
+	if n == int64(t.Policy.MaxAttempts) && !state.Locked(now) {
+		state.Lockouts++
+		state.LockedUntil = now.Add(backoff(t.Policy.LockoutDuration, state.Lockouts-1, t.Policy.MaxLockout))
+		if err = t.Cache.SetTyped(key, state); err != nil {
+			return
+		}
+		locked = true
+	}
+
+	a = Attempts{
+		Failures:    int(n),
+		Lockouts:    state.Lockouts,
+		LastFailure: now,
+		LockedUntil: state.LockedUntil,
+	}
+	return
+}
+
+// count increments the failures of a key and extends their window
+func (t AttemptTracker) count(key string) (int64, error) {
+	n, err := t.Cache.Inc(failuresKey(key), 1)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := t.Cache.Expire(failuresKey(key), t.Policy.Window); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Reset clears the attempts of a key, usually after a successful attempt
+func (t AttemptTracker) Reset(key string) error {
+	for _, k := range []string{key, failuresKey(key), lastFailureKey(key)} {
+		if err := t.Cache.Del(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t AttemptTracker) delay(failures int) time.Duration {
+	if failures <= t.Policy.FreeAttempts {
+		return 0
+	}
+	return backoff(t.Policy.BaseDelay, failures-t.Policy.FreeAttempts-1, t.Policy.MaxDelay)
+}
+
+// backoff returns base doubled n times without exceeding max
+func backoff(base time.Duration, n int, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+
+	if d > max {
+		return max
+	}
+	return d
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAttemptTracker(t *testing.T) {
+	policy := AttemptPolicy{
+		FreeAttempts:    2,
+		MaxAttempts:     4,
+		Window:          time.Minute,
+		BaseDelay:       10 * time.Millisecond,
+		MaxDelay:        time.Second,
+		LockoutDuration: 50 * time.Millisecond,
+		MaxLockout:      time.Second,
+	}
+
+	tracker := NewAttemptTracker(devCache, policy)
+	key := "attempts-test:user@test.com"
+
+	for i := 0; i < policy.FreeAttempts; i++ {
+		if _, locked, err := tracker.Fail(key); err != nil {
+			t.Fatal(err)
+		} else if locked {
+			t.Fatal("expected key not to be locked")
+		}
+	}
+
+	if wait, err := tracker.Wait(key); err != nil {
+		t.Fatal(err)
+	} else if wait != 0 {
+		t.Errorf("expected no delay within the free attempts got %v", wait)
+	}
+
+	if _, _, err := tracker.Fail(key); err != nil {
+		t.Fatal(err)
+	}
+
+	if wait, err := tracker.Wait(key); err != nil {
+		t.Fatal(err)
+	} else if wait <= 0 || wait > policy.BaseDelay {
+		t.Errorf("expected a delay up to %v got %v", policy.BaseDelay, wait)
+	}
+
+	a, locked, err := tracker.Fail(key)
+	if err != nil {
+		t.Fatal(err)
+	} else if !locked || a.Lockouts != 1 {
+		t.Fatalf("expected key to be locked once got %v", a)
+	}
+
+	if wait, err := tracker.Wait(key); err != nil {
+		t.Fatal(err)
+	} else if wait <= policy.BaseDelay*2 {
+		t.Errorf("expected to wait for the lockout got %v", wait)
+	}
+
+	time.Sleep(policy.LockoutDuration)
+
+	// failures after the lockout expired start over
+	if a, locked, err := tracker.Fail(key); err != nil {
+		t.Fatal(err)
+	} else if locked || a.Failures != 1 {
+		t.Errorf("expected a new series of failures got %v", a)
+	}
+
+	if err := tracker.Reset(key); err != nil {
+		t.Fatal(err)
+	}
+
+	if a, err := tracker.Get(key); err != nil {
+		t.Fatal(err)
+	} else if a.Failures != 0 || a.Lockouts != 0 {
+		t.Errorf("expected no failures after reset got %v", a)
+	}
+}
+
+func TestAttemptTrackerConcurrentFailures(t *testing.T) {
+	policy := AttemptPolicy{
+		FreeAttempts:    2,
+		MaxAttempts:     10,
+		Window:          time.Minute,
+		BaseDelay:       time.Millisecond,
+		MaxDelay:        time.Second,
+		LockoutDuration: time.Minute,
+		MaxLockout:      time.Hour,
+	}
+
+	tracker := NewAttemptTracker(devCache, policy)
+	key := "attempts-test:concurrent"
+	defer tracker.Reset(key)
+
+	var wg sync.WaitGroup
+	var locks int32
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, locked, err := tracker.Fail(key)
+			if err != nil {
+				t.Error(err)
+			} else if locked {
+				atomic.AddInt32(&locks, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	a, err := tracker.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	} else if a.Failures != 25 {
+		t.Errorf("expected 25 failures got %d", a.Failures)
+	} else if locks != 1 || a.Lockouts != 1 || !a.Locked(time.Now()) {
+		t.Errorf("expected one lockout got %d locks and %v", locks, a)
+	}
+}
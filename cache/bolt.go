@@ -0,0 +1,770 @@
+package cache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/staticbackendhq/core/cache/observer"
+	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/model"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltKeys     = []byte("keys")
+	boltQueues   = []byte("queues")
+	boltReliable = []byte("reliable")
+	boltItems    = []byte("items")
+	boltDead     = []byte("dead")
+)
+
+// CacheBolt is an embedded on-disk Volatilizer for single node deployments,
+// the values survive a restart and the pub/sub is in-process
+type CacheBolt struct {
+	DB       *bolt.DB
+	log      *logger.Logger
+	observer observer.Observer
+	done     chan struct{}
+}
+
+// NewBoltCache opens or creates the cache file and removes its expired keys,
+// they are then removed by a background sweeper
+func NewBoltCache(file string, log *logger.Logger) (*CacheBolt, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltQueues); err != nil {
+			return err
+		} else if _, err := tx.CreateBucketIfNotExists(boltReliable); err != nil {
+			return err
+		}
+
+		_, err := tx.CreateBucketIfNotExists(boltKeys)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	b := &CacheBolt{
+		DB:       db,
+		log:      log,
+		observer: observer.NewObserver(log),
+		done:     make(chan struct{}),
+	}
+
+	if err := b.purge(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	go b.sweep()
+
+	return b, nil
+}
+
+// Close stops the sweeper and closes the cache file
+func (b *CacheBolt) Close() error {
+	close(b.done)
+	return b.DB.Close()
+}
+
+// sweep purges the expired keys every sweepInterval until the cache is closed
+func (b *CacheBolt) sweep() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.purge(); err != nil {
+				b.log.Error().Err(err).Msg("error removing the expired cache keys")
+			}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// purge removes the expired keys
+func (b *CacheBolt) purge() error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltKeys)
+
+		// deleting while iterating with ForEach is not supported
+		var expired [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			if e, err := decodeEntry(v); err != nil || e.expired() {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// get returns the entry of a key, the key is not found if it has expired
+func (b *CacheBolt) get(tx *bolt.Tx, key string) (entry, bool) {
+	v := tx.Bucket(boltKeys).Get([]byte(key))
+	if v == nil {
+		return entry{}, false
+	}
+
+	e, err := decodeEntry(v)
+	if err != nil || e.expired() {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func (b *CacheBolt) put(tx *bolt.Tx, key string, e entry) error {
+	return tx.Bucket(boltKeys).Put([]byte(key), e.encode())
+}
+
+// Get gets a value by its id
+func (b *CacheBolt) Get(key string) (val string, err error) {
+	err = b.DB.View(func(tx *bolt.Tx) error {
+		e, ok := b.get(tx, key)
+		if !ok {
+			return errKeyNotFound
+		}
+
+		val = e.Value
+		return nil
+	})
+	return
+}
+
+// Set sets a value for a key
+func (b *CacheBolt) Set(key string, value string) error {
+	return b.SetEx(key, value, defaultExpiration)
+}
+
+// SetEx sets a value for a key expiring after ttl
+func (b *CacheBolt) SetEx(key string, value string, ttl time.Duration) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		return b.put(tx, key, newEntry(value, ttl))
+	})
+}
+
+// SetNX sets the value if the key does not exist or has expired
+func (b *CacheBolt) SetNX(key string, value string, ttl time.Duration) (ok bool, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		if _, found := b.get(tx, key); found {
+			return nil
+		}
+
+		ok = true
+		return b.put(tx, key, newEntry(value, ttl))
+	})
+	return
+}
+
+// Del removes a key
+func (b *CacheBolt) Del(key string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltKeys).Delete([]byte(key))
+	})
+}
+
+// Exists returns true if the key exists and has not expired
+func (b *CacheBolt) Exists(key string) (ok bool, err error) {
+	err = b.DB.View(func(tx *bolt.Tx) error {
+		_, ok = b.get(tx, key)
+		return nil
+	})
+	return
+}
+
+// Expire sets or removes the key expiration
+func (b *CacheBolt) Expire(key string, ttl time.Duration) (ok bool, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		e, found := b.get(tx, key)
+		if !found {
+			return nil
+		}
+
+		ok = true
+		return b.put(tx, key, newEntry(e.Value, ttl))
+	})
+	return
+}
+
+// TTL returns the remaining time to live of a key
+func (b *CacheBolt) TTL(key string) (ttl time.Duration, err error) {
+	err = b.DB.View(func(tx *bolt.Tx) error {
+		e, ok := b.get(tx, key)
+		if !ok {
+			return errKeyNotFound
+		}
+
+		ttl = e.ttl()
+		return nil
+	})
+	return
+}
+
+// GetTyped retrives the value for a key and unmarshal the JSON value into the
+// interface
+func (b *CacheBolt) GetTyped(key string, v any) error {
+	val, err := b.Get(key)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(val), v)
+}
+
+// SetTyped converts the interface into JSON before storing its string value
+func (b *CacheBolt) SetTyped(key string, v any) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return b.Set(key, string(buf))
+}
+
+// Inc increments a value (atomic in a write transaction)
+func (b *CacheBolt) Inc(key string, by int64) (n int64, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		// like Redis, a missing key starts at 0 and the expiration is kept
+		e, ok := b.get(tx, key)
+		if ok {
+			n, err = strconv.ParseInt(e.Value, 10, 64)
+			if err != nil {
+				return err
+			}
+		}
+
+		n += by
+
+		e.Value = strconv.FormatInt(n, 10)
+		return b.put(tx, key, e)
+	})
+	return
+}
+
+// Dec decrements a value (atomic in a write transaction)
+func (b *CacheBolt) Dec(key string, by int64) (int64, error) {
+	return b.Inc(key, -1*by)
+}
+
+// HSet sets the field of a hash (stored as a JSON object)
+func (b *CacheBolt) HSet(key, field, value string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		e, _ := b.get(tx, key)
+		h, err := decodeHash(e)
+		if err != nil {
+			return err
+		}
+
+		h[field] = value
+
+		e.Value = encodeValue(h)
+		return b.put(tx, key, e)
+	})
+}
+
+// HGet returns the field of a hash
+func (b *CacheBolt) HGet(key, field string) (val string, err error) {
+	h, err := b.HGetAll(key)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := h[field]
+	if !ok {
+		return "", errKeyNotFound
+	}
+	return val, nil
+}
+
+// HDel removes the field of a hash, the hash is removed with its last field
+func (b *CacheBolt) HDel(key, field string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		e, ok := b.get(tx, key)
+		if !ok {
+			return nil
+		}
+
+		h, err := decodeHash(e)
+		if err != nil {
+			return err
+		}
+
+		delete(h, field)
+		if len(h) == 0 {
+			return tx.Bucket(boltKeys).Delete([]byte(key))
+		}
+
+		e.Value = encodeValue(h)
+		return b.put(tx, key, e)
+	})
+}
+
+// HGetAll returns all the fields of a hash
+func (b *CacheBolt) HGetAll(key string) (h map[string]string, err error) {
+	err = b.DB.View(func(tx *bolt.Tx) error {
+		e, _ := b.get(tx, key)
+		h, err = decodeHash(e)
+		return err
+	})
+	return
+}
+
+// ZAdd adds or updates a member of a sorted set (stored as a JSON object)
+func (b *CacheBolt) ZAdd(key, member string, score float64) error {
+	_, err := b.zupdate(key, member, func(float64) float64 { return score })
+	return err
+}
+
+// ZIncrBy increments the score of a member of a sorted set
+func (b *CacheBolt) ZIncrBy(key, member string, by float64) (float64, error) {
+	return b.zupdate(key, member, func(score float64) float64 { return score + by })
+}
+
+// zupdate sets the score of a member from its current score
+func (b *CacheBolt) zupdate(key, member string, fn func(float64) float64) (score float64, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		e, _ := b.get(tx, key)
+		z, err := decodeZSet(e)
+		if err != nil {
+			return err
+		}
+
+		score = fn(z[member])
+		z[member] = score
+
+		e.Value = encodeValue(z)
+		return b.put(tx, key, e)
+	})
+	return
+}
+
+// ZScore returns the score of a member of a sorted set
+func (b *CacheBolt) ZScore(key, member string) (float64, error) {
+	z, err := b.zset(key)
+	if err != nil {
+		return 0, err
+	}
+
+	score, ok := z[member]
+	if !ok {
+		return 0, errKeyNotFound
+	}
+	return score, nil
+}
+
+// ZRem removes a member of a sorted set, the set is removed with its last
+// member
+func (b *CacheBolt) ZRem(key, member string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		e, ok := b.get(tx, key)
+		if !ok {
+			return nil
+		}
+
+		z, err := decodeZSet(e)
+		if err != nil {
+			return err
+		}
+
+		delete(z, member)
+		if len(z) == 0 {
+			return tx.Bucket(boltKeys).Delete([]byte(key))
+		}
+
+		e.Value = encodeValue(z)
+		return b.put(tx, key, e)
+	})
+}
+
+// ZRange returns the members between start and stop ordered by score
+func (b *CacheBolt) ZRange(key string, start, stop int64, reverse bool) ([]ScoredMember, error) {
+	z, err := b.zset(key)
+	if err != nil {
+		return nil, err
+	}
+	return rangeZSet(z, start, stop, reverse), nil
+}
+
+func (b *CacheBolt) zset(key string) (z map[string]float64, err error) {
+	err = b.DB.View(func(tx *bolt.Tx) error {
+		e, _ := b.get(tx, key)
+		z, err = decodeZSet(e)
+		return err
+	})
+	return
+}
+
+// Subscribe subscribes to a topic to receive messages on system/user events
+func (b *CacheBolt) Subscribe(send chan model.Command, token, channel string, close chan bool) {
+	pubsub := b.observer.Subscribe(channel)
+
+	ch := pubsub.Channel()
+
+	for {
+		select {
+		case m := <-ch:
+			var msg model.Command
+			if err := json.Unmarshal([]byte(m.(string)), &msg); err != nil {
+				b.log.Error().Err(err).Msg("error parsing JSON message")
+				_ = pubsub.Close()
+				_ = b.observer.Unsubscribe(channel, pubsub)
+				return
+			}
+
+			if !receive(&msg, token, channel, b.HasPermission) {
+				continue
+			}
+			send <- msg
+		case <-close:
+			_ = pubsub.Close()
+			_ = b.observer.Unsubscribe(channel, pubsub)
+			return
+		}
+	}
+}
+
+// Publish sends a message and all subscribers will receive it if they're
+// subscribed to that topic
+func (b *CacheBolt) Publish(msg model.Command) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// Publish the event to system so server-side function can trigger
+	// but only for non system msg
+	if !msg.IsSystemEvent && msg.Channel != "sbsys" {
+		go func(sysmsg model.Command) {
+			sysmsg.IsSystemEvent = true
+			buf, err := json.Marshal(sysmsg)
+			if err != nil {
+				b.log.Error().Err(err).Msg("error marshaling the system msg")
+				return
+			}
+			if err := b.observer.Publish("sbsys", string(buf)); err != nil {
+				b.log.Error().Err(err).Msg("error occurred during publishing to 'sbsys' channel")
+			}
+		}(msg)
+	}
+
+	if count := b.observer.PubNumSub(msg.Channel)[msg.Channel]; count == 0 {
+		return nil
+	}
+	return b.observer.Publish(msg.Channel, string(buf))
+}
+
+// PublishDocument publishes a database update message (created, updated, deleted)
+// All subscribers will get notified
+func (b *CacheBolt) PublishDocument(auth model.Auth, dbName, channel, typ string, v any) {
+	msg, err := documentMessage(auth, dbName, channel, typ, v)
+	if err != nil {
+		b.log.Error().Err(err).Msg("error publishing db doc")
+		return
+	}
+
+	if err := b.Publish(msg); err != nil {
+		b.log.Error().Err(err).Msg("unable to publish db doc events")
+	}
+}
+
+// HasPermission determines if a session token has permission to a collection
+func (b *CacheBolt) HasPermission(token, repo, payload string) bool {
+	return hasPermission(b.GetTyped, b.log, token, repo, payload)
+}
+
+// QueueWork appends the value to a bucket keyed by an increasing sequence
+func (b *CacheBolt) QueueWork(key, value string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		q, err := tx.Bucket(boltQueues).CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+
+		seq, err := q.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		id := make([]byte, 8)
+		binary.BigEndian.PutUint64(id, seq)
+		return q.Put(id, []byte(value))
+	})
+}
+
+// DequeueWork removes and returns the oldest value of the queue, it returns
+// an empty string when the queue is empty
+func (b *CacheBolt) DequeueWork(key string) (val string, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		q := tx.Bucket(boltQueues).Bucket([]byte(key))
+		if q == nil {
+			return nil
+		}
+
+		c := q.Cursor()
+		k, v := c.First()
+		if k == nil {
+			return nil
+		}
+
+		val = string(v)
+		return c.Delete()
+	})
+	return
+}
+
+// reliable returns the items and dead letters buckets of a reliable queue,
+// they are created in a write transaction and nil if the queue does not
+// exist in a read transaction
+func (b *CacheBolt) reliable(tx *bolt.Tx, key string) (items, dead *bolt.Bucket, err error) {
+	root := tx.Bucket(boltReliable)
+	if !tx.Writable() {
+		q := root.Bucket([]byte(key))
+		if q == nil {
+			return nil, nil, nil
+		}
+		return q.Bucket(boltItems), q.Bucket(boltDead), nil
+	}
+
+	q, err := root.CreateBucketIfNotExists([]byte(key))
+	if err != nil {
+		return
+	} else if items, err = q.CreateBucketIfNotExists(boltItems); err != nil {
+		return
+	}
+
+	dead, err = q.CreateBucketIfNotExists(boltDead)
+	return
+}
+
+// itemID returns the bucket key of a queue item id
+func itemID(id string) ([]byte, error) {
+	seq, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return k, nil
+}
+
+// Enqueue adds an item to a reliable queue keyed by an increasing sequence
+func (b *CacheBolt) Enqueue(key, value string, delay time.Duration) (id string, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		items, _, err := b.reliable(tx, key)
+		if err != nil {
+			return err
+		}
+
+		seq, err := items.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		id = strconv.FormatUint(seq, 10)
+		k, _ := itemID(id)
+
+		buf, err := json.Marshal(newQueueItem(id, value, delay))
+		if err != nil {
+			return err
+		}
+		return items.Put(k, buf)
+	})
+	return
+}
+
+// Lease leases the oldest available item of a reliable queue
+func (b *CacheBolt) Lease(key string, visibility time.Duration, maxAttempts int) (leased *QueueItem, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		items, dead, err := b.reliable(tx, key)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		// the dead items are moved once the cursor is done
+		var exhausted [][]byte
+		defer func() {
+			for _, k := range exhausted {
+				_ = items.Delete(k)
+			}
+		}()
+
+		c := items.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var it QueueItem
+			if err := json.Unmarshal(v, &it); err != nil {
+				return err
+			} else if !it.available(now) {
+				continue
+			}
+
+			if !it.lease(now, visibilityOrDefault(visibility), maxAttempts) {
+				if err := dead.Put(k, v); err != nil {
+					return err
+				}
+				exhausted = append(exhausted, k)
+				continue
+			}
+
+			buf, err := json.Marshal(it)
+			if err != nil {
+				return err
+			}
+
+			leased = &it
+			return items.Put(k, buf)
+		}
+		return nil
+	})
+	return
+}
+
+// Ack removes a leased item given its lease receipt
+func (b *CacheBolt) Ack(key, receipt string) (ok bool, err error) {
+	id, valid := receiptID(receipt)
+	if !valid {
+		return false, nil
+	}
+
+	k, err := itemID(id)
+	if err != nil {
+		return false, nil
+	}
+
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		items, _, err := b.reliable(tx, key)
+		if err != nil {
+			return err
+		}
+
+		v := items.Get(k)
+		if v == nil {
+			return nil
+		}
+
+		var it QueueItem
+		if err := json.Unmarshal(v, &it); err != nil {
+			return err
+		} else if !it.acknowledges(receipt) {
+			return nil
+		}
+
+		ok = true
+		return items.Delete(k)
+	})
+	return
+}
+
+// QueueStats counts the items of a reliable queue by state
+func (b *CacheBolt) QueueStats(key string) (stats QueueStats, err error) {
+	items, err := b.QueueItems(key)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, it := range items {
+		stats.count(it, now)
+	}
+
+	err = b.DB.View(func(tx *bolt.Tx) error {
+		if _, dead, _ := b.reliable(tx, key); dead != nil {
+			stats.Dead = int64(dead.Stats().KeyN)
+		}
+		return nil
+	})
+	return
+}
+
+// QueueItems returns the items of a reliable queue
+func (b *CacheBolt) QueueItems(key string) ([]QueueItem, error) {
+	return b.queueItems(key, false)
+}
+
+// DeadLetters returns the items that exceeded their max attempts
+func (b *CacheBolt) DeadLetters(key string) ([]QueueItem, error) {
+	return b.queueItems(key, true)
+}
+
+func (b *CacheBolt) queueItems(key string, deadLetters bool) (list []QueueItem, err error) {
+	list = make([]QueueItem, 0)
+	err = b.DB.View(func(tx *bolt.Tx) error {
+		items, dead, _ := b.reliable(tx, key)
+		if deadLetters {
+			items = dead
+		}
+		if items == nil {
+			return nil
+		}
+
+		return items.ForEach(func(_, v []byte) error {
+			var it QueueItem
+			if err := json.Unmarshal(v, &it); err != nil {
+				return err
+			}
+
+			list = append(list, it)
+			return nil
+		})
+	})
+	return
+}
+
+// AcquireLock sets the key if it does not exist or has expired
+func (b *CacheBolt) AcquireLock(key, owner string, ttl time.Duration) (ok bool, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		if _, held := b.get(tx, key); held {
+			return nil
+		}
+
+		ok = true
+		return b.put(tx, key, newEntry(owner, ttl))
+	})
+	return
+}
+
+// RenewLock extends the lock expiration if owner still holds it
+func (b *CacheBolt) RenewLock(key, owner string, ttl time.Duration) (ok bool, err error) {
+	err = b.DB.Update(func(tx *bolt.Tx) error {
+		if e, held := b.get(tx, key); !held || e.Value != owner {
+			return nil
+		}
+
+		ok = true
+		return b.put(tx, key, newEntry(owner, ttl))
+	})
+	return
+}
+
+// ReleaseLock removes the lock if owner still holds it
+func (b *CacheBolt) ReleaseLock(key, owner string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		if e, held := b.get(tx, key); !held || e.Value != owner {
+			return nil
+		}
+
+		return tx.Bucket(boltKeys).Delete([]byte(key))
+	})
+}
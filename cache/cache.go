@@ -4,7 +4,8 @@ package cache
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/staticbackendhq/core/config"
@@ -55,12 +56,63 @@ func (c *Cache) Get(key string) (string, error) {
 
 // Set sets a value for a key
 func (c *Cache) Set(key string, value string) error {
-	if _, err := c.Rdb.Set(c.Ctx, key, value, 12*time.Hour).Result(); err != nil {
+	if _, err := c.Rdb.Set(c.Ctx, key, value, defaultExpiration).Result(); err != nil {
 		return err
 	}
 	return nil
 }
 
+// SetEx sets a value for a key expiring after ttl
+func (c *Cache) SetEx(key string, value string, ttl time.Duration) error {
+	return c.Rdb.Set(c.Ctx, key, value, ttl).Err()
+}
+
+// SetNX uses Redis's SET NX
+func (c *Cache) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return c.Rdb.SetNX(c.Ctx, key, value, ttl).Result()
+}
+
+// Del removes a key
+func (c *Cache) Del(key string) error {
+	return c.Rdb.Del(c.Ctx, key).Err()
+}
+
+// Exists returns true if the key exists
+func (c *Cache) Exists(key string) (bool, error) {
+	n, err := c.Rdb.Exists(c.Ctx, key).Result()
+	return n > 0, err
+}
+
+// Expire sets the key expiration or persists the key when ttl is 0
+func (c *Cache) Expire(key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		// PERSIST returns false when the key had no expiration
+		ok, err := c.Exists(key)
+		if err != nil || !ok {
+			return ok, err
+		}
+		return true, c.Rdb.Persist(c.Ctx, key).Err()
+	}
+	return c.Rdb.PExpire(c.Ctx, key, ttl).Result()
+}
+
+// TTL returns the remaining time to live of a key
+func (c *Cache) TTL(key string) (time.Duration, error) {
+	ttl, err := c.Rdb.PTTL(c.Ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// Redis returns -2 for a missing key and -1 for a key without expiration
+	switch ttl {
+	case -2:
+		return 0, errKeyNotFound
+	case -1:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
 // GetTyped retrives the value for a key and unmarshal the JSON value into the
 // interface
 func (c *Cache) GetTyped(key string, v interface{}) error {
@@ -91,6 +143,66 @@ func (c *Cache) Dec(key string, by int64) (int64, error) {
 	return c.Rdb.DecrBy(c.Ctx, key, by).Result()
 }
 
+// HSet sets the field of a hash
+func (c *Cache) HSet(key, field, value string) error {
+	return c.Rdb.HSet(c.Ctx, key, field, value).Err()
+}
+
+// HGet returns the field of a hash
+func (c *Cache) HGet(key, field string) (string, error) {
+	return c.Rdb.HGet(c.Ctx, key, field).Result()
+}
+
+// HDel removes the field of a hash
+func (c *Cache) HDel(key, field string) error {
+	return c.Rdb.HDel(c.Ctx, key, field).Err()
+}
+
+// HGetAll returns all the fields of a hash
+func (c *Cache) HGetAll(key string) (map[string]string, error) {
+	return c.Rdb.HGetAll(c.Ctx, key).Result()
+}
+
+// ZAdd adds or updates a member of a sorted set
+func (c *Cache) ZAdd(key, member string, score float64) error {
+	return c.Rdb.ZAdd(c.Ctx, key, &redis.Z{Score: score, Member: member}).Err()
+}
+
+// ZIncrBy increments the score of a member of a sorted set
+func (c *Cache) ZIncrBy(key, member string, by float64) (float64, error) {
+	return c.Rdb.ZIncrBy(c.Ctx, key, by, member).Result()
+}
+
+// ZScore returns the score of a member of a sorted set
+func (c *Cache) ZScore(key, member string) (float64, error) {
+	return c.Rdb.ZScore(c.Ctx, key, member).Result()
+}
+
+// ZRem removes a member of a sorted set
+func (c *Cache) ZRem(key, member string) error {
+	return c.Rdb.ZRem(c.Ctx, key, member).Err()
+}
+
+// ZRange returns the members between start and stop ordered by score
+func (c *Cache) ZRange(key string, start, stop int64, reverse bool) ([]ScoredMember, error) {
+	var zs []redis.Z
+	var err error
+	if reverse {
+		zs, err = c.Rdb.ZRevRangeWithScores(c.Ctx, key, start, stop).Result()
+	} else {
+		zs, err = c.Rdb.ZRangeWithScores(c.Ctx, key, start, stop).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ScoredMember, 0, len(zs))
+	for _, z := range zs {
+		members = append(members, ScoredMember{Member: z.Member.(string), Score: z.Score})
+	}
+	return members, nil
+}
+
 // Subscribe subscribes to a topic to receive messages on system/user events
 func (c *Cache) Subscribe(send chan model.Command, token, channel string, close chan bool) {
 	pubsub := c.Rdb.Subscribe(c.Ctx, channel)
@@ -111,12 +223,7 @@ func (c *Cache) Subscribe(send chan model.Command, token, channel string, close
 				return
 			}
 
-			// TODO: this will need more thinking
-			if msg.Type == model.MsgTypeChanIn {
-				msg.Type = model.MsgTypeChanOut
-			} else if msg.IsSystemEvent {
-
-			} else if msg.IsDBEvent() && !c.HasPermission(token, channel, msg.Data) {
+			if !receive(&msg, token, channel, c.HasPermission) {
 				continue
 			}
 			send <- msg
@@ -177,20 +284,12 @@ func (c *Cache) Publish(msg model.Command) error {
 // PublishDocument publishes a database update message (created, updated, deleted)
 // All subscribers will get notified
 func (c *Cache) PublishDocument(auth model.Auth, dbName, channel, typ string, v interface{}) {
-	b, err := json.Marshal(v)
+	msg, err := documentMessage(auth, dbName, channel, typ, v)
 	if err != nil {
 		c.log.Error().Err(err).Msg("error publishing db doc")
 		return
 	}
 
-	msg := model.Command{
-		Channel: channel,
-		Data:    string(b),
-		Type:    typ,
-		Auth:    auth,
-		Base:    dbName,
-	}
-
 	if err := c.Publish(msg); err != nil {
 		c.log.Error().Err(err).Msg("unable to publish db doc events")
 	}
@@ -198,42 +297,7 @@ func (c *Cache) PublishDocument(auth model.Auth, dbName, channel, typ string, v
 
 // HasPermission determines if a session token has permission to a collection
 func (c *Cache) HasPermission(token, repo, payload string) bool {
-	// sbsys is a reserved channel used internally, no need to check for
-	// permissions
-	if repo == "sbsys" {
-		return true
-	}
-
-	var me model.Auth
-	if err := c.GetTyped(token, &me); err != nil {
-		return false
-	}
-
-	docs := make(map[string]interface{})
-	if err := json.Unmarshal([]byte(payload), &docs); err != nil {
-		c.log.Error().Err(err).Msg("error decoding docs for permissions check")
-
-		return false
-	}
-
-	switch internal.ReadPermission(repo) {
-	case internal.PermGroup:
-		acctID, ok := docs["accountId"]
-		if !ok {
-			return false
-		}
-
-		return fmt.Sprintf("%v", acctID) == me.AccountID
-	case internal.PermOwner:
-		owner, ok := docs["ownerId"]
-		if !ok {
-			return false
-		}
-
-		return fmt.Sprintf("%v", owner) == me.UserID
-	default:
-		return true
-	}
+	return hasPermission(c.GetTyped, c.log, token, repo, payload)
 }
 
 // QueueWork uses Redis's LIST (atomic) as a work queue
@@ -255,3 +319,239 @@ func (c *Cache) DequeueWork(key string) (string, error) {
 
 	return val, nil
 }
+
+// a reliable queue uses a hash of items (without their visibility), a sorted
+// set of the waiting items and one of the leased items both scored by the
+// time they become visible in milliseconds, and a list of dead letters. The
+// queue name is a hash tag so the scripts' keys share a Redis Cluster slot.
+func reliableKeys(key string) []string {
+	tag := "{" + key + "}"
+	return []string{tag + ":items", tag + ":waiting", tag + ":leased", tag + ":dead", tag + ":seq"}
+}
+
+// the expired leases are moved back to the waiting items before leasing the
+// first visible item
+var leaseScript = redis.NewScript(`
+local expired = redis.call("ZRANGEBYSCORE", KEYS[3], "-inf", ARGV[1])
+for _, id in ipairs(expired) do
+	redis.call("ZREM", KEYS[3], id)
+	redis.call("ZADD", KEYS[2], ARGV[1], id)
+end
+
+while true do
+	local ids = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1], "LIMIT", 0, 1)
+	if #ids == 0 then
+		return false
+	end
+
+	local id = ids[1]
+	redis.call("ZREM", KEYS[2], id)
+
+	local raw = redis.call("HGET", KEYS[1], id)
+	if raw then
+		local item = cjson.decode(raw)
+		if tonumber(ARGV[3]) > 0 and item.attempts >= tonumber(ARGV[3]) then
+			redis.call("HDEL", KEYS[1], id)
+			redis.call("RPUSH", KEYS[4], raw)
+		else
+			item.attempts = item.attempts + 1
+			item.leased = true
+			item.receipt = id .. ":" .. ARGV[4]
+			raw = cjson.encode(item)
+			redis.call("HSET", KEYS[1], id, raw)
+			redis.call("ZADD", KEYS[3], ARGV[2], id)
+			return raw
+		end
+	end
+end
+`)
+
+// the item is only acknowledged with the receipt of its current lease
+var ackScript = redis.NewScript(`
+local raw = redis.call("HGET", KEYS[1], ARGV[1])
+if not raw then
+	return 0
+end
+
+local item = cjson.decode(raw)
+if not item.leased or item.receipt ~= ARGV[2] then
+	return 0
+end
+
+redis.call("HDEL", KEYS[1], ARGV[1])
+redis.call("ZREM", KEYS[2], ARGV[1])
+redis.call("ZREM", KEYS[3], ARGV[1])
+return 1
+`)
+
+// Enqueue adds an item to a reliable queue
+func (c *Cache) Enqueue(key, value string, delay time.Duration) (string, error) {
+	keys := reliableKeys(key)
+
+	seq, err := c.Rdb.Incr(c.Ctx, keys[4]).Result()
+	if err != nil {
+		return "", err
+	}
+
+	it := newQueueItem(strconv.FormatInt(seq, 10), value, delay)
+
+	_, err = c.Rdb.TxPipelined(c.Ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(c.Ctx, keys[0], it.ID, encodeValue(it))
+		pipe.ZAdd(c.Ctx, keys[1], &redis.Z{Score: float64(it.VisibleAt.UnixMilli()), Member: it.ID})
+		return nil
+	})
+	return it.ID, err
+}
+
+// Lease leases the first visible item of a reliable queue (atomic via a
+// script)
+func (c *Cache) Lease(key string, visibility time.Duration, maxAttempts int) (*QueueItem, error) {
+	now := time.Now()
+	expires := now.Add(visibilityOrDefault(visibility))
+
+	// the receipt is the item's ID and this nonce
+	nonce := internal.RandStringRunes(16)
+
+	raw, err := leaseScript.Run(c.Ctx, c.Rdb, reliableKeys(key)[:4], now.UnixMilli(), expires.UnixMilli(), maxAttempts, nonce).Text()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var it QueueItem
+	if err := json.Unmarshal([]byte(raw), &it); err != nil {
+		return nil, err
+	}
+
+	it.VisibleAt = time.UnixMilli(expires.UnixMilli())
+	return &it, nil
+}
+
+// Ack removes a leased item given its lease receipt (atomic via a script)
+func (c *Cache) Ack(key, receipt string) (bool, error) {
+	id, ok := receiptID(receipt)
+	if !ok {
+		return false, nil
+	}
+
+	n, err := ackScript.Run(c.Ctx, c.Rdb, reliableKeys(key)[:3], id, receipt).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// QueueStats counts the items of a reliable queue by state
+func (c *Cache) QueueStats(key string) (stats QueueStats, err error) {
+	keys := reliableKeys(key)
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	var ready, expired, delayed, leased *redis.IntCmd
+	var dead *redis.IntCmd
+	_, err = c.Rdb.Pipelined(c.Ctx, func(pipe redis.Pipeliner) error {
+		ready = pipe.ZCount(c.Ctx, keys[1], "-inf", now)
+		delayed = pipe.ZCount(c.Ctx, keys[1], "("+now, "+inf")
+		expired = pipe.ZCount(c.Ctx, keys[2], "-inf", now)
+		leased = pipe.ZCount(c.Ctx, keys[2], "("+now, "+inf")
+		dead = pipe.LLen(c.Ctx, keys[3])
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// an expired lease is available again
+	stats.Ready = ready.Val() + expired.Val()
+	stats.Delayed = delayed.Val()
+	stats.Leased = leased.Val()
+	stats.Dead = dead.Val()
+	return
+}
+
+// QueueItems returns the items of a reliable queue ordered by visibility
+func (c *Cache) QueueItems(key string) ([]QueueItem, error) {
+	keys := reliableKeys(key)
+
+	var visible []redis.Z
+	for _, k := range keys[1:3] {
+		zs, err := c.Rdb.ZRangeWithScores(c.Ctx, k, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		visible = append(visible, zs...)
+	}
+
+	sort.Slice(visible, func(i, j int) bool { return visible[i].Score < visible[j].Score })
+
+	items := make([]QueueItem, 0, len(visible))
+	for _, z := range visible {
+		raw, err := c.Rdb.HGet(c.Ctx, keys[0], z.Member.(string)).Result()
+		if err == redis.Nil {
+			// acknowledged in the meantime
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var it QueueItem
+		if err := json.Unmarshal([]byte(raw), &it); err != nil {
+			return nil, err
+		}
+
+		it.VisibleAt = time.UnixMilli(int64(z.Score))
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// DeadLetters returns the items that exceeded their max attempts
+func (c *Cache) DeadLetters(key string) ([]QueueItem, error) {
+	list, err := c.Rdb.LRange(c.Ctx, reliableKeys(key)[3], 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]QueueItem, 0, len(list))
+	for _, raw := range list {
+		var it QueueItem
+		if err := json.Unmarshal([]byte(raw), &it); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// the lock is renewed or released only by its owner
+var (
+	renewLockScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	end
+	return 0
+	`)
+
+	releaseLockScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0
+	`)
+)
+
+// AcquireLock uses Redis's SET NX with an expiration
+func (c *Cache) AcquireLock(key, owner string, ttl time.Duration) (bool, error) {
+	return c.Rdb.SetNX(c.Ctx, key, owner, ttl).Result()
+}
+
+// RenewLock extends the lock expiration if owner still holds it
+func (c *Cache) RenewLock(key, owner string, ttl time.Duration) (bool, error) {
+	n, err := renewLockScript.Run(c.Ctx, c.Rdb, []string{key}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// ReleaseLock removes the lock if owner still holds it
+func (c *Cache) ReleaseLock(key, owner string) error {
+	return releaseLockScript.Run(c.Ctx, c.Rdb, []string{key}, owner).Err()
+}
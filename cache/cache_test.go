@@ -3,6 +3,8 @@ package cache
 import (
 	"encoding/json"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,7 +67,7 @@ func TestCacheSubscribe(t *testing.T) {
 			timer := time.NewTimer(5 * time.Second)
 			select {
 			case res := <-receiver:
-				if res != payload {
+				if !reflect.DeepEqual(res, payload) {
 					t.Error("Incorrect message is received")
 				}
 				break
@@ -109,7 +111,7 @@ func TestCacheSubscribeOnDBEvent(t *testing.T) {
 			defer timer.Stop()
 			select {
 			case res := <-receiver:
-				if res != payload {
+				if !reflect.DeepEqual(res, payload) {
 					t.Error("Incorrect message is received")
 				}
 				break
@@ -217,3 +219,65 @@ func TestCachePublishDocument(t *testing.T) {
 		})
 	}
 }
+
+func TestCacheLock(t *testing.T) {
+	tests := []suite{
+		{name: "lock with redis cache", cache: redisCache},
+		{name: "lock with dev mem cache", cache: devCache},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key := "unittest:lock"
+			ttl := 200 * time.Millisecond
+
+			if ok, err := tc.cache.AcquireLock(key, "a", ttl); err != nil {
+				t.Fatal(err)
+			} else if !ok {
+				t.Fatal("expected to acquire the lock")
+			}
+
+			if ok, err := tc.cache.AcquireLock(key, "b", ttl); err != nil {
+				t.Fatal(err)
+			} else if ok {
+				t.Fatal("expected the lock to be held")
+			}
+
+			// only the owner renews and releases the lock
+			if ok, err := tc.cache.RenewLock(key, "b", ttl); err != nil {
+				t.Fatal(err)
+			} else if ok {
+				t.Error("expected the renewal by another owner to fail")
+			}
+			if err := tc.cache.ReleaseLock(key, "b"); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := tc.cache.RenewLock(key, "a", ttl); err != nil {
+				t.Fatal(err)
+			} else if !ok {
+				t.Error("expected the owner to renew the lock")
+			}
+
+			// the lock can be acquired once expired
+			time.Sleep(ttl + 50*time.Millisecond)
+
+			if ok, err := tc.cache.AcquireLock(key, "b", ttl); err != nil {
+				t.Fatal(err)
+			} else if !ok {
+				t.Fatal("expected to acquire the expired lock")
+			}
+			if err := tc.cache.ReleaseLock(key, "b"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestReliableKeysShareAClusterSlot(t *testing.T) {
+	// Redis Cluster only hashes the {tag} of a key, the scripts' keys must
+	// be in the same slot
+	for _, k := range reliableKeys("db_jobs") {
+		if !strings.HasPrefix(k, "{db_jobs}:") {
+			t.Errorf("expected %s to be tagged with the queue name", k)
+		}
+	}
+}
@@ -0,0 +1,543 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/model"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+)
+
+// startNATS runs an embedded NATS server with JetStream storing in dir
+func startNATS(dir string) (*natsserver.Server, error) {
+	srv, err := natsserver.NewServer(&natsserver.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  dir,
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go srv.Start()
+
+	if !srv.ReadyForConnections(5 * time.Second) {
+		return nil, errors.New("the NATS server did not start")
+	}
+	return srv, nil
+}
+
+// newConformanceSuites opens the embedded and NATS implementations in dir
+func newConformanceSuites(dir string, log *logger.Logger) ([]suite, func(), error) {
+	bc, err := NewBoltCache(filepath.Join(dir, "sb.cache"), log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	srv, err := startNATS(filepath.Join(dir, "jetstream"))
+	if err != nil {
+		bc.Close()
+		return nil, nil, err
+	}
+
+	nc, err := NewNATSCache(srv.ClientURL(), log)
+	if err != nil {
+		bc.Close()
+		srv.Shutdown()
+		return nil, nil, err
+	}
+
+	suites := []suite{
+		{name: "redis", cache: redisCache},
+		{name: "dev", cache: devCache},
+		{name: "embedded", cache: bc},
+		{name: "nats", cache: nc},
+	}
+
+	closeAll := func() {
+		nc.Close()
+		srv.Shutdown()
+		bc.Close()
+	}
+	return suites, closeAll, nil
+}
+
+// TestConformance runs the same suite against every Volatilizer
+func TestConformance(t *testing.T) {
+	suites, closeAll, err := newConformanceSuites(t.TempDir(), devCache.log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeAll()
+
+	for _, tc := range suites {
+		t.Run(tc.name, func(t *testing.T) {
+			testVolatilizer(t, tc.cache)
+		})
+	}
+}
+
+func testVolatilizer(t *testing.T, c Volatilizer) {
+	prefix := fmt.Sprintf("conformance:%d:", time.Now().UnixNano())
+
+	t.Run("get set del", func(t *testing.T) {
+		key := prefix + "key"
+
+		if _, err := c.Get(key); err == nil {
+			t.Fatal("expected an error getting a missing key")
+		}
+
+		if err := c.Set(key, "value"); err != nil {
+			t.Fatal(err)
+		}
+		if v, err := c.Get(key); err != nil {
+			t.Fatal(err)
+		} else if v != "value" {
+			t.Errorf("expected value got %s", v)
+		}
+
+		if err := c.Del(key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Get(key); err == nil {
+			t.Error("expected the key to be deleted")
+		}
+	})
+
+	t.Run("typed", func(t *testing.T) {
+		key := prefix + "typed"
+
+		if err := c.SetTyped(key, adminAuth); err != nil {
+			t.Fatal(err)
+		}
+
+		var auth model.Auth
+		if err := c.GetTyped(key, &auth); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(auth, adminAuth) {
+			t.Errorf("expected %v got %v", adminAuth, auth)
+		}
+	})
+
+	t.Run("inc dec", func(t *testing.T) {
+		key := prefix + "counter"
+
+		if n, err := c.Inc(key, 5); err != nil {
+			t.Fatal(err)
+		} else if n != 5 {
+			t.Errorf("expected 5 got %d", n)
+		}
+		if n, err := c.Inc(key, 2); err != nil {
+			t.Fatal(err)
+		} else if n != 7 {
+			t.Errorf("expected 7 got %d", n)
+		}
+		if n, err := c.Dec(key, 10); err != nil {
+			t.Fatal(err)
+		} else if n != -3 {
+			t.Errorf("expected -3 got %d", n)
+		}
+
+		if v, err := c.Get(key); err != nil {
+			t.Fatal(err)
+		} else if v != "-3" {
+			t.Errorf("expected the counter to be readable as -3 got %s", v)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		key := prefix + "expiring"
+		ttl := 200 * time.Millisecond
+
+		if err := c.SetEx(key, "value", ttl); err != nil {
+			t.Fatal(err)
+		}
+		if d, err := c.TTL(key); err != nil {
+			t.Fatal(err)
+		} else if d <= 0 || d > ttl {
+			t.Errorf("expected a ttl up to %v got %v", ttl, d)
+		}
+		if ok, err := c.SetNX(key, "other", 0); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected SetNX to fail on an existing key")
+		}
+
+		time.Sleep(ttl + 50*time.Millisecond)
+
+		if ok, err := c.Exists(key); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected the key to be expired")
+		}
+		if ok, err := c.SetNX(key, "other", 0); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Error("expected SetNX to set the expired key")
+		}
+		if d, err := c.TTL(key); err != nil {
+			t.Fatal(err)
+		} else if d != 0 {
+			t.Errorf("expected no expiration got %v", d)
+		}
+
+		if ok, err := c.Expire(key, ttl); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Error("expected the expiration to be set")
+		}
+
+		time.Sleep(ttl + 50*time.Millisecond)
+
+		if _, err := c.Get(key); err == nil {
+			t.Error("expected the key to be expired")
+		}
+		if ok, err := c.Expire(key, ttl); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected Expire to return false on a missing key")
+		}
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		key := prefix + "hash"
+
+		for field, value := range map[string]string{"a": "1", "b": "2"} {
+			if err := c.HSet(key, field, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if v, err := c.HGet(key, "b"); err != nil {
+			t.Fatal(err)
+		} else if v != "2" {
+			t.Errorf("expected 2 got %s", v)
+		}
+		if _, err := c.HGet(key, "missing"); err == nil {
+			t.Error("expected an error getting a missing field")
+		}
+
+		if err := c.HDel(key, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if h, err := c.HGetAll(key); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(h, map[string]string{"b": "2"}) {
+			t.Errorf("expected only the b field got %v", h)
+		}
+
+		if err := c.HDel(key, "b"); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := c.Exists(key); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected the hash to be removed with its last field")
+		}
+	})
+
+	t.Run("sorted set", func(t *testing.T) {
+		key := prefix + "leaderboard"
+
+		for member, score := range map[string]float64{"alice": 30, "bob": 10, "carol": 20} {
+			if err := c.ZAdd(key, member, score); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if score, err := c.ZIncrBy(key, "bob", 25); err != nil {
+			t.Fatal(err)
+		} else if score != 35 {
+			t.Errorf("expected 35 got %v", score)
+		}
+		if score, err := c.ZScore(key, "carol"); err != nil {
+			t.Fatal(err)
+		} else if score != 20 {
+			t.Errorf("expected 20 got %v", score)
+		}
+
+		top, err := c.ZRange(key, 0, 1, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []ScoredMember{{Member: "bob", Score: 35}, {Member: "alice", Score: 30}}
+		if !reflect.DeepEqual(top, expected) {
+			t.Errorf("expected %v got %v", expected, top)
+		}
+
+		if err := c.ZRem(key, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		all, err := c.ZRange(key, 0, -1, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = []ScoredMember{{Member: "carol", Score: 20}, {Member: "alice", Score: 30}}
+		if !reflect.DeepEqual(all, expected) {
+			t.Errorf("expected %v got %v", expected, all)
+		}
+	})
+
+	t.Run("publish subscribe", func(t *testing.T) {
+		receiver := make(chan model.Command)
+		closeCn := make(chan bool)
+
+		channel := prefix + "channel"
+		go c.Subscribe(receiver, "", channel, closeCn)
+		time.Sleep(50 * time.Millisecond) // need to wait for proper subscriber startup
+
+		payload := model.Command{Type: model.MsgTypeChanIn, Data: "hello", Channel: channel}
+		if err := c.Publish(payload); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case res := <-receiver:
+			if res.Data != payload.Data || res.Channel != channel {
+				t.Errorf("incorrect message received %v", res)
+			} else if res.Type != model.MsgTypeChanOut {
+				t.Errorf("expected type %s got %s", model.MsgTypeChanOut, res.Type)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the subscriber did not receive the message")
+		}
+
+		closeCn <- true
+	})
+
+	t.Run("work queue", func(t *testing.T) {
+		key := prefix + "queue"
+
+		if v, err := c.DequeueWork(key); err != nil {
+			t.Fatal(err)
+		} else if v != "" {
+			t.Errorf("expected an empty queue got %s", v)
+		}
+
+		for _, v := range []string{"first", "second", "third"} {
+			if err := c.QueueWork(key, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, expected := range []string{"first", "second", "third", ""} {
+			if v, err := c.DequeueWork(key); err != nil {
+				t.Fatal(err)
+			} else if v != expected {
+				t.Errorf("expected %q got %q", expected, v)
+			}
+		}
+	})
+
+	t.Run("reliable queue", func(t *testing.T) {
+		key := prefix + "reliable"
+		visibility := 200 * time.Millisecond
+
+		ids := make(map[string]string)
+		for _, v := range []string{"a", "b", "c"} {
+			var delay time.Duration
+			if v == "c" {
+				delay = 300 * time.Millisecond
+			}
+
+			id, err := c.Enqueue(key, v, delay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids[v] = id
+		}
+
+		expectStats := func(expected QueueStats) {
+			t.Helper()
+
+			if stats, err := c.QueueStats(key); err != nil {
+				t.Fatal(err)
+			} else if stats != expected {
+				t.Errorf("expected stats %v got %v", expected, stats)
+			}
+		}
+
+		expectStats(QueueStats{Ready: 2, Delayed: 1})
+
+		receipts := make(map[string]string)
+		for _, expected := range []string{"a", "b"} {
+			it, err := c.Lease(key, visibility, 2)
+			if err != nil {
+				t.Fatal(err)
+			} else if it == nil || it.Value != expected || it.ID != ids[expected] {
+				t.Fatalf("expected to lease %s got %v", expected, it)
+			} else if it.Attempts != 1 {
+				t.Errorf("expected 1 attempt got %d", it.Attempts)
+			} else if len(it.Receipt) == 0 {
+				t.Fatalf("expected a lease receipt for %s", expected)
+			}
+			receipts[expected] = it.Receipt
+		}
+		if it, err := c.Lease(key, visibility, 2); err != nil {
+			t.Fatal(err)
+		} else if it != nil {
+			t.Fatalf("expected the delayed item to be invisible got %v", it)
+		}
+
+		if ok, err := c.Ack(key, ids["b"]); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected the item to require its lease receipt")
+		}
+		if ok, err := c.Ack(key, receipts["b"]); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Error("expected the leased item to be acknowledged")
+		}
+		if ok, err := c.Ack(key, receipts["b"]); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected the item to be acknowledged once")
+		}
+
+		expectStats(QueueStats{Leased: 1, Delayed: 1})
+
+		// a's lease expires and c becomes visible
+		time.Sleep(350 * time.Millisecond)
+
+		leased := make(map[string]int)
+		for range 2 {
+			it, err := c.Lease(key, visibility, 2)
+			if err != nil {
+				t.Fatal(err)
+			} else if it == nil {
+				t.Fatal("expected an item to lease")
+			}
+			leased[it.Value] = it.Attempts
+		}
+		if expected := map[string]int{"a": 2, "c": 1}; !reflect.DeepEqual(leased, expected) {
+			t.Errorf("expected the leases %v got %v", expected, leased)
+		}
+
+		// a was leased again, its first lease cannot acknowledge it
+		if ok, err := c.Ack(key, receipts["a"]); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected the expired lease receipt to be refused")
+		}
+
+		time.Sleep(visibility + 50*time.Millisecond)
+
+		// a exceeds its max attempts
+		if it, err := c.Lease(key, visibility, 2); err != nil {
+			t.Fatal(err)
+		} else if it == nil || it.Value != "c" {
+			t.Fatalf("expected to lease c got %v", it)
+		}
+
+		dead, err := c.DeadLetters(key)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(dead) != 1 || dead[0].Value != "a" || dead[0].Attempts != 2 {
+			t.Errorf("expected a to be a dead letter got %v", dead)
+		}
+
+		items, err := c.QueueItems(key)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(items) != 1 || items[0].ID != ids["c"] || !items[0].Leased {
+			t.Errorf("expected only c to be leased got %v", items)
+		}
+
+		expectStats(QueueStats{Leased: 1, Dead: 1})
+	})
+
+	t.Run("lock", func(t *testing.T) {
+		key := prefix + "lock"
+		ttl := 200 * time.Millisecond
+
+		if ok, err := c.AcquireLock(key, "a", ttl); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Fatal("expected to acquire the lock")
+		}
+		if ok, err := c.AcquireLock(key, "b", ttl); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Fatal("expected the lock to be held")
+		}
+		if ok, err := c.RenewLock(key, "b", ttl); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected the renewal by another owner to fail")
+		}
+
+		time.Sleep(ttl + 50*time.Millisecond)
+
+		if ok, err := c.AcquireLock(key, "b", ttl); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Fatal("expected to acquire the expired lock")
+		}
+		if err := c.ReleaseLock(key, "b"); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := c.AcquireLock(key, "a", ttl); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Error("expected to acquire the released lock")
+		}
+	})
+}
+
+func TestEmbeddedCachePersists(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sb.cache")
+
+	bc, err := NewBoltCache(file, devCache.log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bc.SetTyped("session", adminAuth); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.QueueWork("jobs", "pending"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := bc.AcquireLock("expired", "a", time.Millisecond); err != nil || !ok {
+		t.Fatalf("unable to acquire the lock: %v", err)
+	}
+
+	if err := bc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	bc, err = NewBoltCache(file, devCache.log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Close()
+
+	var auth model.Auth
+	if err := bc.GetTyped("session", &auth); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(auth, adminAuth) {
+		t.Errorf("expected the session to survive a restart got %v", auth)
+	}
+
+	if v, err := bc.DequeueWork("jobs"); err != nil {
+		t.Fatal(err)
+	} else if v != "pending" {
+		t.Errorf("expected the queued work to survive a restart got %q", v)
+	}
+
+	if _, err := bc.Get("expired"); err == nil {
+		t.Error("expected the expired key to be removed")
+	}
+}
@@ -3,11 +3,11 @@ package cache
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/staticbackendhq/core/cache/observer"
-	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/logger"
 	"github.com/staticbackendhq/core/model"
 )
@@ -15,19 +15,74 @@ import (
 // CacheDev used in local dev mode and is memory-based
 type CacheDev struct {
 	data     map[string]string
+	hashes   map[string]map[string]string
+	zsets    map[string]map[string]float64
+	expires  map[string]time.Time
+	queues   map[string]*devQueue
 	log      *logger.Logger
 	observer observer.Observer
 	m        *sync.RWMutex
 }
 
-// NewDevCache returns a memory-based Volatilizer
+// NewDevCache returns a memory-based Volatilizer, the expired keys are
+// removed by a background sweeper
 func NewDevCache(log *logger.Logger) *CacheDev {
-	return &CacheDev{
+	d := &CacheDev{
 		data:     make(map[string]string),
+		hashes:   make(map[string]map[string]string),
+		zsets:    make(map[string]map[string]float64),
+		expires:  make(map[string]time.Time),
+		queues:   make(map[string]*devQueue),
 		observer: observer.NewObserver(log),
 		log:      log,
 		m:        &sync.RWMutex{},
 	}
+
+	go d.sweep()
+
+	return d
+}
+
+// sweep removes the expired keys every sweepInterval
+func (d *CacheDev) sweep() {
+	for range time.Tick(sweepInterval) {
+		d.m.Lock()
+		for key := range d.expires {
+			d.evict(key)
+		}
+		d.m.Unlock()
+	}
+}
+
+// evict removes the key if it has expired, the lock must be held
+func (d *CacheDev) evict(key string) {
+	if d.expired(key) {
+		d.remove(key)
+	}
+}
+
+// remove deletes the key of any type, the lock must be held
+func (d *CacheDev) remove(key string) {
+	delete(d.data, key)
+	delete(d.hashes, key)
+	delete(d.zsets, key)
+	delete(d.expires, key)
+}
+
+// exists returns true if the key of any type exists and has not expired, the
+// lock must be held
+func (d *CacheDev) exists(key string) bool {
+	if d.expired(key) {
+		return false
+	}
+
+	if _, ok := d.data[key]; ok {
+		return true
+	} else if _, ok := d.hashes[key]; ok {
+		return true
+	}
+	_, ok := d.zsets[key]
+	return ok
 }
 
 // Get gets a value by its id
@@ -36,21 +91,99 @@ func (d *CacheDev) Get(key string) (val string, err error) {
 	defer d.m.RUnlock()
 
 	val, ok := d.data[key]
-	if !ok {
-		err = errors.New("key not found in cache")
+	if !ok || d.expired(key) {
+		return "", errKeyNotFound
 	}
 	return
 }
 
 // Set sets a value for a key
 func (d *CacheDev) Set(key string, value string) error {
+	return d.SetEx(key, value, defaultExpiration)
+}
+
+// SetEx sets a value for a key expiring after ttl
+func (d *CacheDev) SetEx(key string, value string, ttl time.Duration) error {
 	d.m.Lock()
 	defer d.m.Unlock()
 
+	d.set(key, value, ttl)
+	return nil
+}
+
+// set replaces the key, the lock must be held
+func (d *CacheDev) set(key, value string, ttl time.Duration) {
+	d.remove(key)
+
 	d.data[key] = value
+	if ttl > 0 {
+		d.expires[key] = time.Now().Add(ttl)
+	}
+}
+
+// SetNX sets the value if the key does not exist
+func (d *CacheDev) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.exists(key) {
+		return false, nil
+	}
+
+	d.set(key, value, ttl)
+	return true, nil
+}
+
+// Del removes a key
+func (d *CacheDev) Del(key string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.remove(key)
 	return nil
 }
 
+// Exists returns true if the key exists
+func (d *CacheDev) Exists(key string) (bool, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	return d.exists(key), nil
+}
+
+// Expire sets or removes the key expiration
+func (d *CacheDev) Expire(key string, ttl time.Duration) (bool, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if !d.exists(key) {
+		return false, nil
+	}
+
+	if ttl > 0 {
+		d.expires[key] = time.Now().Add(ttl)
+	} else {
+		delete(d.expires, key)
+	}
+	return true, nil
+}
+
+// TTL returns the remaining time to live of a key
+func (d *CacheDev) TTL(key string) (time.Duration, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	if !d.exists(key) {
+		return 0, errKeyNotFound
+	}
+
+	exp, ok := d.expires[key]
+	if !ok {
+		return 0, nil
+	}
+	return time.Until(exp), nil
+}
+
 // GetTyped retrives the value for a key and unmarshal the JSON value into the
 func (d *CacheDev) GetTyped(key string, v any) error {
 	val, err := d.Get(key)
@@ -71,27 +204,163 @@ func (d *CacheDev) SetTyped(key string, v any) error {
 	return d.Set(key, string(b))
 }
 
-// Inc increments a value (non-atomic)
+// Inc increments a value, like Redis a missing key starts at 0 and the
+// expiration is kept
 func (d *CacheDev) Inc(key string, by int64) (n int64, err error) {
-	if err = d.GetTyped(key, &n); err != nil {
-		if err.Error() == "key not found in cache" {
-			n = 0
-		} else {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.evict(key)
+
+	if val, ok := d.data[key]; ok {
+		n, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
 			return
 		}
 	}
 
 	n += by
 
-	err = d.SetTyped(key, n)
+	d.data[key] = strconv.FormatInt(n, 10)
 	return
 }
 
-// Dec decrements a value (non-atomic)
+// Dec decrements a value
 func (d *CacheDev) Dec(key string, by int64) (int64, error) {
 	return d.Inc(key, -1*by)
 }
 
+// HSet sets the field of a hash
+func (d *CacheDev) HSet(key, field, value string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.evict(key)
+
+	h, ok := d.hashes[key]
+	if !ok {
+		h = make(map[string]string)
+		d.hashes[key] = h
+	}
+
+	h[field] = value
+	return nil
+}
+
+// HGet returns the field of a hash
+func (d *CacheDev) HGet(key, field string) (string, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	val, ok := d.hashes[key][field]
+	if !ok || d.expired(key) {
+		return "", errKeyNotFound
+	}
+	return val, nil
+}
+
+// HDel removes the field of a hash, the hash is removed with its last field
+func (d *CacheDev) HDel(key, field string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if h, ok := d.hashes[key]; ok {
+		delete(h, field)
+		if len(h) == 0 {
+			d.remove(key)
+		}
+	}
+	return nil
+}
+
+// HGetAll returns all the fields of a hash
+func (d *CacheDev) HGetAll(key string) (map[string]string, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	h := make(map[string]string)
+	if d.expired(key) {
+		return h, nil
+	}
+
+	for field, val := range d.hashes[key] {
+		h[field] = val
+	}
+	return h, nil
+}
+
+// ZAdd adds or updates a member of a sorted set
+func (d *CacheDev) ZAdd(key, member string, score float64) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.evict(key)
+
+	z, ok := d.zsets[key]
+	if !ok {
+		z = make(map[string]float64)
+		d.zsets[key] = z
+	}
+
+	z[member] = score
+	return nil
+}
+
+// ZIncrBy increments the score of a member of a sorted set
+func (d *CacheDev) ZIncrBy(key, member string, by float64) (float64, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.evict(key)
+
+	z, ok := d.zsets[key]
+	if !ok {
+		z = make(map[string]float64)
+		d.zsets[key] = z
+	}
+
+	z[member] += by
+	return z[member], nil
+}
+
+// ZScore returns the score of a member of a sorted set
+func (d *CacheDev) ZScore(key, member string) (float64, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	score, ok := d.zsets[key][member]
+	if !ok || d.expired(key) {
+		return 0, errKeyNotFound
+	}
+	return score, nil
+}
+
+// ZRem removes a member of a sorted set, the set is removed with its last
+// member
+func (d *CacheDev) ZRem(key, member string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if z, ok := d.zsets[key]; ok {
+		delete(z, member)
+		if len(z) == 0 {
+			d.remove(key)
+		}
+	}
+	return nil
+}
+
+// ZRange returns the members between start and stop ordered by score
+func (d *CacheDev) ZRange(key string, start, stop int64, reverse bool) ([]ScoredMember, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	if d.expired(key) {
+		return []ScoredMember{}, nil
+	}
+	return rangeZSet(d.zsets[key], start, stop, reverse), nil
+}
+
 // Subscribe subscribes to a topic to receive messages on system/user events
 func (d *CacheDev) Subscribe(send chan model.Command, token, channel string, close chan bool) {
 	pubsub := d.observer.Subscribe(channel)
@@ -109,12 +378,7 @@ func (d *CacheDev) Subscribe(send chan model.Command, token, channel string, clo
 				return
 			}
 
-			// TODO: this will need more thinking
-			if msg.Type == model.MsgTypeChanIn {
-				msg.Type = model.MsgTypeChanOut
-			} else if msg.IsSystemEvent {
-
-			} else if msg.IsDBEvent() && !d.HasPermission(token, channel, msg.Data) {
+			if !receive(&msg, token, channel, d.HasPermission) {
 				continue
 			}
 			send <- msg
@@ -165,20 +429,12 @@ func (d *CacheDev) Publish(msg model.Command) error {
 // PublishDocument publishes a database update message (created, updated, deleted)
 // All subscribers will get notified
 func (d *CacheDev) PublishDocument(auth model.Auth, dbName, channel, typ string, v any) {
-	b, err := json.Marshal(v)
+	msg, err := documentMessage(auth, dbName, channel, typ, v)
 	if err != nil {
 		d.log.Error().Err(err).Msg("error publishing db doc")
 		return
 	}
 
-	msg := model.Command{
-		Channel: channel,
-		Data:    string(b),
-		Type:    typ,
-		Auth:    auth,
-		Base:    dbName,
-	}
-
 	if err := d.Publish(msg); err != nil {
 		d.log.Error().Err(err).Msg("unable to publish db doc events")
 	}
@@ -186,40 +442,7 @@ func (d *CacheDev) PublishDocument(auth model.Auth, dbName, channel, typ string,
 
 // HasPermission determines if a session token has permission to a collection
 func (d *CacheDev) HasPermission(token, repo, payload string) bool {
-	if repo == "sbsys" {
-		return true
-	}
-
-	var me model.Auth
-	if err := d.GetTyped(token, &me); err != nil {
-		return false
-	}
-
-	docs := make(map[string]interface{})
-	if err := json.Unmarshal([]byte(payload), &docs); err != nil {
-		d.log.Error().Err(err).Msg("error decoding docs for permissions check")
-
-		return false
-	}
-
-	switch internal.ReadPermission(repo) {
-	case internal.PermGroup:
-		acctID, ok := docs["accountId"]
-		if !ok {
-			return false
-		}
-
-		return fmt.Sprintf("%v", acctID) == me.AccountID
-	case internal.PermOwner:
-		owner, ok := docs["ownerId"]
-		if !ok {
-			return false
-		}
-
-		return fmt.Sprintf("%v", owner) == me.UserID
-	default:
-		return true
-	}
+	return hasPermission(d.GetTyped, d.log, token, repo, payload)
 }
 
 // QueueWork uses a slice to replicate a work queue (non-atomic)
@@ -231,7 +454,8 @@ func (d *CacheDev) QueueWork(key, value string) error {
 
 	queue = append(queue, value)
 
-	return d.SetTyped(key, queue)
+	// like a Redis list, the queue does not expire
+	return d.SetEx(key, encodeValue(queue), 0)
 }
 
 // DequeueWork uses a string slice to replicate a work queue (non-atomic)
@@ -239,7 +463,10 @@ func (d *CacheDev) QueueWork(key, value string) error {
 // kind of loop
 func (d *CacheDev) DequeueWork(key string) (val string, err error) {
 	var queue []string
-	if err = d.GetTyped(key, &queue); err != nil {
+	if err = d.GetTyped(key, &queue); errors.Is(err, errKeyNotFound) {
+		// like an empty queue
+		return "", nil
+	} else if err != nil {
 		return
 	} else if len(queue) == 0 {
 		return
@@ -247,6 +474,165 @@ func (d *CacheDev) DequeueWork(key string) (val string, err error) {
 
 	val = queue[0]
 
-	err = d.SetTyped(key, queue[1:])
+	err = d.SetEx(key, encodeValue(queue[1:]), 0)
 	return
 }
+
+// devQueue holds the items of a reliable queue in their enqueue order
+type devQueue struct {
+	seq   int64
+	items []*QueueItem
+	dead  []QueueItem
+}
+
+// queue returns the reliable queue of a key, the lock must be held
+func (d *CacheDev) queue(key string) *devQueue {
+	q, ok := d.queues[key]
+	if !ok {
+		q = &devQueue{}
+		d.queues[key] = q
+	}
+	return q
+}
+
+// Enqueue adds an item to a reliable queue
+func (d *CacheDev) Enqueue(key, value string, delay time.Duration) (string, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	q := d.queue(key)
+	q.seq++
+
+	it := newQueueItem(strconv.FormatInt(q.seq, 10), value, delay)
+	q.items = append(q.items, &it)
+	return it.ID, nil
+}
+
+// Lease leases the oldest available item of a reliable queue
+func (d *CacheDev) Lease(key string, visibility time.Duration, maxAttempts int) (*QueueItem, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	q := d.queue(key)
+	now := time.Now()
+
+	for i := 0; i < len(q.items); {
+		it := q.items[i]
+		if !it.available(now) {
+			i++
+			continue
+		}
+
+		if !it.lease(now, visibilityOrDefault(visibility), maxAttempts) {
+			q.dead = append(q.dead, *it)
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			continue
+		}
+
+		leased := *it
+		return &leased, nil
+	}
+	return nil, nil
+}
+
+// Ack removes a leased item given its lease receipt
+func (d *CacheDev) Ack(key, receipt string) (bool, error) {
+	id, ok := receiptID(receipt)
+	if !ok {
+		return false, nil
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	q := d.queue(key)
+	for i, it := range q.items {
+		if it.ID == id && it.acknowledges(receipt) {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// QueueStats counts the items of a reliable queue by state
+func (d *CacheDev) QueueStats(key string) (QueueStats, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	q := d.queue(key)
+	now := time.Now()
+
+	stats := QueueStats{Dead: int64(len(q.dead))}
+	for _, it := range q.items {
+		stats.count(*it, now)
+	}
+	return stats, nil
+}
+
+// QueueItems returns the items of a reliable queue
+func (d *CacheDev) QueueItems(key string) ([]QueueItem, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	q := d.queue(key)
+
+	items := make([]QueueItem, 0, len(q.items))
+	for _, it := range q.items {
+		items = append(items, *it)
+	}
+	return items, nil
+}
+
+// DeadLetters returns the items that exceeded their max attempts
+func (d *CacheDev) DeadLetters(key string) ([]QueueItem, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	q := d.queue(key)
+	return append([]QueueItem{}, q.dead...), nil
+}
+
+// expired returns true if the key has an expiration in the past, the lock
+// must be held
+func (d *CacheDev) expired(key string) bool {
+	exp, ok := d.expires[key]
+	return ok && !time.Now().Before(exp)
+}
+
+// AcquireLock sets the key if it does not exist or has expired
+func (d *CacheDev) AcquireLock(key, owner string, ttl time.Duration) (bool, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.exists(key) {
+		return false, nil
+	}
+
+	d.set(key, owner, ttl)
+	return true, nil
+}
+
+// RenewLock extends the lock expiration if owner still holds it
+func (d *CacheDev) RenewLock(key, owner string, ttl time.Duration) (bool, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.data[key] != owner || d.expired(key) {
+		return false, nil
+	}
+
+	d.expires[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+// ReleaseLock removes the lock if owner still holds it
+func (d *CacheDev) ReleaseLock(key, owner string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.data[key] == owner {
+		d.remove(key)
+	}
+	return nil
+}
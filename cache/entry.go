@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// defaultExpiration is how long a value set via Set lives
+const defaultExpiration = 12 * time.Hour
+
+// sweepInterval is how often the expired keys are removed
+const sweepInterval = time.Minute
+
+var errKeyNotFound = errors.New("key not found in cache")
+
+// entry is a value stored by the persistent Volatilizers along with its
+// expiration
+type entry struct {
+	Value string `json:"v"`
+	// Expires is a Unix time in nanoseconds, 0 never expires
+	Expires int64 `json:"e,omitempty"`
+}
+
+func newEntry(value string, ttl time.Duration) entry {
+	e := entry{Value: value}
+	if ttl > 0 {
+		e.Expires = time.Now().Add(ttl).UnixNano()
+	}
+	return e
+}
+
+func (e entry) expired() bool {
+	return e.Expires > 0 && time.Now().UnixNano() >= e.Expires
+}
+
+func decodeEntry(b []byte) (e entry, err error) {
+	err = json.Unmarshal(b, &e)
+	return
+}
+
+func (e entry) encode() []byte {
+	b, _ := json.Marshal(e)
+	return b
+}
+
+// ttl returns the remaining time to live of the entry, 0 if it never expires
+func (e entry) ttl() time.Duration {
+	if e.Expires == 0 {
+		return 0
+	}
+	return time.Until(time.Unix(0, e.Expires))
+}
+
+// decodeHash returns the fields of a hash stored in an entry
+func decodeHash(e entry) (map[string]string, error) {
+	h := make(map[string]string)
+	if len(e.Value) == 0 {
+		return h, nil
+	}
+
+	err := json.Unmarshal([]byte(e.Value), &h)
+	return h, err
+}
+
+// decodeZSet returns the members and scores of a sorted set stored in an
+// entry
+func decodeZSet(e entry) (map[string]float64, error) {
+	z := make(map[string]float64)
+	if len(e.Value) == 0 {
+		return z, nil
+	}
+
+	err := json.Unmarshal([]byte(e.Value), &z)
+	return z, err
+}
+
+func encodeValue(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// rangeZSet orders the members by score (then by member like Redis) and
+// returns the ones between the start and stop indexes
+func rangeZSet(z map[string]float64, start, stop int64, reverse bool) []ScoredMember {
+	members := make([]ScoredMember, 0, len(z))
+	for m, score := range z {
+		members = append(members, ScoredMember{Member: m, Score: score})
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if reverse {
+			a, b = b, a
+		}
+
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Member < b.Member
+	})
+
+	n := int64(len(members))
+	if start < 0 {
+		start = max(start+n, 0)
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop {
+		return []ScoredMember{}
+	}
+	return members[start : stop+1]
+}
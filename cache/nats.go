@@ -0,0 +1,839 @@
+package cache
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/model"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	natsBucket       = "staticbackend"
+	natsQueueStream  = "SB_QUEUES"
+	natsQueuePrefix  = "sb.queue."
+	natsPubSubPrefix = "sb.pubsub."
+)
+
+// CacheNATS uses a NATS JetStream key-value bucket for the cache, the work
+// queues are JetStream work-queue streams and the pub/sub uses core NATS
+// subjects
+type CacheNATS struct {
+	Conn *nats.Conn
+	JS   jetstream.JetStream
+	KV   jetstream.KeyValue
+	Ctx  context.Context
+	log  *logger.Logger
+
+	queues    jetstream.Stream
+	consumers map[string]jetstream.Consumer
+	mx        sync.Mutex
+	done      chan struct{}
+}
+
+// NewNATSCache connects to the NATS server and creates the key-value bucket
+// and the work queue stream if they do not exist, the expired keys are
+// removed by a background sweeper
+func NewNATSCache(url string, log *logger.Logger) (*CacheNATS, error) {
+	if len(url) == 0 {
+		url = nats.DefaultURL
+	}
+
+	nc, err := nats.Connect(url, nats.Name("staticbackend"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	// the bucket does not expire its keys, each value holds its own
+	// expiration
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket: natsBucket,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	queues, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      natsQueueStream,
+		Subjects:  []string{natsQueuePrefix + ">"},
+		Retention: jetstream.WorkQueuePolicy,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	n := &CacheNATS{
+		Conn:      nc,
+		JS:        js,
+		KV:        kv,
+		Ctx:       ctx,
+		log:       log,
+		queues:    queues,
+		consumers: make(map[string]jetstream.Consumer),
+		done:      make(chan struct{}),
+	}
+
+	go n.sweep()
+
+	return n, nil
+}
+
+// Close stops the sweeper and drains the connection
+func (n *CacheNATS) Close() error {
+	close(n.done)
+	return n.Conn.Drain()
+}
+
+// sweep removes the expired keys every sweepInterval until the cache is
+// closed
+func (n *CacheNATS) sweep() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := n.purge(); err != nil {
+				n.log.Error().Err(err).Msg("error removing the expired cache keys")
+			}
+		case <-n.done:
+			return
+		}
+	}
+}
+
+// purge removes the expired keys unless they changed in the meantime
+func (n *CacheNATS) purge() error {
+	keys, err := n.KV.ListKeys(n.Ctx)
+	if err != nil {
+		return err
+	}
+
+	for k := range keys.Keys() {
+		kve, err := n.KV.Get(n.Ctx, k)
+		if err != nil {
+			continue
+		}
+
+		if e, err := decodeEntry(kve.Value()); err == nil && !e.expired() {
+			continue
+		}
+
+		err = n.KV.Delete(n.Ctx, k, jetstream.LastRevision(kve.Revision()))
+		if err != nil && !errors.Is(err, jetstream.ErrKeyExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+// natsToken encodes a key or channel with the characters allowed in the key
+// and subject names
+func natsToken(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// get returns the entry of an encoded key, the key is not found if it has
+// expired
+func (n *CacheNATS) get(k string) (e entry, rev uint64, err error) {
+	kve, err := n.KV.Get(n.Ctx, k)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return e, 0, errKeyNotFound
+	} else if err != nil {
+		return
+	}
+
+	rev = kve.Revision()
+
+	e, err = decodeEntry(kve.Value())
+	if err != nil {
+		return
+	} else if e.expired() {
+		return e, rev, errKeyNotFound
+	}
+	return
+}
+
+// compareAndSwap writes e if the key is still at revision rev, a revision of
+// 0 creates the key. It returns false when the key has changed.
+func (n *CacheNATS) compareAndSwap(k string, e entry, rev uint64) (bool, error) {
+	var err error
+	if rev == 0 {
+		_, err = n.KV.Create(n.Ctx, k, e.encode())
+	} else {
+		_, err = n.KV.Update(n.Ctx, k, e.encode(), rev)
+	}
+
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// update writes the entry returned by fn until the encoded key did not change
+// in the meantime, a nil entry removes the key. The entry passed to fn is
+// empty if the key is not found.
+func (n *CacheNATS) update(k string, fn func(e entry, found bool) (*entry, error)) error {
+	for {
+		e, rev, err := n.get(k)
+		found := err == nil
+		if err != nil && !errors.Is(err, errKeyNotFound) {
+			return err
+		} else if !found {
+			e = entry{}
+		}
+
+		next, err := fn(e, found)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		if next != nil {
+			ok, err = n.compareAndSwap(k, *next, rev)
+		} else if !found {
+			return nil
+		} else {
+			ok, err = true, n.KV.Delete(n.Ctx, k, jetstream.LastRevision(rev))
+			if errors.Is(err, jetstream.ErrKeyExists) {
+				ok, err = false, nil
+			}
+		}
+
+		if err != nil {
+			return err
+		} else if ok {
+			return nil
+		}
+	}
+}
+
+// Get gets a value by its id
+func (n *CacheNATS) Get(key string) (string, error) {
+	e, _, err := n.get(natsToken(key))
+	if err != nil {
+		return "", err
+	}
+	return e.Value, nil
+}
+
+// Set sets a value for a key
+func (n *CacheNATS) Set(key string, value string) error {
+	return n.SetEx(key, value, defaultExpiration)
+}
+
+// SetEx sets a value for a key expiring after ttl
+func (n *CacheNATS) SetEx(key string, value string, ttl time.Duration) error {
+	_, err := n.KV.Put(n.Ctx, natsToken(key), newEntry(value, ttl).encode())
+	return err
+}
+
+// SetNX creates the key if it does not exist or has expired
+func (n *CacheNATS) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	_, rev, err := n.get(natsToken(key))
+	if err == nil {
+		return false, nil
+	} else if !errors.Is(err, errKeyNotFound) {
+		return false, err
+	}
+
+	return n.compareAndSwap(natsToken(key), newEntry(value, ttl), rev)
+}
+
+// Del removes a key
+func (n *CacheNATS) Del(key string) error {
+	return n.KV.Delete(n.Ctx, natsToken(key))
+}
+
+// Exists returns true if the key exists and has not expired
+func (n *CacheNATS) Exists(key string) (bool, error) {
+	_, _, err := n.get(natsToken(key))
+	if errors.Is(err, errKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Expire sets or removes the key expiration
+func (n *CacheNATS) Expire(key string, ttl time.Duration) (ok bool, err error) {
+	err = n.update(natsToken(key), func(e entry, found bool) (*entry, error) {
+		if ok = found; !found {
+			return nil, nil
+		}
+
+		e = newEntry(e.Value, ttl)
+		return &e, nil
+	})
+	return
+}
+
+// TTL returns the remaining time to live of a key
+func (n *CacheNATS) TTL(key string) (time.Duration, error) {
+	e, _, err := n.get(natsToken(key))
+	if err != nil {
+		return 0, err
+	}
+	return e.ttl(), nil
+}
+
+// GetTyped retrives the value for a key and unmarshal the JSON value into the
+// interface
+func (n *CacheNATS) GetTyped(key string, v any) error {
+	s, err := n.Get(key)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(s), v)
+}
+
+// SetTyped converts the interface into JSON before storing its string value
+func (n *CacheNATS) SetTyped(key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return n.Set(key, string(b))
+}
+
+// Inc increments a value (atomic via the key's revision), like Redis a
+// missing key starts at 0 and the expiration is kept
+func (n *CacheNATS) Inc(key string, by int64) (int64, error) {
+	return n.incr(natsToken(key), by)
+}
+
+func (n *CacheNATS) incr(k string, by int64) (v int64, err error) {
+	err = n.update(k, func(e entry, found bool) (*entry, error) {
+		v = 0
+		if found {
+			cur, err := strconv.ParseInt(e.Value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			v = cur
+		}
+
+		v += by
+		e.Value = strconv.FormatInt(v, 10)
+		return &e, nil
+	})
+	return
+}
+
+// Dec decrements a value (atomic via the key's revision)
+func (n *CacheNATS) Dec(key string, by int64) (int64, error) {
+	return n.Inc(key, -1*by)
+}
+
+// HSet sets the field of a hash (stored as a JSON object)
+func (n *CacheNATS) HSet(key, field, value string) error {
+	return n.update(natsToken(key), func(e entry, _ bool) (*entry, error) {
+		h, err := decodeHash(e)
+		if err != nil {
+			return nil, err
+		}
+
+		h[field] = value
+
+		e.Value = encodeValue(h)
+		return &e, nil
+	})
+}
+
+// HGet returns the field of a hash
+func (n *CacheNATS) HGet(key, field string) (string, error) {
+	h, err := n.HGetAll(key)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := h[field]
+	if !ok {
+		return "", errKeyNotFound
+	}
+	return val, nil
+}
+
+// HDel removes the field of a hash, the hash is removed with its last field
+func (n *CacheNATS) HDel(key, field string) error {
+	return n.update(natsToken(key), func(e entry, found bool) (*entry, error) {
+		if !found {
+			return nil, nil
+		}
+
+		h, err := decodeHash(e)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(h, field)
+		if len(h) == 0 {
+			return nil, nil
+		}
+
+		e.Value = encodeValue(h)
+		return &e, nil
+	})
+}
+
+// HGetAll returns all the fields of a hash
+func (n *CacheNATS) HGetAll(key string) (map[string]string, error) {
+	e, _, err := n.get(natsToken(key))
+	if err != nil && !errors.Is(err, errKeyNotFound) {
+		return nil, err
+	} else if err != nil {
+		e = entry{}
+	}
+	return decodeHash(e)
+}
+
+// ZAdd adds or updates a member of a sorted set (stored as a JSON object)
+func (n *CacheNATS) ZAdd(key, member string, score float64) error {
+	_, err := n.zupdate(key, member, func(float64) float64 { return score })
+	return err
+}
+
+// ZIncrBy increments the score of a member of a sorted set
+func (n *CacheNATS) ZIncrBy(key, member string, by float64) (float64, error) {
+	return n.zupdate(key, member, func(score float64) float64 { return score + by })
+}
+
+// zupdate sets the score of a member from its current score
+func (n *CacheNATS) zupdate(key, member string, fn func(float64) float64) (score float64, err error) {
+	err = n.update(natsToken(key), func(e entry, _ bool) (*entry, error) {
+		z, err := decodeZSet(e)
+		if err != nil {
+			return nil, err
+		}
+
+		score = fn(z[member])
+		z[member] = score
+
+		e.Value = encodeValue(z)
+		return &e, nil
+	})
+	return
+}
+
+// ZScore returns the score of a member of a sorted set
+func (n *CacheNATS) ZScore(key, member string) (float64, error) {
+	z, err := n.zset(key)
+	if err != nil {
+		return 0, err
+	}
+
+	score, ok := z[member]
+	if !ok {
+		return 0, errKeyNotFound
+	}
+	return score, nil
+}
+
+// ZRem removes a member of a sorted set, the set is removed with its last
+// member
+func (n *CacheNATS) ZRem(key, member string) error {
+	return n.update(natsToken(key), func(e entry, found bool) (*entry, error) {
+		if !found {
+			return nil, nil
+		}
+
+		z, err := decodeZSet(e)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(z, member)
+		if len(z) == 0 {
+			return nil, nil
+		}
+
+		e.Value = encodeValue(z)
+		return &e, nil
+	})
+}
+
+// ZRange returns the members between start and stop ordered by score
+func (n *CacheNATS) ZRange(key string, start, stop int64, reverse bool) ([]ScoredMember, error) {
+	z, err := n.zset(key)
+	if err != nil {
+		return nil, err
+	}
+	return rangeZSet(z, start, stop, reverse), nil
+}
+
+func (n *CacheNATS) zset(key string) (map[string]float64, error) {
+	e, _, err := n.get(natsToken(key))
+	if err != nil && !errors.Is(err, errKeyNotFound) {
+		return nil, err
+	} else if err != nil {
+		e = entry{}
+	}
+	return decodeZSet(e)
+}
+
+// Subscribe subscribes to a topic to receive messages on system/user events
+func (n *CacheNATS) Subscribe(send chan model.Command, token, channel string, close chan bool) {
+	ch := make(chan *nats.Msg, 64)
+	sub, err := n.Conn.ChanSubscribe(natsPubSubPrefix+natsToken(channel), ch)
+	if err != nil {
+		n.log.Error().Err(err).Msg("error establishing PubSub subscription")
+		return
+	}
+
+	for {
+		select {
+		case m := <-ch:
+			var msg model.Command
+			if err := json.Unmarshal(m.Data, &msg); err != nil {
+				n.log.Error().Err(err).Msg("error parsing JSON message")
+				_ = sub.Unsubscribe()
+				return
+			}
+
+			if !receive(&msg, token, channel, n.HasPermission) {
+				continue
+			}
+			send <- msg
+		case <-close:
+			_ = sub.Unsubscribe()
+			return
+		}
+	}
+}
+
+// Publish sends a message and all subscribers will receive it if they're
+// subscribed to that topic
+func (n *CacheNATS) Publish(msg model.Command) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// Publish the event to system so server-side function can trigger
+	// but only for non system msg
+	if !msg.IsSystemEvent && msg.Channel != "sbsys" {
+		sysmsg := msg
+		sysmsg.IsSystemEvent = true
+
+		sb, err := json.Marshal(sysmsg)
+		if err != nil {
+			n.log.Error().Err(err).Msg("error marshaling the system msg")
+		} else if err := n.Conn.Publish(natsPubSubPrefix+natsToken("sbsys"), sb); err != nil {
+			n.log.Error().Err(err).Msg("error publishing to system channel")
+		}
+	}
+
+	return n.Conn.Publish(natsPubSubPrefix+natsToken(msg.Channel), b)
+}
+
+// PublishDocument publishes a database update message (created, updated, deleted)
+// All subscribers will get notified
+func (n *CacheNATS) PublishDocument(auth model.Auth, dbName, channel, typ string, v any) {
+	msg, err := documentMessage(auth, dbName, channel, typ, v)
+	if err != nil {
+		n.log.Error().Err(err).Msg("error publishing db doc")
+		return
+	}
+
+	if err := n.Publish(msg); err != nil {
+		n.log.Error().Err(err).Msg("unable to publish db doc events")
+	}
+}
+
+// HasPermission determines if a session token has permission to a collection
+func (n *CacheNATS) HasPermission(token, repo, payload string) bool {
+	return hasPermission(n.GetTyped, n.log, token, repo, payload)
+}
+
+// QueueWork publishes the value to the queue's subject of the work queue
+// stream
+func (n *CacheNATS) QueueWork(key, value string) error {
+	_, err := n.JS.Publish(n.Ctx, natsQueuePrefix+natsToken(key), []byte(value))
+	return err
+}
+
+// DequeueWork fetches the next value of the queue and acknowledges it, it
+// returns an empty string when the queue is empty
+func (n *CacheNATS) DequeueWork(key string) (string, error) {
+	cons, err := n.consumer(key)
+	if err != nil {
+		return "", err
+	}
+
+	batch, err := cons.FetchNoWait(1)
+	if err != nil {
+		return "", err
+	}
+
+	for msg := range batch.Messages() {
+		if err := msg.DoubleAck(n.Ctx); err != nil {
+			return "", err
+		}
+		return string(msg.Data()), nil
+	}
+	return "", batch.Error()
+}
+
+// consumer returns the durable consumer of a queue, one consumer per queue
+// filters its subject
+func (n *CacheNATS) consumer(key string) (jetstream.Consumer, error) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if cons, ok := n.consumers[key]; ok {
+		return cons, nil
+	}
+
+	cons, err := n.queues.CreateOrUpdateConsumer(n.Ctx, jetstream.ConsumerConfig{
+		Durable:       natsToken(key),
+		FilterSubject: natsQueuePrefix + natsToken(key),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n.consumers[key] = cons
+	return cons, nil
+}
+
+// queueKey returns the encoded key of a reliable queue's item (prefix q),
+// dead letter (prefix d) or sequence (prefix s without id)
+func queueKey(prefix, key, id string) string {
+	k := prefix + "." + natsToken(key)
+	if len(id) > 0 {
+		k += "." + id
+	}
+	return k
+}
+
+// queueEntry is a reliable queue item with the revision of its key
+type queueEntry struct {
+	key  string
+	rev  uint64
+	item QueueItem
+}
+
+// queueEntries returns the items or dead letters of a reliable queue in
+// their enqueue order
+func (n *CacheNATS) queueEntries(prefix, key string) ([]queueEntry, error) {
+	w, err := n.KV.WatchFiltered(n.Ctx, []string{queueKey(prefix, key, "*")}, jetstream.IgnoreDeletes())
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+
+	var list []queueEntry
+	for kve := range w.Updates() {
+		// a nil entry indicates all initial values are received
+		if kve == nil {
+			break
+		}
+
+		e, err := decodeEntry(kve.Value())
+		if err != nil {
+			return nil, err
+		}
+
+		qe := queueEntry{key: kve.Key(), rev: kve.Revision()}
+		if err := json.Unmarshal([]byte(e.Value), &qe.item); err != nil {
+			return nil, err
+		}
+		list = append(list, qe)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, _ := strconv.ParseUint(list[i].item.ID, 10, 64)
+		b, _ := strconv.ParseUint(list[j].item.ID, 10, 64)
+		return a < b
+	})
+	return list, nil
+}
+
+// Enqueue adds an item to a reliable queue, each item is a key of the bucket
+func (n *CacheNATS) Enqueue(key, value string, delay time.Duration) (string, error) {
+	seq, err := n.incr(queueKey("s", key, ""), 1)
+	if err != nil {
+		return "", err
+	}
+
+	it := newQueueItem(strconv.FormatInt(seq, 10), value, delay)
+	_, err = n.KV.Create(n.Ctx, queueKey("q", key, it.ID), entry{Value: encodeValue(it)}.encode())
+	return it.ID, err
+}
+
+// Lease leases the oldest available item of a reliable queue, an item leased
+// concurrently by another instance is skipped
+func (n *CacheNATS) Lease(key string, visibility time.Duration, maxAttempts int) (*QueueItem, error) {
+	list, err := n.queueEntries("q", key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, qe := range list {
+		it := qe.item
+		if !it.available(now) {
+			continue
+		}
+
+		if !it.lease(now, visibilityOrDefault(visibility), maxAttempts) {
+			err := n.KV.Delete(n.Ctx, qe.key, jetstream.LastRevision(qe.rev))
+			if errors.Is(err, jetstream.ErrKeyExists) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			dead := entry{Value: encodeValue(it)}
+			if _, err := n.KV.Put(n.Ctx, queueKey("d", key, it.ID), dead.encode()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if ok, err := n.compareAndSwap(qe.key, entry{Value: encodeValue(it)}, qe.rev); err != nil {
+			return nil, err
+		} else if ok {
+			return &it, nil
+		}
+	}
+	return nil, nil
+}
+
+// Ack removes a leased item given its lease receipt
+func (n *CacheNATS) Ack(key, receipt string) (bool, error) {
+	id, ok := receiptID(receipt)
+	if !ok {
+		return false, nil
+	}
+
+	k := queueKey("q", key, id)
+
+	e, rev, err := n.get(k)
+	if errors.Is(err, errKeyNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	var it QueueItem
+	if err := json.Unmarshal([]byte(e.Value), &it); err != nil {
+		return false, err
+	} else if !it.acknowledges(receipt) {
+		return false, nil
+	}
+
+	err = n.KV.Delete(n.Ctx, k, jetstream.LastRevision(rev))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		// leased again in the meantime
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// QueueStats counts the items of a reliable queue by state
+func (n *CacheNATS) QueueStats(key string) (stats QueueStats, err error) {
+	items, err := n.QueueItems(key)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, it := range items {
+		stats.count(it, now)
+	}
+
+	dead, err := n.DeadLetters(key)
+	stats.Dead = int64(len(dead))
+	return
+}
+
+// QueueItems returns the items of a reliable queue
+func (n *CacheNATS) QueueItems(key string) ([]QueueItem, error) {
+	return n.queueItems("q", key)
+}
+
+// DeadLetters returns the items that exceeded their max attempts
+func (n *CacheNATS) DeadLetters(key string) ([]QueueItem, error) {
+	return n.queueItems("d", key)
+}
+
+func (n *CacheNATS) queueItems(prefix, key string) ([]QueueItem, error) {
+	list, err := n.queueEntries(prefix, key)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]QueueItem, 0, len(list))
+	for _, qe := range list {
+		items = append(items, qe.item)
+	}
+	return items, nil
+}
+
+// AcquireLock creates the key if it does not exist or has expired
+func (n *CacheNATS) AcquireLock(key, owner string, ttl time.Duration) (bool, error) {
+	_, rev, err := n.get(natsToken(key))
+	if err == nil {
+		return false, nil
+	} else if !errors.Is(err, errKeyNotFound) {
+		return false, err
+	}
+
+	return n.compareAndSwap(natsToken(key), newEntry(owner, ttl), rev)
+}
+
+// RenewLock extends the lock expiration if owner still holds it
+func (n *CacheNATS) RenewLock(key, owner string, ttl time.Duration) (bool, error) {
+	e, rev, err := n.get(natsToken(key))
+	if errors.Is(err, errKeyNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	} else if e.Value != owner {
+		return false, nil
+	}
+
+	return n.compareAndSwap(natsToken(key), newEntry(owner, ttl), rev)
+}
+
+// ReleaseLock removes the lock if owner still holds it
+func (n *CacheNATS) ReleaseLock(key, owner string) error {
+	e, rev, err := n.get(natsToken(key))
+	if errors.Is(err, errKeyNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if e.Value != owner {
+		return nil
+	}
+
+	err = n.KV.Delete(n.Ctx, natsToken(key), jetstream.LastRevision(rev))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		// the lock changed hands in the meantime
+		return nil
+	}
+	return err
+}
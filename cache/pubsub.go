@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/model"
+)
+
+// hasPermission determines if a session token has permission to a
+// collection, the session is read via getTyped
+func hasPermission(getTyped func(string, any) error, log *logger.Logger, token, repo, payload string) bool {
+	// sbsys is a reserved channel used internally, no need to check for
+	// permissions
+	if repo == "sbsys" {
+		return true
+	}
+
+	var me model.Auth
+	if err := getTyped(token, &me); err != nil {
+		return false
+	}
+
+	docs := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(payload), &docs); err != nil {
+		log.Error().Err(err).Msg("error decoding docs for permissions check")
+
+		return false
+	}
+
+	switch me.ReadPermission(repo) {
+	case internal.PermGroup:
+		acctID, ok := docs["accountId"]
+		if !ok {
+			return false
+		}
+
+		return fmt.Sprintf("%v", acctID) == me.AccountID
+	case internal.PermOwner:
+		owner, ok := docs["ownerId"]
+		if !ok {
+			return false
+		}
+
+		return fmt.Sprintf("%v", owner) == me.UserID
+	default:
+		return true
+	}
+}
+
+// receive prepares a message for a subscriber, it returns false when the
+// subscriber cannot receive it
+func receive(msg *model.Command, token, channel string, hasPermission func(token, repo, payload string) bool) bool {
+	// TODO: this will need more thinking
+	if msg.Type == model.MsgTypeChanIn {
+		msg.Type = model.MsgTypeChanOut
+	} else if msg.IsSystemEvent {
+
+	} else if msg.IsDBEvent() && !hasPermission(token, channel, msg.Data) {
+		return false
+	}
+	return true
+}
+
+// documentMessage returns the message published for a database event
+func documentMessage(auth model.Auth, dbName, channel, typ string, v any) (model.Command, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return model.Command{}, err
+	}
+
+	msg := model.Command{
+		Channel: channel,
+		Data:    string(b),
+		Type:    typ,
+		Auth:    auth,
+		Base:    dbName,
+	}
+	return msg, nil
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/internal"
+)
+
+// DefaultVisibility is how long a leased item stays invisible when no
+// visibility timeout is given
+const DefaultVisibility = 30 * time.Second
+
+// QueueItem is an item of a reliable work queue
+type QueueItem struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+	// Attempts is how many times the item has been leased
+	Attempts int `json:"attempts"`
+	// VisibleAt is when the item becomes available, for a leased item it's
+	// when its lease expires
+	VisibleAt time.Time `json:"visibleAt"`
+	Leased    bool      `json:"leased"`
+	// Receipt identifies the item's current lease, only the holder of the
+	// current lease can acknowledge the item
+	Receipt string `json:"receipt,omitempty"`
+}
+
+// QueueStats counts the items of a reliable work queue by state
+type QueueStats struct {
+	Ready   int64 `json:"ready"`
+	Delayed int64 `json:"delayed"`
+	Leased  int64 `json:"leased"`
+	Dead    int64 `json:"dead"`
+}
+
+func newQueueItem(id, value string, delay time.Duration) QueueItem {
+	return QueueItem{ID: id, Value: value, VisibleAt: time.Now().Add(delay)}
+}
+
+// available returns true if the item can be leased, an item whose lease
+// expired is available again
+func (it QueueItem) available(now time.Time) bool {
+	return !it.VisibleAt.After(now)
+}
+
+// lease hides the item until now+visibility, it returns false without
+// changing the item if it was already leased maxAttempts times (0 is
+// unlimited)
+func (it *QueueItem) lease(now time.Time, visibility time.Duration, maxAttempts int) bool {
+	if maxAttempts > 0 && it.Attempts >= maxAttempts {
+		return false
+	}
+
+	it.Attempts++
+	it.Leased = true
+	it.VisibleAt = now.Add(visibility)
+	it.Receipt = newReceipt(it.ID)
+	return true
+}
+
+// newReceipt returns a receipt made of the item's ID and a lease nonce
+func newReceipt(id string) string {
+	return id + ":" + internal.RandStringRunes(16)
+}
+
+// receiptID returns the ID of the item a receipt was issued for
+func receiptID(receipt string) (string, bool) {
+	id, nonce, ok := strings.Cut(receipt, ":")
+	return id, ok && len(id) > 0 && len(nonce) > 0
+}
+
+// acknowledges returns true if the receipt is the item's current lease, an
+// item whose lease expired can be acknowledged until it is leased again
+func (it QueueItem) acknowledges(receipt string) bool {
+	return it.Leased && len(it.Receipt) > 0 && it.Receipt == receipt
+}
+
+// count adds the item to its state's count
+func (s *QueueStats) count(it QueueItem, now time.Time) {
+	switch {
+	case it.available(now):
+		s.Ready++
+	case it.Leased:
+		s.Leased++
+	default:
+		s.Delayed++
+	}
+}
+
+func visibilityOrDefault(visibility time.Duration) time.Duration {
+	if visibility <= 0 {
+		return DefaultVisibility
+	}
+	return visibility
+}
@@ -1,6 +1,22 @@
 package cache
 
-import "github.com/staticbackendhq/core/model"
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	CacheProviderRedis    = "redis"
+	CacheProviderNATS     = "nats"
+	CacheProviderEmbedded = "embedded"
+)
+
+// ScoredMember is a member of a sorted set with its score
+type ScoredMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
 
 // PublishDocumentEvent used to publish database events
 type PublishDocumentEvent func(auth model.Auth, dbName, channel, typ string, v interface{})
@@ -11,6 +27,20 @@ type Volatilizer interface {
 	Get(key string) (string, error)
 	// Set sets a string value
 	Set(key string, value string) error
+	// SetEx sets a string value expiring after ttl, a ttl of 0 never expires
+	SetEx(key string, value string, ttl time.Duration) error
+	// SetNX sets a string value only if the key does not exist, a ttl of 0
+	// never expires
+	SetNX(key string, value string, ttl time.Duration) (bool, error)
+	// Del removes a key
+	Del(key string) error
+	// Exists returns true if the key exists
+	Exists(key string) (bool, error)
+	// Expire sets the key expiration, a ttl of 0 removes it. It returns false
+	// if the key does not exist
+	Expire(key string, ttl time.Duration) (bool, error)
+	// TTL returns the remaining time to live of a key, 0 if it never expires
+	TTL(key string) (time.Duration, error)
 	// GetTyped returns a typed struct by its key
 	GetTyped(key string, v any) error
 	// SetTyped sets a typed struct for a key
@@ -19,6 +49,25 @@ type Volatilizer interface {
 	Inc(key string, by int64) (int64, error)
 	// Dec decrements a value for a key
 	Dec(key string, by int64) (int64, error)
+	// HSet sets the field of a hash
+	HSet(key, field, value string) error
+	// HGet returns the field of a hash
+	HGet(key, field string) (string, error)
+	// HDel removes the field of a hash
+	HDel(key, field string) error
+	// HGetAll returns all the fields of a hash
+	HGetAll(key string) (map[string]string, error)
+	// ZAdd adds or updates a member of a sorted set
+	ZAdd(key, member string, score float64) error
+	// ZIncrBy increments the score of a member of a sorted set
+	ZIncrBy(key, member string, by float64) (float64, error)
+	// ZScore returns the score of a member of a sorted set
+	ZScore(key, member string) (float64, error)
+	// ZRem removes a member of a sorted set
+	ZRem(key, member string) error
+	// ZRange returns the members from start to stop (inclusive, negative
+	// indexes count from the end) ordered by score, highest first if reverse
+	ZRange(key string, start, stop int64, reverse bool) ([]ScoredMember, error)
 	// Subscribe subscribes to a pub/sub channel
 	Subscribe(send chan model.Command, token, channel string, close chan bool)
 	// Publish publishes a message to a channel
@@ -29,4 +78,28 @@ type Volatilizer interface {
 	QueueWork(key, value string) error
 	// DequeueWork dequeue work item (if available)
 	DequeueWork(key string) (string, error)
+	// Enqueue adds an item to a reliable queue available after delay and
+	// returns its id
+	Enqueue(key, value string, delay time.Duration) (string, error)
+	// Lease returns the next available item of a reliable queue (nil if
+	// none) and hides it for visibility until it is acknowledged with its
+	// receipt. An item already leased maxAttempts times (0 is unlimited) is
+	// moved to the dead letters instead.
+	Lease(key string, visibility time.Duration, maxAttempts int) (*QueueItem, error)
+	// Ack removes a leased item given the receipt of its lease, it returns
+	// false if the item is not leased or was leased again since
+	Ack(key, receipt string) (bool, error)
+	// QueueStats counts the items of a reliable queue by state
+	QueueStats(key string) (QueueStats, error)
+	// QueueItems returns the items of a reliable queue
+	QueueItems(key string) ([]QueueItem, error)
+	// DeadLetters returns the items that exceeded their max attempts
+	DeadLetters(key string) ([]QueueItem, error)
+	// AcquireLock sets key to owner if it does not exist, the lock expires
+	// after ttl unless renewed
+	AcquireLock(key, owner string, ttl time.Duration) (bool, error)
+	// RenewLock extends the lock expiration if it is still held by owner
+	RenewLock(key, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLock removes the lock if it is still held by owner
+	ReleaseLock(key, owner string) error
 }
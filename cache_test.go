@@ -0,0 +1,193 @@
+package staticbackend
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/staticbackendhq/core/cache"
+)
+
+func TestSudoCacheExpiry(t *testing.T) {
+	set := map[string]any{"key": "expiring", "value": "v", "ttl": 60}
+	resp := dbReq(t, sudoCache, "POST", "/sudo/cache", set, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, sudoCache, "GET", "/sudo/cache?key=expiring&type=ttl", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var ttl int64
+	if err := parseBody(resp.Body, &ttl); err != nil {
+		t.Fatal(err)
+	} else if ttl <= 0 || ttl > 60 {
+		t.Errorf("expected a ttl up to 60 seconds got %d", ttl)
+	}
+
+	// the key exists so it's not set
+	set["nx"] = true
+	resp = dbReq(t, sudoCache, "POST", "/sudo/cache", set, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var ok bool
+	if err := parseBody(resp.Body, &ok); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		t.Error("expected the set if not exists to fail")
+	}
+
+	resp = dbReq(t, sudoCache, "DELETE", "/sudo/cache?key=expiring", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, sudoCache, "GET", "/sudo/cache?key=expiring&type=exists", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if err := parseBody(resp.Body, &ok); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		t.Error("expected the key to be deleted")
+	}
+}
+
+func TestSudoCacheHashAndSortedSet(t *testing.T) {
+	defer dbReq(t, sudoCache, "DELETE", "/sudo/cache?key=profile", nil, true)
+	defer dbReq(t, sudoCache, "DELETE", "/sudo/cache?key=scores", nil, true)
+
+	hset := map[string]any{"key": "profile", "type": "hash", "field": "name", "value": "sb"}
+	resp := dbReq(t, sudoCache, "POST", "/sudo/cache", hset, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, sudoCache, "GET", "/sudo/cache?key=profile&type=hash", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var h map[string]string
+	if err := parseBody(resp.Body, &h); err != nil {
+		t.Fatal(err)
+	} else if h["name"] != "sb" {
+		t.Errorf("expected the name field to be sb got %v", h)
+	}
+
+	for member, score := range map[string]float64{"a": 1, "b": 2, "c": 3} {
+		zadd := map[string]any{"key": "scores", "type": "zset", "member": member, "score": score}
+		resp := dbReq(t, sudoCache, "POST", "/sudo/cache", zadd, true)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+	}
+
+	zincr := map[string]any{"key": "scores", "type": "zincr", "member": "a", "score": 5}
+	resp = dbReq(t, sudoCache, "POST", "/sudo/cache", zincr, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, sudoCache, "DELETE", "/sudo/cache?key=scores&type=zset&member=c", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, sudoCache, "GET", "/sudo/cache?key=scores&type=zset&rev=true", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var members []cache.ScoredMember
+	if err := parseBody(resp.Body, &members); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []cache.ScoredMember{{Member: "a", Score: 6}, {Member: "b", Score: 2}}
+	if !reflect.DeepEqual(members, expected) {
+		t.Errorf("expected %v got %v", expected, members)
+	}
+}
+
+func TestSudoCacheReliableQueue(t *testing.T) {
+	add := map[string]any{"key": "jobs", "type": "queue", "value": "job-1"}
+	resp := dbReq(t, sudoCache, "POST", "/sudo/cache", add, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var id string
+	if err := parseBody(resp.Body, &id); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = dbReq(t, sudoCache, "GET", "/sudo/cache?key=jobs&type=queue&visibility=60&maxAttempts=3", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var item cache.QueueItem
+	if err := parseBody(resp.Body, &item); err != nil {
+		t.Fatal(err)
+	} else if item.ID != id || item.Value != "job-1" || !item.Leased {
+		t.Fatalf("expected job-1 to be leased got %v", item)
+	}
+
+	// the leased item is invisible
+	resp = dbReq(t, sudoCache, "GET", "/sudo/cache?key=jobs&type=queue", nil, true)
+	if body := GetResponseBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatal(body)
+	} else if !strings.HasSuffix(strings.TrimSpace(body), "null") {
+		t.Errorf("expected no item to lease got %s", body)
+	}
+
+	resp = dbReq(t, sudoCache, "GET", "/sudo/cache?key=jobs&type=queue&op=stats", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var stats cache.QueueStats
+	if err := parseBody(resp.Body, &stats); err != nil {
+		t.Fatal(err)
+	} else if stats != (cache.QueueStats{Leased: 1}) {
+		t.Errorf("expected one leased item got %v", stats)
+	}
+
+	// the item's ID is not its lease receipt
+	ack := map[string]any{"key": "jobs", "type": "ack", "receipt": id}
+	resp = dbReq(t, sudoCache, "POST", "/sudo/cache", ack, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var ok bool
+	if err := parseBody(resp.Body, &ok); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		t.Error("expected the item to require its lease receipt")
+	}
+
+	ack["receipt"] = item.Receipt
+	resp = dbReq(t, sudoCache, "POST", "/sudo/cache", ack, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if err := parseBody(resp.Body, &ok); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Error("expected the item to be acknowledged")
+	}
+
+	resp = dbReq(t, sudoCache, "GET", "/sudo/cache?key=jobs&type=queue&visibility=soon", nil, true)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 got %d", resp.StatusCode)
+	}
+}
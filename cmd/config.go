@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/staticbackendhq/core/config"
+)
+
+// runConfig handles the config check command, it returns false for other
+// commands
+func runConfig(file string, args []string) (bool, error) {
+	if len(args) == 0 || args[0] != "config" {
+		return false, nil
+	}
+
+	if len(args) < 2 || args[1] != "check" {
+		return true, errors.New("usage: staticbackend config check [-config file]")
+	}
+
+	return true, checkCmd(file, args[2:])
+}
+
+// checkCmd loads and validates the configuration without starting the server
+func checkCmd(file string, args []string) error {
+	fs := flag.NewFlagSet("config check", flag.ExitOnError)
+	fs.StringVar(&file, "config", file, "YAML or TOML configuration file")
+	fs.Parse(args)
+
+	c, err := config.Load(file)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	for _, w := range c.Warnings() {
+		fmt.Println("warning:", w)
+	}
+
+	if len(file) > 0 {
+		fmt.Printf("%s: configuration is valid\n", file)
+	} else {
+		fmt.Println("configuration is valid")
+	}
+	return nil
+}
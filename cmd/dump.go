@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+)
+
+// runDump handles the export, import and migrate commands, it returns false
+// for other commands
+func runDump(c config.AppConfig, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "export":
+		return true, exportCmd(c, args[1:])
+	case "import":
+		return true, importCmd(c, args[1:])
+	case "migrate":
+		return true, migrateCmd(c, args[1:])
+	}
+	return false, nil
+}
+
+// exportCmd writes a database archive to a file
+func exportCmd(c config.AppConfig, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dbName := fs.String("db", "", "Name of the database to export")
+	out := fs.String("out", "", "Archive file to create, default to {db}.tar.gz")
+	blobs := fs.Bool("blobs", false, "Include the uploaded files content")
+	portable := fs.Bool("portable", false, "Export an archive that can be imported in any database engine")
+	fs.Parse(args)
+
+	if len(*dbName) == 0 {
+		return errors.New("the -db flag is required")
+	}
+
+	if len(*out) == 0 {
+		*out = *dbName + ".tar.gz"
+	}
+
+	setup(c)
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	m, err := backend.ExportDatabase(backend.DB, backend.Filestore, *dbName, backend.ExportOptions{Blobs: *blobs, Portable: *portable}, f)
+	if err != nil {
+		return err
+	}
+
+	printManifest("exported", m)
+	return f.Close()
+}
+
+// importCmd loads a database archive in the configured database engine
+func importCmd(c config.AppConfig, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	in := fs.String("in", "", "Archive file to import")
+	name := fs.String("name", "", "Import under this database name with a new public key")
+	fs.Parse(args)
+
+	if len(*in) == 0 {
+		return errors.New("the -in flag is required")
+	}
+
+	setup(c)
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	m, err := backend.ImportDatabase(backend.DB, backend.Filestore, f, backend.ImportOptions{Name: *name})
+	if err != nil {
+		return err
+	}
+
+	printManifest("imported", m)
+	return nil
+}
+
+// migrateCmd copies a database from the configured engine to another one
+// without an intermediate file. Between engines not sharing their layout the
+// database is copied as a portable archive, its records and documents get new
+// IDs and it gets a new public key. The uploaded files stay in place.
+func migrateCmd(c config.AppConfig, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbName := fs.String("db", "", "Name of the database to migrate")
+	toStore := fs.String("to", "", "Target data store: postgresql, sqlite or mongo")
+	toURL := fs.String("to-url", "", "Connection URL of the target database")
+	fs.Parse(args)
+
+	if len(*dbName) == 0 || len(*toStore) == 0 || len(*toURL) == 0 {
+		return errors.New("the -db, -to and -to-url flags are required")
+	}
+
+	setup(c)
+
+	dst, err := backend.OpenDatabase(*toStore, *toURL)
+	if err != nil {
+		return err
+	}
+
+	opts := backend.ExportOptions{}
+	if err := backend.CheckDumpLayout(backend.DB.DumpLayout(), dst.DumpLayout()); err != nil {
+		opts.Portable = true
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := backend.ExportDatabase(backend.DB, backend.Filestore, *dbName, opts, pw)
+		pw.CloseWithError(err)
+	}()
+
+	m, err := backend.ImportDatabase(dst, backend.Filestore, pr, backend.ImportOptions{})
+	// unblocks the export if the import stopped early
+	pr.CloseWithError(err)
+	if err != nil {
+		return err
+	}
+
+	printManifest("migrated", m)
+	return nil
+}
+
+func setup(c config.AppConfig) {
+	config.Current = c
+	backend.Setup(c)
+}
+
+func printManifest(action string, m backend.DumpManifest) {
+	var rows int64
+	for _, n := range m.Tables {
+		rows += n
+	}
+
+	fmt.Printf("%s database %s: %d tables, %d rows, %d files\n", action, m.Database, len(m.Tables), rows, m.Blobs)
+}
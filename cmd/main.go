@@ -11,12 +11,10 @@ import (
 )
 
 func main() {
-	c := config.LoadConfig()
-
-	log := logger.Get(c)
-
 	var v bool
+	var configFile string
 	flag.BoolVar(&v, "v", false, "Display the version and build info")
+	flag.StringVar(&configFile, "config", os.Getenv("CONFIG_FILE"), "YAML or TOML configuration file, environment variables take precedence")
 	flag.Parse()
 	if v {
 		fmt.Printf("StaticBackend version %s | %s (%s)\n\n",
@@ -27,6 +25,37 @@ func main() {
 		os.Exit(0)
 	}
 
+	if ok, err := runConfig(configFile, flag.Args()); ok {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	c, err := config.Load(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := c.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	log := logger.Get(c)
+	for _, w := range c.Warnings() {
+		log.Warn().Msg(w)
+	}
+
+	if ok, err := runDump(c, flag.Args()); ok {
+		if err != nil {
+			log.Fatal().Err(err).Msg("command failed")
+		}
+		return
+	}
+
 	if len(c.Port) == 0 {
 		c.Port = "8099"
 	}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	fieldKeys     map[int]string
+	fieldKeysOnce sync.Once
+)
+
+// keyByField maps each AppConfig field index to its environment variable
+// name by loading the config with one variable set at a time to "yes" and
+// "no" and comparing with the defaults
+func keyByField() map[int]string {
+	fieldKeysOnce.Do(func() {
+		fieldKeys = make(map[int]string)
+		defaults := reflect.ValueOf(load(func(string) string { return "" }))
+		for key := range keys() {
+			for _, value := range []string{"yes", "no"} {
+				cfg := load(func(k string) string {
+					if k == key {
+						return value
+					}
+					return ""
+				})
+
+				v := reflect.ValueOf(cfg)
+				for i := 0; i < v.NumField(); i++ {
+					if v.Field(i).Comparable() && !v.Field(i).Equal(defaults.Field(i)) {
+						fieldKeys[i] = key
+					}
+				}
+			}
+		}
+	})
+	return fieldKeys
+}
+
+// Changed returns the environment variable names of the settings that
+// differ between a and b
+func Changed(a, b AppConfig) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+
+	var changed []string
+	for i, key := range keyByField() {
+		if !va.Field(i).Equal(vb.Field(i)) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
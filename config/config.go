@@ -1,12 +1,17 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strings"
+)
 
 var Current AppConfig
 
 type AppConfig struct {
-	// PrimaryInstanceHostname indicates the name of the instance that should run
-	// the scheduler. Only one instance can run the scheduler.
+	// PrimaryInstanceHostname restricts the scheduler leadership to the
+	// instance with this hostname. When empty, all instances are eligible and
+	// another one takes over if the leader stops.
 	PrimaryInstanceHostname string
 
 	// Port web server port
@@ -18,6 +23,9 @@ type AppConfig struct {
 	AppSecret string
 	// AppURL is the full URL of the backend (important for social logins callbacks)
 	AppURL string
+	// TrustedProxies comma separated IPs or CIDRs of the reverse proxies
+	// allowed to set the client IP via X-Forwarded-For and X-Real-IP
+	TrustedProxies string
 	// FromCLI if we're running in the CLI
 	FromCLI string
 
@@ -37,6 +45,16 @@ type AppConfig struct {
 	FromEmail string
 	// FromName used when SB sends email
 	FromName string
+	// SMTPHost is the SMTP server host when using the smtp mail provider
+	SMTPHost string
+	// SMTPPort is the SMTP server port, default to 465 for tls and 587 otherwise
+	SMTPPort string
+	// SMTPUsername used to authenticate with the SMTP server
+	SMTPUsername string
+	// SMTPPassword used to authenticate with the SMTP server
+	SMTPPassword string
+	// SMTPEncryption is either "tls", "starttls" (default) or "none"
+	SMTPEncryption string
 
 	// StripeKey used for Stripe communication
 	StripeKey string
@@ -68,6 +86,13 @@ type AppConfig struct {
 	RedisHost string
 	// RedisPassword if RedisURL is not used, password for Redis
 	RedisPassword string
+	// CacheProvider used as the cache and pub/sub implementation, "redis"
+	// (default), "nats" or "embedded" for single node deployments
+	CacheProvider string
+	// NATSURL URL for NATS when using the nats cache provider
+	NATSURL string
+	// CacheFile is the file of the embedded cache provider
+	CacheFile string
 
 	// S3AccessKey access key for S3 connection
 	S3AccessKey string
@@ -81,8 +106,11 @@ type AppConfig struct {
 	S3Bucket string
 	// S3CDNURL CDN URL
 	S3CDNURL string
+	// DedupFiles if "yes" identical uploaded content is stored once per database
+	DedupFiles bool
 
-	// KeepPermissionInName if "yes" will keep the repo permission in repo name
+	// KeepPermissionInName if "yes" (the default) will keep the repo
+	// permission in repo name, "no" removes it
 	KeepPermissionInName bool
 
 	// LogConsoleLevel could be use to specify the minimum log level is wanted
@@ -97,50 +125,103 @@ type AppConfig struct {
 	FullTextIndexFile string
 	// ActivateFlag when set, the /account/init can bypass Stripe if matching val
 	ActivateFlag string
-	// PluginsPath is the full qualified path where plugins are stored
-	PluginsPath string
+	// MetricsToken is the bearer token required by the /metrics endpoint, it
+	// is disabled when empty
+	MetricsToken string
+	// TracesExporter exports OpenTelemetry traces when "otlp" or "stdout"
+	TracesExporter string
+
+	// ConfigFile is the file the configuration was loaded from, it's read
+	// again when reloading
+	ConfigFile string
+
+	// warnings are the deprecated values found while loading
+	warnings []string
 }
 
+// LoadConfig reads the configuration from the environment variables
 func LoadConfig() AppConfig {
-	return AppConfig{
-		PrimaryInstanceHostname:  os.Getenv("PRIMARY_INSTANCE_HOSTNAME"),
-		Port:                     os.Getenv("PORT"),
-		AppEnv:                   os.Getenv("APP_ENV"),
-		AppSecret:                os.Getenv("APP_SECRET"),
-		AppURL:                   os.Getenv("APP_URL"),
-		FromCLI:                  os.Getenv("SB_FROM_CLI"),
-		DataStore:                os.Getenv("DATA_STORE"),
-		DatabaseURL:              os.Getenv("DATABASE_URL"),
-		MailProvider:             os.Getenv("MAIL_PROVIDER"),
-		FromEmail:                os.Getenv("FROM_EMAIL"),
-		FromName:                 os.Getenv("FROM_NAME"),
-		StorageProvider:          os.Getenv("STORAGE_PROVIDER"),
-		LocalStorageURL:          os.Getenv("LOCAL_STORAGE_URL"),
-		RedisURL:                 os.Getenv("REDIS_URL"),
-		RedisHost:                os.Getenv("REDIS_HOST"),
-		RedisPassword:            os.Getenv("REDIS_PASSWORD"),
-		StripeKey:                os.Getenv("STRIPE_KEY"),
-		StripePriceIDIdea:        os.Getenv("STRIPE_PRICEID_IDEA"),
-		StripePriceIDLaunch:      os.Getenv("STRIPE_PRICEID_LAUNCH"),
-		StripePriceIDTraction:    os.Getenv("STRIPE_PRICEID_TRACTION"),
-		StripePriceIDGrowth:      os.Getenv("STRIPE_PRICEID_GROWTH"),
-		StripeWebhookSecret:      os.Getenv("STRIPE_WEBHOOK_SECRET"),
-		StripeRedirectFromPortal: os.Getenv("STRIPE_REDIRECT"),
-		TwilioAccountID:          os.Getenv("TWILIO_ACCOUNTSID"),
-		TwilioAuthToken:          os.Getenv("TWILIO_AUTHTOKEN"),
-		TwilioTestCellNumber:     os.Getenv("MY_CELL"),
-		TwilioNumber:             os.Getenv("TWILIO_NUMBER"),
-		S3AccessKey:              os.Getenv("S3_ACCESSKEY"),
-		S3SecretKey:              os.Getenv("S3_SECRETKEY"),
-		S3Endpoint:               os.Getenv("S3_ENDPOINT"),
-		S3Region:                 os.Getenv("S3_REGION"),
-		S3Bucket:                 os.Getenv("S3_BUCKET"),
-		S3CDNURL:                 os.Getenv("S3_CDN_URL"),
-		KeepPermissionInName:     os.Getenv("KEEP_PERM_COL_NAME") == "",
-		LogConsoleLevel:          os.Getenv("LOG_CONSOLE_LEVEL"),
-		LogFilename:              os.Getenv("LOG_FILENAME"),
-		FullTextIndexFile:        os.Getenv("FTS_INDEX_FILE"),
-		ActivateFlag:             os.Getenv("ACTIVATE_FLAG"),
-		PluginsPath:              os.Getenv("PLUGINS_PATH"),
+	return load(os.Getenv)
+}
+
+// load reads each setting by its environment variable name via get
+func load(get func(string) string) AppConfig {
+	keepPerm, permWarning := keepPermission(get("KEEP_PERM_COL_NAME"))
+
+	c := AppConfig{
+		PrimaryInstanceHostname:  get("PRIMARY_INSTANCE_HOSTNAME"),
+		Port:                     get("PORT"),
+		AppEnv:                   get("APP_ENV"),
+		AppSecret:                get("APP_SECRET"),
+		AppURL:                   get("APP_URL"),
+		TrustedProxies:           get("TRUSTED_PROXIES"),
+		FromCLI:                  get("SB_FROM_CLI"),
+		DataStore:                get("DATA_STORE"),
+		DatabaseURL:              get("DATABASE_URL"),
+		MailProvider:             get("MAIL_PROVIDER"),
+		FromEmail:                get("FROM_EMAIL"),
+		FromName:                 get("FROM_NAME"),
+		SMTPHost:                 get("SMTP_HOST"),
+		SMTPPort:                 get("SMTP_PORT"),
+		SMTPUsername:             get("SMTP_USERNAME"),
+		SMTPPassword:             get("SMTP_PASSWORD"),
+		SMTPEncryption:           get("SMTP_ENCRYPTION"),
+		StorageProvider:          get("STORAGE_PROVIDER"),
+		LocalStorageURL:          get("LOCAL_STORAGE_URL"),
+		RedisURL:                 get("REDIS_URL"),
+		RedisHost:                get("REDIS_HOST"),
+		RedisPassword:            get("REDIS_PASSWORD"),
+		CacheProvider:            get("CACHE_PROVIDER"),
+		NATSURL:                  get("NATS_URL"),
+		CacheFile:                get("CACHE_FILE"),
+		StripeKey:                get("STRIPE_KEY"),
+		StripePriceIDIdea:        get("STRIPE_PRICEID_IDEA"),
+		StripePriceIDLaunch:      get("STRIPE_PRICEID_LAUNCH"),
+		StripePriceIDTraction:    get("STRIPE_PRICEID_TRACTION"),
+		StripePriceIDGrowth:      get("STRIPE_PRICEID_GROWTH"),
+		StripeWebhookSecret:      get("STRIPE_WEBHOOK_SECRET"),
+		StripeRedirectFromPortal: get("STRIPE_REDIRECT"),
+		TwilioAccountID:          get("TWILIO_ACCOUNTSID"),
+		TwilioAuthToken:          get("TWILIO_AUTHTOKEN"),
+		TwilioTestCellNumber:     get("MY_CELL"),
+		TwilioNumber:             get("TWILIO_NUMBER"),
+		S3AccessKey:              get("S3_ACCESSKEY"),
+		S3SecretKey:              get("S3_SECRETKEY"),
+		S3Endpoint:               get("S3_ENDPOINT"),
+		S3Region:                 get("S3_REGION"),
+		S3Bucket:                 get("S3_BUCKET"),
+		S3CDNURL:                 get("S3_CDN_URL"),
+		DedupFiles:               get("DEDUP_FILES") == "yes",
+		KeepPermissionInName:     keepPerm,
+		LogConsoleLevel:          get("LOG_CONSOLE_LEVEL"),
+		LogFilename:              get("LOG_FILENAME"),
+		FullTextIndexFile:        get("FTS_INDEX_FILE"),
+		ActivateFlag:             get("ACTIVATE_FLAG"),
+		MetricsToken:             get("METRICS_TOKEN"),
+		TracesExporter:           get("OTEL_TRACES_EXPORTER"),
+	}
+
+	if len(permWarning) > 0 {
+		c.warnings = append(c.warnings, permWarning)
+	}
+	return c
+}
+
+// Warnings returns the deprecated values of the configuration, they still
+// apply but should be changed
+func (c AppConfig) Warnings() []string {
+	return c.warnings
+}
+
+// keepPermission parses KEEP_PERM_COL_NAME. Any value used to remove the
+// permission from the collection names, the values other than yes/no still
+// do with a deprecation warning.
+func keepPermission(v string) (keep bool, warning string) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "yes", "true", "1":
+		return true, ""
+	case "no", "false", "0":
+		return false, ""
 	}
+	return false, fmt.Sprintf(`KEEP_PERM_COL_NAME: %q is deprecated and removes the permission from the collection names, use "no" instead`, v)
 }
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadFile(t *testing.T) {
+	yml := writeFile(t, "sb.yaml", `
+app_secret: a-very-long-key-should-be-32long
+DATA_STORE: mem
+database_url: mem
+port: 8100
+dedup_files: true
+keep_perm_col_name: false
+`)
+
+	toml := writeFile(t, "sb.toml", `
+app_secret = "a-very-long-key-should-be-32long"
+DATA_STORE = "mem"
+database_url = "mem"
+port = 8100
+dedup_files = true
+keep_perm_col_name = false
+`)
+
+	for _, file := range []string{yml, toml} {
+		c, err := Load(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.DataStore != "mem" || c.DatabaseURL != "mem" {
+			t.Errorf("%s: expected mem data store got %s %s", file, c.DataStore, c.DatabaseURL)
+		} else if c.Port != "8100" {
+			t.Errorf("%s: expected port 8100 got %s", file, c.Port)
+		} else if !c.DedupFiles || c.KeepPermissionInName {
+			t.Errorf("%s: expected booleans true/false got %v/%v", file, c.DedupFiles, c.KeepPermissionInName)
+		} else if c.ConfigFile != file {
+			t.Errorf("expected ConfigFile %s got %s", file, c.ConfigFile)
+		}
+	}
+}
+
+func TestLoadEnvTakesPrecedence(t *testing.T) {
+	file := writeFile(t, "sb.yml", "data_store: mem\nport: 8100\n")
+
+	t.Setenv("DATA_STORE", "sqlite")
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.DataStore != "sqlite" {
+		t.Errorf("expected env value sqlite got %s", c.DataStore)
+	} else if c.Port != "8100" {
+		t.Errorf("expected file value 8100 got %s", c.Port)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown.yaml": "data_store: mem\ncolour: blue\n",
+		"nested.yaml":  "data_store:\n  engine: mem\n",
+		"sb.json":      "{}",
+	}
+
+	expected := map[string]string{
+		"unknown.yaml": "unknown settings: colour",
+		"nested.yaml":  "data_store must be a string, number or boolean",
+		"sb.json":      "unsupported config file format",
+	}
+
+	for name, content := range tests {
+		_, err := Load(writeFile(t, name, content))
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if !strings.Contains(err.Error(), expected[name]) {
+			t.Errorf("%s: expected error containing %q got %v", name, expected[name], err)
+		}
+	}
+}
+
+func TestKeepPermissionInName(t *testing.T) {
+	tests := []struct {
+		value   string
+		keep    bool
+		warning bool
+	}{
+		{"", true, false},
+		{"yes", true, false},
+		{"true", true, false},
+		{"no", false, false},
+		{"0", false, false},
+		{"remove", false, true},
+	}
+
+	for _, tc := range tests {
+		t.Setenv("KEEP_PERM_COL_NAME", tc.value)
+		c := LoadConfig()
+		if c.KeepPermissionInName != tc.keep {
+			t.Errorf("%q: expected keep %v got %v", tc.value, tc.keep, c.KeepPermissionInName)
+		}
+		if got := len(c.Warnings()) > 0; got != tc.warning {
+			t.Errorf("%q: expected warning %v got %v", tc.value, tc.warning, c.Warnings())
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := AppConfig{
+		AppSecret:   "a-very-long-key-should-be-32long",
+		DataStore:   "mem",
+		DatabaseURL: "mem",
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Port = "web"
+	c.AppSecret = "short"
+	c.DataStore = "oracle"
+	c.MailProvider = "smtp"
+	c.StorageProvider = "S3"
+	c.LogConsoleLevel = "loud"
+	c.TracesExporter = "zipkin"
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	for _, key := range []string{"PORT", "APP_SECRET", "DATA_STORE", "SMTP_HOST", "S3_BUCKET", "LOG_CONSOLE_LEVEL", "OTEL_TRACES_EXPORTER"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("expected an error for %s got %v", key, err)
+		}
+	}
+}
+
+func TestChanged(t *testing.T) {
+	a := AppConfig{Port: "8099", DataStore: "mem", DedupFiles: true, KeepPermissionInName: true}
+	b := a
+	b.DataStore = "sqlite"
+	b.DedupFiles = false
+	b.KeepPermissionInName = false
+	b.ConfigFile = "sb.yaml"
+
+	expected := []string{"DATA_STORE", "DEDUP_FILES", "KEEP_PERM_COL_NAME"}
+	if changed := Changed(a, b); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected %v got %v", expected, changed)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the configuration from a YAML (.yml, .yaml) or TOML (.toml)
+// file and the environment variables. The file keys are the environment
+// variable names (case insensitive) and an environment variable that is set
+// takes precedence over the file. When file is empty only the environment
+// variables are used.
+func Load(file string) (AppConfig, error) {
+	values := make(map[string]string)
+	if len(file) > 0 {
+		v, err := readFile(file)
+		if err != nil {
+			return AppConfig{}, err
+		}
+		values = v
+	}
+
+	get := func(key string) string {
+		if v, ok := os.LookupEnv(key); ok {
+			return v
+		}
+		return values[key]
+	}
+
+	cfg := load(get)
+	cfg.ConfigFile = file
+	return cfg, nil
+}
+
+// keys returns the environment variable names read by load
+func keys() map[string]bool {
+	known := make(map[string]bool)
+	load(func(key string) string {
+		known[key] = true
+		return ""
+	})
+	return known
+}
+
+func readFile(file string) (map[string]string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(b, &raw)
+	case ".toml":
+		err = toml.Unmarshal(b, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", file, err)
+	}
+
+	known := keys()
+
+	var unknown []string
+	values := make(map[string]string)
+	for k, v := range raw {
+		key := strings.ToUpper(k)
+		if !known[key] {
+			unknown = append(unknown, k)
+			continue
+		}
+
+		s, err := scalar(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s %w", file, k, err)
+		}
+		values[key] = s
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%s: unknown settings: %s", file, strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// scalar converts a file value to its environment variable form, booleans
+// become "yes" or "no"
+func scalar(v any) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "", nil
+	case bool:
+		if x {
+			return "yes", nil
+		}
+		return "no", nil
+	case string:
+		return x, nil
+	case int, int64, uint64, float64:
+		return fmt.Sprint(x), nil
+	default:
+		return "", fmt.Errorf("must be a string, number or boolean")
+	}
+}
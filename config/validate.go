@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Validate returns an error listing every invalid setting, the config
+// package can't import the providers' packages so their names are listed
+// here
+func (c AppConfig) Validate() error {
+	var errs []error
+	invalid := func(format string, a ...any) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if strings.EqualFold(value, a) {
+				return
+			}
+		}
+		invalid("%s: unknown value %q, expected one of: %s", key, value, strings.Join(allowed[1:], ", "))
+	}
+
+	if len(c.Port) > 0 {
+		if _, err := strconv.Atoi(c.Port); err != nil {
+			invalid("PORT: %q is not a number", c.Port)
+		}
+	}
+
+	// the secret is used as the AES key
+	switch len(c.AppSecret) {
+	case 16, 24, 32:
+	default:
+		invalid("APP_SECRET: must be 16, 24 or 32 bytes long for AES, got %d", len(c.AppSecret))
+	}
+
+	if _, err := ParseTrustedProxies(c.TrustedProxies); err != nil {
+		invalid("TRUSTED_PROXIES: %v", err)
+	}
+
+	oneOf("DATA_STORE", c.DataStore, "", "pg", "mongo", "sqlite", "mem")
+	if len(c.DatabaseURL) == 0 {
+		invalid("DATABASE_URL: is required")
+	}
+
+	oneOf("CACHE_PROVIDER", c.CacheProvider, "", "redis", "nats", "embedded")
+
+	oneOf("MAIL_PROVIDER", c.MailProvider, "", "dev", "ses", "smtp")
+	if strings.EqualFold(c.MailProvider, "smtp") && len(c.SMTPHost) == 0 {
+		invalid("SMTP_HOST: is required when MAIL_PROVIDER is smtp")
+	}
+	oneOf("SMTP_ENCRYPTION", c.SMTPEncryption, "", "tls", "starttls", "none")
+
+	oneOf("STORAGE_PROVIDER", c.StorageProvider, "", "local", "s3")
+	if strings.EqualFold(c.StorageProvider, "s3") && len(c.S3Bucket) == 0 {
+		invalid("S3_BUCKET: is required when STORAGE_PROVIDER is s3")
+	}
+
+	if len(c.LogConsoleLevel) > 0 {
+		if _, err := zerolog.ParseLevel(c.LogConsoleLevel); err != nil {
+			invalid("LOG_CONSOLE_LEVEL: unknown level %q", c.LogConsoleLevel)
+		}
+	}
+
+	oneOf("OTEL_TRACES_EXPORTER", c.TracesExporter, "", "none", "otlp", "stdout", "console")
+
+	return errors.Join(errs...)
+}
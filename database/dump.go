@@ -0,0 +1,220 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layouts of the dumped rows, an export can only be imported in an engine
+// using the same layout, except the portable archives
+const (
+	// DumpLayoutSQL rows are maps of column names to values, shared by the
+	// SQLite and PostgreSQL engines
+	DumpLayoutSQL = "sql"
+	// DumpLayoutMemory rows are the encoded records of the memory engine
+	DumpLayoutMemory = "memory"
+	// DumpLayoutMongo rows are MongoDB Extended JSON documents
+	DumpLayoutMongo = "mongo"
+	// DumpLayoutPortable archives hold the records and documents in an
+	// engine-neutral form, they are imported in any engine
+	DumpLayoutPortable = "portable"
+)
+
+// DumpValue converts a value scanned from a column of colType to a portable
+// JSON value
+func DumpValue(colType string, v any) any {
+	colType = strings.ToUpper(colType)
+
+	switch x := v.(type) {
+	case []byte:
+		switch colType {
+		case "JSON", "JSONB":
+			if json.Valid(x) {
+				return json.RawMessage(x)
+			}
+			return string(x)
+		case "BLOB", "BYTEA":
+			// encoded as base64 by encoding/json
+			return x
+		default:
+			return string(x)
+		}
+	case string:
+		if (colType == "JSON" || colType == "JSONB") && json.Valid([]byte(x)) {
+			return json.RawMessage(x)
+		}
+	case int64:
+		if colType == "BOOLEAN" || colType == "BOOL" {
+			return x != 0
+		}
+	}
+	return v
+}
+
+// RestoreValue converts a portable JSON value decoded with UseNumber to the
+// value inserted in a column of colType
+func RestoreValue(colType string, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch strings.ToUpper(colType) {
+	case "JSON", "JSONB":
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case "BLOB", "BYTEA":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a base64 string got %v", v)
+		}
+		return base64.StdEncoding.DecodeString(s)
+	case "TIMESTAMP", "TIMESTAMPTZ", "DATETIME", "DATE":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a timestamp got %v", v)
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case "BOOLEAN", "BOOL":
+		if n, ok := v.(json.Number); ok {
+			return n.String() != "0", nil
+		}
+		return v, nil
+	}
+
+	switch x := v.(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n, nil
+		}
+		return x.Float64()
+	case map[string]any, []any:
+		b, err := json.Marshal(x)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+	return v, nil
+}
+
+// DumpSQLRows calls fn with each row as a map of column names to portable
+// values
+func DumpSQLRows(rows *sql.Rows, fn func(row map[string]any) error) error {
+	defer rows.Close()
+
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		values := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+
+		row := make(map[string]any)
+		for i, col := range cols {
+			row[col.Name()] = DumpValue(col.DatabaseTypeName(), values[i])
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DumpSQLTables reads the tables in a transaction and calls fn with each row,
+// the transaction isolation gives a consistent snapshot of all tables
+func DumpSQLTables(tx *sql.Tx, tableName func(table string) string, tables []string, fn func(table string, row map[string]any) error) error {
+	for _, table := range tables {
+		rows, err := tx.Query(fmt.Sprintf("SELECT * FROM %s", tableName(table)))
+		if err != nil {
+			return err
+		}
+
+		err = DumpSQLRows(rows, func(row map[string]any) error {
+			return fn(table, row)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreSQLRows inserts dumped rows in a table, columns missing from the
+// table are ignored and null or missing values get the column default
+func RestoreSQLRows(db *sql.DB, table string, rows []map[string]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	types := make(map[string]string)
+
+	res, err := db.Query(fmt.Sprintf("SELECT * FROM %s LIMIT 0", table))
+	if err != nil {
+		return err
+	}
+
+	cols, err := res.ColumnTypes()
+	res.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, col := range cols {
+		types[col.Name()] = col.DatabaseTypeName()
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		var names, params []string
+		var values []any
+		for name, v := range row {
+			colType, ok := types[name]
+			if !ok || v == nil {
+				continue
+			}
+
+			val, err := RestoreValue(colType, v)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s.%s: %w", table, name, err)
+			}
+
+			names = append(names, fmt.Sprintf(`"%s"`, name))
+			values = append(values, val)
+			params = append(params, fmt.Sprintf("$%d", len(values)))
+		}
+
+		qry := fmt.Sprintf(
+			"INSERT INTO %s(%s) VALUES(%s)",
+			table,
+			strings.Join(names, ", "),
+			strings.Join(params, ", "),
+		)
+		if _, err := tx.Exec(qry, values...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
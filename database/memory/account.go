@@ -119,3 +119,8 @@ func (m *Memory) GetUserByID(dbName, accountID, userID string) (user model.User,
 	err = getByID(m, dbName, "sb_tokens", userID, &user)
 	return
 }
+
+func (m *Memory) FindUserByID(dbName, userID string) (user model.User, err error) {
+	err = getByID(m, dbName, "sb_tokens", userID, &user)
+	return
+}
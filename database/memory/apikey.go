@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) CreateAPIKey(dbName string, k model.APIKey) (id string, err error) {
+	id = m.NewID()
+	k.ID = id
+
+	err = create(m, dbName, "sb_apikeys", id, k)
+	return
+}
+
+func (m *Memory) GetAPIKeyByHash(dbName, hash string) (k model.APIKey, err error) {
+	list, err := all[model.APIKey](m, dbName, "sb_apikeys")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.APIKey) bool {
+		return x.KeyHash == hash
+	})
+
+	if len(list) == 0 {
+		err = errors.New("api key not found")
+		return
+	}
+
+	k = list[0]
+	return
+}
+
+func (m *Memory) ListAPIKeys(dbName string) (results []model.APIKey, err error) {
+	list, err := all[model.APIKey](m, dbName, "sb_apikeys")
+	if err != nil {
+		return
+	}
+
+	results = sortSlice(list, func(a, b model.APIKey) bool {
+		return a.Created.After(b.Created)
+	})
+	return
+}
+
+func (m *Memory) TouchAPIKey(dbName, id string, lastUsed time.Time, ip string) error {
+	var k model.APIKey
+	if err := getByID(m, dbName, "sb_apikeys", id, &k); err != nil {
+		return err
+	} else if len(k.ID) == 0 {
+		return errors.New("api key not found")
+	}
+
+	k.LastUsed = lastUsed
+	k.LastIP = ip
+
+	return create(m, dbName, "sb_apikeys", id, k)
+}
+
+func (m *Memory) DeleteAPIKey(dbName, id string) error {
+	key := fmt.Sprintf("%s_sb_apikeys", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], id)
+	mx.Unlock()
+	return nil
+}
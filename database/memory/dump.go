@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) DumpLayout() string {
+	return database.DumpLayoutMemory
+}
+
+func (m *Memory) DumpTables(dbName string) ([]string, error) {
+	return m.ListCollections(dbName)
+}
+
+// DumpRows returns the encoded records as is, they can only be restored in
+// another memory database
+func (m *Memory) DumpRows(dbName string, tables []string, fn func(table string, row map[string]any) error) error {
+	// the records are copied first to get a consistent snapshot
+	snapshot := make(map[string]map[string][]byte)
+
+	mx.RLock()
+	for _, table := range tables {
+		repo := m.DB[fmt.Sprintf("%s_%s", dbName, table)]
+
+		records := make(map[string][]byte, len(repo))
+		for id, b := range repo {
+			records[id] = b
+		}
+		snapshot[table] = records
+	}
+	mx.RUnlock()
+
+	for _, table := range tables {
+		records := snapshot[table]
+
+		ids := make([]string, 0, len(records))
+		for id := range records {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			if err := fn(table, map[string]any{"id": id, "gob": records[id]}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Memory) RestoreTenant(cus model.Tenant, base model.DatabaseConfig) error {
+	var existing model.Tenant
+	if err := getByID(m, "sb", "customers", cus.ID, &existing); err != nil || len(existing.ID) == 0 {
+		if err := create(m, "sb", "customers", cus.ID, cus); err != nil {
+			return err
+		}
+	}
+
+	return create(m, "sb", "apps", base.ID, base)
+}
+
+func (m *Memory) RestoreRows(dbName, table string, rows []map[string]any) error {
+	key := fmt.Sprintf("%s_%s", dbName, table)
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	repo, ok := m.DB[key]
+	if !ok {
+		repo = make(map[string][]byte)
+		m.DB[key] = repo
+	}
+
+	for _, row := range rows {
+		id, ok := row["id"].(string)
+		if !ok {
+			return fmt.Errorf("invalid row id in %s: %v", table, row["id"])
+		}
+
+		var b []byte
+		switch v := row["gob"].(type) {
+		case []byte:
+			b = v
+		case string:
+			data, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return err
+			}
+			b = data
+		default:
+			return fmt.Errorf("invalid row content in %s for %s", table, id)
+		}
+
+		repo[id] = b
+	}
+	return nil
+}
+
+func (m *Memory) DumpDocuments(dbName string, cols []string, fn func(col string, doc model.PortableDocument) error) error {
+	for _, col := range cols {
+		docs, err := all[map[string]any](m, dbName, col)
+		if err != nil {
+			return err
+		}
+
+		list := make([]model.PortableDocument, 0, len(docs))
+		for _, doc := range docs {
+			list = append(list, toPortableDocument(doc))
+		}
+
+		list = sortSlice(list, func(a, b model.PortableDocument) bool {
+			return a.ID < b.ID
+		})
+
+		for _, doc := range list {
+			if err := fn(col, doc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Memory) RestoreDocuments(dbName, col string, docs []model.PortableDocument) error {
+	key := fmt.Sprintf("%s_%s", dbName, col)
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	// collections are created even when empty
+	repo, ok := m.DB[key]
+	if !ok {
+		repo = make(map[string][]byte)
+		m.DB[key] = repo
+	}
+
+	for _, pd := range docs {
+		doc := make(map[string]any, len(pd.Data)+4)
+		for k, v := range pd.Data {
+			doc[k] = v
+		}
+
+		doc[FieldID] = pd.ID
+		doc[FieldAccountID] = pd.AccountID
+		doc[FieldOwnerID] = pd.OwnerID
+		doc[FieldCreated] = pd.Created
+
+		repo[pd.ID] = mustEnc(doc)
+	}
+	return nil
+}
+
+func (m *Memory) RestoreAccount(dbName string, a model.Account) error {
+	return create(m, dbName, "sb_accounts", a.ID, a)
+}
+
+func (m *Memory) RestoreUser(dbName string, tok model.User) error {
+	return create(m, dbName, "sb_tokens", tok.ID, tok)
+}
+
+func (m *Memory) RestoreFile(dbName string, f model.File) error {
+	return create(m, dbName, "sb_files", f.ID, f)
+}
+
+func toPortableDocument(doc map[string]any) model.PortableDocument {
+	pd := model.PortableDocument{Data: make(map[string]any)}
+	for k, v := range doc {
+		switch k {
+		case FieldID:
+			pd.ID, _ = v.(string)
+		case FieldAccountID:
+			pd.AccountID, _ = v.(string)
+		case FieldOwnerID:
+			pd.OwnerID, _ = v.(string)
+		case FieldCreated:
+			pd.Created, _ = v.(time.Time)
+		default:
+			pd.Data[k] = v
+		}
+	}
+	return pd
+}
+
+func (m *Memory) DeleteDatabase(dbName string) error {
+	bases, err := all[model.DatabaseConfig](m, "sb", "apps")
+	if err != nil {
+		return err
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	for key := range m.DB {
+		if strings.HasPrefix(key, dbName+"_") {
+			delete(m.DB, key)
+		}
+	}
+
+	for _, base := range bases {
+		if base.Name == dbName {
+			delete(m.DB["sb_apps"], base.ID)
+		}
+	}
+	return nil
+}
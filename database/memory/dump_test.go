@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestDumpAndRestore(t *testing.T) {
+	col := "dump_tests"
+
+	doc, err := datastore.CreateDocument(adminAuth, confDBName, col, map[string]interface{}{"name": "dumped"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := datastore.FindDatabaseByName(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cus, err := datastore.FindTenant(conf.TenantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := model.DatabaseConfig{
+		ID:            datastore.NewID(),
+		TenantID:      cus.ID,
+		Name:          fmt.Sprintf("dump%d", time.Now().UnixNano()),
+		AllowedDomain: []string{"localhost"},
+		IsActive:      true,
+		Created:       time.Now(),
+		MFARole:       2,
+	}
+
+	// restoring an existing tenant only adds the database
+	if err := datastore.RestoreTenant(cus, base); err != nil {
+		t.Fatal(err)
+	}
+
+	tables := []string{"sb_accounts", "sb_tokens", col}
+	dumped := make(map[string][]map[string]any)
+	err = datastore.DumpRows(confDBName, tables, func(table string, row map[string]any) error {
+		// same encoding as the export archive
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+
+		var restored map[string]any
+		if err := dec.Decode(&restored); err != nil {
+			return err
+		}
+
+		dumped[table] = append(dumped[table], restored)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range tables {
+		if err := datastore.RestoreRows(base.Name, table, dumped[table]); err != nil {
+			t.Fatalf("restoring %s: %v", table, err)
+		}
+	}
+
+	check, err := datastore.FindDatabaseByName(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.ID != base.ID || check.MFARole != 2 {
+		t.Errorf("expected the database to keep its ID and settings got %v", check)
+	}
+
+	user, err := datastore.FindUserByID(base.Name, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if user.Email != adminToken.Email || user.AccountID != adminToken.AccountID {
+		t.Errorf("expected the restored user got %v", user)
+	}
+
+	id := fmt.Sprintf("%v", doc[FieldID])
+	restored, err := datastore.GetDocumentByID(adminAuth, base.Name, col, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if restored["name"] != "dumped" || fmt.Sprintf("%v", restored[FieldID]) != id {
+		t.Errorf("expected the restored document with its ID got %v", restored)
+	}
+
+	if err := datastore.DeleteDatabase(base.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, err := datastore.DatabaseExists(base.Name); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Error("expected the database to be deleted")
+	}
+
+	if _, err := datastore.FindTenant(cus.ID); err != nil {
+		t.Errorf("expected the tenant to be kept got %v", err)
+	}
+}
+
+func TestDumpDocumentsAndRestore(t *testing.T) {
+	col := "portable_tests"
+
+	doc, err := datastore.CreateDocument(adminAuth, confDBName, col, map[string]interface{}{"name": "portable", "views": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var docs []model.PortableDocument
+	err = datastore.DumpDocuments(confDBName, []string{col}, func(c string, pd model.PortableDocument) error {
+		if c != col {
+			t.Errorf("expected collection %s got %s", col, c)
+		}
+		docs = append(docs, pd)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(docs) != 1 {
+		t.Fatalf("expected 1 document got %d", len(docs))
+	}
+
+	pd := docs[0]
+	if pd.ID != fmt.Sprintf("%v", doc[FieldID]) || pd.AccountID != adminAuth.AccountID || pd.OwnerID != adminAuth.UserID {
+		t.Errorf("expected normalised system fields got %v", pd)
+	} else if pd.Created.IsZero() {
+		t.Error("expected the creation time to be dumped")
+	} else if _, ok := pd.Data[FieldID]; ok {
+		t.Errorf("expected the data to exclude system fields got %v", pd.Data)
+	}
+
+	restoredCol := "portable_restored"
+	if err := datastore.RestoreDocuments(confDBName, restoredCol, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := datastore.GetDocumentByID(adminAuth, confDBName, restoredCol, pd.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if restored["name"] != "portable" || fmt.Sprintf("%v", restored[FieldID]) != pd.ID {
+		t.Errorf("expected the restored document with its ID got %v", restored)
+	}
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) SaveEmailTemplate(dbName string, tmpl model.EmailTemplate) error {
+	exists, err := m.GetEmailTemplate(dbName, tmpl.Name)
+	if err == nil {
+		tmpl.ID = exists.ID
+	} else {
+		tmpl.ID = m.NewID()
+	}
+
+	tmpl.Updated = time.Now()
+	return create(m, dbName, "sb_email_templates", tmpl.ID, tmpl)
+}
+
+func (m *Memory) GetEmailTemplate(dbName, name string) (tmpl model.EmailTemplate, err error) {
+	list, err := all[model.EmailTemplate](m, dbName, "sb_email_templates")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.EmailTemplate) bool {
+		return x.Name == name
+	})
+
+	if len(list) != 1 {
+		err = errors.New("email template not found")
+		return
+	}
+
+	tmpl = list[0]
+	return
+}
+
+func (m *Memory) ListEmailTemplates(dbName string) (results []model.EmailTemplate, err error) {
+	list, err := all[model.EmailTemplate](m, dbName, "sb_email_templates")
+	if err != nil {
+		return
+	}
+
+	results = sortSlice(list, func(a, b model.EmailTemplate) bool {
+		return a.Name < b.Name
+	})
+	return
+}
+
+func (m *Memory) DeleteEmailTemplate(dbName, name string) error {
+	tmpl, err := m.GetEmailTemplate(dbName, name)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s_sb_email_templates", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], tmpl.ID)
+	mx.Unlock()
+	return nil
+}
+
+func (m *Memory) AddEmailLog(dbName string, entry model.EmailLog) (id string, err error) {
+	id = m.NewID()
+	entry.ID = id
+	err = create(m, dbName, "sb_email_logs", id, entry)
+	return
+}
+
+func (m *Memory) ListEmailLogs(dbName string) (results []model.EmailLog, err error) {
+	list, err := all[model.EmailLog](m, dbName, "sb_email_logs")
+	if err != nil {
+		return
+	}
+
+	results = sortSlice(list, func(a, b model.EmailLog) bool {
+		return a.Sent.After(b.Sent)
+	})
+
+	if len(results) > 100 {
+		results = results[:100]
+	}
+	return
+}
@@ -2,6 +2,8 @@ package memory
 
 import (
 	"testing"
+
+	"github.com/staticbackendhq/core/model"
 )
 
 func TestForm(t *testing.T) {
@@ -31,3 +33,52 @@ func TestForm(t *testing.T) {
 		t.Errorf("expected forms[0] to be test got %s", forms[0])
 	}
 }
+
+func TestFormDefinition(t *testing.T) {
+	def := model.FormDefinition{
+		Name: "contact",
+		Fields: []model.FormField{
+			{Name: "email", Type: model.FormFieldEmail, Required: true},
+			{Name: "message", MaxLength: 500},
+		},
+		RedirectURL: "https://test.com/thanks",
+	}
+
+	if err := datastore.SaveFormDefinition(confDBName, def); err != nil {
+		t.Fatal(err)
+	}
+
+	def.NotifyEmail = "owner@test.com"
+	if err := datastore.SaveFormDefinition(confDBName, def); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetFormDefinition(confDBName, def.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(check.ID) == 0 {
+		t.Fatal("expected the form definition to exists")
+	} else if check.NotifyEmail != def.NotifyEmail {
+		t.Errorf("expected notify email to be %s got %s", def.NotifyEmail, check.NotifyEmail)
+	} else if len(check.Fields) != 2 || !check.Fields[0].Required || check.Fields[1].MaxLength != 500 {
+		t.Errorf("fields are not as expected %v", check.Fields)
+	}
+
+	list, err := datastore.ListFormDefinitions(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 {
+		t.Errorf("expected 1 form definition got %d", len(list))
+	}
+
+	if err := datastore.DeleteFormDefinition(confDBName, def.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err = datastore.GetFormDefinition(confDBName, def.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(check.ID) > 0 {
+		t.Errorf("expected the form definition to be deleted")
+	}
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) SaveFormDefinition(dbName string, def model.FormDefinition) error {
+	exists, err := m.GetFormDefinition(dbName, def.Name)
+	if err != nil {
+		return err
+	}
+
+	def.ID = exists.ID
+	if len(def.ID) == 0 {
+		def.ID = m.NewID()
+	}
+
+	def.Updated = time.Now()
+	return create(m, dbName, "sb_form_definitions", def.ID, def)
+}
+
+func (m *Memory) GetFormDefinition(dbName, name string) (def model.FormDefinition, err error) {
+	list, err := all[model.FormDefinition](m, dbName, "sb_form_definitions")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.FormDefinition) bool {
+		return x.Name == name
+	})
+
+	if len(list) > 0 {
+		def = list[0]
+	}
+	return
+}
+
+func (m *Memory) ListFormDefinitions(dbName string) (results []model.FormDefinition, err error) {
+	list, err := all[model.FormDefinition](m, dbName, "sb_form_definitions")
+	if err != nil {
+		return
+	}
+
+	results = sortSlice(list, func(a, b model.FormDefinition) bool {
+		return a.Name < b.Name
+	})
+	return
+}
+
+func (m *Memory) DeleteFormDefinition(dbName, name string) error {
+	def, err := m.GetFormDefinition(dbName, name)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s_sb_form_definitions", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], def.ID)
+	mx.Unlock()
+	return nil
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) SaveRole(dbName string, role model.Role) error {
+	// roles are keyed by their name
+	return create(m, dbName, "sb_roles", role.Name, role)
+}
+
+func (m *Memory) ListRoles(dbName string) (results []model.Role, err error) {
+	list, err := all[model.Role](m, dbName, "sb_roles")
+	if err != nil {
+		return
+	}
+
+	results = sortSlice(list, func(a, b model.Role) bool {
+		return a.Name < b.Name
+	})
+	return
+}
+
+func (m *Memory) DeleteRole(dbName, name string) error {
+	key := fmt.Sprintf("%s_sb_roles", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], name)
+	mx.Unlock()
+	return nil
+}
+
+func (m *Memory) CreateGroup(dbName string, g model.Group) (id string, err error) {
+	list, err := m.ListGroups(dbName, g.AccountID)
+	if err != nil {
+		return
+	}
+
+	for _, exists := range list {
+		if exists.Name == g.Name {
+			err = fmt.Errorf("group %s already exists", g.Name)
+			return
+		}
+	}
+
+	id = m.NewID()
+	g.ID = id
+
+	err = create(m, dbName, "sb_groups", id, g)
+	return
+}
+
+func (m *Memory) GetGroup(dbName, accountID, id string) (g model.Group, err error) {
+	if err = getByID(m, dbName, "sb_groups", id, &g); err != nil {
+		return
+	} else if g.AccountID != accountID {
+		err = errors.New("group not found")
+	}
+	return
+}
+
+func (m *Memory) UpdateGroup(dbName string, g model.Group) error {
+	exists, err := m.GetGroup(dbName, g.AccountID, g.ID)
+	if err != nil {
+		return err
+	}
+
+	exists.Name = g.Name
+	exists.Roles = g.Roles
+
+	return create(m, dbName, "sb_groups", exists.ID, exists)
+}
+
+func (m *Memory) ListGroups(dbName, accountID string) (results []model.Group, err error) {
+	list, err := all[model.Group](m, dbName, "sb_groups")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.Group) bool {
+		return x.AccountID == accountID
+	})
+
+	results = sortSlice(list, func(a, b model.Group) bool {
+		return a.Name < b.Name
+	})
+	return
+}
+
+func (m *Memory) DeleteGroup(dbName, accountID, id string) error {
+	if _, err := m.GetGroup(dbName, accountID, id); err != nil {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s_sb_groups", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], id)
+	mx.Unlock()
+	return nil
+}
+
+func (m *Memory) AddGroupMember(dbName, groupID, userID string) error {
+	var g model.Group
+	if err := getByID(m, dbName, "sb_groups", groupID, &g); err != nil {
+		return err
+	}
+
+	for _, member := range g.Members {
+		if member == userID {
+			return nil
+		}
+	}
+
+	g.Members = append(g.Members, userID)
+	return create(m, dbName, "sb_groups", groupID, g)
+}
+
+func (m *Memory) RemoveGroupMember(dbName, groupID, userID string) error {
+	var g model.Group
+	if err := getByID(m, dbName, "sb_groups", groupID, &g); err != nil {
+		return err
+	}
+
+	g.Members = filter(g.Members, func(x string) bool {
+		return x != userID
+	})
+	return create(m, dbName, "sb_groups", groupID, g)
+}
+
+func (m *Memory) ListUserGroups(dbName, userID string) (results []model.Group, err error) {
+	list, err := all[model.Group](m, dbName, "sb_groups")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.Group) bool {
+		for _, member := range x.Members {
+			if member == userID {
+				return true
+			}
+		}
+		return false
+	})
+
+	results = sortSlice(list, func(a, b model.Group) bool {
+		return a.Name < b.Name
+	})
+	return
+}
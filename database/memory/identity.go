@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) CreateIdentity(dbName string, idt model.Identity) (id string, err error) {
+	exists, err := m.FindIdentity(dbName, idt.Provider, idt.Subject)
+	if err != nil {
+		return
+	} else if len(exists.ID) > 0 {
+		err = fmt.Errorf("identity %s already exists for provider %s", idt.Subject, idt.Provider)
+		return
+	}
+
+	id = m.NewID()
+	idt.ID = id
+
+	err = create(m, dbName, "sb_identities", id, idt)
+	return
+}
+
+func (m *Memory) FindIdentity(dbName, provider, subject string) (idt model.Identity, err error) {
+	list, err := all[model.Identity](m, dbName, "sb_identities")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.Identity) bool {
+		return x.Provider == provider && x.Subject == subject
+	})
+
+	if len(list) > 0 {
+		idt = list[0]
+	}
+	return
+}
+
+func (m *Memory) ListIdentities(dbName, userID string) (results []model.Identity, err error) {
+	list, err := all[model.Identity](m, dbName, "sb_identities")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.Identity) bool {
+		return x.UserID == userID
+	})
+
+	results = sortSlice(list, func(a, b model.Identity) bool {
+		return a.Created.Before(b.Created)
+	})
+	return
+}
+
+func (m *Memory) DeleteIdentity(dbName, userID, id string) error {
+	var idt model.Identity
+	if err := getByID(m, dbName, "sb_identities", id, &idt); err != nil {
+		return err
+	} else if idt.UserID != userID {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s_sb_identities", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], id)
+	mx.Unlock()
+	return nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) CreateInvitation(dbName string, inv model.Invitation) (id string, err error) {
+	id = m.NewID()
+	inv.ID = id
+
+	err = create(m, dbName, "sb_invitations", id, inv)
+	return
+}
+
+func (m *Memory) GetInvitation(dbName, id string) (inv model.Invitation, err error) {
+	if err = getByID(m, dbName, "sb_invitations", id, &inv); err != nil {
+		return
+	} else if len(inv.ID) == 0 {
+		err = errors.New("invitation not found")
+	}
+	return
+}
+
+func (m *Memory) ListInvitations(dbName, accountID string) (results []model.Invitation, err error) {
+	list, err := all[model.Invitation](m, dbName, "sb_invitations")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.Invitation) bool {
+		return x.AccountID == accountID
+	})
+
+	results = sortSlice(list, func(a, b model.Invitation) bool {
+		return a.Created.After(b.Created)
+	})
+	return
+}
+
+func (m *Memory) RenewInvitation(dbName, id, nonce string, expires time.Time) error {
+	inv, err := m.GetInvitation(dbName, id)
+	if err != nil {
+		return err
+	}
+
+	inv.Nonce = nonce
+	inv.Expires = expires
+
+	return create(m, dbName, "sb_invitations", id, inv)
+}
+
+func (m *Memory) DeleteInvitation(dbName, id string) error {
+	key := fmt.Sprintf("%s_sb_invitations", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], id)
+	mx.Unlock()
+	return nil
+}
@@ -44,11 +44,12 @@ func (m *Memory) ResetPassword(dbName, email, code, password string) error {
 	tok, err := m.FindUserByEmail(dbName, email)
 	if err != nil {
 		return err
-	} else if tok.ResetCode != code {
+	} else if len(tok.ResetCode) == 0 || tok.ResetCode != code {
 		return fmt.Errorf("invalid code")
 	}
 
 	tok.Password = password
+	tok.ResetCode = ""
 	return create(m, dbName, "sb_tokens", tok.ID, tok)
 }
 
@@ -72,6 +73,26 @@ func (m *Memory) UserSetPassword(dbName, tokenID, password string) error {
 	return create(m, dbName, "sb_tokens", tok.ID, tok)
 }
 
+func (m *Memory) SetUserVerified(dbName, userID string, verified bool) error {
+	var tok model.User
+	if err := getByID(m, dbName, "sb_tokens", userID, &tok); err != nil {
+		return err
+	}
+
+	tok.Verified = verified
+	return create(m, dbName, "sb_tokens", tok.ID, tok)
+}
+
+func (m *Memory) UpdateUserProfile(dbName, userID string, p model.UserProfile) error {
+	var tok model.User
+	if err := getByID(m, dbName, "sb_tokens", userID, &tok); err != nil {
+		return err
+	}
+
+	tok.UserProfile = p
+	return create(m, dbName, "sb_tokens", tok.ID, tok)
+}
+
 func (m *Memory) RemoveUser(auth model.Auth, dbName, userID string) error {
 	key := fmt.Sprintf("%s_sb_tokens", dbName)
 	docs, ok := m.DB[key]
@@ -160,3 +160,46 @@ func TestUserAddRemoveFromAccount(t *testing.T) {
 		t.Error("new user is still in account users?")
 	}
 }
+
+func TestResetPasswordInvalidCode(t *testing.T) {
+	if err := datastore.SetPasswordResetCode(confDBName, adminToken.ID, "valid_code"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := datastore.ResetPassword(confDBName, adminEmail, "wrong_code", "nope"); err == nil {
+		t.Error("expected an error with an invalid code")
+	}
+
+	if err := datastore.ResetPassword(confDBName, adminEmail, "valid_code", adminPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	// a reset code can only be used once
+	if err := datastore.ResetPassword(confDBName, adminEmail, "valid_code", "nope"); err == nil {
+		t.Error("expected an error when reusing a reset code")
+	}
+}
+
+func TestSetUserVerified(t *testing.T) {
+	if err := datastore.SetUserVerified(confDBName, adminToken.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := datastore.FindUser(confDBName, adminToken.ID, adminToken.Token)
+	if err != nil {
+		t.Fatal(err)
+	} else if tok.Verified {
+		t.Error("expected user to be unverified")
+	}
+
+	if err := datastore.SetUserVerified(confDBName, adminToken.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err = datastore.FindUser(confDBName, adminToken.ID, adminToken.Token)
+	if err != nil {
+		t.Fatal(err)
+	} else if !tok.Verified {
+		t.Error("expected user to be verified")
+	}
+}
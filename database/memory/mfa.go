@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) SaveMFA(dbName string, mfa model.MFA) error {
+	mfa.Updated = time.Now()
+	return create(m, dbName, "sb_mfa", mfa.UserID, mfa)
+}
+
+func (m *Memory) GetMFA(dbName, userID string) (mfa model.MFA, err error) {
+	list, err := all[model.MFA](m, dbName, "sb_mfa")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.MFA) bool {
+		return x.UserID == userID
+	})
+
+	if len(list) > 0 {
+		mfa = list[0]
+	}
+	return
+}
+
+func (m *Memory) DeleteMFA(dbName, userID string) error {
+	key := fmt.Sprintf("%s_sb_mfa", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], userID)
+	mx.Unlock()
+	return nil
+}
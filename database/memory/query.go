@@ -61,7 +61,7 @@ func secureRead(auth model.Auth, col string, list []map[string]any) []map[string
 
 	// if they're not root and repo is not public
 	if !strings.HasPrefix(col, "pub_") && auth.Role < 100 {
-		switch internal.ReadPermission(col) {
+		switch auth.ReadPermission(col) {
 		case internal.PermGroup:
 			filter[FieldAccountID] = auth.AccountID
 		case internal.PermOwner:
@@ -90,7 +90,7 @@ func secureRead(auth model.Auth, col string, list []map[string]any) []map[string
 func canWrite(auth model.Auth, col string, doc map[string]any) bool {
 	// if they are not "root", we use permission
 	if auth.Role < 100 {
-		switch internal.WritePermission(col) {
+		switch auth.WritePermission(col) {
 		case internal.PermGroup:
 			return doc[FieldAccountID] == auth.AccountID
 		case internal.PermOwner:
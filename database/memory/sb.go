@@ -164,6 +164,69 @@ func (m *Memory) EnableExternalLogin(tenantID string, config map[string]model.OA
 	return create(m, "sb", "customers", tenantID, cus)
 }
 
+func (m *Memory) SetSMSConfig(baseID string, config model.SMSConfig) error {
+	b, err := model.EncryptSMSConfig(config)
+	if err != nil {
+		return err
+	}
+
+	base, err := m.FindDatabase(baseID)
+	if err != nil {
+		return err
+	}
+
+	base.SMSConfig = b
+	return create(m, "sb", "apps", baseID, base)
+}
+
+func (m *Memory) SetAllowedDomains(baseID string, domains []string) error {
+	base, err := m.FindDatabase(baseID)
+	if err != nil {
+		return err
+	}
+
+	base.AllowedDomain = domains
+	return create(m, "sb", "apps", baseID, base)
+}
+
+func (m *Memory) SetMFARole(baseID string, role int) error {
+	base, err := m.FindDatabase(baseID)
+	if err != nil {
+		return err
+	}
+
+	base.MFARole = role
+	return create(m, "sb", "apps", baseID, base)
+}
+
+func (m *Memory) SetAuthPolicy(baseID string, policy model.AuthPolicy) error {
+	base, err := m.FindDatabase(baseID)
+	if err != nil {
+		return err
+	}
+
+	base.AuthPolicy = policy
+	return create(m, "sb", "apps", baseID, base)
+}
+
+func (m *Memory) FindDatabaseByName(name string) (base model.DatabaseConfig, err error) {
+	list, err := all[model.DatabaseConfig](m, "sb", "apps")
+	if err != nil {
+		return
+	}
+
+	results := filter(list, func(x model.DatabaseConfig) bool {
+		return x.Name == name
+	})
+
+	if len(results) != 1 {
+		return base, fmt.Errorf("cannot find database %s", name)
+	}
+
+	base = results[0]
+	return
+}
+
 func (m *Memory) DeleteTenant(dbName, email string) error {
 	return nil
 }
@@ -30,9 +30,25 @@ func (m *Memory) ListTasksByBase(dbName string) ([]model.Task, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	for i := range tasks {
+		tasks[i].BaseName = dbName
+	}
 	return tasks, nil
 }
 
+func (m *Memory) GetTask(dbName, id string) (task model.Task, err error) {
+	if err = getByID(m, dbName, "sb_tasks", id, &task); err != nil {
+		return
+	} else if len(task.ID) == 0 {
+		err = errors.New("task not found")
+		return
+	}
+
+	task.BaseName = dbName
+	return
+}
+
 func (m *Memory) AddTask(dbName string, task model.Task) (id string, err error) {
 	id = m.NewID()
 	task.ID = id
@@ -53,5 +69,76 @@ func (m *Memory) DeleteTask(dbName, id string) error {
 	mx.Lock()
 	m.DB[key] = tasks
 	mx.Unlock()
+
+	runs, err := m.ListTaskRuns(dbName, id)
+	if err != nil {
+		return err
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	for _, run := range runs {
+		delete(m.DB[dbName+"_sb_task_runs"], run.ID)
+	}
+	return nil
+}
+
+func (m *Memory) SetTaskPaused(dbName, id string, paused bool) error {
+	task, err := m.GetTask(dbName, id)
+	if err != nil {
+		return err
+	}
+
+	task.Paused = paused
+	return create(m, dbName, "sb_tasks", id, task)
+}
+
+func (m *Memory) RanTask(dbName string, run model.TaskRun) error {
+	task, err := m.GetTask(dbName, run.TaskID)
+	if err != nil {
+		return err
+	}
+
+	task.LastRun = run.Started
+	if err := create(m, dbName, "sb_tasks", task.ID, task); err != nil {
+		return err
+	}
+
+	run.ID = m.NewID()
+	if err := create(m, dbName, "sb_task_runs", run.ID, run); err != nil {
+		return err
+	}
+
+	// only the most recent runs are kept
+	runs, err := m.ListTaskRuns(dbName, run.TaskID)
+	if err != nil {
+		return err
+	} else if len(runs) <= model.MaxTaskRuns {
+		return nil
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	for _, old := range runs[model.MaxTaskRuns:] {
+		delete(m.DB[dbName+"_sb_task_runs"], old.ID)
+	}
 	return nil
 }
+
+func (m *Memory) ListTaskRuns(dbName, taskID string) ([]model.TaskRun, error) {
+	runs, err := all[model.TaskRun](m, dbName, "sb_task_runs")
+	if err != nil {
+		return nil, err
+	}
+
+	runs = filter(runs, func(run model.TaskRun) bool {
+		return run.TaskID == taskID
+	})
+
+	runs = sortSlice(runs, func(a, b model.TaskRun) bool {
+		return a.Started.After(b.Started)
+	})
+	return runs, nil
+}
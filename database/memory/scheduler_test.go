@@ -2,6 +2,9 @@ package memory
 
 import (
 	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
 )
 
 func TestListTasks(t *testing.T) {
@@ -10,3 +13,73 @@ func TestListTasks(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestTaskRuns(t *testing.T) {
+	task := model.Task{
+		Name:     "task-runs-" + datastore.NewID(),
+		Type:     model.TaskTypeMessage,
+		Value:    "test",
+		Interval: "*/5 * * * *",
+		Timezone: "America/Toronto",
+		CatchUp:  model.TaskCatchUpOnce,
+		LastRun:  time.Now(),
+	}
+
+	id, err := datastore.AddTask(confDBName, task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer datastore.DeleteTask(confDBName, id)
+
+	if err := datastore.SetTaskPaused(confDBName, id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetTask(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if !check.Paused {
+		t.Error("expected the task to be paused")
+	} else if check.Timezone != task.Timezone || check.CatchUp != task.CatchUp {
+		t.Errorf("expected timezone and catch-up to be saved got %s %s", check.Timezone, check.CatchUp)
+	} else if check.BaseName != confDBName {
+		t.Errorf("expected base name %s got %s", confDBName, check.BaseName)
+	}
+
+	started := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	for i := 0; i < 3; i++ {
+		run := model.TaskRun{
+			TaskID:    id,
+			Trigger:   model.TaskTriggerSchedule,
+			Status:    model.TaskRunSuccess,
+			Started:   started.Add(time.Duration(i) * time.Minute),
+			Completed: started.Add(time.Duration(i)*time.Minute + time.Second),
+			Output:    "ok",
+		}
+		if i == 2 {
+			run.Trigger = model.TaskTriggerManual
+			run.Status = model.TaskRunFailure
+			run.Error = "boom"
+		}
+
+		if err := datastore.RanTask(confDBName, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := datastore.ListTaskRuns(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(runs) != 3 {
+		t.Fatalf("expected 3 runs got %d", len(runs))
+	} else if runs[0].Trigger != model.TaskTriggerManual || runs[0].Error != "boom" {
+		t.Errorf("expected the most recent run first got %v", runs[0])
+	}
+
+	check, err = datastore.GetTask(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if !check.LastRun.Equal(runs[0].Started) {
+		t.Errorf("expected last run to be %v got %v", runs[0].Started, check.LastRun)
+	}
+}
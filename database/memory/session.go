@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) CreateSession(dbName string, s model.Session) (id string, err error) {
+	id = m.NewID()
+	s.ID = id
+
+	err = create(m, dbName, "sb_sessions", id, s)
+	return
+}
+
+func (m *Memory) GetSessionByID(dbName, id string) (s model.Session, err error) {
+	if err = getByID(m, dbName, "sb_sessions", id, &s); err != nil {
+		return
+	} else if len(s.ID) == 0 {
+		err = errors.New("session not found")
+	}
+	return
+}
+
+func (m *Memory) ListSessions(dbName, userID string) (results []model.Session, err error) {
+	list, err := all[model.Session](m, dbName, "sb_sessions")
+	if err != nil {
+		return
+	}
+
+	list = filter(list, func(x model.Session) bool {
+		return x.UserID == userID
+	})
+
+	results = sortSlice(list, func(a, b model.Session) bool {
+		return a.LastUsed.After(b.LastUsed)
+	})
+	return
+}
+
+func (m *Memory) RotateSession(dbName string, s model.Session, prevHash string) (bool, error) {
+	key := fmt.Sprintf("%s_sb_sessions", dbName)
+
+	// the compare and update must happen under the same lock
+	mx.Lock()
+	defer mx.Unlock()
+
+	b, ok := m.DB[key][s.ID]
+	if !ok {
+		return false, nil
+	}
+
+	var exists model.Session
+	if err := mustDec(b, &exists); err != nil {
+		return false, err
+	} else if exists.RefreshHash != prevHash {
+		return false, nil
+	}
+
+	exists.RefreshHash = s.RefreshHash
+	exists.JWTID = s.JWTID
+	exists.UserAgent = s.UserAgent
+	exists.IP = s.IP
+	exists.LastUsed = s.LastUsed
+	exists.Expires = s.Expires
+
+	m.DB[key][s.ID] = mustEnc(exists)
+	return true, nil
+}
+
+func (m *Memory) DeleteSession(dbName, id string) error {
+	key := fmt.Sprintf("%s_sb_sessions", dbName)
+
+	mx.Lock()
+	delete(m.DB[key], id)
+	mx.Unlock()
+	return nil
+}
+
+func (m *Memory) DeleteUserSessions(dbName, userID string) error {
+	list, err := m.ListSessions(dbName, userID)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s_sb_sessions", dbName)
+
+	mx.Lock()
+	for _, s := range list {
+		delete(m.DB[key], s.ID)
+	}
+	mx.Unlock()
+	return nil
+}
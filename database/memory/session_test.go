@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestSessions(t *testing.T) {
+	s := model.Session{
+		AccountID:   adminToken.AccountID,
+		UserID:      adminToken.ID,
+		RefreshHash: "hash-1",
+		JWTID:       "jti-1",
+		UserAgent:   "unit test",
+		IP:          "127.0.0.1",
+		Created:     time.Now(),
+		LastUsed:    time.Now(),
+		Expires:     time.Now().Add(time.Hour),
+	}
+
+	id, err := datastore.CreateSession(confDBName, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.ID = id
+	s.RefreshHash = "hash-2"
+	s.JWTID = "jti-2"
+	s.LastUsed = time.Now().Add(time.Minute)
+	if ok, err := datastore.RotateSession(confDBName, s, "hash-1"); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Fatal("expected session to be rotated")
+	}
+
+	// the refresh hash changed, rotating from the old hash is a no-op
+	s.RefreshHash = "hash-3"
+	if ok, err := datastore.RotateSession(confDBName, s, "hash-1"); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		t.Error("expected rotation from a stale hash to fail")
+	}
+
+	check, err := datastore.GetSessionByID(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.RefreshHash != "hash-2" || check.JWTID != "jti-2" {
+		t.Errorf("expected session to be rotated got %v", check)
+	} else if check.UserID != adminToken.ID {
+		t.Errorf("expected user id to be %s got %s", adminToken.ID, check.UserID)
+	}
+
+	s.JWTID = "jti-3"
+	if _, err := datastore.CreateSession(confDBName, s); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := datastore.ListSessions(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 2 {
+		t.Fatalf("expected 2 sessions got %d", len(list))
+	}
+
+	if err := datastore.DeleteSession(confDBName, id); err != nil {
+		t.Fatal(err)
+	} else if _, err := datastore.GetSessionByID(confDBName, id); err == nil {
+		t.Error("expected an error getting a deleted session")
+	}
+
+	if err := datastore.DeleteUserSessions(confDBName, adminToken.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = datastore.ListSessions(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 0 {
+		t.Errorf("expected no sessions got %d", len(list))
+	}
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) AddSMSMessage(dbName string, msg model.SMSMessage) (id string, err error) {
+	id = m.NewID()
+	msg.ID = id
+	err = create(m, dbName, "sb_sms", id, msg)
+	return
+}
+
+func (m *Memory) UpdateSMSStatus(dbName, messageID, status, errMsg string) error {
+	list, err := all[model.SMSMessage](m, dbName, "sb_sms")
+	if err != nil {
+		return err
+	}
+
+	matches := filter(list, func(x model.SMSMessage) bool {
+		return x.MessageID == messageID
+	})
+
+	for _, msg := range matches {
+		msg.Status = status
+		msg.Error = errMsg
+		msg.Updated = time.Now()
+
+		if err := create(m, dbName, "sb_sms", msg.ID, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetSMSMessageByID(dbName, id string) (msg model.SMSMessage, err error) {
+	err = getByID(m, dbName, "sb_sms", id, &msg)
+	return
+}
+
+func (m *Memory) ListSMSMessages(dbName string) (results []model.SMSMessage, err error) {
+	list, err := all[model.SMSMessage](m, dbName, "sb_sms")
+	if err != nil {
+		return
+	}
+
+	results = sortSlice(list, func(a, b model.SMSMessage) bool {
+		return a.Created.After(b.Created)
+	})
+
+	if len(results) > 100 {
+		results = results[:100]
+	}
+	return
+}
@@ -41,9 +41,40 @@ func (m *Memory) ListAllFiles(dbName, accountID string) (results []model.File, e
 		return
 	}
 
+	// if no accountID is specify, the admin UI
+	// display all files uploaded.
 	results = filter(files, func(x model.File) bool {
-		return x.AccountID == accountID
+		return len(accountID) == 0 || x.AccountID == accountID
 	})
 
 	return
 }
+
+func (m *Memory) GetFileByChecksum(dbName, checksum string) (f model.File, err error) {
+	files, err := all[model.File](m, dbName, "sb_files")
+	if err != nil {
+		return
+	}
+
+	matches := filter(files, func(x model.File) bool {
+		return x.Checksum == checksum
+	})
+
+	if len(matches) > 0 {
+		f = matches[0]
+	}
+	return
+}
+
+func (m *Memory) CountFileReferences(dbName, key string) (int64, error) {
+	files, err := all[model.File](m, dbName, "sb_files")
+	if err != nil {
+		return 0, err
+	}
+
+	refs := filter(files, func(x model.File) bool {
+		return x.Key == key
+	})
+
+	return int64(len(refs)), nil
+}
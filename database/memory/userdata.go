@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func (m *Memory) ListDocumentsByOwner(dbName, col, ownerID string) ([]map[string]any, error) {
+	list, err := all[map[string]any](m, dbName, col)
+	if err != nil {
+		if errors.Is(err, errCollectionNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	list = filter(list, func(doc map[string]any) bool {
+		return doc[FieldOwnerID] == ownerID
+	})
+
+	return sortSlice(list, func(a, b map[string]any) bool {
+		return fmt.Sprintf("%v", a[FieldCreated]) < fmt.Sprintf("%v", b[FieldCreated])
+	}), nil
+}
+
+func (m *Memory) DeleteDocumentsByOwner(dbName, col, ownerID string) (int64, error) {
+	list, err := m.ListDocumentsByOwner(dbName, col, ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.deleteDocs(dbName, col, list), nil
+}
+
+func (m *Memory) ListFormSubmissionsByEmail(dbName, email string) ([]map[string]any, error) {
+	list, err := all[map[string]any](m, dbName, "sb_forms")
+	if err != nil {
+		if errors.Is(err, errCollectionNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return filter(list, func(doc map[string]any) bool {
+		for _, v := range doc {
+			if s, ok := v.(string); ok && strings.EqualFold(s, email) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (m *Memory) DeleteFormSubmissionsByEmail(dbName, email string) (int64, error) {
+	list, err := m.ListFormSubmissionsByEmail(dbName, email)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.deleteDocs(dbName, "sb_forms", list), nil
+}
+
+func (m *Memory) deleteDocs(dbName, col string, list []map[string]any) (n int64) {
+	key := fmt.Sprintf("%s_%s", dbName, col)
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	for _, doc := range list {
+		delete(m.DB[key], fmt.Sprintf("%v", doc[FieldID]))
+		n++
+	}
+	return
+}
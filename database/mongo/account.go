@@ -28,6 +28,12 @@ type LocalToken struct {
 	Role      int                `bson:"role" json:"role"`
 	ResetCode string             `bson:"resetCode" json:"-"`
 	Created   time.Time          `bson:"created" json:"created"`
+	// Unverified is stored instead of a verified flag so users created
+	// before email verification are considered verified
+	Unverified  bool                   `bson:"unverified" json:"-"`
+	DisplayName string                 `bson:"displayName" json:"displayName"`
+	AvatarID    string                 `bson:"avatarId" json:"avatarId"`
+	Profile     map[string]interface{} `bson:"profile" json:"profile"`
 }
 
 func toLocalToken(token model.User) LocalToken {
@@ -42,14 +48,18 @@ func toLocalToken(token model.User) LocalToken {
 	}
 
 	return LocalToken{
-		ID:        id,
-		AccountID: acctID,
-		Token:     token.Token,
-		Email:     token.Email,
-		Password:  token.Password,
-		Role:      token.Role,
-		ResetCode: token.ResetCode,
-		Created:   token.Created,
+		ID:          id,
+		AccountID:   acctID,
+		Token:       token.Token,
+		Email:       token.Email,
+		Password:    token.Password,
+		Role:        token.Role,
+		ResetCode:   token.ResetCode,
+		Created:     token.Created,
+		Unverified:  !token.Verified,
+		DisplayName: token.DisplayName,
+		AvatarID:    token.AvatarID,
+		Profile:     token.Profile,
 	}
 }
 
@@ -63,6 +73,12 @@ func fromLocalToken(tok LocalToken) model.User {
 		Role:      tok.Role,
 		ResetCode: tok.ResetCode,
 		Created:   tok.Created,
+		Verified:  !tok.Unverified,
+		UserProfile: model.UserProfile{
+			DisplayName: tok.DisplayName,
+			AvatarID:    tok.AvatarID,
+			Profile:     tok.Profile,
+		},
 	}
 }
 
@@ -220,8 +236,12 @@ func (mg *Mongo) SetPasswordResetCode(dbName, tokenID, code string) error {
 func (mg *Mongo) ResetPassword(dbName, email, code, password string) error {
 	db := mg.Client.Database(dbName)
 
+	if len(code) == 0 {
+		return errors.New("invalid code")
+	}
+
 	filter := bson.M{"email": email, "resetCode": code}
-	update := bson.M{"$set": bson.M{"pw": password}}
+	update := bson.M{"$set": bson.M{"pw": password, "resetCode": ""}}
 	res, err := db.Collection("sb_tokens").UpdateOne(mg.Ctx, filter, update)
 	if err != nil {
 		return err
@@ -246,6 +266,31 @@ func (mg *Mongo) GetUserByID(dbName, accountID, userID string) (user model.User,
 
 	filter := bson.M{FieldID: uid, FieldAccountID: aid}
 	sr := db.Collection("sb_tokens").FindOne(mg.Ctx, filter)
-	err = sr.Decode(&user)
+
+	var tok LocalToken
+	if err = sr.Decode(&tok); err != nil {
+		return
+	}
+
+	user = fromLocalToken(tok)
+	return
+}
+
+func (mg *Mongo) FindUserByID(dbName, userID string) (user model.User, err error) {
+	db := mg.Client.Database(dbName)
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return
+	}
+
+	sr := db.Collection("sb_tokens").FindOne(mg.Ctx, bson.M{FieldID: uid})
+
+	var tok LocalToken
+	if err = sr.Decode(&tok); err != nil {
+		return
+	}
+
+	user = fromLocalToken(tok)
 	return
 }
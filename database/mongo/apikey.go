@@ -0,0 +1,160 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalAPIKey struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	AccountID  primitive.ObjectID `bson:"accountId" json:"accountId"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"`
+	KeyHash    string             `bson:"hash" json:"-"`
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	AllowedIPs []string           `bson:"ips" json:"allowedIps"`
+	Expires    time.Time          `bson:"exp" json:"expires"`
+	LastUsed   time.Time          `bson:"used" json:"lastUsed"`
+	LastIP     string             `bson:"lastIp" json:"lastIp"`
+	Created    time.Time          `bson:"created" json:"created"`
+}
+
+func fromLocalAPIKey(lk LocalAPIKey) model.APIKey {
+	return model.APIKey{
+		ID:         lk.ID.Hex(),
+		AccountID:  lk.AccountID.Hex(),
+		UserID:     lk.UserID.Hex(),
+		Name:       lk.Name,
+		Prefix:     lk.Prefix,
+		KeyHash:    lk.KeyHash,
+		Scopes:     lk.Scopes,
+		AllowedIPs: lk.AllowedIPs,
+		Expires:    lk.Expires,
+		LastUsed:   lk.LastUsed,
+		LastIP:     lk.LastIP,
+		Created:    lk.Created,
+	}
+}
+
+func (mg *Mongo) CreateAPIKey(dbName string, k model.APIKey) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(k.AccountID)
+	if err != nil {
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(k.UserID)
+	if err != nil {
+		return
+	}
+
+	lk := LocalAPIKey{
+		ID:         primitive.NewObjectID(),
+		AccountID:  acctID,
+		UserID:     userID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		KeyHash:    k.KeyHash,
+		Scopes:     k.Scopes,
+		AllowedIPs: k.AllowedIPs,
+		Expires:    k.Expires,
+		LastUsed:   k.LastUsed,
+		LastIP:     k.LastIP,
+		Created:    k.Created,
+	}
+
+	res, err := db.Collection("sb_apikeys").InsertOne(mg.Ctx, lk)
+	if err != nil {
+		return
+	}
+
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return id, errors.New("unable to get inserted id for api key")
+	}
+
+	id = oid.Hex()
+	return
+}
+
+func (mg *Mongo) GetAPIKeyByHash(dbName, hash string) (k model.APIKey, err error) {
+	db := mg.Client.Database(dbName)
+
+	var result LocalAPIKey
+
+	sr := db.Collection("sb_apikeys").FindOne(mg.Ctx, bson.M{"hash": hash})
+	if err = sr.Decode(&result); err != nil {
+		return
+	}
+
+	k = fromLocalAPIKey(result)
+	return
+}
+
+func (mg *Mongo) ListAPIKeys(dbName string) ([]model.APIKey, error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find()
+	opt.SetSort(bson.M{"created": -1})
+
+	cur, err := db.Collection("sb_apikeys").Find(mg.Ctx, bson.M{}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.APIKey
+
+	for cur.Next(mg.Ctx) {
+		var lk LocalAPIKey
+		if err := cur.Decode(&lk); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalAPIKey(lk))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (mg *Mongo) TouchAPIKey(dbName, id string, lastUsed time.Time, ip string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"used": lastUsed, "lastIp": ip}}
+
+	if _, err := db.Collection("sb_apikeys").UpdateByID(mg.Ctx, oid, update); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) DeleteAPIKey(dbName, id string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("sb_apikeys").DeleteOne(mg.Ctx, bson.M{FieldID: oid}); err != nil {
+		return err
+	}
+	return nil
+}
@@ -158,7 +158,7 @@ func (mg *Mongo) ListDocuments(auth model.Auth, dbName, col string, params model
 
 	filter := bson.M{}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(acctID, userID, auth, col, filter)
 
 	count, err := db.Collection(model.CleanCollectionName(col)).CountDocuments(mg.Ctx, filter)
 	if err != nil {
@@ -230,7 +230,7 @@ func (mg *Mongo) QueryDocuments(auth model.Auth, dbName, col string, filter map[
 		return result, err
 	}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(acctID, userID, auth, col, filter)
 
 	count, err := db.Collection(model.CleanCollectionName(col)).CountDocuments(mg.Ctx, filter)
 	if err != nil {
@@ -306,7 +306,7 @@ func (mg *Mongo) GetDocumentByID(auth model.Auth, dbName, col, id string) (map[s
 
 	filter := bson.M{FieldID: oid}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(acctID, userID, auth, col, filter)
 
 	sr := db.Collection(model.CleanCollectionName(col)).FindOne(mg.Ctx, filter)
 	if err := sr.Decode(&result); err != nil {
@@ -340,7 +340,7 @@ func (mg *Mongo) GetDocumentsByIDs(auth model.Auth, dbName, col string, ids []st
 
 	filter := bson.M{FieldID: bson.M{"$in": oids}}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(acctID, userID, auth, col, filter)
 
 	cur, err := db.Collection(model.CleanCollectionName(col)).Find(mg.Ctx, filter)
 	if err != nil {
@@ -374,7 +374,7 @@ func (mg *Mongo) UpdateDocument(auth model.Auth, dbName, col, id string, doc map
 
 	filter := bson.M{FieldID: oid}
 
-	secureWrite(acctID, userID, auth.Role, col, filter)
+	secureWrite(acctID, userID, auth, col, filter)
 
 	newProps := bson.M{}
 	for k, v := range doc {
@@ -411,7 +411,7 @@ func (mg *Mongo) UpdateDocuments(auth model.Auth, dbName, col string, filters ma
 		return 0, err
 	}
 
-	secureWrite(acctID, userID, auth.Role, col, filters)
+	secureWrite(acctID, userID, auth, col, filters)
 	removeNotEditableFields(updateFields)
 
 	var ids []string
@@ -474,7 +474,7 @@ func (mg *Mongo) IncrementValue(auth model.Auth, dbName, col, id, field string,
 
 	filter := bson.M{FieldID: oid}
 
-	secureWrite(acctID, userID, auth.Role, col, filter)
+	secureWrite(acctID, userID, auth, col, filter)
 
 	update := bson.M{"$inc": bson.M{field: n}}
 
@@ -508,7 +508,7 @@ func (mg *Mongo) DeleteDocument(auth model.Auth, dbName, col, id string) (int64,
 
 	filter := bson.M{FieldID: oid}
 
-	secureWrite(acctID, userID, auth.Role, col, filter)
+	secureWrite(acctID, userID, auth, col, filter)
 
 	res, err := db.Collection(model.CleanCollectionName(col)).DeleteOne(mg.Ctx, filter)
 	if err != nil {
@@ -528,7 +528,7 @@ func (mg *Mongo) DeleteDocuments(auth model.Auth, dbName, col string, filters ma
 		return 0, err
 	}
 
-	secureWrite(acctID, userID, auth.Role, col, filters)
+	secureWrite(acctID, userID, auth, col, filters)
 
 	res, err := db.Collection(model.CleanCollectionName(col)).DeleteMany(mg.Ctx, filters)
 	if err != nil {
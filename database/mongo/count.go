@@ -12,7 +12,7 @@ func (mg *Mongo) Count(auth model.Auth, dbName, col string, filter map[string]in
 		return
 	}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(acctID, userID, auth, col, filter)
 
 	count, err = db.Collection(model.CleanCollectionName(col)).CountDocuments(mg.Ctx, filter)
 	if err != nil {
@@ -0,0 +1,291 @@
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (mg *Mongo) DumpLayout() string {
+	return database.DumpLayoutMongo
+}
+
+func (mg *Mongo) DumpTables(dbName string) ([]string, error) {
+	return mg.ListCollections(dbName)
+}
+
+// DumpRows returns the documents as canonical Extended JSON so their types
+// (ObjectID, dates, binary) are kept on restore. The collections are read
+// one after the other, writes happening during the dump may be partially
+// included.
+func (mg *Mongo) DumpRows(dbName string, tables []string, fn func(table string, row map[string]any) error) error {
+	db := mg.Client.Database(dbName)
+
+	for _, table := range tables {
+		if err := mg.dumpCollection(db.Collection(table), func(row map[string]any) error {
+			return fn(table, row)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mg *Mongo) dumpCollection(col *mongo.Collection, fn func(row map[string]any) error) error {
+	cur, err := col.Find(mg.Ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(mg.Ctx)
+
+	for cur.Next(mg.Ctx) {
+		b, err := bson.MarshalExtJSON(cur.Current, true, false)
+		if err != nil {
+			return err
+		}
+
+		row := make(map[string]any)
+		if err := json.Unmarshal(b, &row); err != nil {
+			return err
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+func (mg *Mongo) RestoreTenant(cus model.Tenant, base model.DatabaseConfig) error {
+	db := mg.Client.Database("sbsys")
+
+	lc := toLocalCustomer(cus)
+	id, err := primitive.ObjectIDFromHex(cus.ID)
+	if err != nil {
+		return fmt.Errorf("invalid tenant id: %w", err)
+	}
+	lc.ID = id
+
+	count, err := db.Collection("accounts").CountDocuments(mg.Ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	} else if count == 0 {
+		if _, err := db.Collection("accounts").InsertOne(mg.Ctx, lc); err != nil {
+			return err
+		}
+	}
+
+	lb := toLocalBase(base)
+	lb.ID, err = primitive.ObjectIDFromHex(base.ID)
+	if err != nil {
+		return fmt.Errorf("invalid database id: %w", err)
+	}
+
+	_, err = db.Collection("bases").InsertOne(mg.Ctx, lb)
+	return err
+}
+
+func (mg *Mongo) RestoreRows(dbName, table string, rows []map[string]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+
+		var doc bson.D
+		if err := bson.UnmarshalExtJSON(b, true, &doc); err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+
+	db := mg.Client.Database(dbName)
+	_, err := db.Collection(model.CleanCollectionName(table)).InsertMany(mg.Ctx, docs)
+	return err
+}
+
+// DumpDocuments returns the documents with their ObjectIDs as hex strings,
+// their creation time is the time of their ObjectID
+func (mg *Mongo) DumpDocuments(dbName string, cols []string, fn func(col string, doc model.PortableDocument) error) error {
+	db := mg.Client.Database(dbName)
+
+	opts := options.Find().SetSort(bson.M{FieldID: 1})
+	for _, col := range cols {
+		cur, err := db.Collection(col).Find(mg.Ctx, bson.M{}, opts)
+		if err != nil {
+			return err
+		}
+
+		for cur.Next(mg.Ctx) {
+			var doc bson.M
+			if err := cur.Decode(&doc); err != nil {
+				cur.Close(mg.Ctx)
+				return err
+			}
+
+			if err := fn(col, toPortableDocument(doc)); err != nil {
+				cur.Close(mg.Ctx)
+				return err
+			}
+		}
+
+		err = cur.Err()
+		cur.Close(mg.Ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreDocuments inserts the documents with their IDs as ObjectIDs, the
+// creation time is not kept since it's the time of the ObjectID
+func (mg *Mongo) RestoreDocuments(dbName, col string, docs []model.PortableDocument) error {
+	db := mg.Client.Database(dbName)
+	name := model.CleanCollectionName(col)
+
+	// collections are created even when empty
+	if len(docs) == 0 {
+		return db.CreateCollection(mg.Ctx, name)
+	}
+
+	list := make([]interface{}, 0, len(docs))
+	for _, pd := range docs {
+		doc := bson.M{}
+		for k, v := range pd.Data {
+			doc[k] = v
+		}
+
+		ids := map[string]string{FieldID: pd.ID, FieldAccountID: pd.AccountID, FieldOwnerID: pd.OwnerID}
+		for field, id := range ids {
+			oid, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q in %s: %w", field, id, col, err)
+			}
+			doc[field] = oid
+		}
+
+		list = append(list, doc)
+	}
+
+	if _, err := db.Collection(name).InsertMany(mg.Ctx, list); err != nil {
+		return err
+	}
+
+	go mg.ensureIndex(dbName, name)
+	return nil
+}
+
+func (mg *Mongo) RestoreAccount(dbName string, a model.Account) error {
+	db := mg.Client.Database(dbName)
+
+	id, err := primitive.ObjectIDFromHex(a.ID)
+	if err != nil {
+		return fmt.Errorf("invalid account id: %w", err)
+	}
+
+	la := LocalAccount{ID: id, Email: a.Email, Created: a.Created}
+	_, err = db.Collection("sb_accounts").InsertOne(mg.Ctx, la)
+	return err
+}
+
+func (mg *Mongo) RestoreUser(dbName string, tok model.User) error {
+	db := mg.Client.Database(dbName)
+
+	lt := toLocalToken(tok)
+	if lt.ID.IsZero() {
+		return fmt.Errorf("invalid user id %q or account id %q", tok.ID, tok.AccountID)
+	}
+
+	_, err := db.Collection("sb_tokens").InsertOne(mg.Ctx, lt)
+	return err
+}
+
+func (mg *Mongo) RestoreFile(dbName string, f model.File) error {
+	db := mg.Client.Database(dbName)
+
+	lf := toLocalFile(f)
+	if lf.ID.IsZero() {
+		return fmt.Errorf("invalid file id %q or account id %q", f.ID, f.AccountID)
+	}
+
+	_, err := db.Collection("sb_files").InsertOne(mg.Ctx, lf)
+	return err
+}
+
+func toPortableDocument(doc bson.M) model.PortableDocument {
+	pd := model.PortableDocument{Data: make(map[string]any)}
+	for k, v := range doc {
+		switch k {
+		case FieldID:
+			if oid, ok := v.(primitive.ObjectID); ok {
+				pd.ID = oid.Hex()
+				pd.Created = oid.Timestamp()
+			}
+		case FieldAccountID:
+			pd.AccountID = fmt.Sprint(portableValue(v))
+		case FieldOwnerID:
+			pd.OwnerID = fmt.Sprint(portableValue(v))
+		default:
+			pd.Data[k] = portableValue(v)
+		}
+	}
+	return pd
+}
+
+// portableValue converts the BSON types to plain JSON values
+func portableValue(v any) any {
+	switch x := v.(type) {
+	case primitive.ObjectID:
+		return x.Hex()
+	case primitive.DateTime:
+		return x.Time().UTC()
+	case primitive.Timestamp:
+		return time.Unix(int64(x.T), 0).UTC()
+	case primitive.Decimal128:
+		return x.String()
+	case primitive.Binary:
+		return x.Data
+	case primitive.M:
+		m := make(map[string]any, len(x))
+		for k, v := range x {
+			m[k] = portableValue(v)
+		}
+		return m
+	case primitive.D:
+		m := make(map[string]any, len(x))
+		for _, e := range x {
+			m[e.Key] = portableValue(e.Value)
+		}
+		return m
+	case primitive.A:
+		list := make([]any, len(x))
+		for i, v := range x {
+			list[i] = portableValue(v)
+		}
+		return list
+	}
+	return v
+}
+
+func (mg *Mongo) DeleteDatabase(dbName string) error {
+	if err := mg.Client.Database(dbName).Drop(mg.Ctx); err != nil {
+		return err
+	}
+
+	db := mg.Client.Database("sbsys")
+	_, err := db.Collection("bases").DeleteMany(mg.Ctx, bson.M{"name": dbName})
+	return err
+}
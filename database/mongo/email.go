@@ -0,0 +1,191 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalEmailTemplate struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	Name     string             `bson:"name" json:"name"`
+	Subject  string             `bson:"subject" json:"subject"`
+	HTMLBody string             `bson:"html" json:"htmlBody"`
+	TextBody string             `bson:"text" json:"textBody"`
+	Updated  time.Time          `bson:"updated" json:"updated"`
+}
+
+type LocalEmailLog struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	Template string             `bson:"tmpl" json:"template"`
+	From     string             `bson:"from" json:"from"`
+	To       string             `bson:"to" json:"to"`
+	Subject  string             `bson:"subject" json:"subject"`
+	Status   string             `bson:"status" json:"status"`
+	Error    string             `bson:"err" json:"error"`
+	Sent     time.Time          `bson:"sent" json:"sent"`
+}
+
+func fromLocalEmailTemplate(lt LocalEmailTemplate) model.EmailTemplate {
+	return model.EmailTemplate{
+		ID:       lt.ID.Hex(),
+		Name:     lt.Name,
+		Subject:  lt.Subject,
+		HTMLBody: lt.HTMLBody,
+		TextBody: lt.TextBody,
+		Updated:  lt.Updated,
+	}
+}
+
+func fromLocalEmailLog(ll LocalEmailLog) model.EmailLog {
+	return model.EmailLog{
+		ID:       ll.ID.Hex(),
+		Template: ll.Template,
+		From:     ll.From,
+		To:       ll.To,
+		Subject:  ll.Subject,
+		Status:   ll.Status,
+		Error:    ll.Error,
+		Sent:     ll.Sent,
+	}
+}
+
+func (mg *Mongo) SaveEmailTemplate(dbName string, tmpl model.EmailTemplate) error {
+	db := mg.Client.Database(dbName)
+
+	filter := bson.M{"name": tmpl.Name}
+	update := bson.M{
+		"$set": bson.M{
+			"subject": tmpl.Subject,
+			"html":    tmpl.HTMLBody,
+			"text":    tmpl.TextBody,
+			"updated": time.Now(),
+		},
+		"$setOnInsert": bson.M{FieldID: primitive.NewObjectID()},
+	}
+
+	opt := options.Update()
+	opt.SetUpsert(true)
+
+	if _, err := db.Collection("sb_email_templates").UpdateOne(mg.Ctx, filter, update, opt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) GetEmailTemplate(dbName, name string) (tmpl model.EmailTemplate, err error) {
+	db := mg.Client.Database(dbName)
+
+	var result LocalEmailTemplate
+
+	sr := db.Collection("sb_email_templates").FindOne(mg.Ctx, bson.M{"name": name})
+	if err = sr.Decode(&result); err != nil {
+		return
+	}
+
+	tmpl = fromLocalEmailTemplate(result)
+	return
+}
+
+func (mg *Mongo) ListEmailTemplates(dbName string) ([]model.EmailTemplate, error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find()
+	opt.SetSort(bson.M{"name": 1})
+
+	cur, err := db.Collection("sb_email_templates").Find(mg.Ctx, bson.M{}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.EmailTemplate
+
+	for cur.Next(mg.Ctx) {
+		var lt LocalEmailTemplate
+		if err := cur.Decode(&lt); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalEmailTemplate(lt))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (mg *Mongo) DeleteEmailTemplate(dbName, name string) error {
+	db := mg.Client.Database(dbName)
+
+	if _, err := db.Collection("sb_email_templates").DeleteOne(mg.Ctx, bson.M{"name": name}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) AddEmailLog(dbName string, entry model.EmailLog) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	ll := LocalEmailLog{
+		ID:       primitive.NewObjectID(),
+		Template: entry.Template,
+		From:     entry.From,
+		To:       entry.To,
+		Subject:  entry.Subject,
+		Status:   entry.Status,
+		Error:    entry.Error,
+		Sent:     entry.Sent,
+	}
+
+	res, err := db.Collection("sb_email_logs").InsertOne(mg.Ctx, ll)
+	if err != nil {
+		return
+	}
+
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return id, errors.New("unable to get inserted id for email log")
+	}
+
+	id = oid.Hex()
+	return
+}
+
+func (mg *Mongo) ListEmailLogs(dbName string) ([]model.EmailLog, error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find()
+	opt.SetLimit(100)
+	opt.SetSort(bson.M{"sent": -1})
+
+	cur, err := db.Collection("sb_email_logs").Find(mg.Ctx, bson.M{}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.EmailLog
+
+	for cur.Next(mg.Ctx) {
+		var ll LocalEmailLog
+		if err := cur.Decode(&ll); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalEmailLog(ll))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
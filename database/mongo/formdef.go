@@ -0,0 +1,120 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalFormDefinition struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	Name          string             `bson:"name" json:"name"`
+	Fields        []model.FormField  `bson:"fields" json:"fields"`
+	RedirectURL   string             `bson:"redirect" json:"redirectUrl"`
+	ErrorURL      string             `bson:"errorUrl" json:"errorUrl"`
+	NotifyEmail   string             `bson:"notify" json:"notifyEmail"`
+	EmailTemplate string             `bson:"tmpl" json:"emailTemplate"`
+	MaxPerHour    int                `bson:"maxh" json:"maxPerHour"`
+	Updated       time.Time          `bson:"updated" json:"updated"`
+}
+
+func fromLocalFormDefinition(ld LocalFormDefinition) model.FormDefinition {
+	return model.FormDefinition{
+		ID:            ld.ID.Hex(),
+		Name:          ld.Name,
+		Fields:        ld.Fields,
+		RedirectURL:   ld.RedirectURL,
+		ErrorURL:      ld.ErrorURL,
+		NotifyEmail:   ld.NotifyEmail,
+		EmailTemplate: ld.EmailTemplate,
+		MaxPerHour:    ld.MaxPerHour,
+		Updated:       ld.Updated,
+	}
+}
+
+func (mg *Mongo) SaveFormDefinition(dbName string, def model.FormDefinition) error {
+	db := mg.Client.Database(dbName)
+
+	filter := bson.M{"name": def.Name}
+	update := bson.M{
+		"$set": bson.M{
+			"fields":   def.Fields,
+			"redirect": def.RedirectURL,
+			"errorUrl": def.ErrorURL,
+			"notify":   def.NotifyEmail,
+			"tmpl":     def.EmailTemplate,
+			"maxh":     def.MaxPerHour,
+			"updated":  time.Now(),
+		},
+		"$setOnInsert": bson.M{FieldID: primitive.NewObjectID()},
+	}
+
+	opt := options.Update()
+	opt.SetUpsert(true)
+
+	if _, err := db.Collection("sb_form_definitions").UpdateOne(mg.Ctx, filter, update, opt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) GetFormDefinition(dbName, name string) (def model.FormDefinition, err error) {
+	db := mg.Client.Database(dbName)
+
+	var result LocalFormDefinition
+
+	sr := db.Collection("sb_form_definitions").FindOne(mg.Ctx, bson.M{"name": name})
+	if err = sr.Decode(&result); errors.Is(err, mongo.ErrNoDocuments) {
+		return model.FormDefinition{}, nil
+	} else if err != nil {
+		return
+	}
+
+	def = fromLocalFormDefinition(result)
+	return
+}
+
+func (mg *Mongo) ListFormDefinitions(dbName string) ([]model.FormDefinition, error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find()
+	opt.SetSort(bson.M{"name": 1})
+
+	cur, err := db.Collection("sb_form_definitions").Find(mg.Ctx, bson.M{}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.FormDefinition
+
+	for cur.Next(mg.Ctx) {
+		var ld LocalFormDefinition
+		if err := cur.Decode(&ld); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalFormDefinition(ld))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (mg *Mongo) DeleteFormDefinition(dbName, name string) error {
+	db := mg.Client.Database(dbName)
+
+	if _, err := db.Collection("sb_form_definitions").DeleteOne(mg.Ctx, bson.M{"name": name}); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalRole struct {
+	Name        string    `bson:"_id" json:"name"`
+	Description string    `bson:"desc" json:"description"`
+	Permissions []string  `bson:"perms" json:"permissions"`
+	Updated     time.Time `bson:"updated" json:"updated"`
+}
+
+type LocalGroup struct {
+	ID        primitive.ObjectID   `bson:"_id" json:"id"`
+	AccountID primitive.ObjectID   `bson:"accountId" json:"accountId"`
+	Name      string               `bson:"name" json:"name"`
+	Roles     []string             `bson:"roles" json:"roles"`
+	Members   []primitive.ObjectID `bson:"members" json:"members"`
+	Created   time.Time            `bson:"created" json:"created"`
+}
+
+func fromLocalRole(lr LocalRole) model.Role {
+	return model.Role{
+		Name:        lr.Name,
+		Description: lr.Description,
+		Permissions: lr.Permissions,
+		Updated:     lr.Updated,
+	}
+}
+
+func fromLocalGroup(lg LocalGroup) model.Group {
+	g := model.Group{
+		ID:        lg.ID.Hex(),
+		AccountID: lg.AccountID.Hex(),
+		Name:      lg.Name,
+		Roles:     lg.Roles,
+		Created:   lg.Created,
+	}
+
+	for _, member := range lg.Members {
+		g.Members = append(g.Members, member.Hex())
+	}
+	return g
+}
+
+func (mg *Mongo) SaveRole(dbName string, role model.Role) error {
+	db := mg.Client.Database(dbName)
+
+	lr := LocalRole{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		Updated:     role.Updated,
+	}
+
+	opt := options.Replace()
+	opt.SetUpsert(true)
+
+	if _, err := db.Collection("sb_roles").ReplaceOne(mg.Ctx, bson.M{FieldID: role.Name}, lr, opt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) ListRoles(dbName string) ([]model.Role, error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find()
+	opt.SetSort(bson.M{FieldID: 1})
+
+	cur, err := db.Collection("sb_roles").Find(mg.Ctx, bson.M{}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.Role
+
+	for cur.Next(mg.Ctx) {
+		var lr LocalRole
+		if err := cur.Decode(&lr); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalRole(lr))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (mg *Mongo) DeleteRole(dbName, name string) error {
+	db := mg.Client.Database(dbName)
+
+	if _, err := db.Collection("sb_roles").DeleteOne(mg.Ctx, bson.M{FieldID: name}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) CreateGroup(dbName string, g model.Group) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(g.AccountID)
+	if err != nil {
+		return
+	}
+
+	count, err := db.Collection("sb_groups").CountDocuments(mg.Ctx, bson.M{"accountId": acctID, "name": g.Name})
+	if err != nil {
+		return
+	} else if count > 0 {
+		err = errors.New("a group with this name already exists")
+		return
+	}
+
+	lg := LocalGroup{
+		ID:        primitive.NewObjectID(),
+		AccountID: acctID,
+		Name:      g.Name,
+		Roles:     g.Roles,
+		Members:   []primitive.ObjectID{},
+		Created:   g.Created,
+	}
+
+	if _, err = db.Collection("sb_groups").InsertOne(mg.Ctx, lg); err != nil {
+		return
+	}
+
+	id = lg.ID.Hex()
+	return
+}
+
+func (mg *Mongo) GetGroup(dbName, accountID, id string) (g model.Group, err error) {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return
+	}
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var lg LocalGroup
+
+	sr := db.Collection("sb_groups").FindOne(mg.Ctx, bson.M{FieldID: oid, "accountId": acctID})
+	if err = sr.Decode(&lg); err != nil {
+		return
+	}
+
+	g = fromLocalGroup(lg)
+	return
+}
+
+func (mg *Mongo) UpdateGroup(dbName string, g model.Group) error {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(g.AccountID)
+	if err != nil {
+		return err
+	}
+
+	oid, err := primitive.ObjectIDFromHex(g.ID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{FieldID: oid, "accountId": acctID}
+	update := bson.M{"$set": bson.M{"name": g.Name, "roles": g.Roles}}
+
+	if _, err := db.Collection("sb_groups").UpdateOne(mg.Ctx, filter, update); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) ListGroups(dbName, accountID string) ([]model.Group, error) {
+	acctID, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mg.findGroups(dbName, bson.M{"accountId": acctID})
+}
+
+func (mg *Mongo) DeleteGroup(dbName, accountID, id string) error {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return err
+	}
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("sb_groups").DeleteOne(mg.Ctx, bson.M{FieldID: oid, "accountId": acctID}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) AddGroupMember(dbName, groupID, userID string) error {
+	return mg.updateGroupMembers(dbName, groupID, userID, "$addToSet")
+}
+
+func (mg *Mongo) RemoveGroupMember(dbName, groupID, userID string) error {
+	return mg.updateGroupMembers(dbName, groupID, userID, "$pull")
+}
+
+func (mg *Mongo) updateGroupMembers(dbName, groupID, userID, op string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(groupID)
+	if err != nil {
+		return err
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{op: bson.M{"members": uid}}
+	if _, err := db.Collection("sb_groups").UpdateByID(mg.Ctx, oid, update); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) ListUserGroups(dbName, userID string) ([]model.Group, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mg.findGroups(dbName, bson.M{"members": uid})
+}
+
+func (mg *Mongo) findGroups(dbName string, filter bson.M) ([]model.Group, error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find()
+	opt.SetSort(bson.M{"name": 1})
+
+	cur, err := db.Collection("sb_groups").Find(mg.Ctx, filter, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.Group
+
+	for cur.Next(mg.Ctx) {
+		var lg LocalGroup
+		if err := cur.Decode(&lg); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalGroup(lg))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
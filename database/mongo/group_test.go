@@ -0,0 +1,106 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestRolesAndGroups(t *testing.T) {
+	role := model.Role{
+		Name:        "editor",
+		Permissions: []string{"db:read:articles"},
+		Updated:     time.Now(),
+	}
+	if err := datastore.SaveRole(confDBName, role); err != nil {
+		t.Fatal(err)
+	}
+
+	role.Permissions = append(role.Permissions, "db:write:articles")
+	if err := datastore.SaveRole(confDBName, role); err != nil {
+		t.Fatal(err)
+	}
+
+	roles, err := datastore.ListRoles(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(roles) != 1 || len(roles[0].Permissions) != 2 {
+		t.Fatalf("expected the updated role got %v", roles)
+	}
+
+	g := model.Group{
+		AccountID: adminToken.AccountID,
+		Name:      "writers",
+		Roles:     []string{"editor"},
+		Created:   time.Now(),
+	}
+
+	id, err := datastore.CreateGroup(confDBName, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.CreateGroup(confDBName, g); err == nil {
+		t.Error("expected an error creating a group with the same name")
+	}
+
+	// adding a member twice is a no-op
+	for i := 0; i < 2; i++ {
+		if err := datastore.AddGroupMember(confDBName, id, adminToken.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check, err := datastore.GetGroup(confDBName, adminToken.AccountID, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(check.Members) != 1 || check.Members[0] != adminToken.ID {
+		t.Errorf("expected 1 member got %v", check.Members)
+	}
+
+	check.Name = "authors"
+	if err := datastore.UpdateGroup(confDBName, check); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := datastore.ListUserGroups(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(groups) != 1 || groups[0].Name != "authors" || groups[0].Roles[0] != "editor" {
+		t.Fatalf("expected the user's group got %v", groups)
+	}
+
+	if err := datastore.RemoveGroupMember(confDBName, id, adminToken.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err = datastore.ListUserGroups(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(groups) != 0 {
+		t.Errorf("expected no groups got %v", groups)
+	}
+
+	if err := datastore.DeleteGroup(confDBName, adminToken.AccountID, id); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err = datastore.ListGroups(confDBName, adminToken.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(groups) != 0 {
+		t.Errorf("expected no groups got %v", groups)
+	}
+
+	if err := datastore.DeleteRole(confDBName, "editor"); err != nil {
+		t.Fatal(err)
+	}
+
+	roles, err = datastore.ListRoles(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(roles) != 0 {
+		t.Errorf("expected no roles got %v", roles)
+	}
+}
@@ -0,0 +1,152 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalIdentity struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	AccountID primitive.ObjectID `bson:"accountId" json:"accountId"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Provider  string             `bson:"provider" json:"provider"`
+	Subject   string             `bson:"sub" json:"subject"`
+	Email     string             `bson:"email" json:"email"`
+	Created   time.Time          `bson:"created" json:"created"`
+}
+
+func fromLocalIdentity(li LocalIdentity) model.Identity {
+	return model.Identity{
+		ID:        li.ID.Hex(),
+		AccountID: li.AccountID.Hex(),
+		UserID:    li.UserID.Hex(),
+		Provider:  li.Provider,
+		Subject:   li.Subject,
+		Email:     li.Email,
+		Created:   li.Created,
+	}
+}
+
+func (mg *Mongo) CreateIdentity(dbName string, idt model.Identity) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(idt.AccountID)
+	if err != nil {
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(idt.UserID)
+	if err != nil {
+		return
+	}
+
+	exists, err := mg.FindIdentity(dbName, idt.Provider, idt.Subject)
+	if err != nil {
+		return
+	} else if len(exists.ID) > 0 {
+		err = errors.New("identity already exists for this provider")
+		return
+	}
+
+	li := LocalIdentity{
+		ID:        primitive.NewObjectID(),
+		AccountID: acctID,
+		UserID:    userID,
+		Provider:  idt.Provider,
+		Subject:   idt.Subject,
+		Email:     idt.Email,
+		Created:   idt.Created,
+	}
+
+	res, err := db.Collection("sb_identities").InsertOne(mg.Ctx, li)
+	if err != nil {
+		return
+	}
+
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return id, errors.New("unable to get inserted id for identity")
+	}
+
+	id = oid.Hex()
+	return
+}
+
+func (mg *Mongo) FindIdentity(dbName, provider, subject string) (idt model.Identity, err error) {
+	db := mg.Client.Database(dbName)
+
+	var result LocalIdentity
+
+	filter := bson.M{"provider": provider, "sub": subject}
+	sr := db.Collection("sb_identities").FindOne(mg.Ctx, filter)
+	if err = sr.Decode(&result); errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Identity{}, nil
+	} else if err != nil {
+		return
+	}
+
+	idt = fromLocalIdentity(result)
+	return
+}
+
+func (mg *Mongo) ListIdentities(dbName, userID string) ([]model.Identity, error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := options.Find()
+	opt.SetSort(bson.M{"created": 1})
+
+	cur, err := db.Collection("sb_identities").Find(mg.Ctx, bson.M{"userId": oid}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.Identity
+
+	for cur.Next(mg.Ctx) {
+		var li LocalIdentity
+		if err := cur.Decode(&li); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalIdentity(li))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (mg *Mongo) DeleteIdentity(dbName, userID, id string) error {
+	db := mg.Client.Database(dbName)
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{FieldID: oid, "userId": uid}
+	if _, err := db.Collection("sb_identities").DeleteOne(mg.Ctx, filter); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalInvitation struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	AccountID primitive.ObjectID `bson:"accountId" json:"accountId"`
+	InvitedBy primitive.ObjectID `bson:"invitedBy" json:"invitedBy"`
+	Email     string             `bson:"email" json:"email"`
+	Role      int                `bson:"role" json:"role"`
+	Link      string             `bson:"link" json:"link"`
+	Nonce     string             `bson:"nonce" json:"-"`
+	Expires   time.Time          `bson:"exp" json:"expires"`
+	Created   time.Time          `bson:"created" json:"created"`
+}
+
+func fromLocalInvitation(li LocalInvitation) model.Invitation {
+	return model.Invitation{
+		ID:        li.ID.Hex(),
+		AccountID: li.AccountID.Hex(),
+		InvitedBy: li.InvitedBy.Hex(),
+		Email:     li.Email,
+		Role:      li.Role,
+		Link:      li.Link,
+		Nonce:     li.Nonce,
+		Expires:   li.Expires,
+		Created:   li.Created,
+	}
+}
+
+func (mg *Mongo) CreateInvitation(dbName string, inv model.Invitation) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(inv.AccountID)
+	if err != nil {
+		return
+	}
+
+	invitedBy, err := primitive.ObjectIDFromHex(inv.InvitedBy)
+	if err != nil {
+		return
+	}
+
+	li := LocalInvitation{
+		ID:        primitive.NewObjectID(),
+		AccountID: acctID,
+		InvitedBy: invitedBy,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		Link:      inv.Link,
+		Nonce:     inv.Nonce,
+		Expires:   inv.Expires,
+		Created:   inv.Created,
+	}
+
+	if _, err = db.Collection("sb_invitations").InsertOne(mg.Ctx, li); err != nil {
+		return
+	}
+
+	id = li.ID.Hex()
+	return
+}
+
+func (mg *Mongo) GetInvitation(dbName, id string) (inv model.Invitation, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var li LocalInvitation
+
+	sr := db.Collection("sb_invitations").FindOne(mg.Ctx, bson.M{FieldID: oid})
+	if err = sr.Decode(&li); err != nil {
+		return
+	}
+
+	inv = fromLocalInvitation(li)
+	return
+}
+
+func (mg *Mongo) ListInvitations(dbName, accountID string) ([]model.Invitation, error) {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := options.Find()
+	opt.SetSort(bson.M{"created": -1})
+
+	cur, err := db.Collection("sb_invitations").Find(mg.Ctx, bson.M{"accountId": acctID}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.Invitation
+
+	for cur.Next(mg.Ctx) {
+		var li LocalInvitation
+		if err := cur.Decode(&li); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalInvitation(li))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (mg *Mongo) RenewInvitation(dbName, id, nonce string, expires time.Time) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"nonce": nonce, "exp": expires}}
+	if _, err := db.Collection("sb_invitations").UpdateByID(mg.Ctx, oid, update); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) DeleteInvitation(dbName, id string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("sb_invitations").DeleteOne(mg.Ctx, bson.M{FieldID: oid}); err != nil {
+		return err
+	}
+	return nil
+}
@@ -98,6 +98,42 @@ func (mg *Mongo) UserSetPassword(dbName, tokenID, password string) error {
 	return nil
 }
 
+func (mg *Mongo) SetUserVerified(dbName, userID string, verified bool) error {
+	db := mg.Client.Database(dbName)
+
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"unverified": !verified}}
+	if _, err := db.Collection("sb_tokens").UpdateOne(mg.Ctx, filter, update); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) UpdateUserProfile(dbName, userID string, p model.UserProfile) error {
+	db := mg.Client.Database(dbName)
+
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{
+		"displayName": p.DisplayName,
+		"avatarId":    p.AvatarID,
+		"profile":     p.Profile,
+	}}
+	if _, err := db.Collection("sb_tokens").UpdateOne(mg.Ctx, filter, update); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (mg *Mongo) GetFirstUserFromAccountID(dbName, accountID string) (tok model.User, err error) {
 	db := mg.Client.Database(dbName)
 
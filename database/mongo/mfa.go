@@ -0,0 +1,94 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalMFA struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	UserID        primitive.ObjectID `bson:"userId" json:"userId"`
+	Secret        []byte             `bson:"secret" json:"-"`
+	Enabled       bool               `bson:"enabled" json:"enabled"`
+	RecoveryCodes []string           `bson:"codes" json:"-"`
+	LastStep      int64              `bson:"step" json:"-"`
+	Updated       time.Time          `bson:"updated" json:"updated"`
+}
+
+func (mg *Mongo) SaveMFA(dbName string, m model.MFA) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(m.UserID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"userId": oid}
+	update := bson.M{
+		"$set": bson.M{
+			"secret":  m.Secret,
+			"enabled": m.Enabled,
+			"codes":   m.RecoveryCodes,
+			"step":    m.LastStep,
+			"updated": time.Now(),
+		},
+		"$setOnInsert": bson.M{FieldID: primitive.NewObjectID()},
+	}
+
+	opt := options.Update()
+	opt.SetUpsert(true)
+
+	if _, err := db.Collection("sb_mfa").UpdateOne(mg.Ctx, filter, update, opt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) GetMFA(dbName, userID string) (m model.MFA, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return
+	}
+
+	var result LocalMFA
+
+	sr := db.Collection("sb_mfa").FindOne(mg.Ctx, bson.M{"userId": oid})
+	if err = sr.Decode(&result); errors.Is(err, mongo.ErrNoDocuments) {
+		return model.MFA{}, nil
+	} else if err != nil {
+		return
+	}
+
+	m = model.MFA{
+		UserID:        result.UserID.Hex(),
+		Secret:        result.Secret,
+		Enabled:       result.Enabled,
+		RecoveryCodes: result.RecoveryCodes,
+		LastStep:      result.LastStep,
+		Updated:       result.Updated,
+	}
+	return
+}
+
+func (mg *Mongo) DeleteMFA(dbName, userID string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("sb_mfa").DeleteOne(mg.Ctx, bson.M{"userId": oid}); err != nil {
+		return err
+	}
+	return nil
+}
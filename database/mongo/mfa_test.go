@@ -0,0 +1,54 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestMFA(t *testing.T) {
+	m, err := datastore.GetMFA(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(m.UserID) > 0 {
+		t.Fatalf("expected no second factor got %v", m)
+	}
+
+	m = model.MFA{
+		UserID:        adminToken.ID,
+		Secret:        []byte("encrypted-secret"),
+		RecoveryCodes: []string{"hash-1", "hash-2"},
+	}
+	if err := datastore.SaveMFA(confDBName, m); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Enabled = true
+	m.LastStep = 42
+	m.RecoveryCodes = []string{"hash-2"}
+	if err := datastore.SaveMFA(confDBName, m); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetMFA(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if !check.Enabled || check.LastStep != 42 {
+		t.Errorf("expected second factor to be updated got %v", check)
+	} else if string(check.Secret) != "encrypted-secret" {
+		t.Errorf("expected secret to be kept got %s", string(check.Secret))
+	} else if len(check.RecoveryCodes) != 1 || check.RecoveryCodes[0] != "hash-2" {
+		t.Errorf("expected one recovery code got %v", check.RecoveryCodes)
+	}
+
+	if err := datastore.DeleteMFA(confDBName, adminToken.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err = datastore.GetMFA(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(check.UserID) > 0 {
+		t.Errorf("expected second factor to be deleted got %v", check)
+	}
+}
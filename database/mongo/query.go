@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -51,10 +52,10 @@ func (mg *Mongo) ParseQuery(clauses [][]interface{}) (map[string]interface{}, er
 	return filter, nil
 }
 
-func secureRead(acctID, userID primitive.ObjectID, role int, col string, filter bson.M) {
+func secureRead(acctID, userID primitive.ObjectID, auth model.Auth, col string, filter bson.M) {
 	// if they're not root and repo is not public
-	if !strings.HasPrefix(col, "pub_") && role < 100 {
-		switch internal.ReadPermission(col) {
+	if !strings.HasPrefix(col, "pub_") && auth.Role < 100 {
+		switch auth.ReadPermission(col) {
 		case internal.PermGroup:
 			filter[FieldAccountID] = acctID
 		case internal.PermOwner:
@@ -64,10 +65,10 @@ func secureRead(acctID, userID primitive.ObjectID, role int, col string, filter
 	}
 }
 
-func secureWrite(acctID, userID primitive.ObjectID, role int, col string, filter bson.M) {
+func secureWrite(acctID, userID primitive.ObjectID, auth model.Auth, col string, filter bson.M) {
 	// if they are not "root", we use permission
-	if role < 100 {
-		switch internal.WritePermission(col) {
+	if auth.Role < 100 {
+		switch auth.WritePermission(col) {
 		case internal.PermGroup:
 			filter[FieldAccountID] = acctID
 		case internal.PermOwner:
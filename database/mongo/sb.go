@@ -63,6 +63,9 @@ type LocalBase struct {
 	Whitelist        []string           `bson:"whitelist" json:"whitelist"`
 	IsActive         bool               `bson:"active" json:"-"`
 	MonthlyEmailSent int                `bson:"mes" json:"-"`
+	SMSConfig        []byte             `bson:"sms" json:"-"`
+	MFARole          int                `bson:"mfaRole" json:"mfaRole"`
+	AuthPolicy       model.AuthPolicy   `bson:"authPolicy" json:"authPolicy"`
 }
 
 func toLocalBase(b model.DatabaseConfig) LocalBase {
@@ -77,6 +80,9 @@ func toLocalBase(b model.DatabaseConfig) LocalBase {
 		Whitelist:        b.AllowedDomain,
 		IsActive:         b.IsActive,
 		MonthlyEmailSent: b.MonthlySentEmail,
+		SMSConfig:        b.SMSConfig,
+		MFARole:          b.MFARole,
+		AuthPolicy:       b.AuthPolicy,
 	}
 }
 
@@ -88,6 +94,9 @@ func fromLocalBase(b LocalBase) model.DatabaseConfig {
 		AllowedDomain:    b.Whitelist,
 		IsActive:         b.IsActive,
 		MonthlySentEmail: b.MonthlyEmailSent,
+		SMSConfig:        b.SMSConfig,
+		MFARole:          b.MFARole,
+		AuthPolicy:       b.AuthPolicy,
 	}
 }
 
@@ -145,6 +154,16 @@ func (mg *Mongo) FindDatabase(baseID string) (conf model.DatabaseConfig, err err
 	return
 }
 
+func (mg *Mongo) FindDatabaseByName(name string) (conf model.DatabaseConfig, err error) {
+	db := mg.Client.Database("sbsys")
+
+	var lb LocalBase
+	sr := db.Collection("bases").FindOne(mg.Ctx, bson.M{"name": name})
+	err = sr.Decode(&lb)
+	conf = fromLocalBase(lb)
+	return
+}
+
 func (mg *Mongo) DatabaseExists(name string) (bool, error) {
 	db := mg.Client.Database("sbsys")
 
@@ -289,6 +308,83 @@ func (mg *Mongo) EnableExternalLogin(tenantID string, config map[string]model.OA
 	return nil
 }
 
+func (mg *Mongo) SetSMSConfig(baseID string, config model.SMSConfig) error {
+	b, err := model.EncryptSMSConfig(config)
+	if err != nil {
+		return err
+	}
+
+	db := mg.Client.Database("sbsys")
+
+	oid, err := primitive.ObjectIDFromHex(baseID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{FieldID: oid}
+	update := bson.M{"$set": bson.M{"sms": b}}
+
+	res := db.Collection("bases").FindOneAndUpdate(mg.Ctx, filter, update)
+	if err := res.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) SetMFARole(baseID string, role int) error {
+	db := mg.Client.Database("sbsys")
+
+	oid, err := primitive.ObjectIDFromHex(baseID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{FieldID: oid}
+	update := bson.M{"$set": bson.M{"mfaRole": role}}
+
+	res := db.Collection("bases").FindOneAndUpdate(mg.Ctx, filter, update)
+	if err := res.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) SetAuthPolicy(baseID string, policy model.AuthPolicy) error {
+	db := mg.Client.Database("sbsys")
+
+	oid, err := primitive.ObjectIDFromHex(baseID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{FieldID: oid}
+	update := bson.M{"$set": bson.M{"authPolicy": policy}}
+
+	res := db.Collection("bases").FindOneAndUpdate(mg.Ctx, filter, update)
+	if err := res.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) SetAllowedDomains(baseID string, domains []string) error {
+	db := mg.Client.Database("sbsys")
+
+	oid, err := primitive.ObjectIDFromHex(baseID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{FieldID: oid}
+	update := bson.M{"$set": bson.M{"whitelist": domains}}
+
+	res := db.Collection("bases").FindOneAndUpdate(mg.Ctx, filter, update)
+	if err := res.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (mg *Mongo) NewID() string {
 	return primitive.NewObjectID().Hex()
 }
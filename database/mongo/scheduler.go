@@ -7,6 +7,7 @@ import (
 	"github.com/staticbackendhq/core/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type LocalTask struct {
@@ -17,10 +18,24 @@ type LocalTask struct {
 	Meta     string             `bson:"meta" json:"meta"`
 	Interval string             `bson:"invertal" json:"interval"`
 	LastRun  time.Time          `bson:"last" json:"last"`
+	Timezone string             `bson:"tz" json:"timezone"`
+	Paused   bool               `bson:"paused" json:"paused"`
+	CatchUp  string             `bson:"catchUp" json:"catchUp"`
 
 	BaseName string `bson:"-" json:"base"`
 }
 
+type LocalTaskRun struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	TaskID    primitive.ObjectID `bson:"taskId" json:"taskId"`
+	Trigger   string             `bson:"trigger" json:"trigger"`
+	Status    string             `bson:"status" json:"status"`
+	Started   time.Time          `bson:"started" json:"started"`
+	Completed time.Time          `bson:"completed" json:"completed"`
+	Output    string             `bson:"output" json:"output"`
+	Error     string             `bson:"error" json:"error"`
+}
+
 func toLocalTask(t model.Task) LocalTask {
 	id, err := primitive.ObjectIDFromHex(t.ID)
 	if err != nil {
@@ -35,6 +50,9 @@ func toLocalTask(t model.Task) LocalTask {
 		Meta:     t.Meta,
 		Interval: t.Interval,
 		LastRun:  t.LastRun,
+		Timezone: t.Timezone,
+		Paused:   t.Paused,
+		CatchUp:  t.CatchUp,
 	}
 }
 
@@ -47,10 +65,26 @@ func fromLocalTask(lt LocalTask) model.Task {
 		Meta:     lt.Meta,
 		Interval: lt.Interval,
 		LastRun:  lt.LastRun,
+		Timezone: lt.Timezone,
+		Paused:   lt.Paused,
+		CatchUp:  lt.CatchUp,
 		BaseName: lt.BaseName,
 	}
 }
 
+func fromLocalTaskRun(lr LocalTaskRun) model.TaskRun {
+	return model.TaskRun{
+		ID:        lr.ID.Hex(),
+		TaskID:    lr.TaskID.Hex(),
+		Trigger:   lr.Trigger,
+		Status:    lr.Status,
+		Started:   lr.Started,
+		Completed: lr.Completed,
+		Output:    lr.Output,
+		Error:     lr.Error,
+	}
+}
+
 type LocalMetaMessage struct {
 	Data    string `bson:"data" json:"data"`
 	Channel string `bson:"channel" json:"channel"`
@@ -129,5 +163,141 @@ func (mg *Mongo) DeleteTask(dbName, id string) error {
 	if _, err := db.Collection("sb_tasks").DeleteOne(mg.Ctx, filter); err != nil {
 		return err
 	}
+
+	filter = bson.M{"taskId": oid}
+	if _, err := db.Collection("sb_task_runs").DeleteMany(mg.Ctx, filter); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) GetTask(dbName, id string) (task model.Task, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var lt LocalTask
+	sr := db.Collection("sb_tasks").FindOne(mg.Ctx, bson.M{FieldID: oid})
+	if err = sr.Decode(&lt); err != nil {
+		return
+	}
+
+	lt.BaseName = dbName
+
+	task = fromLocalTask(lt)
+	return
+}
+
+func (mg *Mongo) SetTaskPaused(dbName, id string, paused bool) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{FieldID: oid}
+	update := bson.M{"$set": bson.M{"paused": paused}}
+	if _, err := db.Collection("sb_tasks").UpdateOne(mg.Ctx, filter, update); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) RanTask(dbName string, run model.TaskRun) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(run.TaskID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{FieldID: oid}
+	update := bson.M{"$set": bson.M{"last": run.Started}}
+	if _, err := db.Collection("sb_tasks").UpdateOne(mg.Ctx, filter, update); err != nil {
+		return err
+	}
+
+	lr := LocalTaskRun{
+		ID:        primitive.NewObjectID(),
+		TaskID:    oid,
+		Trigger:   run.Trigger,
+		Status:    run.Status,
+		Started:   run.Started,
+		Completed: run.Completed,
+		Output:    run.Output,
+		Error:     run.Error,
+	}
+	if _, err := db.Collection("sb_task_runs").InsertOne(mg.Ctx, lr); err != nil {
+		return err
+	}
+
+	// only the most recent runs are kept
+	opt := options.Find()
+	opt.SetSort(bson.M{"started": -1})
+	opt.SetSkip(model.MaxTaskRuns)
+	opt.SetProjection(bson.M{FieldID: 1})
+
+	cur, err := db.Collection("sb_task_runs").Find(mg.Ctx, bson.M{"taskId": oid}, opt)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var ids []primitive.ObjectID
+	for cur.Next(mg.Ctx) {
+		var old LocalTaskRun
+		if err := cur.Decode(&old); err != nil {
+			return err
+		}
+
+		ids = append(ids, old.ID)
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	} else if len(ids) == 0 {
+		return nil
+	}
+
+	filter = bson.M{FieldID: bson.M{"$in": ids}}
+	if _, err := db.Collection("sb_task_runs").DeleteMany(mg.Ctx, filter); err != nil {
+		return err
+	}
 	return nil
 }
+
+func (mg *Mongo) ListTaskRuns(dbName, taskID string) ([]model.TaskRun, error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := options.Find()
+	opt.SetSort(bson.M{"started": -1})
+
+	cur, err := db.Collection("sb_task_runs").Find(mg.Ctx, bson.M{"taskId": oid}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var runs []model.TaskRun
+	for cur.Next(mg.Ctx) {
+		var lr LocalTaskRun
+		if err := cur.Decode(&lr); err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, fromLocalTaskRun(lr))
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
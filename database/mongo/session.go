@@ -0,0 +1,188 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalSession struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	AccountID   primitive.ObjectID `bson:"accountId" json:"accountId"`
+	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
+	RefreshHash string             `bson:"rhash" json:"-"`
+	JWTID       string             `bson:"jti" json:"-"`
+	UserAgent   string             `bson:"ua" json:"userAgent"`
+	IP          string             `bson:"ip" json:"ip"`
+	Created     time.Time          `bson:"created" json:"created"`
+	LastUsed    time.Time          `bson:"used" json:"lastUsed"`
+	Expires     time.Time          `bson:"exp" json:"expires"`
+}
+
+func fromLocalSession(ls LocalSession) model.Session {
+	return model.Session{
+		ID:          ls.ID.Hex(),
+		AccountID:   ls.AccountID.Hex(),
+		UserID:      ls.UserID.Hex(),
+		RefreshHash: ls.RefreshHash,
+		JWTID:       ls.JWTID,
+		UserAgent:   ls.UserAgent,
+		IP:          ls.IP,
+		Created:     ls.Created,
+		LastUsed:    ls.LastUsed,
+		Expires:     ls.Expires,
+	}
+}
+
+func (mg *Mongo) CreateSession(dbName string, s model.Session) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	acctID, err := primitive.ObjectIDFromHex(s.AccountID)
+	if err != nil {
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(s.UserID)
+	if err != nil {
+		return
+	}
+
+	ls := LocalSession{
+		ID:          primitive.NewObjectID(),
+		AccountID:   acctID,
+		UserID:      userID,
+		RefreshHash: s.RefreshHash,
+		JWTID:       s.JWTID,
+		UserAgent:   s.UserAgent,
+		IP:          s.IP,
+		Created:     s.Created,
+		LastUsed:    s.LastUsed,
+		Expires:     s.Expires,
+	}
+
+	res, err := db.Collection("sb_sessions").InsertOne(mg.Ctx, ls)
+	if err != nil {
+		return
+	}
+
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return id, errors.New("unable to get inserted id for session")
+	}
+
+	id = oid.Hex()
+	return
+}
+
+func (mg *Mongo) GetSessionByID(dbName, id string) (s model.Session, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var result LocalSession
+
+	sr := db.Collection("sb_sessions").FindOne(mg.Ctx, bson.M{FieldID: oid})
+	if err = sr.Decode(&result); err != nil {
+		return
+	}
+
+	s = fromLocalSession(result)
+	return
+}
+
+func (mg *Mongo) ListSessions(dbName, userID string) ([]model.Session, error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := options.Find()
+	opt.SetSort(bson.M{"used": -1})
+
+	cur, err := db.Collection("sb_sessions").Find(mg.Ctx, bson.M{"userId": oid}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.Session
+
+	for cur.Next(mg.Ctx) {
+		var ls LocalSession
+		if err := cur.Decode(&ls); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalSession(ls))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (mg *Mongo) RotateSession(dbName string, s model.Session, prevHash string) (bool, error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(s.ID)
+	if err != nil {
+		return false, err
+	}
+
+	update := bson.M{"$set": bson.M{
+		"rhash": s.RefreshHash,
+		"jti":   s.JWTID,
+		"ua":    s.UserAgent,
+		"ip":    s.IP,
+		"used":  s.LastUsed,
+		"exp":   s.Expires,
+	}}
+
+	filter := bson.M{FieldID: oid, "rhash": prevHash}
+
+	res, err := db.Collection("sb_sessions").UpdateOne(mg.Ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (mg *Mongo) DeleteSession(dbName, id string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("sb_sessions").DeleteOne(mg.Ctx, bson.M{FieldID: oid}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) DeleteUserSessions(dbName, userID string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("sb_sessions").DeleteMany(mg.Ctx, bson.M{"userId": oid}); err != nil {
+		return err
+	}
+	return nil
+}
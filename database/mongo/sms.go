@@ -0,0 +1,155 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalSMSMessage struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	AccountID  primitive.ObjectID `bson:"accountId" json:"accountId"`
+	Provider   string             `bson:"provider" json:"provider"`
+	MessageID  string             `bson:"mid" json:"messageId"`
+	ToNumber   string             `bson:"to" json:"toNumber"`
+	FromNumber string             `bson:"from" json:"fromNumber"`
+	Body       string             `bson:"body" json:"body"`
+	Status     string             `bson:"status" json:"status"`
+	Error      string             `bson:"err" json:"error"`
+	Created    time.Time          `bson:"created" json:"created"`
+	Updated    time.Time          `bson:"updated" json:"updated"`
+}
+
+func toLocalSMSMessage(msg model.SMSMessage) LocalSMSMessage {
+	id, err := primitive.ObjectIDFromHex(msg.ID)
+	if err != nil {
+		return LocalSMSMessage{}
+	}
+
+	acctID, err := primitive.ObjectIDFromHex(msg.AccountID)
+	if err != nil {
+		return LocalSMSMessage{}
+	}
+
+	return LocalSMSMessage{
+		ID:         id,
+		AccountID:  acctID,
+		Provider:   msg.Provider,
+		MessageID:  msg.MessageID,
+		ToNumber:   msg.ToNumber,
+		FromNumber: msg.FromNumber,
+		Body:       msg.Body,
+		Status:     msg.Status,
+		Error:      msg.Error,
+		Created:    msg.Created,
+		Updated:    msg.Updated,
+	}
+}
+
+func fromLocalSMSMessage(lm LocalSMSMessage) model.SMSMessage {
+	return model.SMSMessage{
+		ID:         lm.ID.Hex(),
+		AccountID:  lm.AccountID.Hex(),
+		Provider:   lm.Provider,
+		MessageID:  lm.MessageID,
+		ToNumber:   lm.ToNumber,
+		FromNumber: lm.FromNumber,
+		Body:       lm.Body,
+		Status:     lm.Status,
+		Error:      lm.Error,
+		Created:    lm.Created,
+		Updated:    lm.Updated,
+	}
+}
+
+func (mg *Mongo) AddSMSMessage(dbName string, msg model.SMSMessage) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	msg.ID = primitive.NewObjectID().Hex()
+
+	lm := toLocalSMSMessage(msg)
+
+	res, err := db.Collection("sb_sms").InsertOne(mg.Ctx, lm)
+	if err != nil {
+		return
+	}
+
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return id, errors.New("unable to get inserted id for text message")
+	}
+
+	id = oid.Hex()
+	return
+}
+
+func (mg *Mongo) UpdateSMSStatus(dbName, messageID, status, errMsg string) error {
+	db := mg.Client.Database(dbName)
+
+	filter := bson.M{"mid": messageID}
+	update := bson.M{"$set": bson.M{
+		"status":  status,
+		"err":     errMsg,
+		"updated": time.Now(),
+	}}
+
+	if _, err := db.Collection("sb_sms").UpdateMany(mg.Ctx, filter, update); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mg *Mongo) GetSMSMessageByID(dbName, id string) (msg model.SMSMessage, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var result LocalSMSMessage
+
+	sr := db.Collection("sb_sms").FindOne(mg.Ctx, bson.M{FieldID: oid})
+	if err = sr.Decode(&result); err != nil {
+		return
+	}
+
+	msg = fromLocalSMSMessage(result)
+	return
+}
+
+func (mg *Mongo) ListSMSMessages(dbName string) ([]model.SMSMessage, error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find()
+	opt.SetLimit(100)
+	opt.SetSort(bson.M{"created": -1})
+
+	cur, err := db.Collection("sb_sms").Find(mg.Ctx, bson.M{}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var results []model.SMSMessage
+
+	for cur.Next(mg.Ctx) {
+		var lm LocalSMSMessage
+		if err := cur.Decode(&lm); err != nil {
+			return nil, err
+		}
+
+		results = append(results, fromLocalSMSMessage(lm))
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
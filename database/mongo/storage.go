@@ -8,14 +8,17 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type LocalFile struct {
 	ID        primitive.ObjectID `bson:"_id" json:"id"`
 	AccountID primitive.ObjectID `bson:"accountId" json:"accountId"`
+	UserID    string             `bson:"userId" json:"userId"`
 	Key       string             `bson:"key" json:"key"`
 	URL       string             `bson:"url" json:"url"`
 	Size      int64              `bson:"size" json:"size"`
+	Checksum  string             `bson:"sum" json:"checksum"`
 	Uploaded  time.Time          `bson:"on" json:"uploaded"`
 }
 
@@ -33,9 +36,11 @@ func toLocalFile(f model.File) LocalFile {
 	return LocalFile{
 		ID:        id,
 		AccountID: acctID,
+		UserID:    f.UserID,
 		Key:       f.Key,
 		URL:       f.URL,
 		Size:      f.Size,
+		Checksum:  f.Checksum,
 		Uploaded:  f.Uploaded,
 	}
 }
@@ -44,9 +49,11 @@ func fromLocalFile(lf LocalFile) model.File {
 	return model.File{
 		ID:        lf.ID.Hex(),
 		AccountID: lf.AccountID.Hex(),
+		UserID:    lf.UserID,
 		Key:       lf.Key,
 		URL:       lf.URL,
 		Size:      lf.Size,
+		Checksum:  lf.Checksum,
 		Uploaded:  lf.Uploaded,
 	}
 }
@@ -148,3 +155,27 @@ func (mg *Mongo) ListAllFiles(dbName, accountID string) ([]model.File, error) {
 
 	return results, nil
 }
+
+func (mg *Mongo) GetFileByChecksum(dbName, checksum string) (f model.File, err error) {
+	db := mg.Client.Database(dbName)
+
+	var result LocalFile
+
+	sr := db.Collection("sb_files").FindOne(mg.Ctx, bson.M{"sum": checksum})
+	if err = sr.Decode(&result); errors.Is(err, mongo.ErrNoDocuments) {
+		return model.File{}, nil
+	} else if err != nil {
+		return
+	} else if err = sr.Err(); err != nil {
+		return
+	}
+
+	f = fromLocalFile(result)
+	return
+}
+
+func (mg *Mongo) CountFileReferences(dbName, key string) (int64, error) {
+	db := mg.Client.Database(dbName)
+
+	return db.Collection("sb_files").CountDocuments(mg.Ctx, bson.M{"key": key})
+}
@@ -63,3 +63,59 @@ func TestFileStorage(t *testing.T) {
 		t.Errorf("deleted file id returned? %v", check)
 	}
 }
+
+func TestFileChecksumAndReferences(t *testing.T) {
+	f := model.File{
+		AccountID: adminAccount.ID,
+		Key:       "shared-key",
+		URL:       "https://test/shared",
+		Size:      42,
+		Checksum:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		Uploaded:  time.Now(),
+	}
+
+	id, err := datastore.AddFile(confDBName, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dup, err := datastore.GetFileByChecksum(confDBName, f.Checksum)
+	if err != nil {
+		t.Fatal(err)
+	} else if dup.Key != f.Key {
+		t.Errorf("expected key to be %s got %s", f.Key, dup.Key)
+	}
+
+	// a second file pointing to the same stored content
+	id2, err := datastore.AddFile(confDBName, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := datastore.CountFileReferences(confDBName, f.Key)
+	if err != nil {
+		t.Fatal(err)
+	} else if refs != 2 {
+		t.Errorf("expected 2 references got %d", refs)
+	}
+
+	if err := datastore.DeleteFile(confDBName, id); err != nil {
+		t.Fatal(err)
+	} else if err := datastore.DeleteFile(confDBName, id2); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err = datastore.CountFileReferences(confDBName, f.Key)
+	if err != nil {
+		t.Fatal(err)
+	} else if refs != 0 {
+		t.Errorf("expected 0 references got %d", refs)
+	}
+
+	none, err := datastore.GetFileByChecksum(confDBName, "not-a-checksum")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(none.ID) > 0 {
+		t.Errorf("expected no file got %v", none)
+	}
+}
@@ -0,0 +1,112 @@
+package mongo
+
+import (
+	"strings"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (mg *Mongo) ListDocumentsByOwner(dbName, col, ownerID string) ([]map[string]interface{}, error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := db.Collection(model.CleanCollectionName(col)).Find(mg.Ctx, bson.M{FieldOwnerID: oid})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(mg.Ctx)
+
+	var docs []map[string]interface{}
+	for cur.Next(mg.Ctx) {
+		var v map[string]interface{}
+		if err := cur.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		cleanMap(v)
+
+		docs = append(docs, v)
+	}
+	return docs, cur.Err()
+}
+
+func (mg *Mongo) DeleteDocumentsByOwner(dbName, col, ownerID string) (int64, error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := db.Collection(model.CleanCollectionName(col)).DeleteMany(mg.Ctx, bson.M{FieldOwnerID: oid})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (mg *Mongo) ListFormSubmissionsByEmail(dbName, email string) (results []map[string]interface{}, err error) {
+	db := mg.Client.Database(dbName)
+
+	cur, err := db.Collection("sb_forms").Find(mg.Ctx, bson.M{})
+	if err != nil {
+		return
+	}
+	defer cur.Close(mg.Ctx)
+
+	for cur.Next(mg.Ctx) {
+		var result bson.M
+		if err := cur.Decode(&result); err != nil {
+			return nil, err
+		}
+
+		if !holdsEmail(result, email) {
+			continue
+		}
+
+		result["id"] = result[FieldID]
+		delete(result, FieldID)
+
+		results = append(results, result)
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) DeleteFormSubmissionsByEmail(dbName, email string) (int64, error) {
+	db := mg.Client.Database(dbName)
+
+	entries, err := mg.ListFormSubmissionsByEmail(dbName, email)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	var ids []interface{}
+	for _, entry := range entries {
+		ids = append(ids, entry["id"])
+	}
+
+	res, err := db.Collection("sb_forms").DeleteMany(mg.Ctx, bson.M{FieldID: bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// holdsEmail returns true when a top-level field of the submission holds the
+// email
+func holdsEmail(doc map[string]interface{}, email string) bool {
+	for _, v := range doc {
+		if s, ok := v.(string); ok && strings.EqualFold(s, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"time"
+
 	"github.com/staticbackendhq/core/model"
 )
 
@@ -44,6 +46,16 @@ type Persister interface {
 	ChangeTenantPlan(tenantID string, plan int) error
 	// EnableExternalLogin adds or creates a new config for an external login provider
 	EnableExternalLogin(tenantID string, config map[string]model.OAuthConfig) error
+	// SetSMSConfig sets the SMS provider and its credentials for a database
+	SetSMSConfig(baseID string, config model.SMSConfig) error
+	// SetAllowedDomains sets the origins allowed to call the API with this database public key
+	SetAllowedDomains(baseID string, domains []string) error
+	// SetMFARole sets the minimum role required to sign in with a second factor
+	SetMFARole(baseID string, role int) error
+	// SetAuthPolicy sets the email verification and password rules of a database
+	SetAuthPolicy(baseID string, policy model.AuthPolicy) error
+	// FindDatabaseByName returns a database matching by its name
+	FindDatabaseByName(name string) (model.DatabaseConfig, error)
 	// NewID generates a unique identifier that can be used in your model
 	NewID() string
 	// DeleteTenant removes the database and tenant
@@ -55,6 +67,8 @@ type Persister interface {
 	GetUserByID(dbName, accountID, userID string) (model.User, error)
 	// FindUser find a user by its ID
 	FindUser(dbName, userID, token string) (model.User, error)
+	// FindUserByID returns a user by its ID in any account
+	FindUserByID(dbName, userID string) (model.User, error)
 	// FindRootUser validates that those credentials are the root user for a database
 	FindRootUser(dbName, userID, accountID, token string) (model.User, error)
 	// GetRootForBase returns the root user for a database
@@ -83,8 +97,12 @@ type Persister interface {
 	SetUserRole(dbName, email string, role int) error
 	// UserSetPassword user initiated password reset
 	UserSetPassword(dbName, userID, password string) error
+	// SetUserVerified flags a user's email as verified or not
+	SetUserVerified(dbName, userID string, verified bool) error
 	// RemoveUser permanently removes a user from an account
 	RemoveUser(auth model.Auth, dbName, userID string) error
+	// UpdateUserProfile sets the custom profile fields of a user
+	UpdateUserProfile(dbName, userID string, p model.UserProfile) error
 
 	// base CRUD
 	// CreateDocument creates a record in a collection
@@ -120,6 +138,15 @@ type Persister interface {
 	ListFormSubmissions(dbName, name string) ([]map[string]interface{}, error)
 	// GetForms returns all forms
 	GetForms(dbName string) ([]string, error)
+	// SaveFormDefinition creates or updates a form definition by its name
+	SaveFormDefinition(dbName string, def model.FormDefinition) error
+	// GetFormDefinition returns a form definition by its name, the ID is empty when
+	// the form has no definition
+	GetFormDefinition(dbName, name string) (model.FormDefinition, error)
+	// ListFormDefinitions lists all form definitions
+	ListFormDefinitions(dbName string) ([]model.FormDefinition, error)
+	// DeleteFormDefinition removes a form definition by its name
+	DeleteFormDefinition(dbName, name string) error
 
 	// Function functions
 	// AddFunction creates a server-side function
@@ -150,6 +177,15 @@ type Persister interface {
 	AddTask(string, model.Task) (string, error)
 	// DeleteTask removes a task from the reserved sb_tasks collection
 	DeleteTask(dbName, id string) error
+	// GetTask returns a task by its ID
+	GetTask(dbName, id string) (model.Task, error)
+	// SetTaskPaused pauses or resumes a task
+	SetTaskPaused(dbName, id string, paused bool) error
+	// RanTask records a task run, updates the task's last run and keeps the
+	// last model.MaxTaskRuns runs
+	RanTask(dbName string, run model.TaskRun) error
+	// ListTaskRuns returns the runs of a task, most recent first
+	ListTaskRuns(dbName, taskID string) ([]model.TaskRun, error)
 
 	// Files / storage
 	// AddFile adds a new file
@@ -160,6 +196,163 @@ type Persister interface {
 	DeleteFile(dbName, fileID string) error
 	// ListAllFiles lists all file
 	ListAllFiles(dbName, accountID string) ([]model.File, error)
+	// GetFileByChecksum returns a file matching a SHA-256 content checksum or
+	// an empty File if none matches
+	GetFileByChecksum(dbName, checksum string) (f model.File, err error)
+	// CountFileReferences returns the number of files pointing to a storage key
+	CountFileReferences(dbName, key string) (int64, error)
+
+	// SMS messages
+	// AddSMSMessage records a sent text message
+	AddSMSMessage(dbName string, msg model.SMSMessage) (id string, err error)
+	// UpdateSMSStatus updates the delivery status of a message by its provider message ID
+	UpdateSMSStatus(dbName, messageID, status, errMsg string) error
+	// GetSMSMessageByID returns a text message by its ID
+	GetSMSMessageByID(dbName, id string) (model.SMSMessage, error)
+	// ListSMSMessages lists the text messages, most recent first
+	ListSMSMessages(dbName string) ([]model.SMSMessage, error)
+
+	// Email templates and sent log
+	// SaveEmailTemplate creates or updates an email template by its name
+	SaveEmailTemplate(dbName string, tmpl model.EmailTemplate) error
+	// GetEmailTemplate returns an email template by its name
+	GetEmailTemplate(dbName, name string) (model.EmailTemplate, error)
+	// ListEmailTemplates lists all email templates
+	ListEmailTemplates(dbName string) ([]model.EmailTemplate, error)
+	// DeleteEmailTemplate removes an email template by its name
+	DeleteEmailTemplate(dbName, name string) error
+	// AddEmailLog records a sent email
+	AddEmailLog(dbName string, entry model.EmailLog) (id string, err error)
+	// ListEmailLogs lists the sent emails, most recent first
+	ListEmailLogs(dbName string) ([]model.EmailLog, error)
+
+	// Sessions and refresh tokens
+	// CreateSession creates a signed-in session for a user
+	CreateSession(dbName string, s model.Session) (id string, err error)
+	// GetSessionByID returns a session by its ID
+	GetSessionByID(dbName, id string) (model.Session, error)
+	// ListSessions lists the sessions of a user, most recently used first
+	ListSessions(dbName, userID string) ([]model.Session, error)
+	// RotateSession updates a session's refresh token hash, access token ID and
+	// expiration only if its refresh hash is still prevHash. It returns false
+	// when the session was not updated, i.e. already rotated or removed.
+	RotateSession(dbName string, s model.Session, prevHash string) (bool, error)
+	// DeleteSession removes a session
+	DeleteSession(dbName, id string) error
+	// DeleteUserSessions removes all sessions of a user
+	DeleteUserSessions(dbName, userID string) error
+
+	// Two-factor authentication
+	// SaveMFA creates or updates the second factor of a user
+	SaveMFA(dbName string, m model.MFA) error
+	// GetMFA returns the second factor of a user, the UserID is empty when the
+	// user has none
+	GetMFA(dbName, userID string) (model.MFA, error)
+	// DeleteMFA removes the second factor of a user
+	DeleteMFA(dbName, userID string) error
+
+	// API keys
+	// CreateAPIKey creates an API key, only its hash is stored
+	CreateAPIKey(dbName string, k model.APIKey) (id string, err error)
+	// GetAPIKeyByHash returns the API key matching a key hash
+	GetAPIKeyByHash(dbName, hash string) (model.APIKey, error)
+	// ListAPIKeys lists the API keys of a database, newest first
+	ListAPIKeys(dbName string) ([]model.APIKey, error)
+	// TouchAPIKey records when and from which IP an API key was last used
+	TouchAPIKey(dbName, id string, lastUsed time.Time, ip string) error
+	// DeleteAPIKey revokes an API key
+	DeleteAPIKey(dbName, id string) error
+
+	// External login identities
+	// CreateIdentity links an external login identity to a user
+	CreateIdentity(dbName string, idt model.Identity) (id string, err error)
+	// FindIdentity returns the identity of a provider's subject, the ID is
+	// empty when none is linked
+	FindIdentity(dbName, provider, subject string) (model.Identity, error)
+	// ListIdentities lists the identities linked to a user
+	ListIdentities(dbName, userID string) ([]model.Identity, error)
+	// DeleteIdentity unlinks an identity from a user
+	DeleteIdentity(dbName, userID, id string) error
+
+	// Roles and groups
+	// SaveRole creates or updates a role by its name
+	SaveRole(dbName string, role model.Role) error
+	// ListRoles lists the roles of a database by name
+	ListRoles(dbName string) ([]model.Role, error)
+	// DeleteRole removes a role, groups keep its name but get no permissions
+	DeleteRole(dbName, name string) error
+	// CreateGroup creates a group in an account, its name is unique
+	CreateGroup(dbName string, g model.Group) (id string, err error)
+	// GetGroup returns a group of an account with its members
+	GetGroup(dbName, accountID, id string) (model.Group, error)
+	// UpdateGroup changes the name and roles of a group
+	UpdateGroup(dbName string, g model.Group) error
+	// ListGroups lists the groups of an account with their members
+	ListGroups(dbName, accountID string) ([]model.Group, error)
+	// DeleteGroup removes a group and its memberships
+	DeleteGroup(dbName, accountID, id string) error
+	// AddGroupMember adds a user to a group, it does nothing if already a member
+	AddGroupMember(dbName, groupID, userID string) error
+	// RemoveGroupMember removes a user from a group
+	RemoveGroupMember(dbName, groupID, userID string) error
+	// ListUserGroups lists the groups a user is a member of
+	ListUserGroups(dbName, userID string) ([]model.Group, error)
+
+	// Account invitations
+	// CreateInvitation creates a pending invitation
+	CreateInvitation(dbName string, inv model.Invitation) (id string, err error)
+	// GetInvitation returns an invitation by its ID
+	GetInvitation(dbName, id string) (model.Invitation, error)
+	// ListInvitations lists the pending invitations of an account, newest first
+	ListInvitations(dbName, accountID string) ([]model.Invitation, error)
+	// RenewInvitation changes the nonce and expiry of a resent invitation
+	RenewInvitation(dbName, id, nonce string, expires time.Time) error
+	// DeleteInvitation removes an accepted or revoked invitation
+	DeleteInvitation(dbName, id string) error
+
+	// Per-user data export and erase
+	// ListDocumentsByOwner lists the records of a collection created by a user
+	ListDocumentsByOwner(dbName, col, ownerID string) ([]map[string]interface{}, error)
+	// DeleteDocumentsByOwner removes the records of a collection created by a user
+	DeleteDocumentsByOwner(dbName, col, ownerID string) (int64, error)
+	// ListFormSubmissionsByEmail lists the form submissions having a field
+	// holding the email
+	ListFormSubmissionsByEmail(dbName, email string) ([]map[string]interface{}, error)
+	// DeleteFormSubmissionsByEmail removes the form submissions having a field
+	// holding the email
+	DeleteFormSubmissionsByEmail(dbName, email string) (int64, error)
+
+	// Database export and import
+	// DumpLayout returns the layout of the dumped rows, see the DumpLayout
+	// constants
+	DumpLayout() string
+	// DumpTables lists the system tables and collections of a database
+	DumpTables(dbName string) ([]string, error)
+	// DumpRows calls fn with each raw row of the tables, they are read from a
+	// consistent snapshot when the engine supports it
+	DumpRows(dbName string, tables []string, fn func(table string, row map[string]any) error) error
+	// RestoreTenant creates a tenant if it does not exist and its database
+	// keeping their IDs
+	RestoreTenant(cus model.Tenant, base model.DatabaseConfig) error
+	// RestoreRows inserts dumped rows in a table keeping their IDs, the
+	// collection is created if needed
+	RestoreRows(dbName, table string, rows []map[string]any) error
+	// DumpDocuments calls fn with each document of the collections in the
+	// engine-neutral form of the portable archives
+	DumpDocuments(dbName string, cols []string, fn func(col string, doc model.PortableDocument) error) error
+	// RestoreDocuments inserts engine-neutral documents keeping their ID,
+	// account, owner and creation time, the collection is created if needed
+	RestoreDocuments(dbName, col string, docs []model.PortableDocument) error
+	// RestoreAccount inserts an account keeping its ID and creation time
+	RestoreAccount(dbName string, a model.Account) error
+	// RestoreUser inserts a user keeping its ID, password and creation time
+	RestoreUser(dbName string, tok model.User) error
+	// RestoreFile inserts a file keeping its ID
+	RestoreFile(dbName string, f model.File) error
+	// DeleteDatabase removes the tables and collections of a database and the
+	// database itself, the tenant is kept
+	DeleteDatabase(dbName string) error
+
 	// Count returns the numbers of entries in a collection based on optional filters
 	Count(auth model.Auth, dbName, col string, filters map[string]interface{}) (int64, error)
 }
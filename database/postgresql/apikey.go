@@ -0,0 +1,131 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateAPIKey(dbName string, k model.APIKey) (id string, err error) {
+	scopes, err := json.Marshal(k.Scopes)
+	if err != nil {
+		return
+	}
+
+	ips, err := json.Marshal(k.AllowedIPs)
+	if err != nil {
+		return
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_apikeys(account_id, user_id, name, prefix, key_hash, scopes, allowed_ips, expires, last_used, last_ip, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(
+		qry,
+		k.AccountID,
+		k.UserID,
+		k.Name,
+		k.Prefix,
+		k.KeyHash,
+		string(scopes),
+		string(ips),
+		k.Expires,
+		k.LastUsed,
+		k.LastIP,
+		k.Created,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) GetAPIKeyByHash(dbName, hash string) (k model.APIKey, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_apikeys 
+		WHERE key_hash = $1
+	`, dbName)
+
+	row := pg.DB.QueryRow(qry, hash)
+
+	err = scanAPIKey(row, &k)
+	return
+}
+
+func (pg *PostgreSQL) ListAPIKeys(dbName string) (results []model.APIKey, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_apikeys
+		ORDER BY created DESC
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k model.APIKey
+		if err = scanAPIKey(rows, &k); err != nil {
+			return
+		}
+
+		results = append(results, k)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) TouchAPIKey(dbName, id string, lastUsed time.Time, ip string) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_apikeys SET 
+			last_used = $2,
+			last_ip = $3
+		WHERE id = $1
+	`, dbName)
+
+	if _, err := pg.DB.Exec(qry, id, lastUsed, ip); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) DeleteAPIKey(dbName, id string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s.sb_apikeys WHERE id = $1`, dbName)
+
+	if _, err := pg.DB.Exec(qry, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanAPIKey(rows Scanner, k *model.APIKey) error {
+	var scopes, ips []byte
+	err := rows.Scan(
+		&k.ID,
+		&k.AccountID,
+		&k.UserID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		&scopes,
+		&ips,
+		&k.Expires,
+		&k.LastUsed,
+		&k.LastIP,
+		&k.Created,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(scopes, &k.Scopes); err != nil {
+		return err
+	}
+	return json.Unmarshal(ips, &k.AllowedIPs)
+}
@@ -0,0 +1,55 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestAPIKeys(t *testing.T) {
+	k := model.APIKey{
+		AccountID:  adminToken.AccountID,
+		UserID:     adminToken.ID,
+		Name:       "unit test",
+		Prefix:     "sbk_abcd",
+		KeyHash:    model.HashAPIKey("sbk_abcd-unit-test"),
+		Scopes:     []string{"db:read:*", model.ScopeStorage},
+		AllowedIPs: []string{"10.0.0.0/8"},
+		Created:    time.Now(),
+	}
+
+	id, err := datastore.CreateAPIKey(confDBName, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetAPIKeyByHash(confDBName, k.KeyHash)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.ID != id || check.Name != k.Name {
+		t.Errorf("expected api key %s got %v", id, check)
+	} else if len(check.Scopes) != 2 || len(check.AllowedIPs) != 1 {
+		t.Errorf("expected scopes and allowed IPs got %v", check)
+	}
+
+	used := time.Now().Add(time.Minute)
+	if err := datastore.TouchAPIKey(confDBName, id, used, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := datastore.ListAPIKeys(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 {
+		t.Fatalf("expected 1 api key got %d", len(list))
+	} else if list[0].LastIP != "10.0.0.1" || list[0].LastUsed.IsZero() {
+		t.Errorf("expected last used to be tracked got %v", list[0])
+	}
+
+	if err := datastore.DeleteAPIKey(confDBName, id); err != nil {
+		t.Fatal(err)
+	} else if _, err := datastore.GetAPIKeyByHash(confDBName, k.KeyHash); err == nil {
+		t.Error("expected an error getting a revoked api key")
+	}
+}
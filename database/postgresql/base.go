@@ -44,31 +44,13 @@ func (j *JSONB) Scan(value interface{}) error {
 func (pg *PostgreSQL) CreateDocument(auth model.Auth, dbName, col string, doc map[string]interface{}) (inserted map[string]interface{}, err error) {
 	inserted = doc
 
-	cleancol := model.CleanCollectionName(col)
-
-	//TODO: find a good way to prevent doing the create
-	// table if not exists each time
-
-	qry := fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s.%s (
-			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
-			account_id uuid REFERENCES %s.sb_accounts(id) ON DELETE CASCADE,
-			owner_id uuid REFERENCES %s.sb_tokens(id) ON DELETE CASCADE,
-			data jsonb NOT NULL,
-			created timestamp NOT NULL
-		);
-
-		CREATE INDEX IF NOT EXISTS %s_acctid_idx ON %s.%s (account_id);			
-	`, dbName, cleancol, dbName, dbName, cleancol, dbName, cleancol)
-
-	if _, err = pg.DB.Exec(qry); err != nil {
-		err = fmt.Errorf("error creating table: %w", err)
+	if err = pg.createCollection(dbName, col); err != nil {
 		return
 	}
 
 	var id string
 
-	qry = fmt.Sprintf(`
+	qry := fmt.Sprintf(`
 		INSERT INTO %s.%s(account_id, owner_id, data, created)
 		VALUES($1, $2, $3, $4)
 		RETURNING id;
@@ -93,6 +75,30 @@ func (pg *PostgreSQL) CreateDocument(auth model.Auth, dbName, col string, doc ma
 	return
 }
 
+func (pg *PostgreSQL) createCollection(dbName, col string) error {
+	cleancol := model.CleanCollectionName(col)
+
+	//TODO: find a good way to prevent doing the create
+	// table if not exists each time
+
+	qry := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s.%s (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			account_id uuid REFERENCES %s.sb_accounts(id) ON DELETE CASCADE,
+			owner_id uuid REFERENCES %s.sb_tokens(id) ON DELETE CASCADE,
+			data jsonb NOT NULL,
+			created timestamp NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS %s_acctid_idx ON %s.%s (account_id);			
+	`, dbName, cleancol, dbName, dbName, cleancol, dbName, cleancol)
+
+	if _, err := pg.DB.Exec(qry); err != nil {
+		return fmt.Errorf("error creating table: %w", err)
+	}
+	return nil
+}
+
 func (pg *PostgreSQL) BulkCreateDocument(auth model.Auth, dbName, col string, docs []interface{}) error {
 	//TODO: Naive implementation, not sure if PostgreSQL
 	// has a better way for bulk insert, but will suffice for now.
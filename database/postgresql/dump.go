@@ -0,0 +1,238 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) DumpLayout() string {
+	return database.DumpLayoutSQL
+}
+
+func (pg *PostgreSQL) DumpTables(dbName string) ([]string, error) {
+	return pg.ListCollections(dbName)
+}
+
+func (pg *PostgreSQL) DumpRows(dbName string, tables []string, fn func(table string, row map[string]any) error) error {
+	// a repeatable read transaction sees the same snapshot for all tables
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	tx, err := pg.DB.BeginTx(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tableName := func(table string) string {
+		return fmt.Sprintf("%s.%s", dbName, table)
+	}
+	return database.DumpSQLTables(tx, tableName, tables, fn)
+}
+
+func (pg *PostgreSQL) RestoreTenant(cus model.Tenant, base model.DatabaseConfig) error {
+	_, err := pg.DB.Exec(`
+	INSERT INTO sb.customers(id, email, stripe_id, sub_id, plan, is_active, created, external_logins)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (id) DO NOTHING;
+	`, cus.ID, cus.Email,
+		cus.StripeID,
+		cus.SubscriptionID,
+		cus.Plan,
+		cus.IsActive,
+		cus.Created,
+		cus.ExternalLogins,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := pg.DB.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", base.Name)); err != nil {
+		return err
+	}
+
+	policy, err := json.Marshal(base.AuthPolicy)
+	if err != nil {
+		return err
+	}
+
+	_, err = pg.DB.Exec(`
+	INSERT INTO sb.apps(id, customer_id, name, allowed_domain, is_active, monthly_email_sent, created, sms_config, mfa_role, auth_policy)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+	`, base.ID, base.TenantID,
+		base.Name,
+		pq.Array(base.AllowedDomain),
+		base.IsActive,
+		base.MonthlySentEmail,
+		base.Created,
+		base.SMSConfig,
+		base.MFARole,
+		policy,
+	)
+	if err != nil {
+		return err
+	}
+
+	return pg.createSystemTables(base.Name)
+}
+
+func (pg *PostgreSQL) RestoreRows(dbName, table string, rows []map[string]any) error {
+	if !strings.HasPrefix(table, "sb_") {
+		if err := pg.createCollection(dbName, table); err != nil {
+			return err
+		}
+	}
+	return database.RestoreSQLRows(pg.DB, fmt.Sprintf("%s.%s", dbName, table), rows)
+}
+
+func (pg *PostgreSQL) DumpDocuments(dbName string, cols []string, fn func(col string, doc model.PortableDocument) error) error {
+	// a repeatable read transaction sees the same snapshot for all collections
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	tx, err := pg.DB.BeginTx(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, col := range cols {
+		qry := fmt.Sprintf(`SELECT * FROM %s.%s ORDER BY id`, dbName, model.CleanCollectionName(col))
+
+		if err := dumpDocuments(tx, qry, func(doc model.PortableDocument) error {
+			return fn(col, doc)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dumpDocuments(tx *sql.Tx, qry string, fn func(doc model.PortableDocument) error) error {
+	rows, err := tx.Query(qry)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var doc Document
+		if err := scanDocument(rows, &doc); err != nil {
+			return err
+		}
+
+		if err := fn(toPortableDocument(doc)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (pg *PostgreSQL) RestoreDocuments(dbName, col string, docs []model.PortableDocument) error {
+	if err := pg.createCollection(dbName, col); err != nil {
+		return err
+	}
+
+	tx, err := pg.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.%s(id, account_id, owner_id, data, created)
+		VALUES($1, $2, $3, $4, $5)
+	`, dbName, model.CleanCollectionName(col))
+
+	for _, doc := range docs {
+		if _, err := tx.Exec(qry, doc.ID, doc.AccountID, doc.OwnerID, JSONB(doc.Data), doc.Created); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (pg *PostgreSQL) RestoreAccount(dbName string, a model.Account) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_accounts(id, email, created)
+		VALUES($1, $2, $3);
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, a.ID, a.Email, a.Created)
+	return err
+}
+
+func (pg *PostgreSQL) RestoreUser(dbName string, tok model.User) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_tokens(id, account_id, email, password, token, role, reset_code, created, verified, display_name, avatar_id, profile)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+	`, dbName)
+
+	_, err := pg.DB.Exec(
+		qry,
+		tok.ID,
+		tok.AccountID,
+		tok.Email,
+		tok.Password,
+		tok.Token,
+		tok.Role,
+		tok.ResetCode,
+		tok.Created,
+		tok.Verified,
+		tok.DisplayName,
+		tok.AvatarID,
+		JSONB(tok.Profile),
+	)
+	return err
+}
+
+func (pg *PostgreSQL) RestoreFile(dbName string, f model.File) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_files(id, account_id, key, url, size, uploaded, checksum, user_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8);
+	`, dbName)
+
+	_, err := pg.DB.Exec(
+		qry,
+		f.ID,
+		f.AccountID,
+		f.Key,
+		f.URL,
+		f.Size,
+		f.Uploaded,
+		f.Checksum,
+		f.UserID,
+	)
+	return err
+}
+
+// toPortableDocument removes the system fields added to the data when read
+func toPortableDocument(doc Document) model.PortableDocument {
+	data := make(map[string]any, len(doc.Data))
+	for k, v := range doc.Data {
+		if k == FieldID || k == FieldAccountID {
+			continue
+		}
+		data[k] = v
+	}
+
+	return model.PortableDocument{
+		ID:        doc.ID,
+		AccountID: doc.AccountID,
+		OwnerID:   doc.OwnerID,
+		Created:   doc.Created,
+		Data:      data,
+	}
+}
+
+func (pg *PostgreSQL) DeleteDatabase(dbName string) error {
+	if _, err := pg.DB.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS %s CASCADE;`, dbName)); err != nil {
+		return err
+	}
+
+	_, err := pg.DB.Exec(`DELETE FROM sb.apps WHERE name = $1`, dbName)
+	return err
+}
@@ -0,0 +1,257 @@
+package postgresql
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/database/sqlite"
+	"github.com/staticbackendhq/core/model"
+	_ "modernc.org/sqlite"
+)
+
+// dumpRows returns the rows of the tables with the same encoding as the
+// export archive
+func dumpRows(t *testing.T, src database.Persister, dbName string, tables []string) map[string][]map[string]any {
+	dumped := make(map[string][]map[string]any)
+	err := src.DumpRows(dbName, tables, func(table string, row map[string]any) error {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+
+		var restored map[string]any
+		if err := dec.Decode(&restored); err != nil {
+			return err
+		}
+
+		dumped[table] = append(dumped[table], restored)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dumped
+}
+
+func restoreRows(t *testing.T, dbName string, tables []string, dumped map[string][]map[string]any) {
+	for _, table := range tables {
+		if err := datastore.RestoreRows(dbName, table, dumped[table]); err != nil {
+			t.Fatalf("restoring %s: %v", table, err)
+		}
+	}
+}
+
+func TestDumpAndRestore(t *testing.T) {
+	col := "dump_tests"
+
+	doc, err := datastore.CreateDocument(adminAuth, confDBName, col, map[string]interface{}{"name": "dumped"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := datastore.FindDatabaseByName(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cus, err := datastore.FindTenant(conf.TenantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := model.DatabaseConfig{
+		ID:            datastore.NewID(),
+		TenantID:      cus.ID,
+		Name:          fmt.Sprintf("dump%d", time.Now().UnixNano()),
+		AllowedDomain: []string{"localhost"},
+		IsActive:      true,
+		Created:       time.Now(),
+		MFARole:       2,
+	}
+
+	// restoring an existing tenant only adds the database
+	if err := datastore.RestoreTenant(cus, base); err != nil {
+		t.Fatal(err)
+	}
+
+	tables := []string{"sb_accounts", "sb_tokens", col}
+	restoreRows(t, base.Name, tables, dumpRows(t, datastore, confDBName, tables))
+
+	check, err := datastore.FindDatabaseByName(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.ID != base.ID || check.MFARole != 2 {
+		t.Errorf("expected the database to keep its ID and settings got %v", check)
+	}
+
+	user, err := datastore.FindUserByID(base.Name, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if user.Email != adminToken.Email || user.AccountID != adminToken.AccountID {
+		t.Errorf("expected the restored user got %v", user)
+	}
+
+	id := fmt.Sprintf("%v", doc[FieldID])
+	restored, err := datastore.GetDocumentByID(adminAuth, base.Name, col, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if restored["name"] != "dumped" || fmt.Sprintf("%v", restored[FieldID]) != id {
+		t.Errorf("expected the restored document with its ID got %v", restored)
+	}
+
+	if err := datastore.DeleteDatabase(base.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, err := datastore.DatabaseExists(base.Name); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Error("expected the database to be deleted")
+	}
+
+	if _, err := datastore.FindTenant(cus.ID); err != nil {
+		t.Errorf("expected the tenant to be kept got %v", err)
+	}
+}
+
+func TestRestoreFromSQLite(t *testing.T) {
+	col := "sqlite_tests"
+
+	conn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	src := sqlite.New(conn, fakePubDocEvent, nil)
+	if src.DumpLayout() != datastore.DumpLayout() {
+		t.Fatalf("expected SQLite to share the %s layout", datastore.DumpLayout())
+	}
+
+	cus, err := src.CreateTenant(model.Tenant{
+		Email:          "sqlite-dump@test.com",
+		StripeID:       "sqlite-dump@test.com",
+		SubscriptionID: "sqlite-dump@test.com",
+		IsActive:       true,
+		Created:        time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := src.CreateDatabase(model.DatabaseConfig{
+		ID:            src.NewID(),
+		TenantID:      cus.ID,
+		Name:          fmt.Sprintf("sqlite%d", time.Now().UnixNano()),
+		AllowedDomain: []string{"localhost"},
+		IsActive:      true,
+		Created:       time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acctID, err := src.CreateAccount(base.Name, cus.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok := model.User{
+		AccountID: acctID,
+		Email:     cus.Email,
+		Password:  adminPassword,
+		Token:     "sqlite-token",
+		Role:      100,
+		Created:   time.Now(),
+		Verified:  true,
+	}
+	userID, err := src.CreateUser(base.Name, tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: acctID, UserID: userID, Email: cus.Email, Role: 100}
+	doc, err := src.CreateDocument(auth, base.Name, col, map[string]interface{}{"name": "from sqlite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := datastore.RestoreTenant(cus, base); err != nil {
+		t.Fatal(err)
+	}
+
+	tables := []string{"sb_accounts", "sb_tokens", col}
+	restoreRows(t, base.Name, tables, dumpRows(t, src, base.Name, tables))
+
+	user, err := datastore.FindUserByID(base.Name, userID)
+	if err != nil {
+		t.Fatal(err)
+	} else if user.Email != tok.Email || user.AccountID != acctID || !user.Verified {
+		t.Errorf("expected the SQLite user got %v", user)
+	}
+
+	id := fmt.Sprintf("%v", doc[FieldID])
+	restored, err := datastore.GetDocumentByID(auth, base.Name, col, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if restored["name"] != "from sqlite" || fmt.Sprintf("%v", restored[FieldID]) != id {
+		t.Errorf("expected the SQLite document with its ID got %v", restored)
+	}
+
+	if err := datastore.DeleteDatabase(base.Name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDumpDocumentsAndRestore(t *testing.T) {
+	col := "portable_tests"
+
+	doc, err := datastore.CreateDocument(adminAuth, confDBName, col, map[string]interface{}{"name": "portable", "views": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var docs []model.PortableDocument
+	err = datastore.DumpDocuments(confDBName, []string{col}, func(c string, pd model.PortableDocument) error {
+		if c != col {
+			t.Errorf("expected collection %s got %s", col, c)
+		}
+		docs = append(docs, pd)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(docs) != 1 {
+		t.Fatalf("expected 1 document got %d", len(docs))
+	}
+
+	pd := docs[0]
+	if pd.ID != fmt.Sprintf("%v", doc[FieldID]) || pd.AccountID != adminAuth.AccountID || pd.OwnerID != adminAuth.UserID {
+		t.Errorf("expected normalised system fields got %v", pd)
+	} else if pd.Created.IsZero() {
+		t.Error("expected the creation time to be dumped")
+	} else if _, ok := pd.Data[FieldID]; ok {
+		t.Errorf("expected the data to exclude system fields got %v", pd.Data)
+	}
+
+	restoredCol := "portable_restored"
+	if err := datastore.RestoreDocuments(confDBName, restoredCol, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := datastore.GetDocumentByID(adminAuth, confDBName, restoredCol, pd.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if restored["name"] != "portable" || fmt.Sprintf("%v", restored[FieldID]) != pd.ID {
+		t.Errorf("expected the restored document with its ID got %v", restored)
+	}
+}
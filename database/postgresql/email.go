@@ -0,0 +1,149 @@
+package postgresql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) SaveEmailTemplate(dbName string, tmpl model.EmailTemplate) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_email_templates(name, subject, html_body, text_body, updated)
+		VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT(name) DO UPDATE SET 
+			subject = excluded.subject,
+			html_body = excluded.html_body,
+			text_body = excluded.text_body,
+			updated = excluded.updated;
+	`, dbName)
+
+	_, err := pg.DB.Exec(
+		qry,
+		tmpl.Name,
+		tmpl.Subject,
+		tmpl.HTMLBody,
+		tmpl.TextBody,
+		time.Now(),
+	)
+	return err
+}
+
+func (pg *PostgreSQL) GetEmailTemplate(dbName, name string) (tmpl model.EmailTemplate, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_email_templates 
+		WHERE name = $1
+	`, dbName)
+
+	row := pg.DB.QueryRow(qry, name)
+
+	err = scanEmailTemplate(row, &tmpl)
+	return
+}
+
+func (pg *PostgreSQL) ListEmailTemplates(dbName string) (results []model.EmailTemplate, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_email_templates
+		ORDER BY name
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tmpl model.EmailTemplate
+		if err = scanEmailTemplate(rows, &tmpl); err != nil {
+			return
+		}
+
+		results = append(results, tmpl)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) DeleteEmailTemplate(dbName, name string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s.sb_email_templates WHERE name = $1`, dbName)
+
+	if _, err := pg.DB.Exec(qry, name); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) AddEmailLog(dbName string, entry model.EmailLog) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_email_logs(template, from_email, to_email, subject, status, error, sent)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(
+		qry,
+		entry.Template,
+		entry.From,
+		entry.To,
+		entry.Subject,
+		entry.Status,
+		entry.Error,
+		entry.Sent,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) ListEmailLogs(dbName string) (results []model.EmailLog, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_email_logs
+		ORDER BY sent DESC
+		LIMIT 100
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry model.EmailLog
+		if err = scanEmailLog(rows, &entry); err != nil {
+			return
+		}
+
+		results = append(results, entry)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func scanEmailTemplate(rows Scanner, tmpl *model.EmailTemplate) error {
+	return rows.Scan(
+		&tmpl.ID,
+		&tmpl.Name,
+		&tmpl.Subject,
+		&tmpl.HTMLBody,
+		&tmpl.TextBody,
+		&tmpl.Updated,
+	)
+}
+
+func scanEmailLog(rows Scanner, entry *model.EmailLog) error {
+	return rows.Scan(
+		&entry.ID,
+		&entry.Template,
+		&entry.From,
+		&entry.To,
+		&entry.Subject,
+		&entry.Status,
+		&entry.Error,
+		&entry.Sent,
+	)
+}
@@ -0,0 +1,71 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestEmailTemplates(t *testing.T) {
+	tmpl := model.EmailTemplate{
+		Name:     "welcome",
+		Subject:  "Welcome {{.name}}",
+		HTMLBody: "<p>Hello {{.name}}</p>",
+		TextBody: "Hello {{.name}}",
+	}
+
+	if err := datastore.SaveEmailTemplate(confDBName, tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl.Subject = "Welcome aboard {{.name}}"
+	if err := datastore.SaveEmailTemplate(confDBName, tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetEmailTemplate(confDBName, tmpl.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.Subject != tmpl.Subject {
+		t.Errorf("expected subject to be %s got %s", tmpl.Subject, check.Subject)
+	}
+
+	list, err := datastore.ListEmailTemplates(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 {
+		t.Errorf("expected 1 template got %d", len(list))
+	}
+
+	if err := datastore.DeleteEmailTemplate(confDBName, tmpl.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.GetEmailTemplate(confDBName, tmpl.Name); err == nil {
+		t.Errorf("expected template to be deleted")
+	}
+}
+
+func TestEmailLogs(t *testing.T) {
+	entry := model.EmailLog{
+		From:    "unit@test.com",
+		To:      "dest@test.com",
+		Subject: "unit test",
+		Status:  model.EmailStatusSent,
+		Sent:    time.Now(),
+	}
+
+	if _, err := datastore.AddEmailLog(confDBName, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := datastore.ListEmailLogs(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) == 0 {
+		t.Fatal("expected at least one email log")
+	} else if list[0].To != entry.To {
+		t.Errorf("expected to be %s got %s", entry.To, list[0].To)
+	}
+}
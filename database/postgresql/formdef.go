@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) SaveFormDefinition(dbName string, def model.FormDefinition) error {
+	fields, err := json.Marshal(def.Fields)
+	if err != nil {
+		return err
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_form_definitions(name, fields, redirect_url, error_url, notify_email, email_template, max_per_hour, updated)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT(name) DO UPDATE SET 
+			fields = excluded.fields,
+			redirect_url = excluded.redirect_url,
+			error_url = excluded.error_url,
+			notify_email = excluded.notify_email,
+			email_template = excluded.email_template,
+			max_per_hour = excluded.max_per_hour,
+			updated = excluded.updated;
+	`, dbName)
+
+	_, err = pg.DB.Exec(
+		qry,
+		def.Name,
+		string(fields),
+		def.RedirectURL,
+		def.ErrorURL,
+		def.NotifyEmail,
+		def.EmailTemplate,
+		def.MaxPerHour,
+		time.Now(),
+	)
+	return err
+}
+
+func (pg *PostgreSQL) GetFormDefinition(dbName, name string) (def model.FormDefinition, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_form_definitions 
+		WHERE name = $1
+	`, dbName)
+
+	row := pg.DB.QueryRow(qry, name)
+
+	err = scanFormDefinition(row, &def)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.FormDefinition{}, nil
+	}
+	return
+}
+
+func (pg *PostgreSQL) ListFormDefinitions(dbName string) (results []model.FormDefinition, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_form_definitions
+		ORDER BY name
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var def model.FormDefinition
+		if err = scanFormDefinition(rows, &def); err != nil {
+			return
+		}
+
+		results = append(results, def)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) DeleteFormDefinition(dbName, name string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s.sb_form_definitions WHERE name = $1`, dbName)
+
+	if _, err := pg.DB.Exec(qry, name); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanFormDefinition(rows Scanner, def *model.FormDefinition) error {
+	var fields []byte
+	err := rows.Scan(
+		&def.ID,
+		&def.Name,
+		&fields,
+		&def.RedirectURL,
+		&def.ErrorURL,
+		&def.NotifyEmail,
+		&def.EmailTemplate,
+		&def.MaxPerHour,
+		&def.Updated,
+	)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(fields, &def.Fields)
+}
@@ -0,0 +1,92 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateIdentity(dbName string, idt model.Identity) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_identities(account_id, user_id, provider, subject, email, created)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(
+		qry,
+		idt.AccountID,
+		idt.UserID,
+		idt.Provider,
+		idt.Subject,
+		idt.Email,
+		idt.Created,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) FindIdentity(dbName, provider, subject string) (idt model.Identity, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_identities 
+		WHERE provider = $1 AND subject = $2
+	`, dbName)
+
+	row := pg.DB.QueryRow(qry, provider, subject)
+
+	err = scanIdentity(row, &idt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Identity{}, nil
+	}
+	return
+}
+
+func (pg *PostgreSQL) ListIdentities(dbName, userID string) (results []model.Identity, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_identities
+		WHERE user_id = $1
+		ORDER BY created
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, userID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var idt model.Identity
+		if err = scanIdentity(rows, &idt); err != nil {
+			return
+		}
+
+		results = append(results, idt)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) DeleteIdentity(dbName, userID, id string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s.sb_identities WHERE user_id = $1 AND id = $2`, dbName)
+
+	if _, err := pg.DB.Exec(qry, userID, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanIdentity(rows Scanner, idt *model.Identity) error {
+	return rows.Scan(
+		&idt.ID,
+		&idt.AccountID,
+		&idt.UserID,
+		&idt.Provider,
+		&idt.Subject,
+		&idt.Email,
+		&idt.Created,
+	)
+}
@@ -0,0 +1,106 @@
+package postgresql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateInvitation(dbName string, inv model.Invitation) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_invitations(account_id, invited_by, email, role, link, nonce, expires, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(
+		qry,
+		inv.AccountID,
+		inv.InvitedBy,
+		inv.Email,
+		inv.Role,
+		inv.Link,
+		inv.Nonce,
+		inv.Expires,
+		inv.Created,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) GetInvitation(dbName, id string) (inv model.Invitation, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_invitations 
+		WHERE id = $1
+	`, dbName)
+
+	row := pg.DB.QueryRow(qry, id)
+
+	err = scanInvitation(row, &inv)
+	return
+}
+
+func (pg *PostgreSQL) ListInvitations(dbName, accountID string) (results []model.Invitation, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_invitations
+		WHERE account_id = $1
+		ORDER BY created DESC
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, accountID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var inv model.Invitation
+		if err = scanInvitation(rows, &inv); err != nil {
+			return
+		}
+
+		results = append(results, inv)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) RenewInvitation(dbName, id, nonce string, expires time.Time) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_invitations SET 
+			nonce = $2,
+			expires = $3
+		WHERE id = $1
+	`, dbName)
+
+	if _, err := pg.DB.Exec(qry, id, nonce, expires); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) DeleteInvitation(dbName, id string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s.sb_invitations WHERE id = $1`, dbName)
+
+	if _, err := pg.DB.Exec(qry, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanInvitation(rows Scanner, inv *model.Invitation) error {
+	return rows.Scan(
+		&inv.ID,
+		&inv.AccountID,
+		&inv.InvitedBy,
+		&inv.Email,
+		&inv.Role,
+		&inv.Link,
+		&inv.Nonce,
+		&inv.Expires,
+		&inv.Created,
+	)
+}
@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,8 +23,8 @@ func (pg *PostgreSQL) CreateUser(dbName string, tok model.User) (id string, err
 	tok.Created = time.Now()
 
 	qry := fmt.Sprintf(`
-		INSERT INTO %s.sb_tokens(account_id, email, password, token, role, reset_code, created)
-		VALUES($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO %s.sb_tokens(account_id, email, password, token, role, reset_code, created, verified, display_name, avatar_id, profile)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id;
 	`, dbName)
 
@@ -36,6 +37,10 @@ func (pg *PostgreSQL) CreateUser(dbName string, tok model.User) (id string, err
 		tok.Role,
 		tok.ResetCode,
 		tok.Created,
+		tok.Verified,
+		tok.DisplayName,
+		tok.AvatarID,
+		JSONB(tok.Profile),
 	).Scan(&id)
 	return
 }
@@ -78,6 +83,33 @@ func (pg *PostgreSQL) UserSetPassword(dbName, tokenID, password string) error {
 	return nil
 }
 
+func (pg *PostgreSQL) SetUserVerified(dbName, userID string, verified bool) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_tokens SET verified = $2
+		WHERE id = $1;
+	`, dbName)
+
+	if _, err := pg.DB.Exec(qry, userID, verified); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) UpdateUserProfile(dbName, userID string, p model.UserProfile) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_tokens SET
+			display_name = $2,
+			avatar_id = $3,
+			profile = $4
+		WHERE id = $1;
+	`, dbName)
+
+	if _, err := pg.DB.Exec(qry, userID, p.DisplayName, p.AvatarID, JSONB(p.Profile)); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (pg *PostgreSQL) GetFirstUserFromAccountID(dbName, accountID string) (tok model.User, err error) {
 	qry := fmt.Sprintf(`
 		SELECT * 
@@ -110,12 +142,21 @@ func (pg *PostgreSQL) SetPasswordResetCode(dbName, userID, code string) error {
 func (pg *PostgreSQL) ResetPassword(dbName, email, code, password string) error {
 	qry := fmt.Sprintf(`
 		UPDATE %s.sb_tokens SET
-			password = $3
-		WHERE email = $1 AND reset_code = $2
+			password = $3,
+			reset_code = ''
+		WHERE email = $1 AND reset_code = $2 AND reset_code <> ''
 	`, dbName)
 
-	if _, err := pg.DB.Exec(qry, email, code, password); err != nil {
+	res, err := pg.DB.Exec(qry, email, code, password)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
 		return err
+	} else if n == 0 {
+		return errors.New("invalid code")
 	}
 	return nil
 }
@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) SaveMFA(dbName string, m model.MFA) error {
+	codes, err := json.Marshal(m.RecoveryCodes)
+	if err != nil {
+		return err
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_mfa(user_id, secret, enabled, recovery_codes, last_step, updated)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT(user_id) DO UPDATE SET 
+			secret = excluded.secret,
+			enabled = excluded.enabled,
+			recovery_codes = excluded.recovery_codes,
+			last_step = excluded.last_step,
+			updated = excluded.updated;
+	`, dbName)
+
+	_, err = pg.DB.Exec(
+		qry,
+		m.UserID,
+		m.Secret,
+		m.Enabled,
+		string(codes),
+		m.LastStep,
+		time.Now(),
+	)
+	return err
+}
+
+func (pg *PostgreSQL) GetMFA(dbName, userID string) (m model.MFA, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_mfa 
+		WHERE user_id = $1
+	`, dbName)
+
+	var codes []byte
+	err = pg.DB.QueryRow(qry, userID).Scan(
+		&m.UserID,
+		&m.Secret,
+		&m.Enabled,
+		&codes,
+		&m.LastStep,
+		&m.Updated,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.MFA{}, nil
+	} else if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(codes, &m.RecoveryCodes)
+	return
+}
+
+func (pg *PostgreSQL) DeleteMFA(dbName, userID string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s.sb_mfa WHERE user_id = $1`, dbName)
+
+	if _, err := pg.DB.Exec(qry, userID); err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -76,7 +77,11 @@ func (pg *PostgreSQL) createSystemTables(schema string) error {
 			password TEXT NOT NULL,
 			role INTEGER NOT NULL,
 			reset_code TEXT NOT NULL,
-			created timestamp NOT NULL			
+			created timestamp NOT NULL,
+			verified BOOLEAN NOT NULL DEFAULT TRUE,
+			display_name TEXT NOT NULL DEFAULT '',
+			avatar_id TEXT NOT NULL DEFAULT '',
+			profile JSONB NOT NULL DEFAULT '{}'
 		);
 
 		CREATE TABLE IF NOT EXISTS {schema}.sb_forms (
@@ -90,12 +95,16 @@ func (pg *PostgreSQL) createSystemTables(schema string) error {
 		CREATE TABLE IF NOT EXISTS {schema}.sb_files (
 			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
 			account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
-			key TEXT UNIQUE NOT NULL,
+			key TEXT NOT NULL,
 			url TEXT NOT NULL,
 			size INTEGER NOT NULL,			
-			uploaded timestamp NOT NULL			
+			uploaded timestamp NOT NULL,
+			checksum TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL DEFAULT ''
 		);
 		CREATE INDEX IF NOT EXISTS sb_files_acctid_idx ON {schema}.sb_files (account_id);
+		CREATE INDEX IF NOT EXISTS sb_files_key_idx ON {schema}.sb_files (key);
+		CREATE INDEX IF NOT EXISTS sb_files_checksum_idx ON {schema}.sb_files (checksum);
 
 		CREATE TABLE IF NOT EXISTS {schema}.sb_functions (
 			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
@@ -125,8 +134,161 @@ func (pg *PostgreSQL) createSystemTables(schema string) error {
 			value TEXT NOT NULL,
 			meta TEXT NOT NULL,
 			interval TEXT NOT NULL,
-			last_run timestamp NOT NULL
+			last_run timestamp NOT NULL,
+			timezone TEXT NOT NULL DEFAULT '',
+			paused BOOLEAN NOT NULL DEFAULT FALSE,
+			catch_up TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_task_runs (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			task_id uuid REFERENCES {schema}.sb_tasks(id) ON DELETE CASCADE,
+			triggered_by TEXT NOT NULL,
+			status TEXT NOT NULL,
+			started timestamp NOT NULL,
+			completed timestamp NOT NULL,
+			output TEXT NOT NULL,
+			error TEXT NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS sb_task_runs_taskid_idx ON {schema}.sb_task_runs (task_id, started);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_sms (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			provider TEXT NOT NULL,
+			message_id TEXT NOT NULL,
+			to_number TEXT NOT NULL,
+			from_number TEXT NOT NULL,
+			body TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL,
+			created timestamp NOT NULL,
+			updated timestamp NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS sb_sms_message_id_idx ON {schema}.sb_sms (message_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_email_templates (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			name TEXT UNIQUE NOT NULL,
+			subject TEXT NOT NULL,
+			html_body TEXT NOT NULL,
+			text_body TEXT NOT NULL,
+			updated timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_email_logs (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			template TEXT NOT NULL,
+			from_email TEXT NOT NULL,
+			to_email TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL,
+			sent timestamp NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS sb_email_logs_sent_idx ON {schema}.sb_email_logs (sent);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_form_definitions (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			name TEXT UNIQUE NOT NULL,
+			fields JSONB NOT NULL,
+			redirect_url TEXT NOT NULL,
+			error_url TEXT NOT NULL,
+			notify_email TEXT NOT NULL,
+			email_template TEXT NOT NULL,
+			max_per_hour INTEGER NOT NULL,
+			updated timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_sessions (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			user_id uuid REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			refresh_hash TEXT NOT NULL,
+			jwt_id TEXT NOT NULL,
+			user_agent TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			created timestamp NOT NULL,
+			last_used timestamp NOT NULL,
+			expires timestamp NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS sb_sessions_userid_idx ON {schema}.sb_sessions (user_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_mfa (
+			user_id uuid PRIMARY KEY REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			secret bytea NOT NULL,
+			enabled BOOLEAN NOT NULL,
+			recovery_codes JSONB NOT NULL,
+			last_step BIGINT NOT NULL,
+			updated timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_apikeys (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			user_id uuid REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			key_hash TEXT UNIQUE NOT NULL,
+			scopes JSONB NOT NULL,
+			allowed_ips JSONB NOT NULL,
+			expires timestamp NOT NULL,
+			last_used timestamp NOT NULL,
+			last_ip TEXT NOT NULL,
+			created timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_identities (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			user_id uuid REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			provider TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			email TEXT NOT NULL,
+			created timestamp NOT NULL,
+			UNIQUE(provider, subject)
+		);
+
+		CREATE INDEX IF NOT EXISTS sb_identities_userid_idx ON {schema}.sb_identities (user_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_roles (
+			name TEXT PRIMARY KEY,
+			description TEXT NOT NULL,
+			permissions JSONB NOT NULL,
+			updated timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_groups (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			roles JSONB NOT NULL,
+			created timestamp NOT NULL,
+			UNIQUE(account_id, name)
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_group_members (
+			group_id uuid REFERENCES {schema}.sb_groups(id) ON DELETE CASCADE,
+			user_id uuid REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			PRIMARY KEY(group_id, user_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS sb_group_members_userid_idx ON {schema}.sb_group_members (user_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_invitations (
+			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+			account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			invited_by uuid NOT NULL,
+			email TEXT NOT NULL,
+			role INTEGER NOT NULL,
+			link TEXT NOT NULL,
+			nonce TEXT NOT NULL,
+			expires timestamp NOT NULL,
+			created timestamp NOT NULL
 		);
+
+		CREATE INDEX IF NOT EXISTS sb_invitations_acctid_idx ON {schema}.sb_invitations (account_id);
 	`, "{schema}", schema, -1)
 
 	if _, err := pg.DB.Exec(qry); err != nil {
@@ -169,6 +331,17 @@ func (pg *PostgreSQL) FindDatabase(baseID string) (base model.DatabaseConfig, er
 	return
 }
 
+func (pg *PostgreSQL) FindDatabaseByName(name string) (base model.DatabaseConfig, err error) {
+	row := pg.DB.QueryRow(`
+		SELECT * 
+		FROM sb.apps 
+		WHERE name = $1
+	`, name)
+
+	err = scanBase(row, &base)
+	return
+}
+
 func (pg *PostgreSQL) DatabaseExists(name string) (exists bool, err error) {
 	var count int
 	err = pg.DB.QueryRow(`
@@ -272,6 +445,44 @@ func (pg *PostgreSQL) EnableExternalLogin(tenantID string, config map[string]mod
 	return nil
 }
 
+func (pg *PostgreSQL) SetSMSConfig(baseID string, config model.SMSConfig) error {
+	b, err := model.EncryptSMSConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := pg.DB.Exec(`UPDATE sb.apps SET sms_config = $2 WHERE id = $1`, baseID, b); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) SetAllowedDomains(baseID string, domains []string) error {
+	if _, err := pg.DB.Exec(`UPDATE sb.apps SET allowed_domain = $2 WHERE id = $1`, baseID, pq.Array(domains)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) SetMFARole(baseID string, role int) error {
+	if _, err := pg.DB.Exec(`UPDATE sb.apps SET mfa_role = $2 WHERE id = $1`, baseID, role); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) SetAuthPolicy(baseID string, policy model.AuthPolicy) error {
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if _, err := pg.DB.Exec(`UPDATE sb.apps SET auth_policy = $2 WHERE id = $1`, baseID, b); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (pg *PostgreSQL) NewID() string {
 	var id string
 	if err := pg.DB.QueryRow(`SELECT uuid_generate_v4 ()`).Scan(&id); err != nil {
@@ -308,7 +519,8 @@ func scanCustomer(rows Scanner, c *model.Tenant) error {
 }
 
 func scanBase(rows Scanner, b *model.DatabaseConfig) error {
-	return rows.Scan(
+	var authPolicy []byte
+	err := rows.Scan(
 		&b.ID,
 		&b.TenantID,
 		&b.Name,
@@ -316,7 +528,20 @@ func scanBase(rows Scanner, b *model.DatabaseConfig) error {
 		&b.IsActive,
 		&b.MonthlySentEmail,
 		&b.Created,
+		&b.SMSConfig,
+		&b.MFARole,
+		&authPolicy,
 	)
+	if err != nil {
+		return err
+	}
+
+	if len(authPolicy) > 0 {
+		if err := json.Unmarshal(authPolicy, &b.AuthPolicy); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (pg *PostgreSQL) GetAllDatabaseSizes() error {
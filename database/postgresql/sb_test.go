@@ -142,3 +142,82 @@ func TestGetTenantByEmail(t *testing.T) {
 		t.Errorf("expected same email for found customer")
 	}
 }
+
+func TestSetSMSConfig(t *testing.T) {
+	cfg := model.SMSConfig{
+		Provider:     "twilio",
+		AccountID:    "sid",
+		AuthToken:    "token",
+		FromNumber:   "+15555555555",
+		WebhookToken: "webhook-token",
+	}
+
+	if err := datastore.SetSMSConfig(dbTest.ID, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := datastore.FindDatabaseByName(dbTest.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if base.ID != dbTest.ID {
+		t.Errorf("expected database id to be %s got %s", dbTest.ID, base.ID)
+	}
+
+	decrypted, err := base.GetSMSConfig()
+	if err != nil {
+		t.Fatal(err)
+	} else if decrypted != cfg {
+		t.Errorf("expected %v got %v", cfg, decrypted)
+	}
+}
+
+func TestSetAllowedDomains(t *testing.T) {
+	domains := []string{"example.com", "*.example.com"}
+
+	if err := datastore.SetAllowedDomains(dbTest.ID, domains); err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := datastore.FindDatabase(dbTest.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(base.AllowedDomain) != 2 || base.AllowedDomain[1] != "*.example.com" {
+		t.Errorf("expected allowed domains to be %v got %v", domains, base.AllowedDomain)
+	}
+}
+
+func TestSetMFARole(t *testing.T) {
+	if err := datastore.SetMFARole(dbTest.ID, 50); err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := datastore.FindDatabase(dbTest.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if base.MFARole != 50 {
+		t.Errorf("expected mfa role to be 50 got %d", base.MFARole)
+	}
+}
+
+func TestSetAuthPolicy(t *testing.T) {
+	policy := model.AuthPolicy{
+		EmailVerification: model.EmailVerificationBlock,
+		VerifyURL:         "https://example.com/verify",
+		Password: model.PasswordPolicy{
+			MinLength:     10,
+			RequireDigit:  true,
+			CheckBreached: true,
+		},
+	}
+
+	if err := datastore.SetAuthPolicy(dbTest.ID, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := datastore.FindDatabase(dbTest.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if base.AuthPolicy != policy {
+		t.Errorf("expected auth policy %v got %v", policy, base.AuthPolicy)
+	}
+}
@@ -0,0 +1,136 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateSession(dbName string, s model.Session) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_sessions(account_id, user_id, refresh_hash, jwt_id, user_agent, ip, created, last_used, expires)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(
+		qry,
+		s.AccountID,
+		s.UserID,
+		s.RefreshHash,
+		s.JWTID,
+		s.UserAgent,
+		s.IP,
+		s.Created,
+		s.LastUsed,
+		s.Expires,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) GetSessionByID(dbName, id string) (s model.Session, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_sessions 
+		WHERE id = $1
+	`, dbName)
+
+	row := pg.DB.QueryRow(qry, id)
+
+	err = scanSession(row, &s)
+	return
+}
+
+func (pg *PostgreSQL) ListSessions(dbName, userID string) (results []model.Session, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_sessions
+		WHERE user_id = $1
+		ORDER BY last_used DESC
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, userID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s model.Session
+		if err = scanSession(rows, &s); err != nil {
+			return
+		}
+
+		results = append(results, s)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) RotateSession(dbName string, s model.Session, prevHash string) (bool, error) {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_sessions SET 
+			refresh_hash = $2,
+			jwt_id = $3,
+			user_agent = $4,
+			ip = $5,
+			last_used = $6,
+			expires = $7
+		WHERE id = $1 AND refresh_hash = $8
+	`, dbName)
+
+	res, err := pg.DB.Exec(
+		qry,
+		s.ID,
+		s.RefreshHash,
+		s.JWTID,
+		s.UserAgent,
+		s.IP,
+		s.LastUsed,
+		s.Expires,
+		prevHash,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (pg *PostgreSQL) DeleteSession(dbName, id string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s.sb_sessions WHERE id = $1`, dbName)
+
+	if _, err := pg.DB.Exec(qry, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) DeleteUserSessions(dbName, userID string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s.sb_sessions WHERE user_id = $1`, dbName)
+
+	if _, err := pg.DB.Exec(qry, userID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanSession(rows Scanner, s *model.Session) error {
+	return rows.Scan(
+		&s.ID,
+		&s.AccountID,
+		&s.UserID,
+		&s.RefreshHash,
+		&s.JWTID,
+		&s.UserAgent,
+		&s.IP,
+		&s.Created,
+		&s.LastUsed,
+		&s.Expires,
+	)
+}
@@ -0,0 +1,102 @@
+package postgresql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) AddSMSMessage(dbName string, msg model.SMSMessage) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_sms(account_id, provider, message_id, to_number, from_number, body, status, error, created, updated)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(
+		qry,
+		msg.AccountID,
+		msg.Provider,
+		msg.MessageID,
+		msg.ToNumber,
+		msg.FromNumber,
+		msg.Body,
+		msg.Status,
+		msg.Error,
+		msg.Created,
+		msg.Updated,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) UpdateSMSStatus(dbName, messageID, status, errMsg string) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_sms SET 
+			status = $2,
+			error = $3,
+			updated = $4
+		WHERE message_id = $1
+	`, dbName)
+
+	if _, err := pg.DB.Exec(qry, messageID, status, errMsg, time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) GetSMSMessageByID(dbName, id string) (msg model.SMSMessage, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_sms 
+		WHERE id = $1
+	`, dbName)
+
+	row := pg.DB.QueryRow(qry, id)
+
+	err = scanSMSMessage(row, &msg)
+	return
+}
+
+func (pg *PostgreSQL) ListSMSMessages(dbName string) (results []model.SMSMessage, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_sms
+		ORDER BY created DESC
+		LIMIT 100
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg model.SMSMessage
+		if err = scanSMSMessage(rows, &msg); err != nil {
+			return
+		}
+
+		results = append(results, msg)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func scanSMSMessage(rows Scanner, msg *model.SMSMessage) error {
+	return rows.Scan(
+		&msg.ID,
+		&msg.AccountID,
+		&msg.Provider,
+		&msg.MessageID,
+		&msg.ToNumber,
+		&msg.FromNumber,
+		&msg.Body,
+		&msg.Status,
+		&msg.Error,
+		&msg.Created,
+		&msg.Updated,
+	)
+}
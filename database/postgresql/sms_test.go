@@ -0,0 +1,47 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestSMSMessages(t *testing.T) {
+	msg := model.SMSMessage{
+		AccountID:  adminAccount.ID,
+		Provider:   "dev",
+		MessageID:  "msg-123",
+		ToNumber:   "+15555555555",
+		FromNumber: "+15555555556",
+		Body:       "unit test",
+		Status:     "queued",
+		Created:    time.Now(),
+		Updated:    time.Now(),
+	}
+
+	id, err := datastore.AddSMSMessage(confDBName, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := datastore.UpdateSMSStatus(confDBName, msg.MessageID, "delivered", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetSMSMessageByID(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.Status != "delivered" {
+		t.Errorf("expected status to be delivered got %s", check.Status)
+	} else if check.Body != msg.Body {
+		t.Errorf("expected body to be %s got %s", msg.Body, check.Body)
+	}
+
+	list, err := datastore.ListSMSMessages(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) == 0 {
+		t.Errorf("expected at least one message")
+	}
+}
@@ -0,0 +1,8 @@
+ALTER TABLE {schema}.sb_files
+ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE {schema}.sb_files
+DROP CONSTRAINT IF EXISTS sb_files_key_key;
+
+CREATE INDEX IF NOT EXISTS sb_files_key_idx ON {schema}.sb_files (key);
+CREATE INDEX IF NOT EXISTS sb_files_checksum_idx ON {schema}.sb_files (checksum);
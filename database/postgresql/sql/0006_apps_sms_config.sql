@@ -0,0 +1,2 @@
+ALTER TABLE sb.apps
+ADD COLUMN IF NOT EXISTS sms_config bytea;
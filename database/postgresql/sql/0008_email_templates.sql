@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS {schema}.sb_email_templates (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	name TEXT UNIQUE NOT NULL,
+	subject TEXT NOT NULL,
+	html_body TEXT NOT NULL,
+	text_body TEXT NOT NULL,
+	updated timestamp NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS {schema}.sb_email_logs (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	template TEXT NOT NULL,
+	from_email TEXT NOT NULL,
+	to_email TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL,
+	sent timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sb_email_logs_sent_idx ON {schema}.sb_email_logs (sent);
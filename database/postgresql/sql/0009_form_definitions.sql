@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS {schema}.sb_form_definitions (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	name TEXT UNIQUE NOT NULL,
+	fields JSONB NOT NULL,
+	redirect_url TEXT NOT NULL,
+	error_url TEXT NOT NULL,
+	notify_email TEXT NOT NULL,
+	email_template TEXT NOT NULL,
+	max_per_hour INTEGER NOT NULL,
+	updated timestamp NOT NULL
+);
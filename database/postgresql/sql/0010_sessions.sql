@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {schema}.sb_sessions (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+	user_id uuid REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+	refresh_hash TEXT NOT NULL,
+	jwt_id TEXT NOT NULL,
+	user_agent TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	created timestamp NOT NULL,
+	last_used timestamp NOT NULL,
+	expires timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sb_sessions_userid_idx ON {schema}.sb_sessions (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE sb.apps
+ADD COLUMN IF NOT EXISTS mfa_role INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS {schema}.sb_mfa (
+	user_id uuid PRIMARY KEY REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+	secret bytea NOT NULL,
+	enabled BOOLEAN NOT NULL,
+	recovery_codes JSONB NOT NULL,
+	last_step BIGINT NOT NULL,
+	updated timestamp NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE {schema}.sb_tokens
+ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS {schema}.sb_apikeys (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+	user_id uuid REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	key_hash TEXT UNIQUE NOT NULL,
+	scopes JSONB NOT NULL,
+	allowed_ips JSONB NOT NULL,
+	expires timestamp NOT NULL,
+	last_used timestamp NOT NULL,
+	last_ip TEXT NOT NULL,
+	created timestamp NOT NULL
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS {schema}.sb_identities (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+	user_id uuid REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+	provider TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	email TEXT NOT NULL,
+	created timestamp NOT NULL,
+	UNIQUE(provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS sb_identities_userid_idx ON {schema}.sb_identities (user_id);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS {schema}.sb_roles (
+	name TEXT PRIMARY KEY,
+	description TEXT NOT NULL,
+	permissions JSONB NOT NULL,
+	updated timestamp NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS {schema}.sb_groups (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	roles JSONB NOT NULL,
+	created timestamp NOT NULL,
+	UNIQUE(account_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS {schema}.sb_group_members (
+	group_id uuid REFERENCES {schema}.sb_groups(id) ON DELETE CASCADE,
+	user_id uuid REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+	PRIMARY KEY(group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS sb_group_members_userid_idx ON {schema}.sb_group_members (user_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {schema}.sb_invitations (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	account_id uuid REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+	invited_by uuid NOT NULL,
+	email TEXT NOT NULL,
+	role INTEGER NOT NULL,
+	link TEXT NOT NULL,
+	nonce TEXT NOT NULL,
+	expires timestamp NOT NULL,
+	created timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sb_invitations_acctid_idx ON {schema}.sb_invitations (account_id);
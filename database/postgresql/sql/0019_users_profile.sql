@@ -0,0 +1,7 @@
+ALTER TABLE {schema}.sb_tokens
+ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS avatar_id TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS profile JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE {schema}.sb_files
+ADD COLUMN IF NOT EXISTS user_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+ALTER TABLE {schema}.sb_tasks
+ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS catch_up TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS {schema}.sb_task_runs (
+	id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+	task_id uuid REFERENCES {schema}.sb_tasks(id) ON DELETE CASCADE,
+	triggered_by TEXT NOT NULL,
+	status TEXT NOT NULL,
+	started timestamp NOT NULL,
+	completed timestamp NOT NULL,
+	output TEXT NOT NULL,
+	error TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sb_task_runs_taskid_idx ON {schema}.sb_task_runs (task_id, started);
@@ -1,6 +1,8 @@
 package postgresql
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/staticbackendhq/core/model"
@@ -8,8 +10,8 @@ import (
 
 func (pg *PostgreSQL) AddFile(dbName string, f model.File) (id string, err error) {
 	qry := fmt.Sprintf(`
-		INSERT INTO %s.sb_files(account_id, key, url, size, uploaded)
-		VALUES($1, $2, $3, $4, $5)
+		INSERT INTO %s.sb_files(account_id, key, url, size, uploaded, checksum, user_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id;
 	`, dbName)
 
@@ -20,6 +22,8 @@ func (pg *PostgreSQL) AddFile(dbName string, f model.File) (id string, err error
 		f.URL,
 		f.Size,
 		f.Uploaded,
+		f.Checksum,
+		f.UserID,
 	).Scan(&id)
 	return
 }
@@ -86,6 +90,34 @@ func (pg *PostgreSQL) ListAllFiles(dbName, accountID string) (results []model.Fi
 	return
 }
 
+func (pg *PostgreSQL) GetFileByChecksum(dbName, checksum string) (f model.File, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.sb_files 
+		WHERE checksum = $1
+		LIMIT 1
+	`, dbName)
+
+	row := pg.DB.QueryRow(qry, checksum)
+
+	err = scanFile(row, &f)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.File{}, nil
+	}
+	return
+}
+
+func (pg *PostgreSQL) CountFileReferences(dbName, key string) (count int64, err error) {
+	qry := fmt.Sprintf(`
+		SELECT COUNT(*) 
+		FROM %s.sb_files 
+		WHERE key = $1
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry, key).Scan(&count)
+	return
+}
+
 func scanFile(rows Scanner, f *model.File) error {
 	return rows.Scan(
 		&f.ID,
@@ -94,5 +126,7 @@ func scanFile(rows Scanner, f *model.File) error {
 		&f.URL,
 		&f.Size,
 		&f.Uploaded,
+		&f.Checksum,
+		&f.UserID,
 	)
 }
@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+// matchEmail matches form submissions having a top-level field holding
+// the email
+const matchEmail = `EXISTS (
+	SELECT 1 FROM jsonb_each_text(data) AS f 
+	WHERE lower(f.value) = lower($1)
+)`
+
+func (pg *PostgreSQL) ListDocumentsByOwner(dbName, col, ownerID string) (docs []map[string]interface{}, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s.%s 
+		WHERE owner_id = $1
+		ORDER BY created
+	`, dbName, model.CleanCollectionName(col))
+
+	rows, err := pg.DB.Query(qry, ownerID)
+	if err != nil {
+		if !isTableExists(err) {
+			return nil, nil
+		}
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var doc Document
+		if err = scanDocument(rows, &doc); err != nil {
+			return
+		}
+
+		doc.Data[FieldID] = doc.ID
+		doc.Data[FieldAccountID] = doc.AccountID
+
+		docs = append(docs, doc.Data)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) DeleteDocumentsByOwner(dbName, col, ownerID string) (int64, error) {
+	qry := fmt.Sprintf(`
+		DELETE 
+		FROM %s.%s 
+		WHERE owner_id = $1
+	`, dbName, model.CleanCollectionName(col))
+
+	res, err := pg.DB.Exec(qry, ownerID)
+	if err != nil {
+		if !isTableExists(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (pg *PostgreSQL) ListFormSubmissionsByEmail(dbName, email string) (results []map[string]interface{}, err error) {
+	qry := fmt.Sprintf(`
+		SELECT name, data 
+		FROM %s.sb_forms 
+		WHERE %s
+		ORDER BY created
+	`, dbName, matchEmail)
+
+	rows, err := pg.DB.Query(qry, email)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var data JSONB
+		if err = rows.Scan(&name, &data); err != nil {
+			return
+		}
+
+		data[FieldFormName] = name
+		results = append(results, data)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) DeleteFormSubmissionsByEmail(dbName, email string) (int64, error) {
+	qry := fmt.Sprintf(`
+		DELETE 
+		FROM %s.sb_forms 
+		WHERE %s
+	`, dbName, matchEmail)
+
+	res, err := pg.DB.Exec(qry, email)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
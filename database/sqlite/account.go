@@ -129,8 +129,22 @@ func (sl *SQLite) GetUserByID(dbName, accountID, userID string) (user model.User
 	return
 }
 
+func (sl *SQLite) FindUserByID(dbName, userID string) (user model.User, err error) {
+	qry := fmt.Sprintf(`
+	SELECT * 
+	FROM %s_sb_tokens
+	WHERE id = $1;
+`, dbName)
+
+	row := sl.DB.QueryRow(qry, userID)
+
+	err = scanToken(row, &user)
+	return
+}
+
 func scanToken(rows Scanner, tok *model.User) error {
-	return rows.Scan(
+	var profile JSON
+	err := rows.Scan(
 		&tok.ID,
 		&tok.AccountID,
 		&tok.Token,
@@ -139,7 +153,13 @@ func scanToken(rows Scanner, tok *model.User) error {
 		&tok.Role,
 		&tok.ResetCode,
 		&tok.Created,
+		&tok.Verified,
+		&tok.DisplayName,
+		&tok.AvatarID,
+		&profile,
 	)
+	tok.Profile = profile
+	return err
 }
 
 func scanAccount(rows Scanner, a *model.Account) error {
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateAPIKey(dbName string, k model.APIKey) (id string, err error) {
+	scopes, err := json.Marshal(k.Scopes)
+	if err != nil {
+		return
+	}
+
+	ips, err := json.Marshal(k.AllowedIPs)
+	if err != nil {
+		return
+	}
+
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_apikeys(id, account_id, user_id, name, prefix, key_hash, scopes, allowed_ips, expires, last_used, last_ip, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, dbName)
+
+	_, err = sl.DB.Exec(
+		qry,
+		id,
+		k.AccountID,
+		k.UserID,
+		k.Name,
+		k.Prefix,
+		k.KeyHash,
+		string(scopes),
+		string(ips),
+		k.Expires,
+		k.LastUsed,
+		k.LastIP,
+		k.Created,
+	)
+	return
+}
+
+func (sl *SQLite) GetAPIKeyByHash(dbName, hash string) (k model.APIKey, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_apikeys 
+		WHERE key_hash = $1
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, hash)
+
+	err = scanAPIKey(row, &k)
+	return
+}
+
+func (sl *SQLite) ListAPIKeys(dbName string) (results []model.APIKey, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_apikeys
+		ORDER BY created DESC
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k model.APIKey
+		if err = scanAPIKey(rows, &k); err != nil {
+			return
+		}
+
+		results = append(results, k)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) TouchAPIKey(dbName, id string, lastUsed time.Time, ip string) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_apikeys SET 
+			last_used = $2,
+			last_ip = $3
+		WHERE id = $1
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, id, lastUsed, ip); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) DeleteAPIKey(dbName, id string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_apikeys WHERE id = $1`, dbName)
+
+	if _, err := sl.DB.Exec(qry, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanAPIKey(rows Scanner, k *model.APIKey) error {
+	var scopes, ips string
+	err := rows.Scan(
+		&k.ID,
+		&k.AccountID,
+		&k.UserID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		&scopes,
+		&ips,
+		&k.Expires,
+		&k.LastUsed,
+		&k.LastIP,
+		&k.Created,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &k.Scopes); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(ips), &k.AllowedIPs)
+}
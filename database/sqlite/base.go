@@ -50,38 +50,8 @@ func (j *JSON) Scan(value interface{}) error {
 func (sl *SQLite) CreateDocument(auth model.Auth, dbName, col string, doc map[string]interface{}) (inserted map[string]interface{}, err error) {
 	inserted = doc
 
-	cleancol := model.CleanCollectionName(col)
-
-	//TODO: find a good way to prevent doing the create
-	// table if not exists each time
-
-	// for SQLite, this seems to cause issue with tests
-	// so I'm using a map to hold if the collection was already
-	// created
-
-	m := &sync.RWMutex{}
-	m.Lock()
-	defer m.Unlock()
-
-	if _, ok := sl.collections[col]; !ok {
-		qry := fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s_%s (
-			id TEXT PRIMARY KEY,
-			account_id TEXT REFERENCES %s_sb_accounts(id) ON DELETE CASCADE,
-			owner_id TEXT REFERENCES %s_sb_tokens(id) ON DELETE CASCADE,
-			data JSON NOT NULL,
-			created timestamp NOT NULL
-		);
-
-		CREATE INDEX IF NOT EXISTS %s_%s_acctid_idx ON %s_%s (account_id);			
-	`, dbName, cleancol, dbName, dbName, dbName, cleancol, dbName, cleancol)
-
-		if _, err = sl.DB.Exec(qry); err != nil {
-			err = fmt.Errorf("error creating table: %w", err)
-			return
-		}
-
-		sl.collections[col] = true
+	if err = sl.createCollection(dbName, col); err != nil {
+		return
 	}
 
 	id := sl.NewID()
@@ -113,6 +83,46 @@ func (sl *SQLite) CreateDocument(auth model.Auth, dbName, col string, doc map[st
 	return
 }
 
+func (sl *SQLite) createCollection(dbName, col string) error {
+	cleancol := model.CleanCollectionName(col)
+
+	//TODO: find a good way to prevent doing the create
+	// table if not exists each time
+
+	// for SQLite, this seems to cause issue with tests
+	// so I'm using a map to hold if the collection was already
+	// created
+
+	m := &sync.RWMutex{}
+	m.Lock()
+	defer m.Unlock()
+
+	// the same collection name can exist in multiple databases
+	key := dbName + "_" + cleancol
+	if _, ok := sl.collections[key]; ok {
+		return nil
+	}
+
+	qry := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s_%s (
+			id TEXT PRIMARY KEY,
+			account_id TEXT REFERENCES %s_sb_accounts(id) ON DELETE CASCADE,
+			owner_id TEXT REFERENCES %s_sb_tokens(id) ON DELETE CASCADE,
+			data JSON NOT NULL,
+			created timestamp NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS %s_%s_acctid_idx ON %s_%s (account_id);			
+	`, dbName, cleancol, dbName, dbName, dbName, cleancol, dbName, cleancol)
+
+	if _, err := sl.DB.Exec(qry); err != nil {
+		return fmt.Errorf("error creating table: %w", err)
+	}
+
+	sl.collections[key] = true
+	return nil
+}
+
 func (sl *SQLite) BulkCreateDocument(auth model.Auth, dbName, col string, docs []interface{}) error {
 	//TODO: Naive implementation, not sure if SQLite
 	// has a better way for bulk insert, but will suffice for now.
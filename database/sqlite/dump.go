@@ -0,0 +1,257 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) DumpLayout() string {
+	return database.DumpLayoutSQL
+}
+
+func (sl *SQLite) DumpTables(dbName string) ([]string, error) {
+	return sl.ListCollections(dbName)
+}
+
+func (sl *SQLite) DumpRows(dbName string, tables []string, fn func(table string, row map[string]any) error) error {
+	// a read transaction sees the same snapshot for all tables
+	tx, err := sl.DB.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tableName := func(table string) string {
+		return fmt.Sprintf("%s_%s", dbName, table)
+	}
+	return database.DumpSQLTables(tx, tableName, tables, fn)
+}
+
+func (sl *SQLite) RestoreTenant(cus model.Tenant, base model.DatabaseConfig) error {
+	_, err := sl.DB.Exec(`
+	INSERT INTO sb_customers(id, email, stripe_id, sub_id, plan, is_active, created, external_logins)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (id) DO NOTHING;
+	`, cus.ID, cus.Email,
+		cus.StripeID,
+		cus.SubscriptionID,
+		cus.Plan,
+		cus.IsActive,
+		cus.Created,
+		cus.ExternalLogins,
+	)
+	if err != nil {
+		return err
+	}
+
+	policy, err := json.Marshal(base.AuthPolicy)
+	if err != nil {
+		return err
+	}
+
+	_, err = sl.DB.Exec(`
+	INSERT INTO sb_apps(id, customer_id, name, allowed_domain, is_active, monthly_email_sent, created, sms_config, mfa_role, auth_policy)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+	`, base.ID, base.TenantID,
+		base.Name,
+		strings.Join(base.AllowedDomain, "|"),
+		base.IsActive,
+		base.MonthlySentEmail,
+		base.Created,
+		base.SMSConfig,
+		base.MFARole,
+		string(policy),
+	)
+	if err != nil {
+		return err
+	}
+
+	return sl.createSystemTables(base.Name)
+}
+
+func (sl *SQLite) RestoreRows(dbName, table string, rows []map[string]any) error {
+	if !strings.HasPrefix(table, "sb_") {
+		if err := sl.createCollection(dbName, table); err != nil {
+			return err
+		}
+	}
+	return database.RestoreSQLRows(sl.DB, fmt.Sprintf("%s_%s", dbName, table), rows)
+}
+
+func (sl *SQLite) DumpDocuments(dbName string, cols []string, fn func(col string, doc model.PortableDocument) error) error {
+	// a read transaction sees the same snapshot for all collections
+	tx, err := sl.DB.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, col := range cols {
+		qry := fmt.Sprintf(`SELECT * FROM %s_%s ORDER BY id`, dbName, model.CleanCollectionName(col))
+
+		if err := dumpDocuments(tx, qry, func(doc model.PortableDocument) error {
+			return fn(col, doc)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dumpDocuments(tx *sql.Tx, qry string, fn func(doc model.PortableDocument) error) error {
+	rows, err := tx.Query(qry)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var doc Document
+		if err := scanDocument(rows, &doc); err != nil {
+			return err
+		}
+
+		if err := fn(toPortableDocument(doc)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (sl *SQLite) RestoreDocuments(dbName, col string, docs []model.PortableDocument) error {
+	if err := sl.createCollection(dbName, col); err != nil {
+		return err
+	}
+
+	tx, err := sl.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_%s(id, account_id, owner_id, data, created)
+		VALUES($1, $2, $3, $4, $5)
+	`, dbName, model.CleanCollectionName(col))
+
+	for _, doc := range docs {
+		if _, err := tx.Exec(qry, doc.ID, doc.AccountID, doc.OwnerID, JSON(doc.Data), doc.Created); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (sl *SQLite) RestoreAccount(dbName string, a model.Account) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_accounts(id, email, created)
+		VALUES($1, $2, $3);
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, a.ID, a.Email, a.Created)
+	return err
+}
+
+func (sl *SQLite) RestoreUser(dbName string, tok model.User) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_tokens(id, account_id, email, password, token, role, reset_code, created, verified, display_name, avatar_id, profile)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+	`, dbName)
+
+	_, err := sl.DB.Exec(
+		qry,
+		tok.ID,
+		tok.AccountID,
+		tok.Email,
+		tok.Password,
+		tok.Token,
+		tok.Role,
+		tok.ResetCode,
+		tok.Created,
+		tok.Verified,
+		tok.DisplayName,
+		tok.AvatarID,
+		JSON(tok.Profile),
+	)
+	return err
+}
+
+func (sl *SQLite) RestoreFile(dbName string, f model.File) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_files(id, account_id, key, url, size, uploaded, checksum, user_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8);
+	`, dbName)
+
+	_, err := sl.DB.Exec(
+		qry,
+		f.ID,
+		f.AccountID,
+		f.Key,
+		f.URL,
+		f.Size,
+		f.Uploaded,
+		f.Checksum,
+		f.UserID,
+	)
+	return err
+}
+
+// toPortableDocument removes the system fields added to the data when read
+func toPortableDocument(doc Document) model.PortableDocument {
+	data := make(map[string]any, len(doc.Data))
+	for k, v := range doc.Data {
+		if k == FieldID || k == FieldAccountID {
+			continue
+		}
+		data[k] = v
+	}
+
+	return model.PortableDocument{
+		ID:        doc.ID,
+		AccountID: doc.AccountID,
+		OwnerID:   doc.OwnerID,
+		Created:   doc.Created,
+		Data:      data,
+	}
+}
+
+func (sl *SQLite) DeleteDatabase(dbName string) error {
+	tables, err := sl.ListCollections(dbName)
+	if err != nil {
+		return err
+	}
+
+	// the collections and the tables referencing the accounts and tokens
+	// are dropped first
+	sort.SliceStable(tables, func(i, j int) bool {
+		return dropOrder(tables[i]) < dropOrder(tables[j])
+	})
+
+	for _, table := range tables {
+		if _, err := sl.DB.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s_%s", dbName, table)); err != nil {
+			return err
+		}
+
+		delete(sl.collections, dbName+"_"+table)
+	}
+
+	_, err = sl.DB.Exec(`DELETE FROM sb_apps WHERE name = $1`, dbName)
+	return err
+}
+
+func dropOrder(table string) int {
+	switch table {
+	case "sb_accounts":
+		return 2
+	case "sb_tokens":
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) SaveEmailTemplate(dbName string, tmpl model.EmailTemplate) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_email_templates(id, name, subject, html_body, text_body, updated)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT(name) DO UPDATE SET 
+			subject = excluded.subject,
+			html_body = excluded.html_body,
+			text_body = excluded.text_body,
+			updated = excluded.updated;
+	`, dbName)
+
+	_, err := sl.DB.Exec(
+		qry,
+		sl.NewID(),
+		tmpl.Name,
+		tmpl.Subject,
+		tmpl.HTMLBody,
+		tmpl.TextBody,
+		time.Now(),
+	)
+	return err
+}
+
+func (sl *SQLite) GetEmailTemplate(dbName, name string) (tmpl model.EmailTemplate, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_email_templates 
+		WHERE name = $1
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, name)
+
+	err = scanEmailTemplate(row, &tmpl)
+	return
+}
+
+func (sl *SQLite) ListEmailTemplates(dbName string) (results []model.EmailTemplate, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_email_templates
+		ORDER BY name
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tmpl model.EmailTemplate
+		if err = scanEmailTemplate(rows, &tmpl); err != nil {
+			return
+		}
+
+		results = append(results, tmpl)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) DeleteEmailTemplate(dbName, name string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_email_templates WHERE name = $1`, dbName)
+
+	if _, err := sl.DB.Exec(qry, name); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) AddEmailLog(dbName string, entry model.EmailLog) (id string, err error) {
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_email_logs(id, template, from_email, to_email, subject, status, error, sent)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8);
+	`, dbName)
+
+	_, err = sl.DB.Exec(
+		qry,
+		id,
+		entry.Template,
+		entry.From,
+		entry.To,
+		entry.Subject,
+		entry.Status,
+		entry.Error,
+		entry.Sent,
+	)
+	return
+}
+
+func (sl *SQLite) ListEmailLogs(dbName string) (results []model.EmailLog, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_email_logs
+		ORDER BY sent DESC
+		LIMIT 100
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry model.EmailLog
+		if err = scanEmailLog(rows, &entry); err != nil {
+			return
+		}
+
+		results = append(results, entry)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func scanEmailTemplate(rows Scanner, tmpl *model.EmailTemplate) error {
+	return rows.Scan(
+		&tmpl.ID,
+		&tmpl.Name,
+		&tmpl.Subject,
+		&tmpl.HTMLBody,
+		&tmpl.TextBody,
+		&tmpl.Updated,
+	)
+}
+
+func scanEmailLog(rows Scanner, entry *model.EmailLog) error {
+	return rows.Scan(
+		&entry.ID,
+		&entry.Template,
+		&entry.From,
+		&entry.To,
+		&entry.Subject,
+		&entry.Status,
+		&entry.Error,
+		&entry.Sent,
+	)
+}
@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) SaveFormDefinition(dbName string, def model.FormDefinition) error {
+	fields, err := json.Marshal(def.Fields)
+	if err != nil {
+		return err
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_form_definitions(id, name, fields, redirect_url, error_url, notify_email, email_template, max_per_hour, updated)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT(name) DO UPDATE SET 
+			fields = excluded.fields,
+			redirect_url = excluded.redirect_url,
+			error_url = excluded.error_url,
+			notify_email = excluded.notify_email,
+			email_template = excluded.email_template,
+			max_per_hour = excluded.max_per_hour,
+			updated = excluded.updated;
+	`, dbName)
+
+	_, err = sl.DB.Exec(
+		qry,
+		sl.NewID(),
+		def.Name,
+		string(fields),
+		def.RedirectURL,
+		def.ErrorURL,
+		def.NotifyEmail,
+		def.EmailTemplate,
+		def.MaxPerHour,
+		time.Now(),
+	)
+	return err
+}
+
+func (sl *SQLite) GetFormDefinition(dbName, name string) (def model.FormDefinition, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_form_definitions 
+		WHERE name = $1
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, name)
+
+	err = scanFormDefinition(row, &def)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.FormDefinition{}, nil
+	}
+	return
+}
+
+func (sl *SQLite) ListFormDefinitions(dbName string) (results []model.FormDefinition, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_form_definitions
+		ORDER BY name
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var def model.FormDefinition
+		if err = scanFormDefinition(rows, &def); err != nil {
+			return
+		}
+
+		results = append(results, def)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) DeleteFormDefinition(dbName, name string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_form_definitions WHERE name = $1`, dbName)
+
+	if _, err := sl.DB.Exec(qry, name); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanFormDefinition(rows Scanner, def *model.FormDefinition) error {
+	var fields string
+	err := rows.Scan(
+		&def.ID,
+		&def.Name,
+		&fields,
+		&def.RedirectURL,
+		&def.ErrorURL,
+		&def.NotifyEmail,
+		&def.EmailTemplate,
+		&def.MaxPerHour,
+		&def.Updated,
+	)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(fields), &def.Fields)
+}
@@ -0,0 +1,268 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) SaveRole(dbName string, role model.Role) error {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return err
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_roles(name, description, permissions, updated)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT(name) DO UPDATE SET
+			description = excluded.description,
+			permissions = excluded.permissions,
+			updated = excluded.updated
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, role.Name, role.Description, string(permissions), role.Updated); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) ListRoles(dbName string) (results []model.Role, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_roles
+		ORDER BY name
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role model.Role
+		if err = scanRole(rows, &role); err != nil {
+			return
+		}
+
+		results = append(results, role)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) DeleteRole(dbName, name string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_roles WHERE name = $1`, dbName)
+
+	if _, err := sl.DB.Exec(qry, name); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) CreateGroup(dbName string, g model.Group) (id string, err error) {
+	roles, err := json.Marshal(g.Roles)
+	if err != nil {
+		return
+	}
+
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_groups(id, account_id, name, roles, created)
+		VALUES($1, $2, $3, $4, $5)
+	`, dbName)
+
+	_, err = sl.DB.Exec(qry, id, g.AccountID, g.Name, string(roles), g.Created)
+	return
+}
+
+func (sl *SQLite) GetGroup(dbName, accountID, id string) (g model.Group, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_groups 
+		WHERE account_id = $1 AND id = $2
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, accountID, id)
+	if err = scanGroup(row, &g); err != nil {
+		return
+	}
+
+	g.Members, err = sl.groupMembers(dbName, g.ID)
+	return
+}
+
+func (sl *SQLite) UpdateGroup(dbName string, g model.Group) error {
+	roles, err := json.Marshal(g.Roles)
+	if err != nil {
+		return err
+	}
+
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_groups SET 
+			name = $3,
+			roles = $4
+		WHERE account_id = $1 AND id = $2
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, g.AccountID, g.ID, g.Name, string(roles)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) ListGroups(dbName, accountID string) ([]model.Group, error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_groups
+		WHERE account_id = $1
+		ORDER BY name
+	`, dbName)
+
+	return sl.queryGroups(dbName, qry, accountID)
+}
+
+func (sl *SQLite) DeleteGroup(dbName, accountID, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_group_members 
+		WHERE group_id IN (SELECT id FROM %s_sb_groups WHERE account_id = $1 AND id = $2)
+	`, dbName, dbName)
+
+	if _, err := sl.DB.Exec(qry, accountID, id); err != nil {
+		return err
+	}
+
+	qry = fmt.Sprintf(`DELETE FROM %s_sb_groups WHERE account_id = $1 AND id = $2`, dbName)
+
+	if _, err := sl.DB.Exec(qry, accountID, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) AddGroupMember(dbName, groupID, userID string) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_group_members(group_id, user_id)
+		VALUES($1, $2)
+		ON CONFLICT DO NOTHING
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, groupID, userID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) RemoveGroupMember(dbName, groupID, userID string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_group_members WHERE group_id = $1 AND user_id = $2`, dbName)
+
+	if _, err := sl.DB.Exec(qry, groupID, userID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) ListUserGroups(dbName, userID string) ([]model.Group, error) {
+	qry := fmt.Sprintf(`
+		SELECT g.* 
+		FROM %s_sb_groups g
+		INNER JOIN %s_sb_group_members m ON m.group_id = g.id
+		WHERE m.user_id = $1
+		ORDER BY g.name
+	`, dbName, dbName)
+
+	return sl.queryGroups(dbName, qry, userID)
+}
+
+func (sl *SQLite) queryGroups(dbName, qry string, args ...any) ([]model.Group, error) {
+	rows, err := sl.DB.Query(qry, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.Group
+	for rows.Next() {
+		var g model.Group
+		if err := scanGroup(rows, &g); err != nil {
+			return nil, err
+		}
+
+		results = append(results, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, g := range results {
+		members, err := sl.groupMembers(dbName, g.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i].Members = members
+	}
+	return results, nil
+}
+
+func (sl *SQLite) groupMembers(dbName, groupID string) (members []string, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id 
+		FROM %s_sb_group_members
+		WHERE group_id = $1
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, groupID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		if err = rows.Scan(&userID); err != nil {
+			return
+		}
+
+		members = append(members, userID)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func scanRole(rows Scanner, role *model.Role) error {
+	var permissions string
+	err := rows.Scan(
+		&role.Name,
+		&role.Description,
+		&permissions,
+		&role.Updated,
+	)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(permissions), &role.Permissions)
+}
+
+func scanGroup(rows Scanner, g *model.Group) error {
+	var roles string
+	err := rows.Scan(
+		&g.ID,
+		&g.AccountID,
+		&g.Name,
+		&roles,
+		&g.Created,
+	)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(roles), &g.Roles)
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateIdentity(dbName string, idt model.Identity) (id string, err error) {
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_identities(id, account_id, user_id, provider, subject, email, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+	`, dbName)
+
+	_, err = sl.DB.Exec(
+		qry,
+		id,
+		idt.AccountID,
+		idt.UserID,
+		idt.Provider,
+		idt.Subject,
+		idt.Email,
+		idt.Created,
+	)
+	return
+}
+
+func (sl *SQLite) FindIdentity(dbName, provider, subject string) (idt model.Identity, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_identities 
+		WHERE provider = $1 AND subject = $2
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, provider, subject)
+
+	err = scanIdentity(row, &idt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Identity{}, nil
+	}
+	return
+}
+
+func (sl *SQLite) ListIdentities(dbName, userID string) (results []model.Identity, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_identities
+		WHERE user_id = $1
+		ORDER BY created
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, userID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var idt model.Identity
+		if err = scanIdentity(rows, &idt); err != nil {
+			return
+		}
+
+		results = append(results, idt)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) DeleteIdentity(dbName, userID, id string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_identities WHERE user_id = $1 AND id = $2`, dbName)
+
+	if _, err := sl.DB.Exec(qry, userID, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanIdentity(rows Scanner, idt *model.Identity) error {
+	return rows.Scan(
+		&idt.ID,
+		&idt.AccountID,
+		&idt.UserID,
+		&idt.Provider,
+		&idt.Subject,
+		&idt.Email,
+		&idt.Created,
+	)
+}
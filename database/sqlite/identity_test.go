@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestIdentities(t *testing.T) {
+	idt := model.Identity{
+		AccountID: adminToken.AccountID,
+		UserID:    adminToken.ID,
+		Provider:  "keycloak",
+		Subject:   "subject-1",
+		Email:     adminToken.Email,
+		Created:   time.Now(),
+	}
+
+	id, err := datastore.CreateIdentity(confDBName, idt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.CreateIdentity(confDBName, idt); err == nil {
+		t.Error("expected an error linking the same subject twice")
+	}
+
+	idt.Provider = "github"
+	if _, err := datastore.CreateIdentity(confDBName, idt); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.FindIdentity(confDBName, "keycloak", "subject-1")
+	if err != nil {
+		t.Fatal(err)
+	} else if check.ID != id || check.UserID != adminToken.ID {
+		t.Errorf("expected identity %s got %v", id, check)
+	}
+
+	missing, err := datastore.FindIdentity(confDBName, "keycloak", "unknown")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(missing.ID) > 0 {
+		t.Errorf("expected no identity got %v", missing)
+	}
+
+	list, err := datastore.ListIdentities(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 2 {
+		t.Fatalf("expected 2 identities got %d", len(list))
+	}
+
+	if err := datastore.DeleteIdentity(confDBName, adminToken.ID, id); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = datastore.ListIdentities(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 || list[0].Provider != "github" {
+		t.Errorf("expected the github identity to remain got %v", list)
+	}
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateInvitation(dbName string, inv model.Invitation) (id string, err error) {
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_invitations(id, account_id, invited_by, email, role, link, nonce, expires, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, dbName)
+
+	_, err = sl.DB.Exec(
+		qry,
+		id,
+		inv.AccountID,
+		inv.InvitedBy,
+		inv.Email,
+		inv.Role,
+		inv.Link,
+		inv.Nonce,
+		inv.Expires,
+		inv.Created,
+	)
+	return
+}
+
+func (sl *SQLite) GetInvitation(dbName, id string) (inv model.Invitation, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_invitations 
+		WHERE id = $1
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, id)
+
+	err = scanInvitation(row, &inv)
+	return
+}
+
+func (sl *SQLite) ListInvitations(dbName, accountID string) (results []model.Invitation, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_invitations
+		WHERE account_id = $1
+		ORDER BY created DESC
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, accountID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var inv model.Invitation
+		if err = scanInvitation(rows, &inv); err != nil {
+			return
+		}
+
+		results = append(results, inv)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) RenewInvitation(dbName, id, nonce string, expires time.Time) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_invitations SET 
+			nonce = $2,
+			expires = $3
+		WHERE id = $1
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, id, nonce, expires); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) DeleteInvitation(dbName, id string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_invitations WHERE id = $1`, dbName)
+
+	if _, err := sl.DB.Exec(qry, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanInvitation(rows Scanner, inv *model.Invitation) error {
+	return rows.Scan(
+		&inv.ID,
+		&inv.AccountID,
+		&inv.InvitedBy,
+		&inv.Email,
+		&inv.Role,
+		&inv.Link,
+		&inv.Nonce,
+		&inv.Expires,
+		&inv.Created,
+	)
+}
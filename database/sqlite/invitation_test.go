@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestInvitations(t *testing.T) {
+	inv := model.Invitation{
+		AccountID: adminToken.AccountID,
+		InvitedBy: adminToken.ID,
+		Email:     "invited@test.com",
+		Role:      50,
+		Link:      "https://app.test/invite",
+		Nonce:     "nonce-1",
+		Expires:   time.Now().Add(time.Hour),
+		Created:   time.Now(),
+	}
+
+	id, err := datastore.CreateInvitation(confDBName, inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetInvitation(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.Email != inv.Email || check.Role != 50 || check.Nonce != "nonce-1" {
+		t.Errorf("expected the invitation got %v", check)
+	}
+
+	expires := time.Now().Add(2 * time.Hour)
+	if err := datastore.RenewInvitation(confDBName, id, "nonce-2", expires); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := datastore.ListInvitations(confDBName, adminToken.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 || list[0].Nonce != "nonce-2" || !list[0].Expires.After(inv.Expires) {
+		t.Fatalf("expected the renewed invitation got %v", list)
+	}
+
+	if err := datastore.DeleteInvitation(confDBName, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.GetInvitation(confDBName, id); err == nil {
+		t.Error("expected an error getting a deleted invitation")
+	}
+}
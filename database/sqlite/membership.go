@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -25,8 +26,8 @@ func (sl *SQLite) CreateUser(dbName string, tok model.User) (id string, err erro
 	id = sl.NewID()
 
 	qry := fmt.Sprintf(`
-		INSERT INTO %s_sb_tokens(id, account_id, email, password, token, role, reset_code, created)
-		VALUES($1, $2, $3, $4, $5, $6, $7, $8);
+		INSERT INTO %s_sb_tokens(id, account_id, email, password, token, role, reset_code, created, verified, display_name, avatar_id, profile)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
 	`, dbName)
 
 	_, err = sl.DB.Exec(
@@ -39,6 +40,10 @@ func (sl *SQLite) CreateUser(dbName string, tok model.User) (id string, err erro
 		tok.Role,
 		tok.ResetCode,
 		tok.Created,
+		tok.Verified,
+		tok.DisplayName,
+		tok.AvatarID,
+		JSON(tok.Profile),
 	)
 	return
 }
@@ -81,6 +86,33 @@ func (sl *SQLite) UserSetPassword(dbName, tokenID, password string) error {
 	return nil
 }
 
+func (sl *SQLite) SetUserVerified(dbName, userID string, verified bool) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_tokens SET verified = $2
+		WHERE id = $1;
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, userID, verified); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) UpdateUserProfile(dbName, userID string, p model.UserProfile) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_tokens SET
+			display_name = $2,
+			avatar_id = $3,
+			profile = $4
+		WHERE id = $1;
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, userID, p.DisplayName, p.AvatarID, JSON(p.Profile)); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (sl *SQLite) GetFirstUserFromAccountID(dbName, accountID string) (tok model.User, err error) {
 	qry := fmt.Sprintf(`
 		SELECT * 
@@ -113,12 +145,21 @@ func (sl *SQLite) SetPasswordResetCode(dbName, userID, code string) error {
 func (sl *SQLite) ResetPassword(dbName, email, code, password string) error {
 	qry := fmt.Sprintf(`
 		UPDATE %s_sb_tokens SET
-			password = $3
-		WHERE email = $1 AND reset_code = $2
+			password = $3,
+			reset_code = ''
+		WHERE email = $1 AND reset_code = $2 AND reset_code <> ''
 	`, dbName)
 
-	if _, err := sl.DB.Exec(qry, email, code, password); err != nil {
+	res, err := sl.DB.Exec(qry, email, code, password)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
 		return err
+	} else if n == 0 {
+		return errors.New("invalid code")
 	}
 	return nil
 }
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) SaveMFA(dbName string, m model.MFA) error {
+	codes, err := json.Marshal(m.RecoveryCodes)
+	if err != nil {
+		return err
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_mfa(user_id, secret, enabled, recovery_codes, last_step, updated)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT(user_id) DO UPDATE SET 
+			secret = excluded.secret,
+			enabled = excluded.enabled,
+			recovery_codes = excluded.recovery_codes,
+			last_step = excluded.last_step,
+			updated = excluded.updated;
+	`, dbName)
+
+	_, err = sl.DB.Exec(
+		qry,
+		m.UserID,
+		m.Secret,
+		m.Enabled,
+		string(codes),
+		m.LastStep,
+		time.Now(),
+	)
+	return err
+}
+
+func (sl *SQLite) GetMFA(dbName, userID string) (m model.MFA, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_mfa 
+		WHERE user_id = $1
+	`, dbName)
+
+	var codes string
+	err = sl.DB.QueryRow(qry, userID).Scan(
+		&m.UserID,
+		&m.Secret,
+		&m.Enabled,
+		&codes,
+		&m.LastStep,
+		&m.Updated,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.MFA{}, nil
+	} else if err != nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(codes), &m.RecoveryCodes)
+	return
+}
+
+func (sl *SQLite) DeleteMFA(dbName, userID string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_mfa WHERE user_id = $1`, dbName)
+
+	if _, err := sl.DB.Exec(qry, userID); err != nil {
+		return err
+	}
+	return nil
+}
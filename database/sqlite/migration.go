@@ -157,11 +157,16 @@ func up(db *sql.DB, prefix string, version int) error {
 		return err
 	}
 
-	if _, err := tx.Exec(string(b)); err != nil {
+	qry, err := expandSchemas(tx, string(b))
+	if err != nil {
 		return err
 	}
 
-	qry := `
+	if _, err := tx.Exec(qry); err != nil {
+		return err
+	}
+
+	qry = `
 		INSERT INTO sb_migrations(id, version, files)
 		VALUES($1, $2, $3);
 	`
@@ -172,3 +177,39 @@ func up(db *sql.DB, prefix string, version int) error {
 
 	return tx.Commit()
 }
+
+// expandSchemas repeats a migration targeting the tenants' system tables
+// for every database. Those migrations use the {schema} placeholder like
+// createSystemTables does.
+func expandSchemas(tx *sql.Tx, qry string) (string, error) {
+	if !strings.Contains(qry, "{schema}") {
+		return qry, nil
+	}
+
+	rows, err := tx.Query(`SELECT name FROM sb_apps`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+
+		sb.WriteString(strings.Replace(qry, "{schema}", name, -1))
+		sb.WriteString("\n")
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if sb.Len() == 0 {
+		// no database yet, new ones are created with the latest tables
+		return "SELECT 1;", nil
+	}
+	return sb.String(), nil
+}
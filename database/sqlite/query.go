@@ -80,7 +80,7 @@ func secureRead(auth model.Auth, col string) string {
 		return "WHERE $1=$1 AND $2=$2 "
 	}
 
-	switch internal.ReadPermission(col) {
+	switch auth.ReadPermission(col) {
 	case internal.PermGroup:
 		return "WHERE account_id = $1 AND $2=$2 "
 	case internal.PermOwner:
@@ -96,7 +96,7 @@ func secureWrite(auth model.Auth, col string) string {
 		return "WHERE $1=$1 AND $2=$2 "
 	}
 
-	switch internal.WritePermission(col) {
+	switch auth.WritePermission(col) {
 	case internal.PermGroup:
 		return "WHERE account_id = $1 AND $2=$2 "
 	case internal.PermOwner:
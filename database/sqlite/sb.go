@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -66,7 +67,11 @@ func (sl *SQLite) createSystemTables(schema string) error {
 			password TEXT NOT NULL,
 			role INTEGER NOT NULL,
 			reset_code TEXT NOT NULL,
-			created timestamp NOT NULL			
+			created timestamp NOT NULL,
+			verified BOOLEAN NOT NULL DEFAULT TRUE,
+			display_name TEXT NOT NULL DEFAULT '',
+			avatar_id TEXT NOT NULL DEFAULT '',
+			profile JSON NOT NULL DEFAULT '{}'
 		);
 
 		CREATE TABLE IF NOT EXISTS {schema}_sb_forms (
@@ -80,12 +85,16 @@ func (sl *SQLite) createSystemTables(schema string) error {
 		CREATE TABLE IF NOT EXISTS {schema}_sb_files (
 			id TEXT PRIMARY KEY,
 			account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
-			key TEXT UNIQUE NOT NULL,
+			key TEXT NOT NULL,
 			url TEXT NOT NULL,
 			size INTEGER NOT NULL,			
-			uploaded timestamp NOT NULL			
+			uploaded timestamp NOT NULL,
+			checksum TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL DEFAULT ''
 		);
 		CREATE INDEX IF NOT EXISTS {schema}_sb_files_acctid_idx ON {schema}_sb_files (account_id);
+		CREATE INDEX IF NOT EXISTS {schema}_sb_files_key_idx ON {schema}_sb_files (key);
+		CREATE INDEX IF NOT EXISTS {schema}_sb_files_checksum_idx ON {schema}_sb_files (checksum);
 
 		CREATE TABLE IF NOT EXISTS {schema}_sb_functions (
 			id TEXT PRIMARY KEY,
@@ -115,8 +124,160 @@ func (sl *SQLite) createSystemTables(schema string) error {
 			value TEXT NOT NULL,
 			meta TEXT NOT NULL,
 			interval TEXT NOT NULL,
-			last_run timestamp NOT NULL
+			last_run timestamp NOT NULL,
+			timezone TEXT NOT NULL DEFAULT '',
+			paused BOOLEAN NOT NULL DEFAULT FALSE,
+			catch_up TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_task_runs (
+			id TEXT PRIMARY KEY,
+			task_id TEXT REFERENCES {schema}_sb_tasks(id) ON DELETE CASCADE,
+			triggered_by TEXT NOT NULL,
+			status TEXT NOT NULL,
+			started timestamp NOT NULL,
+			completed timestamp NOT NULL,
+			output TEXT NOT NULL,
+			error TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS {schema}_sb_task_runs_taskid_idx ON {schema}_sb_task_runs (task_id, started);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_sms (
+			id TEXT PRIMARY KEY,
+			account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			provider TEXT NOT NULL,
+			message_id TEXT NOT NULL,
+			to_number TEXT NOT NULL,
+			from_number TEXT NOT NULL,
+			body TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL,
+			created timestamp NOT NULL,
+			updated timestamp NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS {schema}_sb_sms_message_id_idx ON {schema}_sb_sms (message_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_email_templates (
+			id TEXT PRIMARY KEY,
+			name TEXT UNIQUE NOT NULL,
+			subject TEXT NOT NULL,
+			html_body TEXT NOT NULL,
+			text_body TEXT NOT NULL,
+			updated timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_email_logs (
+			id TEXT PRIMARY KEY,
+			template TEXT NOT NULL,
+			from_email TEXT NOT NULL,
+			to_email TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL,
+			sent timestamp NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS {schema}_sb_email_logs_sent_idx ON {schema}_sb_email_logs (sent);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_form_definitions (
+			id TEXT PRIMARY KEY,
+			name TEXT UNIQUE NOT NULL,
+			fields JSON NOT NULL,
+			redirect_url TEXT NOT NULL,
+			error_url TEXT NOT NULL,
+			notify_email TEXT NOT NULL,
+			email_template TEXT NOT NULL,
+			max_per_hour INTEGER NOT NULL,
+			updated timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_sessions (
+			id TEXT PRIMARY KEY,
+			account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			user_id TEXT REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			refresh_hash TEXT NOT NULL,
+			jwt_id TEXT NOT NULL,
+			user_agent TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			created timestamp NOT NULL,
+			last_used timestamp NOT NULL,
+			expires timestamp NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS {schema}_sb_sessions_userid_idx ON {schema}_sb_sessions (user_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_mfa (
+			user_id TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			secret BLOB NOT NULL,
+			enabled BOOLEAN NOT NULL,
+			recovery_codes JSON NOT NULL,
+			last_step INTEGER NOT NULL,
+			updated timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_apikeys (
+			id TEXT PRIMARY KEY,
+			account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			user_id TEXT REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			key_hash TEXT UNIQUE NOT NULL,
+			scopes JSON NOT NULL,
+			allowed_ips JSON NOT NULL,
+			expires timestamp NOT NULL,
+			last_used timestamp NOT NULL,
+			last_ip TEXT NOT NULL,
+			created timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_identities (
+			id TEXT PRIMARY KEY,
+			account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			user_id TEXT REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			provider TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			email TEXT NOT NULL,
+			created timestamp NOT NULL,
+			UNIQUE(provider, subject)
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_identities_userid_idx ON {schema}_sb_identities (user_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_roles (
+			name TEXT PRIMARY KEY,
+			description TEXT NOT NULL,
+			permissions TEXT NOT NULL,
+			updated timestamp NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_groups (
+			id TEXT PRIMARY KEY,
+			account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			roles TEXT NOT NULL,
+			created timestamp NOT NULL,
+			UNIQUE(account_id, name)
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_group_members (
+			group_id TEXT REFERENCES {schema}_sb_groups(id) ON DELETE CASCADE,
+			user_id TEXT REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			PRIMARY KEY(group_id, user_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_group_members_userid_idx ON {schema}_sb_group_members (user_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_invitations (
+			id TEXT PRIMARY KEY,
+			account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			invited_by TEXT NOT NULL,
+			email TEXT NOT NULL,
+			role INTEGER NOT NULL,
+			link TEXT NOT NULL,
+			nonce TEXT NOT NULL,
+			expires timestamp NOT NULL,
+			created timestamp NOT NULL
 		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_invitations_acctid_idx ON {schema}_sb_invitations (account_id);
 	`, "{schema}", schema, -1)
 
 	if _, err := sl.DB.Exec(qry); err != nil {
@@ -159,6 +320,17 @@ func (sl *SQLite) FindDatabase(baseID string) (base model.DatabaseConfig, err er
 	return
 }
 
+func (sl *SQLite) FindDatabaseByName(name string) (base model.DatabaseConfig, err error) {
+	row := sl.DB.QueryRow(`
+		SELECT * 
+		FROM sb_apps 
+		WHERE name = $1
+	`, name)
+
+	err = scanBase(row, &base)
+	return
+}
+
 func (sl *SQLite) DatabaseExists(name string) (exists bool, err error) {
 	var count int
 	err = sl.DB.QueryRow(`
@@ -262,6 +434,45 @@ func (sl *SQLite) EnableExternalLogin(tenantID string, config map[string]model.O
 	return nil
 }
 
+func (sl *SQLite) SetSMSConfig(baseID string, config model.SMSConfig) error {
+	b, err := model.EncryptSMSConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := sl.DB.Exec(`UPDATE sb_apps SET sms_config = $2 WHERE id = $1`, baseID, b); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) SetAllowedDomains(baseID string, domains []string) error {
+	allowed := strings.Join(domains, "|")
+	if _, err := sl.DB.Exec(`UPDATE sb_apps SET allowed_domain = $2 WHERE id = $1`, baseID, allowed); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) SetMFARole(baseID string, role int) error {
+	if _, err := sl.DB.Exec(`UPDATE sb_apps SET mfa_role = $2 WHERE id = $1`, baseID, role); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) SetAuthPolicy(baseID string, policy model.AuthPolicy) error {
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if _, err := sl.DB.Exec(`UPDATE sb_apps SET auth_policy = $2 WHERE id = $1`, baseID, string(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (sl *SQLite) NewID() string {
 	id, err := uuid.NewUUID()
 	if err != nil {
@@ -299,6 +510,7 @@ func scanCustomer(rows Scanner, c *model.Tenant) error {
 
 func scanBase(rows Scanner, b *model.DatabaseConfig) error {
 	var allowedDomain string
+	var authPolicy []byte
 	err := rows.Scan(
 		&b.ID,
 		&b.TenantID,
@@ -307,10 +519,22 @@ func scanBase(rows Scanner, b *model.DatabaseConfig) error {
 		&b.IsActive,
 		&b.MonthlySentEmail,
 		&b.Created,
+		&b.SMSConfig,
+		&b.MFARole,
+		&authPolicy,
 	)
+	if err != nil {
+		return err
+	}
+
+	if len(authPolicy) > 0 {
+		if err := json.Unmarshal(authPolicy, &b.AuthPolicy); err != nil {
+			return err
+		}
+	}
 
 	b.AllowedDomain = strings.Split(allowedDomain, "|")
-	return err
+	return nil
 }
 
 func (sl *SQLite) GetAllDatabaseSizes() error {
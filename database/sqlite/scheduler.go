@@ -26,7 +26,7 @@ func (sl *SQLite) ListTasks() (results []model.Task, err error) {
 
 func (sl *SQLite) ListTasksByBase(dbName string) (results []model.Task, err error) {
 	qry := fmt.Sprintf(`
-		SELECT * 
+		SELECT id, name, type, value, meta, interval, last_run, timezone, paused, catch_up
 		FROM %s_sb_tasks 
 	`, dbName)
 
@@ -42,6 +42,8 @@ func (sl *SQLite) ListTasksByBase(dbName string) (results []model.Task, err erro
 			return
 		}
 
+		t.BaseName = dbName
+
 		results = append(results, t)
 	}
 
@@ -49,10 +51,26 @@ func (sl *SQLite) ListTasksByBase(dbName string) (results []model.Task, err erro
 	return
 }
 
+func (sl *SQLite) GetTask(dbName, id string) (task model.Task, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, name, type, value, meta, interval, last_run, timezone, paused, catch_up
+		FROM %s_sb_tasks 
+		WHERE id = $1
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, id)
+	if err = scanTask(row, &task); err != nil {
+		return
+	}
+
+	task.BaseName = dbName
+	return
+}
+
 func (sl *SQLite) AddTask(dbName string, task model.Task) (id string, err error) {
 	qry := fmt.Sprintf(`
-	INSERT INTO %s_sb_tasks(id, name, type, value, meta, interval, last_run)
-	VALUES($1, $2, $3, $4, $5, $6, $7);
+	INSERT INTO %s_sb_tasks(id, name, type, value, meta, interval, last_run, timezone, paused, catch_up)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 	`, dbName)
 
 	id = sl.NewID()
@@ -66,6 +84,9 @@ func (sl *SQLite) AddTask(dbName string, task model.Task) (id string, err error)
 		task.Meta,
 		task.Interval,
 		task.LastRun,
+		task.Timezone,
+		task.Paused,
+		task.CatchUp,
 	)
 	return
 }
@@ -82,6 +103,95 @@ func (sl *SQLite) DeleteTask(dbName, id string) error {
 	return nil
 }
 
+func (sl *SQLite) SetTaskPaused(dbName, id string, paused bool) error {
+	qry := fmt.Sprintf(`
+	UPDATE %s_sb_tasks SET
+		paused = $2
+	WHERE id = $1;
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, id, paused); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) RanTask(dbName string, run model.TaskRun) error {
+	qry := fmt.Sprintf(`
+	UPDATE %s_sb_tasks SET
+		last_run = $2
+	WHERE id = $1;
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, run.TaskID, run.Started); err != nil {
+		return err
+	}
+
+	qry = fmt.Sprintf(`
+	INSERT INTO %s_sb_task_runs(id, task_id, triggered_by, status, started, completed, output, error)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8);
+	`, dbName)
+
+	_, err := sl.DB.Exec(
+		qry,
+		sl.NewID(),
+		run.TaskID,
+		run.Trigger,
+		run.Status,
+		run.Started,
+		run.Completed,
+		run.Output,
+		run.Error,
+	)
+	if err != nil {
+		return err
+	}
+
+	// only the most recent runs are kept
+	qry = fmt.Sprintf(`
+	DELETE FROM %s_sb_task_runs
+	WHERE task_id = $1 AND id NOT IN (
+		SELECT id 
+		FROM %s_sb_task_runs
+		WHERE task_id = $1
+		ORDER BY started DESC
+		LIMIT $2
+	);
+	`, dbName, dbName)
+
+	if _, err := sl.DB.Exec(qry, run.TaskID, model.MaxTaskRuns); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) ListTaskRuns(dbName, taskID string) (results []model.TaskRun, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, task_id, triggered_by, status, started, completed, output, error
+		FROM %s_sb_task_runs 
+		WHERE task_id = $1
+		ORDER BY started DESC
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, taskID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var run model.TaskRun
+		if err = scanTaskRun(rows, &run); err != nil {
+			return
+		}
+
+		results = append(results, run)
+	}
+
+	err = rows.Err()
+	return
+}
+
 func scanTask(rows Scanner, t *model.Task) error {
 	return rows.Scan(
 		&t.ID,
@@ -91,5 +201,21 @@ func scanTask(rows Scanner, t *model.Task) error {
 		&t.Meta,
 		&t.Interval,
 		&t.LastRun,
+		&t.Timezone,
+		&t.Paused,
+		&t.CatchUp,
+	)
+}
+
+func scanTaskRun(rows Scanner, run *model.TaskRun) error {
+	return rows.Scan(
+		&run.ID,
+		&run.TaskID,
+		&run.Trigger,
+		&run.Status,
+		&run.Started,
+		&run.Completed,
+		&run.Output,
+		&run.Error,
 	)
 }
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateSession(dbName string, s model.Session) (id string, err error) {
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_sessions(id, account_id, user_id, refresh_hash, jwt_id, user_agent, ip, created, last_used, expires)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, dbName)
+
+	_, err = sl.DB.Exec(
+		qry,
+		id,
+		s.AccountID,
+		s.UserID,
+		s.RefreshHash,
+		s.JWTID,
+		s.UserAgent,
+		s.IP,
+		s.Created,
+		s.LastUsed,
+		s.Expires,
+	)
+	return
+}
+
+func (sl *SQLite) GetSessionByID(dbName, id string) (s model.Session, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_sessions 
+		WHERE id = $1
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, id)
+
+	err = scanSession(row, &s)
+	return
+}
+
+func (sl *SQLite) ListSessions(dbName, userID string) (results []model.Session, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_sessions
+		WHERE user_id = $1
+		ORDER BY last_used DESC
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, userID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s model.Session
+		if err = scanSession(rows, &s); err != nil {
+			return
+		}
+
+		results = append(results, s)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) RotateSession(dbName string, s model.Session, prevHash string) (bool, error) {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_sessions SET 
+			refresh_hash = $2,
+			jwt_id = $3,
+			user_agent = $4,
+			ip = $5,
+			last_used = $6,
+			expires = $7
+		WHERE id = $1 AND refresh_hash = $8
+	`, dbName)
+
+	res, err := sl.DB.Exec(
+		qry,
+		s.ID,
+		s.RefreshHash,
+		s.JWTID,
+		s.UserAgent,
+		s.IP,
+		s.LastUsed,
+		s.Expires,
+		prevHash,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (sl *SQLite) DeleteSession(dbName, id string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_sessions WHERE id = $1`, dbName)
+
+	if _, err := sl.DB.Exec(qry, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) DeleteUserSessions(dbName, userID string) error {
+	qry := fmt.Sprintf(`DELETE FROM %s_sb_sessions WHERE user_id = $1`, dbName)
+
+	if _, err := sl.DB.Exec(qry, userID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanSession(rows Scanner, s *model.Session) error {
+	return rows.Scan(
+		&s.ID,
+		&s.AccountID,
+		&s.UserID,
+		&s.RefreshHash,
+		&s.JWTID,
+		&s.UserAgent,
+		&s.IP,
+		&s.Created,
+		&s.LastUsed,
+		&s.Expires,
+	)
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) AddSMSMessage(dbName string, msg model.SMSMessage) (id string, err error) {
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_sms(id, account_id, provider, message_id, to_number, from_number, body, status, error, created, updated)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+	`, dbName)
+
+	_, err = sl.DB.Exec(
+		qry,
+		id,
+		msg.AccountID,
+		msg.Provider,
+		msg.MessageID,
+		msg.ToNumber,
+		msg.FromNumber,
+		msg.Body,
+		msg.Status,
+		msg.Error,
+		msg.Created,
+		msg.Updated,
+	)
+	return
+}
+
+func (sl *SQLite) UpdateSMSStatus(dbName, messageID, status, errMsg string) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_sms SET 
+			status = $2,
+			error = $3,
+			updated = $4
+		WHERE message_id = $1
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, messageID, status, errMsg, time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sl *SQLite) GetSMSMessageByID(dbName, id string) (msg model.SMSMessage, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_sms 
+		WHERE id = $1
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, id)
+
+	err = scanSMSMessage(row, &msg)
+	return
+}
+
+func (sl *SQLite) ListSMSMessages(dbName string) (results []model.SMSMessage, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_sms
+		ORDER BY created DESC
+		LIMIT 100
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg model.SMSMessage
+		if err = scanSMSMessage(rows, &msg); err != nil {
+			return
+		}
+
+		results = append(results, msg)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func scanSMSMessage(rows Scanner, msg *model.SMSMessage) error {
+	return rows.Scan(
+		&msg.ID,
+		&msg.AccountID,
+		&msg.Provider,
+		&msg.MessageID,
+		&msg.ToNumber,
+		&msg.FromNumber,
+		&msg.Body,
+		&msg.Status,
+		&msg.Error,
+		&msg.Created,
+		&msg.Updated,
+	)
+}
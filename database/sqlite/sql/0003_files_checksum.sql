@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_files_v2 (
+	id TEXT PRIMARY KEY,
+	account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+	key TEXT NOT NULL,
+	url TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	uploaded timestamp NOT NULL,
+	checksum TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO {schema}_sb_files_v2(id, account_id, key, url, size, uploaded)
+SELECT id, account_id, key, url, size, uploaded FROM {schema}_sb_files;
+
+DROP TABLE {schema}_sb_files;
+
+ALTER TABLE {schema}_sb_files_v2 RENAME TO {schema}_sb_files;
+
+CREATE INDEX IF NOT EXISTS {schema}_sb_files_acctid_idx ON {schema}_sb_files (account_id);
+CREATE INDEX IF NOT EXISTS {schema}_sb_files_key_idx ON {schema}_sb_files (key);
+CREATE INDEX IF NOT EXISTS {schema}_sb_files_checksum_idx ON {schema}_sb_files (checksum);
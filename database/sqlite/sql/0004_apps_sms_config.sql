@@ -0,0 +1,2 @@
+ALTER TABLE sb_apps
+ADD COLUMN sms_config BLOB;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_sms (
+	id TEXT PRIMARY KEY,
+	account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+	provider TEXT NOT NULL,
+	message_id TEXT NOT NULL,
+	to_number TEXT NOT NULL,
+	from_number TEXT NOT NULL,
+	body TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL,
+	created timestamp NOT NULL,
+	updated timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS {schema}_sb_sms_message_id_idx ON {schema}_sb_sms (message_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_form_definitions (
+	id TEXT PRIMARY KEY,
+	name TEXT UNIQUE NOT NULL,
+	fields JSON NOT NULL,
+	redirect_url TEXT NOT NULL,
+	error_url TEXT NOT NULL,
+	notify_email TEXT NOT NULL,
+	email_template TEXT NOT NULL,
+	max_per_hour INTEGER NOT NULL,
+	updated timestamp NOT NULL
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_sessions (
+	id TEXT PRIMARY KEY,
+	account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+	refresh_hash TEXT NOT NULL,
+	jwt_id TEXT NOT NULL,
+	user_agent TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	created timestamp NOT NULL,
+	last_used timestamp NOT NULL,
+	expires timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS {schema}_sb_sessions_userid_idx ON {schema}_sb_sessions (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE sb_apps
+ADD COLUMN mfa_role INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_mfa (
+	user_id TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+	secret BLOB NOT NULL,
+	enabled BOOLEAN NOT NULL,
+	recovery_codes JSON NOT NULL,
+	last_step INTEGER NOT NULL,
+	updated timestamp NOT NULL
+);
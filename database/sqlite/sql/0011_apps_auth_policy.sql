@@ -0,0 +1,2 @@
+ALTER TABLE sb_apps
+ADD COLUMN auth_policy TEXT NOT NULL DEFAULT '{}';
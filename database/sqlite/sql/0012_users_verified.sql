@@ -0,0 +1,2 @@
+ALTER TABLE {schema}_sb_tokens
+ADD COLUMN verified BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_apikeys (
+	id TEXT PRIMARY KEY,
+	account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	key_hash TEXT UNIQUE NOT NULL,
+	scopes JSON NOT NULL,
+	allowed_ips JSON NOT NULL,
+	expires timestamp NOT NULL,
+	last_used timestamp NOT NULL,
+	last_ip TEXT NOT NULL,
+	created timestamp NOT NULL
+);
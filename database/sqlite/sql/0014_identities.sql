@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_identities (
+	id TEXT PRIMARY KEY,
+	account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+	provider TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	email TEXT NOT NULL,
+	created timestamp NOT NULL,
+	UNIQUE(provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS {schema}_sb_identities_userid_idx ON {schema}_sb_identities (user_id);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_roles (
+	name TEXT PRIMARY KEY,
+	description TEXT NOT NULL,
+	permissions TEXT NOT NULL,
+	updated timestamp NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS {schema}_sb_groups (
+	id TEXT PRIMARY KEY,
+	account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	roles TEXT NOT NULL,
+	created timestamp NOT NULL,
+	UNIQUE(account_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS {schema}_sb_group_members (
+	group_id TEXT REFERENCES {schema}_sb_groups(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+	PRIMARY KEY(group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS {schema}_sb_group_members_userid_idx ON {schema}_sb_group_members (user_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {schema}_sb_invitations (
+	id TEXT PRIMARY KEY,
+	account_id TEXT REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+	invited_by TEXT NOT NULL,
+	email TEXT NOT NULL,
+	role INTEGER NOT NULL,
+	link TEXT NOT NULL,
+	nonce TEXT NOT NULL,
+	expires timestamp NOT NULL,
+	created timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS {schema}_sb_invitations_acctid_idx ON {schema}_sb_invitations (account_id);
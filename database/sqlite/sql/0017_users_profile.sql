@@ -0,0 +1,11 @@
+ALTER TABLE {schema}_sb_tokens
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE {schema}_sb_tokens
+ADD COLUMN avatar_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE {schema}_sb_tokens
+ADD COLUMN profile JSON NOT NULL DEFAULT '{}';
+
+ALTER TABLE {schema}_sb_files
+ADD COLUMN user_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,20 @@
+ALTER TABLE {schema}_sb_tasks
+ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE {schema}_sb_tasks
+ADD COLUMN paused BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE {schema}_sb_tasks
+ADD COLUMN catch_up TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS {schema}_sb_task_runs (
+	id TEXT PRIMARY KEY,
+	task_id TEXT REFERENCES {schema}_sb_tasks(id) ON DELETE CASCADE,
+	triggered_by TEXT NOT NULL,
+	status TEXT NOT NULL,
+	started timestamp NOT NULL,
+	completed timestamp NOT NULL,
+	output TEXT NOT NULL,
+	error TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS {schema}_sb_task_runs_taskid_idx ON {schema}_sb_task_runs (task_id, started);
@@ -1,6 +1,8 @@
 package sqlite
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/staticbackendhq/core/model"
@@ -10,8 +12,8 @@ func (sl *SQLite) AddFile(dbName string, f model.File) (id string, err error) {
 	id = sl.NewID()
 
 	qry := fmt.Sprintf(`
-		INSERT INTO %s_sb_files(id, account_id, key, url, size, uploaded)
-		VALUES($1, $2, $3, $4, $5, $6);
+		INSERT INTO %s_sb_files(id, account_id, key, url, size, uploaded, checksum, user_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8);
 	`, dbName)
 
 	_, err = sl.DB.Exec(
@@ -22,6 +24,8 @@ func (sl *SQLite) AddFile(dbName string, f model.File) (id string, err error) {
 		f.URL,
 		f.Size,
 		f.Uploaded,
+		f.Checksum,
+		f.UserID,
 	)
 	return
 }
@@ -86,6 +90,34 @@ func (sl *SQLite) ListAllFiles(dbName, accountID string) (results []model.File,
 	return
 }
 
+func (sl *SQLite) GetFileByChecksum(dbName, checksum string) (f model.File, err error) {
+	qry := fmt.Sprintf(`
+		SELECT * 
+		FROM %s_sb_files 
+		WHERE checksum = $1
+		LIMIT 1
+	`, dbName)
+
+	row := sl.DB.QueryRow(qry, checksum)
+
+	err = scanFile(row, &f)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.File{}, nil
+	}
+	return
+}
+
+func (sl *SQLite) CountFileReferences(dbName, key string) (count int64, err error) {
+	qry := fmt.Sprintf(`
+		SELECT COUNT(*) 
+		FROM %s_sb_files 
+		WHERE key = $1
+	`, dbName)
+
+	err = sl.DB.QueryRow(qry, key).Scan(&count)
+	return
+}
+
 func scanFile(rows Scanner, f *model.File) error {
 	return rows.Scan(
 		&f.ID,
@@ -94,5 +126,7 @@ func scanFile(rows Scanner, f *model.File) error {
 		&f.URL,
 		&f.Size,
 		&f.Uploaded,
+		&f.Checksum,
+		&f.UserID,
 	)
 }
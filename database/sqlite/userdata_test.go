@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestUserProfile(t *testing.T) {
+	p := model.UserProfile{
+		DisplayName: "Admin",
+		AvatarID:    "avatar-file",
+		Profile:     map[string]interface{}{"theme": "dark"},
+	}
+
+	if err := datastore.UpdateUserProfile(confDBName, adminToken.ID, p); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.FindUserByID(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.DisplayName != "Admin" || check.AvatarID != "avatar-file" || check.Profile["theme"] != "dark" {
+		t.Errorf("expected the profile to be updated got %v", check.UserProfile)
+	}
+}
+
+func TestUserDataByOwner(t *testing.T) {
+	col := "userdata_tests"
+
+	for i := 0; i < 2; i++ {
+		doc := map[string]interface{}{"name": "owned"}
+		if _, err := datastore.CreateDocument(adminAuth, confDBName, col, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	docs, err := datastore.ListDocumentsByOwner(confDBName, col, adminAuth.UserID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(docs) != 2 || docs[0]["name"] != "owned" {
+		t.Fatalf("expected 2 owned documents got %v", docs)
+	}
+
+	n, err := datastore.DeleteDocumentsByOwner(confDBName, col, adminAuth.UserID)
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Errorf("expected 2 deleted documents got %d", n)
+	}
+
+	docs, err = datastore.ListDocumentsByOwner(confDBName, "userdata_missing", adminAuth.UserID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(docs) != 0 {
+		t.Errorf("expected no documents in a missing collection got %v", docs)
+	}
+
+	f := model.File{
+		AccountID: adminAccount.ID,
+		UserID:    adminAuth.UserID,
+		Key:       "owned-key",
+		URL:       "https://test/owned",
+		Size:      42,
+		Uploaded:  time.Now(),
+	}
+
+	id, err := datastore.AddFile(confDBName, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetFileByID(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.UserID != adminAuth.UserID {
+		t.Errorf("expected file uploader %s got %s", adminAuth.UserID, check.UserID)
+	}
+}
+
+func TestFormSubmissionsByEmail(t *testing.T) {
+	email := "gdpr@test.com"
+
+	if err := datastore.AddFormSubmission(confDBName, "contact", map[string]interface{}{"email": "GDPR@test.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := datastore.AddFormSubmission(confDBName, "contact", map[string]interface{}{"email": "other@test.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := datastore.ListFormSubmissionsByEmail(confDBName, email)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 {
+		t.Fatalf("expected 1 submission got %v", entries)
+	}
+
+	n, err := datastore.DeleteFormSubmissionsByEmail(confDBName, email)
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("expected 1 deleted submission got %d", n)
+	}
+
+	entries, err = datastore.ListFormSubmissionsByEmail(confDBName, email)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 0 {
+		t.Errorf("expected no submission left got %v", entries)
+	}
+}
@@ -0,0 +1,108 @@
+package staticbackend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// sudoAllowedDomains lists (GET) or replaces (POST) the origins allowed to
+// call the API with the database public key
+func sudoAllowedDomains(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		data := new(struct {
+			Domains []string `json:"domains"`
+		})
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		domains, err := updateAllowedDomains(conf, data.Domains)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		respond(w, http.StatusOK, domains)
+		return
+	}
+
+	respond(w, http.StatusOK, cleanAllowedDomains(conf.AllowedDomain))
+}
+
+// updateAllowedDomains validates and saves the allowed domains and refreshes
+// the cached database config used by the WithDB middleware.
+func updateAllowedDomains(conf model.DatabaseConfig, domains []string) ([]string, error) {
+	domains = cleanAllowedDomains(domains)
+
+	for _, d := range domains {
+		if err := validateAllowedDomain(d); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := backend.DB.SetAllowedDomains(conf.ID, domains); err != nil {
+		return nil, err
+	}
+
+	conf.AllowedDomain = domains
+	if err := backend.Cache.SetTyped(conf.ID, conf); err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+// cleanAllowedDomains lowercases, trims and removes empty and duplicate domains
+func cleanAllowedDomains(domains []string) []string {
+	results := []string{}
+	seen := make(map[string]bool)
+
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if len(d) == 0 || seen[d] {
+			continue
+		}
+
+		seen[d] = true
+		results = append(results, d)
+	}
+	return results
+}
+
+func validateAllowedDomain(d string) error {
+	if d == "*" {
+		return nil
+	}
+
+	if strings.Contains(d, "://") {
+		u, err := url.Parse(d)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid origin: %s", d)
+		} else if len(strings.Trim(u.Path, "/")) > 0 || len(u.RawQuery) > 0 {
+			return fmt.Errorf("origin cannot contain a path: %s", d)
+		}
+		return nil
+	}
+
+	host := strings.TrimPrefix(d, "*.")
+	if strings.ContainsAny(host, "*/ ") {
+		return fmt.Errorf("invalid domain: %s", d)
+	} else if len(host) == 0 {
+		return errors.New("domain cannot be empty")
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package staticbackend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+// originReq sends a public key request from origin through the Cors and
+// WithDB middlewares
+func originReq(origin string) *httptest.ResponseRecorder {
+	h := middleware.Chain(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		middleware.Cors(),
+		middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
+	)
+
+	req := httptest.NewRequest("GET", "https://api.sb.test/db/col", nil)
+	req.Header.Set("SB-PUBLIC-KEY", pubKey)
+	if len(origin) > 0 {
+		req.Header.Set("Origin", origin)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestAllowedDomains(t *testing.T) {
+	resp := dbReq(t, sudoAllowedDomains, "GET", "/sudo/domains", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var original []string
+	if err := parseBody(resp.Body, &original); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		data := map[string][]string{"domains": original}
+		dbReq(t, sudoAllowedDomains, "POST", "/sudo/domains", data, true)
+	}()
+
+	data := map[string][]string{"domains": {"bad/domain"}}
+	resp = dbReq(t, sudoAllowedDomains, "POST", "/sudo/domains", data, true)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid domain got %d", resp.StatusCode)
+	}
+
+	data = map[string][]string{"domains": {"*.Example.com", "localhost:3000", " "}}
+	resp = dbReq(t, sudoAllowedDomains, "POST", "/sudo/domains", data, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var domains []string
+	if err := parseBody(resp.Body, &domains); err != nil {
+		t.Fatal(err)
+	} else if len(domains) != 2 || domains[0] != "*.example.com" {
+		t.Errorf("expected cleaned domains got %v", domains)
+	}
+
+	prev := config.Current.AppEnv
+	config.Current.AppEnv = AppEnvProd
+	defer func() {
+		config.Current.AppEnv = prev
+	}()
+
+	origins := map[string]int{
+		"":                        http.StatusOK,
+		"https://app.example.com": http.StatusOK,
+		"http://localhost:3000":   http.StatusOK,
+		"http://localhost:4000":   http.StatusForbidden,
+		"https://example.com":     http.StatusForbidden,
+		"https://evil.com":        http.StatusForbidden,
+		"https://api.sb.test":     http.StatusOK,
+	}
+
+	for origin, status := range origins {
+		w := originReq(origin)
+		if w.Code != status {
+			t.Errorf("%s: expected status %d got %d", origin, status, w.Code)
+		} else if status == http.StatusForbidden && len(w.Header().Get("Access-Control-Allow-Origin")) > 0 {
+			t.Errorf("%s: expected no Access-Control-Allow-Origin header", origin)
+		}
+	}
+}
+
+func TestAllowedDomainsEnforcedAsWritten(t *testing.T) {
+	resp := dbReq(t, sudoAllowedDomains, "GET", "/sudo/domains", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var original []string
+	if err := parseBody(resp.Body, &original); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		data := map[string][]string{"domains": original}
+		dbReq(t, sudoAllowedDomains, "POST", "/sudo/domains", data, true)
+	}()
+
+	prev := config.Current.AppEnv
+	config.Current.AppEnv = AppEnvProd
+	defer func() {
+		config.Current.AppEnv = prev
+	}()
+
+	// the default only allows local origins
+	data := map[string][]string{"domains": {middleware.DefaultAllowedDomain}}
+	resp = dbReq(t, sudoAllowedDomains, "POST", "/sudo/domains", data, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if w := originReq("http://localhost:3000"); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for localhost got %d", w.Code)
+	}
+	if w := originReq("https://app.example.com"); w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 with the default domains got %d", w.Code)
+	}
+
+	// "*" is the explicit opt-out
+	data = map[string][]string{"domains": {"*"}}
+	resp = dbReq(t, sudoAllowedDomains, "POST", "/sudo/domains", data, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if w := originReq("https://app.example.com"); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 with * allowed got %d", w.Code)
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		match   bool
+	}{
+		{"*", "https://any.com", true},
+		{"example.com", "https://example.com", true},
+		{"example.com", "https://www.example.com", false},
+		{"*.example.com", "https://a.b.example.com", true},
+		{"*.example.com", "https://notexample.com", false},
+		{"localhost:3000", "http://localhost:3000", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://example.com:8443", "https://example.com:8443", true},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.origin)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if match := middleware.MatchDomain(tt.pattern, u); match != tt.match {
+			t.Errorf("%s with %s: expected %v got %v", tt.pattern, tt.origin, tt.match, match)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package staticbackend
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+// sudoExport streams a gzipped tar archive of the database, the uploaded
+// files are included with ?blobs=1 and ?portable=1 exports an archive that
+// can be imported in any engine. Archives are imported with the
+// staticbackend import command.
+func sudoExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := backend.ExportOptions{
+		Blobs:    r.URL.Query().Get("blobs") == "1",
+		Portable: r.URL.Query().Get("portable") == "1",
+	}
+
+	filename := fmt.Sprintf("%s_%s.tar.gz", conf.Name, time.Now().Format("20060102150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// the archive is streamed, errors cannot change the status anymore
+	if _, err := backend.ExportDatabase(backend.DB, backend.Filestore, conf.Name, opts, w); err != nil {
+		backend.Log.Error().Err(err).Msgf("error exporting database %s", conf.Name)
+	}
+}
@@ -0,0 +1,263 @@
+package staticbackend
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/database/memory"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestDatabaseExportImport(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := map[string]any{"title": "exported"}
+	resp := dbReq(t, db.add, "POST", "/db/exporttests", doc)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var created map[string]any
+	if err := parseBody(resp.Body, &created); err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("exported file")
+	auth := model.Auth{AccountID: testAccountID}
+	if _, err := backend.Storage(auth, conf).Save("export.txt", "", bytes.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = dbReq(t, sudoExport, "GET", "/sudo/export?blobs=1", nil, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	archive, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := memory.New(backend.Cache.PublishDocument)
+	m, err := backend.ImportDatabase(dst, backend.Filestore, bytes.NewReader(archive), backend.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	} else if m.Database != conf.Name || m.Tables["exporttests"] != 1 || m.Blobs == 0 {
+		t.Fatalf("unexpected manifest %v", m)
+	}
+
+	imported, err := dst.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	} else if imported.Name != conf.Name || imported.TenantID != conf.TenantID {
+		t.Errorf("expected the database to keep its ID and tenant got %v", imported)
+	}
+
+	id := created["id"].(string)
+	check, err := dst.GetDocumentByID(model.Auth{AccountID: testAccountID, Role: 100}, conf.Name, "exporttests", id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check["title"] != "exported" {
+		t.Errorf("expected the imported document got %v", check)
+	}
+
+	if _, err := backend.ImportDatabase(dst, backend.Filestore, bytes.NewReader(archive), backend.ImportOptions{}); !errors.Is(err, backend.ErrDatabaseExists) {
+		t.Errorf("expected an error importing an existing database got %v", err)
+	}
+
+	if resp := dbReq(t, sudoExport, "POST", "/sudo/export", nil, true); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 got %d", resp.StatusCode)
+	}
+}
+
+func TestDatabaseImportOtherLayout(t *testing.T) {
+	src, err := backend.OpenDatabase("sqlite", filepath.Join(t.TempDir(), "export.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cus, err := src.CreateTenant(model.Tenant{Email: "layout@test.com", IsActive: true, Created: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := model.DatabaseConfig{ID: src.NewID(), TenantID: cus.ID, Name: "layouttest", IsActive: true, Created: time.Now()}
+	if _, err := src.CreateDatabase(base); err != nil {
+		t.Fatal(err)
+	}
+
+	acctID, err := src.CreateAccount(base.Name, cus.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: acctID, Role: 100}
+	if _, err := src.CreateDocument(auth, base.Name, "layouttests", map[string]any{"title": "sqlite"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if _, err := backend.ExportDatabase(src, backend.Filestore, base.Name, backend.ExportOptions{}, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	// a SQLite archive cannot be imported in the memory engine, nothing is
+	// created in the target
+	dst := memory.New(backend.Cache.PublishDocument)
+	if _, err := backend.ImportDatabase(dst, backend.Filestore, &archive, backend.ImportOptions{}); !errors.Is(err, backend.ErrDumpLayout) {
+		t.Fatalf("expected a layout error got %v", err)
+	}
+
+	if exists, err := dst.DatabaseExists(base.Name); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Error("expected the database to not be created")
+	}
+}
+
+func TestDatabasePortableImport(t *testing.T) {
+	src, err := backend.OpenDatabase("sqlite", filepath.Join(t.TempDir(), "portable.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cus, err := src.CreateTenant(model.Tenant{Email: "portable@test.com", IsActive: true, Created: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := model.DatabaseConfig{ID: src.NewID(), TenantID: cus.ID, Name: "portabletest", IsActive: true, Created: time.Now()}
+	if _, err := src.CreateDatabase(base); err != nil {
+		t.Fatal(err)
+	}
+
+	acctID, err := src.CreateAccount(base.Name, cus.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID, err := src.CreateUser(base.Name, model.User{AccountID: acctID, Email: cus.Email, Password: "hashed", Token: "tok", Role: 100, Verified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := model.File{AccountID: acctID, UserID: userID, Key: "portable.txt", URL: "/portable.txt", Size: 8, Uploaded: time.Now()}
+	fileID, err := src.AddFile(base.Name, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: acctID, UserID: userID, Role: 100}
+	author, err := src.CreateDocument(auth, base.Name, "authors", map[string]any{"name": "portable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := map[string]any{
+		"title":    "moved",
+		"authorId": author["id"],
+		"files":    []any{fileID},
+		"views":    3,
+	}
+	if _, err := src.CreateDocument(auth, base.Name, "posts", post); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	opts := backend.ExportOptions{Portable: true}
+	if _, err := backend.ExportDatabase(src, backend.Filestore, base.Name, opts, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	// a SQLite database moves to the memory engine with new IDs
+	dst := memory.New(backend.Cache.PublishDocument)
+	m, err := backend.ImportDatabase(dst, backend.Filestore, &archive, backend.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	} else if m.Tables["collections/posts"] != 1 || m.Tables["records/users"] != 1 || m.Tables["records/files"] != 1 {
+		t.Fatalf("unexpected manifest %v", m)
+	}
+
+	imported, err := dst.FindDatabaseByName(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if imported.ID == base.ID {
+		t.Error("expected the database to get a new ID")
+	}
+
+	tok, err := dst.FindUserByEmail(base.Name, cus.Email)
+	if err != nil {
+		t.Fatal(err)
+	} else if tok.ID == userID || tok.Password != "hashed" || tok.Role != 100 {
+		t.Errorf("expected the user with a new ID and its password got %v", tok)
+	}
+
+	files, err := dst.ListAllFiles(base.Name, "")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) != 1 || files[0].ID == fileID || files[0].UserID != tok.ID || files[0].AccountID != tok.AccountID {
+		t.Fatalf("expected the file to reference the new IDs got %v", files)
+	}
+
+	var srcDocs, dstDocs []model.PortableDocument
+	collect := func(list *[]model.PortableDocument) func(string, model.PortableDocument) error {
+		return func(col string, doc model.PortableDocument) error {
+			*list = append(*list, doc)
+			return nil
+		}
+	}
+
+	if err := src.DumpDocuments(base.Name, []string{"authors", "posts"}, collect(&srcDocs)); err != nil {
+		t.Fatal(err)
+	} else if err := dst.DumpDocuments(base.Name, []string{"authors", "posts"}, collect(&dstDocs)); err != nil {
+		t.Fatal(err)
+	} else if len(dstDocs) != 2 {
+		t.Fatalf("expected 2 documents got %d", len(dstDocs))
+	}
+
+	newAuthor, newPost := dstDocs[0], dstDocs[1]
+	if newAuthor.ID == author["id"] || newAuthor.OwnerID != tok.ID || newAuthor.AccountID != tok.AccountID {
+		t.Errorf("expected the author with new IDs got %v", newAuthor)
+	} else if !newAuthor.Created.Equal(srcDocs[0].Created) {
+		t.Errorf("expected the creation time %v got %v", srcDocs[0].Created, newAuthor.Created)
+	}
+
+	if newPost.Data["authorId"] != newAuthor.ID {
+		t.Errorf("expected the reference to be rewritten got %v", newPost.Data)
+	} else if list, ok := newPost.Data["files"].([]any); !ok || list[0] != files[0].ID {
+		t.Errorf("expected the file reference to be rewritten got %v", newPost.Data["files"])
+	} else if newPost.Data["views"] != int64(3) {
+		t.Errorf("expected the number to be kept got %T %v", newPost.Data["views"], newPost.Data["views"])
+	}
+
+	// and back to SQLite
+	archive.Reset()
+	if _, err := backend.ExportDatabase(dst, backend.Filestore, base.Name, opts, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	back, err := backend.OpenDatabase("sqlite", filepath.Join(t.TempDir(), "back.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = backend.ImportDatabase(back, backend.Filestore, &archive, backend.ImportOptions{Name: "portableback"})
+	if err != nil {
+		t.Fatal(err)
+	} else if m.Tables["collections/posts"] != 1 || m.Tables["collections/authors"] != 1 {
+		t.Fatalf("unexpected manifest %v", m)
+	}
+
+	if _, err := back.FindUserByEmail("portableback", cus.Email); err != nil {
+		t.Errorf("expected the user to be imported: %v", err)
+	}
+}
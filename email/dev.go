@@ -13,6 +13,9 @@ func (d Dev) Send(data SendMailData) error {
 	fmt.Println("to: ", data.To)
 	fmt.Println("subject: ", data.Subject)
 	fmt.Printf("body\n%s\n\n", data.TextBody)
+	for _, f := range data.Files {
+		fmt.Printf("attachment: %s (%d bytes)\n", f.Filename, len(f.Content))
+	}
 	fmt.Println("====== /SENDING EMAIL ======")
 	return nil
 }
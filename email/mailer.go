@@ -1,8 +1,9 @@
 package email
 
 const (
-	MailProviderDev = "dev"
-	MailProviderSES = "ses"
+	MailProviderDev  = "dev"
+	MailProviderSES  = "ses"
+	MailProviderSMTP = "smtp"
 )
 
 // SendMailData contains necessary fields to send an email
@@ -17,6 +18,23 @@ type SendMailData struct {
 	ReplyTo  string `json:"replyTo"`
 
 	Body string `json:"body"`
+
+	// Template is the name of a stored email template used to render the
+	// Subject, HTMLBody and TextBody with Data
+	Template string         `json:"template"`
+	Data     map[string]any `json:"data"`
+	// Attachments are file IDs (from the file storage) to attach
+	Attachments []string `json:"attachments"`
+
+	// Files are the loaded attachments sent with the email
+	Files []Attachment `json:"-"`
+}
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
 }
 
 // Mailer is used to have different implementation for sending email
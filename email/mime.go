@@ -0,0 +1,177 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// ErrHeaderLineBreak is returned when a header value contains a line break,
+// it could inject other headers
+var ErrHeaderLineBreak = errors.New("email headers cannot contain line breaks")
+
+// checkHeaders rejects the header values containing \r or \n
+func checkHeaders(data SendMailData) error {
+	values := []string{data.From, data.FromName, data.To, data.ToName, data.ReplyTo, data.Subject}
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return ErrHeaderLineBreak
+		}
+	}
+	return nil
+}
+
+// buildMessage builds a RFC 5322 message with a text and HTML alternative
+// body and the attachments if any.
+func buildMessage(data SendMailData) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := checkHeaders(data); err != nil {
+		return nil, err
+	}
+
+	if len(data.ReplyTo) == 0 {
+		data.ReplyTo = data.From
+	}
+
+	from := mail.Address{Name: data.FromName, Address: data.From}
+	to := mail.Address{Name: data.ToName, Address: data.To}
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	if len(data.ReplyTo) > 0 {
+		replyTo, err := mail.ParseAddress(data.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Reply-To: %w", err)
+		}
+		fmt.Fprintf(&buf, "Reply-To: %s\r\n", replyTo.String())
+	}
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", data.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	alt, err := buildAlternative(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data.Files) == 0 {
+		buf.Write(alt.header)
+		buf.WriteString("\r\n")
+		buf.Write(alt.body)
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Type", alt.contentType)
+	pw, err := mw.CreatePart(h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pw.Write(alt.body); err != nil {
+		return nil, err
+	}
+
+	for _, f := range data.Files {
+		ct := f.ContentType
+		if len(ct) == 0 {
+			ct = "application/octet-stream"
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Type", ct)
+		h.Set("Content-Transfer-Encoding", "base64")
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.Filename}))
+
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeBase64(pw, f.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+type mimePart struct {
+	contentType string
+	header      []byte
+	body        []byte
+}
+
+func buildAlternative(data SendMailData) (part mimePart, err error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	bodies := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", data.TextBody},
+		{"text/html; charset=utf-8", data.HTMLBody},
+	}
+
+	for _, b := range bodies {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Type", b.contentType)
+		h.Set("Content-Transfer-Encoding", "quoted-printable")
+
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			return part, err
+		}
+
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(b.content)); err != nil {
+			return part, err
+		}
+		if err := qp.Close(); err != nil {
+			return part, err
+		}
+	}
+
+	if err = mw.Close(); err != nil {
+		return
+	}
+
+	part.contentType = "multipart/alternative; boundary=" + mw.Boundary()
+	part.header = []byte("Content-Type: " + part.contentType + "\r\n")
+	part.body = body.Bytes()
+	return
+}
+
+// writeBase64 writes b encoded in base64 with lines of 76 characters
+func writeBase64(w io.Writer, b []byte) error {
+	enc := base64.StdEncoding.EncodeToString(b)
+	for len(enc) > 76 {
+		if _, err := w.Write([]byte(enc[:76] + "\r\n")); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+
+	_, err := w.Write([]byte(enc + "\r\n"))
+	return err
+}
@@ -0,0 +1,169 @@
+package email
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"text/template"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/storage"
+)
+
+// Outbox sends emails for a database. It renders the stored templates, loads
+// the attachments from the file storage and records each sent email.
+type Outbox struct {
+	Mailer  Mailer
+	DB      database.Persister
+	Storage storage.Storer
+}
+
+// Send prepares and sends the email and returns its log entry. An error is
+// returned without a log entry when the template or attachments cannot be
+// loaded.
+func (o Outbox) Send(dbName string, data SendMailData) (entry model.EmailLog, err error) {
+	if len(data.Template) > 0 {
+		if err = o.applyTemplate(dbName, &data); err != nil {
+			return
+		}
+	}
+
+	normalizeBodies(&data)
+
+	for _, fileID := range data.Attachments {
+		f, err := o.loadAttachment(dbName, fileID)
+		if err != nil {
+			return entry, err
+		}
+
+		data.Files = append(data.Files, f)
+	}
+
+	entry = model.EmailLog{
+		Template: data.Template,
+		From:     data.From,
+		To:       data.To,
+		Subject:  data.Subject,
+		Status:   model.EmailStatusSent,
+		Sent:     time.Now(),
+	}
+
+	sendErr := o.Mailer.Send(data)
+	if sendErr != nil {
+		entry.Status = model.EmailStatusFailed
+		entry.Error = sendErr.Error()
+	}
+
+	id, err := o.DB.AddEmailLog(dbName, entry)
+	if err != nil {
+		return
+	}
+
+	entry.ID = id
+	err = sendErr
+	return
+}
+
+func (o Outbox) applyTemplate(dbName string, data *SendMailData) error {
+	tmpl, err := o.DB.GetEmailTemplate(dbName, data.Template)
+	if err != nil {
+		return err
+	}
+
+	subject, err := executeText(tmpl.Subject, data.Data)
+	if err != nil {
+		return err
+	}
+
+	text, err := executeText(tmpl.TextBody, data.Data)
+	if err != nil {
+		return err
+	}
+
+	t, err := htmltemplate.New("html").Parse(tmpl.HTMLBody)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data.Data); err != nil {
+		return err
+	}
+
+	data.Subject = subject
+	data.HTMLBody = buf.String()
+	data.TextBody = text
+	data.Body = ""
+	return nil
+}
+
+// ValidateTemplate makes sure the subject and bodies of an email template
+// are valid templates
+func ValidateTemplate(tmpl model.EmailTemplate) error {
+	if _, err := template.New("subject").Parse(tmpl.Subject); err != nil {
+		return err
+	} else if _, err := template.New("text").Parse(tmpl.TextBody); err != nil {
+		return err
+	} else if _, err := htmltemplate.New("html").Parse(tmpl.HTMLBody); err != nil {
+		return err
+	}
+	return nil
+}
+
+func executeText(s string, data any) (string, error) {
+	t, err := template.New("text").Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// normalizeBodies makes sure both the HTML and text bodies are set
+func normalizeBodies(data *SendMailData) {
+	// if only body is provided
+	if len(data.Body) > 0 {
+		data.HTMLBody = data.Body
+		data.TextBody = StripHTML(data.Body)
+	} else if len(data.TextBody) == 0 && len(data.HTMLBody) > 0 {
+		data.TextBody = StripHTML(data.HTMLBody)
+	} else if len(data.HTMLBody) == 0 && len(data.TextBody) > 0 {
+		data.HTMLBody = data.TextBody
+	}
+}
+
+func (o Outbox) loadAttachment(dbName, fileID string) (a Attachment, err error) {
+	f, err := o.DB.GetFileByID(dbName, fileID)
+	if err != nil {
+		return
+	}
+
+	rc, err := o.Storage.Get(f.Key)
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return
+	}
+
+	a.Filename = path.Base(f.Key)
+	a.Content = b
+
+	a.ContentType = mime.TypeByExtension(path.Ext(a.Filename))
+	if len(a.ContentType) == 0 {
+		a.ContentType = http.DetectContentType(b)
+	}
+	return
+}
@@ -0,0 +1,30 @@
+package email
+
+import "sync"
+
+// Reloadable is a Mailer whose provider can be replaced while it's in use
+type Reloadable struct {
+	mu     sync.RWMutex
+	mailer Mailer
+}
+
+// NewReloadable returns a Reloadable sending via m
+func NewReloadable(m Mailer) *Reloadable {
+	return &Reloadable{mailer: m}
+}
+
+// Set replaces the provider used by the next sends
+func (r *Reloadable) Set(m Mailer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.mailer = m
+}
+
+func (r *Reloadable) Send(data SendMailData) error {
+	r.mu.RLock()
+	m := r.mailer
+	r.mu.RUnlock()
+
+	return m.Send(data)
+}
@@ -3,6 +3,7 @@ package email
 import (
 	"context"
 	"fmt"
+	"net/mail"
 	"strings"
 
 	"github.com/staticbackendhq/core/config"
@@ -20,6 +21,10 @@ func (AWSSES) Send(data SendMailData) error {
 		return fmt.Errorf("empty To email")
 	}
 
+	if err := checkHeaders(data); err != nil {
+		return err
+	}
+
 	if len(data.ReplyTo) == 0 {
 		data.ReplyTo = data.From
 	}
@@ -37,7 +42,24 @@ func (AWSSES) Send(data SendMailData) error {
 	// Create an SES client.
 	svc := ses.NewFromConfig(cfg)
 
-	from := fmt.Sprintf("%s <%s>", data.FromName, data.From)
+	// attachments require sending the raw MIME message
+	if len(data.Files) > 0 {
+		msg, err := buildMessage(data)
+		if err != nil {
+			return err
+		}
+
+		input := &ses.SendRawEmailInput{
+			RawMessage: &types.RawMessage{Data: msg},
+		}
+
+		if _, err := svc.SendRawEmail(context.TODO(), input); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	from := (&mail.Address{Name: data.FromName, Address: data.From}).String()
 
 	// Assemble the email.
 	input := &ses.SendEmailInput{
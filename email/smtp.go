@@ -0,0 +1,150 @@
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/config"
+)
+
+const (
+	SMTPEncryptionTLS      = "tls"
+	SMTPEncryptionStartTLS = "starttls"
+	SMTPEncryptionNone     = "none"
+
+	// smtpDialTimeout limits the connection to the server and smtpTimeout
+	// the whole exchange
+	smtpDialTimeout = 10 * time.Second
+	smtpTimeout     = time.Minute
+)
+
+// SMTP sends emails via the SMTP server configured with the SMTP_* environment
+// variables, or with its fields when Host is set
+type SMTP struct {
+	Host       string
+	Port       string
+	Username   string
+	Password   string
+	Encryption string
+}
+
+// settings returns s when its Host is set, otherwise the SMTP server from
+// the current configuration
+func (s SMTP) settings() SMTP {
+	if len(s.Host) > 0 {
+		return s
+	}
+
+	return SMTP{
+		Host:       config.Current.SMTPHost,
+		Port:       config.Current.SMTPPort,
+		Username:   config.Current.SMTPUsername,
+		Password:   config.Current.SMTPPassword,
+		Encryption: config.Current.SMTPEncryption,
+	}
+}
+
+func (s SMTP) Send(data SendMailData) error {
+	if len(data.To) == 0 || !strings.Contains(data.To, "@") {
+		return fmt.Errorf("empty To email")
+	}
+
+	s = s.settings()
+
+	host := s.Host
+	if len(host) == 0 {
+		return errors.New("SMTP_HOST is not set")
+	}
+
+	enc := strings.ToLower(s.Encryption)
+	if len(enc) == 0 {
+		enc = SMTPEncryptionStartTLS
+	}
+
+	port := s.Port
+	if len(port) == 0 {
+		port = "587"
+		if enc == SMTPEncryptionTLS {
+			port = "465"
+		}
+	}
+
+	msg, err := buildMessage(data)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(host, port)
+	tlsConfig := &tls.Config{ServerName: host}
+
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+
+	var conn net.Conn
+	switch enc {
+	case SMTPEncryptionTLS:
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	case SMTPEncryptionStartTLS, SMTPEncryptionNone:
+		conn, err = dialer.Dial("tcp", addr)
+	default:
+		return fmt.Errorf("unsupported SMTP encryption: %s", enc)
+	}
+	if err != nil {
+		return err
+	}
+
+	// an unresponsive server cannot block the sender
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if enc == SMTPEncryptionStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if len(s.Username) > 0 {
+		auth := smtp.PlainAuth("", s.Username, s.Password, host)
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(data.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(data.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,227 @@
+package email
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/config"
+)
+
+// fakeSMTPServer accepts one connection and returns the received DATA
+func fakeSMTPServer(t *testing.T) (addr string, received chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received = make(chan string, 1)
+
+	go func() {
+		defer ln.Close()
+
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rdr := bufio.NewReader(conn)
+		write := func(s string) {
+			conn.Write([]byte(s + "\r\n"))
+		}
+
+		write("220 localhost ESMTP")
+
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := rdr.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					write("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				write("250-localhost")
+				write("250 AUTH PLAIN")
+			case strings.HasPrefix(cmd, "AUTH"):
+				write("235 Authentication successful")
+			case strings.HasPrefix(cmd, "DATA"):
+				inData = true
+				write("354 End data with <CR><LF>.<CR><LF>")
+			case strings.HasPrefix(cmd, "QUIT"):
+				write("221 Bye")
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), received
+}
+
+func TestSMTPSend(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := config.Current
+	defer func() {
+		config.Current = prev
+	}()
+
+	config.Current.SMTPHost = host
+	config.Current.SMTPPort = port
+	config.Current.SMTPUsername = "user"
+	config.Current.SMTPPassword = "pass"
+	config.Current.SMTPEncryption = SMTPEncryptionNone
+
+	data := SendMailData{
+		From:     "from@test.com",
+		FromName: "Unit Test",
+		To:       "to@test.com",
+		Subject:  "Héllo",
+		HTMLBody: "<p>hello</p>",
+		TextBody: "hello",
+		Files: []Attachment{
+			{Filename: "file.txt", ContentType: "text/plain", Content: []byte("attached")},
+		},
+	}
+
+	if err := (SMTP{}).Send(data); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-received))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := new(mime.WordDecoder)
+	if subject, err := dec.DecodeHeader(msg.Header.Get("Subject")); err != nil {
+		t.Fatal(err)
+	} else if subject != data.Subject {
+		t.Errorf("expected subject to be %s got %s", data.Subject, subject)
+	}
+
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "multipart/mixed") {
+		t.Errorf("expected a multipart/mixed message got %s", ct)
+	}
+
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(body), `filename=file.txt`) {
+		t.Errorf("expected the attachment in the message got %s", body)
+	}
+}
+
+func TestSMTPRequiresStartTLS(t *testing.T) {
+	addr, _ := fakeSMTPServer(t)
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := config.Current
+	defer func() {
+		config.Current = prev
+	}()
+
+	config.Current.SMTPHost = host
+	config.Current.SMTPPort = port
+	config.Current.SMTPEncryption = ""
+
+	data := SendMailData{From: "from@test.com", To: "to@test.com", TextBody: "hello"}
+	if err := (SMTP{}).Send(data); err == nil {
+		t.Errorf("expected an error when the server does not support STARTTLS")
+	}
+}
+
+func TestBuildMessageRejectsHeaderInjection(t *testing.T) {
+	injected := []SendMailData{
+		{From: "from@test.com", To: "to@test.com", ReplyTo: "a@test.com\r\nBcc: victim@test.com"},
+		{From: "from@test.com", To: "to@test.com", Subject: "hi\r\nBcc: victim@test.com"},
+		{From: "from@test.com", FromName: "Me\nBcc: victim@test.com", To: "to@test.com"},
+	}
+
+	for _, data := range injected {
+		if _, err := buildMessage(data); err != ErrHeaderLineBreak {
+			t.Errorf("expected ErrHeaderLineBreak got %v for %v", err, data)
+		}
+	}
+
+	msg, err := buildMessage(SendMailData{From: "from@test.com", To: "to@test.com", ReplyTo: "Support <help@test.com>"})
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(msg), "Reply-To: \"Support\" <help@test.com>\r\n") {
+		t.Errorf("expected the formatted Reply-To got %s", msg)
+	}
+}
+
+func TestSMTPClosesConnOnBadGreeting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	closed := make(chan bool, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("554 no service\r\n"))
+
+		// the client closes the connection when the greeting fails
+		_, err = io.ReadAll(conn)
+		closed <- err == nil
+	}()
+
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := SMTP{Host: host, Port: port, Encryption: SMTPEncryptionNone}
+	if err := s.Send(SendMailData{From: "from@test.com", To: "to@test.com"}); err == nil {
+		t.Fatal("expected an error for a rejected greeting")
+	}
+
+	select {
+	case ok := <-closed:
+		if !ok {
+			t.Error("expected the connection to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("the connection was not closed")
+	}
+}
@@ -1,25 +1,22 @@
-package main
+package extra
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 )
 
+// ConvertParams are the options for converting a web page to PDF or PNG
 type ConvertParams struct {
 	ToPDF    bool   `json:"toPDF"`
 	URL      string `json:"url"`
 	FullPage bool   `json:"fullpage"`
 }
 
-func Do(body []byte) (buf []byte, err error) {
-	var data ConvertParams
-	if err = json.Unmarshal(body, &data); err != nil {
-		return
-	}
-
+// HTMLToX captures a web page as PDF or PNG using a headless Chrome/Chromium
+// that must be installed on the host.
+func HTMLToX(data ConvertParams) (buf []byte, err error) {
 	// explicitly set flags for Headless/CI environments
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.NoSandbox,
@@ -0,0 +1,497 @@
+package extra
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var whitespaces = regexp.MustCompile(`\s+`)
+
+// RenderPDF executes the HTML template tmpl with data and writes the resulting
+// document as a PDF to output.
+//
+// The rendering is done in pure Go and supports the subset of HTML needed for
+// documents like invoices and receipts: headings, paragraphs, line breaks,
+// bold, italic, underline, links, horizontal rules, lists, tables (border and
+// colspan attributes) and PNG/JPEG images as base64 data URI. CSS is ignored,
+// use the align attribute to center or right-align blocks and cells.
+//
+// The standard PDF fonts are used, characters outside the Windows-1252
+// character set are not rendered.
+func RenderPDF(tmpl string, data any, output io.Writer) error {
+	t, err := template.New("pdf").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	doc, err := html.Parse(&buf)
+	if err != nil {
+		return err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	r := &pdfRenderer{
+		pdf:       pdf,
+		tr:        pdf.UnicodeTranslatorFromDescriptor(""),
+		size:      11,
+		lineStart: true,
+	}
+	r.setFont()
+	r.render(doc)
+
+	return pdf.Output(output)
+}
+
+type pdfRenderer struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+
+	bold      int
+	italic    int
+	underline int
+	size      float64
+	link      string
+
+	// lists holds the next item number of each opened ordered list or -1 for
+	// unordered ones.
+	lists     []int
+	lineStart bool
+	images    int
+}
+
+func (r *pdfRenderer) setFont() {
+	style := ""
+	if r.bold > 0 {
+		style += "B"
+	}
+	if r.italic > 0 {
+		style += "I"
+	}
+	if r.underline > 0 {
+		style += "U"
+	}
+	r.pdf.SetFont("Helvetica", style, r.size)
+}
+
+func (r *pdfRenderer) lineHeight() float64 {
+	return r.pdf.PointConvert(r.size) * 1.4
+}
+
+func (r *pdfRenderer) render(n *html.Node) {
+	if n.Type == html.TextNode {
+		r.text(n.Data)
+		return
+	} else if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Head, atom.Script, atom.Style, atom.Title:
+			return
+		case atom.Br:
+			r.newLine()
+			return
+		case atom.Hr:
+			r.rule()
+			return
+		case atom.Img:
+			r.image(n)
+			return
+		case atom.Table:
+			r.table(n)
+			return
+		}
+
+		if align := alignOf(n); align != "L" && isBlock(n) {
+			r.alignedBlock(n, align)
+			return
+		}
+	}
+
+	size, link := r.size, r.link
+	r.open(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+	r.close(n)
+	r.size, r.link = size, link
+	r.setFont()
+}
+
+func (r *pdfRenderer) open(n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	if isBlock(n) {
+		r.endLine()
+	}
+
+	switch n.DataAtom {
+	case atom.B, atom.Strong, atom.Th:
+		r.bold++
+	case atom.I, atom.Em:
+		r.italic++
+	case atom.U:
+		r.underline++
+	case atom.A:
+		r.link = attr(n, "href")
+		r.underline++
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.bold++
+		r.size = headingSize(n.DataAtom)
+	case atom.Ul, atom.Ol:
+		next := -1
+		if n.DataAtom == atom.Ol {
+			next = 1
+		}
+		r.lists = append(r.lists, next)
+
+		left, _, _, _ := r.pdf.GetMargins()
+		r.pdf.SetLeftMargin(left + 6)
+		r.pdf.SetX(left + 6)
+	case atom.Li:
+		r.listItem()
+	}
+	r.setFont()
+}
+
+func (r *pdfRenderer) close(n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.B, atom.Strong, atom.Th:
+		r.bold--
+	case atom.I, atom.Em:
+		r.italic--
+	case atom.U, atom.A:
+		r.underline--
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.bold--
+	case atom.Ul, atom.Ol:
+		r.lists = r.lists[:len(r.lists)-1]
+
+		left, _, _, _ := r.pdf.GetMargins()
+		r.pdf.SetLeftMargin(left - 6)
+	}
+
+	if isBlock(n) {
+		r.endLine()
+	}
+
+	switch n.DataAtom {
+	case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.pdf.Ln(r.lineHeight() * 0.4)
+	}
+}
+
+func (r *pdfRenderer) text(s string) {
+	s = whitespaces.ReplaceAllString(s, " ")
+	if r.lineStart {
+		s = strings.TrimLeft(s, " ")
+	}
+
+	if len(s) == 0 {
+		return
+	}
+
+	if len(r.link) > 0 {
+		r.pdf.WriteLinkString(r.lineHeight(), r.tr(s), r.link)
+	} else {
+		r.pdf.Write(r.lineHeight(), r.tr(s))
+	}
+	r.lineStart = false
+}
+
+func (r *pdfRenderer) newLine() {
+	r.pdf.Ln(r.lineHeight())
+	r.lineStart = true
+}
+
+// endLine moves to the next line unless nothing was written on the current one
+func (r *pdfRenderer) endLine() {
+	if !r.lineStart {
+		r.newLine()
+	}
+}
+
+func (r *pdfRenderer) listItem() {
+	if len(r.lists) == 0 {
+		return
+	}
+
+	marker := "•"
+	if next := r.lists[len(r.lists)-1]; next > 0 {
+		marker = fmt.Sprintf("%d.", next)
+		r.lists[len(r.lists)-1]++
+	}
+
+	left, _, _, _ := r.pdf.GetMargins()
+	r.pdf.SetX(left - 5)
+	r.pdf.Write(r.lineHeight(), r.tr(marker))
+	r.pdf.SetX(left)
+}
+
+func (r *pdfRenderer) rule() {
+	r.endLine()
+
+	left, _, right, _ := r.pdf.GetMargins()
+	width, _ := r.pdf.GetPageSize()
+
+	y := r.pdf.GetY() + 1
+	r.pdf.Line(left, y, width-right, y)
+	r.pdf.SetY(y + 2)
+}
+
+// alignedBlock renders a centered or right-aligned block as plain text using
+// the block's font style.
+func (r *pdfRenderer) alignedBlock(n *html.Node, align string) {
+	r.endLine()
+
+	size := r.size
+	r.open(n)
+	r.pdf.MultiCell(0, r.lineHeight(), r.tr(textOf(n)), "", align, false)
+	r.lineStart = true
+	r.close(n)
+	r.size = size
+	r.setFont()
+}
+
+func (r *pdfRenderer) image(n *html.Node) {
+	src := attr(n, "src")
+	if !strings.HasPrefix(src, "data:image/") {
+		return
+	}
+
+	meta, data, ok := strings.Cut(strings.TrimPrefix(src, "data:image/"), ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return
+	}
+
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return
+	}
+
+	r.images++
+	name := fmt.Sprintf("img%d", r.images)
+	opts := fpdf.ImageOptions{
+		ImageType: strings.TrimSuffix(meta, ";base64"),
+		ReadDpi:   true,
+	}
+	r.pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(b))
+
+	// width attribute is in pixels (96 DPI)
+	var width float64
+	if px, err := strconv.ParseFloat(attr(n, "width"), 64); err == nil {
+		width = px * 25.4 / 96
+	}
+
+	r.endLine()
+	r.pdf.ImageOptions(name, -1, -1, width, 0, true, opts, 0, "")
+	r.lineStart = true
+}
+
+type pdfCell struct {
+	text    string
+	header  bool
+	align   string
+	colspan int
+}
+
+func (r *pdfRenderer) table(n *html.Node) {
+	r.endLine()
+
+	rows := tableRows(n)
+
+	cols := 0
+	for _, row := range rows {
+		span := 0
+		for _, c := range row {
+			span += c.colspan
+		}
+		if span > cols {
+			cols = span
+		}
+	}
+
+	if cols == 0 {
+		return
+	}
+
+	left, _, right, bottom := r.pdf.GetMargins()
+	pageWidth, pageHeight := r.pdf.GetPageSize()
+	colWidth := (pageWidth - left - right) / float64(cols)
+
+	border := len(attr(n, "border")) > 0 && attr(n, "border") != "0"
+	lh := r.lineHeight()
+
+	r.pdf.SetFillColor(235, 235, 235)
+	for _, row := range rows {
+		height := lh
+		for _, c := range row {
+			r.cellFont(c)
+			lines := r.pdf.SplitLines([]byte(r.tr(c.text)), colWidth*float64(c.colspan))
+			if h := float64(len(lines)) * lh; h > height {
+				height = h
+			}
+		}
+
+		if r.pdf.GetY()+height > pageHeight-bottom {
+			r.pdf.AddPage()
+		}
+
+		x, y := left, r.pdf.GetY()
+		for _, c := range row {
+			width := colWidth * float64(c.colspan)
+
+			style := ""
+			if border {
+				style += "D"
+			}
+			if c.header {
+				style += "F"
+			}
+			if len(style) > 0 {
+				r.pdf.Rect(x, y, width, height, style)
+			}
+
+			r.cellFont(c)
+			r.pdf.SetXY(x, y)
+			r.pdf.MultiCell(width, lh, r.tr(c.text), "", c.align, false)
+			x += width
+		}
+		r.pdf.SetXY(left, y+height)
+	}
+
+	r.setFont()
+	r.pdf.Ln(lh * 0.4)
+	r.lineStart = true
+}
+
+func (r *pdfRenderer) cellFont(c pdfCell) {
+	style := ""
+	if c.header {
+		style = "B"
+	}
+	r.pdf.SetFont("Helvetica", style, r.size)
+}
+
+func tableRows(n *html.Node) (rows [][]pdfCell) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		switch c.DataAtom {
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			rows = append(rows, tableRows(c)...)
+		case atom.Tr:
+			var row []pdfCell
+			for td := c.FirstChild; td != nil; td = td.NextSibling {
+				if td.DataAtom != atom.Td && td.DataAtom != atom.Th {
+					continue
+				}
+
+				colspan, err := strconv.Atoi(attr(td, "colspan"))
+				if err != nil || colspan < 1 {
+					colspan = 1
+				}
+
+				row = append(row, pdfCell{
+					text:    textOf(td),
+					header:  td.DataAtom == atom.Th,
+					align:   alignOf(td),
+					colspan: colspan,
+				})
+			}
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+func textOf(n *html.Node) string {
+	var sb strings.Builder
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		} else if n.DataAtom == atom.Br {
+			sb.WriteString("\n")
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	lines := strings.Split(sb.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(whitespaces.ReplaceAllString(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func alignOf(n *html.Node) string {
+	switch strings.ToLower(attr(n, "align")) {
+	case "center":
+		return "C"
+	case "right":
+		return "R"
+	}
+	return "L"
+}
+
+func isBlock(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Ul, atom.Ol, atom.Li, atom.Section, atom.Header, atom.Footer,
+		atom.Article, atom.Address, atom.Blockquote:
+		return true
+	}
+	return false
+}
+
+func headingSize(a atom.Atom) float64 {
+	switch a {
+	case atom.H1:
+		return 20
+	case atom.H2:
+		return 16
+	case atom.H3:
+		return 14
+	case atom.H4:
+		return 12
+	}
+	return 11
+}
@@ -0,0 +1,62 @@
+package extra
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenderPDF(t *testing.T) {
+	img, err := os.ReadFile("./testdata/src.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := `<html><head><title>ignored</title><style>p {color: red;}</style></head>
+	<body>
+		<img src="data:image/png;base64,{{.logo}}" width="120">
+		<h1 align="center">Receipt #{{.number}}</h1>
+		<p>Thank you <b>{{.name}}</b>, <i>your payment</i> was received.<br>
+		Questions? <a href="https://staticbackend.dev">Contact us</a></p>
+		<hr>
+		<ul>{{range .items}}<li>{{.name}}</li>{{end}}</ul>
+		<ol><li>first</li><li>second</li></ol>
+		<table border="1">
+			<thead><tr><th>Item</th><th>Qty</th><th>Price</th></tr></thead>
+			<tbody>
+			{{range .items}}<tr><td>{{.name}}</td><td>{{.qty}}</td><td align="right">{{.price}}</td></tr>{{end}}
+			<tr><td colspan="2" align="right">Total</td><td align="right">{{.total}}</td></tr>
+			</tbody>
+		</table>
+		<p align="right">Café & crème — 2€</p>
+	</body></html>`
+
+	data := map[string]any{
+		"logo":   base64.StdEncoding.EncodeToString(img),
+		"number": 1234,
+		"name":   "Dominic",
+		"items": []map[string]any{
+			{"name": "Pro plan", "qty": 1, "price": "$29.00"},
+			{"name": strings.Repeat("long item description ", 10), "qty": 2, "price": "$10.00"},
+		},
+		"total": "$49.00",
+	}
+
+	var buf bytes.Buffer
+	if err := RenderPDF(tmpl, data, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("expected a PDF document got %q", buf.Bytes()[:10])
+	}
+}
+
+func TestRenderPDFInvalidTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderPDF("<p>{{.name</p>", nil, &buf); err == nil {
+		t.Errorf("expected an error for an invalid template")
+	}
+}
@@ -2,18 +2,14 @@ package staticbackend
 
 import (
 	"bytes"
-	"encoding/json"
+	"crypto/subtle"
 	"fmt"
-	"io"
 	"net/http"
-	"path"
-	"path/filepath"
-	"plugin"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/staticbackendhq/core/backend"
-	"github.com/staticbackendhq/core/config"
 	"github.com/staticbackendhq/core/extra"
 	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/logger"
@@ -52,8 +48,6 @@ func (ex *extras) resizeImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ext := filepath.Ext(h.Filename)
-
 	// TODO: Remove all but a-zA-Z/ from name
 
 	name := r.Form.Get("name")
@@ -75,157 +69,239 @@ func (ex *extras) resizeImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fileKey := fmt.Sprintf("%s/%s/%s%s",
-		config.Name,
-		auth.AccountID,
-		name,
-		ext,
-	)
-
 	resizedBytes := buf.Bytes()
 
 	ex.log.Info().Msgf("resized bytes: %d", len(resizedBytes))
-	upData := model.UploadFileData{FileKey: fileKey, File: bytes.NewReader(resizedBytes)}
-	url, err := backend.Filestore.Save(upData)
+
+	fileSvc := backend.Storage(auth, config)
+	savedFile, err := fileSvc.Save(h.Filename, name, bytes.NewReader(resizedBytes), int64(len(resizedBytes)))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	f := model.File{
-		AccountID: auth.AccountID,
-		Key:       fileKey,
-		URL:       url,
-		Size:      int64(len(b)),
-		Uploaded:  time.Now(),
+	respond(w, http.StatusOK, savedFile)
+}
+
+func (ex *extras) sudoSendSMS(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	newID, err := backend.DB.AddFile(config.Name, f)
+	var data sms.SMSData
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the cached database config does not include the SMS credentials
+	base, err := backend.DB.FindDatabase(conf.ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	data := new(struct {
-		ID  string `json:"id"`
-		URL string `json:"url"`
-	})
-	data.ID = newID
-	data.URL = url
+	msg, err := sms.Send(backend.DB, base, auth.AccountID, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	respond(w, http.StatusOK, data)
+	respond(w, http.StatusOK, msg)
 }
 
-func (ex *extras) sudoSendSMS(w http.ResponseWriter, r *http.Request) {
-	var data sms.SMSData
+func (ex *extras) sudoSMSConfig(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data model.SMSConfig
 	if err := parseBody(r.Body, &data); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := sms.Send(data); err != nil {
+	if _, err := sms.New(data, ""); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	base, err := backend.DB.FindDatabase(conf.ID)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	respond(w, http.StatusOK, true)
-}
+	current, err := base.GetSMSConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-// ConvertParams is also replicated in the plugin implementation
-type ConvertParams struct {
-	ToPDF    bool   `json:"toPDF"`
-	URL      string `json:"url"`
-	FullPage bool   `json:"fullpage"`
+	// keep the existing webhook token so the provider's callback URL
+	// remains valid
+	if len(data.WebhookToken) == 0 {
+		data.WebhookToken = current.WebhookToken
+	}
+	if len(data.WebhookToken) == 0 {
+		data.WebhookToken = internal.RandStringRunes(32)
+	}
+
+	if err := backend.DB.SetSMSConfig(conf.ID, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := new(struct {
+		CallbackURL string `json:"callbackUrl"`
+	})
+	result.CallbackURL = sms.CallbackURL(conf.ID, data.WebhookToken)
+
+	respond(w, http.StatusOK, result)
 }
 
-func (ex *extras) htmlToX(w http.ResponseWriter, r *http.Request) {
-	config, auth, err := middleware.Extract(r, true)
+func (ex *extras) sudoSMSMessages(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	id := getURLPart(r.URL.Path, 4)
+	if len(id) > 0 {
+		msg, err := backend.DB.GetSMSMessageByID(conf.Name, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, msg)
+		return
+	}
+
+	list, err := backend.DB.ListSMSMessages(conf.Name)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	r.Body.Close()
 
-	var data ConvertParams
-	if err := json.Unmarshal(body, &data); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	respond(w, http.StatusOK, list)
+}
+
+// smsStatus receives the providers' delivery status webhooks on
+// /sms/status/{database id}/{webhook token}
+func (ex *extras) smsStatus(w http.ResponseWriter, r *http.Request) {
+	baseID, token := getURLPart(r.URL.Path, 3), getURLPart(r.URL.Path, 4)
+
+	base, err := backend.DB.FindDatabase(baseID)
+	if err != nil {
+		http.Error(w, "invalid database", http.StatusNotFound)
 		return
 	}
 
-	buf, err := convertToPDF(body)
+	cfg, err := base.GetSMSConfig()
 	if err != nil {
+		ex.log.Error().Err(err).Msg("cannot decrypt SMS config")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	ext := ".png"
-	if data.ToPDF {
-		ext = ".pdf"
+	if len(cfg.WebhookToken) == 0 ||
+		subtle.ConstantTimeCompare([]byte(token), []byte(cfg.WebhookToken)) != 1 {
+		http.Error(w, "invalid webhook token", http.StatusUnauthorized)
+		return
 	}
 
-	fileKey := fmt.Sprintf("%s/%s/%d%s",
-		config.Name,
-		auth.AccountID,
-		time.Now().UnixNano(),
-		ext,
-	)
-
-	ufd := model.UploadFileData{
-		FileKey: fileKey,
-		File:    bytes.NewReader(buf),
+	sender, err := sms.New(cfg, sms.CallbackURL(base.ID, cfg.WebhookToken))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	fileURL, err := backend.Filestore.Save(ufd)
+
+	ds, err := sender.ParseStatus(r)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if len(ds.MessageID) == 0 {
+		http.Error(w, "missing message id", http.StatusBadRequest)
+		return
+	}
+
+	if err := backend.DB.UpdateSMSStatus(base.Name, ds.MessageID, strings.ToLower(ds.Status), ds.Error); err != nil {
+		ex.log.Error().Err(err).Msgf("cannot update SMS status for %s", ds.MessageID)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	f := model.File{
-		AccountID: auth.AccountID,
-		Key:       fileKey,
-		URL:       fileURL,
-		Size:      int64(len(buf)),
-		Uploaded:  time.Now(),
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ex *extras) htmlToX(w http.ResponseWriter, r *http.Request) {
+	config, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data extra.ConvertParams
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	newID, err := backend.DB.AddFile(config.Name, f)
+	buf, err := extra.HTMLToX(data)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	result := new(struct {
-		ID  string `json:"id"`
-		URL string `json:"url"`
-	})
-	result.ID = newID
-	result.URL = fileURL
+	ext := ".png"
+	if data.ToPDF {
+		ext = ".pdf"
+	}
 
-	respond(w, http.StatusOK, data)
-}
+	name := fmt.Sprintf("%d", time.Now().UnixNano())
 
-func convertToPDF(body []byte) ([]byte, error) {
-	ppath := path.Join(config.Current.PluginsPath, "topdf.so")
-	p, err := plugin.Open(ppath)
+	fileSvc := backend.Storage(auth, config)
+	savedFile, err := fileSvc.Save(name+ext, name, bytes.NewReader(buf), int64(len(buf)))
 	if err != nil {
-		return nil, err
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	fn, err := p.Lookup("Do")
+	respond(w, http.StatusOK, savedFile)
+}
+
+func (ex *extras) renderPDF(w http.ResponseWriter, r *http.Request) {
+	config, auth, err := middleware.Extract(r, true)
 	if err != nil {
-		return nil, err
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	f, ok := fn.(func(data []byte) ([]byte, error))
-	if !ok {
-		return nil, fmt.Errorf("unable to cast ToPDF to func([]byte) ([]byte, error)")
+	var data struct {
+		Name     string `json:"name"`
+		Template string `json:"template"`
+		Data     any    `json:"data"`
+	}
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if len(data.Template) == 0 {
+		http.Error(w, "template is required", http.StatusBadRequest)
+		return
+	}
+
+	fileSvc := backend.Storage(auth, config)
+	savedFile, err := fileSvc.GeneratePDF(data.Name, data.Template, data.Data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	return f(body)
+	respond(w, http.StatusOK, savedFile)
 }
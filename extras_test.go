@@ -2,6 +2,7 @@ package staticbackend
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -12,8 +13,10 @@ import (
 
 	"github.com/staticbackendhq/core/backend"
 	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/extra"
 	"github.com/staticbackendhq/core/logger"
 	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
 	"github.com/staticbackendhq/core/sms"
 )
 
@@ -88,15 +91,22 @@ func TestSudoSendSMS(t *testing.T) {
 		t.Skip("missing Twilio AccountSID and/or AuthToken")
 	}
 
-	to := config.Current.TwilioTestCellNumber
-	from := config.Current.TwilioNumber
+	cfg := model.SMSConfig{
+		Provider:   sms.SMSProviderTwilio,
+		AccountID:  aID,
+		AuthToken:  twiToken,
+		FromNumber: config.Current.TwilioNumber,
+	}
+
+	cfgResp := dbReq(t, extexec.sudoSMSConfig, "POST", "/extra/sms/config", cfg, true)
+	defer cfgResp.Body.Close()
+	if cfgResp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, cfgResp))
+	}
 
 	data := sms.SMSData{
-		AccountSID: aID,
-		AuthToken:  twiToken,
-		ToNumber:   to,
-		FromNumber: from,
-		Body:       "from unit test of StaticBackend",
+		ToNumber: config.Current.TwilioTestCellNumber,
+		Body:     "from unit test of StaticBackend",
 	}
 
 	resp := dbReq(t, extexec.sudoSendSMS, "POST", "/extra/sms", data, true)
@@ -113,11 +123,85 @@ func TestSudoSendSMS(t *testing.T) {
 	}
 }
 
+func TestSMSDeliveryStatus(t *testing.T) {
+	cfg := model.SMSConfig{
+		Provider:   sms.SMSProviderDev,
+		FromNumber: "+15555555555",
+	}
+
+	cfgResp := dbReq(t, extexec.sudoSMSConfig, "POST", "/extra/sms/config", cfg, true)
+	defer cfgResp.Body.Close()
+	if cfgResp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, cfgResp))
+	}
+
+	base, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := base.GetSMSConfig()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(saved.WebhookToken) == 0 {
+		t.Fatal("expected a webhook token to be generated")
+	}
+
+	data := sms.SMSData{ToNumber: "+15555555556", Body: "unit test"}
+
+	resp := dbReq(t, extexec.sudoSendSMS, "POST", "/extra/sms", data, true)
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var msg model.SMSMessage
+	if err := parseBody(resp.Body, &msg); err != nil {
+		t.Fatal(err)
+	} else if msg.Status != sms.StatusQueued || msg.FromNumber != cfg.FromNumber {
+		t.Errorf("unexpected message %v", msg)
+	}
+
+	webhook := func(token string) int {
+		ds := sms.DeliveryStatus{MessageID: msg.MessageID, Status: "delivered"}
+		b, err := json.Marshal(ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest("POST", "/sms/status/"+pubKey+"/"+token, bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		extexec.smsStatus(w, req)
+		return w.Code
+	}
+
+	if code := webhook("invalid"); code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for an invalid token got %d", code)
+	}
+
+	if code := webhook(saved.WebhookToken); code > 299 {
+		t.Fatalf("expected status 204 got %d", code)
+	}
+
+	msgResp := dbReq(t, extexec.sudoSMSMessages, "GET", "/extra/sms/messages/"+msg.ID, nil, true)
+	defer msgResp.Body.Close()
+	if msgResp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, msgResp))
+	}
+
+	var check model.SMSMessage
+	if err := parseBody(msgResp.Body, &check); err != nil {
+		t.Fatal(err)
+	} else if check.Status != sms.StatusDelivered {
+		t.Errorf("expected status to be delivered got %s", check.Status)
+	}
+}
+
 func TestHtmlToPDF(t *testing.T) {
 	// TODO: this is intermitant and when it failes it's with that
 	// error line:128: context deadline exceeded
 
-	data := ConvertParams{
+	data := extra.ConvertParams{
 		ToPDF: true,
 		URL:   "https://staticbackend.dev",
 	}
@@ -142,7 +226,7 @@ func TestHtmlToPNG(t *testing.T) {
 	//
 	// we need to determine why it's doing this and remove the Skip
 
-	data := ConvertParams{
+	data := extra.ConvertParams{
 		ToPDF:    false,
 		URL:      "https://staticbackend.dev",
 		FullPage: true,
@@ -161,3 +245,42 @@ func TestHtmlToPNG(t *testing.T) {
 		t.Errorf("expected status 200 got %s", resp.Status)
 	}
 }
+
+func TestRenderPDF(t *testing.T) {
+	data := new(struct {
+		Name     string `json:"name"`
+		Template string `json:"template"`
+		Data     any    `json:"data"`
+	})
+	data.Name = "invoice"
+	data.Template = `<h1>Invoice {{.number}}</h1>
+	<table border="1">
+		<tr><th>Item</th><th>Price</th></tr>
+		{{range .items}}<tr><td>{{.name}}</td><td align="right">{{.price}}</td></tr>{{end}}
+	</table>`
+	data.Data = map[string]any{
+		"number": 42,
+		"items": []map[string]any{
+			{"name": "Pro plan", "price": "$29.00"},
+		},
+	}
+
+	resp := dbReq(t, extexec.renderPDF, "POST", "/extra/pdf", data)
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Fatalf("expected status 200 got %s, %s", resp.Status, string(b))
+	}
+
+	var sf backend.SavedFile
+	if err := parseBody(resp.Body, &sf); err != nil {
+		t.Fatal(err)
+	} else if len(sf.ID) == 0 || len(sf.Checksum) == 0 {
+		t.Errorf("expected a saved file got %v", sf)
+	}
+}
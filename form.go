@@ -1,11 +1,31 @@
 package staticbackend
 
 import (
+	"errors"
+	"fmt"
+	"html"
+	"mime/multipart"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// formMaxPerHour is the default maximum submissions per IP per hour
+	formMaxPerHour = 10
+	// formSpamThreshold is the score from which a submission is flagged as spam
+	formSpamThreshold = 5
 )
 
 func submitForm(w http.ResponseWriter, r *http.Request) {
@@ -17,28 +37,103 @@ func submitForm(w http.ResponseWriter, r *http.Request) {
 
 	form := getURLPart(r.URL.Path, 2)
 
-	doc := make(map[string]interface{})
-
-	//TODO: Why forms would need multiplart/form-data
-	// There's no file upload available via form
-	/*if err := r.ParseMultipartForm(32 << 20); err != nil {
+	def, err := backend.DB.GetFormDefinition(conf.Name, form)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
-	}*/
+	}
 
-	if err := r.ParseForm(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	reject := func(msg string, status int) {
+		if len(def.ErrorURL) > 0 {
+			http.Redirect(w, r, addQueryParam(def.ErrorURL, "error", msg), http.StatusSeeOther)
+			return
+		}
+		http.Error(w, msg, status)
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			reject(err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if err := r.ParseForm(); err != nil {
+		reject(err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// if there's something in the _hp_ field, it's a bot
 	if len(r.Form.Get("_hp_")) > 0 {
-		http.Error(w, "invalid form field present", http.StatusBadRequest)
+		reject("invalid form field present", http.StatusBadRequest)
+		return
+	}
+
+	maxPerHour := def.MaxPerHour
+	if maxPerHour <= 0 {
+		maxPerHour = formMaxPerHour
+	}
+
+	// submissions per IP for the current hour
+	key := fmt.Sprintf("%s_form_%s_%s_%s", conf.Name, form, internal.ClientIP(r), time.Now().Format("2006010215"))
+	submissions, err := backend.Cache.Inc(key, 1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if submissions > int64(maxPerHour) {
+		reject("too many submissions, please try again later", http.StatusTooManyRequests)
 		return
 	}
 
-	for k, v := range r.Form {
-		doc[k] = strings.Join(v, ", ")
+	doc := make(map[string]interface{})
+
+	if len(def.ID) == 0 {
+		for k, v := range r.Form {
+			doc[k] = strings.Join(v, ", ")
+		}
+	} else {
+		doc, err = validateFormSubmission(def, r)
+		if err != nil {
+			reject(err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// files and events are owned by the root user since forms are public
+	root, err := backend.DB.GetRootForBase(conf.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auth := model.Auth{
+		AccountID: root.AccountID,
+		UserID:    root.ID,
+		Email:     root.Email,
+		Role:      root.Role,
+		Token:     root.Token,
+	}
+
+	for _, field := range def.Fields {
+		if field.Type != model.FormFieldFile || r.MultipartForm == nil {
+			continue
+		}
+
+		files := r.MultipartForm.File[field.Name]
+		if len(files) == 0 {
+			continue
+		}
+
+		sf, err := saveFormFile(auth, conf, files[0])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		doc[field.Name] = sf.URL
+	}
+
+	isSpam := spamScore(doc, submissions) >= formSpamThreshold
+	if isSpam {
+		doc["_spam_"] = true
 	}
 
 	if err := backend.DB.AddFormSubmission(conf.Name, form, doc); err != nil {
@@ -46,9 +141,195 @@ func submitForm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !isSpam {
+		backend.Cache.PublishDocument(auth, conf.Name, "form-"+form, model.MsgTypeFormSubmit, doc)
+
+		if len(def.NotifyEmail) > 0 {
+			go notifyFormSubmission(conf.Name, def, doc)
+		}
+	}
+
+	if len(def.RedirectURL) > 0 {
+		http.Redirect(w, r, def.RedirectURL, http.StatusSeeOther)
+		return
+	}
+
 	respond(w, http.StatusOK, true)
 }
 
+// validateFormSubmission validates the posted values against the form
+// definition and returns the document to save. Fields not in the definition
+// are ignored.
+func validateFormSubmission(def model.FormDefinition, r *http.Request) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+
+	for _, field := range def.Fields {
+		if field.Type == model.FormFieldFile {
+			var files []*multipart.FileHeader
+			if r.MultipartForm != nil {
+				files = r.MultipartForm.File[field.Name]
+			}
+
+			if len(files) == 0 {
+				if field.Required {
+					return nil, fmt.Errorf("%s is required", field.Name)
+				}
+				continue
+			}
+
+			if field.MaxLength > 0 && files[0].Size > int64(field.MaxLength) {
+				return nil, fmt.Errorf("%s exceeds the maximum size of %d bytes", field.Name, field.MaxLength)
+			}
+			continue
+		}
+
+		value := strings.TrimSpace(strings.Join(r.Form[field.Name], ", "))
+		if len(value) == 0 {
+			if field.Required {
+				return nil, fmt.Errorf("%s is required", field.Name)
+			}
+
+			// unchecked checkboxes are not posted
+			if field.Type == model.FormFieldBool {
+				doc[field.Name] = false
+			} else {
+				doc[field.Name] = ""
+			}
+			continue
+		}
+
+		if field.MaxLength > 0 && utf8.RuneCountInString(value) > field.MaxLength {
+			return nil, fmt.Errorf("%s exceeds the maximum length of %d", field.Name, field.MaxLength)
+		}
+
+		v, err := parseFormValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is invalid: %v", field.Name, err)
+		}
+
+		doc[field.Name] = v
+	}
+
+	return doc, nil
+}
+
+func parseFormValue(typ, value string) (any, error) {
+	switch typ {
+	case "", model.FormFieldText:
+		return value, nil
+	case model.FormFieldEmail:
+		addr, err := mail.ParseAddress(value)
+		if err != nil {
+			return nil, errors.New("invalid email")
+		}
+		return addr.Address, nil
+	case model.FormFieldNumber:
+		return strconv.ParseFloat(value, 64)
+	case model.FormFieldURL:
+		u, err := url.ParseRequestURI(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, errors.New("invalid URL")
+		}
+		return value, nil
+	case model.FormFieldDate:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return nil, errors.New("expected a date in YYYY-MM-DD format")
+		}
+		return value, nil
+	case model.FormFieldBool:
+		if value == "on" {
+			return true, nil
+		}
+		return strconv.ParseBool(value)
+	}
+	return nil, fmt.Errorf("unsupported field type: %s", typ)
+}
+
+func saveFormFile(auth model.Auth, conf model.DatabaseConfig, fh *multipart.FileHeader) (sf backend.SavedFile, err error) {
+	file, err := fh.Open()
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	return backend.Storage(auth, conf).Save(fh.Filename, "", file, fh.Size)
+}
+
+// spamScore returns a naive spam score for a submission, links, markup and
+// repeated submissions from the same IP raise the score.
+func spamScore(doc map[string]interface{}, submissions int64) int {
+	score := 0
+	if submissions > 3 {
+		score += int(submissions - 3)
+	}
+
+	for _, v := range doc {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		s = strings.ToLower(s)
+
+		links := strings.Count(s, "http://") + strings.Count(s, "https://")
+		if links > 2 {
+			score += links - 2
+		}
+
+		if strings.Contains(s, "[url=") || strings.Contains(s, "<a ") {
+			score += 3
+		}
+	}
+	return score
+}
+
+func notifyFormSubmission(dbName string, def model.FormDefinition, doc map[string]interface{}) {
+	data := email.SendMailData{
+		From:     backend.Config.FromEmail,
+		FromName: backend.Config.FromName,
+		To:       def.NotifyEmail,
+		Subject:  fmt.Sprintf("New submission for form %s", def.Name),
+	}
+
+	if len(def.EmailTemplate) > 0 {
+		data.Template = def.EmailTemplate
+		data.Data = doc
+	} else {
+		var keys []string
+		for k := range doc {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var sb strings.Builder
+		sb.WriteString("<table>")
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "<tr><th>%s</th><td>%s</td></tr>", html.EscapeString(k), html.EscapeString(fmt.Sprintf("%v", doc[k])))
+		}
+		sb.WriteString("</table>")
+
+		data.Body = sb.String()
+	}
+
+	outbox := email.Outbox{
+		Mailer:  backend.Emailer,
+		DB:      backend.DB,
+		Storage: backend.Filestore,
+	}
+
+	if _, err := outbox.Send(dbName, data); err != nil {
+		backend.Log.Error().Err(err).Msgf("error sending form %s notification", def.Name)
+	}
+}
+
+func addQueryParam(u, key, value string) string {
+	sep := "?"
+	if strings.Contains(u, "?") {
+		sep = "&"
+	}
+	return u + sep + key + "=" + url.QueryEscape(value)
+}
+
 func listForm(w http.ResponseWriter, r *http.Request) {
 	conf, _, err := middleware.Extract(r, true)
 	if err != nil {
@@ -66,3 +347,106 @@ func listForm(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, http.StatusOK, results)
 }
+
+// sudoFormDefinitions lists and saves the form definitions on /sudo/forms
+// and gets or deletes one on /sudo/forms/{name}
+func sudoFormDefinitions(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := getURLPart(r.URL.Path, 3)
+
+	if r.Method == http.MethodPost {
+		var def model.FormDefinition
+		if err := parseBody(r.Body, &def); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(name) > 0 {
+			def.Name = name
+		}
+
+		if err := validateFormDefinition(def); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := backend.DB.SaveFormDefinition(conf.Name, def); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+		return
+	} else if r.Method == http.MethodDelete {
+		if err := backend.DB.DeleteFormDefinition(conf.Name, name); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+		return
+	}
+
+	if len(name) > 0 {
+		def, err := backend.DB.GetFormDefinition(conf.Name, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		} else if len(def.ID) == 0 {
+			http.Error(w, "form definition not found", http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, def)
+		return
+	}
+
+	list, err := backend.DB.ListFormDefinitions(conf.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, list)
+}
+
+func validateFormDefinition(def model.FormDefinition) error {
+	if len(strings.TrimSpace(def.Name)) == 0 {
+		return errors.New("name is required")
+	} else if strings.Contains(def.Name, "/") {
+		return errors.New("name cannot contain /")
+	} else if len(def.Fields) == 0 {
+		return errors.New("at least one field is required")
+	}
+
+	for _, field := range def.Fields {
+		if len(field.Name) == 0 {
+			return errors.New("field name is required")
+		}
+
+		switch field.Type {
+		case "",
+			model.FormFieldText,
+			model.FormFieldEmail,
+			model.FormFieldNumber,
+			model.FormFieldURL,
+			model.FormFieldDate,
+			model.FormFieldBool,
+			model.FormFieldFile:
+		default:
+			return fmt.Errorf("unsupported field type: %s", field.Type)
+		}
+	}
+
+	if len(def.NotifyEmail) > 0 {
+		if _, err := mail.ParseAddress(def.NotifyEmail); err != nil {
+			return errors.New("invalid notify email")
+		}
+	}
+	return nil
+}
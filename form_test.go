@@ -1,8 +1,19 @@
 package staticbackend
 
 import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
 )
 
 func TestFormSubmission(t *testing.T) {
@@ -33,3 +44,214 @@ func TestFormSubmission(t *testing.T) {
 		t.Errorf("expected email to be unit@test.com got %v", results[0]["email"])
 	}
 }
+
+func postMultipartForm(t *testing.T, path string, fields map[string]string, filename string, content []byte) *http.Response {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for k, v := range fields {
+		if err := writer.WriteField(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(filename) > 0 {
+		part, err := writer.CreateFormFile("attachment", filename)
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := part.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", path, &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("SB-PUBLIC-KEY", pubKey)
+
+	w := httptest.NewRecorder()
+	h := middleware.Chain(
+		http.HandlerFunc(submitForm),
+		middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
+	)
+	h.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func TestFormDefinitionSubmission(t *testing.T) {
+	def := model.FormDefinition{
+		Name: "contact",
+		Fields: []model.FormField{
+			{Name: "email", Type: model.FormFieldEmail, Required: true},
+			{Name: "message", MaxLength: 20},
+			{Name: "newsletter", Type: model.FormFieldBool},
+			{Name: "attachment", Type: model.FormFieldFile},
+		},
+		RedirectURL: "https://test.com/thanks",
+		ErrorURL:    "https://test.com/error",
+	}
+
+	resp := dbReq(t, sudoFormDefinitions, "POST", "/sudo/forms", def, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	// invalid field types are rejected
+	invalid := def
+	invalid.Fields = []model.FormField{{Name: "x", Type: "unknown"}}
+	resp = dbReq(t, sudoFormDefinitions, "POST", "/sudo/forms/invalid", invalid, true)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid field type got %d", resp.StatusCode)
+	}
+
+	// missing required field redirects to the error URL
+	resp = postMultipartForm(t, "/postform/contact", map[string]string{"message": "hello"}, "", nil)
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatal(GetResponseBody(t, resp))
+	} else if loc := resp.Header.Get("Location"); !strings.HasPrefix(loc, "https://test.com/error?error=") {
+		t.Errorf("expected redirect to the error URL got %s", loc)
+	}
+
+	// max length
+	fields := map[string]string{"email": "unit@test.com", "message": strings.Repeat("a", 21)}
+	resp = postMultipartForm(t, "/postform/contact", fields, "", nil)
+	if loc := resp.Header.Get("Location"); !strings.Contains(loc, "maximum+length") {
+		t.Errorf("expected a max length error got %s", loc)
+	}
+
+	fields = map[string]string{"email": "unit@test.com", "message": "hello", "other": "ignored"}
+	resp = postMultipartForm(t, "/postform/contact", fields, "cv.txt", []byte("my cv"))
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatal(GetResponseBody(t, resp))
+	} else if loc := resp.Header.Get("Location"); loc != def.RedirectURL {
+		t.Errorf("expected redirect to %s got %s", def.RedirectURL, loc)
+	}
+
+	resp = dbReq(t, listForm, "GET", "/form?name=contact", nil, true)
+	defer resp.Body.Close()
+
+	var results []map[string]interface{}
+	if err := parseBody(resp.Body, &results); err != nil {
+		t.Fatal(err)
+	} else if len(results) != 1 {
+		t.Fatalf("expected 1 submission got %d", len(results))
+	}
+
+	sub := results[0]
+	if sub["email"] != "unit@test.com" {
+		t.Errorf("expected email to be unit@test.com got %v", sub["email"])
+	} else if sub["newsletter"] != false {
+		t.Errorf("expected newsletter to be false got %v", sub["newsletter"])
+	} else if _, ok := sub["other"]; ok {
+		t.Errorf("expected undefined fields to be ignored got %v", sub)
+	} else if u, ok := sub["attachment"].(string); !ok || !strings.HasSuffix(u, ".txt") {
+		t.Errorf("expected attachment to be a file URL got %v", sub["attachment"])
+	}
+}
+
+func TestFormRateLimitIgnoresSpoofedIP(t *testing.T) {
+	def := model.FormDefinition{
+		Name:       "ratelimited",
+		Fields:     []model.FormField{{Name: "email", Type: model.FormFieldEmail}},
+		MaxPerHour: 2,
+	}
+
+	if resp := dbReq(t, sudoFormDefinitions, "POST", "/sudo/forms", def, true); resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	submit := func(i int) *http.Response {
+		val := url.Values{}
+		val.Add("email", "unit@test.com")
+
+		req := httptest.NewRequest("POST", "/postform/ratelimited", strings.NewReader(val.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("SB-PUBLIC-KEY", pubKey)
+		req.RemoteAddr = "198.51.100.30:1234"
+		// a new spoofed IP for each submission
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+
+		w := httptest.NewRecorder()
+		middleware.Chain(
+			http.HandlerFunc(submitForm),
+			middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
+		).ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	for i := 0; i < def.MaxPerHour; i++ {
+		if resp := submit(i); resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+	}
+
+	if resp := submit(def.MaxPerHour); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status 429 got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestFormSubmissionTriggersFunction(t *testing.T) {
+	code := `
+	function handle(channel, type, data) {
+		if (type != "form_submit") return;
+
+		const res = create("formtrigger", {email: data.email});
+		if (!res.ok) {
+			log("ERROR: " + res.content);
+			return;
+		}
+	}
+	`
+
+	data := model.ExecData{
+		FunctionName: "fn-form-trigger",
+		Code:         code,
+		TriggerTopic: "form-signup",
+	}
+	addResp := dbReq(t, funexec.add, "POST", "/", data, true)
+	defer addResp.Body.Close()
+	if addResp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, addResp))
+	}
+
+	val := url.Values{}
+	val.Add("email", "trigger@test.com")
+
+	resp := dbReq(t, submitForm, "POST", "/postform/signup", val, false, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	// give sometimes for the event to propagate
+	time.Sleep(650 * time.Millisecond)
+
+	resp = dbReq(t, db.list, "GET", "/db/formtrigger", nil)
+	defer resp.Body.Close()
+
+	var docs model.PagedResult
+	if err := parseBody(resp.Body, &docs); err != nil {
+		t.Fatal(err)
+	} else if len(docs.Results) != 1 {
+		t.Errorf("expected the function to create 1 document got %d", len(docs.Results))
+	}
+}
+
+func TestFormSpamScore(t *testing.T) {
+	doc := map[string]interface{}{"message": "hello"}
+	if score := spamScore(doc, 1); score != 0 {
+		t.Errorf("expected score to be 0 got %d", score)
+	}
+
+	doc["message"] = `buy now <a href="http://a.com">a</a> http://b.com http://c.com https://d.com`
+	if score := spamScore(doc, 1); score < formSpamThreshold {
+		t.Errorf("expected score to be at least %d got %d", formSpamThreshold, score)
+	}
+
+	if score := spamScore(map[string]interface{}{}, 9); score < formSpamThreshold {
+		t.Errorf("expected repeated submissions to be flagged got %d", score)
+	}
+}
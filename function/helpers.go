@@ -44,4 +44,14 @@ type JSSendMailArg struct {
 	Subject  string `json:"subject"`
 	HTMLBody string `json:"htmlBody"`
 	TextBody string `json:"textBody"`
+	// Template is the name of a stored email template rendered with Data
+	Template    string         `json:"template"`
+	Data        map[string]any `json:"data"`
+	Attachments []string       `json:"attachments"`
+}
+
+type JSSendSMSArg struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Body string `json:"body"`
 }
@@ -0,0 +1,163 @@
+package function
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/logger"
+)
+
+const (
+	// LeaderKey holds the ID of the instance running the task scheduler
+	LeaderKey = "sb:scheduler:leader"
+
+	// DefaultLeaderTTL is how long a leader keeps its lease without renewing
+	// it, the lease is renewed every third of it
+	DefaultLeaderTTL = 15 * time.Second
+)
+
+// Elector elects one leader among the instances sharing a Volatilizer. The
+// leader holds a lease it renews, when it stops renewing another instance
+// takes over once the lease expires.
+type Elector struct {
+	Volatile cache.Volatilizer
+	Log      *logger.Logger
+	// ID identifies this instance
+	ID  string
+	TTL time.Duration
+
+	// OnElected and OnDemoted are called when this instance gains and loses
+	// the leadership
+	OnElected func()
+	OnDemoted func()
+
+	mx          sync.RWMutex
+	leader      bool
+	lastRenewal time.Time
+	stop        chan struct{}
+}
+
+// NewElector returns an Elector using the hostname and a random suffix as
+// instance ID and the DefaultLeaderTTL
+func NewElector(volatile cache.Volatilizer, log *logger.Logger) *Elector {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "sb"
+	}
+
+	return &Elector{
+		Volatile: volatile,
+		Log:      log,
+		ID:       hostname + "-" + internal.RandStringRunes(6),
+		TTL:      DefaultLeaderTTL,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Run campaigns for the leadership until Stop is called
+func (e *Elector) Run() {
+	e.campaign()
+
+	ticker := time.NewTicker(e.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.campaign()
+		case <-e.stop:
+			if e.IsLeader() {
+				e.demote()
+
+				if err := e.Volatile.ReleaseLock(LeaderKey, e.ID); err != nil {
+					e.Log.Warn().Err(err).Msg("error releasing the scheduler leadership")
+				}
+			}
+			return
+		}
+	}
+}
+
+// Stop resigns the leadership and stops campaigning
+func (e *Elector) Stop() {
+	close(e.stop)
+}
+
+// IsLeader returns true if this instance holds the leadership
+func (e *Elector) IsLeader() bool {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+
+	return e.leader
+}
+
+// Leader returns the ID of the current leader, it is empty when there is
+// no leader
+func (e *Elector) Leader() string {
+	id, err := e.Volatile.Get(LeaderKey)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+func (e *Elector) campaign() {
+	if e.IsLeader() {
+		ok, err := e.Volatile.RenewLock(LeaderKey, e.ID, e.TTL)
+		if err != nil {
+			e.Log.Warn().Err(err).Msg("error renewing the scheduler leadership")
+
+			// another instance might have taken over once the lease expired
+			e.mx.RLock()
+			expired := time.Since(e.lastRenewal) >= e.TTL
+			e.mx.RUnlock()
+
+			if expired {
+				e.demote()
+			}
+			return
+		} else if !ok {
+			e.demote()
+			return
+		}
+
+		e.mx.Lock()
+		e.lastRenewal = time.Now()
+		e.mx.Unlock()
+		return
+	}
+
+	ok, err := e.Volatile.AcquireLock(LeaderKey, e.ID, e.TTL)
+	if err != nil {
+		e.Log.Warn().Err(err).Msg("error acquiring the scheduler leadership")
+		return
+	} else if !ok {
+		return
+	}
+
+	e.mx.Lock()
+	e.leader = true
+	e.lastRenewal = time.Now()
+	e.mx.Unlock()
+
+	e.Log.Info().Msgf("instance %s elected as the scheduler leader", e.ID)
+
+	if e.OnElected != nil {
+		e.OnElected()
+	}
+}
+
+func (e *Elector) demote() {
+	e.mx.Lock()
+	e.leader = false
+	e.mx.Unlock()
+
+	e.Log.Warn().Msgf("instance %s lost the scheduler leadership", e.ID)
+
+	if e.OnDemoted != nil {
+		e.OnDemoted()
+	}
+}
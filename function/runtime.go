@@ -1,10 +1,13 @@
 package function
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strings"
 	"time"
@@ -12,11 +15,19 @@ import (
 	"github.com/staticbackendhq/core/cache"
 	"github.com/staticbackendhq/core/database"
 	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/extra"
+	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/metrics"
 	"github.com/staticbackendhq/core/model"
 	"github.com/staticbackendhq/core/search"
+	"github.com/staticbackendhq/core/sms"
+	"github.com/staticbackendhq/core/storage"
+	"github.com/staticbackendhq/core/tracing"
 
 	"github.com/dop251/goja"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 type ExecutionEnvironment struct {
@@ -26,10 +37,16 @@ type ExecutionEnvironment struct {
 	Volatile  cache.Volatilizer
 	Email     email.Mailer
 	Search    *search.Search
+	Storage   storage.Storer
 	Data      model.ExecData
 
 	CurrentRun model.ExecHistory
 	Log        *logger.Logger
+
+	// Context parents the tracing spans of the execution, the request's
+	// context is used when executing for an HTTP request
+	Context context.Context
+	ctx     context.Context
 }
 
 type Result struct {
@@ -37,7 +54,28 @@ type Result struct {
 	Content interface{} `json:"content"`
 }
 
-func (env *ExecutionEnvironment) Execute(data interface{}) error {
+func (env *ExecutionEnvironment) Execute(data interface{}) (err error) {
+	defer func(start time.Time) {
+		metrics.FunctionExecuted(env.BaseName, start, err)
+	}(time.Now())
+
+	ctx := env.Context
+	if r, ok := data.(*http.Request); ok {
+		ctx = r.Context()
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, span := tracing.Start(ctx, "function.Execute",
+		attribute.String("function.name", env.Data.FunctionName),
+		attribute.String("db.name", env.BaseName),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	env.ctx = ctx
+	env.DataStore = tracing.BindPersister(ctx, env.DataStore)
+	env.Volatile = tracing.BindVolatilizer(ctx, env.Volatile)
+
 	vm := goja.New()
 	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
 
@@ -56,6 +94,12 @@ func (env *ExecutionEnvironment) Execute(data interface{}) error {
 	if err := env.addSendMail(vm); err != nil {
 		return err
 	}
+	if err := env.addSendSMS(vm); err != nil {
+		return err
+	}
+	if err := env.addRenderPDF(vm); err != nil {
+		return err
+	}
 
 	if _, err := vm.RunString(env.Data.Code); err != nil {
 		return err
@@ -80,7 +124,7 @@ func (env *ExecutionEnvironment) Execute(data interface{}) error {
 	env.CurrentRun.Output = append(env.CurrentRun.Output, "Function started")
 
 	_, err = handler(goja.Undefined(), args...)
-	go env.complete(err)
+	env.complete(err)
 	if err != nil {
 		return fmt.Errorf("error executing your function: %v", err)
 	}
@@ -88,6 +132,36 @@ func (env *ExecutionEnvironment) Execute(data interface{}) error {
 	return nil
 }
 
+// traced runs a helper binding in its own span, the database and cache calls
+// it makes are children of this span
+func (env *ExecutionEnvironment) traced(name string, fn func(goja.FunctionCall) goja.Value) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parent := env.ctx
+		if parent == nil {
+			parent = context.Background()
+		}
+
+		ctx, span := tracing.Start(parent, "function."+name)
+		defer span.End()
+
+		db, volatile := env.DataStore, env.Volatile
+		env.ctx = ctx
+		env.DataStore = tracing.BindPersister(ctx, db)
+		env.Volatile = tracing.BindVolatilizer(ctx, volatile)
+		defer func() {
+			env.ctx, env.DataStore, env.Volatile = parent, db, volatile
+		}()
+
+		v := fn(call)
+		if v != nil {
+			if res, ok := v.Export().(Result); ok && !res.OK {
+				span.SetStatus(codes.Error, fmt.Sprint(res.Content))
+			}
+		}
+		return v
+	}
+}
+
 func (env *ExecutionEnvironment) prepareArguments(vm *goja.Runtime, data interface{}) ([]goja.Value, error) {
 	var args []goja.Value
 
@@ -157,7 +231,7 @@ func (env *ExecutionEnvironment) addHelpers(vm *goja.Runtime) error {
 	if err != nil {
 		return err
 	}
-	err = vm.Set("fetch", func(call goja.FunctionCall) goja.Value {
+	err = vm.Set("fetch", env.traced("fetch", func(call goja.FunctionCall) goja.Value {
 		url := ""
 		fetchOptions := NewJSFetcthOptionArg()
 		if len(call.Arguments) == 0 {
@@ -174,23 +248,28 @@ func (env *ExecutionEnvironment) addHelpers(vm *goja.Runtime) error {
 			return vm.ToValue(Result{Content: "the url should not be blank"})
 		}
 
+		ctx := env.ctx
+
 		responseChan := make(chan interface{})
 		go func() {
-			client := http.Client{Timeout: time.Duration(30) * time.Second}
+			client := http.Client{
+				Timeout:   time.Duration(30) * time.Second,
+				Transport: tracing.Transport(nil),
+			}
 			var request *http.Request
 			var err error
 			bodyReader := strings.NewReader(fetchOptions.Body)
 			switch fetchOptions.Method {
 			case "GET":
-				request, err = http.NewRequest(http.MethodGet, url, nil)
+				request, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 			case "POST":
-				request, err = http.NewRequest(http.MethodPost, url, bodyReader)
+				request, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bodyReader)
 			case "PUT":
-				request, err = http.NewRequest(http.MethodPut, url, bodyReader)
+				request, err = http.NewRequestWithContext(ctx, http.MethodPut, url, bodyReader)
 			case "DELETE":
-				request, err = http.NewRequest(http.MethodDelete, url, bodyReader)
+				request, err = http.NewRequestWithContext(ctx, http.MethodDelete, url, bodyReader)
 			case "PATCH":
-				request, err = http.NewRequest(http.MethodPatch, url, bodyReader)
+				request, err = http.NewRequestWithContext(ctx, http.MethodPatch, url, bodyReader)
 			}
 			if err != nil {
 				responseChan <- err
@@ -221,6 +300,27 @@ func (env *ExecutionEnvironment) addHelpers(vm *goja.Runtime) error {
 			return vm.ToValue(Result{OK: true, Content: HTTPResponse{Status: response.StatusCode, Body: string(bodyBytes)}})
 		}
 		return goja.Undefined()
+	}))
+	if err != nil {
+		return err
+	}
+
+	// inGroup and hasPermission check the groups and roles of the user
+	// executing the function
+	err = vm.Set("inGroup", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return vm.ToValue(false)
+		}
+		return vm.ToValue(env.Auth.InGroup(call.Argument(0).String()))
+	})
+	if err != nil {
+		return err
+	}
+	err = vm.Set("hasPermission", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return vm.ToValue(false)
+		}
+		return vm.ToValue(env.Auth.HasPermission(call.Argument(0).String()))
 	})
 	if err != nil {
 		return err
@@ -229,7 +329,7 @@ func (env *ExecutionEnvironment) addHelpers(vm *goja.Runtime) error {
 }
 
 func (env *ExecutionEnvironment) addDatabaseFunctions(vm *goja.Runtime) error {
-	err := vm.Set("create", func(call goja.FunctionCall) goja.Value {
+	err := vm.Set("create", env.traced("create", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) != 2 {
 			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for create(col, doc"})
 		}
@@ -251,12 +351,12 @@ func (env *ExecutionEnvironment) addDatabaseFunctions(vm *goja.Runtime) error {
 			return vm.ToValue(Result{Content: err.Error()})
 		}
 		return vm.ToValue(Result{OK: true, Content: doc})
-	})
+	}))
 	if err != nil {
 		return err
 	}
 
-	err = vm.Set("list", func(call goja.FunctionCall) goja.Value {
+	err = vm.Set("list", env.traced("list", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) < 1 {
 			return vm.ToValue(Result{Content: "argument missmatch: your need at least 1 argument for list(col, [params])"})
 		}
@@ -288,12 +388,12 @@ func (env *ExecutionEnvironment) addDatabaseFunctions(vm *goja.Runtime) error {
 		}
 
 		return vm.ToValue(Result{OK: true, Content: result})
-	})
+	}))
 	if err != nil {
 		return err
 	}
 
-	err = vm.Set("getById", func(call goja.FunctionCall) goja.Value {
+	err = vm.Set("getById", env.traced("getById", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) != 2 {
 			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for get(col, id)"})
 		}
@@ -315,12 +415,12 @@ func (env *ExecutionEnvironment) addDatabaseFunctions(vm *goja.Runtime) error {
 		}
 
 		return vm.ToValue(Result{OK: true, Content: doc})
-	})
+	}))
 	if err != nil {
 		return err
 	}
 
-	err = vm.Set("query", func(call goja.FunctionCall) goja.Value {
+	err = vm.Set("query", env.traced("query", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) < 2 {
 			return vm.ToValue(Result{Content: "argument missmatch: you need at least 2 arguments for query(col, filter, [params])"})
 		}
@@ -366,12 +466,12 @@ func (env *ExecutionEnvironment) addDatabaseFunctions(vm *goja.Runtime) error {
 		}
 
 		return vm.ToValue(Result{OK: true, Content: result})
-	})
+	}))
 	if err != nil {
 		return err
 	}
 
-	err = vm.Set("update", func(call goja.FunctionCall) goja.Value {
+	err = vm.Set("update", env.traced("update", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) != 3 {
 			return vm.ToValue(Result{Content: "argument missmatch: you need 3 arguments for update(col, id, doc)"})
 		}
@@ -399,12 +499,12 @@ func (env *ExecutionEnvironment) addDatabaseFunctions(vm *goja.Runtime) error {
 		}
 
 		return vm.ToValue(Result{OK: true, Content: updated})
-	})
+	}))
 	if err != nil {
 		return err
 	}
 
-	err = vm.Set("del", func(call goja.FunctionCall) goja.Value {
+	err = vm.Set("del", env.traced("del", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) != 2 {
 			return vm.ToValue(Result{Content: "argument missmatch: you need 3 arguments for del(col, id)"})
 		}
@@ -423,7 +523,7 @@ func (env *ExecutionEnvironment) addDatabaseFunctions(vm *goja.Runtime) error {
 		}
 
 		return vm.ToValue(Result{OK: true, Content: deleted})
-	})
+	}))
 	if err != nil {
 		return err
 	}
@@ -444,25 +544,148 @@ func (env *ExecutionEnvironment) addSendMail(vm *goja.Runtime) error {
 		}
 
 		data := email.SendMailData{
-			FromName: "",
-			From:     sma.From,
-			To:       sma.To,
-			ToName:   "",
-			Subject:  sma.Subject,
-			HTMLBody: sma.HTMLBody,
-			TextBody: sma.TextBody,
-			ReplyTo:  "",
-			Body:     "",
+			FromName:    "",
+			From:        sma.From,
+			To:          sma.To,
+			ToName:      "",
+			Subject:     sma.Subject,
+			HTMLBody:    sma.HTMLBody,
+			TextBody:    sma.TextBody,
+			ReplyTo:     "",
+			Body:        "",
+			Template:    sma.Template,
+			Data:        sma.Data,
+			Attachments: sma.Attachments,
+		}
+
+		outbox := email.Outbox{
+			Mailer:  env.Email,
+			DB:      env.DataStore,
+			Storage: env.Storage,
+		}
+
+		entry, err := outbox.Send(env.BaseName, data)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("send mail error: %v", err)})
 		}
+		return vm.ToValue(Result{OK: true, Content: entry})
+	}
+
+	err := vm.Set("sendMail", env.traced("sendMail", smf))
+	if err != nil {
+		return err
+	}
+	return nil
+}
 
-		err := env.Email.Send(data)
+func (env *ExecutionEnvironment) addSendSMS(vm *goja.Runtime) error {
+	err := vm.Set("sendSMS", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need only one arguments(object) for sendSMS"})
+		}
+
+		ssa := JSSendSMSArg{}
+		if err := vm.ExportTo(call.Argument(0), &ssa); err != nil {
+			return vm.ToValue(Result{Content: "argument should be an object"})
+		}
+
+		base, err := env.DataStore.FindDatabaseByName(env.BaseName)
 		if err != nil {
-			return vm.ToValue(Result{Content: fmt.Sprintf("send mail error: %v", err)})
+			return vm.ToValue(Result{Content: fmt.Sprintf("cannot find database: %v", err)})
 		}
-		return vm.ToValue(Result{OK: true})
+
+		data := sms.SMSData{
+			ToNumber:   ssa.To,
+			FromNumber: ssa.From,
+			Body:       ssa.Body,
+		}
+
+		msg, err := sms.Send(env.DataStore, base, env.Auth.AccountID, data)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("send SMS error: %v", err)})
+		}
+		return vm.ToValue(Result{OK: true, Content: msg})
+	})
+	if err != nil {
+		return err
 	}
+	return nil
+}
+
+func (env *ExecutionEnvironment) addRenderPDF(vm *goja.Runtime) error {
+	err := vm.Set("renderPDF", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 2 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need at least 2 arguments for renderPDF(template, data, [name])"})
+		}
+
+		var tmpl, name string
+		if err := vm.ExportTo(call.Argument(0), &tmpl); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		}
+
+		data := call.Argument(1).Export()
+
+		if len(call.Arguments) > 2 {
+			if err := vm.ExportTo(call.Argument(2), &name); err != nil {
+				return vm.ToValue(Result{Content: "the third argument should be a string"})
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := extra.RenderPDF(tmpl, data, &buf); err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error rendering PDF: %v", err)})
+		}
+
+		if len(name) == 0 {
+			name = "document"
+		}
+
+		file := internal.NewHashingReader(bytes.NewReader(buf.Bytes()))
+
+		key := fmt.Sprintf("%s/%s/%s_%s.pdf",
+			env.BaseName,
+			env.Auth.AccountID,
+			internal.CleanUpFileName(name),
+			internal.RandStringRunes(16),
+		)
 
-	err := vm.Set("sendMail", smf)
+		ufd := model.UploadFileData{
+			FileKey:  key,
+			File:     file,
+			Size:     int64(buf.Len()),
+			Mimetype: "application/pdf",
+		}
+		url, err := env.Storage.Save(ufd)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error saving PDF: %v", err)})
+		}
+
+		checksum, err := file.Checksum()
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error computing checksum: %v", err)})
+		}
+
+		f := model.File{
+			AccountID: env.Auth.AccountID,
+			UserID:    env.Auth.UserID,
+			Key:       key,
+			URL:       url,
+			Size:      int64(buf.Len()),
+			Checksum:  checksum,
+			Uploaded:  time.Now(),
+		}
+
+		id, err := env.DataStore.AddFile(env.BaseName, f)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error adding file: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: map[string]interface{}{
+			"id":       id,
+			"url":      url,
+			"checksum": checksum,
+		}})
+	})
 	if err != nil {
 		return err
 	}
@@ -547,18 +770,29 @@ func (env *ExecutionEnvironment) addVolatileFunctions(vm *goja.Runtime) error {
 	}
 
 	err = vm.Set("cacheSet", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) != 2 {
-			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for cacheSet(key, value)"})
+		if len(call.Arguments) < 2 || len(call.Arguments) > 3 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 or 3 arguments for cacheSet(key, value, [ttl seconds])"})
 		}
 
 		var key, value string
+		var ttl int64
 		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
 			return vm.ToValue(Result{Content: "the first argument should be a string"})
 		} else if err := vm.ExportTo(call.Argument(1), &value); err != nil {
 			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		} else if len(call.Arguments) == 3 {
+			if err := vm.ExportTo(call.Argument(2), &ttl); err != nil {
+				return vm.ToValue(Result{Content: "the 3rd argument should be a number"})
+			}
 		}
 
-		if err := env.Volatile.Set(key, value); err != nil {
+		var err error
+		if ttl > 0 {
+			err = env.Volatile.SetEx(key, value, time.Duration(ttl)*time.Second)
+		} else {
+			err = env.Volatile.Set(key, value)
+		}
+		if err != nil {
 			return vm.ToValue(Result{Content: fmt.Sprintf("error while setting cache value: %v", err)})
 		}
 
@@ -568,6 +802,445 @@ func (env *ExecutionEnvironment) addVolatileFunctions(vm *goja.Runtime) error {
 		return err
 	}
 
+	err = vm.Set("cacheSetNX", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 2 || len(call.Arguments) > 3 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 or 3 arguments for cacheSetNX(key, value, [ttl seconds])"})
+		}
+
+		var key, value string
+		var ttl int64
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &value); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		} else if len(call.Arguments) == 3 {
+			if err := vm.ExportTo(call.Argument(2), &ttl); err != nil {
+				return vm.ToValue(Result{Content: "the 3rd argument should be a number"})
+			}
+		}
+
+		ok, err := env.Volatile.SetNX(key, value, time.Duration(ttl)*time.Second)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while setting cache value: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: ok})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheDel", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 1 argument for cacheDel(key)"})
+		}
+
+		var key string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		}
+
+		if err := env.Volatile.Del(key); err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while deleting cache value: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheExists", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 1 argument for cacheExists(key)"})
+		}
+
+		var key string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		}
+
+		ok, err := env.Volatile.Exists(key)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while checking cache key: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: ok})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheExpire", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 2 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for cacheExpire(key, ttl seconds)"})
+		}
+
+		var key string
+		var ttl int64
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &ttl); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a number"})
+		}
+
+		ok, err := env.Volatile.Expire(key, time.Duration(ttl)*time.Second)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while setting cache expiration: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: ok})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheTTL", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 1 argument for cacheTTL(key)"})
+		}
+
+		var key string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		}
+
+		ttl, err := env.Volatile.TTL(key)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while getting cache expiration: %v", err)})
+		}
+
+		// in seconds, 0 means the key never expires
+		return vm.ToValue(Result{OK: true, Content: int64(math.Ceil(ttl.Seconds()))})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheHSet", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 3 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 3 arguments for cacheHSet(key, field, value)"})
+		}
+
+		var key, field, value string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &field); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(2), &value); err != nil {
+			return vm.ToValue(Result{Content: "the 3rd argument should be a string"})
+		}
+
+		if err := env.Volatile.HSet(key, field, value); err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while setting hash field: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheHGet", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 2 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for cacheHGet(key, field)"})
+		}
+
+		var key, field string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &field); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		}
+
+		val, err := env.Volatile.HGet(key, field)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while getting hash field: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: val})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheHDel", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 2 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for cacheHDel(key, field)"})
+		}
+
+		var key, field string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &field); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		}
+
+		if err := env.Volatile.HDel(key, field); err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while deleting hash field: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheHGetAll", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 1 argument for cacheHGetAll(key)"})
+		}
+
+		var key string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		}
+
+		h, err := env.Volatile.HGetAll(key)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while getting hash: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: h})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheZAdd", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 3 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 3 arguments for cacheZAdd(key, member, score)"})
+		}
+
+		var key, member string
+		var score float64
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &member); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(2), &score); err != nil {
+			return vm.ToValue(Result{Content: "the 3rd argument should be a number"})
+		}
+
+		if err := env.Volatile.ZAdd(key, member, score); err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while adding sorted set member: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheZIncrBy", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 3 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 3 arguments for cacheZIncrBy(key, member, n)"})
+		}
+
+		var key, member string
+		var by float64
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &member); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(2), &by); err != nil {
+			return vm.ToValue(Result{Content: "the 3rd argument should be a number"})
+		}
+
+		score, err := env.Volatile.ZIncrBy(key, member, by)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while incrementing sorted set member: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: score})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheZScore", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 2 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for cacheZScore(key, member)"})
+		}
+
+		var key, member string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &member); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		}
+
+		score, err := env.Volatile.ZScore(key, member)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while getting sorted set member: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: score})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheZRem", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 2 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for cacheZRem(key, member)"})
+		}
+
+		var key, member string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &member); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		}
+
+		if err := env.Volatile.ZRem(key, member); err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while removing sorted set member: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("cacheZRange", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 3 || len(call.Arguments) > 4 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 3 or 4 arguments for cacheZRange(key, start, stop, [reverse])"})
+		}
+
+		var key string
+		var start, stop int64
+		var reverse bool
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &start); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a number"})
+		} else if err := vm.ExportTo(call.Argument(2), &stop); err != nil {
+			return vm.ToValue(Result{Content: "the 3rd argument should be a number"})
+		} else if len(call.Arguments) == 4 {
+			if err := vm.ExportTo(call.Argument(3), &reverse); err != nil {
+				return vm.ToValue(Result{Content: "the 4th argument should be a boolean"})
+			}
+		}
+
+		members, err := env.Volatile.ZRange(key, start, stop, reverse)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while getting sorted set range: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: members})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("queueAdd", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 2 || len(call.Arguments) > 3 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 or 3 arguments for queueAdd(key, value, [delay seconds])"})
+		}
+
+		var key, value string
+		var delay int64
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &value); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		} else if len(call.Arguments) == 3 {
+			if err := vm.ExportTo(call.Argument(2), &delay); err != nil {
+				return vm.ToValue(Result{Content: "the 3rd argument should be a number"})
+			}
+		}
+
+		id, err := env.Volatile.Enqueue(key, value, time.Duration(delay)*time.Second)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while adding queue item: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: id})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("queueLease", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 1 || len(call.Arguments) > 3 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 1 to 3 arguments for queueLease(key, [visibility seconds], [max attempts])"})
+		}
+
+		var key string
+		var visibility int64
+		var maxAttempts int
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if len(call.Arguments) > 1 {
+			if err := vm.ExportTo(call.Argument(1), &visibility); err != nil {
+				return vm.ToValue(Result{Content: "the 2nd argument should be a number"})
+			}
+		}
+		if len(call.Arguments) > 2 {
+			if err := vm.ExportTo(call.Argument(2), &maxAttempts); err != nil {
+				return vm.ToValue(Result{Content: "the 3rd argument should be a number"})
+			}
+		}
+
+		it, err := env.Volatile.Lease(key, time.Duration(visibility)*time.Second, maxAttempts)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while leasing queue item: %v", err)})
+		} else if it == nil {
+			// the queue has no available item
+			return vm.ToValue(Result{OK: true, Content: nil})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: it})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("queueAck", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 2 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for queueAck(key, receipt)"})
+		}
+
+		var key, receipt string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		} else if err := vm.ExportTo(call.Argument(1), &receipt); err != nil {
+			return vm.ToValue(Result{Content: "the 2nd argument should be a string"})
+		}
+
+		ok, err := env.Volatile.Ack(key, receipt)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while acknowledging queue item: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: ok})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vm.Set("queueStats", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return vm.ToValue(Result{Content: "argument missmatch: you need 1 argument for queueStats(key)"})
+		}
+
+		var key string
+		if err := vm.ExportTo(call.Argument(0), &key); err != nil {
+			return vm.ToValue(Result{Content: "the first argument should be a string"})
+		}
+
+		stats, err := env.Volatile.QueueStats(key)
+		if err != nil {
+			return vm.ToValue(Result{Content: fmt.Sprintf("error while counting queue items: %v", err)})
+		}
+
+		return vm.ToValue(Result{OK: true, Content: stats})
+	})
+	if err != nil {
+		return err
+	}
+
 	err = vm.Set("inc", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) != 2 {
 			return vm.ToValue(Result{Content: "argument missmatch: you need 2 arguments for inc(key, n)"})
@@ -690,8 +1363,13 @@ func (env *ExecutionEnvironment) complete(err error) {
 		env.CurrentRun.Output = append(env.CurrentRun.Output, err.Error())
 	}
 
-	//TODO: this needs to be regrouped and ran un batch
-	if err := env.DataStore.RanFunction(env.BaseName, env.Data.ID, env.CurrentRun); err != nil {
-		env.Log.Error().Err(err).Msg("error logging function complete")
-	}
+	// the output is complete when Execute returns, the history is saved in
+	// the background
+	run := env.CurrentRun
+	go func() {
+		//TODO: this needs to be regrouped and ran un batch
+		if err := env.DataStore.RanFunction(env.BaseName, env.Data.ID, run); err != nil {
+			env.Log.Error().Err(err).Msg("error logging function complete")
+		}
+	}()
 }
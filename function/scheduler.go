@@ -1,22 +1,31 @@
 package function
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/staticbackendhq/core/cache"
 	"github.com/staticbackendhq/core/database"
 	"github.com/staticbackendhq/core/email"
 	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/metrics"
 	"github.com/staticbackendhq/core/model"
 	"github.com/staticbackendhq/core/search"
+	"github.com/staticbackendhq/core/storage"
+	"github.com/staticbackendhq/core/tracing"
 
 	"github.com/go-co-op/gocron"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 type TaskScheduler struct {
@@ -24,52 +33,492 @@ type TaskScheduler struct {
 	DataStore database.Persister
 	Search    *search.Search
 	Email     email.Mailer
+	Storage   storage.Storer
 	Log       *logger.Logger
+	// Backup runs the backup tasks, the backups are implemented in the
+	// backend package
+	Backup func(task model.Task) error
+	// InstanceID owns the task locks preventing a task from overlapping
+	// itself across instances
+	InstanceID string
+
+	// schedulers holds one scheduler per task timezone, it is nil when the
+	// scheduler is not running on this instance
+	schedulers map[string]*gocron.Scheduler
+	// scheduled holds the scheduled tasks by ID
+	scheduled map[string]model.Task
+	// stop stops the periodic reload of the tasks
+	stop chan struct{}
+	mx   sync.Mutex
+}
+
+const (
+	// taskLockTTL is renewed while a task runs so a task never overlaps itself
+	taskLockTTL = time.Minute
+
+	// maxTaskOutput is the size of the output kept for a task run
+	maxTaskOutput = 16 * 1024
+
+	// taskReloadInterval is how often the leader reloads the tasks in case
+	// it missed a task event
+	taskReloadInterval = 5 * time.Minute
+
+	// ChannelTaskEvent is the system channel notifying the leader that a
+	// task was added, updated or deleted
+	ChannelTaskEvent = "sys-tasks"
+
+	taskEventScheduled = "task-scheduled"
+	taskEventCanceled  = "task-canceled"
+)
+
+// ErrTaskRunning is returned when a task is triggered while its previous run
+// is not completed
+var ErrTaskRunning = errors.New("the previous run of this task is not completed")
+
+// ValidateTask makes sure the task's cron expression, timezone and catch-up
+// policy are valid
+func ValidateTask(task model.Task) error {
+	if _, err := parseSchedule(task); err != nil {
+		return err
+	}
+
+	switch task.CatchUp {
+	case "", model.TaskCatchUpSkip, model.TaskCatchUpOnce, model.TaskCatchUpAll:
+		return nil
+	}
+	return fmt.Errorf("invalid catch-up policy %q", task.CatchUp)
+}
+
+// parseSchedule parses the cron expression the same way the scheduler does,
+// in the task's timezone
+func parseSchedule(task model.Task) (cron.Schedule, error) {
+	loc, err := time.LoadLocation(task.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", task.Timezone, err)
+	}
+
+	// the timezone is set by the scheduler
+	if strings.HasPrefix(task.Interval, "CRON_TZ=") || strings.HasPrefix(task.Interval, "TZ=") {
+		return nil, errors.New("the timezone must be set in the task's timezone")
+	}
+
+	sched, err := cron.ParseStandard(fmt.Sprintf("CRON_TZ=%s %s", loc.String(), task.Interval))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", task.Interval, err)
+	}
+	return sched, nil
+}
+
+// MissedRuns returns the number of runs the task missed between its last run
+// and now, up to model.MaxTaskCatchUp. A task that never ran has not missed
+// any run.
+func MissedRuns(task model.Task, now time.Time) int {
+	if task.LastRun.IsZero() {
+		return 0
+	}
+
+	sched, err := parseSchedule(task)
+	if err != nil {
+		return 0
+	}
 
-	Scheduler *gocron.Scheduler
+	missed := 0
+	for next := sched.Next(task.LastRun); !next.After(now) && missed < model.MaxTaskCatchUp; next = sched.Next(next) {
+		missed++
+	}
+	return missed
 }
 
+// Start loads the tasks and runs them on their schedule until Stop is called,
+// the runs missed while no scheduler was running are caught up according to
+// each task's policy
 func (ts *TaskScheduler) Start() {
 	tasks, err := ts.DataStore.ListTasks()
 	if err != nil {
 		ts.Log.Error().Err(err).Msg("error loading tasks")
 		return
 	}
-	ts.Scheduler = gocron.NewScheduler(time.UTC)
-	ts.Scheduler.TagsUnique()
+
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+
+	if ts.schedulers != nil {
+		return
+	}
+
+	ts.schedulers = make(map[string]*gocron.Scheduler)
+	ts.scheduled = make(map[string]model.Task)
+	ts.stop = make(chan struct{})
 
 	for _, task := range tasks {
-		_, err := ts.Scheduler.Cron(task.Interval).Tag(task.ID).Do(ts.run, task)
-		if err != nil {
-			ts.Log.Error().Err(err).Msgf("error scheduling this task: %s", task.ID)
+		ts.schedule(task)
+
+		go ts.catchUp(task, time.Now())
+	}
+
+	go ts.reloadEvery(taskReloadInterval, ts.stop)
+}
+
+// Stop stops scheduling the tasks, the running tasks complete
+func (ts *TaskScheduler) Stop() {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+
+	if ts.stop != nil {
+		close(ts.stop)
+	}
+
+	for _, s := range ts.schedulers {
+		s.Stop()
+	}
+	ts.schedulers = nil
+	ts.scheduled = nil
+	ts.stop = nil
+}
+
+// Listen applies the task events published by AddOnTheFly and
+// RemoveOnTheFly, they are ignored when the scheduler is not running on this
+// instance
+func (ts *TaskScheduler) Listen() {
+	receiver := make(chan model.Command)
+	close := make(chan bool)
+
+	go ts.Volatile.Subscribe(receiver, "system", ChannelTaskEvent, close)
+
+	for {
+		select {
+		case msg := <-receiver:
+			ts.receivedTaskEvent(msg)
+		case <-close:
+			ts.Log.Info().Msg("task event channel closed")
+			return
 		}
 	}
+}
+
+func (ts *TaskScheduler) receivedTaskEvent(msg model.Command) {
+	var task model.Task
+	if err := json.Unmarshal([]byte(msg.Data), &task); err != nil {
+		ts.Log.Warn().Err(err).Msg("unable to parse the task event")
+		return
+	}
+
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+
+	if ts.schedulers == nil {
+		return
+	}
+
+	switch msg.Type {
+	case taskEventScheduled:
+		ts.unschedule(task.ID)
+		ts.schedule(task)
+	case taskEventCanceled:
+		ts.unschedule(task.ID)
+	}
+}
 
-	ts.Scheduler.StartBlocking()
+// AddOnTheFly notifies the instance running the scheduler that the task was
+// added or updated, it is scheduled or rescheduled there
+func (ts *TaskScheduler) AddOnTheFly(task model.Task) error {
+	return ts.publishTaskEvent(taskEventScheduled, task)
 }
 
-func (ts *TaskScheduler) AddOnTheFly(task model.Task) {
-	_, err := ts.Scheduler.Cron(task.Interval).Tag(task.ID).Do(ts.run, task)
+// RemoveOnTheFly notifies the instance running the scheduler that the task
+// was deleted, it is canceled there
+func (ts *TaskScheduler) RemoveOnTheFly(task model.Task) error {
+	return ts.publishTaskEvent(taskEventCanceled, task)
+}
+
+func (ts *TaskScheduler) publishTaskEvent(typ string, task model.Task) error {
+	b, err := json.Marshal(task)
 	if err != nil {
-		ts.Log.Error().Err(err).Msgf("error scheduling this task: %s", task.ID)
+		return err
+	}
+
+	msg := model.Command{
+		SID:           "system",
+		Type:          typ,
+		Data:          string(b),
+		Channel:       ChannelTaskEvent,
+		Token:         "system",
+		IsSystemEvent: true,
 	}
+	return ts.Volatile.Publish(msg)
 }
 
+// CancelTask removes the task from the scheduler running on this instance
 func (ts *TaskScheduler) CancelTask(id string) error {
-	return ts.Scheduler.RemoveByTag(id)
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+
+	if ts.schedulers == nil {
+		return nil
+	}
+
+	if !ts.unschedule(id) {
+		return gocron.ErrJobNotFoundWithTag
+	}
+	return nil
+}
+
+// IsScheduled returns true when the task is scheduled on this instance
+func (ts *TaskScheduler) IsScheduled(id string) bool {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+
+	_, ok := ts.scheduled[id]
+	return ok
+}
+
+// Reload schedules the new tasks, reschedules the tasks whose schedule
+// changed and cancels the deleted tasks. The leader reloads the tasks
+// periodically in case it missed a task event.
+func (ts *TaskScheduler) Reload() error {
+	tasks, err := ts.DataStore.ListTasks()
+	if err != nil {
+		return err
+	}
+
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+
+	if ts.schedulers == nil {
+		return nil
+	}
+
+	current := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		current[task.ID] = true
+
+		prev, ok := ts.scheduled[task.ID]
+		if ok && prev.Interval == task.Interval && prev.Timezone == task.Timezone {
+			continue
+		}
+
+		ts.unschedule(task.ID)
+		ts.schedule(task)
+	}
+
+	for id := range ts.scheduled {
+		if !current[id] {
+			ts.unschedule(id)
+		}
+	}
+	return nil
+}
+
+func (ts *TaskScheduler) reloadEvery(d time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := ts.Reload(); err != nil {
+				ts.Log.Error().Err(err).Msg("error reloading tasks")
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// unschedule removes the task from the scheduler of its timezone, ts.mx must
+// be held
+func (ts *TaskScheduler) unschedule(id string) bool {
+	if _, ok := ts.scheduled[id]; !ok {
+		return false
+	}
+	delete(ts.scheduled, id)
+
+	// the timezone may have changed since the task was scheduled
+	for _, s := range ts.schedulers {
+		s.RemoveByTag(id)
+	}
+	return true
+}
+
+// schedule adds the task to the scheduler of its timezone, ts.mx must be held
+func (ts *TaskScheduler) schedule(task model.Task) {
+	loc, err := time.LoadLocation(task.Timezone)
+	if err != nil {
+		ts.Log.Error().Err(err).Msgf("invalid timezone for this task: %s", task.ID)
+		return
+	}
+
+	s, ok := ts.schedulers[loc.String()]
+	if !ok {
+		s = gocron.NewScheduler(loc)
+		s.TagsUnique()
+		s.StartAsync()
+
+		ts.schedulers[loc.String()] = s
+	}
+
+	if _, err := s.Cron(task.Interval).Tag(task.ID).Do(ts.run, task); err != nil {
+		ts.Log.Error().Err(err).Msgf("error scheduling this task: %s", task.ID)
+		return
+	}
+	ts.scheduled[task.ID] = task
+}
+
+// catchUp executes the runs the task missed according to its catch-up policy
+func (ts *TaskScheduler) catchUp(task model.Task, now time.Time) {
+	if task.Paused {
+		return
+	}
+
+	missed := MissedRuns(task, now)
+	if missed == 0 {
+		return
+	}
+
+	switch task.CatchUp {
+	case model.TaskCatchUpOnce:
+		missed = 1
+	case model.TaskCatchUpAll:
+	default:
+		ts.Log.Info().Msgf("skipping %d missed run(s) of job:%s", missed, task.Name)
+		return
+	}
+
+	ts.Log.Info().Msgf("catching up %d missed run(s) of job:%s", missed, task.Name)
+
+	for i := 0; i < missed; i++ {
+		if _, err := ts.execute(task, model.TaskTriggerCatchUp); err != nil {
+			ts.Log.Warn().Err(err).Msgf("error catching up job:%s", task.Name)
+			return
+		}
+	}
+}
+
+// RunNow executes the task immediately, the run is recorded in the task's
+// history. ErrTaskRunning is returned when its previous run is not completed.
+func (ts *TaskScheduler) RunNow(task model.Task) (model.TaskRun, error) {
+	return ts.execute(task, model.TaskTriggerManual)
+}
+
+// TaskLockKey holds the ID of the instance running the task
+func TaskLockKey(id string) string {
+	return "sb:task:" + id + ":lock"
+}
+
+// lockTask acquires the task's lock and renews it until the returned
+// function is called
+func (ts *TaskScheduler) lockTask(task model.Task) (func(), error) {
+	key := TaskLockKey(task.ID)
+
+	ok, err := ts.Volatile.AcquireLock(key, ts.InstanceID, taskLockTTL)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrTaskRunning
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(taskLockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := ts.Volatile.RenewLock(key, ts.InstanceID, taskLockTTL); err != nil {
+					ts.Log.Warn().Err(err).Msgf("error renewing the lock of task %s", task.ID)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	release := func() {
+		close(done)
+
+		if err := ts.Volatile.ReleaseLock(key, ts.InstanceID); err != nil {
+			ts.Log.Warn().Err(err).Msgf("error releasing the lock of task %s", task.ID)
+		}
+	}
+	return release, nil
 }
 
 func (ts *TaskScheduler) run(task model.Task) {
-	ts.Log.Info().Msgf("executing job:%s typed:%s value:%s", task.Name, task.Type, task.Value)
+	// the task may have been paused or deleted since it was scheduled
+	current, err := ts.DataStore.GetTask(task.BaseName, task.ID)
+	if err != nil {
+		ts.Log.Warn().Err(err).Msgf("skipping job:%s, unable to load the task", task.Name)
+		return
+	} else if current.Paused {
+		return
+	}
+
+	if _, err := ts.execute(current, model.TaskTriggerSchedule); errors.Is(err, ErrTaskRunning) {
+		// a previous run may still be executing, here or on a former leader
+		ts.Log.Warn().Msgf("skipping job:%s, its previous run is not completed", task.Name)
+	} else if err != nil {
+		ts.Log.Error().Err(err).Msgf("error acquiring the lock of task %s", task.ID)
+	}
+}
 
-	// the task must run as the root base user
+// execute runs the task and records the run, the returned error is only set
+// when the task could not be started
+func (ts *TaskScheduler) execute(task model.Task, trigger string) (run model.TaskRun, err error) {
+	release, err := ts.lockTask(task)
+	if err != nil {
+		return
+	}
+	defer release()
+
+	ts.Log.Info().Msgf("executing job:%s typed:%s value:%s trigger:%s", task.Name, task.Type, task.Value, trigger)
+
+	ctx, span := tracing.Start(context.Background(), "task.run",
+		attribute.String("task.id", task.ID),
+		attribute.String("task.type", task.Type),
+		attribute.String("task.trigger", trigger),
+		attribute.String("db.name", task.BaseName),
+	)
+	defer span.End()
+
+	run = model.TaskRun{
+		TaskID:  task.ID,
+		Trigger: trigger,
+		Started: time.Now(),
+	}
+
+	output, runErr := ts.dispatch(ctx, task)
+
+	run.Completed = time.Now()
+	run.Status = model.TaskRunSuccess
+	if len(output) > maxTaskOutput {
+		output = output[:maxTaskOutput]
+	}
+	run.Output = output
+
+	if runErr != nil {
+		run.Status = model.TaskRunFailure
+		run.Error = runErr.Error()
+
+		span.SetStatus(codes.Error, runErr.Error())
+	}
+	metrics.TaskRan(task.BaseName, task.Type, runErr)
+
+	if err := ts.DataStore.RanTask(task.BaseName, run); err != nil {
+		ts.Log.Error().Err(err).Msgf("error recording the run of task %s", task.ID)
+	}
+	return run, nil
+}
+
+// dispatch executes the task as the root user of its database and returns
+// its output
+func (ts *TaskScheduler) dispatch(ctx context.Context, task model.Task) (string, error) {
 	var auth model.Auth
 	if err := ts.Volatile.GetTyped("root:"+task.BaseName, &auth); err != nil {
 		tok, err := ts.DataStore.GetRootForBase(task.BaseName)
 		if err != nil {
 			ts.Log.Error().Err(err).Msgf("error finding root token for base %s", task.BaseName)
-
-			return
+			return "", err
 		}
 
 		auth = model.Auth{
@@ -81,26 +530,42 @@ func (ts *TaskScheduler) run(task model.Task) {
 		}
 
 		if err := ts.Volatile.SetTyped("root:"+task.BaseName, auth); err != nil {
-			ts.Log.Error().Err(err).Msg("error setting auth inside TaskScheduler.run")
-			return
+			ts.Log.Error().Err(err).Msg("error setting auth inside TaskScheduler.dispatch")
+			return "", err
 		}
 	}
 
 	switch task.Type {
 	case model.TaskTypeFunction:
-		ts.execFunction(auth, task)
+		return ts.execFunction(ctx, auth, task)
 	case model.TaskTypeMessage:
-		ts.sendMessage(auth, task)
+		return ts.sendMessage(auth, task)
 	case model.TaskTypeHTTP:
-		ts.httpRequest(auth, task)
+		return ts.httpRequest(ctx, auth, task)
+	case model.TaskTypeBackup:
+		return ts.backup(task)
 	}
+	return "", fmt.Errorf("unsupported task type %s", task.Type)
 }
 
-func (ts *TaskScheduler) execFunction(auth model.Auth, task model.Task) {
+func (ts *TaskScheduler) backup(task model.Task) (string, error) {
+	if ts.Backup == nil {
+		ts.Log.Warn().Msgf("no backup handler to run task %s", task.ID)
+		return "", errors.New("no backup handler")
+	}
+
+	if err := ts.Backup(task); err != nil {
+		ts.Log.Error().Err(err).Msgf("error backing up database %s on task %s", task.BaseName, task.ID)
+		return "", err
+	}
+	return fmt.Sprintf("database %s backed up", task.BaseName), nil
+}
+
+func (ts *TaskScheduler) execFunction(ctx context.Context, auth model.Auth, task model.Task) (string, error) {
 	fn, err := ts.DataStore.GetFunctionForExecution(task.BaseName, task.Value)
 	if err != nil {
 		ts.Log.Error().Err(err).Msgf("cannot find function %s on task %s", task.Value, task.ID)
-		return
+		return "", err
 	}
 
 	exe := &ExecutionEnvironment{
@@ -110,8 +575,10 @@ func (ts *TaskScheduler) execFunction(auth model.Auth, task model.Task) {
 		Volatile:  ts.Volatile,
 		Search:    ts.Search,
 		Email:     ts.Email,
+		Storage:   ts.Storage,
 		Data:      fn,
 		Log:       ts.Log,
+		Context:   ctx,
 	}
 
 	var meta model.MetaMessage
@@ -119,7 +586,7 @@ func (ts *TaskScheduler) execFunction(auth model.Auth, task model.Task) {
 	if len(task.Meta) > 0 {
 		if err := json.Unmarshal([]byte(task.Meta), &meta); err != nil {
 			ts.Log.Warn().Msgf("unable to get meta data for type MetaMessage for task: %s", task.ID)
-			return
+			return "", err
 		}
 	}
 
@@ -132,12 +599,17 @@ func (ts *TaskScheduler) execFunction(auth model.Auth, task model.Task) {
 		IsSystemEvent: true,
 	}
 
-	if err := exe.Execute(msg); err != nil {
+	err = exe.Execute(msg)
+
+	output := strings.Join(exe.CurrentRun.Output, "\n")
+	if err != nil {
 		ts.Log.Error().Err(err).Msgf("error executing function %s", task.Value)
+		return output, err
 	}
+	return output, nil
 }
 
-func (ts *TaskScheduler) sendMessage(auth model.Auth, task model.Task) {
+func (ts *TaskScheduler) sendMessage(auth model.Auth, task model.Task) (string, error) {
 	token := auth.ReconstructToken()
 
 	var meta model.MetaMessage
@@ -145,7 +617,7 @@ func (ts *TaskScheduler) sendMessage(auth model.Auth, task model.Task) {
 	if len(task.Meta) > 0 {
 		if err := json.Unmarshal([]byte(task.Meta), &meta); err != nil {
 			ts.Log.Warn().Msgf("unable to get meta data for type MetaMessage for task: %s", task.ID)
-			return
+			return "", err
 		}
 	}
 
@@ -161,10 +633,12 @@ func (ts *TaskScheduler) sendMessage(auth model.Auth, task model.Task) {
 
 	if err := ts.Volatile.Publish(msg); err != nil {
 		ts.Log.Error().Err(err).Msgf("error publishing message from task: %s", task.ID)
+		return "", err
 	}
+	return fmt.Sprintf("message %s published", task.Value), nil
 }
 
-func (ts *TaskScheduler) httpRequest(auth model.Auth, task model.Task) {
+func (ts *TaskScheduler) httpRequest(ctx context.Context, auth model.Auth, task model.Task) (string, error) {
 	token := auth.ReconstructToken()
 
 	var meta model.MetaMessage
@@ -173,12 +647,12 @@ func (ts *TaskScheduler) httpRequest(auth model.Auth, task model.Task) {
 	if len(task.Meta) > 0 {
 		if err := json.Unmarshal([]byte(task.Meta), &meta); err != nil {
 			ts.Log.Warn().Msgf("unable to get meta data for type MetaMessage for task: %s", task.ID)
-			return
+			return "", err
 		}
 
 		if err := json.Unmarshal([]byte(meta.HTTPHeaders), &headers); err != nil {
 			ts.Log.Err(err).Msg("unable to parse HTTP headers from meta data")
-			return
+			return "", err
 		}
 	}
 
@@ -197,7 +671,7 @@ func (ts *TaskScheduler) httpRequest(auth model.Auth, task model.Task) {
 		var v map[string]any
 		if err := json.Unmarshal([]byte(meta.Data), &v); err != nil {
 			ts.Log.Warn().Err(err).Msg("unable to parse meta data")
-			return
+			return "", err
 		}
 
 		data := url.Values{}
@@ -208,10 +682,10 @@ func (ts *TaskScheduler) httpRequest(auth model.Auth, task model.Task) {
 		body = data.Encode()
 	}
 
-	req, err := http.NewRequest(meta.HTTPMethod, task.Value, strings.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, meta.HTTPMethod, task.Value, strings.NewReader(body))
 	if err != nil {
 		ts.Log.Err(err).Msg("unable to construct the HTTP request")
-		return
+		return "", err
 	}
 
 	req.Header.Add("Content-Type", meta.ContentType)
@@ -220,17 +694,18 @@ func (ts *TaskScheduler) httpRequest(auth model.Auth, task model.Task) {
 		req.Header.Add(key, val)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	client := http.Client{Transport: tracing.Transport(nil)}
+	resp, err := client.Do(req)
 	if err != nil {
 		ts.Log.Err(err).Msg("error executing HTTP request")
-		return
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		ts.Log.Err(err).Msg("unable to read HTTP response body")
-		return
+		return "", err
 	}
 
 	msg := model.Command{
@@ -243,7 +718,11 @@ func (ts *TaskScheduler) httpRequest(auth model.Auth, task model.Task) {
 		Base:    task.BaseName,
 	}
 
+	output := fmt.Sprintf("%s\n%s", resp.Status, b)
+
 	if err := ts.Volatile.Publish(msg); err != nil {
 		ts.Log.Error().Err(err).Msgf("error publishing message from task: %s", task.ID)
+		return output, err
 	}
+	return output, nil
 }
@@ -11,10 +11,12 @@ import (
 )
 
 type Subscriber struct {
-	PubSub            cache.Volatilizer
-	GetExecEnv        func(msg model.Command) (*ExecutionEnvironment, error)
-	Log               *logger.Logger
-	IsPrimaryInstance bool
+	PubSub     cache.Volatilizer
+	GetExecEnv func(msg model.Command) (*ExecutionEnvironment, error)
+	Log        *logger.Logger
+	// IsLeader returns true when this instance runs the scheduler, the
+	// functions are only executed by the leader
+	IsLeader func() bool
 
 	relax sync.Map
 }
@@ -31,9 +33,9 @@ func (sub *Subscriber) Start() {
 	for {
 		select {
 		case msg := <-receiver:
-			// only handle function execution on the primary instance
+			// only handle function execution on the leader instance
 			// otherwise it would cause duplication work.
-			if sub.IsPrimaryInstance {
+			if sub.IsLeader() {
 				go sub.process(msg)
 			}
 		case <-close:
@@ -47,7 +49,8 @@ func (sub *Subscriber) process(msg model.Command) {
 	case model.MsgTypeChanOut,
 		model.MsgTypeDBCreated,
 		model.MsgTypeDBUpdated,
-		model.MsgTypeDBDeleted:
+		model.MsgTypeDBDeleted,
+		model.MsgTypeFormSubmit:
 		sub.handleRealtimeEvents(msg)
 	default:
 		// for user triggered events, we enforce a max of 5 msg / 60 secs
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/cache"
 	"github.com/staticbackendhq/core/model"
 )
 
@@ -454,6 +455,9 @@ func TestFunctionWithVolatilizerHelpers(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
+	if err := backend.Cache.Del("fn-leaderboard"); err != nil {
+		t.Fatal(err)
+	}
 
 	code := `
 	function handle(channel, type, body) {
@@ -487,6 +491,51 @@ func TestFunctionWithVolatilizerHelpers(t *testing.T) {
 			return;
 		}
 
+		res = cacheSet("ok-unit-test-ttl", "expiring", 60);
+		if (!res.ok) {
+			log("ERROR: " + res.content);
+			return;
+		}
+
+		res = cacheZAdd("fn-leaderboard", "alice", 3);
+		if (!res.ok) {
+			log("ERROR: " + res.content);
+			return;
+		}
+
+		res = cacheZIncrBy("fn-leaderboard", "bob", 5);
+		if (!res.ok) {
+			log("ERROR: " + res.content);
+			return;
+		}
+
+		res = cacheZRange("fn-leaderboard", 0, 0, true);
+		if (!res.ok) {
+			log("ERROR: " + res.content);
+			return;
+		} else if (res.content.length != 1 || res.content[0].member != "bob") {
+			log("ERROR: expected bob to lead the leaderboard");
+			return;
+		}
+
+		res = queueAdd("fn-jobs", "job");
+		if (!res.ok) {
+			log("ERROR: " + res.content);
+			return;
+		}
+
+		let lease = queueLease("fn-jobs", 30, 3);
+		if (!lease.ok || !lease.content || lease.content.value != "job") {
+			log("ERROR: expected to lease the job");
+			return;
+		}
+
+		res = queueAck("fn-jobs", lease.content.receipt);
+		if (!res.ok || !res.content) {
+			log("ERROR: expected to acknowledge the job");
+			return;
+		}
+
 		res = publish("test-channel", "some-type", {a: "which data"});
 		if (!res.ok) {
 			log(res.content);
@@ -569,4 +618,152 @@ func TestFunctionWithVolatilizerHelpers(t *testing.T) {
 	} else if total != 8 {
 		t.Errorf("expected total to be 8 got %d", total)
 	}
+
+	if ttl, err := backend.Cache.TTL("ok-unit-test-ttl"); err != nil {
+		t.Fatal(err)
+	} else if ttl <= 0 || ttl > time.Minute {
+		t.Errorf("expected a ttl up to a minute got %v", ttl)
+	}
+
+	if score, err := backend.Cache.ZScore("fn-leaderboard", "bob"); err != nil {
+		t.Fatal(err)
+	} else if score != 5 {
+		t.Errorf("expected bob's score to be 5 got %v", score)
+	}
+
+	if stats, err := backend.Cache.QueueStats("fn-jobs"); err != nil {
+		t.Fatal(err)
+	} else if stats != (cache.QueueStats{}) {
+		t.Errorf("expected the job to be acknowledged got %v", stats)
+	}
+}
+
+func TestFunctionRenderPDF(t *testing.T) {
+	code := `
+	function handle(body) {
+		var res = renderPDF("<h1>Receipt {{.number}}</h1><p>Thanks {{.name}}</p>", {
+			number: 42,
+			name: body.name
+		}, "receipt");
+		if (!res.ok) {
+			log("ERROR: rendering PDF");
+			log(res.content);
+			return;
+		} else if (!res.content.id || !res.content.url) {
+			log("ERROR: expected a saved file");
+			log(res.content);
+			return;
+		}
+	}`
+	data := model.ExecData{
+		FunctionName: "unittest-pdf",
+		Code:         code,
+		TriggerTopic: "web",
+	}
+	addResp := dbReq(t, funexec.add, "POST", "/", data, true)
+	defer addResp.Body.Close()
+	if addResp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, addResp))
+	}
+
+	val := url.Values{}
+	val.Add("name", "unit test")
+
+	execResp := dbReq(t, funexec.exec, "POST", "/fn/exec/unittest-pdf", val, false, true)
+	defer execResp.Body.Close()
+	if execResp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, execResp))
+	}
+
+	// the execution history is saved asynchronously
+	time.Sleep(250 * time.Millisecond)
+
+	infoResp := dbReq(t, funexec.info, "GET", "/fn/info/unittest-pdf", nil, true)
+	defer infoResp.Body.Close()
+	if infoResp.StatusCode >= 299 {
+		t.Fatal(GetResponseBody(t, infoResp))
+	}
+
+	var checkFn model.ExecData
+	if err := parseBody(infoResp.Body, &checkFn); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(checkFn.History) == 0 {
+		t.Fatal("expected the function to have ran")
+	}
+
+	for _, h := range checkFn.History {
+		for _, line := range h.Output {
+			if strings.Contains(line, "ERROR") {
+				t.Fatalf("found error in function exec log: %v", h.Output)
+			}
+		}
+	}
+}
+
+func TestFunctionSendSMS(t *testing.T) {
+	cfg := model.SMSConfig{Provider: "dev", FromNumber: "+15555555555"}
+	if err := backend.DB.SetSMSConfig(pubKey, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	code := `
+	function handle(body) {
+		var res = sendSMS({to: "+15555555556", body: "Hello " + body.name});
+		if (!res.ok) {
+			log("ERROR: sending SMS");
+			log(res.content);
+			return;
+		} else if (res.content.status != "queued" || !res.content.messageId) {
+			log("ERROR: expected a queued message");
+			log(res.content);
+			return;
+		}
+	}`
+	data := model.ExecData{
+		FunctionName: "unittest-sms",
+		Code:         code,
+		TriggerTopic: "web",
+	}
+	addResp := dbReq(t, funexec.add, "POST", "/", data, true)
+	defer addResp.Body.Close()
+	if addResp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, addResp))
+	}
+
+	val := url.Values{}
+	val.Add("name", "unit test")
+
+	execResp := dbReq(t, funexec.exec, "POST", "/fn/exec/unittest-sms", val, false, true)
+	defer execResp.Body.Close()
+	if execResp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, execResp))
+	}
+
+	// the execution history is saved asynchronously
+	time.Sleep(250 * time.Millisecond)
+
+	infoResp := dbReq(t, funexec.info, "GET", "/fn/info/unittest-sms", nil, true)
+	defer infoResp.Body.Close()
+	if infoResp.StatusCode >= 299 {
+		t.Fatal(GetResponseBody(t, infoResp))
+	}
+
+	var checkFn model.ExecData
+	if err := parseBody(infoResp.Body, &checkFn); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(checkFn.History) == 0 {
+		t.Fatal("expected the function to have ran")
+	}
+
+	for _, h := range checkFn.History {
+		for _, line := range h.Output {
+			if strings.Contains(line, "ERROR") {
+				t.Fatalf("found error in function exec log: %v", h.Output)
+			}
+		}
+	}
 }
@@ -0,0 +1,182 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// groups lists (GET) the groups of the user's account and lets account
+// admins create (POST /account/groups), update (PUT /account/groups/{id}) and
+// delete (DELETE /account/groups/{id}) them. Members are added with POST
+// /account/groups/{id}/members and removed with DELETE
+// /account/groups/{id}/members/{userId}.
+func (a *accounts) groups(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	if r.Method == http.MethodGet {
+		list, err := mship.ListGroups(auth.AccountID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, list)
+		return
+	}
+
+	if auth.Role < middleware.AccountAdminRole {
+		http.Error(w, "only account admins can manage groups", http.StatusForbidden)
+		return
+	}
+
+	id := getURLPart(r.URL.Path, 3)
+	if len(id) > 0 && getURLPart(r.URL.Path, 4) == "members" {
+		a.groupMembers(w, r, mship, auth, id)
+		return
+	}
+
+	if r.Method != http.MethodPost && len(id) == 0 {
+		http.Error(w, "missing group id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var data backend.GroupData
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		g, err := mship.CreateGroup(auth.AccountID, data)
+		if err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusCreated, g)
+	case http.MethodPut:
+		var data backend.GroupData
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		g, err := mship.UpdateGroup(auth.AccountID, id, data)
+		if err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, g)
+	case http.MethodDelete:
+		if err := mship.DeleteGroup(auth.AccountID, id); err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *accounts) groupMembers(w http.ResponseWriter, r *http.Request, mship backend.User, auth model.Auth, groupID string) {
+	switch r.Method {
+	case http.MethodPost:
+		var data = new(struct {
+			UserID string `json:"userId"`
+		})
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.AddGroupMember(auth.AccountID, groupID, data.UserID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	case http.MethodDelete:
+		userID := getURLPart(r.URL.Path, 5)
+		if err := mship.RemoveGroupMember(auth.AccountID, groupID, userID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// roles lists (GET) the roles of the database, root users can create or
+// update (POST /account/roles) and delete (DELETE /account/roles/{name}) them.
+func (a *accounts) roles(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	if r.Method == http.MethodGet {
+		list, err := mship.ListRoles()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, list)
+		return
+	}
+
+	if auth.Role < middleware.RootRole {
+		http.Error(w, "only root users can manage roles", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var role model.Role
+		if err := parseBody(r.Body, &role); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SaveRole(role); err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	case http.MethodDelete:
+		if err := mship.DeleteRole(getURLPart(r.URL.Path, 3)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func groupErrorStatus(err error) int {
+	if errors.Is(err, backend.ErrInvalidGroup) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
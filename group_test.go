@@ -0,0 +1,115 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestGroupsAndRoles(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usr := backend.Membership(conf)
+
+	writer, _, err := usr.CreateUser(testAccountID, "group-writer@test.com", "group1234", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, readerUser, err := usr.CreateUser(testAccountID, "group-reader@test.com", "group1234", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// documents of a _700_ collection are only visible to their owner
+	doc := map[string]any{"title": "owner only"}
+	if resp := sessionReq(t, db.add, "POST", "/db/groupnotes_700_", string(writer), doc); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	listNotes := func() int {
+		resp := sessionReq(t, db.list, "GET", "/db/groupnotes_700_", string(reader), nil)
+		if resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+
+		var result model.PagedResult
+		if err := parseBody(resp.Body, &result); err != nil {
+			t.Fatal(err)
+		}
+		return len(result.Results)
+	}
+
+	if n := listNotes(); n != 0 {
+		t.Fatalf("expected no visible notes got %d", n)
+	}
+
+	role := model.Role{Name: "note-reader", Permissions: []string{"db:read:groupnotes_700_", "notes:*"}}
+	if resp := dbReq(t, acct.roles, "POST", "/account/roles", role, true); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	// only account admins can manage groups
+	data := backend.GroupData{Name: "readers", Roles: []string{"note-reader"}}
+	if resp := sessionReq(t, acct.groups, "POST", "/account/groups", string(reader), data); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403 got %s", GetResponseBody(t, resp))
+	}
+
+	unknown := backend.GroupData{Name: "unknown", Roles: []string{"not-a-role"}}
+	if resp := dbReq(t, acct.groups, "POST", "/account/groups", unknown); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an unknown role got %s", GetResponseBody(t, resp))
+	}
+
+	resp := dbReq(t, acct.groups, "POST", "/account/groups", data)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var g model.Group
+	if err := parseBody(resp.Body, &g); err != nil {
+		t.Fatal(err)
+	}
+
+	member := map[string]string{"userId": readerUser.ID}
+	if resp := dbReq(t, acct.groups, "POST", "/account/groups/"+g.ID+"/members", member); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = sessionReq(t, mship.me, "GET", "/me", string(reader), nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if !me.InGroup("readers") || !me.HasPermission("notes:export") {
+		t.Errorf("expected the readers group and its permissions got %v %v", me.Groups, me.Permissions)
+	}
+
+	if n := listNotes(); n != 1 {
+		t.Errorf("expected the group member to see 1 note got %d", n)
+	}
+
+	path := "/account/groups/" + g.ID + "/members/" + readerUser.ID
+	if resp := dbReq(t, acct.groups, "DELETE", path, nil); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if n := listNotes(); n != 0 {
+		t.Errorf("expected no visible notes once removed from the group got %d", n)
+	}
+
+	if resp := dbReq(t, acct.groups, "DELETE", "/account/groups/"+g.ID, nil); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if resp := dbReq(t, acct.roles, "DELETE", "/account/roles/note-reader", nil, true); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+}
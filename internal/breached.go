@@ -0,0 +1,33 @@
+package internal
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+//go:embed breached.txt
+var breachedList string
+
+var (
+	breachedOnce      sync.Once
+	breachedPasswords map[string]struct{}
+)
+
+// IsBreachedPassword returns true when the password is part of the local list
+// of commonly used passwords found in data breaches. The comparison is case
+// insensitive.
+func IsBreachedPassword(password string) bool {
+	breachedOnce.Do(func() {
+		breachedPasswords = make(map[string]struct{})
+		for _, line := range strings.Split(breachedList, "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) > 0 {
+				breachedPasswords[strings.ToLower(line)] = struct{}{}
+			}
+		}
+	})
+
+	_, ok := breachedPasswords[strings.ToLower(password)]
+	return ok
+}
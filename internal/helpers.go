@@ -1,22 +1,40 @@
 package internal
 
 import (
-	"math/rand"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+
+	"github.com/staticbackendhq/core/config"
 )
 
 var (
 	letterRunes = []rune("abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ2345679")
 )
 
-// RandStringRunes returns a random string with n characters where n>=1
+// RandStringRunes returns a random string with n characters where n>=1, it
+// uses crypto/rand since the strings are used as secrets and tokens
 func RandStringRunes(n int) string {
 	n = maxInt(1, n)
 	b := make([]rune, n)
+	max := big.NewInt(int64(len(letterRunes)))
 	for i := range b {
-		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+		x, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			// crypto/rand does not fail on the supported platforms
+			panic(err)
+		}
+		b[i] = letterRunes[x.Int64()]
 	}
 
 	// due to PostgreSQL schema requiring letter start.
@@ -35,6 +53,128 @@ func CleanUpFileName(s string) string {
 
 }
 
+// ErrChecksumUnavailable is returned when the content was not read
+// sequentially from its start
+var ErrChecksumUnavailable = errors.New("the checksum is only computed on a sequential read")
+
+// HashingReader computes the SHA-256 of the content while it is read, it is
+// a ReadSeeker so it can be given to the storage providers. Seeking back to
+// the start restarts the checksum.
+type HashingReader struct {
+	file   io.ReadSeeker
+	tee    io.Reader
+	h      hash.Hash
+	offset int64
+	valid  bool
+}
+
+// NewHashingReader returns a HashingReader reading file, which must be at
+// its start
+func NewHashingReader(file io.ReadSeeker) *HashingReader {
+	h := sha256.New()
+	return &HashingReader{file: file, tee: io.TeeReader(file, h), h: h, valid: true}
+}
+
+func (hr *HashingReader) Read(p []byte) (int, error) {
+	n, err := hr.tee.Read(p)
+	hr.offset += int64(n)
+	return n, err
+}
+
+func (hr *HashingReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := hr.file.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+
+	if pos == 0 {
+		hr.h.Reset()
+		hr.valid = true
+	} else if pos != hr.offset {
+		hr.valid = false
+	}
+	hr.offset = pos
+	return pos, nil
+}
+
+// Checksum returns the hex encoded SHA-256 of the content read so far
+func (hr *HashingReader) Checksum() (string, error) {
+	if !hr.valid {
+		return "", ErrChecksumUnavailable
+	}
+	return hex.EncodeToString(hr.h.Sum(nil)), nil
+}
+
+// ClientIP returns the IP address of the client making the request. The
+// X-Forwarded-For and X-Real-IP headers are only used when the request comes
+// from one of the TRUSTED_PROXIES, otherwise any client could spoof its IP.
+func ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	proxies := trustedProxies(config.Current.TrustedProxies)
+	if !isTrusted(proxies, ip) {
+		return ip
+	}
+
+	// the right-most address not added by a trusted proxy is the client,
+	// the ones on its left can be set by the client
+	if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+		hops := strings.Split(strings.Join(fwd, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if len(hop) == 0 {
+				continue
+			}
+
+			ip = hop
+			if !isTrusted(proxies, hop) {
+				break
+			}
+		}
+		return ip
+	} else if real := strings.TrimSpace(r.Header.Get("X-Real-IP")); len(real) > 0 {
+		return real
+	}
+	return ip
+}
+
+var (
+	proxiesMu   sync.Mutex
+	proxiesRaw  string
+	proxiesNets []*net.IPNet
+)
+
+// trustedProxies returns the parsed TRUSTED_PROXIES, they're parsed again
+// only when the setting changes. Invalid entries are rejected when the
+// configuration is validated.
+func trustedProxies(raw string) []*net.IPNet {
+	proxiesMu.Lock()
+	defer proxiesMu.Unlock()
+
+	if raw != proxiesRaw {
+		proxiesNets, _ = config.ParseTrustedProxies(raw)
+		proxiesRaw = raw
+	}
+	return proxiesNets
+}
+
+func isTrusted(proxies []*net.IPNet, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, n := range proxies {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // maxInt returns max value between two integers
 func maxInt(x, y int) int {
 	if x > y {
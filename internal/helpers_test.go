@@ -1,6 +1,15 @@
 package internal
 
-import "testing"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/staticbackendhq/core/config"
+)
 
 func TestRandStringRunes(t *testing.T) {
 	lengths := []int{1, 1, 12, 30}
@@ -11,6 +20,19 @@ func TestRandStringRunes(t *testing.T) {
 	}
 }
 
+func TestRandStringRunesAlphabet(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		p := RandStringRunes(32)
+		if seen[p] {
+			t.Fatalf("%s: expected unique strings", p)
+		} else if strings.Trim(p, string(letterRunes)) != "" {
+			t.Fatalf("%s: expected only letter runes", p)
+		}
+		seen[p] = true
+	}
+}
+
 func TestRandStringRuneMinValue(t *testing.T) {
 	lengths := []int{0, -1, -2}
 	for _, length := range lengths {
@@ -33,3 +55,100 @@ func TestCleanUpFileName(t *testing.T) {
 
 	}
 }
+
+func TestClientIP(t *testing.T) {
+	prev := config.Current
+	defer func() {
+		config.Current = prev
+	}()
+
+	config.Current.TrustedProxies = "10.0.0.0/8"
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+
+	if ip := ClientIP(r); ip != "10.0.0.1" {
+		t.Errorf("expected 10.0.0.1 got %s", ip)
+	}
+
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+	if ip := ClientIP(r); ip != "203.0.113.7" {
+		t.Errorf("expected 203.0.113.7 got %s", ip)
+	}
+
+	// the client prepends a spoofed address, the proxy appends the real one
+	r.Header.Set("X-Forwarded-For", "192.0.2.1, 203.0.113.7")
+	if ip := ClientIP(r); ip != "203.0.113.7" {
+		t.Errorf("expected 203.0.113.7 got %s", ip)
+	}
+}
+
+func TestClientIPIgnoresUntrustedHeaders(t *testing.T) {
+	prev := config.Current
+	defer func() {
+		config.Current = prev
+	}()
+
+	for _, proxies := range []string{"", "10.0.0.0/8"} {
+		config.Current.TrustedProxies = proxies
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "198.51.100.9:1234"
+		r.Header.Set("X-Forwarded-For", "203.0.113.7")
+		r.Header.Set("X-Real-IP", "203.0.113.7")
+
+		if ip := ClientIP(r); ip != "198.51.100.9" {
+			t.Errorf("proxies %q: expected the spoofed headers to be ignored got %s", proxies, ip)
+		}
+	}
+}
+
+func TestIsBreachedPassword(t *testing.T) {
+	tables := map[string]bool{
+		"password":             true,
+		"Password":             true,
+		"qwerty123":            true,
+		"correct-horse-staple": false,
+		"":                     false,
+	}
+
+	for pw, expected := range tables {
+		if got := IsBreachedPassword(pw); got != expected {
+			t.Errorf("%s: expected %v got %v", pw, expected, got)
+		}
+	}
+}
+
+func TestHashingReader(t *testing.T) {
+	content := "content hashed while it is read"
+	sum := sha256.Sum256([]byte(content))
+	expected := hex.EncodeToString(sum[:])
+
+	hr := NewHashingReader(strings.NewReader(content))
+
+	// a partial read followed by a rewind restarts the checksum
+	if _, err := io.CopyN(io.Discard, hr, 5); err != nil {
+		t.Fatal(err)
+	} else if _, err := hr.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := io.ReadAll(hr); err != nil {
+		t.Fatal(err)
+	} else if string(b) != content {
+		t.Errorf("expected the content to be read got %s", b)
+	}
+
+	if checksum, err := hr.Checksum(); err != nil {
+		t.Fatal(err)
+	} else if checksum != expected {
+		t.Errorf("expected checksum %s got %s", expected, checksum)
+	}
+
+	// skipping content invalidates the checksum
+	if _, err := hr.Seek(3, io.SeekStart); err != nil {
+		t.Fatal(err)
+	} else if _, err := hr.Checksum(); err != ErrChecksumUnavailable {
+		t.Errorf("expected ErrChecksumUnavailable got %v", err)
+	}
+}
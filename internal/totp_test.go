@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B test vectors for SHA1, last 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tables := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for ts, expected := range tables {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(ts, 0)))
+		if err != nil {
+			t.Fatal(err)
+		} else if code != expected {
+			t.Errorf("at %d expected %s got %s", ts, expected, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, err := TOTPCode(secret, TOTPStep(now)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	if !ok {
+		t.Fatal("expected previous step code to be accepted")
+	} else if step != TOTPStep(now)-1 {
+		t.Errorf("expected step %d got %d", TOTPStep(now)-1, step)
+	}
+
+	if _, ok := ValidateTOTP(secret, code, now, step); ok {
+		t.Error("expected a used code to be rejected")
+	}
+
+	if _, ok := ValidateTOTP(secret, "000000x", now, 0); ok {
+		t.Error("expected invalid code to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("My App", "me@test.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/My%20App:me@test.com?") {
+		t.Errorf("unexpected uri %s", uri)
+	} else if !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=My+App") {
+		t.Errorf("expected secret and issuer in %s", uri)
+	}
+}
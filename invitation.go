@@ -0,0 +1,128 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+// invitations lets account admins list (GET) and send (POST
+// /account/invitations) invitations to join their account, resend one (POST
+// /account/invitations/{id}/resend) or revoke it (DELETE
+// /account/invitations/{id}).
+func (a *accounts) invitations(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if auth.Role < middleware.AccountAdminRole {
+		http.Error(w, "only account admins can manage invitations", http.StatusForbidden)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	id := getURLPart(r.URL.Path, 3)
+
+	switch {
+	case r.Method == http.MethodGet:
+		list, err := mship.ListInvitations(auth.AccountID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, list)
+	case r.Method == http.MethodPost && len(id) == 0:
+		var data backend.InviteData
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		inv, err := mship.Invite(auth, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		respond(w, http.StatusCreated, inv)
+	case r.Method == http.MethodPost && getURLPart(r.URL.Path, 4) == "resend":
+		if err := mship.ResendInvitation(auth.AccountID, id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	case r.Method == http.MethodDelete && len(id) > 0:
+		if err := mship.RevokeInvitation(auth.AccountID, id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// invitation returns the email and expiry of an invitation on GET
+// /invitation?token= so the application can show it. POST /invitation/accept
+// with the token and a password creates the user and signs them in.
+func (m *membership) invitation(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	if r.Method == http.MethodGet {
+		inv, err := mship.GetInvitation(r.URL.Query().Get("token"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		result := struct {
+			Email   string    `json:"email"`
+			Role    int       `json:"role"`
+			Expires time.Time `json:"expires"`
+		}{inv.Email, inv.Role, inv.Expires}
+		respond(w, http.StatusOK, result)
+		return
+	} else if r.Method != http.MethodPost || getURLPart(r.URL.Path, 2) != "accept" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var data = new(struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	})
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := mship.AcceptInvitationAndSignIn(data.Token, data.Password, r.UserAgent(), internal.ClientIP(r))
+	if respondMFAChallenge(w, err) {
+		return
+	} else if errors.Is(err, backend.ErrInvalidInvitation) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), authErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set(middleware.HeaderRefreshToken, tokens.RefreshToken)
+	respond(w, http.StatusOK, tokens.Token)
+}
@@ -0,0 +1,177 @@
+package staticbackend
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+var invitationToken = regexp.MustCompile(`token=([\w.-]+)`)
+
+func TestInvitations(t *testing.T) {
+	mailer := &captureMailer{}
+	prev := backend.Emailer
+	backend.Emailer = mailer
+	defer func() {
+		backend.Emailer = prev
+	}()
+
+	lastToken := func() string {
+		if len(mailer.sent) == 0 {
+			t.Fatal("expected an invitation email")
+		}
+
+		m := invitationToken.FindStringSubmatch(mailer.sent[len(mailer.sent)-1].HTMLBody)
+		if len(m) != 2 {
+			t.Fatalf("expected a token in %s", mailer.sent[len(mailer.sent)-1].HTMLBody)
+		}
+		return m[1]
+	}
+
+	data := backend.InviteData{Email: "invitee@test.com", Role: 50, Link: "https://app.test/invite"}
+
+	// only account admins can invite
+	if resp := sessionReq(t, acct.invitations, "POST", "/account/invitations", userToken, data); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403 got %s", GetResponseBody(t, resp))
+	}
+
+	resp := dbReq(t, acct.invitations, "POST", "/account/invitations", data)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var inv model.Invitation
+	if err := parseBody(resp.Body, &inv); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := dbReq(t, acct.invitations, "POST", "/account/invitations", data); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 inviting the same email twice got %s", GetResponseBody(t, resp))
+	}
+
+	firstToken := lastToken()
+
+	path := "/account/invitations/" + inv.ID + "/resend"
+	if resp := dbReq(t, acct.invitations, "POST", path, nil); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	token := lastToken()
+
+	// resending invalidates the previous link
+	if resp := sessionReq(t, mship.invitation, "GET", "/invitation?token="+firstToken, "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for the first link got %s", GetResponseBody(t, resp))
+	}
+
+	resp = sessionReq(t, mship.invitation, "GET", "/invitation?token="+token, "", nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	accept := map[string]string{"token": token, "password": "invited1234"}
+	resp = sessionReq(t, mship.invitation, "POST", "/invitation/accept", "", accept)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var sessionToken string
+	if err := parseBody(resp.Body, &sessionToken); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = sessionReq(t, mship.me, "GET", "/me", sessionToken, nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if me.AccountID != testAccountID || me.Role != 50 || me.Email != data.Email {
+		t.Errorf("expected the invitee in the account with role 50 got %v", me)
+	}
+
+	// an invitation can only be accepted once
+	if resp := sessionReq(t, mship.invitation, "POST", "/invitation/accept", "", accept); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 got %s", GetResponseBody(t, resp))
+	}
+
+	data.Email = "revoked@test.com"
+	resp = dbReq(t, acct.invitations, "POST", "/account/invitations", data)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if err := parseBody(resp.Body, &inv); err != nil {
+		t.Fatal(err)
+	}
+
+	token = lastToken()
+
+	if resp := dbReq(t, acct.invitations, "DELETE", "/account/invitations/"+inv.ID, nil); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, acct.invitations, "GET", "/account/invitations", nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var pending []model.Invitation
+	if err := parseBody(resp.Body, &pending); err != nil {
+		t.Fatal(err)
+	} else if len(pending) != 0 {
+		t.Errorf("expected no pending invitations got %v", pending)
+	}
+
+	if resp := sessionReq(t, mship.invitation, "GET", "/invitation?token="+token, "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for a revoked invitation got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestInvitationWithExternalLogin(t *testing.T) {
+	if err := loadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	mailer := &captureMailer{}
+	prev := backend.Emailer
+	backend.Emailer = mailer
+	defer func() {
+		backend.Emailer = prev
+	}()
+
+	data := backend.InviteData{Email: "oauth-invitee@test.com", Link: "https://app.test/invite?step=1"}
+	if resp := dbReq(t, acct.invitations, "POST", "/account/invitations", data); resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	m := invitationToken.FindStringSubmatch(mailer.sent[len(mailer.sent)-1].HTMLBody)
+	if len(m) != 2 {
+		t.Fatal("expected a token in the invitation email")
+	}
+
+	idp := newMockOIDC(t)
+	enableMockOIDC(t, "mockinvite", idp.URL)
+
+	el := &ExternalLogins{log: backend.Log}
+
+	// the external email differs from the invited one
+	idp.as("invite-subject", "personal@test.com")
+	extuser := oidcSignIn(t, el, "mockinvite", "invitereq1", "invite="+m[1])
+
+	resp := sessionReq(t, mship.me, "GET", "/me", extuser.Token, nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if me.AccountID != testAccountID || me.Email != data.Email {
+		t.Errorf("expected the invited user in the account got %v", me)
+	}
+}
@@ -0,0 +1,65 @@
+package staticbackend
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+// respondTooManyAttempts writes a 429 status with a Retry-After header when
+// the email or IP must wait before another attempt and returns false for any
+// other error
+func respondTooManyAttempts(w http.ResponseWriter, err error) bool {
+	var attemptsErr *backend.TooManyAttemptsError
+	if !errors.As(err, &attemptsErr) {
+		return false
+	}
+
+	retry := int(math.Ceil(attemptsErr.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	http.Error(w, attemptsErr.Error(), http.StatusTooManyRequests)
+	return true
+}
+
+// sudoLockouts returns (GET) the failed sign-in attempts and lockout state of
+// an email or removes them (DELETE), the email is in the "email" query string
+func sudoLockouts(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	email := strings.ToLower(r.URL.Query().Get("email"))
+	if len(email) == 0 {
+		http.Error(w, "missing email", http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		lockout, err := mship.LockoutStatus(email)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, lockout)
+	case http.MethodDelete:
+		if err := mship.Unlock(email); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
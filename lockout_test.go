@@ -0,0 +1,186 @@
+package staticbackend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func withAttemptPolicies(t *testing.T) {
+	login, ip := backend.LoginAttemptPolicy, backend.IPAttemptPolicy
+	t.Cleanup(func() {
+		backend.LoginAttemptPolicy, backend.IPAttemptPolicy = login, ip
+	})
+
+	backend.LoginAttemptPolicy = cache.AttemptPolicy{
+		FreeAttempts:    3,
+		MaxAttempts:     3,
+		Window:          time.Minute,
+		LockoutDuration: time.Minute,
+		MaxLockout:      time.Minute,
+	}
+	// tests share the same client IP
+	backend.IPAttemptPolicy = cache.AttemptPolicy{
+		FreeAttempts: 1000,
+		MaxAttempts:  1000,
+		Window:       time.Minute,
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	withAttemptPolicies(t)
+
+	email, pw := "lockout@test.com", "lockout_pw"
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := backend.Membership(conf).CreateUser(testAccountID, email, pw, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		resp := mfaLogin(t, email, "wrong-password")
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status 401 got %s", GetResponseBody(t, resp))
+		}
+	}
+
+	// the right password is rejected while locked
+	resp := mfaLogin(t, email, pw)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429 got %s", GetResponseBody(t, resp))
+	} else if len(resp.Header.Get("Retry-After")) == 0 {
+		t.Error("expected a Retry-After header")
+	}
+
+	logs, err := backend.DB.ListEmailLogs(conf.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notified := false
+	for _, l := range logs {
+		if l.To == email {
+			notified = true
+		}
+	}
+	if !notified {
+		t.Error("expected a lockout notification email")
+	}
+
+	path := "/sudo/lockouts?email=" + url.QueryEscape(email)
+	resp = dbReq(t, sudoLockouts, "GET", path, nil, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var lockout backend.Lockout
+	if err := parseBody(resp.Body, &lockout); err != nil {
+		t.Fatal(err)
+	} else if !lockout.Locked || lockout.Failures != 3 {
+		t.Errorf("expected a lockout after 3 failures got %v", lockout)
+	}
+
+	resp = dbReq(t, sudoLockouts, "DELETE", path, nil, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = mfaLogin(t, email, pw)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 after unlock got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestIPLockoutIgnoresSpoofedIP(t *testing.T) {
+	withAttemptPolicies(t)
+
+	backend.IPAttemptPolicy = cache.AttemptPolicy{
+		FreeAttempts:    3,
+		MaxAttempts:     3,
+		Window:          time.Minute,
+		LockoutDuration: time.Minute,
+		MaxLockout:      time.Minute,
+	}
+
+	login := func(i int) *http.Response {
+		b, err := json.Marshal(model.Login{Email: fmt.Sprintf("spoof%d@test.com", i), Password: "wrong-password"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest("POST", "/login", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("SB-PUBLIC-KEY", pubKey)
+		req.RemoteAddr = "198.51.100.20:1234"
+		// a new spoofed IP for each attempt
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+
+		w := httptest.NewRecorder()
+		middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL)(http.HandlerFunc(mship.login)).ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	for i := 0; i < 3; i++ {
+		if resp := login(i); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status 401 got %s", GetResponseBody(t, resp))
+		}
+	}
+
+	if resp := login(3); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the IP to be locked got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestMagicLinkWrongCode(t *testing.T) {
+	withAttemptPolicies(t)
+
+	data := backend.MagicLinkData{
+		FromEmail: "unit@test.com",
+		Email:     userEmail,
+		Subject:   "Magic link",
+		Body:      "<p>[link]</p>",
+		MagicLink: "https://mycustom.link/with-code",
+	}
+	resp := sessionReq(t, mship.magicLink, "POST", "/login/magic", "", data)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	validate := func(code string) *http.Response {
+		u := fmt.Sprintf("/login/magic?email=%s&code=%s", url.QueryEscape(userEmail), code)
+		return sessionReq(t, mship.magicLink, "GET", u, "", nil)
+	}
+
+	if resp := validate("123456"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 with a wrong code got %s", GetResponseBody(t, resp))
+	}
+
+	// in dev mode, the code is always 666333
+	resp = validate("666333")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	// a magic link can only be used once
+	if resp := validate("666333"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status 401 when reusing the code got %s", GetResponseBody(t, resp))
+	}
+
+	if resp := mfaLogin(t, userEmail, userPassword); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 got %s", GetResponseBody(t, resp))
+	}
+}
@@ -36,17 +36,8 @@ func Get(cfg config.AppConfig) *Logger {
 			writers = append(writers, newFileWriter(cfg.LogFilename))
 		}
 
-		if cfg.LogConsoleLevel != "" {
-			level, err := zerolog.ParseLevel(cfg.LogConsoleLevel)
-			if err != nil {
-				panic(err)
-			}
-
-			zerolog.SetGlobalLevel(level)
-		}
-
-		if cfg.AppEnv == "dev" {
-			zerolog.SetGlobalLevel(zerolog.TraceLevel)
+		if err := SetLevel(cfg); err != nil {
+			panic(err)
 		}
 
 		multiWriters := io.MultiWriter(writers...)
@@ -58,3 +49,23 @@ func Get(cfg config.AppConfig) *Logger {
 
 	return &logger
 }
+
+// SetLevel applies the configured console level, it can be called again to
+// change the level of a running server
+func SetLevel(cfg config.AppConfig) error {
+	level := zerolog.DebugLevel
+	if cfg.LogConsoleLevel != "" {
+		l, err := zerolog.ParseLevel(cfg.LogConsoleLevel)
+		if err != nil {
+			return err
+		}
+		level = l
+	}
+
+	if cfg.AppEnv == "dev" {
+		level = zerolog.TraceLevel
+	}
+
+	zerolog.SetGlobalLevel(level)
+	return nil
+}
@@ -2,6 +2,7 @@ package staticbackend
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"net/http"
@@ -56,13 +57,16 @@ func (m *membership) login(w http.ResponseWriter, r *http.Request) {
 
 	mship := backend.Membership(conf)
 
-	token, err := mship.Authenticate(l.Email, l.Password)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+	tokens, err := mship.Login(l.Email, l.Password, r.UserAgent(), internal.ClientIP(r))
+	if respondMFAChallenge(w, err) || respondTooManyAttempts(w, err) {
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), authErrorStatus(err, http.StatusUnauthorized))
 		return
 	}
 
-	respond(w, http.StatusOK, token)
+	w.Header().Set(middleware.HeaderRefreshToken, tokens.RefreshToken)
+	respond(w, http.StatusOK, tokens.Token)
 }
 
 func (m *membership) register(w http.ResponseWriter, r *http.Request) {
@@ -80,13 +84,20 @@ func (m *membership) register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	mship := backend.Membership(conf)
-	token, err := mship.Register(l.Email, l.Password)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	tokens, err := mship.SignUp(l.Email, l.Password, r.UserAgent(), internal.ClientIP(r))
+	if respondMFAChallenge(w, err) {
+		return
+	} else if errors.Is(err, backend.ErrEmailNotVerified) {
+		// the user is created but must verify their email before signing in
+		respond(w, http.StatusAccepted, map[string]bool{"verifyEmail": true})
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), authErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
-	respond(w, http.StatusOK, token)
+	w.Header().Set(middleware.HeaderRefreshToken, tokens.RefreshToken)
+	respond(w, http.StatusOK, tokens.Token)
 }
 
 func (m *membership) setResetCode(w http.ResponseWriter, r *http.Request) {
@@ -131,8 +142,12 @@ func (m *membership) resetPassword(w http.ResponseWriter, r *http.Request) {
 	}
 
 	mship := backend.Membership(conf)
-	if err := mship.ResetPassword(data.Email, data.Code, data.Password); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := mship.RecoverPassword(data.Email, data.Code, data.Password, internal.ClientIP(r)); err != nil {
+		if respondTooManyAttempts(w, err) {
+			return
+		}
+
+		http.Error(w, err.Error(), authErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -232,15 +247,60 @@ func (m *membership) sudoGetTokenFromAccountID(w http.ResponseWriter, r *http.Re
 }
 
 func (m *membership) me(w http.ResponseWriter, r *http.Request) {
-	_, auth, err := middleware.Extract(r, true)
+	conf, auth, err := middleware.Extract(r, true)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
+	if r.Method == http.MethodPut {
+		m.updateProfile(w, r, conf, auth)
+		return
+	}
+
 	respond(w, http.StatusOK, auth)
 }
 
+// profile returns the signed-in user with their profile fields
+func (m *membership) profile(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		m.updateProfile(w, r, conf, auth)
+		return
+	}
+
+	user, err := backend.DB.FindUserByID(conf.Name, auth.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	user.Token = ""
+	respond(w, http.StatusOK, user)
+}
+
+func (m *membership) updateProfile(w http.ResponseWriter, r *http.Request, conf model.DatabaseConfig, auth model.Auth) {
+	var data model.UserProfile
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := backend.Membership(conf).UpdateProfile(auth.UserID, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user.Token = ""
+	respond(w, http.StatusOK, user)
+}
+
 func (m *membership) magicLink(w http.ResponseWriter, r *http.Request) {
 	conf, _, err := middleware.Extract(r, false)
 	if err != nil {
@@ -255,18 +315,16 @@ func (m *membership) magicLink(w http.ResponseWriter, r *http.Request) {
 		email := r.URL.Query().Get("email")
 		code := r.URL.Query().Get("code")
 
-		token, err := mship.ValidateMagicLink(email, code)
-		if err != nil {
-			if strings.Contains(err.Error(), "maximum") {
-				http.Error(w, err.Error(), http.StatusTooManyRequests)
-				return
-			}
-
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		tokens, err := mship.LoginWithMagicLink(email, code, r.UserAgent(), internal.ClientIP(r))
+		if respondMFAChallenge(w, err) || respondTooManyAttempts(w, err) {
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), authErrorStatus(err, http.StatusUnauthorized))
 			return
 		}
 
-		respond(w, http.StatusOK, token)
+		w.Header().Set(middleware.HeaderRefreshToken, tokens.RefreshToken)
+		respond(w, http.StatusOK, tokens.Token)
 		return
 	}
 
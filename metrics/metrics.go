@@ -0,0 +1,209 @@
+// Package metrics exposes Prometheus metrics for the HTTP API, the database
+// and cache engines, the server-side functions, the scheduled tasks and the
+// realtime connections.
+//
+// The database and cache are instrumented by wrapping any
+// [github.com/staticbackendhq/core/database.Persister] with [NewPersister]
+// and any [github.com/staticbackendhq/core/cache.Volatilizer] with
+// [NewVolatilizer].
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "staticbackend"
+
+// Registry holds the StaticBackend collectors and the Go runtime and process
+// collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of the HTTP requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_call_duration_seconds",
+		Help:      "Duration of the database calls by engine and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"engine", "method"})
+
+	dbErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_call_errors_total",
+		Help:      "Number of database calls returning an error by engine and method.",
+	}, []string{"engine", "method"})
+
+	cacheDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cache_call_duration_seconds",
+		Help:      "Duration of the cache calls by engine and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"engine", "method"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Number of cache reads by engine and result (hit or miss).",
+	}, []string{"engine", "result"})
+
+	functionRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "function_executions_total",
+		Help:      "Number of server-side function executions by database and status.",
+	}, []string{"database", "status"})
+
+	functionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "function_duration_seconds",
+		Help:      "Duration of the server-side function executions by database.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"database"})
+
+	taskRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_runs_total",
+		Help:      "Number of scheduled task runs by database, task type and status.",
+	}, []string{"database", "type", "status"})
+
+	sseClients = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sse_connected_clients",
+		Help:      "Number of connected Server-Sent Events clients.",
+	})
+
+	pubsubMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pubsub_messages_total",
+		Help:      "Number of pub/sub messages published and delivered to realtime clients.",
+	}, []string{"direction"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		dbDuration,
+		dbErrors,
+		cacheDuration,
+		cacheLookups,
+		functionRuns,
+		functionDuration,
+		taskRuns,
+		sseClients,
+		pubsubMessages,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format. The requests must
+// have an "Authorization: Bearer {token}" header, the metrics are disabled
+// when token is empty since they expose the database names.
+func Handler(token string) http.Handler {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(token) == 0 {
+			http.Error(w, "metrics are disabled, set METRICS_TOKEN to enable them", http.StatusNotFound)
+			return
+		}
+
+		auth := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(auth, []byte("Bearer "+token)) != 1 {
+			http.Error(w, "invalid metrics token", http.StatusUnauthorized)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Instrument records the count and duration of the requests handled by mux.
+// The route label is the mux pattern matching the request to keep a low
+// number of series.
+func Instrument(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if len(route) == 0 {
+			route = "unmatched"
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		mux.ServeHTTP(rec, r)
+
+		httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+		httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// FunctionExecuted records a server-side function execution
+func FunctionExecuted(dbName string, start time.Time, err error) {
+	functionRuns.WithLabelValues(dbName, status(err)).Inc()
+	functionDuration.WithLabelValues(dbName).Observe(time.Since(start).Seconds())
+}
+
+// TaskRan records a scheduled task run
+func TaskRan(dbName, typ string, err error) {
+	taskRuns.WithLabelValues(dbName, typ, status(err)).Inc()
+}
+
+// ClientConnected increments the number of connected realtime clients
+func ClientConnected() {
+	sseClients.Inc()
+}
+
+// ClientDisconnected decrements the number of connected realtime clients
+func ClientDisconnected() {
+	sseClients.Dec()
+}
+
+// MessageDelivered records a pub/sub message sent to a realtime client
+func MessageDelivered() {
+	pubsubMessages.WithLabelValues("delivered").Inc()
+}
+
+func status(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// statusRecorder keeps the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps the Server-Sent Events streaming working
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/database/memory"
+	"github.com/staticbackendhq/core/logger"
+)
+
+func TestPersisterAndCacheMetrics(t *testing.T) {
+	log := logger.Get(config.AppConfig{})
+	c := NewVolatilizer(cache.NewDevCache(log), "test")
+	db := NewPersister(memory.New(c.PublishDocument), "test")
+
+	if err := c.Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("not-found"); err == nil {
+		t.Fatal("expected a cache miss")
+	}
+
+	if hits := testutil.ToFloat64(cacheLookups.WithLabelValues("test", "hit")); hits != 1 {
+		t.Errorf("expected 1 cache hit got %v", hits)
+	}
+	if misses := testutil.ToFloat64(cacheLookups.WithLabelValues("test", "miss")); misses != 1 {
+		t.Errorf("expected 1 cache miss got %v", misses)
+	}
+
+	if _, err := db.FindDatabase("not-found"); err == nil {
+		t.Fatal("expected an error finding an unknown database")
+	}
+
+	if n := testutil.ToFloat64(dbErrors.WithLabelValues("test", "FindDatabase")); n != 1 {
+		t.Errorf("expected 1 FindDatabase error got %v", n)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	FunctionExecuted("metricsdb", time.Now(), errors.New("boom"))
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler("secret"))
+	h := Instrument(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without token got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 got %d", w.Code)
+	}
+
+	body := w.Body.String()
+
+	// without a token the metrics are not served
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w = httptest.NewRecorder()
+	Handler("").ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 without METRICS_TOKEN got %d", w.Code)
+	}
+
+	for _, s := range []string{
+		`staticbackend_function_executions_total{database="metricsdb",status="failure"} 1`,
+		`staticbackend_http_requests_total{method="GET",route="/metrics",status="401"} 1`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("expected %q in the metrics output", s)
+		}
+	}
+}
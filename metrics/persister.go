@@ -0,0 +1,745 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+// Persister records the duration and errors of the calls to a
+// database.Persister by engine and method
+type Persister struct {
+	db     database.Persister
+	engine string
+}
+
+// NewPersister wraps db, engine is used as the engine label of its calls
+func NewPersister(db database.Persister, engine string) *Persister {
+	return &Persister{db: db, engine: engine}
+}
+
+func (p *Persister) observe(method string, start time.Time, err *error) {
+	dbDuration.WithLabelValues(p.engine, method).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		dbErrors.WithLabelValues(p.engine, method).Inc()
+	}
+}
+
+func (p *Persister) Ping() (err error) {
+	defer p.observe("Ping", time.Now(), &err)
+	return p.db.Ping()
+}
+
+func (p *Persister) CreateIndex(dbName string, col string, field string) (err error) {
+	defer p.observe("CreateIndex", time.Now(), &err)
+	return p.db.CreateIndex(dbName, col, field)
+}
+
+func (p *Persister) CreateTenant(a0 model.Tenant) (r0 model.Tenant, err error) {
+	defer p.observe("CreateTenant", time.Now(), &err)
+	return p.db.CreateTenant(a0)
+}
+
+func (p *Persister) CreateDatabase(a0 model.DatabaseConfig) (r0 model.DatabaseConfig, err error) {
+	defer p.observe("CreateDatabase", time.Now(), &err)
+	return p.db.CreateDatabase(a0)
+}
+
+func (p *Persister) EmailExists(email string) (r0 bool, err error) {
+	defer p.observe("EmailExists", time.Now(), &err)
+	return p.db.EmailExists(email)
+}
+
+func (p *Persister) FindTenant(tenantID string) (r0 model.Tenant, err error) {
+	defer p.observe("FindTenant", time.Now(), &err)
+	return p.db.FindTenant(tenantID)
+}
+
+func (p *Persister) FindDatabase(baseID string) (r0 model.DatabaseConfig, err error) {
+	defer p.observe("FindDatabase", time.Now(), &err)
+	return p.db.FindDatabase(baseID)
+}
+
+func (p *Persister) DatabaseExists(name string) (r0 bool, err error) {
+	defer p.observe("DatabaseExists", time.Now(), &err)
+	return p.db.DatabaseExists(name)
+}
+
+func (p *Persister) ListDatabases() (r0 []model.DatabaseConfig, err error) {
+	defer p.observe("ListDatabases", time.Now(), &err)
+	return p.db.ListDatabases()
+}
+
+func (p *Persister) IncrementMonthlyEmailSent(baseID string) (err error) {
+	defer p.observe("IncrementMonthlyEmailSent", time.Now(), &err)
+	return p.db.IncrementMonthlyEmailSent(baseID)
+}
+
+func (p *Persister) GetTenantByEmail(email string) (r0 model.Tenant, err error) {
+	defer p.observe("GetTenantByEmail", time.Now(), &err)
+	return p.db.GetTenantByEmail(email)
+}
+
+func (p *Persister) GetTenantByStripeID(stripeID string) (r0 model.Tenant, err error) {
+	defer p.observe("GetTenantByStripeID", time.Now(), &err)
+	return p.db.GetTenantByStripeID(stripeID)
+}
+
+func (p *Persister) ActivateTenant(tenantID string, active bool) (err error) {
+	defer p.observe("ActivateTenant", time.Now(), &err)
+	return p.db.ActivateTenant(tenantID, active)
+}
+
+func (p *Persister) ChangeTenantPlan(tenantID string, plan int) (err error) {
+	defer p.observe("ChangeTenantPlan", time.Now(), &err)
+	return p.db.ChangeTenantPlan(tenantID, plan)
+}
+
+func (p *Persister) EnableExternalLogin(tenantID string, config map[string]model.OAuthConfig) (err error) {
+	defer p.observe("EnableExternalLogin", time.Now(), &err)
+	return p.db.EnableExternalLogin(tenantID, config)
+}
+
+func (p *Persister) SetSMSConfig(baseID string, config model.SMSConfig) (err error) {
+	defer p.observe("SetSMSConfig", time.Now(), &err)
+	return p.db.SetSMSConfig(baseID, config)
+}
+
+func (p *Persister) SetAllowedDomains(baseID string, domains []string) (err error) {
+	defer p.observe("SetAllowedDomains", time.Now(), &err)
+	return p.db.SetAllowedDomains(baseID, domains)
+}
+
+func (p *Persister) SetMFARole(baseID string, role int) (err error) {
+	defer p.observe("SetMFARole", time.Now(), &err)
+	return p.db.SetMFARole(baseID, role)
+}
+
+func (p *Persister) SetAuthPolicy(baseID string, policy model.AuthPolicy) (err error) {
+	defer p.observe("SetAuthPolicy", time.Now(), &err)
+	return p.db.SetAuthPolicy(baseID, policy)
+}
+
+func (p *Persister) FindDatabaseByName(name string) (r0 model.DatabaseConfig, err error) {
+	defer p.observe("FindDatabaseByName", time.Now(), &err)
+	return p.db.FindDatabaseByName(name)
+}
+
+func (p *Persister) NewID() string {
+	return p.db.NewID()
+}
+
+func (p *Persister) DeleteTenant(dbName string, email string) (err error) {
+	defer p.observe("DeleteTenant", time.Now(), &err)
+	return p.db.DeleteTenant(dbName, email)
+}
+
+func (p *Persister) GetUserByID(dbName string, accountID string, userID string) (r0 model.User, err error) {
+	defer p.observe("GetUserByID", time.Now(), &err)
+	return p.db.GetUserByID(dbName, accountID, userID)
+}
+
+func (p *Persister) FindUser(dbName string, userID string, token string) (r0 model.User, err error) {
+	defer p.observe("FindUser", time.Now(), &err)
+	return p.db.FindUser(dbName, userID, token)
+}
+
+func (p *Persister) FindUserByID(dbName string, userID string) (r0 model.User, err error) {
+	defer p.observe("FindUserByID", time.Now(), &err)
+	return p.db.FindUserByID(dbName, userID)
+}
+
+func (p *Persister) FindRootUser(dbName string, userID string, accountID string, token string) (r0 model.User, err error) {
+	defer p.observe("FindRootUser", time.Now(), &err)
+	return p.db.FindRootUser(dbName, userID, accountID, token)
+}
+
+func (p *Persister) GetRootForBase(dbName string) (r0 model.User, err error) {
+	defer p.observe("GetRootForBase", time.Now(), &err)
+	return p.db.GetRootForBase(dbName)
+}
+
+func (p *Persister) FindUserByEmail(dbName string, email string) (r0 model.User, err error) {
+	defer p.observe("FindUserByEmail", time.Now(), &err)
+	return p.db.FindUserByEmail(dbName, email)
+}
+
+func (p *Persister) UserEmailExists(dbName string, email string) (r0 bool, err error) {
+	defer p.observe("UserEmailExists", time.Now(), &err)
+	return p.db.UserEmailExists(dbName, email)
+}
+
+func (p *Persister) GetFirstUserFromAccountID(dbName string, accountID string) (r0 model.User, err error) {
+	defer p.observe("GetFirstUserFromAccountID", time.Now(), &err)
+	return p.db.GetFirstUserFromAccountID(dbName, accountID)
+}
+
+func (p *Persister) ListAccounts(dbname string) (r0 []model.Account, err error) {
+	defer p.observe("ListAccounts", time.Now(), &err)
+	return p.db.ListAccounts(dbname)
+}
+
+func (p *Persister) ListUsers(dbname string, accountID string) (r0 []model.User, err error) {
+	defer p.observe("ListUsers", time.Now(), &err)
+	return p.db.ListUsers(dbname, accountID)
+}
+
+func (p *Persister) CreateAccount(dbName string, email string) (r0 string, err error) {
+	defer p.observe("CreateAccount", time.Now(), &err)
+	return p.db.CreateAccount(dbName, email)
+}
+
+func (p *Persister) CreateUser(dbName string, tok model.User) (r0 string, err error) {
+	defer p.observe("CreateUser", time.Now(), &err)
+	return p.db.CreateUser(dbName, tok)
+}
+
+func (p *Persister) SetPasswordResetCode(dbName string, tokenID string, code string) (err error) {
+	defer p.observe("SetPasswordResetCode", time.Now(), &err)
+	return p.db.SetPasswordResetCode(dbName, tokenID, code)
+}
+
+func (p *Persister) ResetPassword(dbName string, email string, code string, password string) (err error) {
+	defer p.observe("ResetPassword", time.Now(), &err)
+	return p.db.ResetPassword(dbName, email, code, password)
+}
+
+func (p *Persister) SetUserRole(dbName string, email string, role int) (err error) {
+	defer p.observe("SetUserRole", time.Now(), &err)
+	return p.db.SetUserRole(dbName, email, role)
+}
+
+func (p *Persister) UserSetPassword(dbName string, userID string, password string) (err error) {
+	defer p.observe("UserSetPassword", time.Now(), &err)
+	return p.db.UserSetPassword(dbName, userID, password)
+}
+
+func (p *Persister) SetUserVerified(dbName string, userID string, verified bool) (err error) {
+	defer p.observe("SetUserVerified", time.Now(), &err)
+	return p.db.SetUserVerified(dbName, userID, verified)
+}
+
+func (p *Persister) RemoveUser(auth model.Auth, dbName string, userID string) (err error) {
+	defer p.observe("RemoveUser", time.Now(), &err)
+	return p.db.RemoveUser(auth, dbName, userID)
+}
+
+func (p *Persister) UpdateUserProfile(dbName string, userID string, profile model.UserProfile) (err error) {
+	defer p.observe("UpdateUserProfile", time.Now(), &err)
+	return p.db.UpdateUserProfile(dbName, userID, profile)
+}
+
+func (p *Persister) CreateDocument(auth model.Auth, dbName string, col string, doc map[string]interface{}) (r0 map[string]interface{}, err error) {
+	defer p.observe("CreateDocument", time.Now(), &err)
+	return p.db.CreateDocument(auth, dbName, col, doc)
+}
+
+func (p *Persister) BulkCreateDocument(auth model.Auth, dbName string, col string, docs []interface{}) (err error) {
+	defer p.observe("BulkCreateDocument", time.Now(), &err)
+	return p.db.BulkCreateDocument(auth, dbName, col, docs)
+}
+
+func (p *Persister) ListDocuments(auth model.Auth, dbName string, col string, params model.ListParams) (r0 model.PagedResult, err error) {
+	defer p.observe("ListDocuments", time.Now(), &err)
+	return p.db.ListDocuments(auth, dbName, col, params)
+}
+
+func (p *Persister) QueryDocuments(auth model.Auth, dbName string, col string, filter map[string]interface{}, params model.ListParams) (r0 model.PagedResult, err error) {
+	defer p.observe("QueryDocuments", time.Now(), &err)
+	return p.db.QueryDocuments(auth, dbName, col, filter, params)
+}
+
+func (p *Persister) GetDocumentByID(auth model.Auth, dbName string, col string, id string) (r0 map[string]interface{}, err error) {
+	defer p.observe("GetDocumentByID", time.Now(), &err)
+	return p.db.GetDocumentByID(auth, dbName, col, id)
+}
+
+func (p *Persister) GetDocumentsByIDs(auth model.Auth, dbName string, col string, ids []string) (r0 []map[string]interface{}, err error) {
+	defer p.observe("GetDocumentsByIDs", time.Now(), &err)
+	return p.db.GetDocumentsByIDs(auth, dbName, col, ids)
+}
+
+func (p *Persister) UpdateDocument(auth model.Auth, dbName string, col string, id string, doc map[string]interface{}) (r0 map[string]interface{}, err error) {
+	defer p.observe("UpdateDocument", time.Now(), &err)
+	return p.db.UpdateDocument(auth, dbName, col, id, doc)
+}
+
+func (p *Persister) UpdateDocuments(auth model.Auth, dbName string, col string, filters map[string]interface{}, updateFields map[string]interface{}) (r0 int64, err error) {
+	defer p.observe("UpdateDocuments", time.Now(), &err)
+	return p.db.UpdateDocuments(auth, dbName, col, filters, updateFields)
+}
+
+func (p *Persister) IncrementValue(auth model.Auth, dbName string, col string, id string, field string, n int) (err error) {
+	defer p.observe("IncrementValue", time.Now(), &err)
+	return p.db.IncrementValue(auth, dbName, col, id, field, n)
+}
+
+func (p *Persister) DeleteDocument(auth model.Auth, dbName string, col string, id string) (r0 int64, err error) {
+	defer p.observe("DeleteDocument", time.Now(), &err)
+	return p.db.DeleteDocument(auth, dbName, col, id)
+}
+
+func (p *Persister) DeleteDocuments(auth model.Auth, dbName string, col string, filters map[string]interface{}) (r0 int64, err error) {
+	defer p.observe("DeleteDocuments", time.Now(), &err)
+	return p.db.DeleteDocuments(auth, dbName, col, filters)
+}
+
+func (p *Persister) ListCollections(dbName string) (r0 []string, err error) {
+	defer p.observe("ListCollections", time.Now(), &err)
+	return p.db.ListCollections(dbName)
+}
+
+func (p *Persister) ParseQuery(clauses [][]interface{}) (r0 map[string]interface{}, err error) {
+	defer p.observe("ParseQuery", time.Now(), &err)
+	return p.db.ParseQuery(clauses)
+}
+
+func (p *Persister) AddFormSubmission(dbName string, form string, doc map[string]interface{}) (err error) {
+	defer p.observe("AddFormSubmission", time.Now(), &err)
+	return p.db.AddFormSubmission(dbName, form, doc)
+}
+
+func (p *Persister) ListFormSubmissions(dbName string, name string) (r0 []map[string]interface{}, err error) {
+	defer p.observe("ListFormSubmissions", time.Now(), &err)
+	return p.db.ListFormSubmissions(dbName, name)
+}
+
+func (p *Persister) GetForms(dbName string) (r0 []string, err error) {
+	defer p.observe("GetForms", time.Now(), &err)
+	return p.db.GetForms(dbName)
+}
+
+func (p *Persister) SaveFormDefinition(dbName string, def model.FormDefinition) (err error) {
+	defer p.observe("SaveFormDefinition", time.Now(), &err)
+	return p.db.SaveFormDefinition(dbName, def)
+}
+
+func (p *Persister) GetFormDefinition(dbName string, name string) (r0 model.FormDefinition, err error) {
+	defer p.observe("GetFormDefinition", time.Now(), &err)
+	return p.db.GetFormDefinition(dbName, name)
+}
+
+func (p *Persister) ListFormDefinitions(dbName string) (r0 []model.FormDefinition, err error) {
+	defer p.observe("ListFormDefinitions", time.Now(), &err)
+	return p.db.ListFormDefinitions(dbName)
+}
+
+func (p *Persister) DeleteFormDefinition(dbName string, name string) (err error) {
+	defer p.observe("DeleteFormDefinition", time.Now(), &err)
+	return p.db.DeleteFormDefinition(dbName, name)
+}
+
+func (p *Persister) AddFunction(dbName string, data model.ExecData) (r0 string, err error) {
+	defer p.observe("AddFunction", time.Now(), &err)
+	return p.db.AddFunction(dbName, data)
+}
+
+func (p *Persister) UpdateFunction(dbName string, id string, code string, trigger string) (err error) {
+	defer p.observe("UpdateFunction", time.Now(), &err)
+	return p.db.UpdateFunction(dbName, id, code, trigger)
+}
+
+func (p *Persister) GetFunctionForExecution(dbName string, name string) (r0 model.ExecData, err error) {
+	defer p.observe("GetFunctionForExecution", time.Now(), &err)
+	return p.db.GetFunctionForExecution(dbName, name)
+}
+
+func (p *Persister) GetFunctionByID(dbName string, id string) (r0 model.ExecData, err error) {
+	defer p.observe("GetFunctionByID", time.Now(), &err)
+	return p.db.GetFunctionByID(dbName, id)
+}
+
+func (p *Persister) GetFunctionByName(dbName string, name string) (r0 model.ExecData, err error) {
+	defer p.observe("GetFunctionByName", time.Now(), &err)
+	return p.db.GetFunctionByName(dbName, name)
+}
+
+func (p *Persister) ListFunctions(dbName string) (r0 []model.ExecData, err error) {
+	defer p.observe("ListFunctions", time.Now(), &err)
+	return p.db.ListFunctions(dbName)
+}
+
+func (p *Persister) ListFunctionsByTrigger(dbName string, trigger string) (r0 []model.ExecData, err error) {
+	defer p.observe("ListFunctionsByTrigger", time.Now(), &err)
+	return p.db.ListFunctionsByTrigger(dbName, trigger)
+}
+
+func (p *Persister) DeleteFunction(dbName string, name string) (err error) {
+	defer p.observe("DeleteFunction", time.Now(), &err)
+	return p.db.DeleteFunction(dbName, name)
+}
+
+func (p *Persister) RanFunction(dbName string, id string, rh model.ExecHistory) (err error) {
+	defer p.observe("RanFunction", time.Now(), &err)
+	return p.db.RanFunction(dbName, id, rh)
+}
+
+func (p *Persister) ListTasks() (r0 []model.Task, err error) {
+	defer p.observe("ListTasks", time.Now(), &err)
+	return p.db.ListTasks()
+}
+
+func (p *Persister) ListTasksByBase(dbName string) (r0 []model.Task, err error) {
+	defer p.observe("ListTasksByBase", time.Now(), &err)
+	return p.db.ListTasksByBase(dbName)
+}
+
+func (p *Persister) AddTask(a0 string, a1 model.Task) (r0 string, err error) {
+	defer p.observe("AddTask", time.Now(), &err)
+	return p.db.AddTask(a0, a1)
+}
+
+func (p *Persister) DeleteTask(dbName string, id string) (err error) {
+	defer p.observe("DeleteTask", time.Now(), &err)
+	return p.db.DeleteTask(dbName, id)
+}
+
+func (p *Persister) GetTask(dbName string, id string) (r0 model.Task, err error) {
+	defer p.observe("GetTask", time.Now(), &err)
+	return p.db.GetTask(dbName, id)
+}
+
+func (p *Persister) SetTaskPaused(dbName string, id string, paused bool) (err error) {
+	defer p.observe("SetTaskPaused", time.Now(), &err)
+	return p.db.SetTaskPaused(dbName, id, paused)
+}
+
+func (p *Persister) RanTask(dbName string, run model.TaskRun) (err error) {
+	defer p.observe("RanTask", time.Now(), &err)
+	return p.db.RanTask(dbName, run)
+}
+
+func (p *Persister) ListTaskRuns(dbName string, taskID string) (r0 []model.TaskRun, err error) {
+	defer p.observe("ListTaskRuns", time.Now(), &err)
+	return p.db.ListTaskRuns(dbName, taskID)
+}
+
+func (p *Persister) AddFile(dbName string, f model.File) (r0 string, err error) {
+	defer p.observe("AddFile", time.Now(), &err)
+	return p.db.AddFile(dbName, f)
+}
+
+func (p *Persister) GetFileByID(dbName string, fileID string) (r0 model.File, err error) {
+	defer p.observe("GetFileByID", time.Now(), &err)
+	return p.db.GetFileByID(dbName, fileID)
+}
+
+func (p *Persister) DeleteFile(dbName string, fileID string) (err error) {
+	defer p.observe("DeleteFile", time.Now(), &err)
+	return p.db.DeleteFile(dbName, fileID)
+}
+
+func (p *Persister) ListAllFiles(dbName string, accountID string) (r0 []model.File, err error) {
+	defer p.observe("ListAllFiles", time.Now(), &err)
+	return p.db.ListAllFiles(dbName, accountID)
+}
+
+func (p *Persister) GetFileByChecksum(dbName string, checksum string) (r0 model.File, err error) {
+	defer p.observe("GetFileByChecksum", time.Now(), &err)
+	return p.db.GetFileByChecksum(dbName, checksum)
+}
+
+func (p *Persister) CountFileReferences(dbName string, key string) (r0 int64, err error) {
+	defer p.observe("CountFileReferences", time.Now(), &err)
+	return p.db.CountFileReferences(dbName, key)
+}
+
+func (p *Persister) AddSMSMessage(dbName string, msg model.SMSMessage) (r0 string, err error) {
+	defer p.observe("AddSMSMessage", time.Now(), &err)
+	return p.db.AddSMSMessage(dbName, msg)
+}
+
+func (p *Persister) UpdateSMSStatus(dbName string, messageID string, status string, errMsg string) (err error) {
+	defer p.observe("UpdateSMSStatus", time.Now(), &err)
+	return p.db.UpdateSMSStatus(dbName, messageID, status, errMsg)
+}
+
+func (p *Persister) GetSMSMessageByID(dbName string, id string) (r0 model.SMSMessage, err error) {
+	defer p.observe("GetSMSMessageByID", time.Now(), &err)
+	return p.db.GetSMSMessageByID(dbName, id)
+}
+
+func (p *Persister) ListSMSMessages(dbName string) (r0 []model.SMSMessage, err error) {
+	defer p.observe("ListSMSMessages", time.Now(), &err)
+	return p.db.ListSMSMessages(dbName)
+}
+
+func (p *Persister) SaveEmailTemplate(dbName string, tmpl model.EmailTemplate) (err error) {
+	defer p.observe("SaveEmailTemplate", time.Now(), &err)
+	return p.db.SaveEmailTemplate(dbName, tmpl)
+}
+
+func (p *Persister) GetEmailTemplate(dbName string, name string) (r0 model.EmailTemplate, err error) {
+	defer p.observe("GetEmailTemplate", time.Now(), &err)
+	return p.db.GetEmailTemplate(dbName, name)
+}
+
+func (p *Persister) ListEmailTemplates(dbName string) (r0 []model.EmailTemplate, err error) {
+	defer p.observe("ListEmailTemplates", time.Now(), &err)
+	return p.db.ListEmailTemplates(dbName)
+}
+
+func (p *Persister) DeleteEmailTemplate(dbName string, name string) (err error) {
+	defer p.observe("DeleteEmailTemplate", time.Now(), &err)
+	return p.db.DeleteEmailTemplate(dbName, name)
+}
+
+func (p *Persister) AddEmailLog(dbName string, entry model.EmailLog) (r0 string, err error) {
+	defer p.observe("AddEmailLog", time.Now(), &err)
+	return p.db.AddEmailLog(dbName, entry)
+}
+
+func (p *Persister) ListEmailLogs(dbName string) (r0 []model.EmailLog, err error) {
+	defer p.observe("ListEmailLogs", time.Now(), &err)
+	return p.db.ListEmailLogs(dbName)
+}
+
+func (p *Persister) CreateSession(dbName string, s model.Session) (r0 string, err error) {
+	defer p.observe("CreateSession", time.Now(), &err)
+	return p.db.CreateSession(dbName, s)
+}
+
+func (p *Persister) GetSessionByID(dbName string, id string) (r0 model.Session, err error) {
+	defer p.observe("GetSessionByID", time.Now(), &err)
+	return p.db.GetSessionByID(dbName, id)
+}
+
+func (p *Persister) ListSessions(dbName string, userID string) (r0 []model.Session, err error) {
+	defer p.observe("ListSessions", time.Now(), &err)
+	return p.db.ListSessions(dbName, userID)
+}
+
+func (p *Persister) RotateSession(dbName string, s model.Session, prevHash string) (ok bool, err error) {
+	defer p.observe("RotateSession", time.Now(), &err)
+	return p.db.RotateSession(dbName, s, prevHash)
+}
+
+func (p *Persister) DeleteSession(dbName string, id string) (err error) {
+	defer p.observe("DeleteSession", time.Now(), &err)
+	return p.db.DeleteSession(dbName, id)
+}
+
+func (p *Persister) DeleteUserSessions(dbName string, userID string) (err error) {
+	defer p.observe("DeleteUserSessions", time.Now(), &err)
+	return p.db.DeleteUserSessions(dbName, userID)
+}
+
+func (p *Persister) SaveMFA(dbName string, m model.MFA) (err error) {
+	defer p.observe("SaveMFA", time.Now(), &err)
+	return p.db.SaveMFA(dbName, m)
+}
+
+func (p *Persister) GetMFA(dbName string, userID string) (r0 model.MFA, err error) {
+	defer p.observe("GetMFA", time.Now(), &err)
+	return p.db.GetMFA(dbName, userID)
+}
+
+func (p *Persister) DeleteMFA(dbName string, userID string) (err error) {
+	defer p.observe("DeleteMFA", time.Now(), &err)
+	return p.db.DeleteMFA(dbName, userID)
+}
+
+func (p *Persister) CreateAPIKey(dbName string, k model.APIKey) (r0 string, err error) {
+	defer p.observe("CreateAPIKey", time.Now(), &err)
+	return p.db.CreateAPIKey(dbName, k)
+}
+
+func (p *Persister) GetAPIKeyByHash(dbName string, hash string) (r0 model.APIKey, err error) {
+	defer p.observe("GetAPIKeyByHash", time.Now(), &err)
+	return p.db.GetAPIKeyByHash(dbName, hash)
+}
+
+func (p *Persister) ListAPIKeys(dbName string) (r0 []model.APIKey, err error) {
+	defer p.observe("ListAPIKeys", time.Now(), &err)
+	return p.db.ListAPIKeys(dbName)
+}
+
+func (p *Persister) TouchAPIKey(dbName string, id string, lastUsed time.Time, ip string) (err error) {
+	defer p.observe("TouchAPIKey", time.Now(), &err)
+	return p.db.TouchAPIKey(dbName, id, lastUsed, ip)
+}
+
+func (p *Persister) DeleteAPIKey(dbName string, id string) (err error) {
+	defer p.observe("DeleteAPIKey", time.Now(), &err)
+	return p.db.DeleteAPIKey(dbName, id)
+}
+
+func (p *Persister) CreateIdentity(dbName string, idt model.Identity) (r0 string, err error) {
+	defer p.observe("CreateIdentity", time.Now(), &err)
+	return p.db.CreateIdentity(dbName, idt)
+}
+
+func (p *Persister) FindIdentity(dbName string, provider string, subject string) (r0 model.Identity, err error) {
+	defer p.observe("FindIdentity", time.Now(), &err)
+	return p.db.FindIdentity(dbName, provider, subject)
+}
+
+func (p *Persister) ListIdentities(dbName string, userID string) (r0 []model.Identity, err error) {
+	defer p.observe("ListIdentities", time.Now(), &err)
+	return p.db.ListIdentities(dbName, userID)
+}
+
+func (p *Persister) DeleteIdentity(dbName string, userID string, id string) (err error) {
+	defer p.observe("DeleteIdentity", time.Now(), &err)
+	return p.db.DeleteIdentity(dbName, userID, id)
+}
+
+func (p *Persister) SaveRole(dbName string, role model.Role) (err error) {
+	defer p.observe("SaveRole", time.Now(), &err)
+	return p.db.SaveRole(dbName, role)
+}
+
+func (p *Persister) ListRoles(dbName string) (r0 []model.Role, err error) {
+	defer p.observe("ListRoles", time.Now(), &err)
+	return p.db.ListRoles(dbName)
+}
+
+func (p *Persister) DeleteRole(dbName string, name string) (err error) {
+	defer p.observe("DeleteRole", time.Now(), &err)
+	return p.db.DeleteRole(dbName, name)
+}
+
+func (p *Persister) CreateGroup(dbName string, g model.Group) (r0 string, err error) {
+	defer p.observe("CreateGroup", time.Now(), &err)
+	return p.db.CreateGroup(dbName, g)
+}
+
+func (p *Persister) GetGroup(dbName string, accountID string, id string) (r0 model.Group, err error) {
+	defer p.observe("GetGroup", time.Now(), &err)
+	return p.db.GetGroup(dbName, accountID, id)
+}
+
+func (p *Persister) UpdateGroup(dbName string, g model.Group) (err error) {
+	defer p.observe("UpdateGroup", time.Now(), &err)
+	return p.db.UpdateGroup(dbName, g)
+}
+
+func (p *Persister) ListGroups(dbName string, accountID string) (r0 []model.Group, err error) {
+	defer p.observe("ListGroups", time.Now(), &err)
+	return p.db.ListGroups(dbName, accountID)
+}
+
+func (p *Persister) DeleteGroup(dbName string, accountID string, id string) (err error) {
+	defer p.observe("DeleteGroup", time.Now(), &err)
+	return p.db.DeleteGroup(dbName, accountID, id)
+}
+
+func (p *Persister) AddGroupMember(dbName string, groupID string, userID string) (err error) {
+	defer p.observe("AddGroupMember", time.Now(), &err)
+	return p.db.AddGroupMember(dbName, groupID, userID)
+}
+
+func (p *Persister) RemoveGroupMember(dbName string, groupID string, userID string) (err error) {
+	defer p.observe("RemoveGroupMember", time.Now(), &err)
+	return p.db.RemoveGroupMember(dbName, groupID, userID)
+}
+
+func (p *Persister) ListUserGroups(dbName string, userID string) (r0 []model.Group, err error) {
+	defer p.observe("ListUserGroups", time.Now(), &err)
+	return p.db.ListUserGroups(dbName, userID)
+}
+
+func (p *Persister) CreateInvitation(dbName string, inv model.Invitation) (r0 string, err error) {
+	defer p.observe("CreateInvitation", time.Now(), &err)
+	return p.db.CreateInvitation(dbName, inv)
+}
+
+func (p *Persister) GetInvitation(dbName string, id string) (r0 model.Invitation, err error) {
+	defer p.observe("GetInvitation", time.Now(), &err)
+	return p.db.GetInvitation(dbName, id)
+}
+
+func (p *Persister) ListInvitations(dbName string, accountID string) (r0 []model.Invitation, err error) {
+	defer p.observe("ListInvitations", time.Now(), &err)
+	return p.db.ListInvitations(dbName, accountID)
+}
+
+func (p *Persister) RenewInvitation(dbName string, id string, nonce string, expires time.Time) (err error) {
+	defer p.observe("RenewInvitation", time.Now(), &err)
+	return p.db.RenewInvitation(dbName, id, nonce, expires)
+}
+
+func (p *Persister) DeleteInvitation(dbName string, id string) (err error) {
+	defer p.observe("DeleteInvitation", time.Now(), &err)
+	return p.db.DeleteInvitation(dbName, id)
+}
+
+func (p *Persister) ListDocumentsByOwner(dbName string, col string, ownerID string) (r0 []map[string]interface{}, err error) {
+	defer p.observe("ListDocumentsByOwner", time.Now(), &err)
+	return p.db.ListDocumentsByOwner(dbName, col, ownerID)
+}
+
+func (p *Persister) DeleteDocumentsByOwner(dbName string, col string, ownerID string) (r0 int64, err error) {
+	defer p.observe("DeleteDocumentsByOwner", time.Now(), &err)
+	return p.db.DeleteDocumentsByOwner(dbName, col, ownerID)
+}
+
+func (p *Persister) ListFormSubmissionsByEmail(dbName string, email string) (r0 []map[string]interface{}, err error) {
+	defer p.observe("ListFormSubmissionsByEmail", time.Now(), &err)
+	return p.db.ListFormSubmissionsByEmail(dbName, email)
+}
+
+func (p *Persister) DeleteFormSubmissionsByEmail(dbName string, email string) (r0 int64, err error) {
+	defer p.observe("DeleteFormSubmissionsByEmail", time.Now(), &err)
+	return p.db.DeleteFormSubmissionsByEmail(dbName, email)
+}
+
+func (p *Persister) DumpLayout() string {
+	return p.db.DumpLayout()
+}
+
+func (p *Persister) DumpTables(dbName string) (r0 []string, err error) {
+	defer p.observe("DumpTables", time.Now(), &err)
+	return p.db.DumpTables(dbName)
+}
+
+func (p *Persister) DumpRows(dbName string, tables []string, fn func(table string, row map[string]any) error) (err error) {
+	defer p.observe("DumpRows", time.Now(), &err)
+	return p.db.DumpRows(dbName, tables, fn)
+}
+
+func (p *Persister) RestoreTenant(cus model.Tenant, base model.DatabaseConfig) (err error) {
+	defer p.observe("RestoreTenant", time.Now(), &err)
+	return p.db.RestoreTenant(cus, base)
+}
+
+func (p *Persister) RestoreRows(dbName string, table string, rows []map[string]any) (err error) {
+	defer p.observe("RestoreRows", time.Now(), &err)
+	return p.db.RestoreRows(dbName, table, rows)
+}
+
+func (p *Persister) DumpDocuments(dbName string, cols []string, fn func(col string, doc model.PortableDocument) error) (err error) {
+	defer p.observe("DumpDocuments", time.Now(), &err)
+	return p.db.DumpDocuments(dbName, cols, fn)
+}
+
+func (p *Persister) RestoreDocuments(dbName string, col string, docs []model.PortableDocument) (err error) {
+	defer p.observe("RestoreDocuments", time.Now(), &err)
+	return p.db.RestoreDocuments(dbName, col, docs)
+}
+
+func (p *Persister) RestoreAccount(dbName string, a model.Account) (err error) {
+	defer p.observe("RestoreAccount", time.Now(), &err)
+	return p.db.RestoreAccount(dbName, a)
+}
+
+func (p *Persister) RestoreUser(dbName string, tok model.User) (err error) {
+	defer p.observe("RestoreUser", time.Now(), &err)
+	return p.db.RestoreUser(dbName, tok)
+}
+
+func (p *Persister) RestoreFile(dbName string, f model.File) (err error) {
+	defer p.observe("RestoreFile", time.Now(), &err)
+	return p.db.RestoreFile(dbName, f)
+}
+
+func (p *Persister) DeleteDatabase(dbName string) (err error) {
+	defer p.observe("DeleteDatabase", time.Now(), &err)
+	return p.db.DeleteDatabase(dbName)
+}
+
+func (p *Persister) Count(auth model.Auth, dbName string, col string, filters map[string]interface{}) (r0 int64, err error) {
+	defer p.observe("Count", time.Now(), &err)
+	return p.db.Count(auth, dbName, col, filters)
+}
@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/model"
+)
+
+// Volatilizer records the duration of the calls to a cache.Volatilizer, the
+// hits and misses of its reads and the published pub/sub messages
+type Volatilizer struct {
+	v      cache.Volatilizer
+	engine string
+}
+
+// NewVolatilizer wraps v, engine is used as the engine label of its calls
+func NewVolatilizer(v cache.Volatilizer, engine string) *Volatilizer {
+	return &Volatilizer{v: v, engine: engine}
+}
+
+func (c *Volatilizer) observe(method string, start time.Time) {
+	cacheDuration.WithLabelValues(c.engine, method).Observe(time.Since(start).Seconds())
+}
+
+// lookup records a read, any error is considered a miss since the engines
+// do not share a "not found" error
+func (c *Volatilizer) lookup(err error) {
+	if err != nil {
+		cacheLookups.WithLabelValues(c.engine, "miss").Inc()
+		return
+	}
+	cacheLookups.WithLabelValues(c.engine, "hit").Inc()
+}
+
+func (c *Volatilizer) Get(key string) (string, error) {
+	defer c.observe("Get", time.Now())
+
+	s, err := c.v.Get(key)
+	c.lookup(err)
+	return s, err
+}
+
+func (c *Volatilizer) Set(key string, value string) error {
+	defer c.observe("Set", time.Now())
+	return c.v.Set(key, value)
+}
+
+func (c *Volatilizer) Del(key string) error {
+	defer c.observe("Del", time.Now())
+	return c.v.Del(key)
+}
+
+func (c *Volatilizer) SetEx(key string, value string, ttl time.Duration) error {
+	defer c.observe("SetEx", time.Now())
+	return c.v.SetEx(key, value, ttl)
+}
+
+func (c *Volatilizer) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	defer c.observe("SetNX", time.Now())
+	return c.v.SetNX(key, value, ttl)
+}
+
+func (c *Volatilizer) Exists(key string) (bool, error) {
+	defer c.observe("Exists", time.Now())
+	return c.v.Exists(key)
+}
+
+func (c *Volatilizer) Expire(key string, ttl time.Duration) (bool, error) {
+	defer c.observe("Expire", time.Now())
+	return c.v.Expire(key, ttl)
+}
+
+func (c *Volatilizer) TTL(key string) (time.Duration, error) {
+	defer c.observe("TTL", time.Now())
+	return c.v.TTL(key)
+}
+
+func (c *Volatilizer) GetTyped(key string, v any) error {
+	defer c.observe("GetTyped", time.Now())
+
+	err := c.v.GetTyped(key, v)
+	c.lookup(err)
+	return err
+}
+
+func (c *Volatilizer) SetTyped(key string, v any) error {
+	defer c.observe("SetTyped", time.Now())
+	return c.v.SetTyped(key, v)
+}
+
+func (c *Volatilizer) Inc(key string, by int64) (int64, error) {
+	defer c.observe("Inc", time.Now())
+	return c.v.Inc(key, by)
+}
+
+func (c *Volatilizer) Dec(key string, by int64) (int64, error) {
+	defer c.observe("Dec", time.Now())
+	return c.v.Dec(key, by)
+}
+
+func (c *Volatilizer) HSet(key, field, value string) error {
+	defer c.observe("HSet", time.Now())
+	return c.v.HSet(key, field, value)
+}
+
+func (c *Volatilizer) HGet(key, field string) (string, error) {
+	defer c.observe("HGet", time.Now())
+
+	v, err := c.v.HGet(key, field)
+	c.lookup(err)
+	return v, err
+}
+
+func (c *Volatilizer) HDel(key, field string) error {
+	defer c.observe("HDel", time.Now())
+	return c.v.HDel(key, field)
+}
+
+func (c *Volatilizer) HGetAll(key string) (map[string]string, error) {
+	defer c.observe("HGetAll", time.Now())
+	return c.v.HGetAll(key)
+}
+
+func (c *Volatilizer) ZAdd(key, member string, score float64) error {
+	defer c.observe("ZAdd", time.Now())
+	return c.v.ZAdd(key, member, score)
+}
+
+func (c *Volatilizer) ZIncrBy(key, member string, by float64) (float64, error) {
+	defer c.observe("ZIncrBy", time.Now())
+	return c.v.ZIncrBy(key, member, by)
+}
+
+func (c *Volatilizer) ZScore(key, member string) (float64, error) {
+	defer c.observe("ZScore", time.Now())
+
+	v, err := c.v.ZScore(key, member)
+	c.lookup(err)
+	return v, err
+}
+
+func (c *Volatilizer) ZRem(key, member string) error {
+	defer c.observe("ZRem", time.Now())
+	return c.v.ZRem(key, member)
+}
+
+func (c *Volatilizer) ZRange(key string, start, stop int64, reverse bool) ([]cache.ScoredMember, error) {
+	defer c.observe("ZRange", time.Now())
+	return c.v.ZRange(key, start, stop, reverse)
+}
+
+func (c *Volatilizer) Subscribe(send chan model.Command, token, channel string, close chan bool) {
+	c.v.Subscribe(send, token, channel, close)
+}
+
+func (c *Volatilizer) Publish(msg model.Command) error {
+	defer c.observe("Publish", time.Now())
+
+	pubsubMessages.WithLabelValues("published").Inc()
+	return c.v.Publish(msg)
+}
+
+func (c *Volatilizer) PublishDocument(auth model.Auth, dbname, channel, typ string, v any) {
+	defer c.observe("PublishDocument", time.Now())
+
+	pubsubMessages.WithLabelValues("published").Inc()
+	c.v.PublishDocument(auth, dbname, channel, typ, v)
+}
+
+func (c *Volatilizer) QueueWork(key, value string) error {
+	defer c.observe("QueueWork", time.Now())
+	return c.v.QueueWork(key, value)
+}
+
+func (c *Volatilizer) DequeueWork(key string) (string, error) {
+	defer c.observe("DequeueWork", time.Now())
+	return c.v.DequeueWork(key)
+}
+
+func (c *Volatilizer) Enqueue(key, value string, delay time.Duration) (string, error) {
+	defer c.observe("Enqueue", time.Now())
+	return c.v.Enqueue(key, value, delay)
+}
+
+func (c *Volatilizer) Lease(key string, visibility time.Duration, maxAttempts int) (*cache.QueueItem, error) {
+	defer c.observe("Lease", time.Now())
+	return c.v.Lease(key, visibility, maxAttempts)
+}
+
+func (c *Volatilizer) Ack(key, receipt string) (bool, error) {
+	defer c.observe("Ack", time.Now())
+	return c.v.Ack(key, receipt)
+}
+
+func (c *Volatilizer) QueueStats(key string) (cache.QueueStats, error) {
+	defer c.observe("QueueStats", time.Now())
+	return c.v.QueueStats(key)
+}
+
+func (c *Volatilizer) QueueItems(key string) ([]cache.QueueItem, error) {
+	defer c.observe("QueueItems", time.Now())
+	return c.v.QueueItems(key)
+}
+
+func (c *Volatilizer) DeadLetters(key string) ([]cache.QueueItem, error) {
+	defer c.observe("DeadLetters", time.Now())
+	return c.v.DeadLetters(key)
+}
+
+func (c *Volatilizer) AcquireLock(key, owner string, ttl time.Duration) (bool, error) {
+	defer c.observe("AcquireLock", time.Now())
+	return c.v.AcquireLock(key, owner, ttl)
+}
+
+func (c *Volatilizer) RenewLock(key, owner string, ttl time.Duration) (bool, error) {
+	defer c.observe("RenewLock", time.Now())
+	return c.v.RenewLock(key, owner, ttl)
+}
+
+func (c *Volatilizer) ReleaseLock(key, owner string) error {
+	defer c.observe("ReleaseLock", time.Now())
+	return c.v.ReleaseLock(key, owner)
+}
@@ -0,0 +1,205 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+// respondMFAChallenge writes the challenge with a 202 status when signing in
+// requires a second factor and returns false for any other error
+func respondMFAChallenge(w http.ResponseWriter, err error) bool {
+	var mfaErr *backend.MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		return false
+	}
+
+	respond(w, http.StatusAccepted, mfaErr.Challenge)
+	return true
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrInvalidMFACode), errors.Is(err, backend.ErrInvalidMFAChallenge):
+		return http.StatusUnauthorized
+	case errors.Is(err, backend.ErrMFAAlreadyEnabled), errors.Is(err, backend.ErrMFANotEnabled):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// verifyMFA exchanges a sign-in challenge and a code for a session on POST
+// /login/mfa and starts the enrollment required by the database policy on
+// POST /login/mfa/enroll
+func (m *membership) verifyMFA(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	var data = new(struct {
+		Challenge string `json:"challenge"`
+		Code      string `json:"code"`
+		Issuer    string `json:"issuer"`
+	})
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	if getURLPart(r.URL.Path, 3) == "enroll" {
+		enrollment, err := mship.EnrollMFAWithChallenge(data.Challenge, data.Issuer)
+		if err != nil {
+			http.Error(w, err.Error(), mfaErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, enrollment)
+		return
+	}
+
+	tokens, err := mship.VerifyMFA(data.Challenge, data.Code)
+	if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	w.Header().Set(middleware.HeaderRefreshToken, tokens.RefreshToken)
+	respond(w, http.StatusOK, tokens)
+}
+
+// mfa manages the second factor of the current user. GET returns its status,
+// POST starts an enrollment, PUT confirms it with a code and returns the
+// recovery codes and DELETE disables it. POST /mfa/recovery regenerates the
+// recovery codes.
+func (m *membership) mfa(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	if r.Method == http.MethodGet {
+		status, err := mship.MFAStatus(auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, status)
+		return
+	}
+
+	var data = new(struct {
+		Code   string `json:"code"`
+		Issuer string `json:"issuer"`
+	})
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if getURLPart(r.URL.Path, 2) == "recovery" {
+		codes, err := mship.RegenerateRecoveryCodes(auth, data.Code)
+		if err != nil {
+			http.Error(w, err.Error(), mfaErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, codes)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		enrollment, err := mship.EnrollMFA(auth, data.Issuer)
+		if err != nil {
+			http.Error(w, err.Error(), mfaErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, enrollment)
+	case http.MethodPut:
+		codes, err := mship.ConfirmMFA(auth, data.Code)
+		if err != nil {
+			http.Error(w, err.Error(), mfaErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, codes)
+	case http.MethodDelete:
+		if err := mship.DisableMFA(auth, data.Code); err != nil {
+			http.Error(w, err.Error(), mfaErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// sudoMFA returns (GET) or sets (POST) the minimum role required to sign in
+// with a second factor, DELETE /sudo/mfa/{userId} removes a user's second
+// factor.
+func sudoMFA(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := new(struct {
+		Role int `json:"role"`
+	})
+
+	switch r.Method {
+	case http.MethodGet:
+		data.Role = conf.MFARole
+		respond(w, http.StatusOK, data)
+	case http.MethodPost:
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if data.Role < 0 || data.Role > middleware.RootRole {
+			http.Error(w, "role must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+
+		if err := backend.DB.SetMFARole(conf.ID, data.Role); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// refresh the cached database config used by the WithDB middleware
+		conf.MFARole = data.Role
+		if err := backend.Cache.SetTyped(conf.ID, conf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, data)
+	case http.MethodDelete:
+		userID := getURLPart(r.URL.Path, 3)
+		if len(userID) == 0 {
+			http.Error(w, "missing user id", http.StatusBadRequest)
+			return
+		}
+
+		if err := backend.Membership(conf).ResetMFA(userID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,197 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+func mfaLogin(t *testing.T, email, pw string) *http.Response {
+	login := model.Login{Email: email, Password: pw}
+	return sessionReq(t, mship.login, "POST", "/login", "", login)
+}
+
+func mfaChallenge(t *testing.T, email, pw string) model.MFAChallenge {
+	resp := mfaLogin(t, email, pw)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected an MFA challenge got %s", GetResponseBody(t, resp))
+	}
+
+	var ch model.MFAChallenge
+	if err := parseBody(resp.Body, &ch); err != nil {
+		t.Fatal(err)
+	}
+	return ch
+}
+
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := internal.TOTPCode(secret, internal.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestMFAEnrollAndSignIn(t *testing.T) {
+	email, pw := "mfa@test.com", "mfa_unittest_pw"
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := backend.Membership(conf).CreateUser(testAccountID, email, pw, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := mfaLogin(t, email, pw)
+	var token string
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	} else if err := parseBody(resp.Body, &token); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = sessionReq(t, mship.mfa, "POST", "/mfa", token, map[string]string{"issuer": "Unit Test"})
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var enrollment model.MFAEnrollment
+	if err := parseBody(resp.Body, &enrollment); err != nil {
+		t.Fatal(err)
+	} else if len(enrollment.URI) == 0 || len(enrollment.QRCode) == 0 {
+		t.Fatalf("expected uri and qr code got %v", enrollment)
+	}
+
+	confirm := map[string]string{"code": totpCode(t, enrollment.Secret, 0)}
+	resp = sessionReq(t, mship.mfa, "PUT", "/mfa", token, confirm)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var codes []string
+	if err := parseBody(resp.Body, &codes); err != nil {
+		t.Fatal(err)
+	} else if len(codes) != 10 {
+		t.Fatalf("expected 10 recovery codes got %d", len(codes))
+	}
+
+	// signing in now requires the second factor
+	ch := mfaChallenge(t, email, pw)
+	if ch.Enroll {
+		t.Error("expected challenge to not require enrollment")
+	}
+
+	verify := map[string]string{"challenge": ch.Challenge, "code": "000000"}
+	resp = sessionReq(t, mship.verifyMFA, "POST", "/login/mfa", "", verify)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected invalid code to be rejected got %s", GetResponseBody(t, resp))
+	}
+
+	// the current step was used to confirm the enrollment
+	verify["code"] = totpCode(t, enrollment.Secret, 1)
+	resp = sessionReq(t, mship.verifyMFA, "POST", "/login/mfa", "", verify)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var tokens model.AuthTokens
+	if err := parseBody(resp.Body, &tokens); err != nil {
+		t.Fatal(err)
+	}
+	expectMeStatus(t, tokens.Token, http.StatusOK)
+
+	// recovery codes can be used once
+	ch = mfaChallenge(t, email, pw)
+	verify = map[string]string{"challenge": ch.Challenge, "code": codes[0]}
+	resp = sessionReq(t, mship.verifyMFA, "POST", "/login/mfa", "", verify)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	ch = mfaChallenge(t, email, pw)
+	verify = map[string]string{"challenge": ch.Challenge, "code": codes[0]}
+	resp = sessionReq(t, mship.verifyMFA, "POST", "/login/mfa", "", verify)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected used recovery code to be rejected got %s", GetResponseBody(t, resp))
+	}
+
+	resp = sessionReq(t, mship.mfa, "GET", "/mfa", tokens.Token, nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var status model.MFAStatus
+	if err := parseBody(resp.Body, &status); err != nil {
+		t.Fatal(err)
+	} else if !status.Enabled || status.RecoveryCodesLeft != 9 {
+		t.Errorf("expected enabled with 9 recovery codes left got %v", status)
+	}
+}
+
+func TestMFAPolicyRequiresEnrollment(t *testing.T) {
+	email, pw := "mfapolicy@test.com", "mfa_policy_pw"
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := backend.Membership(conf).CreateUser(testAccountID, email, pw, 50); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := dbReq(t, sudoMFA, "POST", "/sudo/mfa", map[string]int{"role": 50}, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	defer func() {
+		resp := dbReq(t, sudoMFA, "POST", "/sudo/mfa", map[string]int{"role": 0}, true)
+		if resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+	}()
+
+	ch := mfaChallenge(t, email, pw)
+	if !ch.Enroll {
+		t.Fatal("expected the policy to require enrollment")
+	}
+
+	enroll := map[string]string{"challenge": ch.Challenge}
+	resp = sessionReq(t, mship.verifyMFA, "POST", "/login/mfa/enroll", "", enroll)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var enrollment model.MFAEnrollment
+	if err := parseBody(resp.Body, &enrollment); err != nil {
+		t.Fatal(err)
+	}
+
+	verify := map[string]string{"challenge": ch.Challenge, "code": totpCode(t, enrollment.Secret, 0)}
+	resp = sessionReq(t, mship.verifyMFA, "POST", "/login/mfa", "", verify)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var tokens model.AuthTokens
+	if err := parseBody(resp.Body, &tokens); err != nil {
+		t.Fatal(err)
+	} else if len(tokens.RecoveryCodes) != 10 {
+		t.Errorf("expected recovery codes with the tokens got %d", len(tokens.RecoveryCodes))
+	}
+	expectMeStatus(t, tokens.Token, http.StatusOK)
+
+	// users below the role threshold are not challenged
+	resp = mfaLogin(t, userEmail, userPassword)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected user below threshold to sign in got %s", GetResponseBody(t, resp))
+	}
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+// apiKeyTouchInterval limits how often the last used time of a key is saved
+const apiKeyTouchInterval = time.Minute
+
+// ErrAPIKeyNotAllowed is returned when an API key is used on a route that does
+// not accept them
+var ErrAPIKeyNotAllowed = errors.New("API keys are not allowed on this endpoint")
+
+// ScopeFunc returns the scope an API key needs for a request
+type ScopeFunc func(r *http.Request) string
+
+// StaticScope requires the same scope for all requests
+func StaticScope(scope string) ScopeFunc {
+	return func(r *http.Request) string {
+		return scope
+	}
+}
+
+// CollectionScope requires the read scope of the collection at the URL part
+// idx for GET requests and POST ?ids= queries, and the write scope for other
+// methods
+func CollectionScope(idx int) ScopeFunc {
+	return func(r *http.Request) string {
+		write := r.Method != http.MethodGet && !isGetByIDs(r)
+		return model.DBScope(write, urlPart(r.URL.Path, idx))
+	}
+}
+
+// isGetByIDs returns true for POST /db/{col}?ids= which reads the documents
+// whose IDs are in the body
+func isGetByIDs(r *http.Request) bool {
+	q := r.URL.Query()
+	return r.Method == http.MethodPost && len(q.Get("bulk")) == 0 && q.Has("ids")
+}
+
+// ReadScope requires the read scope of the collection at the URL part idx,
+// used for queries sent as POST
+func ReadScope(idx int) ScopeFunc {
+	return func(r *http.Request) string {
+		return model.DBScope(false, urlPart(r.URL.Path, idx))
+	}
+}
+
+// AllowAPIKey lets RequireAuth and RequireRoot accept API keys having the
+// scope returned by scope. It must be added before them in the chain.
+func AllowAPIKey(scope ScopeFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ContextAPIKeyScope, scope)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// IsAPIKey returns true if the authorization token is an API key
+func IsAPIKey(key string) bool {
+	return strings.HasPrefix(key, model.APIKeyPrefix)
+}
+
+// authorizeAPIKey validates an API key for the request and writes the error
+// response when it's not accepted
+func authorizeAPIKey(w http.ResponseWriter, r *http.Request, datastore database.Persister, key string) (model.Auth, bool) {
+	scope, ok := r.Context().Value(ContextAPIKeyScope).(ScopeFunc)
+	if !ok {
+		http.Error(w, ErrAPIKeyNotAllowed.Error(), http.StatusForbidden)
+		return model.Auth{}, false
+	}
+
+	conf, ok := r.Context().Value(ContextBase).(model.DatabaseConfig)
+	if !ok {
+		http.Error(w, "invalid StaticBackend public key", http.StatusBadRequest)
+		return model.Auth{}, false
+	}
+
+	auth, err := ValidateAPIKey(datastore, conf, key, internal.ClientIP(r), scope(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return model.Auth{}, false
+	}
+	return auth, true
+}
+
+// ValidateAPIKey validates that an API key is active, used from an allowed IP
+// and has the scope. The returned Auth is the root user that created the key.
+func ValidateAPIKey(datastore database.Persister, conf model.DatabaseConfig, key, ip, scope string) (model.Auth, error) {
+	k, err := datastore.GetAPIKeyByHash(conf.Name, model.HashAPIKey(key))
+	if err != nil {
+		return model.Auth{}, errors.New("invalid API key")
+	}
+
+	now := time.Now()
+	if k.Expired(now) {
+		return model.Auth{}, errors.New("this API key has expired")
+	} else if !k.AllowIP(ip) {
+		return model.Auth{}, fmt.Errorf("this API key cannot be used from %s", ip)
+	} else if !k.HasScope(scope) {
+		return model.Auth{}, fmt.Errorf("this API key does not have the %s scope", scope)
+	}
+
+	tok, err := datastore.GetUserByID(conf.Name, k.AccountID, k.UserID)
+	if err != nil {
+		return model.Auth{}, fmt.Errorf("error retrieving the API key's user: %w", err)
+	} else if tok.Role < RootRole {
+		return model.Auth{}, errors.New("the API key's user is not a root user anymore")
+	}
+
+	if now.Sub(k.LastUsed) > apiKeyTouchInterval || k.LastIP != ip {
+		if err := datastore.TouchAPIKey(conf.Name, k.ID, now, ip); err != nil {
+			return model.Auth{}, err
+		}
+	}
+
+	a := model.Auth{
+		AccountID: tok.AccountID,
+		UserID:    tok.ID,
+		Email:     tok.Email,
+		Role:      tok.Role,
+		Token:     tok.Token,
+		Verified:  true,
+		APIKeyID:  k.ID,
+	}
+	return a, nil
+}
+
+func urlPart(s string, idx int) string {
+	parts := strings.Split(s, "/")
+	if len(parts) <= idx {
+		return ""
+	}
+	return parts[idx]
+}
@@ -2,18 +2,25 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/staticbackendhq/core/cache"
 	"github.com/staticbackendhq/core/database"
 	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/tracing"
 )
 
 const (
-	RootRole = 100
+	// AccountAdminRole is given to users who sign up, they manage their
+	// account's users, groups and invitations
+	AccountAdminRole = 50
+	RootRole         = 100
 )
 
 // RequireAuth validates that a session token is valid.
@@ -23,6 +30,10 @@ const (
 func RequireAuth(datastore database.Persister, volatile cache.Volatilizer) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the database and cache spans are children of the request span
+			datastore := tracing.BindPersister(r.Context(), datastore)
+			volatile := tracing.BindVolatilizer(r.Context(), volatile)
+
 			key := r.Header.Get("Authorization")
 
 			if len(key) == 0 {
@@ -56,12 +67,30 @@ func RequireAuth(datastore database.Persister, volatile cache.Volatilizer) Middl
 
 			key = strings.Replace(key, "Bearer ", "", -1)
 
+			if IsAPIKey(key) {
+				auth, ok := authorizeAPIKey(w, r, datastore, key)
+				if !ok {
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), ContextAuth, auth)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			ctx := r.Context()
 
 			auth, err := ValidateAuthKey(datastore, volatile, ctx, key)
 			if err != nil {
+				status := http.StatusBadRequest
+				// expired or revoked tokens get a 401 so clients know they
+				// should use their refresh token
+				if errors.Is(err, jwt.ErrExpValidation) || errors.Is(err, ErrSessionRevoked) {
+					status = http.StatusUnauthorized
+				}
+
 				err = fmt.Errorf("error validating auth key: %w", err)
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				http.Error(w, err.Error(), status)
 				return
 			}
 
@@ -72,13 +101,22 @@ func RequireAuth(datastore database.Persister, volatile cache.Volatilizer) Middl
 	}
 }
 
+// ErrSessionRevoked is returned when validating an access token of a session
+// that was signed out
+var ErrSessionRevoked = errors.New("this session has been revoked")
+
 // ValidateAuthKey validates a session token
 func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, ctx context.Context, key string) (model.Auth, error) {
 	a := model.Auth{}
 
 	var pl model.JWTPayload
-	if _, err := jwt.Verify([]byte(key), model.HashSecret, &pl); err != nil {
-		return a, fmt.Errorf("could not verify your authentication token: %s", err.Error())
+	expValidator := jwt.ValidatePayload(&pl.Payload, jwt.ExpirationTimeValidator(time.Now()))
+	if _, err := jwt.Verify([]byte(key), model.HashSecret, &pl, expValidator); err != nil {
+		return a, fmt.Errorf("could not verify your authentication token: %w", err)
+	}
+
+	if isRevoked(volatile, pl) {
+		return a, ErrSessionRevoked
 	}
 
 	conf, ok := ctx.Value(ContextBase).(model.DatabaseConfig)
@@ -88,10 +126,11 @@ func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, c
 
 	var auth model.Auth
 	if err := volatile.GetTyped(pl.Token, &auth); err == nil {
+		auth.JWTID = pl.JWTID
 		return auth, nil
 	}
 
-	parts := strings.Split(key, "|")
+	parts := strings.Split(pl.Token, "|")
 	if len(parts) != 2 {
 		return a, fmt.Errorf("invalid authentication token")
 	}
@@ -111,13 +150,21 @@ func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, c
 		return a, fmt.Errorf("error retrieving your customer account: %v", err)
 	}
 
+	groups, permissions, err := groupAccess(datastore, conf.Name, token.ID)
+	if err != nil {
+		return a, fmt.Errorf("error retrieving your groups: %v", err)
+	}
+
 	a = model.Auth{
-		AccountID: token.AccountID,
-		UserID:    token.ID,
-		Email:     token.Email,
-		Role:      token.Role,
-		Token:     token.Token,
-		Plan:      cus.Plan,
+		AccountID:   token.AccountID,
+		UserID:      token.ID,
+		Email:       token.Email,
+		Role:        conf.UserRole(token),
+		Token:       token.Token,
+		Plan:        cus.Plan,
+		Verified:    token.Verified,
+		Groups:      groups,
+		Permissions: permissions,
 	}
 	if err := volatile.SetTyped(pl.Token, a); err != nil {
 		return a, err
@@ -128,13 +175,38 @@ func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, c
 		return a, err
 	}
 
+	a.JWTID = pl.JWTID
 	return a, nil
 }
 
+// isRevoked checks if the access token was revoked by its session being
+// signed out or the user signing out of all sessions after it was issued
+func isRevoked(volatile cache.Volatilizer, pl model.JWTPayload) bool {
+	if _, err := volatile.Get(model.RevokedTokenKey(pl.JWTID)); err == nil {
+		return true
+	}
+
+	userID, _, _ := strings.Cut(pl.Token, "|")
+	val, err := volatile.Get(model.SignedOutKey(userID))
+	if err != nil {
+		return false
+	}
+
+	cutoff, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || pl.IssuedAt == nil {
+		return false
+	}
+	return pl.IssuedAt.Unix() < cutoff
+}
+
 // RequireRoot validates that the token provided is for a "root" user.
 func RequireRoot(datastore database.Persister, volatile cache.Volatilizer) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the database and cache spans are children of the request span
+			datastore := tracing.BindPersister(r.Context(), datastore)
+			volatile := tracing.BindVolatilizer(r.Context(), volatile)
+
 			key := r.Header.Get("Authorization")
 
 			// we check if the token is in a cookie (used from UI)
@@ -174,6 +246,17 @@ func RequireRoot(datastore database.Persister, volatile cache.Volatilizer) Middl
 				key = rt
 			}
 
+			if IsAPIKey(key) {
+				auth, ok := authorizeAPIKey(w, r, datastore, key)
+				if !ok {
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), ContextAuth, auth)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			ctx := r.Context()
 			conf, ok := ctx.Value(ContextBase).(model.DatabaseConfig)
 			if !ok {
@@ -223,3 +306,20 @@ func ValidateRootToken(datastore database.Persister, base, token string) (model.
 	}
 	return tok, nil
 }
+
+// groupAccess returns the groups of a user and the permissions their roles
+// grant
+func groupAccess(datastore database.Persister, dbName, userID string) (groups []string, permissions []string, err error) {
+	list, err := datastore.ListUserGroups(dbName, userID)
+	if err != nil || len(list) == 0 {
+		return
+	}
+
+	roles, err := datastore.ListRoles(dbName)
+	if err != nil {
+		return
+	}
+
+	groups, permissions = model.GroupAccess(list, roles)
+	return
+}
@@ -2,16 +2,19 @@ package middleware
 
 import (
 	"net/http"
+
+	"github.com/staticbackendhq/core/tracing"
 )
 
 // Middleware is a standard http.Handler
 type Middleware func(h http.Handler) http.Handler
 
 // Chain creates a request pipeline from which the Middleware are chained
-// together and h is the last Handler executed.
+// together and h is the last Handler executed. The pipeline runs in a
+// tracing span continuing the trace-context of the request.
 func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
 	for i := len(middlewares) - 1; i >= 0; i-- {
 		h = middlewares[i](h)
 	}
-	return h
+	return tracing.Handler(h)
 }
@@ -15,6 +15,8 @@ type ContextKey int
 const (
 	ContextAuth ContextKey = iota
 	ContextBase
+	// ContextAPIKeyScope holds the ScopeFunc of routes accepting API keys
+	ContextAPIKeyScope
 )
 
 // Extract extracts the DatabaseConfig and Auth for the request
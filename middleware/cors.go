@@ -5,7 +5,14 @@ import (
 	"strings"
 )
 
+// HeaderRefreshToken is the response header holding the refresh token on
+// sign-in requests
+const HeaderRefreshToken = "SB-Refresh-Token"
+
 // Cors enables calls via remote origin to handle external JavaScript calls mainly.
+//
+// The origin is validated against the database allowed domains by WithDB,
+// preflight requests do not carry the public key value.
 func Cors() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -30,6 +37,7 @@ func Cors() Middleware {
 			headers.Set("Access-Control-Allow-Methods", strings.ToUpper(r.Header.Get("Access-Control-Request-Method")))
 
 			headers.Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+			headers.Set("Access-Control-Expose-Headers", HeaderRefreshToken)
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/staticbackendhq/core/config"
+)
+
+// DefaultAllowedDomain is the allowed domain of new databases
+const DefaultAllowedDomain = "localhost"
+
+// AllowsAllOrigins returns true when the allowed domains contain "*", the
+// explicit opt-out of the origin validation
+func AllowsAllOrigins(allowed []string) bool {
+	for _, d := range allowed {
+		if strings.TrimSpace(d) == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOriginAllowed validates the request Origin against a database allowed
+// domains. Requests without an Origin (server-side calls), same origin
+// requests and all requests in dev mode are allowed. The allowed domains are
+// enforced as written, "*" accepts all origins.
+//
+// An allowed domain is either "*", a host "example.com", a wildcard subdomain
+// "*.example.com", a host and port "localhost:3000" or a full origin
+// "https://example.com:8443".
+func IsOriginAllowed(allowed []string, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 || config.Current.AppEnv == "dev" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || len(u.Host) == 0 {
+		return false
+	}
+
+	// same origin requests, i.e. the web UI
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	} else if app, err := url.Parse(config.Current.AppURL); err == nil && strings.EqualFold(app.Host, u.Host) {
+		return true
+	}
+
+	for _, pattern := range allowed {
+		if MatchDomain(pattern, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchDomain returns true if the origin matches an allowed domain pattern
+func MatchDomain(pattern string, origin *url.URL) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	host := strings.ToLower(origin.Hostname())
+
+	switch {
+	case len(pattern) == 0:
+		return false
+	case pattern == "*":
+		return true
+	case strings.Contains(pattern, "://"):
+		return pattern == strings.ToLower(origin.Scheme+"://"+origin.Host)
+	case strings.Contains(pattern, ":"):
+		return pattern == strings.ToLower(origin.Host)
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return host == pattern
+}
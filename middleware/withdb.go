@@ -8,16 +8,22 @@ import (
 	"github.com/staticbackendhq/core/cache"
 	"github.com/staticbackendhq/core/database"
 	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/tracing"
 )
 
 type BillingPortalGetter func(customerID string) (string, error)
 
 // WithDB validates the presence of the "SB-PUBLIC-KEY" and fetches the proper
 // DatabaseConfig for this Tenant so the rest of the pipeline can executes
-// actions on the right database.
+// actions on the right database. The request Origin must be allowed by the
+// database allowed domains, see IsOriginAllowed.
 func WithDB(datastore database.Persister, volatile cache.Volatilizer, g BillingPortalGetter) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the database and cache spans are children of the request span
+			datastore := tracing.BindPersister(r.Context(), datastore)
+			volatile := tracing.BindVolatilizer(r.Context(), volatile)
+
 			key := r.Header.Get("SB-PUBLIC-KEY")
 
 			// we check in query string (used for SSE)
@@ -75,6 +81,15 @@ func WithDB(datastore database.Persister, volatile cache.Volatilizer, g BillingP
 				ctx = context.WithValue(ctx, ContextBase, conf)
 			}
 
+			if !IsOriginAllowed(conf.AllowedDomain, r) {
+				// prevent the browser from reading the response
+				w.Header().Del("Access-Control-Allow-Origin")
+
+				msg := fmt.Sprintf("origin %s is not allowed for this database", r.Header.Get("Origin"))
+				http.Error(w, msg, http.StatusForbidden)
+				return
+			}
+
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
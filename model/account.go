@@ -23,6 +23,16 @@ type Auth struct {
 	Role      int    `json:"role"`
 	Token     string `json:"-"`
 	Plan      int    `json:"-"`
+	// JWTID is the ID of the access token used for this request
+	JWTID string `json:"-"`
+	// Verified is false for users that did not confirm their email yet
+	Verified bool `json:"verified"`
+	// APIKeyID is set when the request was made with an API key
+	APIKeyID string `json:"-"`
+	// Groups are the names of the groups the user is a member of
+	Groups []string `json:"groups"`
+	// Permissions are granted by the roles of the user's groups
+	Permissions []string `json:"permissions"`
 }
 
 func (auth Auth) ReconstructToken() string {
@@ -53,6 +63,19 @@ type User struct {
 	Role      int       `json:"role"`
 	ResetCode string    `json:"-"`
 	Created   time.Time `json:"created"`
+	// Verified is false until the user confirms their email when the
+	// database requires email verification
+	Verified bool `json:"verified"`
+
+	UserProfile
+}
+
+// UserProfile holds the custom profile fields a user can change
+type UserProfile struct {
+	DisplayName string `json:"displayName"`
+	// AvatarID is the ID of an uploaded file
+	AvatarID string                 `json:"avatarId"`
+	Profile  map[string]interface{} `json:"profile"`
 }
 
 type Login struct {
@@ -81,9 +104,22 @@ type Tenant struct {
 }
 
 func EncryptExternalLogins(tokens map[string]OAuthConfig) ([]byte, error) {
+	return encrypt(tokens)
+}
+
+func (cus *Tenant) GetExternalLogins() (map[string]OAuthConfig, error) {
+	m := make(map[string]OAuthConfig)
+	if err := decrypt(cus.ExternalLogins, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// encrypt encodes v as JSON and encrypts it with the AppSecret
+func encrypt(v any) ([]byte, error) {
 	key := []byte(config.Current.AppSecret)
 
-	b, err := json.Marshal(tokens)
+	b, err := json.Marshal(v)
 	if err != nil {
 		return nil, err
 	}
@@ -106,41 +142,36 @@ func EncryptExternalLogins(tokens map[string]OAuthConfig) ([]byte, error) {
 	return gcm.Seal(nonce, nonce, b, nil), nil
 }
 
-func (cus *Tenant) GetExternalLogins() (map[string]OAuthConfig, error) {
+// decrypt decrypts the ciphertext into v, an empty ciphertext leaves v as is
+func decrypt(ciphertext []byte, v any) error {
 	key := []byte(config.Current.AppSecret)
 
-	ciphertext := cus.ExternalLogins
 	if len(ciphertext) == 0 {
-		return make(map[string]OAuthConfig), nil
+		return nil
 	}
 
 	c, err := aes.NewCipher(key)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	gcm, err := cipher.NewGCM(c)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	nonceSize := gcm.NonceSize()
 	if len(ciphertext) < nonceSize {
-		return nil, errors.New("ciphertext too short")
+		return errors.New("ciphertext too short")
 	}
 
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
 	b, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return nil, err
-	}
-
-	m := make(map[string]OAuthConfig)
-	if err := json.Unmarshal(b, &m); err != nil {
-		return nil, err
+		return err
 	}
 
-	return m, nil
+	return json.Unmarshal(b, v)
 }
 
 func (cus *Tenant) GetProvider(provider string) (cfg OAuthConfig, ok bool) {
@@ -156,4 +187,30 @@ func (cus *Tenant) GetProvider(provider string) (cfg OAuthConfig, ok bool) {
 type OAuthConfig struct {
 	ConsumerKey    string
 	ConsumerSecret string
+	// DiscoveryURL is set for generic OpenID Connect providers, it's the
+	// issuer URL or its .well-known/openid-configuration URL
+	DiscoveryURL string
+	// Scopes are the space separated scopes requested from an OpenID Connect
+	// provider, openid is always added
+	Scopes string
+	// Claims maps the ID token claims to the user fields, empty entries use
+	// the standard claims
+	Claims ClaimMapping
+	// PKCE adds a code challenge to the authorization request
+	PKCE bool
+}
+
+// IsOIDC returns true for generic OpenID Connect providers
+func (cfg OAuthConfig) IsOIDC() bool {
+	return len(cfg.DiscoveryURL) > 0
+}
+
+// ClaimMapping are the claim names holding the user information
+type ClaimMapping struct {
+	Subject   string
+	Email     string
+	Name      string
+	FirstName string
+	LastName  string
+	AvatarURL string
 }
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts all API keys, it's how they are told apart from
+// session and root tokens
+const APIKeyPrefix = "sbk_"
+
+// API key scopes, database scopes are suffixed by a collection name or * for
+// all collections, i.e. db:read:tasks
+const (
+	ScopeDBRead        = "db:read"
+	ScopeDBWrite       = "db:write"
+	ScopeFunctionsExec = "functions:exec"
+	ScopeStorage       = "storage"
+	ScopeSendMail      = "sendmail"
+)
+
+// APIKey is a named key used for server-to-server calls. It acts as the root
+// user that created it, limited to its scopes. Only the hash of the key is
+// stored.
+type APIKey struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId"`
+	UserID    string `json:"userId"`
+	Name      string `json:"name"`
+	// Prefix is the beginning of the key to help identify it
+	Prefix     string    `json:"prefix"`
+	KeyHash    string    `json:"-"`
+	Scopes     []string  `json:"scopes"`
+	AllowedIPs []string  `json:"allowedIps"`
+	Expires    time.Time `json:"expires"`
+	LastUsed   time.Time `json:"lastUsed"`
+	LastIP     string    `json:"lastIp"`
+	Created    time.Time `json:"created"`
+}
+
+// HashAPIKey returns the hash stored for an API key
+func HashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// ValidScope returns true for a known scope
+func ValidScope(scope string) bool {
+	switch scope {
+	case ScopeFunctionsExec, ScopeStorage, ScopeSendMail:
+		return true
+	}
+
+	op, col, ok := strings.Cut(scope, ":")
+	if !ok || op != "db" {
+		return false
+	}
+
+	op, col, ok = strings.Cut(col, ":")
+	return ok && (op == "read" || op == "write") && len(col) > 0
+}
+
+// DBScope returns the database scope of an operation on a collection
+func DBScope(write bool, col string) string {
+	if write {
+		return ScopeDBWrite + ":" + col
+	}
+	return ScopeDBRead + ":" + col
+}
+
+// HasScope returns true if the key was granted the scope
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+
+		// db:read:* grants db:read on all collections
+		if prefix, ok := strings.CutSuffix(s, ":*"); ok && strings.HasPrefix(scope, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired returns true when the key has an expiry that passed
+func (k APIKey) Expired(t time.Time) bool {
+	return !k.Expires.IsZero() && t.After(k.Expires)
+}
+
+// AllowIP returns true when the key has no IP allow-list or the ip matches
+// one of its IP or CIDR entries
+func (k APIKey) AllowIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	for _, allowed := range k.AllowedIPs {
+		if allowed == ip {
+			return true
+		}
+
+		if _, network, err := net.ParseCIDR(allowed); err == nil && parsed != nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package model
+
+const (
+	// EmailVerificationBlock prevents unverified users from signing in
+	EmailVerificationBlock = "block"
+	// EmailVerificationLimit lets unverified users sign in with the lowest
+	// role until they verify their email
+	EmailVerificationLimit = "limit"
+)
+
+// AuthPolicy holds the email verification and password rules of a database
+type AuthPolicy struct {
+	// EmailVerification is empty when not required, otherwise
+	// EmailVerificationBlock or EmailVerificationLimit
+	EmailVerification string `json:"emailVerification"`
+	// VerifyURL is the page of the application receiving the verification
+	// link, the email and code are added as query string parameters
+	VerifyURL string `json:"verifyUrl"`
+	// Template is the name of an email template used for the verification
+	// email, its data has the Email, Code and Link keys
+	Template string         `json:"template"`
+	Password PasswordPolicy `json:"password"`
+}
+
+// PasswordPolicy are the rules a new password must follow
+type PasswordPolicy struct {
+	MinLength     int  `json:"minLength"`
+	RequireUpper  bool `json:"requireUpper"`
+	RequireLower  bool `json:"requireLower"`
+	RequireDigit  bool `json:"requireDigit"`
+	RequireSymbol bool `json:"requireSymbol"`
+	// CheckBreached rejects passwords found in the list of known breached
+	// passwords
+	CheckBreached bool `json:"checkBreached"`
+}
+
+// VerificationRequired returns true when new users need to verify their email
+func (p AuthPolicy) VerificationRequired() bool {
+	return p.EmailVerification == EmailVerificationBlock ||
+		p.EmailVerification == EmailVerificationLimit
+}
+
+// UserRole returns the role a user signs in with, unverified users are
+// limited to the lowest role when the policy is EmailVerificationLimit
+func (conf DatabaseConfig) UserRole(tok User) int {
+	if !tok.Verified && conf.AuthPolicy.EmailVerification == EmailVerificationLimit {
+		return 0
+	}
+	return tok.Role
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Backup is a snapshot of a database taken by a backup task or on demand. The
+// snapshot is an export archive, see backend.ExportDatabase.
+type Backup struct {
+	ID       string `json:"id"`
+	Database string `json:"database"`
+	// Dir is the local directory holding the snapshot, it's saved via the
+	// storage provider when empty
+	Dir    string `json:"dir,omitempty"`
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	Tables int    `json:"tables"`
+	Rows   int64  `json:"rows"`
+	Blobs  int    `json:"blobs"`
+	// Manual backups are kept until deleted, the retention policy only
+	// applies to scheduled backups
+	Manual  bool      `json:"manual"`
+	Created time.Time `json:"created"`
+}
+
+// BackupPolicy is the meta data of a backup task, the task value is the local
+// directory of the snapshots or "storage" for the storage provider
+type BackupPolicy struct {
+	// Daily keeps the last snapshot of this many days
+	Daily int `json:"daily"`
+	// Weekly keeps the last snapshot of this many weeks
+	Weekly int `json:"weekly"`
+	// Blobs includes the uploaded files in the snapshots
+	Blobs bool `json:"blobs"`
+}
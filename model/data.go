@@ -18,6 +18,12 @@ type DatabaseConfig struct {
 	IsActive         bool      `json:"-"`
 	MonthlySentEmail int       `json:"-"`
 	Created          time.Time `json:"created"`
+	SMSConfig        []byte    `json:"-"`
+	// MFARole requires users with this role or higher to sign in with a
+	// second factor, 0 disables the policy
+	MFARole int `json:"mfaRole"`
+	// AuthPolicy holds the email verification and password rules
+	AuthPolicy AuthPolicy `json:"authPolicy"`
 }
 
 type PagedResult struct {
@@ -65,6 +71,7 @@ const (
 	MsgTypeDBDeleted    = "db_deleted"
 	MsgTypeFunctionCall = "fn_call"
 	MsgTypeHTTPResponse = "http_response"
+	MsgTypeFormSubmit   = "form_submit"
 )
 
 type Command struct {
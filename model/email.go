@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+const (
+	EmailStatusSent   = "sent"
+	EmailStatusFailed = "failed"
+)
+
+// EmailTemplate is a named email template stored for a database. The Subject,
+// HTMLBody and TextBody are Go templates executed with the data provided when
+// sending.
+type EmailTemplate struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Subject  string    `json:"subject"`
+	HTMLBody string    `json:"htmlBody"`
+	TextBody string    `json:"textBody"`
+	Updated  time.Time `json:"updated"`
+}
+
+// EmailLog is a sent (or failed) email and its status
+type EmailLog struct {
+	ID       string    `json:"id"`
+	Template string    `json:"template"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Subject  string    `json:"subject"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error"`
+	Sent     time.Time `json:"sent"`
+}
@@ -10,13 +10,18 @@ type UploadFileData struct {
 	File     io.ReadSeeker
 	Size     int64
 	Mimetype string
+	// Private files are not publicly readable, they're only read via the
+	// storage provider's Get
+	Private bool
 }
 
 type File struct {
 	ID        string    `json:"id"`
 	AccountID string    `json:"accountId"`
+	UserID    string    `json:"userId"`
 	Key       string    `json:"key"`
 	URL       string    `json:"url"`
 	Size      int64     `json:"size"`
+	Checksum  string    `json:"checksum"`
 	Uploaded  time.Time `json:"uploaded"`
 }
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const (
+	FormFieldText   = "text"
+	FormFieldEmail  = "email"
+	FormFieldNumber = "number"
+	FormFieldURL    = "url"
+	FormFieldDate   = "date"
+	FormFieldBool   = "bool"
+	FormFieldFile   = "file"
+)
+
+// FormDefinition describes a form, its fields validation and what happens
+// after a submission. Forms without a definition accept any fields.
+type FormDefinition struct {
+	ID     string      `json:"id"`
+	Name   string      `json:"name"`
+	Fields []FormField `json:"fields"`
+	// RedirectURL is where the browser is redirected after a successful
+	// submission, the JSON response is returned when empty
+	RedirectURL string `json:"redirectUrl"`
+	// ErrorURL is where the browser is redirected when the submission is
+	// rejected, the error query string parameter contains the reason
+	ErrorURL string `json:"errorUrl"`
+	// NotifyEmail receives an email for each new submission
+	NotifyEmail string `json:"notifyEmail"`
+	// EmailTemplate is an optional stored email template name used for the
+	// notification, the submission fields are passed as template data
+	EmailTemplate string `json:"emailTemplate"`
+	// MaxPerHour is the maximum submissions per IP per hour (default 10)
+	MaxPerHour int       `json:"maxPerHour"`
+	Updated    time.Time `json:"updated"`
+}
+
+// FormField is a field of a form definition
+type FormField struct {
+	Name string `json:"name"`
+	// Type is one of the FormField* values, text when empty
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// MaxLength is the maximum length in characters, or size in bytes for
+	// file fields, 0 means no limit
+	MaxLength int `json:"maxLength"`
+}
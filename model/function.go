@@ -33,6 +33,19 @@ const (
 	TaskTypeFunction = "function"
 	TaskTypeMessage  = "message"
 	TaskTypeHTTP     = "http"
+	TaskTypeBackup   = "backup"
+)
+
+const (
+	// TaskCatchUpSkip ignores the runs missed while no scheduler was running
+	TaskCatchUpSkip = "skip"
+	// TaskCatchUpOnce runs the task once if it missed any run
+	TaskCatchUpOnce = "once"
+	// TaskCatchUpAll runs the task for each missed run, up to MaxTaskCatchUp
+	TaskCatchUpAll = "all"
+
+	// MaxTaskCatchUp is the maximum of missed runs executed on startup
+	MaxTaskCatchUp = 10
 )
 
 type Task struct {
@@ -43,10 +56,40 @@ type Task struct {
 	Meta     string    ` json:"meta"`
 	Interval string    ` json:"interval"`
 	LastRun  time.Time ` json:"last"`
+	// Timezone is the IANA location of the Interval, UTC when empty
+	Timezone string `json:"timezone"`
+	Paused   bool   `json:"paused"`
+	// CatchUp is the policy for the runs missed during a downtime, they are
+	// skipped by default
+	CatchUp string `json:"catchUp"`
 
 	BaseName string `json:"base"`
 }
 
+const (
+	TaskTriggerSchedule = "schedule"
+	TaskTriggerManual   = "manual"
+	TaskTriggerCatchUp  = "catch-up"
+
+	TaskRunSuccess = "success"
+	TaskRunFailure = "failure"
+
+	// MaxTaskRuns is the number of runs kept per task
+	MaxTaskRuns = 100
+)
+
+// TaskRun records a task execution
+type TaskRun struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"taskId"`
+	Trigger   string    `json:"trigger"`
+	Status    string    `json:"status"`
+	Started   time.Time `json:"started"`
+	Completed time.Time `json:"completed"`
+	Output    string    `json:"output"`
+	Error     string    `json:"error"`
+}
+
 type MetaMessage struct {
 	Data        string `json:"data"`
 	Channel     string `json:"channel"`
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/internal"
+)
+
+// Role is a named set of permissions defined for a database. Permissions are
+// free-form strings functions can check, the db:read:{collection} and
+// db:write:{collection} permissions also give access to the documents of a
+// collection to the whole account instead of only their owner.
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Updated     time.Time `json:"updated"`
+}
+
+// Group is a team of users within an account, its members get the
+// permissions of its roles
+type Group struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId"`
+	Name      string `json:"name"`
+	// Roles are the names of the roles granted to the members
+	Roles []string `json:"roles"`
+	// Members are the IDs of the users in the group
+	Members []string  `json:"members"`
+	Created time.Time `json:"created"`
+}
+
+// GroupAccess returns the names of the groups and the permissions their
+// roles grant
+func GroupAccess(groups []Group, roles []Role) (names []string, permissions []string) {
+	byName := make(map[string]Role)
+	for _, r := range roles {
+		byName[r.Name] = r
+	}
+
+	seen := make(map[string]bool)
+	for _, g := range groups {
+		names = append(names, g.Name)
+
+		for _, name := range g.Roles {
+			for _, p := range byName[name].Permissions {
+				if !seen[p] {
+					seen[p] = true
+					permissions = append(permissions, p)
+				}
+			}
+		}
+	}
+	return
+}
+
+// InGroup returns true if the user is a member of the group
+func (auth Auth) InGroup(name string) bool {
+	for _, g := range auth.Groups {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission returns true if one of the user's roles grants the
+// permission, a permission ending with :* grants all permissions it prefixes
+func (auth Auth) HasPermission(permission string) bool {
+	for _, p := range auth.Permissions {
+		if p == permission {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(p, ":*"); ok && strings.HasPrefix(permission, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadPermission returns the read permission level of a collection for the
+// user, a db:read permission extends an owner permission to the account
+func (auth Auth) ReadPermission(col string) internal.PermissionLevel {
+	level := internal.ReadPermission(col)
+	if level == internal.PermOwner && auth.HasPermission(DBScope(false, col)) {
+		return internal.PermGroup
+	}
+	return level
+}
+
+// WritePermission returns the write permission level of a collection for the
+// user, a db:write permission extends an owner permission to the account
+func (auth Auth) WritePermission(col string) internal.PermissionLevel {
+	level := internal.WritePermission(col)
+	if level == internal.PermOwner && auth.HasPermission(DBScope(true, col)) {
+		return internal.PermGroup
+	}
+	return level
+}
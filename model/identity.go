@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Identity links an external login (OAuth or OpenID Connect) to a user, a
+// user can have multiple identities
+type Identity struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId"`
+	UserID    string `json:"userId"`
+	Provider  string `json:"provider"`
+	// Subject is the user's ID at the provider
+	Subject string    `json:"subject"`
+	Email   string    `json:"email"`
+	Created time.Time `json:"created"`
+}
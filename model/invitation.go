@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Invitation is a pending invite for an email to join an account with a role.
+// The invitee gets a signed link, its nonce changes when the invitation is
+// resent so older links stop working.
+type Invitation struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId"`
+	// InvitedBy is the ID of the user who sent the invitation
+	InvitedBy string `json:"invitedBy"`
+	Email     string `json:"email"`
+	Role      int    `json:"role"`
+	// Link is the page of the application accepting invitations
+	Link    string    `json:"link"`
+	Nonce   string    `json:"-"`
+	Expires time.Time `json:"expires"`
+	Created time.Time `json:"created"`
+}
+
+// Expired returns true once the invitation cannot be accepted anymore
+func (inv Invitation) Expired(t time.Time) bool {
+	return t.After(inv.Expires)
+}
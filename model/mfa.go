@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// MFA is the TOTP second factor of a user. The secret is encrypted and the
+// recovery codes are stored hashed.
+type MFA struct {
+	UserID        string    `json:"userId"`
+	Secret        []byte    `json:"-"`
+	Enabled       bool      `json:"enabled"`
+	RecoveryCodes []string  `json:"-"`
+	LastStep      int64     `json:"-"`
+	Updated       time.Time `json:"updated"`
+}
+
+// EncryptMFASecret encrypts a TOTP secret before it's persisted
+func EncryptMFASecret(secret string) ([]byte, error) {
+	return encrypt(secret)
+}
+
+// GetSecret returns the decrypted TOTP secret
+func (m MFA) GetSecret() (secret string, err error) {
+	err = decrypt(m.Secret, &secret)
+	return
+}
+
+// MFAStatus is the second factor state of a user
+type MFAStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required is true when the database policy requires MFA for the user
+	Required bool `json:"required"`
+	// RecoveryCodesLeft is the number of unused recovery codes
+	RecoveryCodesLeft int `json:"recoveryCodesLeft"`
+}
+
+// MFAEnrollment is returned when enrolling a TOTP second factor, the URI
+// is what authenticator apps scan via the QR code PNG image (data URI).
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode string `json:"qrCode"`
+}
+
+// MFAChallenge is returned when signing in requires a second factor. The
+// challenge is exchanged with a TOTP or recovery code for a session. When
+// Enroll is true the user must enroll a second factor first.
+type MFAChallenge struct {
+	Challenge string    `json:"challenge"`
+	Enroll    bool      `json:"enroll"`
+	Expires   time.Time `json:"expires"`
+}
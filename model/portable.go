@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// PortableDocument is a collection document in the engine-neutral form of the
+// portable export archives
+type PortableDocument struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"accountId"`
+	OwnerID   string    `json:"ownerId"`
+	Created   time.Time `json:"created"`
+	// Data holds the fields of the document without the system fields
+	Data map[string]any `json:"data"`
+}
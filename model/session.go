@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// Session is a signed-in device for a user. The refresh token of a session is
+// rotated on each use, only its hash and the ID of the last access token
+// issued are kept.
+type Session struct {
+	ID          string    `json:"id"`
+	AccountID   string    `json:"accountId"`
+	UserID      string    `json:"userId"`
+	RefreshHash string    `json:"-"`
+	JWTID       string    `json:"-"`
+	UserAgent   string    `json:"userAgent"`
+	IP          string    `json:"ip"`
+	Created     time.Time `json:"created"`
+	LastUsed    time.Time `json:"lastUsed"`
+	Expires     time.Time `json:"expires"`
+	// Current is true when listing the sessions with the access token of
+	// this session
+	Current bool `json:"current"`
+}
+
+// AuthTokens is a short-lived access token and the refresh token used to get
+// a new one once it expires
+type AuthTokens struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	Expires      time.Time `json:"expires"`
+	// RecoveryCodes are returned once when a second factor enrollment is
+	// completed while signing in
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// RevokedTokenKey returns the cache key blocking an access token by its JWT ID
+func RevokedTokenKey(jwtID string) string {
+	return "revoked-jwt:" + jwtID
+}
+
+// SignedOutKey returns the cache key holding the Unix time a user signed out
+// of all their sessions, access tokens issued before are rejected
+func SignedOutKey(userID string) string {
+	return "signed-out:" + userID
+}
+
+// RotatedRefreshKey returns the cache key holding the session ID of an
+// already rotated refresh token hash
+func RotatedRefreshKey(hash string) string {
+	return "rotated-refresh:" + hash
+}
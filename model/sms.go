@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// SMSConfig is the SMS provider and its credentials for a database
+type SMSConfig struct {
+	// Provider is one of the sms.SMSProvider* values
+	Provider string `json:"provider"`
+	// AccountID is the Twilio AccountSID or Vonage API key
+	AccountID string `json:"accountId"`
+	// AuthToken is the Twilio auth token or Vonage API secret
+	AuthToken string `json:"authToken"`
+	// FromNumber is the default number messages are sent from
+	FromNumber string `json:"fromNumber"`
+	// WebhookToken authenticates the delivery status webhook requests
+	WebhookToken string `json:"webhookToken"`
+}
+
+// SMSMessage is a sent text message and its delivery status
+type SMSMessage struct {
+	ID         string    `json:"id"`
+	AccountID  string    `json:"accountId"`
+	Provider   string    `json:"provider"`
+	MessageID  string    `json:"messageId"`
+	ToNumber   string    `json:"toNumber"`
+	FromNumber string    `json:"fromNumber"`
+	Body       string    `json:"body"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+}
+
+func EncryptSMSConfig(cfg SMSConfig) ([]byte, error) {
+	return encrypt(cfg)
+}
+
+// GetSMSConfig returns the decrypted SMS provider configuration, the Provider
+// is empty when none has been configured.
+func (base DatabaseConfig) GetSMSConfig() (cfg SMSConfig, err error) {
+	err = decrypt(base.SMSConfig, &cfg)
+	return
+}
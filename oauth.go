@@ -5,15 +5,18 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/staticbackendhq/core/backend"
 	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/logger"
 	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
 
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/providers/facebook"
+	"github.com/markbates/goth/providers/github"
 	"github.com/markbates/goth/providers/google"
 	"github.com/markbates/goth/providers/twitter"
 )
@@ -22,19 +25,35 @@ const (
 	OAuthProviderTwitter  = "twitter"
 	OAuthProviderFacebook = "facebook"
 	OAuthProviderGoogle   = "google"
+	OAuthProviderGitHub   = "github"
 )
 
+// oauthLinkTTL is how long a signed-in user has to complete the external
+// login that links a new identity
+const oauthLinkTTL = 10 * time.Minute
+
+// oauthLink is a request from a signed-in user to link an external login
+type oauthLink struct {
+	Auth    model.Auth `json:"auth"`
+	Expires time.Time  `json:"expires"`
+}
+
 type ExternalLogins struct {
 	log *logger.Logger
 }
 
 type ExternalUser struct {
-	Token     string `json:"token"`
-	Email     string `json:"email"`
-	Name      string `json:"name"`
-	FirstName string `json:"first"`
-	LastName  string `json:"last"`
-	AvatarURL string `json:"avatarUrl"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	Provider     string `json:"provider"`
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	FirstName    string `json:"first"`
+	LastName     string `json:"last"`
+	AvatarURL    string `json:"avatarUrl"`
+	// MFA is set instead of the tokens when the user needs to complete
+	// their second factor via /login/mfa
+	MFA *model.MFAChallenge `json:"mfa,omitempty"`
 }
 
 func (el *ExternalLogins) login() http.Handler {
@@ -58,6 +77,27 @@ func (el *ExternalLogins) login() http.Handler {
 			return
 		}
 
+		// a signed-in user links this login to their user
+		if code := r.URL.Query().Get("link"); len(code) > 0 {
+			if err := el.startLink(code, reqID); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// an invitee joins the inviting account with this login
+		if invite := r.URL.Query().Get("invite"); len(invite) > 0 {
+			if _, err := backend.Membership(conf).GetInvitation(invite); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := backend.Cache.Set("oauth_invite_"+reqID, invite); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
 		customer, err := backend.DB.FindTenant(conf.TenantID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -141,7 +181,7 @@ func (el *ExternalLogins) callback() http.Handler {
 		}
 
 		sess, err := p.UnmarshalSession(value)
-		if err := backend.Cache.GetTyped(reqID+"_session", &value); err != nil {
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -169,15 +209,8 @@ func (el *ExternalLogins) callback() http.Handler {
 				return
 			}
 
-			accessTokens := fmt.Sprintf("%s|%s", user.AccessToken, user.AccessTokenSecret)
-			sessionToken, err := el.registerOrLogin(conf, provider, user.Email, accessTokens)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-
 			extuser := ExternalUser{
-				Token:     sessionToken,
+				Provider:  provider,
 				Email:     user.Email,
 				Name:      user.Name,
 				FirstName: user.FirstName,
@@ -185,6 +218,19 @@ func (el *ExternalLogins) callback() http.Handler {
 				AvatarURL: user.AvatarURL,
 			}
 
+			tokens, err := el.registerOrLogin(conf, provider, reqID, user, r.UserAgent(), internal.ClientIP(r))
+
+			var mfaErr *backend.MFARequiredError
+			if errors.As(err, &mfaErr) {
+				extuser.MFA = &mfaErr.Challenge
+			} else if err != nil {
+				http.Error(w, err.Error(), authErrorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			extuser.Token = tokens.Token
+			extuser.RefreshToken = tokens.RefreshToken
+
 			if err := backend.Cache.SetTyped("extuser_"+reqID, extuser); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -209,50 +255,194 @@ func (*ExternalLogins) getUser(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, extuser)
 }
 
-func (el *ExternalLogins) registerOrLogin(conf model.DatabaseConfig, provider, email, accessToken string) (sessionToken string, err error) {
-	email = strings.ToLower(email)
+// registerOrLogin signs in the user linked to the external identity. An
+// identity is linked to the user requesting it, or to the user with the same
+// email, or to a new user on its first sign in.
+func (el *ExternalLogins) registerOrLogin(conf model.DatabaseConfig, provider, reqID string, user goth.User, userAgent, ip string) (tokens model.AuthTokens, err error) {
+	email := strings.ToLower(user.Email)
 
-	exists, err := backend.DB.UserEmailExists(conf.Name, email)
+	subject := user.UserID
+	if len(subject) == 0 {
+		subject = email
+	}
+
+	if len(subject) == 0 {
+		err = errors.New("the provider did not return a user id or email")
+		return
+	}
+
+	idt, err := backend.DB.FindIdentity(conf.Name, provider, subject)
 	if err != nil {
 		return
 	}
 
-	if exists {
-		return el.signIn(conf, email)
+	var link oauthLink
+	if err := backend.Cache.GetTyped("oauth_link_"+reqID, &link); err == nil {
+		if err := backend.Cache.Del("oauth_link_" + reqID); err != nil {
+			return model.AuthTokens{}, err
+		}
+
+		if len(idt.ID) > 0 && idt.UserID != link.Auth.UserID {
+			return model.AuthTokens{}, errors.New("this external login is linked to another user")
+		}
+
+		tok, err := backend.DB.GetUserByID(conf.Name, link.Auth.AccountID, link.Auth.UserID)
+		if err != nil {
+			return model.AuthTokens{}, err
+		}
+
+		if len(idt.ID) == 0 {
+			if err := el.linkIdentity(conf, tok, provider, subject, email); err != nil {
+				return model.AuthTokens{}, err
+			}
+		}
+		return backend.Membership(conf).SignInExternal(tok, userAgent, ip)
 	}
 
-	return el.signUp(conf, provider, email, accessToken)
-}
+	if len(idt.ID) > 0 {
+		tok, err := backend.DB.GetUserByID(conf.Name, idt.AccountID, idt.UserID)
+		if err != nil {
+			return model.AuthTokens{}, err
+		}
+		return backend.Membership(conf).SignInExternal(tok, userAgent, ip)
+	}
 
-func (el *ExternalLogins) signIn(conf model.DatabaseConfig, email string) (sessionToken string, err error) {
-	tok, err := backend.DB.FindUserByEmail(conf.Name, email)
+	if invite, err := backend.Cache.Get("oauth_invite_" + reqID); err == nil && len(invite) > 0 {
+		if err := backend.Cache.Del("oauth_invite_" + reqID); err != nil {
+			return model.AuthTokens{}, err
+		}
+
+		pw := fmt.Sprintf("%s:%s|%s", provider, user.AccessToken, user.AccessTokenSecret)
+		tok, err := backend.Membership(conf).AcceptInvitation(invite, pw)
+		if err != nil {
+			return model.AuthTokens{}, err
+		}
+
+		if err := el.linkIdentity(conf, tok, provider, subject, email); err != nil {
+			return model.AuthTokens{}, err
+		}
+		return backend.Membership(conf).SignInExternal(tok, userAgent, ip)
+	}
+
+	if len(email) == 0 {
+		err = errors.New("the provider did not return an email address")
+		return
+	}
+
+	exists, err := backend.DB.UserEmailExists(conf.Name, email)
 	if err != nil {
 		return
 	}
 
-	token := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
+	if !exists {
+		accessToken := fmt.Sprintf("%s|%s", user.AccessToken, user.AccessTokenSecret)
+		if err = el.signUp(conf, provider, email, accessToken); err != nil {
+			return
+		}
+	}
 
-	b, err := backend.GetJWT(token)
+	tok, err := backend.DB.FindUserByEmail(conf.Name, email)
 	if err != nil {
 		return
 	}
 
-	sessionToken = string(b)
-	return
+	if err = el.linkIdentity(conf, tok, provider, subject, email); err != nil {
+		return
+	}
+	return backend.Membership(conf).SignInExternal(tok, userAgent, ip)
+}
+
+func (el *ExternalLogins) linkIdentity(conf model.DatabaseConfig, tok model.User, provider, subject, email string) error {
+	idt := model.Identity{
+		AccountID: tok.AccountID,
+		UserID:    tok.ID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		Created:   time.Now(),
+	}
+
+	_, err := backend.DB.CreateIdentity(conf.Name, idt)
+	return err
 }
 
-func (el *ExternalLogins) signUp(conf model.DatabaseConfig, provider, email, accessToken string) (sessionToken string, err error) {
+func (el *ExternalLogins) signUp(conf model.DatabaseConfig, provider, email, accessToken string) error {
 	pw := fmt.Sprintf("%s:%s", provider, accessToken)
 
 	mship := backend.Membership(conf)
 
-	b, _, err := mship.CreateAccountAndUser(email, pw, 0)
+	_, _, err := mship.CreateAccountAndUser(email, pw, 0)
+	return err
+}
+
+// startLink moves a link request created by a signed-in user to the external
+// login request
+func (el *ExternalLogins) startLink(code, reqID string) error {
+	var link oauthLink
+	if err := backend.Cache.GetTyped("oauth-link:"+code, &link); err != nil {
+		return errors.New("invalid link code")
+	} else if err := backend.Cache.Del("oauth-link:" + code); err != nil {
+		return err
+	} else if time.Now().After(link.Expires) {
+		return errors.New("the link code has expired")
+	}
+
+	return backend.Cache.SetTyped("oauth_link_"+reqID, link)
+}
+
+// link returns a code the signed-in user passes as the "link" parameter of
+// /oauth/login to link the external login to their user
+func (el *ExternalLogins) link(w http.ResponseWriter, r *http.Request) {
+	_, auth, err := middleware.Extract(r, true)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	sessionToken = string(b)
-	return
+	code := internal.RandStringRunes(32)
+	link := oauthLink{Auth: auth, Expires: time.Now().Add(oauthLinkTTL)}
+	if err := backend.Cache.SetTyped("oauth-link:"+code, link); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]string{"code": code})
+}
+
+// identities lists (GET) the external logins linked to the signed-in user
+// or unlinks one (DELETE /oauth/identities/{id})
+func (el *ExternalLogins) identities(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := backend.DB.ListIdentities(conf.Name, auth.UserID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, list)
+	case http.MethodDelete:
+		id := getURLPart(r.URL.Path, 3)
+		if len(id) == 0 {
+			http.Error(w, "missing identity id", http.StatusBadRequest)
+			return
+		}
+
+		if err := backend.DB.DeleteIdentity(conf.Name, auth.UserID, id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
 }
 
 func (el *ExternalLogins) getProvider(dbID, provider, reqID string, info model.OAuthConfig) (p goth.Provider, err error) {
@@ -261,12 +451,18 @@ func (el *ExternalLogins) getProvider(dbID, provider, reqID string, info model.O
 		config.Current.AppURL,
 	)
 
+	if info.IsOIDC() {
+		return newOIDCProvider(provider, callbackURL, info)
+	}
+
 	if provider == OAuthProviderTwitter {
 		return twitter.New(info.ConsumerKey, info.ConsumerSecret, callbackURL), nil
 	} else if provider == OAuthProviderFacebook {
 		return facebook.New(info.ConsumerKey, info.ConsumerSecret, callbackURL), nil
 	} else if provider == OAuthProviderGoogle {
 		return google.New(info.ConsumerKey, info.ConsumerSecret, callbackURL), nil
+	} else if provider == OAuthProviderGitHub {
+		return github.New(info.ConsumerKey, info.ConsumerSecret, callbackURL, "user:email"), nil
 	}
 	return twitter.New("", "", ""), errors.New("invalid auth provider")
 }
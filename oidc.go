@@ -0,0 +1,487 @@
+package staticbackend
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/gbrlsnchs/jwt/v3/jwtutil"
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+// oidcCacheTTL is how long discovery documents and signing keys are kept
+const oidcCacheTTL = time.Hour
+
+// oidcDiscovery is the OpenID Connect provider configuration
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type oidcCacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+var (
+	oidcCache   = make(map[string]oidcCacheEntry)
+	oidcCacheMx sync.Mutex
+)
+
+// oidcProvider is a goth.Provider for generic OpenID Connect providers like
+// Keycloak, Auth0 or Azure AD. The ID token signature is verified with the
+// provider's published keys.
+type oidcProvider struct {
+	name   string
+	info   model.OAuthConfig
+	disc   oidcDiscovery
+	config *oauth2.Config
+	client *http.Client
+}
+
+// oidcSession holds the state of a sign-in between the redirect to the
+// provider and its callback
+type oidcSession struct {
+	AuthURL     string
+	Verifier    string
+	Nonce       string
+	AccessToken string
+	IDToken     string
+	ExpiresAt   time.Time
+}
+
+func newOIDCProvider(name, callbackURL string, info model.OAuthConfig) (*oidcProvider, error) {
+	p := &oidcProvider{
+		name:   name,
+		info:   info,
+		client: http.DefaultClient,
+	}
+
+	if err := p.discover(); err != nil {
+		return nil, err
+	}
+
+	scopes := []string{"openid"}
+	for _, s := range strings.Fields(info.Scopes) {
+		if s != "openid" {
+			scopes = append(scopes, s)
+		}
+	}
+	if len(scopes) == 1 {
+		scopes = append(scopes, "email", "profile")
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     info.ConsumerKey,
+		ClientSecret: info.ConsumerSecret,
+		RedirectURL:  callbackURL,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  p.disc.AuthorizationEndpoint,
+			TokenURL: p.disc.TokenEndpoint,
+		},
+	}
+	return p, nil
+}
+
+func (p *oidcProvider) discover() error {
+	u := p.info.DiscoveryURL
+	if !strings.HasSuffix(u, "/.well-known/openid-configuration") {
+		u = strings.TrimSuffix(u, "/") + "/.well-known/openid-configuration"
+	}
+
+	if v, ok := oidcCached(u); ok {
+		p.disc = v.(oidcDiscovery)
+		return nil
+	}
+
+	if err := p.getJSON(u, "", &p.disc); err != nil {
+		return fmt.Errorf("error fetching the OpenID configuration: %w", err)
+	} else if len(p.disc.AuthorizationEndpoint) == 0 || len(p.disc.TokenEndpoint) == 0 || len(p.disc.JWKSURI) == 0 {
+		return errors.New("the OpenID configuration is missing required endpoints")
+	}
+
+	oidcSetCache(u, p.disc)
+	return nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) SetName(name string) {
+	p.name = name
+}
+
+func (p *oidcProvider) Debug(bool) {}
+
+func (p *oidcProvider) BeginAuth(state string) (goth.Session, error) {
+	sess := &oidcSession{Nonce: internal.RandStringRunes(32)}
+
+	opts := []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("nonce", sess.Nonce)}
+	if p.info.PKCE {
+		sess.Verifier = oauth2.GenerateVerifier()
+		opts = append(opts, oauth2.S256ChallengeOption(sess.Verifier))
+	}
+
+	sess.AuthURL = p.config.AuthCodeURL(state, opts...)
+	return sess, nil
+}
+
+func (p *oidcProvider) UnmarshalSession(data string) (goth.Session, error) {
+	sess := &oidcSession{}
+	err := json.Unmarshal([]byte(data), sess)
+	return sess, err
+}
+
+func (p *oidcProvider) FetchUser(session goth.Session) (goth.User, error) {
+	sess, ok := session.(*oidcSession)
+	if !ok || len(sess.IDToken) == 0 {
+		return goth.User{}, fmt.Errorf("%s cannot get user information without id_token", p.name)
+	}
+
+	claims, err := p.verifyIDToken(sess.IDToken, sess.Nonce)
+	if err != nil {
+		return goth.User{}, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	// some providers only return the profile claims from the userinfo endpoint
+	if len(p.disc.UserInfoEndpoint) > 0 && len(p.claim(claims, p.info.Claims.Email, "email")) == 0 {
+		var info map[string]any
+		if err := p.getJSON(p.disc.UserInfoEndpoint, sess.AccessToken, &info); err != nil {
+			return goth.User{}, fmt.Errorf("error fetching user info: %w", err)
+		} else if info["sub"] != claims["sub"] {
+			return goth.User{}, errors.New("the userinfo subject does not match the id_token")
+		}
+
+		for k, v := range info {
+			if _, ok := claims[k]; !ok {
+				claims[k] = v
+			}
+		}
+	}
+
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		return goth.User{}, errors.New("the email address is not verified by the provider")
+	}
+
+	user := goth.User{
+		RawData:     claims,
+		Provider:    p.name,
+		UserID:      p.claim(claims, p.info.Claims.Subject, "sub"),
+		Email:       p.claim(claims, p.info.Claims.Email, "email"),
+		Name:        p.claim(claims, p.info.Claims.Name, "name"),
+		FirstName:   p.claim(claims, p.info.Claims.FirstName, "given_name"),
+		LastName:    p.claim(claims, p.info.Claims.LastName, "family_name"),
+		AvatarURL:   p.claim(claims, p.info.Claims.AvatarURL, "picture"),
+		AccessToken: sess.AccessToken,
+		ExpiresAt:   sess.ExpiresAt,
+		IDToken:     sess.IDToken,
+	}
+
+	if len(user.UserID) == 0 {
+		return user, errors.New("the id_token has no subject")
+	}
+	return user, nil
+}
+
+func (p *oidcProvider) RefreshTokenAvailable() bool {
+	return false
+}
+
+func (p *oidcProvider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	return nil, errors.New("refresh tokens are not supported")
+}
+
+// claim returns the claim mapped to name or the standard claim when no
+// mapping is set
+func (p *oidcProvider) claim(claims map[string]any, name, standard string) string {
+	if len(name) == 0 {
+		name = standard
+	}
+
+	v, ok := claims[name]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// verifyIDToken validates the signature, issuer, audience, expiry and nonce
+// of an ID token and returns its claims
+func (p *oidcProvider) verifyIDToken(idToken, nonce string) (map[string]any, error) {
+	keys, err := p.keys(false)
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func(keys []oidcKey) (map[string]any, error) {
+		alg := &jwtutil.Resolver{New: func(hd jwt.Header) (jwt.Algorithm, error) {
+			return oidcAlgorithm(hd, keys)
+		}}
+
+		claims := make(map[string]any)
+		_, err := jwt.Verify([]byte(idToken), alg, &claims, jwt.ValidateHeader)
+		return claims, err
+	}
+
+	claims, err := verify(keys)
+	if errors.Is(err, errUnknownOIDCKey) {
+		// the provider might have rotated its keys
+		if keys, err = p.keys(true); err != nil {
+			return nil, err
+		}
+		claims, err = verify(keys)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if claims["iss"] != p.disc.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %v", claims["iss"])
+	} else if !oidcAudience(claims["aud"], p.info.ConsumerKey) {
+		return nil, errors.New("the token was not issued for this client")
+	} else if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, errors.New("invalid nonce")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("the token has expired")
+	}
+	return claims, nil
+}
+
+func (p *oidcProvider) keys(refresh bool) ([]oidcKey, error) {
+	if v, ok := oidcCached(p.disc.JWKSURI); ok && !refresh {
+		return v.([]oidcKey), nil
+	}
+
+	var jwks struct {
+		Keys []oidcKey `json:"keys"`
+	}
+	if err := p.getJSON(p.disc.JWKSURI, "", &jwks); err != nil {
+		return nil, fmt.Errorf("error fetching the signing keys: %w", err)
+	}
+
+	oidcSetCache(p.disc.JWKSURI, jwks.Keys)
+	return jwks.Keys, nil
+}
+
+func (p *oidcProvider) getJSON(u, accessToken string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (s oidcSession) GetAuthURL() (string, error) {
+	if len(s.AuthURL) == 0 {
+		return "", errors.New(goth.NoAuthUrlErrorMessage)
+	}
+	return s.AuthURL, nil
+}
+
+func (s oidcSession) Marshal() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (s *oidcSession) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	p, ok := provider.(*oidcProvider)
+	if !ok {
+		return "", errors.New("invalid provider")
+	}
+
+	var opts []oauth2.AuthCodeOption
+	if len(s.Verifier) > 0 {
+		opts = append(opts, oauth2.VerifierOption(s.Verifier))
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, p.client)
+	token, err := p.config.Exchange(ctx, params.Get("code"), opts...)
+	if err != nil {
+		return "", err
+	}
+
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return "", errors.New("the token response has no id_token")
+	}
+
+	s.AccessToken = token.AccessToken
+	s.IDToken = idToken
+	s.ExpiresAt = token.Expiry
+	return token.AccessToken, nil
+}
+
+var errUnknownOIDCKey = errors.New("unknown signing key")
+
+// oidcAlgorithm returns the verification algorithm for the key matching the
+// token header
+func oidcAlgorithm(hd jwt.Header, keys []oidcKey) (jwt.Algorithm, error) {
+	for _, k := range keys {
+		if len(hd.KeyID) > 0 && k.Kid != hd.KeyID {
+			continue
+		}
+
+		switch {
+		case k.Kty == "RSA" && strings.HasPrefix(hd.Algorithm, "RS"):
+			pub, err := k.rsa()
+			if err != nil {
+				return nil, err
+			}
+
+			switch hd.Algorithm {
+			case "RS256":
+				return jwt.NewRS256(jwt.RSAPublicKey(pub)), nil
+			case "RS384":
+				return jwt.NewRS384(jwt.RSAPublicKey(pub)), nil
+			case "RS512":
+				return jwt.NewRS512(jwt.RSAPublicKey(pub)), nil
+			}
+		case k.Kty == "EC" && strings.HasPrefix(hd.Algorithm, "ES"):
+			pub, err := k.ecdsa()
+			if err != nil {
+				return nil, err
+			}
+
+			switch hd.Algorithm {
+			case "ES256":
+				return jwt.NewES256(jwt.ECDSAPublicKey(pub)), nil
+			case "ES384":
+				return jwt.NewES384(jwt.ECDSAPublicKey(pub)), nil
+			case "ES512":
+				return jwt.NewES512(jwt.ECDSAPublicKey(pub)), nil
+			}
+		}
+	}
+	return nil, errUnknownOIDCKey
+}
+
+func (k oidcKey) rsa() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	pub := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+	return pub, nil
+}
+
+func (k oidcKey) ecdsa() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	return pub, nil
+}
+
+// oidcAudience returns true if the aud claim, a string or a list, contains
+// the client id
+func oidcAudience(aud any, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []any:
+		for _, a := range v {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func oidcCached(key string) (any, bool) {
+	oidcCacheMx.Lock()
+	defer oidcCacheMx.Unlock()
+
+	entry, ok := oidcCache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func oidcSetCache(key string, v any) {
+	oidcCacheMx.Lock()
+	defer oidcCacheMx.Unlock()
+
+	oidcCache[key] = oidcCacheEntry{value: v, expires: time.Now().Add(oidcCacheTTL)}
+}
@@ -0,0 +1,411 @@
+package staticbackend
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// mockOIDC is a minimal OpenID Connect provider issuing ID tokens for the
+// subject and email set by the test
+type mockOIDC struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mx      sync.Mutex
+	subject string
+	email   string
+	codes   map[string]url.Values
+}
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockOIDC{key: key, codes: make(map[string]url.Values)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"userinfo_endpoint":      m.URL + "/userinfo",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		e := big.NewInt(int64(key.E)).Bytes()
+		respond(w, http.StatusOK, map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "mock-key",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(e),
+			}},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		code := fmt.Sprintf("code-%d", time.Now().UnixNano())
+
+		m.mx.Lock()
+		m.codes[code] = params
+		m.mx.Unlock()
+
+		redirect := fmt.Sprintf("%s?code=%s&state=%s", params.Get("redirect_uri"), code, url.QueryEscape(params.Get("state")))
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		m.mx.Lock()
+		params, ok := m.codes[r.Form.Get("code")]
+		delete(m.codes, r.Form.Get("code"))
+		m.mx.Unlock()
+
+		if !ok {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		h := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(h[:]) != params.Get("code_challenge") {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		claims := map[string]any{
+			"iss":   m.URL,
+			"sub":   m.subject,
+			"aud":   params.Get("client_id"),
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": params.Get("nonce"),
+			"email": m.email,
+			"name":  "Mock User",
+		}
+
+		idToken, err := jwt.Sign(claims, jwt.NewRS256(jwt.RSAPrivateKey(m.key)), jwt.KeyID("mock-key"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, map[string]any{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     string(idToken),
+		})
+	})
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockOIDC) as(subject, email string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.subject = subject
+	m.email = email
+}
+
+func enableMockOIDC(t *testing.T, name, discoveryURL string) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cus, err := backend.DB.FindTenant(conf.TenantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logins, err := cus.GetExternalLogins()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logins[name] = model.OAuthConfig{
+		ConsumerKey:    "mock-client",
+		ConsumerSecret: "mock-secret",
+		DiscoveryURL:   discoveryURL,
+		Scopes:         "openid email",
+		PKCE:           true,
+	}
+
+	if err := backend.DB.EnableExternalLogin(cus.ID, logins); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// oidcSignIn goes through the external login flow and returns the user, params
+// are added to the login query string
+// oidcCallback goes through the provider's login and returns the response
+// of the callback
+func oidcCallback(t *testing.T, el *ExternalLogins, provider, reqID, params string) *http.Response {
+	u := fmt.Sprintf("/oauth/login?provider=%s&reqid=%s&%s", provider, reqID, params)
+	req := httptest.NewRequest("GET", u, nil)
+	req.Header.Set("SB-PUBLIC-KEY", pubKey)
+
+	w := httptest.NewRecorder()
+	middleware.Chain(el.login(), middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL)).ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	} else if authURL.Query().Get("code_challenge_method") != "S256" {
+		t.Fatalf("expected a PKCE code challenge in %s", authURL)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	idpResp, err := client.Get(authURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idpResp.Body.Close()
+
+	callbackURL, err := url.Parse(idpResp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest("GET", "/oauth/callback?"+callbackURL.RawQuery, nil)
+	w = httptest.NewRecorder()
+	el.callback().ServeHTTP(w, req)
+	return w.Result()
+}
+
+func oidcSignIn(t *testing.T, el *ExternalLogins, provider, reqID, params string) ExternalUser {
+	if resp := oidcCallback(t, el, provider, reqID, params); resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	req := httptest.NewRequest("GET", "/oauth/get-user?reqid="+reqID, nil)
+	w := httptest.NewRecorder()
+	el.getUser(w, req)
+
+	var extuser ExternalUser
+	if err := json.NewDecoder(w.Result().Body).Decode(&extuser); err != nil {
+		t.Fatal(err)
+	}
+	return extuser
+}
+
+func TestOIDCLogin(t *testing.T) {
+	if err := loadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	idp := newMockOIDC(t)
+	enableMockOIDC(t, "mockoidc", idp.URL)
+
+	el := &ExternalLogins{log: backend.Log}
+
+	idp.as("oidc-subject-1", "oidc@test.com")
+	extuser := oidcSignIn(t, el, "mockoidc", "oidcreq1", "")
+	if extuser.Email != "oidc@test.com" || len(extuser.Token) == 0 {
+		t.Fatalf("expected a signed in user got %v", extuser)
+	}
+
+	me := func(token string) model.Auth {
+		resp := sessionReq(t, mship.me, "GET", "/me", token, nil)
+		if resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+
+		var auth model.Auth
+		if err := parseBody(resp.Body, &auth); err != nil {
+			t.Fatal(err)
+		}
+		return auth
+	}
+
+	first := me(extuser.Token)
+
+	// the identity is found by its subject even if the email changed
+	idp.as("oidc-subject-1", "oidc-changed@test.com")
+	extuser = oidcSignIn(t, el, "mockoidc", "oidcreq2", "")
+	if auth := me(extuser.Token); auth.UserID != first.UserID {
+		t.Errorf("expected user %s got %s", first.UserID, auth.UserID)
+	}
+}
+
+func TestOIDCLinkIdentities(t *testing.T) {
+	if err := loadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	idp := newMockOIDC(t)
+	enableMockOIDC(t, "mocklink", idp.URL)
+
+	el := &ExternalLogins{log: backend.Log}
+
+	resp := sessionReq(t, el.link, "POST", "/oauth/link", userToken, nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var link map[string]string
+	if err := parseBody(resp.Body, &link); err != nil {
+		t.Fatal(err)
+	}
+
+	// the external email differs from the signed-in user's email
+	idp.as("link-subject", "someone-else@test.com")
+	extuser := oidcSignIn(t, el, "mocklink", "linkreq1", "link="+link["code"])
+
+	resp = sessionReq(t, el.identities, "GET", "/oauth/identities", extuser.Token, nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var list []model.Identity
+	if err := parseBody(resp.Body, &list); err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 || list[0].Subject != "link-subject" {
+		t.Fatalf("expected the linked identity got %v", list)
+	}
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := backend.DB.FindUserByEmail(conf.Name, userEmail)
+	if err != nil {
+		t.Fatal(err)
+	} else if list[0].UserID != user.ID {
+		t.Errorf("expected identity linked to %s got %s", user.ID, list[0].UserID)
+	}
+
+	path := "/oauth/identities/" + list[0].ID
+	if resp := sessionReq(t, el.identities, "DELETE", path, extuser.Token, nil); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	// once unlinked, signing in creates a new user for the external email
+	extuser = oidcSignIn(t, el, "mocklink", "linkreq2", "")
+	if extuser.Email != "someone-else@test.com" {
+		t.Errorf("expected the external email got %v", extuser)
+	}
+}
+
+func TestOIDCLoginRequiresMFA(t *testing.T) {
+	if err := loadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	idp := newMockOIDC(t)
+	enableMockOIDC(t, "mockmfa", idp.URL)
+
+	el := &ExternalLogins{log: backend.Log}
+
+	idp.as("mfa-subject", "oidc-mfa@test.com")
+	extuser := oidcSignIn(t, el, "mockmfa", "mfareq1", "")
+	if len(extuser.Token) == 0 || extuser.MFA != nil {
+		t.Fatalf("expected a session without MFA got %v", extuser)
+	}
+
+	resp := sessionReq(t, mship.mfa, "POST", "/mfa", extuser.Token, map[string]string{"issuer": "Unit Test"})
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var enrollment model.MFAEnrollment
+	if err := parseBody(resp.Body, &enrollment); err != nil {
+		t.Fatal(err)
+	}
+
+	confirm := map[string]string{"code": totpCode(t, enrollment.Secret, 0)}
+	resp = sessionReq(t, mship.mfa, "PUT", "/mfa", extuser.Token, confirm)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	// the external login now returns a challenge instead of a session
+	extuser = oidcSignIn(t, el, "mockmfa", "mfareq2", "")
+	if len(extuser.Token) > 0 || extuser.MFA == nil || len(extuser.MFA.Challenge) == 0 {
+		t.Fatalf("expected an MFA challenge and no token got %v", extuser)
+	}
+
+	verify := map[string]string{"challenge": extuser.MFA.Challenge, "code": totpCode(t, enrollment.Secret, 1)}
+	resp = sessionReq(t, mship.verifyMFA, "POST", "/login/mfa", "", verify)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var tokens model.AuthTokens
+	if err := parseBody(resp.Body, &tokens); err != nil {
+		t.Fatal(err)
+	}
+	expectMeStatus(t, tokens.Token, http.StatusOK)
+}
+
+func TestOIDCLoginEmailVerificationBlock(t *testing.T) {
+	if err := loadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	setAuthPolicy(t, model.AuthPolicy{EmailVerification: model.EmailVerificationBlock})
+	defer setAuthPolicy(t, model.AuthPolicy{})
+
+	idp := newMockOIDC(t)
+	enableMockOIDC(t, "mockverify", idp.URL)
+
+	el := &ExternalLogins{log: backend.Log}
+
+	// an unverified user cannot sign in via an external login with their email
+	email := "oidc-verify@test.com"
+	login := model.Login{Email: email, Password: "oidc_verify_pw"}
+	resp := sessionReq(t, mship.register, "POST", "/register", "", login)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected status 202 got %s", GetResponseBody(t, resp))
+	}
+
+	idp.as("verify-subject", email)
+	if resp := oidcCallback(t, el, "mockverify", "verifyreq1", ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403 got %s", GetResponseBody(t, resp))
+	}
+
+	verify := map[string]string{"email": email, "code": verificationCode(t, email)}
+	resp = sessionReq(t, mship.verifyEmail, "POST", "/verify-email", "", verify)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	extuser := oidcSignIn(t, el, "mockverify", "verifyreq2", "")
+	expectMeStatus(t, extuser.Token, http.StatusOK)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/staticbackendhq/core/cache"
 	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/metrics"
 	"github.com/staticbackendhq/core/model"
 
 	"github.com/google/uuid"
@@ -73,6 +74,8 @@ func (b *Broker) start() {
 			b.ids[id.String()] = data.messages
 			b.conf[id.String()] = data.ctx
 
+			metrics.ClientConnected()
+
 			msg := model.Command{
 				Type: model.MsgTypeInit,
 				Data: id.String(),
@@ -96,6 +99,8 @@ func (b *Broker) unsub(c chan model.Command) {
 	id, ok := b.clients[c]
 	if !ok {
 		b.log.Info().Msg("cannot find connection id")
+	} else {
+		metrics.ClientDisconnected()
 	}
 
 	subs, ok := b.subscriptions[id]
@@ -157,6 +162,8 @@ func (b *Broker) Accept(w http.ResponseWriter, r *http.Request) {
 
 			// flush immediately.
 			flusher.Flush()
+
+			metrics.MessageDelivered()
 		case <-ctx.Done():
 			b.closingConnections <- messages
 			return
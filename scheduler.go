@@ -0,0 +1,56 @@
+package staticbackend
+
+import (
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/function"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+type schedulerStatus struct {
+	// Instance is the ID of the instance serving the request
+	Instance string `json:"instance"`
+	// Leader is the ID of the instance running the scheduler
+	Leader   string       `json:"leader"`
+	IsLeader bool         `json:"isLeader"`
+	Tasks    []taskStatus `json:"tasks"`
+}
+
+type taskStatus struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// RunningOn is the ID of the instance currently running the task
+	RunningOn string `json:"runningOn"`
+}
+
+// sudoScheduler returns the scheduler's leader and the running tasks of the
+// database with GET /sudo/scheduler
+func sudoScheduler(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := backend.DB.ListTasksByBase(conf.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := schedulerStatus{
+		Instance: backend.Elector.ID,
+		Leader:   backend.Elector.Leader(),
+		IsLeader: backend.Elector.IsLeader(),
+		Tasks:    make([]taskStatus, 0, len(tasks)),
+	}
+
+	for _, task := range tasks {
+		ts := taskStatus{ID: task.ID, Name: task.Name}
+		ts.RunningOn, _ = backend.Cache.Get(function.TaskLockKey(task.ID))
+		status.Tasks = append(status.Tasks, ts)
+	}
+
+	respond(w, http.StatusOK, status)
+}
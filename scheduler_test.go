@@ -0,0 +1,31 @@
+package staticbackend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+)
+
+func TestSchedulerStatus(t *testing.T) {
+	// the leader is elected asynchronously on startup
+	for i := 0; i < 50 && !backend.Elector.IsLeader(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp := dbReq(t, sudoScheduler, "GET", "/sudo/scheduler", nil, true)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var status schedulerStatus
+	if err := parseBody(resp.Body, &status); err != nil {
+		t.Fatal(err)
+	}
+
+	if !status.IsLeader {
+		t.Error("expected the single instance to be the leader")
+	} else if status.Leader != status.Instance {
+		t.Errorf("expected leader %s to be instance %s", status.Leader, status.Instance)
+	}
+}
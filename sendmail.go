@@ -1,12 +1,15 @@
 package staticbackend
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/staticbackendhq/core/backend"
 	"github.com/staticbackendhq/core/email"
 	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
 )
 
 func sudoSendMail(w http.ResponseWriter, r *http.Request) {
@@ -16,24 +19,27 @@ func sudoSendMail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// if only body is provided
-	if len(data.Body) > 0 {
-		data.HTMLBody = data.Body
-		data.TextBody = email.StripHTML(data.Body)
-	} else if len(data.TextBody) == 0 && len(data.HTMLBody) > 0 {
-		data.TextBody = email.StripHTML(data.HTMLBody)
-	} else if len(data.HTMLBody) == 0 && len(data.TextBody) > 0 {
-		data.HTMLBody = data.TextBody
+	config, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	if err := backend.Emailer.Send(data); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	outbox := email.Outbox{
+		Mailer:  backend.Emailer,
+		DB:      backend.DB,
+		Storage: backend.Filestore,
 	}
 
-	config, _, err := middleware.Extract(r, false)
+	entry, err := outbox.Send(config.Name, data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		// the template or attachments could not be loaded
+		if len(entry.Status) == 0 {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -44,3 +50,97 @@ func sudoSendMail(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, http.StatusOK, true)
 }
+
+// sudoEmailTemplates lists and saves the email templates on /sudo/emailtemplates
+// and gets or deletes one on /sudo/emailtemplates/{name}
+func sudoEmailTemplates(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := getURLPart(r.URL.Path, 3)
+
+	if r.Method == http.MethodPost {
+		var tmpl model.EmailTemplate
+		if err := parseBody(r.Body, &tmpl); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(name) > 0 {
+			tmpl.Name = name
+		}
+
+		if err := validateEmailTemplate(tmpl); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := backend.DB.SaveEmailTemplate(conf.Name, tmpl); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+		return
+	} else if r.Method == http.MethodDelete {
+		if err := backend.DB.DeleteEmailTemplate(conf.Name, name); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+		return
+	}
+
+	if len(name) > 0 {
+		tmpl, err := backend.DB.GetEmailTemplate(conf.Name, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, tmpl)
+		return
+	}
+
+	list, err := backend.DB.ListEmailTemplates(conf.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, list)
+}
+
+func validateEmailTemplate(tmpl model.EmailTemplate) error {
+	if len(strings.TrimSpace(tmpl.Name)) == 0 {
+		return errors.New("name is required")
+	} else if strings.Contains(tmpl.Name, "/") {
+		return errors.New("name cannot contain /")
+	} else if len(tmpl.Subject) == 0 {
+		return errors.New("subject is required")
+	} else if len(tmpl.HTMLBody) == 0 && len(tmpl.TextBody) == 0 {
+		return errors.New("htmlBody or textBody is required")
+	}
+
+	return email.ValidateTemplate(tmpl)
+}
+
+func sudoEmailLog(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := backend.DB.ListEmailLogs(conf.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, list)
+}
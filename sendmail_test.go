@@ -1,11 +1,15 @@
 package staticbackend
 
 import (
+	"bytes"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/staticbackendhq/core/backend"
 	"github.com/staticbackendhq/core/config"
 	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/model"
 )
 
 func Test_Sendmail(t *testing.T) {
@@ -23,3 +27,91 @@ func Test_Sendmail(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+type captureMailer struct {
+	sent []email.SendMailData
+}
+
+func (c *captureMailer) Send(data email.SendMailData) error {
+	c.sent = append(c.sent, data)
+	return nil
+}
+
+func TestSendMailTemplate(t *testing.T) {
+	mailer := &captureMailer{}
+	prev := backend.Emailer
+	backend.Emailer = mailer
+	defer func() {
+		backend.Emailer = prev
+	}()
+
+	tmpl := model.EmailTemplate{
+		Name:     "welcome",
+		Subject:  "Welcome {{.name}}",
+		HTMLBody: "<p>Hello {{.name}}</p>",
+	}
+	resp := dbReq(t, sudoEmailTemplates, "POST", "/sudo/emailtemplates", tmpl, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, sudoEmailTemplates, "GET", "/sudo/emailtemplates/welcome", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	auth := model.Auth{AccountID: testAccountID}
+	conf := model.DatabaseConfig{Name: dbName}
+
+	content := []byte("invoice content")
+	f, err := backend.Storage(auth, conf).Save("invoice.txt", "", bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := email.SendMailData{
+		From:        config.Current.FromEmail,
+		To:          "unit@test.com",
+		Template:    "welcome",
+		Data:        map[string]any{"name": "unit test"},
+		Attachments: []string{f.ID},
+	}
+	resp = dbReq(t, sudoSendMail, "POST", "/sudo/sendmail", data, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected 1 email sent got %d", len(mailer.sent))
+	}
+
+	sent := mailer.sent[0]
+	if sent.Subject != "Welcome unit test" {
+		t.Errorf("expected subject to be rendered got %s", sent.Subject)
+	} else if !strings.Contains(sent.TextBody, "Hello unit test") {
+		t.Errorf("expected text body to be stripped from html got %s", sent.TextBody)
+	} else if len(sent.Files) != 1 || !bytes.Equal(sent.Files[0].Content, content) {
+		t.Errorf("expected the attachment to be loaded got %v", sent.Files)
+	}
+
+	resp = dbReq(t, sudoEmailLog, "GET", "/sudo/emaillog", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var logs []model.EmailLog
+	if err := parseBody(resp.Body, &logs); err != nil {
+		t.Fatal(err)
+	} else if len(logs) == 0 {
+		t.Fatal("expected at least one email log")
+	} else if logs[0].Template != "welcome" || logs[0].Status != model.EmailStatusSent {
+		t.Errorf("expected a sent log for the welcome template got %v", logs[0])
+	}
+
+	// unknown template
+	data.Template = "unknown"
+	resp = dbReq(t, sudoSendMail, "POST", "/sudo/sendmail", data, true)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown template got %d", resp.StatusCode)
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"embed"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
 	"strings"
 	"syscall"
 
@@ -16,9 +16,12 @@ import (
 	"github.com/staticbackendhq/core/config"
 	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/logger"
+	"github.com/staticbackendhq/core/metrics"
 	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
 	"github.com/staticbackendhq/core/realtime"
+	"github.com/staticbackendhq/core/storage"
+	"github.com/staticbackendhq/core/tracing"
 
 	"github.com/stripe/stripe-go/v84"
 	"golang.org/x/sync/errgroup"
@@ -49,6 +52,11 @@ func Start(c config.AppConfig, log *logger.Logger) {
 		}
 	}
 
+	shutdownTracing, err := tracing.Setup(c)
+	if err != nil {
+		log.Fatal().Err(err).Msg("error setting up the tracing exporter")
+	}
+
 	// the backend pckage and this bkn instance holds
 	// all services like the Datastore, Filestore, Emailers, etc.
 	backend.Setup(c)
@@ -128,51 +136,105 @@ func Start(c config.AppConfig, log *logger.Logger) {
 		middleware.RequireRoot(backend.DB, backend.Cache),
 	}
 
+	// keyAuth and keyRoot also accept API keys having the scope
+	keyAuth := func(scope middleware.ScopeFunc) []middleware.Middleware {
+		return []middleware.Middleware{
+			middleware.Cors(),
+			middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
+			middleware.AllowAPIKey(scope),
+			middleware.RequireAuth(backend.DB, backend.Cache),
+		}
+	}
+
+	keyRoot := func(scope middleware.ScopeFunc) []middleware.Middleware {
+		return []middleware.Middleware{
+			middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
+			middleware.AllowAPIKey(scope),
+			middleware.RequireRoot(backend.DB, backend.Cache),
+		}
+	}
+
 	// static assets
 	http.Handle("/static/", http.StripPrefix("/", http.FileServer(http.FS(content))))
 
 	m := &membership{log: log}
 
 	http.Handle("/login/magic", middleware.Chain(http.HandlerFunc(m.magicLink), pubWithDB...))
+	http.Handle("/login/mfa", middleware.Chain(http.HandlerFunc(m.verifyMFA), pubWithDB...))
+	http.Handle("/login/mfa/", middleware.Chain(http.HandlerFunc(m.verifyMFA), pubWithDB...))
 	http.Handle("/login", middleware.Chain(http.HandlerFunc(m.login), pubWithDB...))
 	http.Handle("/register", middleware.Chain(http.HandlerFunc(m.register), pubWithDB...))
 	http.Handle("/email", middleware.Chain(http.HandlerFunc(m.emailExists), pubWithDB...))
 	http.Handle("/password/resetcode", middleware.Chain(http.HandlerFunc(m.setResetCode), stdRoot...))
 	http.Handle("/password/reset", middleware.Chain(http.HandlerFunc(m.resetPassword), pubWithDB...))
+	http.Handle("/verify-email", middleware.Chain(http.HandlerFunc(m.verifyEmail), pubWithDB...))
+	http.Handle("/verify-email/", middleware.Chain(http.HandlerFunc(m.verifyEmail), pubWithDB...))
+	http.Handle("/invitation", middleware.Chain(http.HandlerFunc(m.invitation), pubWithDB...))
+	http.Handle("/invitation/", middleware.Chain(http.HandlerFunc(m.invitation), pubWithDB...))
 	//http.Handle("/setrole", chain(http.HandlerFunc(setRole), withDB))
 	http.Handle("/me", middleware.Chain(http.HandlerFunc(m.me), stdAuth...))
+	http.Handle("/me/profile", middleware.Chain(http.HandlerFunc(m.profile), stdAuth...))
+	http.Handle("/refresh", middleware.Chain(http.HandlerFunc(m.refresh), pubWithDB...))
+	http.Handle("/logout", middleware.Chain(http.HandlerFunc(m.logout), stdAuth...))
+	http.Handle("/sessions", middleware.Chain(http.HandlerFunc(m.sessions), stdAuth...))
+	http.Handle("/sessions/", middleware.Chain(http.HandlerFunc(m.sessions), stdAuth...))
+	http.Handle("/mfa", middleware.Chain(http.HandlerFunc(m.mfa), stdAuth...))
+	http.Handle("/mfa/", middleware.Chain(http.HandlerFunc(m.mfa), stdAuth...))
 
 	// oauth handlers
 	el := &ExternalLogins{log: log}
 	http.Handle("/oauth/login", middleware.Chain(el.login(), pubWithDB...))
 	http.Handle("/oauth/callback/", middleware.Chain(el.callback(), stdPub...))
 	http.Handle("/oauth/get-user", middleware.Chain(http.HandlerFunc(el.getUser), pubWithDB...))
+	http.Handle("/oauth/link", middleware.Chain(http.HandlerFunc(el.link), stdAuth...))
+	http.Handle("/oauth/identities", middleware.Chain(http.HandlerFunc(el.identities), stdAuth...))
+	http.Handle("/oauth/identities/", middleware.Chain(http.HandlerFunc(el.identities), stdAuth...))
 
 	http.Handle("/sudogettoken/", middleware.Chain(http.HandlerFunc(m.sudoGetTokenFromAccountID), stdRoot...))
 
 	// database routes
-	http.Handle("/db/", middleware.Chain(http.HandlerFunc(database.dbreq), stdAuth...))
-	http.Handle("/db/count/", middleware.Chain(http.HandlerFunc(database.count), stdAuth...))
-	http.Handle("/query/", middleware.Chain(http.HandlerFunc(database.query), stdAuth...))
-	http.Handle("/inc/", middleware.Chain(http.HandlerFunc(database.increase), stdAuth...))
-	http.Handle("/sudoquery/", middleware.Chain(http.HandlerFunc(database.query), stdRoot...))
+	http.Handle("/db/", middleware.Chain(http.HandlerFunc(database.dbreq), keyAuth(middleware.CollectionScope(2))...))
+	http.Handle("/db/count/", middleware.Chain(http.HandlerFunc(database.count), keyAuth(middleware.ReadScope(3))...))
+	http.Handle("/query/", middleware.Chain(http.HandlerFunc(database.query), keyAuth(middleware.ReadScope(2))...))
+	http.Handle("/inc/", middleware.Chain(http.HandlerFunc(database.increase), keyAuth(middleware.CollectionScope(2))...))
+	http.Handle("/sudoquery/", middleware.Chain(http.HandlerFunc(database.query), keyRoot(middleware.ReadScope(2))...))
 	http.Handle("/sudolistall/", middleware.Chain(http.HandlerFunc(database.listCollections), stdRoot...))
 	http.Handle("/sudo/index", middleware.Chain(http.HandlerFunc(database.index), stdRoot...))
-	http.Handle("/sudo/", middleware.Chain(http.HandlerFunc(database.dbreq), stdRoot...))
+	http.Handle("/sudo/export", middleware.Chain(http.HandlerFunc(sudoExport), stdRoot...))
+	http.Handle("/sudo/backups", middleware.Chain(http.HandlerFunc(sudoBackups), stdRoot...))
+	http.Handle("/sudo/backups/", middleware.Chain(http.HandlerFunc(sudoBackups), stdRoot...))
+	http.Handle("/sudo/scheduler", middleware.Chain(http.HandlerFunc(sudoScheduler), stdRoot...))
+	http.Handle("/sudo/tasks", middleware.Chain(http.HandlerFunc(sudoTasks), stdRoot...))
+	http.Handle("/sudo/tasks/", middleware.Chain(http.HandlerFunc(sudoTasks), stdRoot...))
+	sudoDB := middleware.Chain(http.HandlerFunc(database.dbreq), keyRoot(middleware.CollectionScope(2))...)
+	http.Handle("/sudo/", sudoDB)
+	http.Handle("/sudo/users/", sudoUsersRoute(middleware.Chain(http.HandlerFunc(sudoUserData), stdRoot...), sudoDB))
 	http.Handle("/newid", middleware.Chain(http.HandlerFunc(database.newID), stdAuth...))
 	http.Handle("/search", middleware.Chain(http.HandlerFunc(database.search), stdAuth...))
 
 	// forms routes
 	http.Handle("/postform/", middleware.Chain(http.HandlerFunc(submitForm), pubWithDB...))
 	http.Handle("/form", middleware.Chain(http.HandlerFunc(listForm), stdRoot...))
+	http.Handle("/sudo/forms", middleware.Chain(http.HandlerFunc(sudoFormDefinitions), stdRoot...))
+	http.Handle("/sudo/forms/", middleware.Chain(http.HandlerFunc(sudoFormDefinitions), stdRoot...))
 
 	// storage
-	http.Handle("/storage/upload", middleware.Chain(http.HandlerFunc(upload), stdAuth...))
-	http.Handle("/sudostorage/delete", middleware.Chain(http.HandlerFunc(deleteFile), stdRoot...))
+	http.Handle("/storage/upload", middleware.Chain(http.HandlerFunc(upload), keyAuth(middleware.StaticScope(model.ScopeStorage))...))
+	http.Handle("/sudostorage/delete", middleware.Chain(http.HandlerFunc(deleteFile), keyRoot(middleware.StaticScope(model.ScopeStorage))...))
 
 	// sudo actions
-	http.Handle("/sudo/sendmail", middleware.Chain(http.HandlerFunc(sudoSendMail), stdRoot...))
+	http.Handle("/sudo/sendmail", middleware.Chain(http.HandlerFunc(sudoSendMail), keyRoot(middleware.StaticScope(model.ScopeSendMail))...))
+	http.Handle("/sudo/emailtemplates", middleware.Chain(http.HandlerFunc(sudoEmailTemplates), stdRoot...))
+	http.Handle("/sudo/emailtemplates/", middleware.Chain(http.HandlerFunc(sudoEmailTemplates), stdRoot...))
+	http.Handle("/sudo/emaillog", middleware.Chain(http.HandlerFunc(sudoEmailLog), stdRoot...))
 	http.Handle("/sudo/cache", middleware.Chain(http.HandlerFunc(sudoCache), stdRoot...))
+	http.Handle("/sudo/mfa", middleware.Chain(http.HandlerFunc(sudoMFA), stdRoot...))
+	http.Handle("/sudo/mfa/", middleware.Chain(http.HandlerFunc(sudoMFA), stdRoot...))
+	http.Handle("/sudo/domains", middleware.Chain(http.HandlerFunc(sudoAllowedDomains), stdRoot...))
+	http.Handle("/sudo/authpolicy", middleware.Chain(http.HandlerFunc(sudoAuthPolicy), stdRoot...))
+	http.Handle("/sudo/lockouts", middleware.Chain(http.HandlerFunc(sudoLockouts), stdRoot...))
+	http.Handle("/sudo/apikeys", middleware.Chain(http.HandlerFunc(sudoAPIKeys), stdRoot...))
+	http.Handle("/sudo/apikeys/", middleware.Chain(http.HandlerFunc(sudoAPIKeys), stdRoot...))
 
 	// account
 	acct := &accounts{log: log}
@@ -181,6 +243,12 @@ func Start(c config.AppConfig, log *logger.Logger) {
 	http.Handle("/account/portal", middleware.Chain(http.HandlerFunc(acct.portal), stdRoot...))
 	http.Handle("/account/users/", middleware.Chain(http.HandlerFunc(acct.deleteUser), stdAuth...))
 	http.Handle("/account/users", middleware.Chain(http.HandlerFunc(acct.addUser), stdAuth...))
+	http.Handle("/account/groups", middleware.Chain(http.HandlerFunc(acct.groups), stdAuth...))
+	http.Handle("/account/groups/", middleware.Chain(http.HandlerFunc(acct.groups), stdAuth...))
+	http.Handle("/account/roles", middleware.Chain(http.HandlerFunc(acct.roles), stdAuth...))
+	http.Handle("/account/roles/", middleware.Chain(http.HandlerFunc(acct.roles), stdAuth...))
+	http.Handle("/account/invitations", middleware.Chain(http.HandlerFunc(acct.invitations), stdAuth...))
+	http.Handle("/account/invitations/", middleware.Chain(http.HandlerFunc(acct.invitations), stdAuth...))
 	http.Handle("/account/add-db", middleware.Chain(http.HandlerFunc(acct.addDatabase), stdAuth...))
 
 	// stripe webhooks
@@ -188,6 +256,7 @@ func Start(c config.AppConfig, log *logger.Logger) {
 	http.HandleFunc("/stripe", swh.process)
 
 	http.HandleFunc("/ping", ping)
+	http.Handle("/metrics", metrics.Handler(c.MetricsToken))
 
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		//TODO: when we move from SSE to full WebSocket re-enable this upgrade
@@ -215,7 +284,7 @@ func Start(c config.AppConfig, log *logger.Logger) {
 	http.Handle("/fn/delete/", middleware.Chain(http.HandlerFunc(f.del), stdRoot...))
 	http.Handle("/fn/del/", middleware.Chain(http.HandlerFunc(f.del), stdRoot...))
 	http.Handle("/fn/info/", middleware.Chain(http.HandlerFunc(f.info), stdRoot...))
-	http.Handle("/fn/exec/", middleware.Chain(http.HandlerFunc(f.exec), stdAuth...))
+	http.Handle("/fn/exec/", middleware.Chain(http.HandlerFunc(f.exec), keyAuth(middleware.StaticScope(model.ScopeFunctionsExec))...))
 	http.Handle("/fn", middleware.Chain(http.HandlerFunc(f.list), stdRoot...))
 
 	// pubsub
@@ -225,14 +294,19 @@ func Start(c config.AppConfig, log *logger.Logger) {
 	ex := &extras{log: log}
 	http.Handle("/extra/resizeimg", middleware.Chain(http.HandlerFunc(ex.resizeImage), stdAuth...))
 	http.Handle("/extra/sms", middleware.Chain(http.HandlerFunc(ex.sudoSendSMS), stdRoot...))
+	http.Handle("/extra/sms/config", middleware.Chain(http.HandlerFunc(ex.sudoSMSConfig), stdRoot...))
+	http.Handle("/extra/sms/messages/", middleware.Chain(http.HandlerFunc(ex.sudoSMSMessages), stdRoot...))
+	http.Handle("/extra/sms/messages", middleware.Chain(http.HandlerFunc(ex.sudoSMSMessages), stdRoot...))
+	http.HandleFunc("/sms/status/", ex.smsStatus)
 	http.Handle("/extra/htmltox", middleware.Chain(http.HandlerFunc(ex.htmlToX), stdAuth...))
+	http.Handle("/extra/pdf", middleware.Chain(http.HandlerFunc(ex.renderPDF), stdAuth...))
 
 	// local storage file serving
 	// only available in dev mode since it's serving /tmp
 	// where the local storage provider serve files
 	if config.Current.AppEnv == AppEnvDev {
 		fs := http.FileServer(http.Dir(os.TempDir()))
-		http.Handle("/localfs/", http.StripPrefix("/localfs/", fs))
+		http.Handle("/localfs/", http.StripPrefix("/localfs/", localFiles(fs)))
 	}
 
 	// ui routes
@@ -242,6 +316,8 @@ func Start(c config.AppConfig, log *logger.Logger) {
 	http.Handle("/ui/users/", middleware.Chain(http.HandlerFunc(webUI.users), stdRoot...))
 	http.Handle("/ui/logins", middleware.Chain(http.HandlerFunc(webUI.logins), stdRoot...))
 	http.Handle("/ui/enable-login", middleware.Chain(http.HandlerFunc(webUI.enableExternalLogin), stdRoot...))
+	http.Handle("/ui/domains", middleware.Chain(http.HandlerFunc(webUI.domains), stdRoot...))
+	http.Handle("/ui/apikeys", middleware.Chain(http.HandlerFunc(webUI.apiKeys), stdRoot...))
 	http.Handle("/ui/db", middleware.Chain(http.HandlerFunc(webUI.dbCols), stdRoot...))
 	http.Handle("/ui/db/save", middleware.Chain(http.HandlerFunc(webUI.dbSave), stdRoot...))
 	http.Handle("/ui/db/del/", middleware.Chain(http.HandlerFunc(webUI.dbDel), stdRoot...))
@@ -252,12 +328,14 @@ func Start(c config.AppConfig, log *logger.Logger) {
 	http.Handle("/ui/fn/", middleware.Chain(http.HandlerFunc(webUI.fnEdit), stdRoot...))
 	http.Handle("/ui/fn", middleware.Chain(http.HandlerFunc(webUI.fnList), stdRoot...))
 	http.Handle("/ui/tasks/new", middleware.Chain(http.HandlerFunc(webUI.taskNew), stdRoot...))
+	http.Handle("/ui/tasks/", middleware.Chain(http.HandlerFunc(webUI.taskDetail), stdRoot...))
 	http.Handle("/ui/tasks", middleware.Chain(http.HandlerFunc(webUI.tasks), stdRoot...))
 	http.Handle("/ui/forms", middleware.Chain(http.HandlerFunc(webUI.forms), stdRoot...))
 	http.Handle("/ui/forms/del/", middleware.Chain(http.HandlerFunc(webUI.formDel), stdRoot...))
 	http.Handle("/ui/fs", middleware.Chain(http.HandlerFunc(webUI.fsList), stdRoot...))
 	http.Handle("/ui/fs/del/", middleware.Chain(http.HandlerFunc(webUI.fsDel), stdRoot...))
 	http.Handle("/ui/my-account/", middleware.Chain(http.HandlerFunc(webUI.myAccount), stdRoot...))
+	http.Handle("/ui/backups", middleware.Chain(http.HandlerFunc(webUI.backups), stdRoot...))
 	http.HandleFunc("/", webUI.login)
 
 	// graceful shutdown
@@ -272,8 +350,19 @@ func Start(c config.AppConfig, log *logger.Logger) {
 		cancel()
 	}()
 
+	// reload the safe settings on SIGHUP
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+
+		for range hup {
+			reload(c, log)
+		}
+	}()
+
 	httpsvr := &http.Server{
-		Addr: ":" + c.Port,
+		Addr:    ":" + c.Port,
+		Handler: metrics.Instrument(http.DefaultServeMux),
 	}
 
 	g, gCtx := errgroup.WithContext(ctx)
@@ -285,6 +374,9 @@ func Start(c config.AppConfig, log *logger.Logger) {
 		if !c.NoFullTextSearch {
 			backend.Search.Close()
 		}
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("error flushing the pending traces")
+		}
 		return httpsvr.Shutdown(context.Background())
 	})
 
@@ -301,63 +393,6 @@ func ping(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, true)
 }
 
-func sudoCache(w http.ResponseWriter, r *http.Request) {
-	conf, _, err := middleware.Extract(r, false)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	if r.Method == http.MethodGet {
-		typ := r.URL.Query().Get("type")
-		key := fmt.Sprintf("%s_%s", conf.Name, r.URL.Query().Get("key"))
-
-		if typ == "queue" {
-			val, err := backend.Cache.DequeueWork(key)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-
-			respond(w, http.StatusOK, val)
-			return
-		}
-		val, err := backend.Cache.Get(key)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		respond(w, http.StatusOK, val)
-	} else if r.Method == http.MethodPost {
-		data := new(struct {
-			Key   string `json:"key"`
-			Value string `json:"value"`
-			Type  string `json:"type"`
-		})
-		if err := parseBody(r.Body, &data); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		data.Key = fmt.Sprintf("%s_%s", conf.Name, data.Key)
-
-		if data.Type == "queue" {
-			if err := backend.Cache.QueueWork(data.Key, data.Value); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			respond(w, http.StatusOK, true)
-			return
-		}
-		if err := backend.Cache.Set(data.Key, data.Value); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		respond(w, http.StatusOK, true)
-	}
-}
-
 func publishMessage(w http.ResponseWriter, r *http.Request) {
 	conf, auth, err := middleware.Extract(r, true)
 	if err != nil {
@@ -400,3 +435,46 @@ func getURLPart(s string, idx int) string {
 	}
 	return parts[idx]
 }
+
+// localFiles serves the local storage files except the private ones
+func localFiles(fs http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if p == storage.LocalPrivateDir || strings.HasPrefix(p, storage.LocalPrivateDir+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		fs.ServeHTTP(w, r)
+	})
+}
+
+// reload loads the configuration again from the environment and the config
+// file, invalid settings are logged and the current ones are kept
+func reload(c config.AppConfig, log *logger.Logger) {
+	next, err := config.Load(c.ConfigFile)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to reload the configuration")
+		return
+	}
+
+	// the port default is set when starting
+	if len(next.Port) == 0 {
+		next.Port = c.Port
+	}
+
+	if err := next.Validate(); err != nil {
+		log.Error().Err(err).Msg("invalid configuration, keeping the current settings")
+		return
+	}
+
+	restart, err := backend.Reload(next)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to reload the configuration")
+		return
+	}
+
+	log.Info().Msg("configuration reloaded")
+	if len(restart) > 0 {
+		log.Warn().Strs("settings", restart).Msg("changed settings require a restart")
+	}
+}
@@ -0,0 +1,101 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+func (m *membership) refresh(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	var data = new(struct {
+		RefreshToken string `json:"refreshToken"`
+	})
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	tokens, err := mship.RefreshSession(data.RefreshToken, r.UserAgent(), internal.ClientIP(r))
+	if errors.Is(err, backend.ErrInvalidRefreshToken) || errors.Is(err, backend.ErrRefreshTokenReused) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, tokens)
+}
+
+func (m *membership) logout(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := backend.Membership(conf).Logout(auth); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, true)
+}
+
+// sessions lists the current user's sessions on GET, DELETE /sessions/{id}
+// revokes a session and DELETE /sessions signs out all of them.
+func (m *membership) sessions(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	id := getURLPart(r.URL.Path, 2)
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := mship.Sessions(auth.UserID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for i := range list {
+			list[i].Current = list[i].JWTID == auth.JWTID
+		}
+
+		respond(w, http.StatusOK, list)
+	case http.MethodDelete:
+		if len(id) == 0 {
+			if err := mship.SignOutEverywhere(auth); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			respond(w, http.StatusOK, true)
+			return
+		}
+
+		if err := mship.RevokeSession(auth.UserID, id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
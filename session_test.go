@@ -0,0 +1,198 @@
+package staticbackend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	sessionEmail    = "session@test.com"
+	sessionPassword = "session_unittest_pw"
+)
+
+func sessionReq(t *testing.T, hf func(http.ResponseWriter, *http.Request), method, path, token string, v interface{}) *http.Response {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("SB-PUBLIC-KEY", pubKey)
+
+	chain := []middleware.Middleware{
+		middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
+	}
+	if len(token) > 0 {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		chain = append(chain, middleware.RequireAuth(backend.DB, backend.Cache))
+	}
+
+	w := httptest.NewRecorder()
+	middleware.Chain(http.HandlerFunc(hf), chain...).ServeHTTP(w, req)
+	return w.Result()
+}
+
+func sessionLogin(t *testing.T, pw string) (token, refreshToken string) {
+	login := model.Login{Email: sessionEmail, Password: pw}
+	resp := sessionReq(t, mship.login, "POST", "/login", "", login)
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if err := parseBody(resp.Body, &token); err != nil {
+		t.Fatal(err)
+	}
+
+	refreshToken = resp.Header.Get(middleware.HeaderRefreshToken)
+	if len(refreshToken) == 0 {
+		t.Fatal("expected a refresh token header")
+	}
+	return
+}
+
+func sessionRefresh(t *testing.T, refreshToken string) (*http.Response, model.AuthTokens) {
+	data := map[string]string{"refreshToken": refreshToken}
+	resp := sessionReq(t, mship.refresh, "POST", "/refresh", "", data)
+
+	var tokens model.AuthTokens
+	if resp.StatusCode <= 299 {
+		if err := parseBody(resp.Body, &tokens); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp, tokens
+}
+
+func expectMeStatus(t *testing.T, token string, status int) {
+	t.Helper()
+
+	resp := sessionReq(t, mship.me, "GET", "/me", token, nil)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		t.Errorf("expected /me status %d got %s", status, GetResponseBody(t, resp))
+	}
+}
+
+func TestSessions(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usr := backend.Membership(conf)
+	if _, _, err := usr.CreateUser(testAccountID, sessionEmail, sessionPassword, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// refresh token rotation and reuse detection
+	token, refreshToken := sessionLogin(t, sessionPassword)
+	expectMeStatus(t, token, http.StatusOK)
+
+	// an unknown secret for a valid session ID is rejected without revoking
+	sessionID, _, _ := strings.Cut(refreshToken, ".")
+	resp, _ := sessionRefresh(t, sessionID+".not-a-rotated-secret")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected an unknown refresh token to be rejected, got %s", GetResponseBody(t, resp))
+	}
+	expectMeStatus(t, token, http.StatusOK)
+
+	resp, tokens := sessionRefresh(t, refreshToken)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	} else if tokens.RefreshToken == refreshToken {
+		t.Error("expected the refresh token to be rotated")
+	}
+	expectMeStatus(t, tokens.Token, http.StatusOK)
+
+	resp, _ = sessionRefresh(t, refreshToken)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected reused refresh token to be rejected, got %s", GetResponseBody(t, resp))
+	}
+
+	resp, _ = sessionRefresh(t, tokens.RefreshToken)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected session to be revoked after reuse, got %s", GetResponseBody(t, resp))
+	}
+	expectMeStatus(t, tokens.Token, http.StatusUnauthorized)
+
+	// listing and revoking sessions
+	tokenA, _ := sessionLogin(t, sessionPassword)
+	tokenB, _ := sessionLogin(t, sessionPassword)
+
+	resp = sessionReq(t, mship.sessions, "GET", "/sessions", tokenA, nil)
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var list []model.Session
+	if err := parseBody(resp.Body, &list); err != nil {
+		t.Fatal(err)
+	} else if len(list) != 2 {
+		t.Fatalf("expected 2 sessions got %d", len(list))
+	}
+
+	otherID := ""
+	for _, s := range list {
+		if !s.Current {
+			otherID = s.ID
+		}
+	}
+	if len(otherID) == 0 {
+		t.Fatalf("expected one session to be flagged as current %v", list)
+	}
+
+	resp = sessionReq(t, mship.sessions, "DELETE", "/sessions/"+otherID, tokenA, nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	expectMeStatus(t, tokenB, http.StatusUnauthorized)
+	expectMeStatus(t, tokenA, http.StatusOK)
+
+	resp = sessionReq(t, mship.logout, "POST", "/logout", tokenA, nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	expectMeStatus(t, tokenA, http.StatusUnauthorized)
+
+	// a password reset signs out all sessions
+	tokenC, refreshC := sessionLogin(t, sessionPassword)
+
+	if err := usr.SetPasswordResetCode(sessionEmail, "reset-code"); err != nil {
+		t.Fatal(err)
+	}
+
+	reset := map[string]string{
+		"email":    sessionEmail,
+		"code":     "reset-code",
+		"password": "new_session_pw",
+	}
+	resp = sessionReq(t, mship.resetPassword, "POST", "/password/reset", "", reset)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	expectMeStatus(t, tokenC, http.StatusUnauthorized)
+
+	resp, _ = sessionRefresh(t, refreshC)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected refresh token to be revoked after password reset, got %s", GetResponseBody(t, resp))
+	}
+
+	token, _ = sessionLogin(t, "new_session_pw")
+	expectMeStatus(t, token, http.StatusOK)
+}
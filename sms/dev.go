@@ -0,0 +1,27 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/staticbackendhq/core/internal"
+)
+
+// Dev prints the text messages to the console
+type Dev struct{}
+
+func (Dev) Send(data SMSData) (string, error) {
+	fmt.Println("====== SENDING SMS ======")
+	fmt.Println("from: ", data.FromNumber)
+	fmt.Println("to: ", data.ToNumber)
+	fmt.Printf("body\n%s\n\n", data.Body)
+	fmt.Println("====== /SENDING SMS ======")
+	return "dev_" + internal.RandStringRunes(24), nil
+}
+
+// ParseStatus expects a JSON encoded DeliveryStatus
+func (Dev) ParseStatus(r *http.Request) (ds DeliveryStatus, err error) {
+	err = json.NewDecoder(r.Body).Decode(&ds)
+	return
+}
@@ -1,58 +1,130 @@
-package sms
-
-import (
-	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"strings"
-)
-
-type SMSData struct {
-	AccountSID string `json:"accountSID"`
-	AuthToken  string `json:"authToken"`
-	ToNumber   string `json:"toNumber"`
-	FromNumber string `json:"fromNumber"`
-	Body       string `json:"body"`
-}
-
-// Send sends a text-message using Twilio
-func Send(data SMSData) error {
-	apiURL := "https://api.twilio.com/2010-04-01/Accounts/" + data.AccountSID + "/Messages.json"
-
-	// Build out the data for the message
-	v := url.Values{}
-	v.Set("To", data.ToNumber)
-	v.Set("From", data.FromNumber)
-	v.Set("Body", data.Body)
-	rb := strings.NewReader(v.Encode())
-
-	client := &http.Client{}
-
-	req, err := http.NewRequest("POST", apiURL, rb)
-	if err != nil {
-		return err
-	}
-
-	req.SetBasicAuth(data.AccountSID, data.AuthToken)
-
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-
-	// Make request
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode > 299 {
-		b, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-
-		return fmt.Errorf("error returned by Twilio: %s", string(b))
-	}
-	return nil
-}
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	SMSProviderDev    = "dev"
+	SMSProviderTwilio = "twilio"
+	SMSProviderVonage = "vonage"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// SMSData contains necessary fields to send a text message
+type SMSData struct {
+	ToNumber   string `json:"toNumber"`
+	FromNumber string `json:"fromNumber"`
+	Body       string `json:"body"`
+}
+
+// DeliveryStatus is a message status update received from the provider's
+// webhook
+type DeliveryStatus struct {
+	MessageID string `json:"messageId"`
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+}
+
+// Sender is used to have different implementation for sending text messages
+type Sender interface {
+	// Send sends the text message and returns the provider's message ID
+	Send(SMSData) (string, error)
+	// ParseStatus reads the provider's delivery status webhook request
+	ParseStatus(*http.Request) (DeliveryStatus, error)
+}
+
+// New returns the Sender for the configured provider. Providers report
+// message delivery status to callbackURL when it's not empty.
+func New(cfg model.SMSConfig, callbackURL string) (Sender, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case SMSProviderDev:
+		return Dev{}, nil
+	case SMSProviderTwilio:
+		return Twilio{
+			AccountSID:     cfg.AccountID,
+			AuthToken:      cfg.AuthToken,
+			StatusCallback: callbackURL,
+		}, nil
+	case SMSProviderVonage:
+		return Vonage{
+			APIKey:         cfg.AccountID,
+			APISecret:      cfg.AuthToken,
+			StatusCallback: callbackURL,
+		}, nil
+	case "":
+		return nil, errors.New("no SMS provider configured for this database")
+	}
+	return nil, fmt.Errorf("unsupported SMS provider: %s", cfg.Provider)
+}
+
+// CallbackURL returns the delivery status webhook URL of a database
+func CallbackURL(baseID, token string) string {
+	if len(config.Current.AppURL) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/sms/status/%s/%s",
+		strings.TrimSuffix(config.Current.AppURL, "/"),
+		baseID,
+		token,
+	)
+}
+
+// Send sends a text message using the database's SMS provider and records
+// it with its status. The message is recorded as failed if the provider
+// returns an error.
+func Send(db database.Persister, base model.DatabaseConfig, accountID string, data SMSData) (msg model.SMSMessage, err error) {
+	cfg, err := base.GetSMSConfig()
+	if err != nil {
+		return
+	}
+
+	sender, err := New(cfg, CallbackURL(base.ID, cfg.WebhookToken))
+	if err != nil {
+		return
+	}
+
+	if len(data.FromNumber) == 0 {
+		data.FromNumber = cfg.FromNumber
+	}
+
+	msg = model.SMSMessage{
+		AccountID:  accountID,
+		Provider:   strings.ToLower(cfg.Provider),
+		ToNumber:   data.ToNumber,
+		FromNumber: data.FromNumber,
+		Body:       data.Body,
+		Status:     StatusQueued,
+		Created:    time.Now(),
+		Updated:    time.Now(),
+	}
+
+	msgID, sendErr := sender.Send(data)
+	if sendErr != nil {
+		msg.Status = StatusFailed
+		msg.Error = sendErr.Error()
+	}
+	msg.MessageID = msgID
+
+	msg.ID, err = db.AddSMSMessage(base.Name, msg)
+	if err != nil {
+		return
+	}
+
+	err = sendErr
+	return
+}
@@ -0,0 +1,137 @@
+package sms
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestNewSender(t *testing.T) {
+	if _, err := New(model.SMSConfig{}, ""); err == nil {
+		t.Errorf("expected an error when no provider is configured")
+	}
+
+	if _, err := New(model.SMSConfig{Provider: "unknown"}, ""); err == nil {
+		t.Errorf("expected an error for an unsupported provider")
+	}
+
+	s, err := New(model.SMSConfig{Provider: "Twilio", AccountID: "sid"}, "https://cb")
+	if err != nil {
+		t.Fatal(err)
+	} else if tw, ok := s.(Twilio); !ok || tw.StatusCallback != "https://cb" {
+		t.Errorf("expected a Twilio sender with a callback got %v", s)
+	}
+}
+
+func TestTwilioSend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "sid" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		} else if r.FormValue("StatusCallback") != "https://cb" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprint(w, `{"sid": "SM123", "status": "queued"}`)
+	}))
+	defer ts.Close()
+
+	twilioAPIURL = ts.URL
+
+	tw := Twilio{AccountSID: "sid", AuthToken: "token", StatusCallback: "https://cb"}
+	id, err := tw.Send(SMSData{ToNumber: "+1", FromNumber: "+2", Body: "test"})
+	if err != nil {
+		t.Fatal(err)
+	} else if id != "SM123" {
+		t.Errorf("expected message id to be SM123 got %s", id)
+	}
+}
+
+func TestTwilioParseStatus(t *testing.T) {
+	tw := Twilio{AuthToken: "token", StatusCallback: "https://cb/sms/status/id/tok"}
+
+	v := url.Values{}
+	v.Set("MessageSid", "SM123")
+	v.Set("MessageStatus", "delivered")
+
+	mac := hmac.New(sha1.New, []byte("token"))
+	mac.Write([]byte(tw.StatusCallback + "MessageSidSM123MessageStatusdelivered"))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest("POST", "/sms/status/id/tok", strings.NewReader(v.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", signature)
+
+	ds, err := tw.ParseStatus(req)
+	if err != nil {
+		t.Fatal(err)
+	} else if ds.MessageID != "SM123" || ds.Status != "delivered" {
+		t.Errorf("unexpected delivery status %v", ds)
+	}
+
+	req = httptest.NewRequest("POST", "/sms/status/id/tok", strings.NewReader(v.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", "invalid")
+
+	if _, err := tw.ParseStatus(req); err == nil {
+		t.Errorf("expected an error for an invalid signature")
+	}
+}
+
+func TestVonageSend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("api_secret") != "secret" {
+			fmt.Fprint(w, `{"messages": [{"status": "4", "error-text": "Bad Credentials"}]}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"messages": [{"status": "0", "message-id": "V123"}]}`)
+	}))
+	defer ts.Close()
+
+	vonageAPIURL = ts.URL
+
+	vo := Vonage{APIKey: "key", APISecret: "secret"}
+	id, err := vo.Send(SMSData{ToNumber: "1", FromNumber: "2", Body: "test"})
+	if err != nil {
+		t.Fatal(err)
+	} else if id != "V123" {
+		t.Errorf("expected message id to be V123 got %s", id)
+	}
+
+	vo.APISecret = "wrong"
+	if _, err := vo.Send(SMSData{ToNumber: "1", FromNumber: "2", Body: "test"}); err == nil {
+		t.Errorf("expected an error for bad credentials")
+	}
+}
+
+func TestVonageParseStatus(t *testing.T) {
+	req := httptest.NewRequest("GET", "/sms/status/id/tok?messageId=V123&status=failed&err-code=5", nil)
+
+	ds, err := Vonage{}.ParseStatus(req)
+	if err != nil {
+		t.Fatal(err)
+	} else if ds.MessageID != "V123" || ds.Status != "failed" || ds.Error != "5" {
+		t.Errorf("unexpected delivery status %v", ds)
+	}
+
+	body := `{"messageId": "V124", "status": "delivered", "err-code": "0"}`
+	req = httptest.NewRequest("POST", "/sms/status/id/tok", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	ds, err = Vonage{}.ParseStatus(req)
+	if err != nil {
+		t.Fatal(err)
+	} else if ds.MessageID != "V124" || ds.Status != "delivered" || len(ds.Error) > 0 {
+		t.Errorf("unexpected delivery status %v", ds)
+	}
+}
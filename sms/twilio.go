@@ -0,0 +1,115 @@
+package sms
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var twilioAPIURL = "https://api.twilio.com/2010-04-01"
+
+// Twilio sends text messages via the Twilio REST API
+type Twilio struct {
+	AccountSID     string
+	AuthToken      string
+	StatusCallback string
+}
+
+func (tw Twilio) Send(data SMSData) (string, error) {
+	apiURL := twilioAPIURL + "/Accounts/" + tw.AccountSID + "/Messages.json"
+
+	// Build out the data for the message
+	v := url.Values{}
+	v.Set("To", data.ToNumber)
+	v.Set("From", data.FromNumber)
+	v.Set("Body", data.Body)
+	if len(tw.StatusCallback) > 0 {
+		v.Set("StatusCallback", tw.StatusCallback)
+	}
+	rb := strings.NewReader(v.Encode())
+
+	client := &http.Client{}
+
+	req, err := http.NewRequest("POST", apiURL, rb)
+	if err != nil {
+		return "", err
+	}
+
+	req.SetBasicAuth(tw.AccountSID, tw.AuthToken)
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	// Make request
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+
+		return "", fmt.Errorf("error returned by Twilio: %s", string(b))
+	}
+
+	var result struct {
+		SID string `json:"sid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.SID, nil
+}
+
+// ParseStatus validates the request signature and reads the status
+// callback parameters
+func (tw Twilio) ParseStatus(r *http.Request) (ds DeliveryStatus, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+
+	if !tw.validSignature(r.Header.Get("X-Twilio-Signature"), r.PostForm) {
+		err = errors.New("invalid Twilio signature")
+		return
+	}
+
+	ds.MessageID = r.PostForm.Get("MessageSid")
+	ds.Status = r.PostForm.Get("MessageStatus")
+	ds.Error = r.PostForm.Get("ErrorCode")
+	return
+}
+
+// validSignature computes the request signature as documented here:
+// https://www.twilio.com/docs/usage/security#validating-requests
+func (tw Twilio) validSignature(signature string, params url.Values) bool {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	s := tw.StatusCallback
+	for _, k := range keys {
+		for _, v := range params[k] {
+			s += k + v
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(tw.AuthToken))
+	mac.Write([]byte(s))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
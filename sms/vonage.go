@@ -0,0 +1,94 @@
+package sms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var vonageAPIURL = "https://rest.nexmo.com"
+
+// Vonage sends text messages via the Vonage (Nexmo) SMS API
+type Vonage struct {
+	APIKey         string
+	APISecret      string
+	StatusCallback string
+}
+
+func (vo Vonage) Send(data SMSData) (string, error) {
+	v := url.Values{}
+	v.Set("api_key", vo.APIKey)
+	v.Set("api_secret", vo.APISecret)
+	v.Set("to", data.ToNumber)
+	v.Set("from", data.FromNumber)
+	v.Set("text", data.Body)
+	if len(vo.StatusCallback) > 0 {
+		v.Set("callback", vo.StatusCallback)
+		v.Set("status-report-req", "1")
+	}
+
+	resp, err := http.PostForm(vonageAPIURL+"/sms/json", v)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Messages []struct {
+			MessageID string `json:"message-id"`
+			Status    string `json:"status"`
+			ErrorText string `json:"error-text"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("error returned by Vonage: %s", resp.Status)
+	}
+
+	if len(result.Messages) == 0 {
+		return "", errors.New("no message returned by Vonage")
+	}
+
+	// a message status of "0" indicates success
+	msg := result.Messages[0]
+	if msg.Status != "0" {
+		return "", fmt.Errorf("error returned by Vonage: %s", msg.ErrorText)
+	}
+	return msg.MessageID, nil
+}
+
+// ParseStatus reads the delivery receipt which could be sent as a GET, a POST
+// form or a POST JSON request depending on the Vonage account settings
+func (vo Vonage) ParseStatus(r *http.Request) (ds DeliveryStatus, err error) {
+	params := make(map[string]string)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]any
+		if err = json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return
+		}
+
+		for k, v := range body {
+			params[k] = fmt.Sprint(v)
+		}
+	} else {
+		if err = r.ParseForm(); err != nil {
+			return
+		}
+
+		for k := range r.Form {
+			params[k] = r.Form.Get(k)
+		}
+	}
+
+	ds.MessageID = params["messageId"]
+	ds.Status = params["status"]
+
+	// an err-code of "0" indicates a delivered message
+	if code := params["err-code"]; len(code) > 0 && code != "0" {
+		ds.Error = code
+	}
+	return
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+// Dir stores files in a local directory, the returned URL is the file path.
+// It's used for backups kept outside the configured storage provider.
+type Dir struct {
+	Root string
+}
+
+func (d Dir) Save(data model.UploadFileData) (string, error) {
+	filename := d.path(data.FileKey)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return "", err
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, data.File); err != nil {
+		return "", err
+	}
+	return filename, f.Close()
+}
+
+func (d Dir) Get(fileKey string) (io.ReadCloser, error) {
+	return os.Open(d.path(fileKey))
+}
+
+func (d Dir) Delete(fileKey string) error {
+	return os.Remove(d.path(fileKey))
+}
+
+// path prevents keys from escaping the root directory
+func (d Dir) path(fileKey string) string {
+	return filepath.Join(d.Root, filepath.Clean("/"+fileKey))
+}
@@ -10,11 +10,21 @@ import (
 	"github.com/staticbackendhq/core/model"
 )
 
+// LocalPrivateDir is the directory of the private files in the temp
+// directory, it must not be served
+const LocalPrivateDir = "sb-private"
+
 type Local struct{}
 
 func (Local) Save(data model.UploadFileData) (string, error) {
-	dir := path.Join(os.TempDir(), path.Dir(data.FileKey))
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	filename := path.Join(os.TempDir(), data.FileKey)
+	perm := os.FileMode(0644)
+	if data.Private {
+		filename = privateFilename(data.FileKey)
+		perm = 0600
+	}
+
+	if err := os.MkdirAll(path.Dir(filename), 0755); err != nil {
 		return "", err
 	}
 
@@ -23,8 +33,7 @@ func (Local) Save(data model.UploadFileData) (string, error) {
 		return "", err
 	}
 
-	filename := path.Join(os.TempDir(), data.FileKey)
-	if err := os.WriteFile(filename, b, 0644); err != nil {
+	if err := os.WriteFile(filename, b, perm); err != nil {
 		return "", err
 	}
 
@@ -32,7 +41,25 @@ func (Local) Save(data model.UploadFileData) (string, error) {
 	return url, nil
 }
 
+func (Local) Get(fileKey string) (io.ReadCloser, error) {
+	if f, err := os.Open(privateFilename(fileKey)); err == nil {
+		return f, nil
+	}
+
+	filename := path.Join(os.TempDir(), fileKey)
+	return os.Open(filename)
+}
+
 func (Local) Delete(fileKey string) error {
+	if err := os.Remove(privateFilename(fileKey)); err == nil {
+		return nil
+	}
+
 	filename := path.Join(os.TempDir(), fileKey)
 	return os.Remove(filename)
 }
+
+// privateFilename prevents private keys from escaping the private directory
+func privateFilename(fileKey string) string {
+	return path.Join(os.TempDir(), LocalPrivateDir, path.Clean("/"+fileKey))
+}
@@ -3,6 +3,9 @@ package storage
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"path"
 	"strings"
 	"testing"
 
@@ -24,3 +27,84 @@ func TestLocalSave(t *testing.T) {
 
 	fmt.Println(url)
 }
+
+func TestLocalGet(t *testing.T) {
+	local := Local{}
+
+	data := model.UploadFileData{FileKey: "unit/test/get.txt", File: bytes.NewReader([]byte("unit test"))}
+	if _, err := local.Save(data); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := local.Get(data.FileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != "unit test" {
+		t.Errorf("expected unit test got %s", b)
+	}
+}
+
+func TestLocalPrivate(t *testing.T) {
+	local := Local{}
+
+	data := model.UploadFileData{FileKey: "unit/test/private.txt", File: bytes.NewReader([]byte("secret")), Private: true}
+	if _, err := local.Save(data); err != nil {
+		t.Fatal(err)
+	}
+	defer local.Delete(data.FileKey)
+
+	// the served directory does not have the file
+	if _, err := os.Stat(path.Join(os.TempDir(), data.FileKey)); err == nil {
+		t.Errorf("expected the private file to be outside the served files")
+	}
+
+	rc, err := local.Get(data.FileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != "secret" {
+		t.Errorf("expected secret got %s", b)
+	}
+}
+
+func TestDirSaveGetDelete(t *testing.T) {
+	dir := Dir{Root: t.TempDir()}
+
+	data := model.UploadFileData{FileKey: "../backups/file.txt", File: bytes.NewReader([]byte("unit test"))}
+	filename, err := dir.Save(data)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(filename, dir.Root) {
+		t.Fatalf("expected the file to stay in %s got %s", dir.Root, filename)
+	}
+
+	rc, err := dir.Get(data.FileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != "unit test" {
+		t.Errorf("expected unit test got %s", b)
+	}
+
+	if err := dir.Delete(data.FileKey); err != nil {
+		t.Fatal(err)
+	} else if _, err := dir.Get(data.FileKey); err == nil {
+		t.Error("expected the file to be deleted")
+	}
+}
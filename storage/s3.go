@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
 
 	"github.com/staticbackendhq/core/config"
 	"github.com/staticbackendhq/core/model"
@@ -34,13 +36,7 @@ func (S3) Save(data model.UploadFileData) (string, error) {
 		contentType = "application/octet-stream"
 	}
 
-	opts := minio.PutObjectOptions{
-		ContentType: contentType,
-		UserMetadata: map[string]string{
-			"x-amz-acl": "public-read",
-		},
-	}
-	_, err = c.PutObject(ctx, bucketName, data.FileKey, data.File, data.Size, opts)
+	_, err = c.PutObject(ctx, bucketName, data.FileKey, data.File, data.Size, putOptions(data, contentType))
 	if err != nil {
 		return "", err
 	}
@@ -54,6 +50,37 @@ func (S3) Save(data model.UploadFileData) (string, error) {
 	return url, nil
 }
 
+func (S3) Get(fileKey string) (io.ReadCloser, error) {
+	ctx := context.Background()
+	endpoint := config.Current.S3Endpoint
+	accessKeyID := config.Current.S3AccessKey
+	secretAccessKey := config.Current.S3SecretKey
+
+	// Initialize minio client object.
+	c, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := c.GetObject(ctx, config.Current.S3Bucket, fileKey, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the object is only requested on the first read or stat
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("%s: %w", fileKey, fs.ErrNotExist)
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
 func (S3) Delete(fileKey string) error {
 	ctx := context.Background()
 	endpoint := config.Current.S3Endpoint
@@ -71,3 +98,19 @@ func (S3) Delete(fileKey string) error {
 
 	return c.RemoveObject(ctx, config.Current.S3Bucket, fileKey, minio.RemoveObjectOptions{})
 }
+
+// putOptions returns the upload options, private files are not readable
+// via their URL
+func putOptions(data model.UploadFileData, contentType string) minio.PutObjectOptions {
+	acl := "public-read"
+	if data.Private {
+		acl = "private"
+	}
+
+	return minio.PutObjectOptions{
+		ContentType: contentType,
+		UserMetadata: map[string]string{
+			"x-amz-acl": acl,
+		},
+	}
+}
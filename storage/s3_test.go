@@ -66,3 +66,15 @@ func TestS3Storage(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestS3PrivateACL(t *testing.T) {
+	opts := putOptions(model.UploadFileData{FileKey: "backup.tar.gz", Private: true}, "application/gzip")
+	if acl := opts.UserMetadata["x-amz-acl"]; acl != "private" {
+		t.Errorf("expected a private ACL got %s", acl)
+	}
+
+	opts = putOptions(model.UploadFileData{FileKey: "avatar.png"}, "image/png")
+	if acl := opts.UserMetadata["x-amz-acl"]; acl != "public-read" {
+		t.Errorf("expected a public-read ACL got %s", acl)
+	}
+}
@@ -1,16 +1,23 @@
 package storage
 
-import "github.com/staticbackendhq/core/model"
+import (
+	"io"
+
+	"github.com/staticbackendhq/core/model"
+)
 
 const (
 	StorageProviderLocal = "local"
 	StorageProviderS3    = "s3"
 )
 
-// Storer handles file saving/deleting
+// Storer handles file saving/reading/deleting
 type Storer interface {
 	// Save saves a file via a storage provider
 	Save(model.UploadFileData) (string, error)
+	// Get returns the content of a file via a storage provider, the error
+	// wraps fs.ErrNotExist when the file does not exist
+	Get(string) (io.ReadCloser, error)
 	// Delete removes a file via a storage provider
 	Delete(string) error
 }
@@ -1,6 +1,9 @@
 package staticbackend
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -9,12 +12,14 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/staticbackendhq/core/backend"
 	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
 )
 
 func TestFileUpload(t *testing.T) {
@@ -64,6 +69,11 @@ func TestFileUpload(t *testing.T) {
 
 	t.Log(data)
 
+	sum := sha256.Sum256([]byte("testing file upload"))
+	if expected := hex.EncodeToString(sum[:]); data.Checksum != expected {
+		t.Errorf("expected checksum to be %s got %s", expected, data.Checksum)
+	}
+
 	// let's remove the web-based prefix to test if file was saved
 	localFilePath := strings.Replace(data.URL, "http://localhost:8099/localfs", "", -1)
 
@@ -86,6 +96,103 @@ func TestFileUpload(t *testing.T) {
 	}
 }
 
+func TestFileDedup(t *testing.T) {
+	backend.Config.DedupFiles = true
+	defer func() {
+		backend.Config.DedupFiles = false
+	}()
+
+	auth := model.Auth{AccountID: testAccountID}
+	conf := model.DatabaseConfig{Name: dbName}
+
+	fileSvc := backend.Storage(auth, conf)
+
+	content := []byte("same content uploaded twice")
+
+	f1, err := fileSvc.Save("avatar.png", "", bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f2, err := fileSvc.Save("avatar.png", "", bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f1.ID == f2.ID {
+		t.Errorf("expected distinct file ids got %s", f1.ID)
+	} else if f1.URL != f2.URL {
+		t.Errorf("expected same url got %s and %s", f1.URL, f2.URL)
+	} else if f1.Checksum != f2.Checksum {
+		t.Errorf("expected same checksum got %s and %s", f1.Checksum, f2.Checksum)
+	}
+
+	localFilePath := path.Join(
+		os.TempDir(),
+		strings.Replace(f1.URL, "http://localhost:8099/localfs", "", -1),
+	)
+
+	if err := fileSvc.Delete(f1.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// the content is still referenced by the 2nd file
+	if _, err := os.Stat(localFilePath); os.IsNotExist(err) {
+		t.Errorf("Expected file %s to exists", localFilePath)
+	}
+
+	if err := fileSvc.Delete(f2.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(localFilePath); !os.IsNotExist(err) {
+		t.Errorf("Expected file %s to not exists", localFilePath)
+	}
+
+	sum := sha256.Sum256(content)
+	if expected := hex.EncodeToString(sum[:]); f1.Checksum != expected {
+		t.Errorf("expected checksum %s got %s", expected, f1.Checksum)
+	}
+
+	// a file saved while the last reference is deleted keeps its content
+	for i := 0; i < 10; i++ {
+		prev, err := fileSvc.Save("avatar.png", "", bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		var next backend.SavedFile
+		var saveErr, deleteErr error
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			next, saveErr = fileSvc.Save("avatar.png", "", bytes.NewReader(content), int64(len(content)))
+		}()
+		go func() {
+			defer wg.Done()
+			deleteErr = fileSvc.Delete(prev.ID)
+		}()
+		wg.Wait()
+
+		if saveErr != nil {
+			t.Fatal(saveErr)
+		} else if deleteErr != nil {
+			t.Fatal(deleteErr)
+		}
+
+		p := path.Join(os.TempDir(), strings.Replace(next.URL, "http://localhost:8099/localfs", "", -1))
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("expected the content of the saved file to exist: %v", err)
+		}
+
+		if err := fileSvc.Delete(next.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestCleanUpFileName(t *testing.T) {
 	fakeNames := make(map[string]string)
 	fakeNames[""] = ""
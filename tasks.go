@@ -0,0 +1,98 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/function"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// sudoTasks lists the database's tasks with GET /sudo/tasks, returns a task
+// with GET /sudo/tasks/{id} and its runs with GET /sudo/tasks/{id}/runs.
+// A task is executed immediately with POST /sudo/tasks/{id}/run and paused
+// or resumed with POST /sudo/tasks/{id}/pause|resume. A task is deleted with
+// DELETE /sudo/tasks/{id}.
+func sudoTasks(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := getURLPart(r.URL.Path, 3)
+	action := getURLPart(r.URL.Path, 4)
+
+	if r.Method == http.MethodGet && len(id) == 0 {
+		tasks, err := backend.DB.ListTasksByBase(conf.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, tasks)
+		return
+	}
+
+	task, err := backend.DB.GetTask(conf.Name, id)
+	if err != nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(action) == 0:
+		respond(w, http.StatusOK, task)
+	case r.Method == http.MethodGet && action == "runs":
+		runs, err := backend.DB.ListTaskRuns(conf.Name, task.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, runs)
+	case r.Method == http.MethodPost && action == "run":
+		run, err := backend.Scheduler.RunNow(task)
+		if errors.Is(err, function.ErrTaskRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, run)
+	case r.Method == http.MethodPost && (action == "pause" || action == "resume"):
+		if err := backend.DB.SetTaskPaused(conf.Name, task.ID, action == "pause"); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	case r.Method == http.MethodDelete && len(action) == 0:
+		if err := deleteTask(conf.Name, task); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+// deleteTask deletes the task and cancels it on the instance running the
+// scheduler
+func deleteTask(dbName string, task model.Task) error {
+	if err := backend.DB.DeleteTask(dbName, task.ID); err != nil {
+		return err
+	}
+
+	if err := backend.Scheduler.RemoveOnTheFly(task); err != nil {
+		// the leader cancels the task on its next reload
+		backend.Log.Warn().Err(err).Msgf("unable to notify the scheduler of task %s", task.ID)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package staticbackend
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/function"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestTaskRunPauseAndHistory(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := model.Task{
+		Name:     "manual-run",
+		Type:     model.TaskTypeMessage,
+		Value:    "task-test",
+		Interval: "0 3 * * *",
+		Timezone: "America/Toronto",
+	}
+	id, err := backend.DB.AddTask(conf.Name, task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.DB.DeleteTask(conf.Name, id)
+
+	resp := dbReq(t, sudoTasks, "POST", fmt.Sprintf("/sudo/tasks/%s/run", id), nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var run model.TaskRun
+	if err := parseBody(resp.Body, &run); err != nil {
+		t.Fatal(err)
+	} else if run.Status != model.TaskRunSuccess || run.Trigger != model.TaskTriggerManual {
+		t.Errorf("expected a successful manual run got %v", run)
+	}
+
+	// a run is refused while another instance runs the task
+	key := function.TaskLockKey(id)
+	if ok, err := backend.Cache.AcquireLock(key, "other-instance", time.Minute); err != nil || !ok {
+		t.Fatalf("unable to lock the task: %v", err)
+	}
+
+	resp = dbReq(t, sudoTasks, "POST", fmt.Sprintf("/sudo/tasks/%s/run", id), nil, true)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409 got %d", resp.StatusCode)
+	}
+
+	if err := backend.Cache.ReleaseLock(key, "other-instance"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = dbReq(t, sudoTasks, "POST", fmt.Sprintf("/sudo/tasks/%s/pause", id), nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, sudoTasks, "GET", "/sudo/tasks/"+id, nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var check model.Task
+	if err := parseBody(resp.Body, &check); err != nil {
+		t.Fatal(err)
+	} else if !check.Paused {
+		t.Error("expected the task to be paused")
+	} else if check.LastRun.IsZero() {
+		t.Error("expected the manual run to update the last run")
+	}
+
+	resp = dbReq(t, sudoTasks, "GET", fmt.Sprintf("/sudo/tasks/%s/runs", id), nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var runs []model.TaskRun
+	if err := parseBody(resp.Body, &runs); err != nil {
+		t.Fatal(err)
+	} else if len(runs) != 1 {
+		t.Fatalf("expected 1 run got %d", len(runs))
+	} else if runs[0].Output != "message task-test published" {
+		t.Errorf("unexpected run output %q", runs[0].Output)
+	}
+}
+
+func TestTaskScheduleAndMissedRuns(t *testing.T) {
+	invalid := []model.Task{
+		{Interval: "not a cron"},
+		{Interval: "0 3 * * *", Timezone: "Mars/Olympus"},
+		{Interval: "CRON_TZ=UTC 0 3 * * *"},
+		{Interval: "0 3 * * *", CatchUp: "sometimes"},
+	}
+	for _, task := range invalid {
+		if err := function.ValidateTask(task); err == nil {
+			t.Errorf("expected task %v to be invalid", task)
+		}
+	}
+
+	loc, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skip("timezone database unavailable")
+	}
+
+	// daily at 3am Toronto time, last ran two days ago
+	task := model.Task{
+		Interval: "0 3 * * *",
+		Timezone: "America/Toronto",
+		LastRun:  time.Date(2024, 3, 1, 3, 0, 0, 0, loc),
+	}
+	if err := function.ValidateTask(task); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 3, 7, 30, 0, 0, time.UTC) // 2:30am in Toronto
+	if n := function.MissedRuns(task, now); n != 1 {
+		t.Errorf("expected 1 missed run got %d", n)
+	}
+
+	now = now.Add(time.Hour)
+	if n := function.MissedRuns(task, now); n != 2 {
+		t.Errorf("expected 2 missed runs got %d", n)
+	}
+
+	task.LastRun = now.AddDate(0, -1, 0)
+	if n := function.MissedRuns(task, now); n != model.MaxTaskCatchUp {
+		t.Errorf("expected missed runs capped to %d got %d", model.MaxTaskCatchUp, n)
+	}
+
+	task.LastRun = time.Time{}
+	if n := function.MissedRuns(task, now); n != 0 {
+		t.Errorf("expected no missed run for a task that never ran got %d", n)
+	}
+}
+
+func TestTaskEventsReachTheScheduler(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the scheduler starts asynchronously once the instance is elected
+	waitFor := func(cond func() bool) bool {
+		for i := 0; i < 100; i++ {
+			if cond() {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	if !waitFor(backend.Elector.IsLeader) {
+		t.Fatal("expected the single instance to be the leader")
+	}
+
+	task := model.Task{
+		Name:     "event-task",
+		Type:     model.TaskTypeMessage,
+		Value:    "task-test",
+		Interval: "0 3 * * *",
+		BaseName: conf.Name,
+	}
+	task.ID, err = backend.DB.AddTask(conf.Name, task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.Scheduler.AddOnTheFly(task); err != nil {
+		t.Fatal(err)
+	}
+
+	if !waitFor(func() bool { return backend.Scheduler.IsScheduled(task.ID) }) {
+		t.Fatal("expected the new task to be scheduled by the leader")
+	}
+
+	resp := dbReq(t, sudoTasks, "DELETE", "/sudo/tasks/"+task.ID, nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if !waitFor(func() bool { return !backend.Scheduler.IsScheduled(task.ID) }) {
+		t.Error("expected the deleted task to be canceled by the leader")
+	}
+
+	// a task added without an event is scheduled on the next reload
+	task.ID, err = backend.DB.AddTask(conf.Name, task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.Scheduler.Reload(); err != nil {
+		t.Fatal(err)
+	} else if !backend.Scheduler.IsScheduled(task.ID) {
+		t.Error("expected the reload to schedule the new task")
+	}
+
+	if err := backend.DB.DeleteTask(conf.Name, task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.Scheduler.Reload(); err != nil {
+		t.Fatal(err)
+	} else if backend.Scheduler.IsScheduled(task.ID) {
+		t.Error("expected the reload to cancel the deleted task")
+	}
+}
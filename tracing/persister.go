@@ -0,0 +1,765 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Persister creates a span for each call to a database.Persister when its
+// bound context has a span
+type Persister struct {
+	db     database.Persister
+	engine string
+	ctx    context.Context
+}
+
+// NewPersister wraps db, engine is used as the db.system attribute of its
+// spans
+func NewPersister(db database.Persister, engine string) *Persister {
+	return &Persister{db: db, engine: engine, ctx: context.Background()}
+}
+
+// WithContext returns a copy of p creating its spans as children of ctx
+func (p *Persister) WithContext(ctx context.Context) database.Persister {
+	return &Persister{db: p.db, engine: p.engine, ctx: ctx}
+}
+
+// BindPersister returns db bound to ctx when it is traced
+func BindPersister(ctx context.Context, db database.Persister) database.Persister {
+	if p, ok := db.(*Persister); ok {
+		return p.WithContext(ctx)
+	}
+	return db
+}
+
+func (p *Persister) start(method string) trace.Span {
+	return startChild(p.ctx, "db."+method, attribute.String("db.system", p.engine))
+}
+
+func end(span trace.Span, err *error) {
+	End(span, *err)
+}
+
+func (p *Persister) Ping() (err error) {
+	defer end(p.start("Ping"), &err)
+	return p.db.Ping()
+}
+
+func (p *Persister) CreateIndex(dbName string, col string, field string) (err error) {
+	defer end(p.start("CreateIndex"), &err)
+	return p.db.CreateIndex(dbName, col, field)
+}
+
+func (p *Persister) CreateTenant(a0 model.Tenant) (r0 model.Tenant, err error) {
+	defer end(p.start("CreateTenant"), &err)
+	return p.db.CreateTenant(a0)
+}
+
+func (p *Persister) CreateDatabase(a0 model.DatabaseConfig) (r0 model.DatabaseConfig, err error) {
+	defer end(p.start("CreateDatabase"), &err)
+	return p.db.CreateDatabase(a0)
+}
+
+func (p *Persister) EmailExists(email string) (r0 bool, err error) {
+	defer end(p.start("EmailExists"), &err)
+	return p.db.EmailExists(email)
+}
+
+func (p *Persister) FindTenant(tenantID string) (r0 model.Tenant, err error) {
+	defer end(p.start("FindTenant"), &err)
+	return p.db.FindTenant(tenantID)
+}
+
+func (p *Persister) FindDatabase(baseID string) (r0 model.DatabaseConfig, err error) {
+	defer end(p.start("FindDatabase"), &err)
+	return p.db.FindDatabase(baseID)
+}
+
+func (p *Persister) DatabaseExists(name string) (r0 bool, err error) {
+	defer end(p.start("DatabaseExists"), &err)
+	return p.db.DatabaseExists(name)
+}
+
+func (p *Persister) ListDatabases() (r0 []model.DatabaseConfig, err error) {
+	defer end(p.start("ListDatabases"), &err)
+	return p.db.ListDatabases()
+}
+
+func (p *Persister) IncrementMonthlyEmailSent(baseID string) (err error) {
+	defer end(p.start("IncrementMonthlyEmailSent"), &err)
+	return p.db.IncrementMonthlyEmailSent(baseID)
+}
+
+func (p *Persister) GetTenantByEmail(email string) (r0 model.Tenant, err error) {
+	defer end(p.start("GetTenantByEmail"), &err)
+	return p.db.GetTenantByEmail(email)
+}
+
+func (p *Persister) GetTenantByStripeID(stripeID string) (r0 model.Tenant, err error) {
+	defer end(p.start("GetTenantByStripeID"), &err)
+	return p.db.GetTenantByStripeID(stripeID)
+}
+
+func (p *Persister) ActivateTenant(tenantID string, active bool) (err error) {
+	defer end(p.start("ActivateTenant"), &err)
+	return p.db.ActivateTenant(tenantID, active)
+}
+
+func (p *Persister) ChangeTenantPlan(tenantID string, plan int) (err error) {
+	defer end(p.start("ChangeTenantPlan"), &err)
+	return p.db.ChangeTenantPlan(tenantID, plan)
+}
+
+func (p *Persister) EnableExternalLogin(tenantID string, config map[string]model.OAuthConfig) (err error) {
+	defer end(p.start("EnableExternalLogin"), &err)
+	return p.db.EnableExternalLogin(tenantID, config)
+}
+
+func (p *Persister) SetSMSConfig(baseID string, config model.SMSConfig) (err error) {
+	defer end(p.start("SetSMSConfig"), &err)
+	return p.db.SetSMSConfig(baseID, config)
+}
+
+func (p *Persister) SetAllowedDomains(baseID string, domains []string) (err error) {
+	defer end(p.start("SetAllowedDomains"), &err)
+	return p.db.SetAllowedDomains(baseID, domains)
+}
+
+func (p *Persister) SetMFARole(baseID string, role int) (err error) {
+	defer end(p.start("SetMFARole"), &err)
+	return p.db.SetMFARole(baseID, role)
+}
+
+func (p *Persister) SetAuthPolicy(baseID string, policy model.AuthPolicy) (err error) {
+	defer end(p.start("SetAuthPolicy"), &err)
+	return p.db.SetAuthPolicy(baseID, policy)
+}
+
+func (p *Persister) FindDatabaseByName(name string) (r0 model.DatabaseConfig, err error) {
+	defer end(p.start("FindDatabaseByName"), &err)
+	return p.db.FindDatabaseByName(name)
+}
+
+func (p *Persister) NewID() string {
+	return p.db.NewID()
+}
+
+func (p *Persister) DeleteTenant(dbName string, email string) (err error) {
+	defer end(p.start("DeleteTenant"), &err)
+	return p.db.DeleteTenant(dbName, email)
+}
+
+func (p *Persister) GetUserByID(dbName string, accountID string, userID string) (r0 model.User, err error) {
+	defer end(p.start("GetUserByID"), &err)
+	return p.db.GetUserByID(dbName, accountID, userID)
+}
+
+func (p *Persister) FindUser(dbName string, userID string, token string) (r0 model.User, err error) {
+	defer end(p.start("FindUser"), &err)
+	return p.db.FindUser(dbName, userID, token)
+}
+
+func (p *Persister) FindUserByID(dbName string, userID string) (r0 model.User, err error) {
+	defer end(p.start("FindUserByID"), &err)
+	return p.db.FindUserByID(dbName, userID)
+}
+
+func (p *Persister) FindRootUser(dbName string, userID string, accountID string, token string) (r0 model.User, err error) {
+	defer end(p.start("FindRootUser"), &err)
+	return p.db.FindRootUser(dbName, userID, accountID, token)
+}
+
+func (p *Persister) GetRootForBase(dbName string) (r0 model.User, err error) {
+	defer end(p.start("GetRootForBase"), &err)
+	return p.db.GetRootForBase(dbName)
+}
+
+func (p *Persister) FindUserByEmail(dbName string, email string) (r0 model.User, err error) {
+	defer end(p.start("FindUserByEmail"), &err)
+	return p.db.FindUserByEmail(dbName, email)
+}
+
+func (p *Persister) UserEmailExists(dbName string, email string) (r0 bool, err error) {
+	defer end(p.start("UserEmailExists"), &err)
+	return p.db.UserEmailExists(dbName, email)
+}
+
+func (p *Persister) GetFirstUserFromAccountID(dbName string, accountID string) (r0 model.User, err error) {
+	defer end(p.start("GetFirstUserFromAccountID"), &err)
+	return p.db.GetFirstUserFromAccountID(dbName, accountID)
+}
+
+func (p *Persister) ListAccounts(dbname string) (r0 []model.Account, err error) {
+	defer end(p.start("ListAccounts"), &err)
+	return p.db.ListAccounts(dbname)
+}
+
+func (p *Persister) ListUsers(dbname string, accountID string) (r0 []model.User, err error) {
+	defer end(p.start("ListUsers"), &err)
+	return p.db.ListUsers(dbname, accountID)
+}
+
+func (p *Persister) CreateAccount(dbName string, email string) (r0 string, err error) {
+	defer end(p.start("CreateAccount"), &err)
+	return p.db.CreateAccount(dbName, email)
+}
+
+func (p *Persister) CreateUser(dbName string, tok model.User) (r0 string, err error) {
+	defer end(p.start("CreateUser"), &err)
+	return p.db.CreateUser(dbName, tok)
+}
+
+func (p *Persister) SetPasswordResetCode(dbName string, tokenID string, code string) (err error) {
+	defer end(p.start("SetPasswordResetCode"), &err)
+	return p.db.SetPasswordResetCode(dbName, tokenID, code)
+}
+
+func (p *Persister) ResetPassword(dbName string, email string, code string, password string) (err error) {
+	defer end(p.start("ResetPassword"), &err)
+	return p.db.ResetPassword(dbName, email, code, password)
+}
+
+func (p *Persister) SetUserRole(dbName string, email string, role int) (err error) {
+	defer end(p.start("SetUserRole"), &err)
+	return p.db.SetUserRole(dbName, email, role)
+}
+
+func (p *Persister) UserSetPassword(dbName string, userID string, password string) (err error) {
+	defer end(p.start("UserSetPassword"), &err)
+	return p.db.UserSetPassword(dbName, userID, password)
+}
+
+func (p *Persister) SetUserVerified(dbName string, userID string, verified bool) (err error) {
+	defer end(p.start("SetUserVerified"), &err)
+	return p.db.SetUserVerified(dbName, userID, verified)
+}
+
+func (p *Persister) RemoveUser(auth model.Auth, dbName string, userID string) (err error) {
+	defer end(p.start("RemoveUser"), &err)
+	return p.db.RemoveUser(auth, dbName, userID)
+}
+
+func (p *Persister) UpdateUserProfile(dbName string, userID string, profile model.UserProfile) (err error) {
+	defer end(p.start("UpdateUserProfile"), &err)
+	return p.db.UpdateUserProfile(dbName, userID, profile)
+}
+
+func (p *Persister) CreateDocument(auth model.Auth, dbName string, col string, doc map[string]interface{}) (r0 map[string]interface{}, err error) {
+	defer end(p.start("CreateDocument"), &err)
+	return p.db.CreateDocument(auth, dbName, col, doc)
+}
+
+func (p *Persister) BulkCreateDocument(auth model.Auth, dbName string, col string, docs []interface{}) (err error) {
+	defer end(p.start("BulkCreateDocument"), &err)
+	return p.db.BulkCreateDocument(auth, dbName, col, docs)
+}
+
+func (p *Persister) ListDocuments(auth model.Auth, dbName string, col string, params model.ListParams) (r0 model.PagedResult, err error) {
+	defer end(p.start("ListDocuments"), &err)
+	return p.db.ListDocuments(auth, dbName, col, params)
+}
+
+func (p *Persister) QueryDocuments(auth model.Auth, dbName string, col string, filter map[string]interface{}, params model.ListParams) (r0 model.PagedResult, err error) {
+	defer end(p.start("QueryDocuments"), &err)
+	return p.db.QueryDocuments(auth, dbName, col, filter, params)
+}
+
+func (p *Persister) GetDocumentByID(auth model.Auth, dbName string, col string, id string) (r0 map[string]interface{}, err error) {
+	defer end(p.start("GetDocumentByID"), &err)
+	return p.db.GetDocumentByID(auth, dbName, col, id)
+}
+
+func (p *Persister) GetDocumentsByIDs(auth model.Auth, dbName string, col string, ids []string) (r0 []map[string]interface{}, err error) {
+	defer end(p.start("GetDocumentsByIDs"), &err)
+	return p.db.GetDocumentsByIDs(auth, dbName, col, ids)
+}
+
+func (p *Persister) UpdateDocument(auth model.Auth, dbName string, col string, id string, doc map[string]interface{}) (r0 map[string]interface{}, err error) {
+	defer end(p.start("UpdateDocument"), &err)
+	return p.db.UpdateDocument(auth, dbName, col, id, doc)
+}
+
+func (p *Persister) UpdateDocuments(auth model.Auth, dbName string, col string, filters map[string]interface{}, updateFields map[string]interface{}) (r0 int64, err error) {
+	defer end(p.start("UpdateDocuments"), &err)
+	return p.db.UpdateDocuments(auth, dbName, col, filters, updateFields)
+}
+
+func (p *Persister) IncrementValue(auth model.Auth, dbName string, col string, id string, field string, n int) (err error) {
+	defer end(p.start("IncrementValue"), &err)
+	return p.db.IncrementValue(auth, dbName, col, id, field, n)
+}
+
+func (p *Persister) DeleteDocument(auth model.Auth, dbName string, col string, id string) (r0 int64, err error) {
+	defer end(p.start("DeleteDocument"), &err)
+	return p.db.DeleteDocument(auth, dbName, col, id)
+}
+
+func (p *Persister) DeleteDocuments(auth model.Auth, dbName string, col string, filters map[string]interface{}) (r0 int64, err error) {
+	defer end(p.start("DeleteDocuments"), &err)
+	return p.db.DeleteDocuments(auth, dbName, col, filters)
+}
+
+func (p *Persister) ListCollections(dbName string) (r0 []string, err error) {
+	defer end(p.start("ListCollections"), &err)
+	return p.db.ListCollections(dbName)
+}
+
+func (p *Persister) ParseQuery(clauses [][]interface{}) (r0 map[string]interface{}, err error) {
+	defer end(p.start("ParseQuery"), &err)
+	return p.db.ParseQuery(clauses)
+}
+
+func (p *Persister) AddFormSubmission(dbName string, form string, doc map[string]interface{}) (err error) {
+	defer end(p.start("AddFormSubmission"), &err)
+	return p.db.AddFormSubmission(dbName, form, doc)
+}
+
+func (p *Persister) ListFormSubmissions(dbName string, name string) (r0 []map[string]interface{}, err error) {
+	defer end(p.start("ListFormSubmissions"), &err)
+	return p.db.ListFormSubmissions(dbName, name)
+}
+
+func (p *Persister) GetForms(dbName string) (r0 []string, err error) {
+	defer end(p.start("GetForms"), &err)
+	return p.db.GetForms(dbName)
+}
+
+func (p *Persister) SaveFormDefinition(dbName string, def model.FormDefinition) (err error) {
+	defer end(p.start("SaveFormDefinition"), &err)
+	return p.db.SaveFormDefinition(dbName, def)
+}
+
+func (p *Persister) GetFormDefinition(dbName string, name string) (r0 model.FormDefinition, err error) {
+	defer end(p.start("GetFormDefinition"), &err)
+	return p.db.GetFormDefinition(dbName, name)
+}
+
+func (p *Persister) ListFormDefinitions(dbName string) (r0 []model.FormDefinition, err error) {
+	defer end(p.start("ListFormDefinitions"), &err)
+	return p.db.ListFormDefinitions(dbName)
+}
+
+func (p *Persister) DeleteFormDefinition(dbName string, name string) (err error) {
+	defer end(p.start("DeleteFormDefinition"), &err)
+	return p.db.DeleteFormDefinition(dbName, name)
+}
+
+func (p *Persister) AddFunction(dbName string, data model.ExecData) (r0 string, err error) {
+	defer end(p.start("AddFunction"), &err)
+	return p.db.AddFunction(dbName, data)
+}
+
+func (p *Persister) UpdateFunction(dbName string, id string, code string, trigger string) (err error) {
+	defer end(p.start("UpdateFunction"), &err)
+	return p.db.UpdateFunction(dbName, id, code, trigger)
+}
+
+func (p *Persister) GetFunctionForExecution(dbName string, name string) (r0 model.ExecData, err error) {
+	defer end(p.start("GetFunctionForExecution"), &err)
+	return p.db.GetFunctionForExecution(dbName, name)
+}
+
+func (p *Persister) GetFunctionByID(dbName string, id string) (r0 model.ExecData, err error) {
+	defer end(p.start("GetFunctionByID"), &err)
+	return p.db.GetFunctionByID(dbName, id)
+}
+
+func (p *Persister) GetFunctionByName(dbName string, name string) (r0 model.ExecData, err error) {
+	defer end(p.start("GetFunctionByName"), &err)
+	return p.db.GetFunctionByName(dbName, name)
+}
+
+func (p *Persister) ListFunctions(dbName string) (r0 []model.ExecData, err error) {
+	defer end(p.start("ListFunctions"), &err)
+	return p.db.ListFunctions(dbName)
+}
+
+func (p *Persister) ListFunctionsByTrigger(dbName string, trigger string) (r0 []model.ExecData, err error) {
+	defer end(p.start("ListFunctionsByTrigger"), &err)
+	return p.db.ListFunctionsByTrigger(dbName, trigger)
+}
+
+func (p *Persister) DeleteFunction(dbName string, name string) (err error) {
+	defer end(p.start("DeleteFunction"), &err)
+	return p.db.DeleteFunction(dbName, name)
+}
+
+func (p *Persister) RanFunction(dbName string, id string, rh model.ExecHistory) (err error) {
+	defer end(p.start("RanFunction"), &err)
+	return p.db.RanFunction(dbName, id, rh)
+}
+
+func (p *Persister) ListTasks() (r0 []model.Task, err error) {
+	defer end(p.start("ListTasks"), &err)
+	return p.db.ListTasks()
+}
+
+func (p *Persister) ListTasksByBase(dbName string) (r0 []model.Task, err error) {
+	defer end(p.start("ListTasksByBase"), &err)
+	return p.db.ListTasksByBase(dbName)
+}
+
+func (p *Persister) AddTask(a0 string, a1 model.Task) (r0 string, err error) {
+	defer end(p.start("AddTask"), &err)
+	return p.db.AddTask(a0, a1)
+}
+
+func (p *Persister) DeleteTask(dbName string, id string) (err error) {
+	defer end(p.start("DeleteTask"), &err)
+	return p.db.DeleteTask(dbName, id)
+}
+
+func (p *Persister) GetTask(dbName string, id string) (r0 model.Task, err error) {
+	defer end(p.start("GetTask"), &err)
+	return p.db.GetTask(dbName, id)
+}
+
+func (p *Persister) SetTaskPaused(dbName string, id string, paused bool) (err error) {
+	defer end(p.start("SetTaskPaused"), &err)
+	return p.db.SetTaskPaused(dbName, id, paused)
+}
+
+func (p *Persister) RanTask(dbName string, run model.TaskRun) (err error) {
+	defer end(p.start("RanTask"), &err)
+	return p.db.RanTask(dbName, run)
+}
+
+func (p *Persister) ListTaskRuns(dbName string, taskID string) (r0 []model.TaskRun, err error) {
+	defer end(p.start("ListTaskRuns"), &err)
+	return p.db.ListTaskRuns(dbName, taskID)
+}
+
+func (p *Persister) AddFile(dbName string, f model.File) (r0 string, err error) {
+	defer end(p.start("AddFile"), &err)
+	return p.db.AddFile(dbName, f)
+}
+
+func (p *Persister) GetFileByID(dbName string, fileID string) (r0 model.File, err error) {
+	defer end(p.start("GetFileByID"), &err)
+	return p.db.GetFileByID(dbName, fileID)
+}
+
+func (p *Persister) DeleteFile(dbName string, fileID string) (err error) {
+	defer end(p.start("DeleteFile"), &err)
+	return p.db.DeleteFile(dbName, fileID)
+}
+
+func (p *Persister) ListAllFiles(dbName string, accountID string) (r0 []model.File, err error) {
+	defer end(p.start("ListAllFiles"), &err)
+	return p.db.ListAllFiles(dbName, accountID)
+}
+
+func (p *Persister) GetFileByChecksum(dbName string, checksum string) (r0 model.File, err error) {
+	defer end(p.start("GetFileByChecksum"), &err)
+	return p.db.GetFileByChecksum(dbName, checksum)
+}
+
+func (p *Persister) CountFileReferences(dbName string, key string) (r0 int64, err error) {
+	defer end(p.start("CountFileReferences"), &err)
+	return p.db.CountFileReferences(dbName, key)
+}
+
+func (p *Persister) AddSMSMessage(dbName string, msg model.SMSMessage) (r0 string, err error) {
+	defer end(p.start("AddSMSMessage"), &err)
+	return p.db.AddSMSMessage(dbName, msg)
+}
+
+func (p *Persister) UpdateSMSStatus(dbName string, messageID string, status string, errMsg string) (err error) {
+	defer end(p.start("UpdateSMSStatus"), &err)
+	return p.db.UpdateSMSStatus(dbName, messageID, status, errMsg)
+}
+
+func (p *Persister) GetSMSMessageByID(dbName string, id string) (r0 model.SMSMessage, err error) {
+	defer end(p.start("GetSMSMessageByID"), &err)
+	return p.db.GetSMSMessageByID(dbName, id)
+}
+
+func (p *Persister) ListSMSMessages(dbName string) (r0 []model.SMSMessage, err error) {
+	defer end(p.start("ListSMSMessages"), &err)
+	return p.db.ListSMSMessages(dbName)
+}
+
+func (p *Persister) SaveEmailTemplate(dbName string, tmpl model.EmailTemplate) (err error) {
+	defer end(p.start("SaveEmailTemplate"), &err)
+	return p.db.SaveEmailTemplate(dbName, tmpl)
+}
+
+func (p *Persister) GetEmailTemplate(dbName string, name string) (r0 model.EmailTemplate, err error) {
+	defer end(p.start("GetEmailTemplate"), &err)
+	return p.db.GetEmailTemplate(dbName, name)
+}
+
+func (p *Persister) ListEmailTemplates(dbName string) (r0 []model.EmailTemplate, err error) {
+	defer end(p.start("ListEmailTemplates"), &err)
+	return p.db.ListEmailTemplates(dbName)
+}
+
+func (p *Persister) DeleteEmailTemplate(dbName string, name string) (err error) {
+	defer end(p.start("DeleteEmailTemplate"), &err)
+	return p.db.DeleteEmailTemplate(dbName, name)
+}
+
+func (p *Persister) AddEmailLog(dbName string, entry model.EmailLog) (r0 string, err error) {
+	defer end(p.start("AddEmailLog"), &err)
+	return p.db.AddEmailLog(dbName, entry)
+}
+
+func (p *Persister) ListEmailLogs(dbName string) (r0 []model.EmailLog, err error) {
+	defer end(p.start("ListEmailLogs"), &err)
+	return p.db.ListEmailLogs(dbName)
+}
+
+func (p *Persister) CreateSession(dbName string, s model.Session) (r0 string, err error) {
+	defer end(p.start("CreateSession"), &err)
+	return p.db.CreateSession(dbName, s)
+}
+
+func (p *Persister) GetSessionByID(dbName string, id string) (r0 model.Session, err error) {
+	defer end(p.start("GetSessionByID"), &err)
+	return p.db.GetSessionByID(dbName, id)
+}
+
+func (p *Persister) ListSessions(dbName string, userID string) (r0 []model.Session, err error) {
+	defer end(p.start("ListSessions"), &err)
+	return p.db.ListSessions(dbName, userID)
+}
+
+func (p *Persister) RotateSession(dbName string, s model.Session, prevHash string) (ok bool, err error) {
+	defer end(p.start("RotateSession"), &err)
+	return p.db.RotateSession(dbName, s, prevHash)
+}
+
+func (p *Persister) DeleteSession(dbName string, id string) (err error) {
+	defer end(p.start("DeleteSession"), &err)
+	return p.db.DeleteSession(dbName, id)
+}
+
+func (p *Persister) DeleteUserSessions(dbName string, userID string) (err error) {
+	defer end(p.start("DeleteUserSessions"), &err)
+	return p.db.DeleteUserSessions(dbName, userID)
+}
+
+func (p *Persister) SaveMFA(dbName string, m model.MFA) (err error) {
+	defer end(p.start("SaveMFA"), &err)
+	return p.db.SaveMFA(dbName, m)
+}
+
+func (p *Persister) GetMFA(dbName string, userID string) (r0 model.MFA, err error) {
+	defer end(p.start("GetMFA"), &err)
+	return p.db.GetMFA(dbName, userID)
+}
+
+func (p *Persister) DeleteMFA(dbName string, userID string) (err error) {
+	defer end(p.start("DeleteMFA"), &err)
+	return p.db.DeleteMFA(dbName, userID)
+}
+
+func (p *Persister) CreateAPIKey(dbName string, k model.APIKey) (r0 string, err error) {
+	defer end(p.start("CreateAPIKey"), &err)
+	return p.db.CreateAPIKey(dbName, k)
+}
+
+func (p *Persister) GetAPIKeyByHash(dbName string, hash string) (r0 model.APIKey, err error) {
+	defer end(p.start("GetAPIKeyByHash"), &err)
+	return p.db.GetAPIKeyByHash(dbName, hash)
+}
+
+func (p *Persister) ListAPIKeys(dbName string) (r0 []model.APIKey, err error) {
+	defer end(p.start("ListAPIKeys"), &err)
+	return p.db.ListAPIKeys(dbName)
+}
+
+func (p *Persister) TouchAPIKey(dbName string, id string, lastUsed time.Time, ip string) (err error) {
+	defer end(p.start("TouchAPIKey"), &err)
+	return p.db.TouchAPIKey(dbName, id, lastUsed, ip)
+}
+
+func (p *Persister) DeleteAPIKey(dbName string, id string) (err error) {
+	defer end(p.start("DeleteAPIKey"), &err)
+	return p.db.DeleteAPIKey(dbName, id)
+}
+
+func (p *Persister) CreateIdentity(dbName string, idt model.Identity) (r0 string, err error) {
+	defer end(p.start("CreateIdentity"), &err)
+	return p.db.CreateIdentity(dbName, idt)
+}
+
+func (p *Persister) FindIdentity(dbName string, provider string, subject string) (r0 model.Identity, err error) {
+	defer end(p.start("FindIdentity"), &err)
+	return p.db.FindIdentity(dbName, provider, subject)
+}
+
+func (p *Persister) ListIdentities(dbName string, userID string) (r0 []model.Identity, err error) {
+	defer end(p.start("ListIdentities"), &err)
+	return p.db.ListIdentities(dbName, userID)
+}
+
+func (p *Persister) DeleteIdentity(dbName string, userID string, id string) (err error) {
+	defer end(p.start("DeleteIdentity"), &err)
+	return p.db.DeleteIdentity(dbName, userID, id)
+}
+
+func (p *Persister) SaveRole(dbName string, role model.Role) (err error) {
+	defer end(p.start("SaveRole"), &err)
+	return p.db.SaveRole(dbName, role)
+}
+
+func (p *Persister) ListRoles(dbName string) (r0 []model.Role, err error) {
+	defer end(p.start("ListRoles"), &err)
+	return p.db.ListRoles(dbName)
+}
+
+func (p *Persister) DeleteRole(dbName string, name string) (err error) {
+	defer end(p.start("DeleteRole"), &err)
+	return p.db.DeleteRole(dbName, name)
+}
+
+func (p *Persister) CreateGroup(dbName string, g model.Group) (r0 string, err error) {
+	defer end(p.start("CreateGroup"), &err)
+	return p.db.CreateGroup(dbName, g)
+}
+
+func (p *Persister) GetGroup(dbName string, accountID string, id string) (r0 model.Group, err error) {
+	defer end(p.start("GetGroup"), &err)
+	return p.db.GetGroup(dbName, accountID, id)
+}
+
+func (p *Persister) UpdateGroup(dbName string, g model.Group) (err error) {
+	defer end(p.start("UpdateGroup"), &err)
+	return p.db.UpdateGroup(dbName, g)
+}
+
+func (p *Persister) ListGroups(dbName string, accountID string) (r0 []model.Group, err error) {
+	defer end(p.start("ListGroups"), &err)
+	return p.db.ListGroups(dbName, accountID)
+}
+
+func (p *Persister) DeleteGroup(dbName string, accountID string, id string) (err error) {
+	defer end(p.start("DeleteGroup"), &err)
+	return p.db.DeleteGroup(dbName, accountID, id)
+}
+
+func (p *Persister) AddGroupMember(dbName string, groupID string, userID string) (err error) {
+	defer end(p.start("AddGroupMember"), &err)
+	return p.db.AddGroupMember(dbName, groupID, userID)
+}
+
+func (p *Persister) RemoveGroupMember(dbName string, groupID string, userID string) (err error) {
+	defer end(p.start("RemoveGroupMember"), &err)
+	return p.db.RemoveGroupMember(dbName, groupID, userID)
+}
+
+func (p *Persister) ListUserGroups(dbName string, userID string) (r0 []model.Group, err error) {
+	defer end(p.start("ListUserGroups"), &err)
+	return p.db.ListUserGroups(dbName, userID)
+}
+
+func (p *Persister) CreateInvitation(dbName string, inv model.Invitation) (r0 string, err error) {
+	defer end(p.start("CreateInvitation"), &err)
+	return p.db.CreateInvitation(dbName, inv)
+}
+
+func (p *Persister) GetInvitation(dbName string, id string) (r0 model.Invitation, err error) {
+	defer end(p.start("GetInvitation"), &err)
+	return p.db.GetInvitation(dbName, id)
+}
+
+func (p *Persister) ListInvitations(dbName string, accountID string) (r0 []model.Invitation, err error) {
+	defer end(p.start("ListInvitations"), &err)
+	return p.db.ListInvitations(dbName, accountID)
+}
+
+func (p *Persister) RenewInvitation(dbName string, id string, nonce string, expires time.Time) (err error) {
+	defer end(p.start("RenewInvitation"), &err)
+	return p.db.RenewInvitation(dbName, id, nonce, expires)
+}
+
+func (p *Persister) DeleteInvitation(dbName string, id string) (err error) {
+	defer end(p.start("DeleteInvitation"), &err)
+	return p.db.DeleteInvitation(dbName, id)
+}
+
+func (p *Persister) ListDocumentsByOwner(dbName string, col string, ownerID string) (r0 []map[string]interface{}, err error) {
+	defer end(p.start("ListDocumentsByOwner"), &err)
+	return p.db.ListDocumentsByOwner(dbName, col, ownerID)
+}
+
+func (p *Persister) DeleteDocumentsByOwner(dbName string, col string, ownerID string) (r0 int64, err error) {
+	defer end(p.start("DeleteDocumentsByOwner"), &err)
+	return p.db.DeleteDocumentsByOwner(dbName, col, ownerID)
+}
+
+func (p *Persister) ListFormSubmissionsByEmail(dbName string, email string) (r0 []map[string]interface{}, err error) {
+	defer end(p.start("ListFormSubmissionsByEmail"), &err)
+	return p.db.ListFormSubmissionsByEmail(dbName, email)
+}
+
+func (p *Persister) DeleteFormSubmissionsByEmail(dbName string, email string) (r0 int64, err error) {
+	defer end(p.start("DeleteFormSubmissionsByEmail"), &err)
+	return p.db.DeleteFormSubmissionsByEmail(dbName, email)
+}
+
+func (p *Persister) DumpLayout() string {
+	return p.db.DumpLayout()
+}
+
+func (p *Persister) DumpTables(dbName string) (r0 []string, err error) {
+	defer end(p.start("DumpTables"), &err)
+	return p.db.DumpTables(dbName)
+}
+
+func (p *Persister) DumpRows(dbName string, tables []string, fn func(table string, row map[string]any) error) (err error) {
+	defer end(p.start("DumpRows"), &err)
+	return p.db.DumpRows(dbName, tables, fn)
+}
+
+func (p *Persister) RestoreTenant(cus model.Tenant, base model.DatabaseConfig) (err error) {
+	defer end(p.start("RestoreTenant"), &err)
+	return p.db.RestoreTenant(cus, base)
+}
+
+func (p *Persister) RestoreRows(dbName string, table string, rows []map[string]any) (err error) {
+	defer end(p.start("RestoreRows"), &err)
+	return p.db.RestoreRows(dbName, table, rows)
+}
+
+func (p *Persister) DumpDocuments(dbName string, cols []string, fn func(col string, doc model.PortableDocument) error) (err error) {
+	defer end(p.start("DumpDocuments"), &err)
+	return p.db.DumpDocuments(dbName, cols, fn)
+}
+
+func (p *Persister) RestoreDocuments(dbName string, col string, docs []model.PortableDocument) (err error) {
+	defer end(p.start("RestoreDocuments"), &err)
+	return p.db.RestoreDocuments(dbName, col, docs)
+}
+
+func (p *Persister) RestoreAccount(dbName string, a model.Account) (err error) {
+	defer end(p.start("RestoreAccount"), &err)
+	return p.db.RestoreAccount(dbName, a)
+}
+
+func (p *Persister) RestoreUser(dbName string, tok model.User) (err error) {
+	defer end(p.start("RestoreUser"), &err)
+	return p.db.RestoreUser(dbName, tok)
+}
+
+func (p *Persister) RestoreFile(dbName string, f model.File) (err error) {
+	defer end(p.start("RestoreFile"), &err)
+	return p.db.RestoreFile(dbName, f)
+}
+
+func (p *Persister) DeleteDatabase(dbName string) (err error) {
+	defer end(p.start("DeleteDatabase"), &err)
+	return p.db.DeleteDatabase(dbName)
+}
+
+func (p *Persister) Count(auth model.Auth, dbName string, col string, filters map[string]interface{}) (r0 int64, err error) {
+	defer end(p.start("Count"), &err)
+	return p.db.Count(auth, dbName, col, filters)
+}
@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/staticbackendhq/core/database/memory"
+	"github.com/staticbackendhq/core/model"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpansAndPropagation(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	db := NewPersister(memory.New(func(model.Auth, string, string, string, any) {}), "memory")
+
+	// outside a request the calls are not traced
+	if _, err := db.FindDatabase("unknown"); err == nil {
+		t.Fatal("expected an error finding an unknown database")
+	}
+	if n := len(rec.Ended()); n != 0 {
+		t.Fatalf("expected no span for an unbound call got %d", n)
+	}
+
+	// the downstream service receives the trace-context
+	var traceparent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/test", Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		BindPersister(r.Context(), db).FindDatabase("unknown")
+
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, downstream.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := http.Client{Transport: Transport(nil)}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	})))
+
+	// the incoming trace-context is continued
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("traceparent", parent)
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range rec.Ended() {
+		spans[span.Name()] = span
+	}
+
+	server, ok := spans["GET /test"]
+	if !ok {
+		t.Fatalf("expected a server span got %v", spans)
+	}
+	if tid := server.SpanContext().TraceID().String(); tid != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the incoming trace to be continued got %s", tid)
+	}
+
+	dbSpan, ok := spans["db.FindDatabase"]
+	if !ok {
+		t.Fatalf("expected a database span got %v", spans)
+	}
+	if dbSpan.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("expected the database span to be a child of the server span")
+	}
+	if dbSpan.Status().Code != codes.Error {
+		t.Errorf("expected the database span to have an error status got %v", dbSpan.Status())
+	}
+
+	client, ok := spans["GET"]
+	if !ok {
+		t.Fatalf("expected a client span got %v", spans)
+	}
+	if len(traceparent) == 0 || traceparent[36:52] != client.SpanContext().SpanID().String() {
+		t.Errorf("expected the client span in the traceparent header got %q", traceparent)
+	}
+}
@@ -0,0 +1,220 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/model"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Volatilizer creates a span for each call to a cache.Volatilizer when its
+// bound context has a span
+type Volatilizer struct {
+	v      cache.Volatilizer
+	engine string
+	ctx    context.Context
+}
+
+// NewVolatilizer wraps v, engine is used as the db.system attribute of its
+// spans
+func NewVolatilizer(v cache.Volatilizer, engine string) *Volatilizer {
+	return &Volatilizer{v: v, engine: engine, ctx: context.Background()}
+}
+
+// WithContext returns a copy of c creating its spans as children of ctx
+func (c *Volatilizer) WithContext(ctx context.Context) cache.Volatilizer {
+	return &Volatilizer{v: c.v, engine: c.engine, ctx: ctx}
+}
+
+// BindVolatilizer returns v bound to ctx when it is traced
+func BindVolatilizer(ctx context.Context, v cache.Volatilizer) cache.Volatilizer {
+	if c, ok := v.(*Volatilizer); ok {
+		return c.WithContext(ctx)
+	}
+	return v
+}
+
+func (c *Volatilizer) start(method string) trace.Span {
+	return startChild(c.ctx, "cache."+method, attribute.String("db.system", c.engine))
+}
+
+func (c *Volatilizer) Get(key string) (s string, err error) {
+	defer end(c.start("Get"), &err)
+	return c.v.Get(key)
+}
+
+func (c *Volatilizer) Set(key string, value string) (err error) {
+	defer end(c.start("Set"), &err)
+	return c.v.Set(key, value)
+}
+
+func (c *Volatilizer) Del(key string) (err error) {
+	defer end(c.start("Del"), &err)
+	return c.v.Del(key)
+}
+
+func (c *Volatilizer) SetEx(key string, value string, ttl time.Duration) (err error) {
+	defer end(c.start("SetEx"), &err)
+	return c.v.SetEx(key, value, ttl)
+}
+
+func (c *Volatilizer) SetNX(key string, value string, ttl time.Duration) (ok bool, err error) {
+	defer end(c.start("SetNX"), &err)
+	return c.v.SetNX(key, value, ttl)
+}
+
+func (c *Volatilizer) Exists(key string) (ok bool, err error) {
+	defer end(c.start("Exists"), &err)
+	return c.v.Exists(key)
+}
+
+func (c *Volatilizer) Expire(key string, ttl time.Duration) (ok bool, err error) {
+	defer end(c.start("Expire"), &err)
+	return c.v.Expire(key, ttl)
+}
+
+func (c *Volatilizer) TTL(key string) (ttl time.Duration, err error) {
+	defer end(c.start("TTL"), &err)
+	return c.v.TTL(key)
+}
+
+func (c *Volatilizer) GetTyped(key string, v any) (err error) {
+	defer end(c.start("GetTyped"), &err)
+	return c.v.GetTyped(key, v)
+}
+
+func (c *Volatilizer) SetTyped(key string, v any) (err error) {
+	defer end(c.start("SetTyped"), &err)
+	return c.v.SetTyped(key, v)
+}
+
+func (c *Volatilizer) Inc(key string, by int64) (n int64, err error) {
+	defer end(c.start("Inc"), &err)
+	return c.v.Inc(key, by)
+}
+
+func (c *Volatilizer) Dec(key string, by int64) (n int64, err error) {
+	defer end(c.start("Dec"), &err)
+	return c.v.Dec(key, by)
+}
+
+func (c *Volatilizer) HSet(key, field, value string) (err error) {
+	defer end(c.start("HSet"), &err)
+	return c.v.HSet(key, field, value)
+}
+
+func (c *Volatilizer) HGet(key, field string) (s string, err error) {
+	defer end(c.start("HGet"), &err)
+	return c.v.HGet(key, field)
+}
+
+func (c *Volatilizer) HDel(key, field string) (err error) {
+	defer end(c.start("HDel"), &err)
+	return c.v.HDel(key, field)
+}
+
+func (c *Volatilizer) HGetAll(key string) (h map[string]string, err error) {
+	defer end(c.start("HGetAll"), &err)
+	return c.v.HGetAll(key)
+}
+
+func (c *Volatilizer) ZAdd(key, member string, score float64) (err error) {
+	defer end(c.start("ZAdd"), &err)
+	return c.v.ZAdd(key, member, score)
+}
+
+func (c *Volatilizer) ZIncrBy(key, member string, by float64) (score float64, err error) {
+	defer end(c.start("ZIncrBy"), &err)
+	return c.v.ZIncrBy(key, member, by)
+}
+
+func (c *Volatilizer) ZScore(key, member string) (score float64, err error) {
+	defer end(c.start("ZScore"), &err)
+	return c.v.ZScore(key, member)
+}
+
+func (c *Volatilizer) ZRem(key, member string) (err error) {
+	defer end(c.start("ZRem"), &err)
+	return c.v.ZRem(key, member)
+}
+
+func (c *Volatilizer) ZRange(key string, start, stop int64, reverse bool) (members []cache.ScoredMember, err error) {
+	defer end(c.start("ZRange"), &err)
+	return c.v.ZRange(key, start, stop, reverse)
+}
+
+// Subscribe is not traced since it blocks for the whole subscription
+func (c *Volatilizer) Subscribe(send chan model.Command, token, channel string, close chan bool) {
+	c.v.Subscribe(send, token, channel, close)
+}
+
+func (c *Volatilizer) Publish(msg model.Command) (err error) {
+	defer end(c.start("Publish"), &err)
+	return c.v.Publish(msg)
+}
+
+func (c *Volatilizer) PublishDocument(auth model.Auth, dbname, channel, typ string, v any) {
+	span := c.start("PublishDocument")
+	defer span.End()
+
+	c.v.PublishDocument(auth, dbname, channel, typ, v)
+}
+
+func (c *Volatilizer) QueueWork(key, value string) (err error) {
+	defer end(c.start("QueueWork"), &err)
+	return c.v.QueueWork(key, value)
+}
+
+func (c *Volatilizer) DequeueWork(key string) (s string, err error) {
+	defer end(c.start("DequeueWork"), &err)
+	return c.v.DequeueWork(key)
+}
+
+func (c *Volatilizer) Enqueue(key, value string, delay time.Duration) (id string, err error) {
+	defer end(c.start("Enqueue"), &err)
+	return c.v.Enqueue(key, value, delay)
+}
+
+func (c *Volatilizer) Lease(key string, visibility time.Duration, maxAttempts int) (it *cache.QueueItem, err error) {
+	defer end(c.start("Lease"), &err)
+	return c.v.Lease(key, visibility, maxAttempts)
+}
+
+func (c *Volatilizer) Ack(key, receipt string) (ok bool, err error) {
+	defer end(c.start("Ack"), &err)
+	return c.v.Ack(key, receipt)
+}
+
+func (c *Volatilizer) QueueStats(key string) (stats cache.QueueStats, err error) {
+	defer end(c.start("QueueStats"), &err)
+	return c.v.QueueStats(key)
+}
+
+func (c *Volatilizer) QueueItems(key string) (items []cache.QueueItem, err error) {
+	defer end(c.start("QueueItems"), &err)
+	return c.v.QueueItems(key)
+}
+
+func (c *Volatilizer) DeadLetters(key string) (items []cache.QueueItem, err error) {
+	defer end(c.start("DeadLetters"), &err)
+	return c.v.DeadLetters(key)
+}
+
+func (c *Volatilizer) AcquireLock(key, owner string, ttl time.Duration) (ok bool, err error) {
+	defer end(c.start("AcquireLock"), &err)
+	return c.v.AcquireLock(key, owner, ttl)
+}
+
+func (c *Volatilizer) RenewLock(key, owner string, ttl time.Duration) (ok bool, err error) {
+	defer end(c.start("RenewLock"), &err)
+	return c.v.RenewLock(key, owner, ttl)
+}
+
+func (c *Volatilizer) ReleaseLock(key, owner string) (err error) {
+	defer end(c.start("ReleaseLock"), &err)
+	return c.v.ReleaseLock(key, owner)
+}
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/function"
 	"github.com/staticbackendhq/core/logger"
 	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
@@ -140,6 +142,17 @@ func (x ui) enableExternalLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// generic OpenID Connect providers are named by the user
+	isOIDC := provider == "oidc"
+	if isOIDC {
+		provider = strings.ToLower(strings.TrimSpace(r.Form.Get("name")))
+		if !validProviderName(provider) {
+			flash := &Flash{Type: "danger", Message: "The provider name must only contain letters, digits and dashes and not be a built-in provider"}
+			render(w, r, "logins.html", logins, flash, x.log)
+			return
+		}
+	}
+
 	keys, ok := logins[provider]
 	if !ok {
 		keys = model.OAuthConfig{}
@@ -148,6 +161,26 @@ func (x ui) enableExternalLogin(w http.ResponseWriter, r *http.Request) {
 	keys.ConsumerKey = apikey
 	keys.ConsumerSecret = secret
 
+	if isOIDC {
+		keys.DiscoveryURL = strings.TrimSpace(r.Form.Get("discovery"))
+		keys.Scopes = r.Form.Get("scopes")
+		keys.PKCE = r.Form.Get("pkce") == "1"
+		keys.Claims = model.ClaimMapping{
+			Subject:   r.Form.Get("claim_sub"),
+			Email:     r.Form.Get("claim_email"),
+			Name:      r.Form.Get("claim_name"),
+			FirstName: r.Form.Get("claim_first"),
+			LastName:  r.Form.Get("claim_last"),
+			AvatarURL: r.Form.Get("claim_avatar"),
+		}
+
+		if _, err := newOIDCProvider(provider, "", keys); err != nil {
+			flash := &Flash{Type: "danger", Message: err.Error()}
+			render(w, r, "logins.html", logins, flash, x.log)
+			return
+		}
+	}
+
 	logins[provider] = keys
 
 	if err := backend.DB.EnableExternalLogin(cus.ID, logins); err != nil {
@@ -159,6 +192,22 @@ func (x ui) enableExternalLogin(w http.ResponseWriter, r *http.Request) {
 	render(w, r, "logins.html", logins, flash, x.log)
 }
 
+// validProviderName returns true for a name that can be used in the OAuth
+// state and does not replace a built-in provider
+func validProviderName(name string) bool {
+	switch name {
+	case "", OAuthProviderTwitter, OAuthProviderFacebook, OAuthProviderGoogle, OAuthProviderGitHub:
+		return false
+	}
+
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
 func (x *ui) dbCols(w http.ResponseWriter, r *http.Request) {
 	conf, auth, err := middleware.Extract(r, false)
 	if err != nil {
@@ -558,7 +607,7 @@ func (x *ui) fsList(w http.ResponseWriter, r *http.Request) {
 }
 
 func (x *ui) fsDel(w http.ResponseWriter, r *http.Request) {
-	conf, _, err := middleware.Extract(r, false)
+	conf, auth, err := middleware.Extract(r, true)
 	if err != nil {
 		renderErr(w, r, err, x.log)
 		return
@@ -566,7 +615,8 @@ func (x *ui) fsDel(w http.ResponseWriter, r *http.Request) {
 
 	fileID := getURLPart(r.URL.Path, 4)
 
-	if err := backend.DB.DeleteFile(conf.Name, fileID); err != nil {
+	fileSvc := backend.Storage(auth, conf)
+	if err := fileSvc.Delete(fileID); err != nil {
 		renderErr(w, r, err, x.log)
 		return
 	}
@@ -590,6 +640,18 @@ func (x ui) accounts(w http.ResponseWriter, r *http.Request) {
 	render(w, r, "accounts_list.html", accounts, nil, nil)
 }
 
+type usersData struct {
+	AccountID string
+	Users     []userLockout
+	Groups    []model.Group
+	Roles     []model.Role
+}
+
+type userLockout struct {
+	model.User
+	Lockout backend.Lockout
+}
+
 func (x ui) users(w http.ResponseWriter, r *http.Request) {
 	conf, _, err := middleware.Extract(r, false)
 	if err != nil {
@@ -599,13 +661,92 @@ func (x ui) users(w http.ResponseWriter, r *http.Request) {
 
 	id := getURLPart(r.URL.Path, 3)
 
+	mship := backend.Membership(conf)
+
+	var flash *Flash
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			renderErr(w, r, err, x.log)
+			return
+		}
+
+		flash = x.usersAction(mship, id, r.Form)
+	}
+
+	data := usersData{AccountID: id}
+
 	users, err := backend.DB.ListUsers(conf.Name, id)
 	if err != nil {
 		renderErr(w, r, err, x.log)
 		return
 	}
 
-	render(w, r, "users_list.html", users, nil, nil)
+	for _, u := range users {
+		lockout, err := mship.LockoutStatus(u.Email)
+		if err != nil {
+			renderErr(w, r, err, x.log)
+			return
+		}
+
+		data.Users = append(data.Users, userLockout{User: u, Lockout: lockout})
+	}
+
+	data.Groups, err = mship.ListGroups(id)
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	data.Roles, err = mship.ListRoles()
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	render(w, r, "users_list.html", data, flash, x.log)
+}
+
+// usersAction handles the forms of the users page, unlocking a user is the
+// default action
+func (x ui) usersAction(mship backend.User, accountID string, form url.Values) *Flash {
+	var err error
+	var msg string
+
+	switch form.Get("action") {
+	case "create-group":
+		data := backend.GroupData{Name: form.Get("name"), Roles: splitList(form.Get("roles"))}
+		_, err = mship.CreateGroup(accountID, data)
+		msg = "The group has been created"
+	case "delete-group":
+		err = mship.DeleteGroup(accountID, form.Get("id"))
+		msg = "The group has been deleted"
+	case "add-member":
+		err = mship.AddGroupMember(accountID, form.Get("id"), form.Get("userId"))
+		msg = "The user has been added to the group"
+	case "remove-member":
+		err = mship.RemoveGroupMember(accountID, form.Get("id"), form.Get("userId"))
+		msg = "The user has been removed from the group"
+	case "save-role":
+		role := model.Role{
+			Name:        form.Get("name"),
+			Description: form.Get("description"),
+			Permissions: splitList(form.Get("permissions")),
+		}
+		err = mship.SaveRole(role)
+		msg = "The role has been saved"
+	case "delete-role":
+		err = mship.DeleteRole(form.Get("name"))
+		msg = "The role has been deleted"
+	default:
+		email := form.Get("email")
+		err = mship.Unlock(email)
+		msg = email + " has been unlocked"
+	}
+
+	if err != nil {
+		return &Flash{Type: "danger", Message: err.Error()}
+	}
+	return &Flash{Type: "success", Message: msg}
 }
 
 func (x ui) tasks(w http.ResponseWriter, r *http.Request) {
@@ -643,9 +784,16 @@ func (x ui) taskNew(w http.ResponseWriter, r *http.Request) {
 			Value:    r.Form.Get("value"),
 			Interval: r.Form.Get("interval"),
 			Meta:     r.Form.Get("meta"),
+			Timezone: r.Form.Get("timezone"),
+			CatchUp:  r.Form.Get("catchUp"),
 			BaseName: conf.Name,
 		}
 
+		if err := function.ValidateTask(task); err != nil {
+			render(w, r, "tasks_new.html", nil, &Flash{Type: "danger", Message: err.Error()}, x.log)
+			return
+		}
+
 		taskID, err := backend.DB.AddTask(conf.Name, task)
 		if err != nil {
 			renderErr(w, r, err, x.log)
@@ -653,7 +801,10 @@ func (x ui) taskNew(w http.ResponseWriter, r *http.Request) {
 		}
 
 		task.ID = taskID
-		backend.Scheduler.AddOnTheFly(task)
+		if err := backend.Scheduler.AddOnTheFly(task); err != nil {
+			// the leader picks the task up on its next reload
+			x.log.Warn().Err(err).Msgf("unable to notify the scheduler of task %s", task.ID)
+		}
 
 		http.Redirect(w, r, "/ui/tasks", http.StatusSeeOther)
 		return
@@ -662,6 +813,203 @@ func (x ui) taskNew(w http.ResponseWriter, r *http.Request) {
 	render(w, r, "tasks_new.html", nil, nil, nil)
 }
 
+func (x ui) taskDetail(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	id := getURLPart(r.URL.Path, 3)
+
+	task, err := backend.DB.GetTask(conf.Name, id)
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	var flash *Flash
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			renderErr(w, r, err, x.log)
+			return
+		}
+
+		switch r.Form.Get("action") {
+		case "delete":
+			if err := deleteTask(conf.Name, task); err != nil {
+				renderErr(w, r, err, x.log)
+				return
+			}
+
+			http.Redirect(w, r, "/ui/tasks", http.StatusSeeOther)
+			return
+		case "pause", "resume":
+			task.Paused = r.Form.Get("action") == "pause"
+			if err := backend.DB.SetTaskPaused(conf.Name, task.ID, task.Paused); err != nil {
+				flash = &Flash{Type: "danger", Message: err.Error()}
+			} else if task.Paused {
+				flash = &Flash{Type: "success", Message: "The job has been paused"}
+			} else {
+				flash = &Flash{Type: "success", Message: "The job has been resumed"}
+			}
+		default:
+			run, err := backend.Scheduler.RunNow(task)
+			if err != nil {
+				flash = &Flash{Type: "danger", Message: err.Error()}
+			} else if run.Status == model.TaskRunFailure {
+				flash = &Flash{Type: "danger", Message: "The job failed: " + run.Error}
+			} else {
+				flash = &Flash{Type: "success", Message: "The job has been executed"}
+			}
+		}
+	}
+
+	runs, err := backend.DB.ListTaskRuns(conf.Name, task.ID)
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	data := struct {
+		Task      model.Task
+		Runs      []model.TaskRun
+		RunningOn string
+	}{
+		Task: task,
+		Runs: runs,
+	}
+	data.RunningOn, _ = backend.Cache.Get(function.TaskLockKey(task.ID))
+
+	render(w, r, "tasks_detail.html", data, flash, x.log)
+}
+
+type domainsData struct {
+	Domains []string
+	// AllOrigins is true when "*" is allowed, origins are not checked
+	AllOrigins bool
+}
+
+func newDomainsData(domains []string) domainsData {
+	return domainsData{Domains: domains, AllOrigins: middleware.AllowsAllOrigins(domains)}
+}
+
+func (x ui) domains(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	domains := cleanAllowedDomains(conf.AllowedDomain)
+
+	if r.Method == http.MethodGet {
+		render(w, r, "domains.html", newDomainsData(domains), nil, x.log)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	domain := strings.ToLower(strings.TrimSpace(r.Form.Get("domain")))
+
+	var updated []string
+	if r.Form.Get("action") == "remove" {
+		for _, d := range domains {
+			if d != domain {
+				updated = append(updated, d)
+			}
+		}
+	} else {
+		updated = append(domains, domain)
+	}
+
+	updated, err = updateAllowedDomains(conf, updated)
+	if err != nil {
+		flash := &Flash{Type: "danger", Message: err.Error()}
+		render(w, r, "domains.html", newDomainsData(domains), flash, x.log)
+		return
+	}
+
+	flash := &Flash{Type: "success", Message: "Allowed domains updated"}
+	render(w, r, "domains.html", newDomainsData(updated), flash, x.log)
+}
+
+type apiKeysData struct {
+	Keys []model.APIKey
+	// NewKey is the key that was just created, it's only displayed once
+	NewKey string
+}
+
+func (x ui) apiKeys(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	var data apiKeysData
+	var flash *Flash
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			renderErr(w, r, err, x.log)
+			return
+		}
+
+		if r.Form.Get("action") == "revoke" {
+			if err := mship.RevokeAPIKey(r.Form.Get("id")); err != nil {
+				renderErr(w, r, err, x.log)
+				return
+			}
+
+			flash = &Flash{Type: "success", Message: "The API key has been revoked"}
+		} else {
+			newKey := backend.NewAPIKey{
+				Name:       r.Form.Get("name"),
+				Scopes:     splitList(r.Form.Get("scopes")),
+				AllowedIPs: splitList(r.Form.Get("ips")),
+			}
+
+			if exp := r.Form.Get("expires"); len(exp) > 0 {
+				newKey.Expires, err = time.Parse("2006-01-02", exp)
+				if err != nil {
+					renderErr(w, r, err, x.log)
+					return
+				}
+			}
+
+			key, _, err := mship.CreateAPIKey(auth, newKey)
+			if err != nil {
+				flash = &Flash{Type: "danger", Message: err.Error()}
+			} else {
+				data.NewKey = key
+				flash = &Flash{Type: "success", Message: "API key created, copy it now, it will not be shown again"}
+			}
+		}
+	}
+
+	data.Keys, err = mship.ListAPIKeys()
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	render(w, r, "apikeys.html", data, flash, x.log)
+}
+
+// splitList splits a comma or space separated form value
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(c rune) bool {
+		return c == ',' || c == ' ' || c == '\n' || c == '\r'
+	})
+}
+
 func (x ui) myAccount(w http.ResponseWriter, r *http.Request) {
 	conf, _, err := middleware.Extract(r, false)
 	if err != nil {
@@ -693,3 +1041,56 @@ func (x ui) myAccount(w http.ResponseWriter, r *http.Request) {
 
 	render(w, r, "customer.html", tenant, nil, x.log)
 }
+
+func (x ui) backups(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	var flash *Flash
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			renderErr(w, r, err, x.log)
+			return
+		}
+
+		switch r.Form.Get("action") {
+		case "restore":
+			restored, err := backend.RestoreBackup(conf.Name, r.Form.Get("id"), r.Form.Get("name"))
+			if err != nil {
+				flash = &Flash{Type: "danger", Message: err.Error()}
+			} else if restored.Name == conf.Name {
+				flash = &Flash{Type: "success", Message: "The backup has been restored"}
+			} else {
+				flash = &Flash{
+					Type:    "success",
+					Message: fmt.Sprintf("The backup has been restored in %s, its public key is %s", restored.Name, restored.ID),
+				}
+			}
+		case "delete":
+			if err := backend.DeleteBackup(conf.Name, r.Form.Get("id")); err != nil {
+				flash = &Flash{Type: "danger", Message: err.Error()}
+			} else {
+				flash = &Flash{Type: "success", Message: "The backup has been deleted"}
+			}
+		default:
+			policy := model.BackupPolicy{Blobs: r.Form.Get("blobs") == "1"}
+			if _, err := backend.BackupDatabase(conf.Name, "", policy, true); err != nil {
+				flash = &Flash{Type: "danger", Message: err.Error()}
+			} else {
+				flash = &Flash{Type: "success", Message: "The backup has been created"}
+			}
+		}
+	}
+
+	list, err := backend.ListBackups(conf.Name)
+	if err != nil {
+		renderErr(w, r, err, x.log)
+		return
+	}
+
+	render(w, r, "backups.html", list, flash, x.log)
+}
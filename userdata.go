@@ -0,0 +1,107 @@
+package staticbackend
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+// sudoUsersRoute routes /sudo/users/{id}/export and /sudo/users/{id}/erase
+// to the user data jobs, other paths target a "users" collection
+func sudoUsersRoute(userData, collection http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch getURLPart(r.URL.Path, 4) {
+		case backend.UserDataExport, backend.UserDataErase:
+			userData.ServeHTTP(w, r)
+		default:
+			collection.ServeHTTP(w, r)
+		}
+	})
+}
+
+// sudoUserData starts an export or erase job of a user's data with POST and
+// returns the status of its last job with GET. The export archive is
+// downloaded with GET /sudo/users/{id}/export/download.
+func sudoUserData(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := getURLPart(r.URL.Path, 3)
+	kind := getURLPart(r.URL.Path, 4)
+
+	mship := backend.Membership(conf)
+
+	if kind == backend.UserDataExport && getURLPart(r.URL.Path, 5) == "download" {
+		downloadUserData(w, r, mship, userID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		job, err := mship.GetUserDataJob(userID, kind)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		// the archive is private, it's only downloaded with the root token
+		if kind == backend.UserDataExport && job.Status == backend.JobDone {
+			job.URL = fmt.Sprintf("%s/sudo/users/%s/export/download", config.Current.AppURL, userID)
+		}
+
+		respond(w, http.StatusOK, job)
+	case http.MethodPost:
+		var job backend.UserDataJob
+		if kind == backend.UserDataExport {
+			job, err = mship.ExportUserData(userID)
+		} else {
+			job, err = mship.EraseUserData(userID)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), userDataErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusAccepted, job)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// downloadUserData streams the archive of the user's last export
+func downloadUserData(w http.ResponseWriter, r *http.Request, mship backend.User, userID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rc, err := mship.OpenUserDataExport(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, userID))
+	io.Copy(w, rc)
+}
+
+func userDataErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, backend.ErrUserDataJobRunning):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
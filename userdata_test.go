@@ -0,0 +1,248 @@
+package staticbackend
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestUserProfileExportAndErase(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usr := backend.Membership(conf)
+
+	email := "gdpr-user@test.com"
+	tok, user, err := usr.CreateUser(testAccountID, email, "gdpr1234", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: testAccountID, UserID: user.ID, Email: email}
+	content := []byte("avatar content")
+	avatar, err := backend.Storage(auth, conf).Save("avatar.png", "", bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := model.UserProfile{AvatarID: "not-a-file"}
+	if resp := sessionReq(t, mship.me, "PUT", "/me", string(tok), invalid); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an invalid avatar got %s", GetResponseBody(t, resp))
+	}
+
+	profile := model.UserProfile{
+		DisplayName: " GDPR User ",
+		AvatarID:    avatar.ID,
+		Profile:     map[string]interface{}{"bio": "hello"},
+	}
+	if resp := sessionReq(t, mship.me, "PUT", "/me", string(tok), profile); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp := sessionReq(t, mship.profile, "GET", "/me/profile", string(tok), nil)
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var me model.User
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if me.DisplayName != "GDPR User" || me.AvatarID != avatar.ID || me.Profile["bio"] != "hello" {
+		t.Fatalf("expected the updated profile got %v", me.UserProfile)
+	} else if len(me.Token) > 0 {
+		t.Error("expected the user token to be hidden")
+	}
+
+	for _, title := range []string{"first", "second"} {
+		doc := map[string]any{"title": title}
+		if resp := sessionReq(t, db.add, "POST", "/db/gdprnotes", string(tok), doc); resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+	}
+
+	// files uploaded before the uploader was recorded are found through the
+	// documents referencing them
+	addLegacyFile := func(name string) model.File {
+		f := model.File{
+			AccountID: testAccountID,
+			Key:       conf.Name + "/" + testAccountID + "/" + name,
+			Size:      int64(len(content)),
+			Uploaded:  time.Now(),
+		}
+
+		upData := model.UploadFileData{FileKey: f.Key, File: bytes.NewReader(content), Size: f.Size}
+		if f.URL, err = backend.Filestore.Save(upData); err != nil {
+			t.Fatal(err)
+		}
+
+		if f.ID, err = backend.DB.AddFile(conf.Name, f); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	legacy := addLegacyFile("legacy_gdpr.txt")
+	other := addLegacyFile("legacy_other.txt")
+
+	doc := map[string]any{"title": "attached", "files": []any{legacy.URL}}
+	if resp := sessionReq(t, db.add, "POST", "/db/gdprnotes", string(tok), doc); resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if err := backend.DB.AddFormSubmission(conf.Name, "contact", map[string]any{"email": email}); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := dbReq(t, sudoUserData, "POST", "/sudo/users/unknown-user/export", nil, true); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404 for an unknown user got %s", GetResponseBody(t, resp))
+	}
+
+	export := waitUserDataJob(t, "/sudo/users/"+user.ID+"/export")
+	if export.Documents != 3 || export.Files != 2 || export.Submissions != 1 || len(export.URL) == 0 {
+		t.Fatalf("unexpected export job %v", export)
+	}
+
+	if !strings.HasSuffix(export.URL, "/sudo/users/"+user.ID+"/export/download") {
+		t.Errorf("expected the authenticated download URL got %s", export.URL)
+	}
+
+	// the archive is not in the publicly served files
+	if _, err := os.Stat(filepath.Join(os.TempDir(), export.Key)); err == nil {
+		t.Error("expected the export archive to be private")
+	}
+
+	resp = dbReq(t, sudoUserData, "GET", "/sudo/users/"+user.ID+"/export/download", nil, true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(data)
+	}
+
+	if !strings.Contains(entries["user.json"], "GDPR User") {
+		t.Errorf("expected the profile in user.json got %s", entries["user.json"])
+	}
+	if n := countLines(entries["documents/gdprnotes.ndjson"]); n != 3 {
+		t.Errorf("expected 3 exported documents got %d", n)
+	}
+	if entries["files/"+avatar.ID+".png"] != string(content) {
+		t.Errorf("expected the avatar content in the archive got %v", entries)
+	}
+	if entries["files/"+legacy.ID+".txt"] != string(content) {
+		t.Errorf("expected the referenced legacy file in the archive got %v", entries)
+	}
+	if _, ok := entries["files/"+other.ID+".txt"]; ok {
+		t.Error("expected the unreferenced legacy file to be left out")
+	}
+	if !strings.Contains(entries["forms.ndjson"], email) {
+		t.Errorf("expected the form submission got %s", entries["forms.ndjson"])
+	}
+
+	erase := waitUserDataJob(t, "/sudo/users/"+user.ID+"/erase")
+	if erase.Documents != 3 || erase.Files != 2 || erase.Submissions != 1 {
+		t.Fatalf("unexpected erase job %v", erase)
+	}
+
+	docs, err := backend.DB.ListDocumentsByOwner(conf.Name, "gdprnotes", user.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(docs) != 0 {
+		t.Errorf("expected the documents to be erased got %v", docs)
+	}
+
+	if _, err := backend.DB.GetFileByID(conf.Name, avatar.ID); err == nil {
+		t.Error("expected the avatar file to be erased")
+	}
+
+	if _, err := backend.DB.GetFileByID(conf.Name, legacy.ID); err == nil {
+		t.Error("expected the referenced legacy file to be erased")
+	}
+	if _, err := backend.DB.GetFileByID(conf.Name, other.ID); err != nil {
+		t.Errorf("expected the unreferenced legacy file to be kept got %v", err)
+	}
+
+	if _, err := backend.Filestore.Get(export.Key); err == nil {
+		t.Error("expected the export archive to be erased")
+	}
+
+	erased, err := backend.DB.FindUserByID(conf.Name, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(erased.DisplayName) > 0 || len(erased.AvatarID) > 0 || len(erased.Profile) > 0 {
+		t.Errorf("expected the profile to be erased got %v", erased.UserProfile)
+	}
+}
+
+// waitUserDataJob starts a user data job and waits for it to end
+func waitUserDataJob(t *testing.T, path string) (job backend.UserDataJob) {
+	resp := dbReq(t, sudoUserData, "POST", path, nil, true)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	for i := 0; i < 50; i++ {
+		resp := dbReq(t, sudoUserData, "GET", path, nil, true)
+		if resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+
+		if err := parseBody(resp.Body, &job); err != nil {
+			t.Fatal(err)
+		}
+
+		switch job.Status {
+		case backend.JobDone:
+			return
+		case backend.JobFailed:
+			t.Fatal(job.Error)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Fatalf("the job did not end %v", job)
+	return
+}
+
+func countLines(s string) (n int) {
+	sc := bufio.NewScanner(strings.NewReader(s))
+	for sc.Scan() {
+		n++
+	}
+	return
+}